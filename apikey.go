@@ -0,0 +1,315 @@
+package yarf
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiKeyEncoding encodes the API key secrets, without the "_" used as prefix separator.
+var apiKeyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// APIKey is the stored record of an API key. The key itself is never stored, only its hash.
+type APIKey struct {
+	// ID is the public identifier of the key, to show and revoke it. It's derived from the hash.
+	ID string
+
+	// Prefix identifies the kind of key, like "sk_live", so leaked keys can be recognized by secret scanners.
+	Prefix string
+
+	// Hash is the SHA-256 hash of the key, in hex.
+	Hash string
+
+	// Hint is the end of the key, to help users recognize it.
+	Hint string
+
+	// Name is a label given by the owner of the key.
+	Name string
+
+	// Subject is the principal the key authenticates, like a user id or a service name.
+	Subject string
+
+	// Scopes granted to the key, checked by RouteMeta.Scopes() like the OAuth2 scopes.
+	Scopes []string
+
+	// Roles granted to the key, for the authorization policies.
+	Roles []string
+
+	// Created is the creation time of the key.
+	Created time.Time
+
+	// Expires is the expiration time of the key. Zero means it doesn't expire.
+	Expires time.Time
+
+	// LastUsed is the time the key was last used, tracked by APIKeyAuth.
+	LastUsed time.Time
+
+	// Revoked disables the key.
+	Revoked bool
+}
+
+// Valid checks if the key isn't revoked or expired at the time provided.
+func (k *APIKey) Valid(now time.Time) bool {
+	return !k.Revoked && (k.Expires.IsZero() || now.Before(k.Expires))
+}
+
+// HasScope checks if the key was granted the scope.
+func (k *APIKey) HasScope(scope string) bool {
+	return containsString(k.Scopes, scope)
+}
+
+// HashAPIKey returns the hash of an API key, used to store and look it up.
+// Keys are random, so a fast hash is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateAPIKey creates a random API key with the prefix, like "sk_live_" followed by 52 random characters.
+// It returns the key, to be shown once to its owner, and the record to store, to be completed with the subject and scopes.
+func GenerateAPIKey(prefix string) (string, *APIKey, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+
+	key := strings.ToLower(apiKeyEncoding.EncodeToString(b))
+	if prefix != "" {
+		key = prefix + "_" + key
+	}
+
+	hash := HashAPIKey(key)
+
+	return key, &APIKey{
+		ID:      hash[:12],
+		Prefix:  prefix,
+		Hash:    hash,
+		Hint:    key[len(key)-4:],
+		Created: time.Now(),
+	}, nil
+}
+
+// APIKeyStore looks up API keys by hash.
+type APIKeyStore interface {
+	// APIKey returns the key with the hash, or nil if it doesn't exist.
+	APIKey(ctx context.Context, hash string) (*APIKey, error)
+
+	// Touch records the last use of the key with the hash.
+	Touch(ctx context.Context, hash string, t time.Time) error
+}
+
+// MemoryAPIKeyStore is an in-memory APIKeyStore implementation, for tests and fixed keys.
+type MemoryAPIKeyStore struct {
+	keys map[string]APIKey
+	lock sync.RWMutex
+}
+
+// NewMemoryAPIKeyStore creates a MemoryAPIKeyStore with the keys provided.
+func NewMemoryAPIKeyStore(keys ...*APIKey) *MemoryAPIKeyStore {
+	s := &MemoryAPIKeyStore{
+		keys: make(map[string]APIKey),
+	}
+	for _, k := range keys {
+		s.Save(k)
+	}
+
+	return s
+}
+
+// Save stores the key, replacing the key with the same hash.
+func (s *MemoryAPIKeyStore) Save(k *APIKey) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.keys[k.Hash] = *k
+}
+
+// Revoke revokes the key with the ID, and reports if it exists.
+func (s *MemoryAPIKeyStore) Revoke(id string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for hash, k := range s.keys {
+		if k.ID == id {
+			k.Revoked = true
+			s.keys[hash] = k
+			return true
+		}
+	}
+
+	return false
+}
+
+// APIKey returns a copy of the key with the hash, or nil if it doesn't exist.
+func (s *MemoryAPIKeyStore) APIKey(ctx context.Context, hash string) (*APIKey, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	k, ok := s.keys[hash]
+	if !ok {
+		return nil, nil
+	}
+
+	return &k, nil
+}
+
+// Touch records the last use of the key with the hash.
+func (s *MemoryAPIKeyStore) Touch(ctx context.Context, hash string, t time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if k, ok := s.keys[hash]; ok {
+		k.LastUsed = t
+		s.keys[hash] = k
+	}
+
+	return nil
+}
+
+// apiKeyKey is the Context storage key for the request API key.
+type apiKeyKey struct{}
+
+// APIKey returns the API key authenticated by the APIKeyAuth middleware, or nil if there is none.
+func (c *Context) APIKey() *APIKey {
+	k, _ := c.get(apiKeyKey{}).(*APIKey)
+	return k
+}
+
+// APIKeyAuth is a middleware authenticating requests with API keys, looked up by hash in the Store.
+// It sets the request Identity with the key subject and roles, and claims with the key scopes,
+// so routes requiring RouteMeta.Scopes() accept the keys granted them.
+//
+//	y.Use(yarf.NewAPIKeyAuth(store, "sk_live"))
+//	y.AddRoute("/invoices", new(Invoices)).Scopes("invoices:read")
+//
+// Requests without a valid key get a 401 error.
+type APIKeyAuth struct {
+	Middleware
+
+	// Store looks up the keys.
+	Store APIKeyStore
+
+	// Header is the request header carrying the key.
+	Header string
+
+	// Bearer also reads the key from the Authorization header, as a bearer token.
+	// With Prefixes, bearer tokens without a known prefix are ignored, so they can be left to the OAuth2 middleware.
+	Bearer bool
+
+	// Prefixes are the key prefixes accepted. Keys with other prefixes are rejected without a lookup.
+	Prefixes []string
+
+	// Optional lets requests without key through, without identity. Invalid keys are still rejected.
+	Optional bool
+
+	// TouchInterval is the minimum time between the last use updates of a key, to limit the store writes.
+	TouchInterval time.Duration
+
+	// Clock tells the time to check the expiration and track the last use. nil uses the system clock.
+	Clock Clock
+}
+
+// NewAPIKeyAuth creates an APIKeyAuth middleware reading the X-API-Key header and bearer tokens,
+// accepting the prefixes provided, or any prefix if none, and updating the last use every minute.
+func NewAPIKeyAuth(store APIKeyStore, prefixes ...string) *APIKeyAuth {
+	return &APIKeyAuth{
+		Store:         store,
+		Header:        "X-API-Key",
+		Bearer:        true,
+		Prefixes:      prefixes,
+		TouchInterval: time.Minute,
+	}
+}
+
+// Phase runs APIKeyAuth with the security middleware.
+func (m *APIKeyAuth) Phase() Phase {
+	return PhaseSecurity
+}
+
+// known checks if the key has one of the accepted prefixes.
+func (m *APIKeyAuth) known(key string) bool {
+	if len(m.Prefixes) == 0 {
+		return true
+	}
+	for _, p := range m.Prefixes {
+		if strings.HasPrefix(key, p+"_") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reject counts a rejected key and returns the 401 error.
+func (m *APIKeyAuth) reject(c *Context, reason string) error {
+	c.metrics().Counter("yarf_apikey_rejected_total", "Requests rejected by the API key authentication.", "reason").Add(1, reason)
+	return ErrorUnauthorized()
+}
+
+// PreDispatch authenticates the request key. CORS preflight requests, which never carry credentials, aren't checked.
+func (m *APIKeyAuth) PreDispatch(c *Context) error {
+	if isPreflight(c.Request) {
+		return nil
+	}
+
+	key := strings.TrimSpace(c.Request.Header.Get(m.Header))
+	if key != "" && !m.known(key) {
+		return m.reject(c, "invalid")
+	}
+	if key == "" && m.Bearer {
+		if token, ok := bearerToken(c.Request); ok && m.known(token) {
+			key = token
+		}
+	}
+	if key == "" {
+		if m.Optional {
+			return nil
+		}
+		return m.reject(c, "missing")
+	}
+
+	hash := HashAPIKey(key)
+	k, err := m.Store.APIKey(c.Request.Context(), hash)
+	if err != nil {
+		return err
+	}
+	if k == nil {
+		return m.reject(c, "invalid")
+	}
+
+	now := clockNow(m.Clock)
+	if !k.Valid(now) {
+		if k.Revoked {
+			return m.reject(c, "revoked")
+		}
+		return m.reject(c, "expired")
+	}
+
+	if now.Sub(k.LastUsed) >= m.TouchInterval {
+		if err := m.Store.Touch(c.Request.Context(), hash, now); err != nil && c.app != nil {
+			c.app.log().Error("api key last use update failed", "key_id", k.ID, "error", err)
+		}
+		k.LastUsed = now
+	}
+
+	claims := Claims{
+		"sub":    k.Subject,
+		"scope":  strings.Join(k.Scopes, " "),
+		"key_id": k.ID,
+	}
+	c.set(apiKeyKey{}, k)
+	c.set(claimsKey{}, claims)
+	c.SetIdentity(&Identity{
+		Subject: k.Subject,
+		Roles:   k.Roles,
+		Source:  "apikey",
+		Claims:  claims,
+	})
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type APIKeyResource struct {
+	Resource
+}
+
+func (r *APIKeyResource) Get(c *Context) error {
+	c.Render(c.Identity().Source + ":" + c.Identity().Subject + ":" + c.APIKey().ID)
+	return nil
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, k, err := GenerateAPIKey("sk_live")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(key, "sk_live_") || len(key) != len("sk_live_")+52 || strings.ContainsAny(key[8:], "_=") {
+		t.Errorf("Unexpected key format %s", key)
+	}
+	if k.Hash != HashAPIKey(key) || k.ID != k.Hash[:12] || k.Prefix != "sk_live" || !strings.HasSuffix(key, k.Hint) {
+		t.Errorf("Unexpected key record %+v", k)
+	}
+	if strings.Contains(k.Hash, key) {
+		t.Error("The key shouldn't be stored")
+	}
+
+	other, _, _ := GenerateAPIKey("sk_live")
+	if other == key {
+		t.Error("Expected random keys")
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	clock := newTestClock()
+	key, k, _ := GenerateAPIKey("sk_test")
+	k.Subject = "billing"
+	k.Scopes = []string{"invoices:read"}
+	k.Expires = clock.Now().Add(time.Hour)
+	store := NewMemoryAPIKeyStore(k)
+
+	m := NewAPIKeyAuth(store, "sk_test")
+	m.Clock = clock
+
+	y := New()
+	y.Use(m)
+	y.AddRoute("/invoices", new(APIKeyResource)).Scopes("invoices:read")
+	y.AddRoute("/payments", new(APIKeyResource)).Scopes("payments:write")
+
+	for name, header := range map[string][2]string{
+		"header": {"X-API-Key", key},
+		"bearer": {"Authorization", "Bearer " + key},
+	} {
+		res := testRequest(y, "GET", "/invoices", map[string]string{header[0]: header[1]})
+		if res.Code != 200 || res.Body.String() != "apikey:billing:"+k.ID {
+			t.Errorf("%s: expected key accepted, got %d '%s'", name, res.Code, res.Body.String())
+		}
+	}
+	if res := testRequest(y, "GET", "/payments", map[string]string{"X-API-Key": key}); res.Code != 403 {
+		t.Errorf("Expected missing scope rejected, got %d", res.Code)
+	}
+
+	for name, header := range map[string][2]string{
+		"missing":        {"", ""},
+		"unknown":        {"X-API-Key", "sk_test_unknown"},
+		"unknown prefix": {"X-API-Key", "pk_test_" + key[8:]},
+		"oauth token":    {"Authorization", "Bearer eyJhbGciOi"},
+	} {
+		if res := testRequest(y, "GET", "/invoices", map[string]string{header[0]: header[1]}); res.Code != 401 {
+			t.Errorf("%s: expected 401, got %d", name, res.Code)
+		}
+	}
+
+	clock.Advance(2 * time.Hour)
+	if res := testRequest(y, "GET", "/invoices", map[string]string{"X-API-Key": key}); res.Code != 401 {
+		t.Errorf("Expected expired key rejected, got %d", res.Code)
+	}
+}
+
+func TestAPIKeyAuthRevoke(t *testing.T) {
+	key, k, _ := GenerateAPIKey("sk_test")
+	store := NewMemoryAPIKeyStore(k)
+
+	y := New()
+	y.Use(NewAPIKeyAuth(store))
+	y.Add("/invoices", new(APIKeyResource))
+
+	if res := testRequest(y, "GET", "/invoices", map[string]string{"X-API-Key": key}); res.Code != 200 {
+		t.Errorf("Expected key accepted, got %d", res.Code)
+	}
+	if !store.Revoke(k.ID) || store.Revoke("missing") {
+		t.Error("Expected only existing keys revoked")
+	}
+	if res := testRequest(y, "GET", "/invoices", map[string]string{"X-API-Key": key}); res.Code != 401 {
+		t.Errorf("Expected revoked key rejected, got %d", res.Code)
+	}
+}
+
+func TestAPIKeyAuthLastUsed(t *testing.T) {
+	clock := newTestClock()
+	key, k, _ := GenerateAPIKey("")
+	store := NewMemoryAPIKeyStore(k)
+
+	m := NewAPIKeyAuth(store)
+	m.Clock = clock
+	m.Optional = true
+
+	y := New()
+	y.Use(m)
+	y.Add("/invoices", new(OverrideResource))
+
+	lastUsed := func() time.Time {
+		k, _ := store.APIKey(context.Background(), HashAPIKey(key))
+		return k.LastUsed
+	}
+
+	start := clock.Now()
+	testRequest(y, "GET", "/invoices", map[string]string{"X-API-Key": key})
+	if !lastUsed().Equal(start) {
+		t.Errorf("Expected last use at %v, got %v", start, lastUsed())
+	}
+
+	clock.Advance(30 * time.Second)
+	testRequest(y, "GET", "/invoices", map[string]string{"X-API-Key": key})
+	if !lastUsed().Equal(start) {
+		t.Errorf("Expected last use updates throttled, got %v", lastUsed())
+	}
+
+	clock.Advance(time.Minute)
+	testRequest(y, "GET", "/invoices", map[string]string{"X-API-Key": key})
+	if !lastUsed().Equal(clock.Now()) {
+		t.Errorf("Expected last use at %v, got %v", clock.Now(), lastUsed())
+	}
+
+	if res := testRequest(y, "GET", "/invoices", nil); res.Code != 200 {
+		t.Errorf("Optional keys should let requests through, got %d", res.Code)
+	}
+}
+
+// failingTouchStore is an APIKeyStore whose last use updates fail.
+type failingTouchStore struct {
+	*MemoryAPIKeyStore
+}
+
+func (s failingTouchStore) Touch(ctx context.Context, hash string, t time.Time) error {
+	return errors.New("store down")
+}
+
+func TestAPIKeyAuthTouchErrorWithoutApp(t *testing.T) {
+	key, k, _ := GenerateAPIKey("")
+	m := NewAPIKeyAuth(failingTouchStore{NewMemoryAPIKeyStore(k)})
+
+	c := NewContext(httptest.NewRequest("GET", "/invoices", nil), httptest.NewRecorder())
+	c.Request.Header.Set("X-API-Key", key)
+	if err := m.PreDispatch(c); err != nil || c.APIKey() == nil {
+		t.Errorf("Expected the key accepted despite the failed update, got %v", err)
+	}
+}
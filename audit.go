@@ -0,0 +1,135 @@
+package yarf
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// MetaAuditAction is the RouteMeta key holding the action name reported by the AuditLogger middleware.
+const MetaAuditAction = "audit.action"
+
+// Audit outcomes
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEvent is the structured record emitted by the AuditLogger for each request.
+type AuditEvent struct {
+	Time     time.Time         `json:"time"`
+	Actor    string            `json:"actor"`
+	Action   string            `json:"action"`
+	Target   map[string]string `json:"target,omitempty"`
+	Outcome  string            `json:"outcome"`
+	Status   int               `json:"status,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	ClientIP string            `json:"client_ip"`
+}
+
+// AuditSink receives the events emitted by the AuditLogger middleware.
+type AuditSink interface {
+	Audit(*AuditEvent)
+}
+
+// AuditSinkFunc adapts a function into an AuditSink.
+type AuditSinkFunc func(*AuditEvent)
+
+// Audit calls f(e).
+func (f AuditSinkFunc) Audit(e *AuditEvent) {
+	f(e)
+}
+
+// auditWriter is an AuditSink writing events as JSON lines.
+type auditWriter struct {
+	enc *json.Encoder
+	sync.Mutex
+}
+
+// NewAuditWriter creates an AuditSink that writes each event as a JSON line into w.
+func NewAuditWriter(w io.Writer) AuditSink {
+	return &auditWriter{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// Audit writes the event.
+func (w *auditWriter) Audit(e *AuditEvent) {
+	w.Lock()
+	defer w.Unlock()
+
+	w.enc.Encode(e)
+}
+
+// AuditLogger is a middleware emitting an AuditEvent to a sink for every request it covers.
+// The action is taken from the route metadata under the MetaAuditAction key,
+// and defaults to the request method and path when it isn't declared.
+// The target of the action are the route params.
+// Insert it into sensitive route groups and declare the actions on each route:
+//
+//	g.Insert(yarf.NewAuditLogger(sink))
+//	g.AddRoute("/users/:id", new(User)).Set(yarf.MetaAuditAction, "users.update")
+type AuditLogger struct {
+	Middleware
+
+	// Sink receives the audit events.
+	Sink AuditSink
+
+	// Actor resolves the identity performing the request.
+	Actor func(*Context) string
+}
+
+// NewAuditLogger creates a new AuditLogger emitting events to sink.
+func NewAuditLogger(sink AuditSink) *AuditLogger {
+	return &AuditLogger{
+		Sink: sink,
+	}
+}
+
+// Phase puts the audit logger with the logging middleware.
+func (a *AuditLogger) Phase() Phase {
+	return PhaseLogging
+}
+
+// End emits the audit event once the request is done, even if it failed.
+func (a *AuditLogger) End(c *Context) error {
+	if a.Sink == nil {
+		return nil
+	}
+
+	e := &AuditEvent{
+		Time:     time.Now(),
+		Action:   c.RouteMeta().String(MetaAuditAction),
+		Outcome:  AuditSuccess,
+		Method:   c.Request.Method,
+		Path:     c.Request.URL.Path,
+		ClientIP: c.GetClientIP(),
+	}
+
+	if e.Action == "" {
+		e.Action = c.Request.Method + " " + c.Request.URL.Path
+	}
+
+	if a.Actor != nil {
+		e.Actor = a.Actor(c)
+	}
+
+	if len(c.Params) > 0 {
+		e.Target = c.redactor().RouteParams(c.Params)
+	}
+
+	if err := c.Err(); err != nil {
+		e.Outcome = AuditFailure
+		e.Error = err.Error()
+		if yerr, ok := err.(YError); ok {
+			e.Status = yerr.Code()
+		}
+	}
+
+	a.Sink.Audit(e)
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package yarf
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuditLogger(t *testing.T) {
+	var events []*AuditEvent
+
+	a := NewAuditLogger(AuditSinkFunc(func(e *AuditEvent) {
+		events = append(events, e)
+	}))
+	a.Actor = func(c *Context) string {
+		return c.Request.Header.Get("X-User")
+	}
+
+	g := RouteGroup("/admin")
+	g.Insert(a)
+	g.AddRoute("/users/:id", new(OKResource)).Set(MetaAuditAction, "users.show")
+	g.Add("/forbidden/:id", new(MockResource))
+
+	y := New()
+	y.AddGroup(g)
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/admin/users/42", nil)
+	req.Header.Set("X-User", "joe")
+	y.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/admin/forbidden/1", nil)
+	y.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 audit events, got %d", len(events))
+	}
+
+	e := events[0]
+	if e.Actor != "joe" || e.Action != "users.show" || e.Target["id"] != "42" || e.Outcome != AuditSuccess {
+		t.Errorf("Unexpected audit event: %+v", e)
+	}
+
+	e = events[1]
+	if e.Action != "GET /admin/forbidden/1" || e.Outcome != AuditFailure || e.Status != 405 {
+		t.Errorf("Unexpected audit event: %+v", e)
+	}
+}
+
+func TestAuditWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	NewAuditWriter(&buf).Audit(&AuditEvent{Action: "test", Outcome: AuditSuccess})
+
+	var e AuditEvent
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Action != "test" {
+		t.Errorf("Expected action 'test', got '%s'", e.Action)
+	}
+}
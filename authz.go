@@ -0,0 +1,214 @@
+package yarf
+
+import (
+	"crypto/x509"
+	"strings"
+)
+
+// MetaPermissions is the RouteMeta key holding the permissions required by a route.
+const MetaPermissions = "authz.permissions"
+
+// Identity is the authenticated principal of a request, set by authentication middleware
+// like OAuth2, and used by authorization policies.
+type Identity struct {
+	// Subject identifies the principal, like a user id or a service name.
+	Subject string
+
+	// Roles granted to the principal.
+	Roles []string
+
+	// Source is the authentication method, like "oauth2" or "mtls".
+	Source string
+
+	// Claims of the credentials, if any.
+	Claims Claims
+
+	// Certificate is the verified client certificate, for mTLS identities.
+	Certificate *x509.Certificate
+}
+
+// identityKey is the Context storage key for the request Identity.
+type identityKey struct{}
+
+// Identity returns the authenticated principal of the request, or nil if there is none.
+func (c *Context) Identity() *Identity {
+	id, _ := c.get(identityKey{}).(*Identity)
+
+	return id
+}
+
+// SetIdentity sets the authenticated principal of the request, for custom authentication middleware.
+func (c *Context) SetIdentity(id *Identity) {
+	c.set(identityKey{}, id)
+}
+
+// Permissions declares the permissions required by the route, checked by the Policy set with Yarf.SetPolicy(),
+// and returns the RouteMeta to allow chaining. Routes requiring permissions are denied if no policy is set.
+//
+//	y.AddRoute("/invoices/:id", new(Invoice)).Permissions("invoices:read")
+func (m *RouteMeta) Permissions(perms ...string) *RouteMeta {
+	existing, _ := m.Get(MetaPermissions).([]string)
+
+	return m.Set(MetaPermissions, append(existing, perms...))
+}
+
+// Decision is the result of an authorization policy.
+type Decision struct {
+	// Allow grants access to the route.
+	Allow bool
+
+	// Reason explains the decision.
+	Reason string
+
+	// Trace lists the rules evaluated, rendered on debug error pages to troubleshoot denials.
+	Trace []string
+}
+
+// Policy decides if a request can access a route requiring permissions.
+type Policy interface {
+	Authorize(c *Context, perms []string) (Decision, error)
+}
+
+// PolicyFunc adapts a function into a Policy.
+type PolicyFunc func(c *Context, perms []string) (Decision, error)
+
+// Authorize calls f(c, perms).
+func (f PolicyFunc) Authorize(c *Context, perms []string) (Decision, error) {
+	return f(c, perms)
+}
+
+// SetPolicy sets the authorization Policy checking the permissions declared by the routes.
+// Call it before starting the servers.
+func (y *Yarf) SetPolicy(p Policy) {
+	y.policy = p
+}
+
+// PolicyError is the 403 error returned when a policy denies a request.
+// The decision is rendered on debug error pages.
+type PolicyError struct {
+	ForbiddenError
+
+	Decision Decision
+}
+
+// policyError creates a PolicyError for the decision.
+func policyError(d Decision) *PolicyError {
+	e := &PolicyError{Decision: d}
+	e.ForbiddenError = *ErrorForbidden()
+	if d.Reason != "" {
+		e.ErrorMsg += ": " + d.Reason
+	}
+
+	return e
+}
+
+// authorize checks the permissions required by the route with the application policy.
+func (r *route) authorize(c *Context) error {
+	perms, _ := r.meta.Get(MetaPermissions).([]string)
+	if len(perms) == 0 {
+		return nil
+	}
+
+	if c.app == nil || c.app.policy == nil {
+		return policyError(Decision{Reason: "no policy set"})
+	}
+
+	d, err := c.app.policy.Authorize(c, perms)
+	if err != nil {
+		return err
+	}
+	if !d.Allow {
+		return policyError(d)
+	}
+
+	return nil
+}
+
+// RolePolicy creates a Policy granting permissions to the roles of the request Identity.
+// Requests are allowed when the roles grant all the permissions required by the route.
+// A "*" permission grants everything, and "resource:*" every action on a resource.
+//
+//	y.SetPolicy(yarf.RolePolicy(map[string][]string{
+//		"admin":  {"*"},
+//		"viewer": {"invoices:read", "users:read"},
+//	}))
+func RolePolicy(roles map[string][]string) Policy {
+	return PolicyFunc(func(c *Context, perms []string) (Decision, error) {
+		id := c.Identity()
+		if id == nil {
+			return Decision{Reason: "no identity", Trace: []string{"request not authenticated"}}, nil
+		}
+
+		var trace []string
+		for _, p := range perms {
+			granted := false
+			for _, role := range id.Roles {
+				for _, g := range roles[role] {
+					if permissionMatch(g, p) {
+						granted = true
+						trace = append(trace, "role "+role+" grants "+p+" with "+g)
+						break
+					}
+				}
+				if granted {
+					break
+				}
+			}
+
+			if !granted {
+				trace = append(trace, "no role of "+id.Subject+" ("+strings.Join(id.Roles, ", ")+") grants "+p)
+				return Decision{Reason: "missing permission " + p, Trace: trace}, nil
+			}
+		}
+
+		return Decision{Allow: true, Trace: trace}, nil
+	})
+}
+
+// permissionMatch checks if a granted permission covers the required one.
+func permissionMatch(granted, required string) bool {
+	if granted == "*" || granted == required {
+		return true
+	}
+
+	return strings.HasSuffix(granted, ":*") && strings.HasPrefix(required, granted[:len(granted)-1])
+}
+
+// Enforcer is the interface of policy engines like casbin's Enforcer.
+type Enforcer interface {
+	Enforce(rvals ...interface{}) (bool, error)
+}
+
+// EnforcerPolicy creates a Policy asking an Enforcer about each permission, with the Identity subject
+// and the permission split as object and action: "invoices:read" is checked as Enforce(sub, "invoices", "read").
+// Permissions without action are checked with an empty action.
+func EnforcerPolicy(e Enforcer) Policy {
+	return PolicyFunc(func(c *Context, perms []string) (Decision, error) {
+		sub := ""
+		if id := c.Identity(); id != nil {
+			sub = id.Subject
+		}
+
+		var trace []string
+		for _, p := range perms {
+			obj, act := p, ""
+			if i := strings.LastIndexByte(p, ':'); i >= 0 {
+				obj, act = p[:i], p[i+1:]
+			}
+
+			ok, err := e.Enforce(sub, obj, act)
+			if err != nil {
+				return Decision{}, err
+			}
+
+			rule := "enforce(" + sub + ", " + obj + ", " + act + ")"
+			if !ok {
+				trace = append(trace, rule+" = deny")
+				return Decision{Reason: "missing permission " + p, Trace: trace}, nil
+			}
+			trace = append(trace, rule+" = allow")
+		}
+
+		return Decision{Allow: true, Trace: trace}, nil
+	})
+}
@@ -0,0 +1,160 @@
+package yarf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// identityMiddleware sets the identity of the X-User header, with the roles of X-Roles.
+type identityMiddleware struct {
+	Middleware
+}
+
+func (m *identityMiddleware) PreDispatch(c *Context) error {
+	if user := c.Request.Header.Get("X-User"); user != "" {
+		c.SetIdentity(&Identity{
+			Subject: user,
+			Roles:   strings.Split(c.Request.Header.Get("X-Roles"), ","),
+			Source:  "test",
+		})
+	}
+
+	return nil
+}
+
+func authzTestYarf(p Policy) *Yarf {
+	y := New()
+	y.Use(new(identityMiddleware))
+	if p != nil {
+		y.SetPolicy(p)
+	}
+	y.Add("/public", new(OKResource))
+	y.AddRoute("/invoices", new(OKResource)).Permissions("invoices:read")
+	y.AddRoute("/admin", new(OKResource)).Permissions("invoices:read").Permissions("users:write")
+
+	return y
+}
+
+func TestRolePolicy(t *testing.T) {
+	y := authzTestYarf(RolePolicy(map[string][]string{
+		"admin":   {"*"},
+		"viewer":  {"invoices:read"},
+		"manager": {"invoices:*", "users:write"},
+	}))
+
+	for _, tc := range []struct {
+		path, user, roles string
+		status            int
+	}{
+		{"/public", "", "", 200},
+		{"/invoices", "", "", 403},
+		{"/invoices", "ann", "viewer", 200},
+		{"/invoices", "bob", "guest", 403},
+		{"/admin", "ann", "viewer", 403},
+		{"/admin", "joe", "viewer,manager", 200},
+		{"/admin", "sue", "admin", 200},
+	} {
+		res := testRequest(y, "GET", tc.path, map[string]string{"X-User": tc.user, "X-Roles": tc.roles})
+		if res.Code != tc.status {
+			t.Errorf("%s as %s (%s): status %d, expected %d", tc.path, tc.user, tc.roles, res.Code, tc.status)
+		}
+	}
+}
+
+func TestPolicyFailClosed(t *testing.T) {
+	y := authzTestYarf(nil)
+
+	if res := testRequest(y, "GET", "/invoices", map[string]string{"X-User": "sue", "X-Roles": "admin"}); res.Code != 403 {
+		t.Errorf("Status %d, expected 403", res.Code)
+	}
+	if res := testRequest(y, "GET", "/public", nil); res.Code != 200 {
+		t.Errorf("Routes without permissions shouldn't need a policy, got %d", res.Code)
+	}
+}
+
+func TestPolicyErrors(t *testing.T) {
+	y := authzTestYarf(PolicyFunc(func(c *Context, perms []string) (Decision, error) {
+		return Decision{}, errors.New("policy engine down")
+	}))
+
+	if res := testRequest(y, "GET", "/invoices", map[string]string{"X-User": "sue", "X-Roles": "admin"}); res.Code != 500 {
+		t.Errorf("Status %d, expected 500", res.Code)
+	}
+}
+
+func TestPolicyDebugTrace(t *testing.T) {
+	y := authzTestYarf(RolePolicy(map[string][]string{"viewer": {"invoices:read"}}))
+	y.Debug = true
+
+	res := testRequest(y, "GET", "/admin", map[string]string{"X-User": "ann", "X-Roles": "viewer"})
+
+	var info debugInfo
+	if err := json.Unmarshal(res.Body.Bytes(), &info); err != nil {
+		t.Fatalf("Expected JSON error page, got '%s': %s", res.Body.String(), err)
+	}
+	if res.Code != 403 || info.Error != "Forbidden: missing permission users:write" {
+		t.Errorf("Unexpected debug info %d: %+v", res.Code, info)
+	}
+	if len(info.Policy) != 2 || info.Policy[0] != "role viewer grants invoices:read with invoices:read" {
+		t.Errorf("Unexpected policy trace: %q", info.Policy)
+	}
+
+	// Production responses don't include the trace
+	y.Debug = false
+	res = testRequest(y, "GET", "/admin", map[string]string{"X-User": "ann", "X-Roles": "viewer"})
+	if res.Code != 403 || res.Body.Len() != 0 {
+		t.Errorf("Unexpected response %d: %q", res.Code, res.Body.String())
+	}
+}
+
+// testEnforcer allows the (sub, obj, act) tuples provided.
+type testEnforcer map[[3]string]bool
+
+func (e testEnforcer) Enforce(rvals ...interface{}) (bool, error) {
+	return e[[3]string{rvals[0].(string), rvals[1].(string), rvals[2].(string)}], nil
+}
+
+func TestEnforcerPolicy(t *testing.T) {
+	y := authzTestYarf(EnforcerPolicy(testEnforcer{
+		{"ann", "invoices", "read"}: true,
+		{"bob", "invoices", "read"}: true,
+		{"bob", "users", "write"}:   true,
+	}))
+
+	for _, tc := range []struct {
+		path, user string
+		status     int
+	}{
+		{"/invoices", "ann", 200},
+		{"/admin", "ann", 403},
+		{"/admin", "bob", 200},
+		{"/invoices", "", 403},
+	} {
+		res := testRequest(y, "GET", tc.path, map[string]string{"X-User": tc.user})
+		if res.Code != tc.status {
+			t.Errorf("%s as %s: status %d, expected %d", tc.path, tc.user, res.Code, tc.status)
+		}
+	}
+}
+
+func TestOAuth2Identity(t *testing.T) {
+	y := New()
+	y.Use(NewOAuth2(TokenValidatorFunc(func(ctx context.Context, token string) (Claims, error) {
+		return Claims{"sub": "user-1", "roles": []interface{}{"viewer"}}, nil
+	})))
+	y.SetPolicy(RolePolicy(map[string][]string{"viewer": {"invoices:read"}}))
+	y.AddRoute("/invoices", new(OKResource)).Permissions("invoices:read")
+
+	req := httptest.NewRequest("GET", "/invoices", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 200 {
+		t.Errorf("Status %d, expected 200", res.Code)
+	}
+}
@@ -0,0 +1,28 @@
+// Package autotls wires golang.org/x/crypto/acme/autocert into Yarf servers,
+// so small deployments get HTTPS certificates from Let's Encrypt without a reverse proxy.
+//
+//	y := yarf.New()
+//	// ...
+//	m := autotls.Manager("/var/cache/certs", "example.com", "www.example.com")
+//	y.StartAutoTLS(":443", ":80", m, nil)
+package autotls
+
+import (
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Manager creates an autocert.Manager that accepts the Let's Encrypt terms of service,
+// only requests certificates for the whitelisted domains and caches them into cacheDir.
+// An empty cacheDir disables the cache, which isn't recommended as certificates are requested on every restart.
+func Manager(cacheDir string, domains ...string) *autocert.Manager {
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+	}
+
+	if cacheDir != "" {
+		m.Cache = autocert.DirCache(cacheDir)
+	}
+
+	return m
+}
@@ -0,0 +1,31 @@
+package autotls
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+func TestManagerImplementsCertManager(t *testing.T) {
+	var m interface{} = Manager("")
+
+	if _, ok := m.(yarf.CertManager); !ok {
+		t.Error("*autocert.Manager doesn't implement yarf.CertManager interface")
+	}
+}
+
+func TestManagerHostPolicy(t *testing.T) {
+	m := Manager(t.TempDir(), "example.com")
+
+	if err := m.HostPolicy(context.Background(), "example.com"); err != nil {
+		t.Errorf("Whitelisted domain should be accepted: %s", err)
+	}
+	if err := m.HostPolicy(context.Background(), "other.com"); err == nil {
+		t.Error("Non whitelisted domain should be rejected")
+	}
+	if _, ok := m.Cache.(autocert.DirCache); !ok {
+		t.Error("Cache dir should be used")
+	}
+}
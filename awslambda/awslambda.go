@@ -0,0 +1,313 @@
+// Package awslambda runs Yarf applications on AWS Lambda, behind API Gateway REST APIs (payload v1),
+// HTTP APIs (payload v2) or Application Load Balancers.
+// Events are converted into http requests dispatched through the same route tree used on servers,
+// and responses are converted back, with binary bodies base64 encoded.
+//
+// The Adapter implements the lambda.Handler interface of github.com/aws/aws-lambda-go:
+//
+//	y := newApp()
+//	lambda.StartHandler(awslambda.New(y))
+package awslambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// event holds the fields of the API Gateway v1, v2 and ALB events.
+type event struct {
+	Version string `json:"version"`
+
+	// v1 and ALB
+	HTTPMethod                      string              `json:"httpMethod"`
+	Path                            string              `json:"path"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+
+	// v2
+	RawPath        string   `json:"rawPath"`
+	RawQueryString string   `json:"rawQueryString"`
+	Cookies        []string `json:"cookies"`
+
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+
+	RequestContext struct {
+		Identity struct {
+			SourceIP string `json:"sourceIp"`
+		} `json:"identity"`
+		HTTP struct {
+			Method   string `json:"method"`
+			SourceIP string `json:"sourceIp"`
+			Protocol string `json:"protocol"`
+		} `json:"http"`
+		ELB *struct {
+			TargetGroupArn string `json:"targetGroupArn"`
+		} `json:"elb"`
+	} `json:"requestContext"`
+}
+
+// kind of the event payload.
+type kind int
+
+const (
+	restAPI kind = iota
+	httpAPI
+	alb
+)
+
+// kind detects the event payload type.
+func (e *event) kind() kind {
+	if e.Version == "2.0" {
+		return httpAPI
+	}
+	if e.RequestContext.ELB != nil {
+		return alb
+	}
+
+	return restAPI
+}
+
+// multiValue is true if the event uses multi value headers and query params.
+func (e *event) multiValue() bool {
+	return e.MultiValueHeaders != nil || e.MultiValueQueryStringParameters != nil
+}
+
+// response is the Lambda response for API Gateway and ALB.
+type response struct {
+	StatusCode        int                 `json:"statusCode"`
+	StatusDescription string              `json:"statusDescription,omitempty"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Cookies           []string            `json:"cookies,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// eventKey is the request context key holding the raw event.
+type eventKey struct{}
+
+// Event returns the raw Lambda event of a request served by the Adapter,
+// to read fields like the authorizer claims.
+func Event(r *http.Request) json.RawMessage {
+	e, _ := r.Context().Value(eventKey{}).(json.RawMessage)
+	return e
+}
+
+// Adapter converts Lambda events into requests served by a http.Handler, usually a *yarf.Yarf.
+type Adapter struct {
+	handler http.Handler
+}
+
+// New creates an Adapter serving the events with h.
+func New(h http.Handler) *Adapter {
+	return &Adapter{handler: h}
+}
+
+// Invoke serves a Lambda event payload and returns the response payload.
+func (a *Adapter) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	e := new(event)
+	if err := json.Unmarshal(payload, e); err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, eventKey{}, json.RawMessage(payload))
+	req, err := e.request(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w := newResponseWriter()
+	a.handler.ServeHTTP(w, req)
+
+	return json.Marshal(w.response(e))
+}
+
+// request builds the http request for the event.
+func (e *event) request(ctx context.Context) (*http.Request, error) {
+	body := []byte(e.Body)
+	if e.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(e.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	u := &url.URL{}
+	method := e.HTTPMethod
+	ip := e.RequestContext.Identity.SourceIP
+
+	switch e.kind() {
+	case httpAPI:
+		method = e.RequestContext.HTTP.Method
+		ip = e.RequestContext.HTTP.SourceIP
+		u.Path = e.RawPath
+		u.RawQuery = e.RawQueryString
+
+	case alb:
+		// ALB query params are sent encoded
+		u.Path = e.Path
+		q := make([]string, 0)
+		if e.multiValue() {
+			for k, values := range e.MultiValueQueryStringParameters {
+				for _, v := range values {
+					q = append(q, k+"="+v)
+				}
+			}
+		} else {
+			for k, v := range e.QueryStringParameters {
+				q = append(q, k+"="+v)
+			}
+		}
+		u.RawQuery = strings.Join(q, "&")
+
+	default:
+		u.Path = e.Path
+		q := url.Values{}
+		if e.multiValue() {
+			for k, values := range e.MultiValueQueryStringParameters {
+				q[k] = values
+			}
+		} else {
+			for k, v := range e.QueryStringParameters {
+				q.Set(k, v)
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if e.multiValue() {
+		for k, values := range e.MultiValueHeaders {
+			for _, v := range values {
+				req.Header.Add(k, v)
+			}
+		}
+	} else {
+		for k, v := range e.Headers {
+			req.Header.Set(k, v)
+		}
+	}
+	for _, c := range e.Cookies {
+		req.Header.Add("Cookie", c)
+	}
+
+	req.Host = req.Header.Get("Host")
+	req.RequestURI = u.RequestURI()
+	if ip != "" {
+		req.RemoteAddr = net.JoinHostPort(ip, "0")
+	}
+	if e.RequestContext.HTTP.Protocol != "" {
+		req.Proto = e.RequestContext.HTTP.Protocol
+	}
+
+	return req, nil
+}
+
+// responseWriter buffers the handler response.
+type responseWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+// newResponseWriter creates an empty responseWriter.
+func newResponseWriter() *responseWriter {
+	return &responseWriter{header: make(http.Header)}
+}
+
+// Header returns the response header map.
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader stores the status code, only the first call takes effect.
+// Informational codes, like 103 Early Hints, can't be sent through Lambda and are dropped.
+func (w *responseWriter) WriteHeader(code int) {
+	if w.code == 0 && code >= 200 {
+		w.code = code
+	}
+}
+
+// Write appends data to the response body.
+func (w *responseWriter) Write(data []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+
+	return w.body.Write(data)
+}
+
+// response converts the buffered response into the Lambda response for the event type.
+func (w *responseWriter) response(e *event) *response {
+	res := &response{StatusCode: w.code}
+	if res.StatusCode == 0 {
+		res.StatusCode = http.StatusOK
+	}
+
+	if w.header.Get("Content-Type") == "" && w.body.Len() > 0 {
+		w.header.Set("Content-Type", http.DetectContentType(w.body.Bytes()))
+	}
+
+	if isText(w.header.Get("Content-Type"), w.body.Bytes()) {
+		res.Body = w.body.String()
+	} else {
+		res.Body = base64.StdEncoding.EncodeToString(w.body.Bytes())
+		res.IsBase64Encoded = true
+	}
+
+	switch {
+	case e.kind() == httpAPI:
+		// Cookies have their own field, other headers are joined
+		res.Cookies = w.header.Values("Set-Cookie")
+		w.header.Del("Set-Cookie")
+		res.Headers = joinHeaders(w.header)
+
+	case e.multiValue():
+		res.MultiValueHeaders = w.header
+
+	default:
+		res.Headers = joinHeaders(w.header)
+	}
+
+	if e.kind() == alb {
+		res.StatusDescription = strconv.Itoa(res.StatusCode) + " " + http.StatusText(res.StatusCode)
+	}
+
+	return res
+}
+
+// joinHeaders converts headers into a single value map.
+func joinHeaders(h http.Header) map[string]string {
+	headers := make(map[string]string, len(h))
+	for k, v := range h {
+		headers[k] = strings.Join(v, ", ")
+	}
+
+	return headers
+}
+
+// isText returns true if the body can be sent as text.
+func isText(contentType string, body []byte) bool {
+	ct := strings.ToLower(contentType)
+	if strings.HasPrefix(ct, "text/") || strings.Contains(ct, "json") || strings.Contains(ct, "xml") ||
+		strings.Contains(ct, "javascript") || strings.Contains(ct, "x-www-form-urlencoded") {
+		return utf8.Valid(body)
+	}
+
+	return false
+}
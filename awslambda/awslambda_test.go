@@ -0,0 +1,130 @@
+package awslambda
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+)
+
+type Echo struct {
+	yarf.Resource
+}
+
+func (r *Echo) Post(c *yarf.Context) error {
+	body := make([]byte, 64)
+	n, _ := c.Request.Body.Read(body)
+
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.Response.Header().Add("Set-Cookie", "a=1")
+	c.Response.Header().Add("Set-Cookie", "b=2")
+	c.Response.WriteHeader(201)
+	c.RenderJSON(map[string]string{
+		"id":     c.Param("id"),
+		"q":      c.QueryValue("q"),
+		"body":   string(body[:n]),
+		"ip":     c.GetClientIP(),
+		"header": c.Request.Header.Get("X-Test"),
+	})
+	return nil
+}
+
+func (r *Echo) Get(c *yarf.Context) error {
+	c.Response.Header().Set("Content-Type", "image/png")
+	c.Response.Write([]byte{0x89, 'P', 'N', 'G', 0xff})
+	return nil
+}
+
+func invoke(t *testing.T, payload string) *response {
+	y := yarf.New()
+	y.Add("/items/:id", new(Echo))
+
+	out, err := New(y).Invoke(context.Background(), []byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := new(response)
+	if err := json.Unmarshal(out, res); err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+func decodeBody(t *testing.T, res *response) map[string]string {
+	var body map[string]string
+	if err := json.Unmarshal([]byte(res.Body), &body); err != nil {
+		t.Fatalf("Invalid body '%s': %s", res.Body, err)
+	}
+
+	return body
+}
+
+func TestRESTAPIEvent(t *testing.T) {
+	res := invoke(t, `{
+		"httpMethod": "POST",
+		"path": "/items/7",
+		"queryStringParameters": {"q": "a b"},
+		"headers": {"X-Test": "yes"},
+		"body": "`+base64.StdEncoding.EncodeToString([]byte("hello"))+`",
+		"isBase64Encoded": true,
+		"requestContext": {"identity": {"sourceIp": "1.2.3.4"}}
+	}`)
+
+	if res.StatusCode != 201 || res.IsBase64Encoded || res.Headers["Set-Cookie"] != "a=1, b=2" {
+		t.Errorf("Unexpected response: %+v", res)
+	}
+
+	body := decodeBody(t, res)
+	want := map[string]string{"id": "7", "q": "a b", "body": "hello", "ip": "1.2.3.4", "header": "yes"}
+	for k, v := range want {
+		if body[k] != v {
+			t.Errorf("Expected %s '%s', got '%s'", k, v, body[k])
+		}
+	}
+}
+
+func TestHTTPAPIEvent(t *testing.T) {
+	res := invoke(t, `{
+		"version": "2.0",
+		"rawPath": "/items/8",
+		"rawQueryString": "q=x%26y",
+		"headers": {"x-test": "v2"},
+		"body": "data",
+		"requestContext": {"http": {"method": "POST", "sourceIp": "5.6.7.8", "protocol": "HTTP/1.1"}}
+	}`)
+
+	if res.StatusCode != 201 || len(res.Cookies) != 2 || res.Headers["Set-Cookie"] != "" {
+		t.Errorf("Unexpected response: %+v", res)
+	}
+
+	body := decodeBody(t, res)
+	if body["id"] != "8" || body["q"] != "x&y" || body["ip"] != "5.6.7.8" || body["header"] != "v2" {
+		t.Errorf("Unexpected request: %v", body)
+	}
+}
+
+func TestALBEvent(t *testing.T) {
+	res := invoke(t, `{
+		"httpMethod": "GET",
+		"path": "/items/9",
+		"multiValueHeaders": {"accept": ["image/png"]},
+		"multiValueQueryStringParameters": {},
+		"requestContext": {"elb": {"targetGroupArn": "arn"}}
+	}`)
+
+	if res.StatusCode != 200 || res.StatusDescription != "200 OK" || !res.IsBase64Encoded {
+		t.Fatalf("Unexpected response: %+v", res)
+	}
+	if res.MultiValueHeaders["Content-Type"][0] != "image/png" {
+		t.Errorf("Expected multi value headers, got %+v", res)
+	}
+
+	data, _ := base64.StdEncoding.DecodeString(res.Body)
+	if len(data) != 5 || data[4] != 0xff {
+		t.Errorf("Unexpected binary body: %v", data)
+	}
+}
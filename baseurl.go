@@ -0,0 +1,136 @@
+package yarf
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SetBaseURL sets the public URL the application is reached at, as scheme, host and an optional path prefix,
+// for applications behind proxies or path-prefixed ingresses:
+//
+//	y.SetBaseURL("https://api.example.com/billing")
+//
+// The path prefix is added to the URLs built by Yarf.URL() and Context.URL(), so the router keeps matching
+// the paths it receives, without the prefix removed by the ingress.
+// Context.AbsoluteURL(), Context.URLFor() and Context.RequestURL() use the scheme and host,
+// and the Location headers of redirects and of Created() and Accepted() responses become absolute URLs.
+// Without a base URL, absolute URLs use Context.Scheme() and Context.Host().
+// Call it before starting the servers. An empty string removes the base URL.
+func (y *Yarf) SetBaseURL(raw string) error {
+	if raw == "" {
+		y.baseURL = nil
+		return nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("yarf: base URL scheme must be http or https")
+	}
+	if u.Host == "" {
+		return errors.New("yarf: base URL without host")
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return errors.New("yarf: base URL can't have user info, query or fragment")
+	}
+
+	y.baseURL = &url.URL{
+		Scheme: u.Scheme,
+		Host:   u.Host,
+		Path:   strings.TrimRight(u.Path, "/"),
+	}
+
+	return nil
+}
+
+// BaseURL returns a copy of the public URL set with SetBaseURL(), or nil if there is none.
+func (y *Yarf) BaseURL() *url.URL {
+	if y.baseURL == nil {
+		return nil
+	}
+	u := *y.baseURL
+
+	return &u
+}
+
+// basePath returns the path prefix of the URLs built for routes: the base URL path and the global prefix.
+func (y *Yarf) basePath() string {
+	if y.baseURL == nil {
+		return y.prefix
+	}
+
+	return y.baseURL.Path + y.prefix
+}
+
+// origin returns the scheme and host of the public URL of the request.
+func (c *Context) origin() *url.URL {
+	if c.app != nil && c.app.baseURL != nil {
+		return &url.URL{Scheme: c.app.baseURL.Scheme, Host: c.app.baseURL.Host}
+	}
+
+	return &url.URL{Scheme: c.Scheme(), Host: c.Host()}
+}
+
+// RequestURL returns the absolute public URL of the request, including the base URL prefix and the query.
+// Use it to build pagination or reconnection links to the current resource:
+//
+//	next := c.RequestURL()
+//	q := next.Query()
+//	q.Set("offset", strconv.Itoa(lq.Offset+lq.Limit))
+//	next.RawQuery = q.Encode()
+//	c.AddLink("next", yarf.Link{Href: next.String()})
+func (c *Context) RequestURL() *url.URL {
+	u := c.origin()
+	if c.app != nil && c.app.baseURL != nil {
+		u.Path = c.app.baseURL.Path
+	}
+	u.Path += c.Request.URL.Path
+	u.RawQuery = c.Request.URL.RawQuery
+
+	return u
+}
+
+// AbsoluteURL resolves a URL reference to an absolute URL, using the base URL scheme and host.
+// Absolute URLs are returned unchanged, root-relative paths like the ones built by Context.URL() are kept as is,
+// and other relative references are resolved against Context.RequestURL().
+// Invalid references are returned unchanged.
+func (c *Context) AbsoluteURL(ref string) string {
+	r, err := url.Parse(ref)
+	if err != nil || r.IsAbs() || strings.HasPrefix(ref, "//") {
+		return ref
+	}
+
+	if strings.HasPrefix(ref, "/") {
+		return c.origin().ResolveReference(r).String()
+	}
+
+	return c.RequestURL().ResolveReference(r).String()
+}
+
+// URLFor builds the absolute URL of a named route, for links leaving the application, like emails or webhooks.
+func (c *Context) URLFor(name string, params Params) (string, error) {
+	u, err := c.URL(name, params)
+	if err != nil {
+		return "", err
+	}
+
+	return c.AbsoluteURL(u), nil
+}
+
+// location returns the Location header value of a URL, absolute when a base URL is set.
+func (c *Context) location(ref string) string {
+	if c.app == nil || c.app.baseURL == nil {
+		return ref
+	}
+
+	return c.AbsoluteURL(ref)
+}
+
+// redirect sends a redirect to the location of the URL.
+func (c *Context) redirect(ref string, code int) {
+	http.Redirect(c.Response, c.Request, c.location(ref), redirectCode(code))
+}
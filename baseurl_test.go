@@ -0,0 +1,131 @@
+package yarf
+
+import (
+	"testing"
+)
+
+// BaseURLResource renders the URLs built for the request.
+type BaseURLResource struct {
+	Resource
+}
+
+func (r *BaseURLResource) Get(c *Context) error {
+	abs, err := c.URLFor("users.show", Params{"id": c.Param("id")})
+	if err != nil {
+		return err
+	}
+
+	c.RenderJSON(map[string]string{
+		"request":  c.RequestURL().String(),
+		"absolute": abs,
+		"relative": c.AbsoluteURL("orders"),
+		"external": c.AbsoluteURL("https://example.com/x"),
+	})
+	return nil
+}
+
+func (r *BaseURLResource) Post(c *Context) error {
+	u, err := c.URL("users.show", Params{"id": "2"})
+	if err != nil {
+		return err
+	}
+
+	c.Created(u, nil)
+	return nil
+}
+
+func (r *BaseURLResource) Put(c *Context) error {
+	return c.RedirectToRoute("users.show", Params{"id": "3"})
+}
+
+func TestSetBaseURL(t *testing.T) {
+	y := New()
+
+	for _, raw := range []string{"ftp://example.com", "/prefix", "https://", "https://example.com/?a=1", "https://u:p@example.com"} {
+		if err := y.SetBaseURL(raw); err == nil {
+			t.Errorf("SetBaseURL(%q) should fail", raw)
+		}
+	}
+
+	if err := y.SetBaseURL("https://api.example.com/billing/"); err != nil {
+		t.Fatal(err)
+	}
+	if u := y.BaseURL(); u.String() != "https://api.example.com/billing" {
+		t.Errorf("BaseURL() = %s", u)
+	}
+
+	// Copies don't change the setting
+	y.BaseURL().Host = "other"
+	if y.BaseURL().Host != "api.example.com" {
+		t.Error("BaseURL() should return a copy")
+	}
+
+	if err := y.SetBaseURL(""); err != nil || y.BaseURL() != nil {
+		t.Errorf("SetBaseURL(\"\") should remove the base URL, got %v, %v", y.BaseURL(), err)
+	}
+}
+
+func TestBaseURLBuilding(t *testing.T) {
+	y := New()
+	y.AddRoute("/users/:id", new(BaseURLResource)).Name("users.show")
+	if err := y.SetBaseURL("https://api.example.com/billing"); err != nil {
+		t.Fatal(err)
+	}
+
+	if u, _ := y.URL("users.show", Params{"id": "1"}); u != "/billing/users/1" {
+		t.Errorf("URL() = %s", u)
+	}
+
+	res := testRequest(y, "GET", "http://internal:8080/users/1?page=2", nil)
+
+	if !jsonEqual(res.Body.String(), `{
+		"request": "https://api.example.com/billing/users/1?page=2",
+		"absolute": "https://api.example.com/billing/users/1",
+		"relative": "https://api.example.com/billing/users/orders",
+		"external": "https://example.com/x"
+	}`) {
+		t.Errorf("Unexpected URLs: %s", res.Body.String())
+	}
+}
+
+func TestBaseURLFallback(t *testing.T) {
+	y := New()
+	y.AddRoute("/users/:id", new(BaseURLResource)).Name("users.show")
+
+	res := testRequest(y, "GET", "http://internal:8080/users/1", nil)
+
+	if !jsonEqual(res.Body.String(), `{
+		"request": "http://internal:8080/users/1",
+		"absolute": "http://internal:8080/users/1",
+		"relative": "http://internal:8080/users/orders",
+		"external": "https://example.com/x"
+	}`) {
+		t.Errorf("Unexpected URLs: %s", res.Body.String())
+	}
+
+	// Location headers are kept as is
+	res = testRequest(y, "POST", "http://internal:8080/users/1", nil)
+
+	if loc := res.Header().Get("Location"); loc != "/users/2" {
+		t.Errorf("Location = %s", loc)
+	}
+}
+
+func TestBaseURLLocation(t *testing.T) {
+	y := New()
+	y.AddRoute("/users/:id", new(BaseURLResource)).Name("users.show")
+	if err := y.SetBaseURL("https://api.example.com/billing"); err != nil {
+		t.Fatal(err)
+	}
+
+	for method, expected := range map[string]string{
+		"POST": "https://api.example.com/billing/users/2",
+		"PUT":  "https://api.example.com/billing/users/3",
+	} {
+		res := testRequest(y, method, "http://internal:8080/users/1", nil)
+
+		if loc := res.Header().Get("Location"); loc != expected {
+			t.Errorf("%s: Location = %s, expected %s", method, loc, expected)
+		}
+	}
+}
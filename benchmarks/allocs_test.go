@@ -0,0 +1,47 @@
+package benchmarks
+
+import (
+	"testing"
+)
+
+// maxAllocs are the allocations allowed per request, on top of the one per route tried,
+// as the request path is split again for every route.
+// They leave one allocation of margin, as the runtime of each supported Go release allocates a bit differently.
+var maxAllocs = map[string]float64{
+	"static": 8,
+	"params": 8,
+	"groups": 26,
+	"miss":   11,
+}
+
+// tables creates the route tables measured by the allocation tests.
+var tables = map[string]func(n int) *Table{
+	"static": Static,
+	"params": Params,
+	"groups": func(n int) *Table { return Groups(n, 5) },
+	"miss":   Miss,
+}
+
+// allocs returns the average allocations of a request to the table.
+func allocs(t *Table) float64 {
+	req := t.Request()
+	w := new(Discard)
+
+	return testing.AllocsPerRun(100, func() {
+		t.App.ServeHTTP(w, req)
+	})
+}
+
+func TestAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("Allocations aren't stable with the race detector")
+	}
+
+	for name, table := range tables {
+		for _, n := range Sizes {
+			if a, max := allocs(table(n)), maxAllocs[name]+float64(n); a > max {
+				t.Errorf("%s with %d routes: expected at most %v allocations per request, got %v", name, n, max, a)
+			}
+		}
+	}
+}
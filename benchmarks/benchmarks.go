@@ -0,0 +1,125 @@
+// Package benchmarks measures the Yarf router over route tables of growing sizes:
+// static routes, param-heavy routes, deeply nested groups and requests missing every route.
+// Run them with:
+//
+//	go test -bench . -benchmem ./benchmarks
+//
+// and profile the matcher with the usual test flags:
+//
+//	go test -bench Params/routes=1000 -cpuprofile cpu.out -memprofile mem.out ./benchmarks
+//	go tool pprof -top cpu.out
+//
+// The allocation tests of the package run with the regular tests, so regressions in the matcher
+// allocations fail the build.
+package benchmarks
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Sizes are the route table sizes measured.
+var Sizes = []int{10, 100, 1000}
+
+// Handler is a resource answering every GET request without body.
+type Handler struct {
+	yarf.Resource
+}
+
+// Get does nothing.
+func (h *Handler) Get(c *yarf.Context) error {
+	return nil
+}
+
+// Table is an application with a route table, and a request matching its last route, the slowest one to find.
+type Table struct {
+	App  *yarf.Yarf
+	Path string
+}
+
+// newApp creates an application without route cache, so every request runs the matcher.
+func newApp() *yarf.Yarf {
+	y := yarf.New()
+	y.UseCache = false
+
+	return y
+}
+
+// Static creates a table of n static routes, like "/static/42/items".
+func Static(n int) *Table {
+	y := newApp()
+	for i := 0; i < n; i++ {
+		y.Add("/static/"+strconv.Itoa(i)+"/items", new(Handler))
+	}
+
+	return &Table{y, "/static/" + strconv.Itoa(n-1) + "/items"}
+}
+
+// Params creates a table of n routes with four params each, like "/params/42/:org/:team/:user/:item".
+func Params(n int) *Table {
+	y := newApp()
+	for i := 0; i < n; i++ {
+		y.Add("/params/"+strconv.Itoa(i)+"/:org/:team/:user/:item", new(Handler))
+	}
+
+	return &Table{y, "/params/" + strconv.Itoa(n-1) + "/acme/core/joe/42"}
+}
+
+// Groups creates n routes inside groups nested depth levels deep, like "/g/g/g/42".
+func Groups(n, depth int) *Table {
+	inner := yarf.RouteGroup("/g")
+	for i := 0; i < n; i++ {
+		inner.Add("/"+strconv.Itoa(i), new(Handler))
+	}
+
+	g := inner
+	for i := 1; i < depth; i++ {
+		outer := yarf.RouteGroup("/g")
+		outer.AddGroup(g)
+		g = outer
+	}
+
+	y := newApp()
+	y.AddGroup(g)
+
+	return &Table{y, strings.Repeat("/g", depth) + "/" + strconv.Itoa(n-1)}
+}
+
+// Miss creates a table of n static routes with a request matching none of them.
+func Miss(n int) *Table {
+	t := Static(n)
+	t.Path = "/missing/route"
+
+	return t
+}
+
+// Request returns a GET request to the table path.
+func (t *Table) Request() *http.Request {
+	req, _ := http.NewRequest("GET", "http://localhost"+t.Path, nil)
+	return req
+}
+
+// Discard is an http.ResponseWriter dropping the responses, to measure the framework alone.
+type Discard struct {
+	header http.Header
+}
+
+// Header returns the response headers.
+func (d *Discard) Header() http.Header {
+	if d.header == nil {
+		d.header = make(http.Header)
+	}
+
+	return d.header
+}
+
+// Write drops the data.
+func (d *Discard) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+// WriteHeader drops the status code.
+func (d *Discard) WriteHeader(code int) {}
@@ -0,0 +1,6 @@
+//go:build race
+
+package benchmarks
+
+// raceEnabled is true when testing with the race detector, which makes sync.Pool drop values at random.
+const raceEnabled = true
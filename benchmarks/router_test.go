@@ -0,0 +1,51 @@
+package benchmarks
+
+import (
+	"strconv"
+	"testing"
+)
+
+// benchmark serves the table request b.N times, for every table size.
+func benchmark(b *testing.B, table func(n int) *Table) {
+	for _, n := range Sizes {
+		b.Run("routes="+strconv.Itoa(n), func(b *testing.B) {
+			t := table(n)
+			req := t.Request()
+			w := new(Discard)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				t.App.ServeHTTP(w, req)
+			}
+		})
+	}
+}
+
+func BenchmarkStatic(b *testing.B) {
+	benchmark(b, Static)
+}
+
+func BenchmarkParams(b *testing.B) {
+	benchmark(b, Params)
+}
+
+func BenchmarkGroups(b *testing.B) {
+	for _, depth := range []int{1, 5, 20} {
+		b.Run("depth="+strconv.Itoa(depth), func(b *testing.B) {
+			benchmark(b, func(n int) *Table { return Groups(n, depth) })
+		})
+	}
+}
+
+func BenchmarkMiss(b *testing.B) {
+	benchmark(b, Miss)
+}
+
+func BenchmarkRouteCache(b *testing.B) {
+	benchmark(b, func(n int) *Table {
+		t := Params(n)
+		t.App.UseCache = true
+		return t
+	})
+}
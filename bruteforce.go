@@ -0,0 +1,282 @@
+package yarf
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// authFailKey is the Context storage key for the authentication failures reported by handlers.
+type authFailKey struct{}
+
+// authFailure is an authentication failure reported during a request.
+type authFailure struct {
+	account string
+}
+
+// AuthFailed reports a failed authentication attempt from the client IP.
+// The BruteForceGuard middleware uses it to delay or ban further attempts.
+func (c *Context) AuthFailed() {
+	c.AuthFailedFor("")
+}
+
+// AuthFailedFor reports a failed authentication attempt against an account, from the client IP.
+func (c *Context) AuthFailedFor(account string) {
+	f, _ := c.get(authFailKey{}).([]authFailure)
+	c.set(authFailKey{}, append(f, authFailure{account}))
+}
+
+// BruteForceRecord stores the failed authentication attempts of an IP or account.
+type BruteForceRecord struct {
+	Failures int
+	Until    time.Time // Blocked until
+}
+
+// BruteForceStore is the storage interface used by BruteForceGuard to keep the failure records.
+type BruteForceStore interface {
+	// Get retrieves the record for a key, or an empty record if it doesn't exist.
+	Get(key string) (BruteForceRecord, error)
+
+	// Set saves the record for a key, to be discarded after ttl.
+	Set(key string, r BruteForceRecord, ttl time.Duration) error
+
+	// Del removes the record for a key.
+	Del(key string) error
+}
+
+// memoryRecord is a BruteForceRecord with expiration time.
+type memoryRecord struct {
+	BruteForceRecord
+	expires time.Time
+}
+
+// MemoryBruteForceStore is an in-memory BruteForceStore implementation.
+type MemoryBruteForceStore struct {
+	// Clock tells the time to expire the records. nil uses the system clock.
+	Clock Clock
+
+	records map[string]memoryRecord
+	sync.Mutex
+}
+
+// NewMemoryBruteForceStore creates a new empty MemoryBruteForceStore.
+func NewMemoryBruteForceStore() *MemoryBruteForceStore {
+	return &MemoryBruteForceStore{
+		records: make(map[string]memoryRecord),
+	}
+}
+
+// Get retrieves the record for a key.
+func (s *MemoryBruteForceStore) Get(key string) (BruteForceRecord, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	r, ok := s.records[key]
+	if !ok {
+		return BruteForceRecord{}, nil
+	}
+	if clockNow(s.Clock).After(r.expires) {
+		delete(s.records, key)
+		return BruteForceRecord{}, nil
+	}
+
+	return r.BruteForceRecord, nil
+}
+
+// Set saves the record for a key.
+func (s *MemoryBruteForceStore) Set(key string, r BruteForceRecord, ttl time.Duration) error {
+	s.Lock()
+	defer s.Unlock()
+
+	now := clockNow(s.Clock)
+	s.records[key] = memoryRecord{r, now.Add(ttl)}
+
+	// Cleanup expired records
+	for k, v := range s.records {
+		if now.After(v.expires) {
+			delete(s.records, k)
+		}
+	}
+
+	return nil
+}
+
+// Del removes the record for a key.
+func (s *MemoryBruteForceStore) Del(key string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.records, key)
+
+	return nil
+}
+
+// BruteForceStats are the counters exposed by BruteForceGuard.
+type BruteForceStats struct {
+	Failures uint64 // Failed attempts reported
+	Blocks   uint64 // Blocking periods started, for IPs and accounts
+	Rejected uint64 // Requests rejected while blocked
+}
+
+// BruteForceGuard is a middleware protecting authentication routes from brute-force attacks.
+// Handlers report failed attempts with Context.AuthFailed() or Context.AuthFailedFor(account).
+// Once an IP or account reaches the Threshold of failures within the Window,
+// it's blocked for an exponentially growing period, starting at Delay and up to MaxDelay.
+// Blocked requests get a 429 error with a Retry-After header.
+type BruteForceGuard struct {
+	Middleware
+
+	// Store keeps the failure records.
+	Store BruteForceStore
+
+	// Threshold is the number of failures allowed before blocking.
+	Threshold int
+
+	// Window is the time failures are remembered after the last one.
+	Window time.Duration
+
+	// Delay is the first blocking period, doubled on each further failure.
+	Delay time.Duration
+
+	// MaxDelay caps the blocking period.
+	MaxDelay time.Duration
+
+	// Account optionally resolves the account targeted by the request before the handler runs,
+	// so blocked accounts are rejected early.
+	Account func(*Context) string
+
+	// ResetOnSuccess clears the record of the account resolved by Account after a request without failures.
+	// IP records are never cleared on success, so logging into another account doesn't reset the failures of an IP.
+	ResetOnSuccess bool
+
+	// Clock tells the time to start and check the blocking periods. nil uses the system clock.
+	// The Store expires the records with its own clock.
+	Clock Clock
+
+	stats BruteForceStats
+}
+
+// NewBruteForceGuard creates a BruteForceGuard with in-memory storage and default settings:
+// 5 failures allowed in 15 minutes, blocking from 1 second up to 1 hour.
+func NewBruteForceGuard() *BruteForceGuard {
+	return &BruteForceGuard{
+		Store:          NewMemoryBruteForceStore(),
+		Threshold:      5,
+		Window:         15 * time.Minute,
+		Delay:          time.Second,
+		MaxDelay:       time.Hour,
+		ResetOnSuccess: true,
+	}
+}
+
+// Phase puts the guard with the security middleware.
+func (g *BruteForceGuard) Phase() Phase {
+	return PhaseSecurity
+}
+
+// Stats returns a snapshot of the guard counters.
+func (g *BruteForceGuard) Stats() BruteForceStats {
+	return BruteForceStats{
+		Failures: atomic.LoadUint64(&g.stats.Failures),
+		Blocks:   atomic.LoadUint64(&g.stats.Blocks),
+		Rejected: atomic.LoadUint64(&g.stats.Rejected),
+	}
+}
+
+// event counts a guard event into the yarf_bruteforce_events_total metric.
+func (g *BruteForceGuard) event(c *Context, event string) {
+	c.metrics().Counter("yarf_bruteforce_events_total", "Brute force guard failures, blocks and rejections.", "event").Add(1, event)
+}
+
+// keys returns the store keys for the client IP and account.
+// The IP is the remote address, unless trusted proxies are configured, so clients can't rotate forwarding headers.
+func (g *BruteForceGuard) keys(c *Context, account string) []string {
+	keys := []string{"ip:" + c.peerIP()}
+	if account != "" {
+		keys = append(keys, "account:"+account)
+	}
+
+	return keys
+}
+
+// PreDispatch rejects requests from blocked IPs or accounts.
+func (g *BruteForceGuard) PreDispatch(c *Context) error {
+	account := ""
+	if g.Account != nil {
+		account = g.Account(c)
+	}
+
+	now := clockNow(g.Clock)
+	for _, k := range g.keys(c, account) {
+		r, err := g.Store.Get(k)
+		if err != nil {
+			return err
+		}
+
+		if r.Until.After(now) {
+			atomic.AddUint64(&g.stats.Rejected, 1)
+			g.event(c, "rejected")
+
+			retry := int(r.Until.Sub(now)/time.Second) + 1
+			c.Response.Header().Set("Retry-After", strconv.Itoa(retry))
+			return ErrorTooManyRequests()
+		}
+	}
+
+	return nil
+}
+
+// End records the failures reported during the request.
+func (g *BruteForceGuard) End(c *Context) error {
+	failures, _ := c.get(authFailKey{}).([]authFailure)
+
+	if len(failures) == 0 {
+		if g.ResetOnSuccess && c.Err() == nil && g.Account != nil {
+			if account := g.Account(c); account != "" {
+				return g.Store.Del("account:" + account)
+			}
+		}
+		return nil
+	}
+
+	for _, f := range failures {
+		atomic.AddUint64(&g.stats.Failures, 1)
+		g.event(c, "failure")
+
+		for _, k := range g.keys(c, f.account) {
+			if err := g.fail(c, k); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fail adds a failure to the record and starts blocking if the threshold is reached.
+func (g *BruteForceGuard) fail(c *Context, key string) error {
+	r, err := g.Store.Get(key)
+	if err != nil {
+		return err
+	}
+
+	r.Failures++
+	ttl := g.Window
+
+	if r.Failures >= g.Threshold {
+		delay := g.Delay << uint(r.Failures-g.Threshold)
+		if delay > g.MaxDelay || delay <= 0 {
+			delay = g.MaxDelay
+		}
+
+		r.Until = clockNow(g.Clock).Add(delay)
+		if delay > ttl {
+			ttl = delay
+		}
+		atomic.AddUint64(&g.stats.Blocks, 1)
+		g.event(c, "block")
+	}
+
+	return g.Store.Set(key, r, ttl)
+}
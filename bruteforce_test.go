@@ -0,0 +1,183 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type LoginResource struct {
+	Resource
+}
+
+func (r *LoginResource) Post(c *Context) error {
+	if c.FormValue("password") != "secret" {
+		c.AuthFailedFor(c.FormValue("user"))
+		return &CustomError{HTTPCode: 401, ErrorMsg: "Unauthorized"}
+	}
+
+	c.Render("Welcome")
+	return nil
+}
+
+func login(y *Yarf, ip, user, password string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "http://localhost:8080/login?user="+user+"&password="+password, nil)
+	req.RemoteAddr = ip
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	return res
+}
+
+func TestBruteForceGuard(t *testing.T) {
+	g := NewBruteForceGuard()
+	g.Threshold = 3
+	g.Delay = time.Minute
+
+	y := New()
+	y.Insert(g)
+	y.Add("/login", new(LoginResource))
+
+	for i := 0; i < 3; i++ {
+		if res := login(y, "10.0.0.1", "joe", "wrong"); res.Code != 401 {
+			t.Fatalf("Failed login %d should return 401, got %d", i, res.Code)
+		}
+	}
+
+	res := login(y, "10.0.0.1", "joe", "secret")
+	if res.Code != 429 {
+		t.Fatalf("Blocked IP should get 429, got %d", res.Code)
+	}
+	if res.Header().Get("Retry-After") == "" {
+		t.Error("Blocked response should include Retry-After header")
+	}
+
+	// Other IPs aren't affected
+	if res := login(y, "10.0.0.2", "ann", "secret"); res.Code != 200 {
+		t.Errorf("Other IP should be allowed, got %d", res.Code)
+	}
+
+	stats := g.Stats()
+	if stats.Failures != 3 || stats.Blocks != 2 || stats.Rejected != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// Account records are kept too
+	r, _ := g.Store.Get("account:joe")
+	if r.Failures != 3 || r.Until.IsZero() {
+		t.Errorf("Account should be blocked, got %+v", r)
+	}
+}
+
+func TestBruteForceGuardReset(t *testing.T) {
+	g := NewBruteForceGuard()
+	g.Account = func(c *Context) string { return c.FormValue("user") }
+
+	y := New()
+	y.Insert(g)
+	y.Add("/login", new(LoginResource))
+
+	login(y, "10.0.0.1", "joe", "wrong")
+	login(y, "10.0.0.1", "joe", "secret")
+
+	if r, _ := g.Store.Get("account:joe"); r.Failures != 0 {
+		t.Errorf("Successful login should reset the account failures, found %d", r.Failures)
+	}
+	if r, _ := g.Store.Get("ip:10.0.0.1"); r.Failures != 1 {
+		t.Errorf("Successful login shouldn't reset the IP failures, found %d", r.Failures)
+	}
+}
+
+func TestBruteForceGuardInterleavedSuccess(t *testing.T) {
+	g := NewBruteForceGuard()
+	g.Threshold = 3
+	g.Account = func(c *Context) string { return c.FormValue("user") }
+
+	y := New()
+	y.Insert(g)
+	y.Add("/login", new(LoginResource))
+
+	// Logging into their own account doesn't let an attacker keep guessing
+	for i := 0; i < 3; i++ {
+		login(y, "10.0.0.1", "user"+strconv.Itoa(i), "wrong")
+		login(y, "10.0.0.1", "mallory", "secret")
+	}
+	if res := login(y, "10.0.0.1", "user9", "wrong"); res.Code != 429 {
+		t.Errorf("Expected IP blocked, got %d", res.Code)
+	}
+}
+
+func TestBruteForceGuardForwardedHeaders(t *testing.T) {
+	g := NewBruteForceGuard()
+	g.Threshold = 2
+
+	y := New()
+	y.Insert(g)
+	y.Add("/login", new(LoginResource))
+
+	send := func(forwarded string) int {
+		req := httptest.NewRequest("POST", "/login?user=joe&password=wrong", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	// Rotating the forwarding headers doesn't reset the IP
+	send("1.1.1.1")
+	send("2.2.2.2")
+	if code := send("3.3.3.3"); code != 429 {
+		t.Errorf("Expected the remote address blocked, got %d", code)
+	}
+
+	// Behind trusted proxies, the forwarded client IP is used
+	g.Store = NewMemoryBruteForceStore()
+	y.TrustProxies("10.0.0.0/8")
+	send("1.1.1.1")
+	send("1.1.1.1")
+	if code := send("2.2.2.2"); code != 401 {
+		t.Errorf("Expected another client behind the proxy allowed, got %d", code)
+	}
+}
+
+func TestBruteForceGuardUnblock(t *testing.T) {
+	clock := newTestClock()
+	g := NewBruteForceGuard()
+	g.Threshold = 1
+	g.Delay = time.Minute
+	g.Clock = clock
+	g.Store.(*MemoryBruteForceStore).Clock = clock
+
+	y := New()
+	y.Insert(g)
+	y.Add("/login", new(LoginResource))
+
+	login(y, "10.0.0.1", "joe", "wrong")
+	if res := login(y, "10.0.0.1", "joe", "secret"); res.Code != 429 || res.Header().Get("Retry-After") != "61" {
+		t.Fatalf("Expected blocked IP for a minute, got %d %s", res.Code, res.Header().Get("Retry-After"))
+	}
+
+	clock.Advance(time.Minute + time.Second)
+	if res := login(y, "10.0.0.1", "joe", "secret"); res.Code != 200 {
+		t.Errorf("Expected IP unblocked after the delay, got %d", res.Code)
+	}
+
+	// Failures are forgotten after the window
+	login(y, "10.0.0.2", "ann", "wrong")
+	clock.Advance(g.Window + time.Minute)
+	if r, _ := g.Store.Get("ip:10.0.0.2"); r.Failures != 0 {
+		t.Errorf("Expected failures forgotten after the window, got %+v", r)
+	}
+}
+
+func TestMemoryBruteForceStoreExpiration(t *testing.T) {
+	s := NewMemoryBruteForceStore()
+	s.Set("key", BruteForceRecord{Failures: 1}, -time.Second)
+
+	if r, _ := s.Get("key"); r.Failures != 0 {
+		t.Error("Expired records shouldn't be returned")
+	}
+}
@@ -10,6 +10,20 @@ type RouteCache struct {
 	params Params
 }
 
+// newRouteCache creates a RouteCache from the matched Context.
+// Data is copied so the Context can be reused.
+func newRouteCache(c *Context) RouteCache {
+	rc := RouteCache{
+		route:  append([]Router(nil), c.groupDispatch...),
+		params: make(Params, len(c.Params)),
+	}
+	for k, v := range c.Params {
+		rc.params[k] = v
+	}
+
+	return rc
+}
+
 // Cache is the service handler for route caching
 type Cache struct {
 	// Cache data storage
@@ -0,0 +1,165 @@
+package yarf
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheControl builds a Cache-Control header value from typed directives.
+// The builder returned by Context.Cache() sets the response header on every change,
+// while the one created by NewCacheControl() can be used with String(), e.g. to declare route headers.
+//
+//	c.Cache().Public().MaxAge(5 * time.Minute).StaleWhileRevalidate(30 * time.Second)
+//
+//	y.AddRoute("/assets/*", assets).Header("Cache-Control", yarf.NewCacheControl().Immutable().String())
+type CacheControl struct {
+	public          bool
+	private         bool
+	noCache         bool
+	noStore         bool
+	mustRevalidate  bool
+	proxyRevalidate bool
+	noTransform     bool
+	immutable       bool
+
+	maxAge               *time.Duration
+	sMaxAge              *time.Duration
+	staleWhileRevalidate *time.Duration
+	staleIfError         *time.Duration
+
+	header http.Header
+}
+
+// NewCacheControl creates an empty CacheControl builder.
+func NewCacheControl() *CacheControl {
+	return new(CacheControl)
+}
+
+// Cache returns a CacheControl builder that sets the Cache-Control header of the response.
+// Each call starts from an empty set of directives.
+func (c *Context) Cache() *CacheControl {
+	return &CacheControl{
+		header: c.Response.Header(),
+	}
+}
+
+// apply updates the bound response header.
+func (cc *CacheControl) apply() *CacheControl {
+	if cc.header != nil {
+		cc.header.Set("Cache-Control", cc.String())
+	}
+
+	return cc
+}
+
+// Public allows shared caches to store the response.
+func (cc *CacheControl) Public() *CacheControl {
+	cc.public, cc.private = true, false
+	return cc.apply()
+}
+
+// Private restricts the response to the client cache.
+func (cc *CacheControl) Private() *CacheControl {
+	cc.private, cc.public = true, false
+	return cc.apply()
+}
+
+// NoCache requires caches to revalidate the response before using it.
+func (cc *CacheControl) NoCache() *CacheControl {
+	cc.noCache = true
+	return cc.apply()
+}
+
+// NoStore forbids caching the response at all. It's a preset: other directives are cleared.
+func (cc *CacheControl) NoStore() *CacheControl {
+	*cc = CacheControl{noStore: true, header: cc.header}
+	return cc.apply()
+}
+
+// MaxAge sets how long the response is fresh.
+func (cc *CacheControl) MaxAge(d time.Duration) *CacheControl {
+	cc.maxAge = &d
+	return cc.apply()
+}
+
+// SMaxAge sets how long the response is fresh for shared caches, replacing MaxAge on them.
+func (cc *CacheControl) SMaxAge(d time.Duration) *CacheControl {
+	cc.sMaxAge = &d
+	return cc.apply()
+}
+
+// MustRevalidate forbids using the response once stale without revalidating it.
+func (cc *CacheControl) MustRevalidate() *CacheControl {
+	cc.mustRevalidate = true
+	return cc.apply()
+}
+
+// ProxyRevalidate is MustRevalidate for shared caches only.
+func (cc *CacheControl) ProxyRevalidate() *CacheControl {
+	cc.proxyRevalidate = true
+	return cc.apply()
+}
+
+// NoTransform forbids intermediaries to change the response body.
+func (cc *CacheControl) NoTransform() *CacheControl {
+	cc.noTransform = true
+	return cc.apply()
+}
+
+// Immutable marks the response as never changing, for versioned assets.
+// It's a preset: the response is also made public with a max age of one year.
+func (cc *CacheControl) Immutable() *CacheControl {
+	year := 365 * 24 * time.Hour
+	cc.immutable, cc.public, cc.private, cc.noStore = true, true, false, false
+	cc.maxAge = &year
+	return cc.apply()
+}
+
+// StaleWhileRevalidate allows using the stale response while it's revalidated in the background.
+func (cc *CacheControl) StaleWhileRevalidate(d time.Duration) *CacheControl {
+	cc.staleWhileRevalidate = &d
+	return cc.apply()
+}
+
+// StaleIfError allows using the stale response when revalidation fails.
+func (cc *CacheControl) StaleIfError(d time.Duration) *CacheControl {
+	cc.staleIfError = &d
+	return cc.apply()
+}
+
+// String returns the header value, with the directives in a fixed order.
+func (cc *CacheControl) String() string {
+	var d []string
+
+	flag := func(set bool, name string) {
+		if set {
+			d = append(d, name)
+		}
+	}
+	seconds := func(v *time.Duration, name string) {
+		if v != nil {
+			s := int64(*v / time.Second)
+			if s < 0 {
+				s = 0
+			}
+			d = append(d, name+"="+strconv.FormatInt(s, 10))
+		}
+	}
+
+	flag(cc.public, "public")
+	flag(cc.private, "private")
+	flag(cc.noCache, "no-cache")
+	flag(cc.noStore, "no-store")
+	seconds(cc.maxAge, "max-age")
+	seconds(cc.sMaxAge, "s-maxage")
+	flag(cc.mustRevalidate, "must-revalidate")
+	flag(cc.proxyRevalidate, "proxy-revalidate")
+	flag(cc.noTransform, "no-transform")
+	flag(cc.immutable, "immutable")
+	seconds(cc.staleWhileRevalidate, "stale-while-revalidate")
+	seconds(cc.staleIfError, "stale-if-error")
+
+	return strings.Join(d, ", ")
+}
@@ -0,0 +1,46 @@
+package yarf
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCacheControl(t *testing.T) {
+	tests := []struct {
+		cc       *CacheControl
+		expected string
+	}{
+		{NewCacheControl(), ""},
+		{NewCacheControl().Public().MaxAge(5 * time.Minute).StaleWhileRevalidate(30 * time.Second), "public, max-age=300, stale-while-revalidate=30"},
+		{NewCacheControl().Public().Private(), "private"},
+		{NewCacheControl().Private().NoCache().MustRevalidate(), "private, no-cache, must-revalidate"},
+		{NewCacheControl().Public().MaxAge(time.Minute).NoStore(), "no-store"},
+		{NewCacheControl().Immutable(), "public, max-age=31536000, immutable"},
+		{NewCacheControl().NoStore().Immutable(), "public, max-age=31536000, immutable"},
+		{NewCacheControl().Public().MaxAge(time.Minute).SMaxAge(time.Hour).ProxyRevalidate().NoTransform().StaleIfError(time.Hour), "public, max-age=60, s-maxage=3600, proxy-revalidate, no-transform, stale-if-error=3600"},
+		{NewCacheControl().MaxAge(1500 * time.Millisecond), "max-age=1"},
+		{NewCacheControl().MaxAge(-time.Second), "max-age=0"},
+	}
+
+	for _, tt := range tests {
+		if s := tt.cc.String(); s != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, s)
+		}
+	}
+}
+
+func TestContextCache(t *testing.T) {
+	c := NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+	c.Response.Header().Set("Cache-Control", "no-cache")
+
+	c.Cache().Public().MaxAge(time.Minute)
+	if h := c.Response.Header().Get("Cache-Control"); h != "public, max-age=60" {
+		t.Errorf("Expected header to be set, got %q", h)
+	}
+
+	c.Cache().NoStore()
+	if h := c.Response.Header().Get("Cache-Control"); h != "no-store" {
+		t.Errorf("Expected header to be replaced, got %q", h)
+	}
+}
@@ -0,0 +1,29 @@
+package yarf
+
+import (
+	"time"
+)
+
+// Clock tells the current time to the rate limits, caches, secure cookies and brute-force lockouts,
+// so tests can move it forward instead of sleeping. yarftest.Clock is a fake implementation.
+// A nil Clock reads the system time.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function into a Clock.
+type ClockFunc func() time.Time
+
+// Now calls the function.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// clockNow returns the time of clk, or the system time if it's nil.
+func clockNow(clk Clock) time.Time {
+	if clk == nil {
+		return time.Now()
+	}
+
+	return clk.Now()
+}
@@ -0,0 +1,41 @@
+package yarf
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// testClock is a Clock moved forward by the tests.
+type testClock struct {
+	now  time.Time
+	lock sync.Mutex
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *testClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func TestClockNow(t *testing.T) {
+	fixed := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if now := clockNow(ClockFunc(func() time.Time { return fixed })); !now.Equal(fixed) {
+		t.Errorf("Expected the clock time, got %s", now)
+	}
+	if now := clockNow(nil); time.Since(now) > time.Second {
+		t.Errorf("Expected the system time without clock, got %s", now)
+	}
+}
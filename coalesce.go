@@ -0,0 +1,133 @@
+package yarf
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// bufferedResponse is a http.ResponseWriter that keeps the response in memory
+// so it can be written later to one or many clients.
+type bufferedResponse struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{
+		header: make(http.Header),
+	}
+}
+
+// Header returns the buffered header map.
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+// WriteHeader stores the status code, only the first call takes effect.
+// Informational codes can't be buffered and are dropped.
+func (b *bufferedResponse) WriteHeader(code int) {
+	if b.code == 0 && !informational(code) {
+		b.code = code
+	}
+}
+
+// Write appends data to the buffered body.
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	if b.code == 0 {
+		b.code = http.StatusOK
+	}
+
+	return b.body.Write(data)
+}
+
+// flight is a GET execution shared by concurrent identical requests.
+type flight struct {
+	wg   sync.WaitGroup
+	res  *bufferedResponse
+	err  error
+	dups int
+}
+
+// CoalescedResource wraps a ResourceHandler so identical concurrent GET requests
+// execute the handler only once, and the response is sent to all of them.
+// Requests are identical when they share the path, the query string and the value of the Vary headers.
+// Middleware keeps running for every request, only the resource's Get method is shared.
+type CoalescedResource struct {
+	ResourceHandler
+
+	// Vary lists the request headers that make responses different, like Authorization or Accept-Language.
+	Vary []string
+
+	flights map[string]*flight
+	sync.Mutex
+}
+
+// Coalesce creates a CoalescedResource wrapping the handler provided.
+func Coalesce(h ResourceHandler, vary ...string) *CoalescedResource {
+	return &CoalescedResource{
+		ResourceHandler: h,
+		Vary:            vary,
+		flights:         make(map[string]*flight),
+	}
+}
+
+// key builds the string identifying identical requests.
+func (r *CoalescedResource) key(c *Context) string {
+	k := c.Request.URL.Path + "?" + c.Request.URL.RawQuery
+	for _, h := range r.Vary {
+		k += "\n" + h + ": " + strings.Join(c.Request.Header[http.CanonicalHeaderKey(h)], ",")
+	}
+
+	return k
+}
+
+// Get executes the wrapped Get method or waits for an identical request to finish it.
+func (r *CoalescedResource) Get(c *Context) error {
+	k := r.key(c)
+
+	r.Lock()
+	if f, ok := r.flights[k]; ok {
+		f.dups++
+		r.Unlock()
+
+		// Wait for the running request and copy its response.
+		// Failed requests are written by finish with their error status.
+		f.wg.Wait()
+		if f.err == nil {
+			f.res.flush(c.Response)
+		}
+		return f.err
+	}
+
+	f := new(flight)
+	f.wg.Add(1)
+	r.flights[k] = f
+	r.Unlock()
+
+	// Run the handler against a buffer
+	f.res = newBufferedResponse()
+	f.err = ErrorUnexpected()
+	rw := c.Response
+	c.Response = f.res
+
+	// Release waiters even if the handler panics.
+	defer func() {
+		c.Response = rw
+
+		r.Lock()
+		delete(r.flights, k)
+		r.Unlock()
+		f.wg.Done()
+	}()
+
+	f.err = r.ResourceHandler.Get(c)
+	c.Response = rw
+
+	if f.err == nil {
+		f.res.flush(c.Response)
+	}
+	return f.err
+}
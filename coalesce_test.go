@@ -0,0 +1,126 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type CountingResource struct {
+	Resource
+
+	calls   int32
+	started chan bool
+	release chan bool
+	err     error
+}
+
+func (r *CountingResource) Get(c *Context) error {
+	atomic.AddInt32(&r.calls, 1)
+	r.started <- true
+	<-r.release
+
+	if r.err != nil {
+		return r.err
+	}
+
+	c.Response.Header().Set("X-Test", "shared")
+	c.Render("response")
+
+	return nil
+}
+
+// coalescedRequests sends n identical requests, releasing the handler once they all joined the same flight.
+func coalescedRequests(r *CountingResource, cr *CoalescedResource, n int) []*httptest.ResponseRecorder {
+	y := New()
+	y.Add("/test", cr)
+
+	var wg sync.WaitGroup
+	results := make([]*httptest.ResponseRecorder, n)
+	send := func(res *httptest.ResponseRecorder) {
+		defer wg.Done()
+		req, _ := http.NewRequest("GET", "http://localhost:8080/test?a=1", nil)
+		y.ServeHTTP(res, req)
+	}
+
+	// Leader
+	results[0] = httptest.NewRecorder()
+	wg.Add(1)
+	go send(results[0])
+	<-r.started
+
+	// Waiters
+	for i := 1; i < n; i++ {
+		results[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go send(results[i])
+	}
+
+	// Wait for all waiters to join the flight before releasing the leader.
+	for {
+		cr.Lock()
+		dups := cr.flights["/test?a=1"].dups
+		cr.Unlock()
+		if dups == n-1 {
+			break
+		}
+		runtime.Gosched()
+	}
+	r.release <- true
+	wg.Wait()
+
+	return results
+}
+
+func TestCoalesceSharesResponse(t *testing.T) {
+	r := &CountingResource{started: make(chan bool), release: make(chan bool)}
+	results := coalescedRequests(r, Coalesce(r), 5)
+
+	if r.calls != 1 {
+		t.Errorf("Handler should run once for identical requests, ran %d times", r.calls)
+	}
+	for i, res := range results {
+		if res.Body.String() != "response" {
+			t.Errorf("Request %d got body '%s'", i, res.Body.String())
+		}
+		if res.Header().Get("X-Test") != "shared" {
+			t.Errorf("Request %d didn't get the shared headers", i)
+		}
+	}
+}
+
+func TestCoalesceSharesErrors(t *testing.T) {
+	r := &CountingResource{started: make(chan bool), release: make(chan bool), err: ErrorNotFound()}
+	results := coalescedRequests(r, Coalesce(r), 3)
+
+	for i, res := range results {
+		if res.Code != 404 {
+			t.Errorf("Request %d: expected the error status 404, got %d", i, res.Code)
+		}
+	}
+}
+
+func TestCoalesceKeyVary(t *testing.T) {
+	cr := Coalesce(new(MockResource), "Accept-Language")
+
+	req1, _ := http.NewRequest("GET", "http://localhost:8080/test?a=1", nil)
+	req1.Header.Set("Accept-Language", "en")
+	req2, _ := http.NewRequest("GET", "http://localhost:8080/test?a=1", nil)
+	req2.Header.Set("Accept-Language", "es")
+	req3, _ := http.NewRequest("GET", "http://localhost:8080/test?a=2", nil)
+	req3.Header.Set("Accept-Language", "en")
+
+	k1 := cr.key(NewContext(req1, httptest.NewRecorder()))
+	k2 := cr.key(NewContext(req2, httptest.NewRecorder()))
+	k3 := cr.key(NewContext(req3, httptest.NewRecorder()))
+
+	if k1 == k2 {
+		t.Error("Requests with different Vary headers shouldn't be coalesced")
+	}
+	if k1 == k3 {
+		t.Error("Requests with different query strings shouldn't be coalesced")
+	}
+}
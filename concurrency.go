@@ -0,0 +1,266 @@
+package yarf
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// concurrencyKey is the Context storage key for the slots held by a request.
+type concurrencyKey struct {
+	l *ConcurrencyLimiter
+}
+
+// concurrencyState is the slots held by a request, and the route load they're counted on.
+type concurrencyState struct {
+	held    []*semaphore
+	pattern string
+	load    *RouteLoad
+}
+
+// RouteLoad counts the requests of a route going through a ConcurrencyLimiter.
+type RouteLoad struct {
+	InFlight int64 `json:"in_flight"` // Requests holding their slots
+	Queued   int64 `json:"queued"`    // Requests waiting in queue for a slot
+}
+
+// semaphore is a counting semaphore with a bounded wait queue.
+type semaphore struct {
+	slots   chan struct{}
+	waiting int32
+}
+
+func newSemaphore(size int) *semaphore {
+	return &semaphore{
+		slots: make(chan struct{}, size),
+	}
+}
+
+// acquire tries to take a slot, waiting in queue if there is room for it.
+// Returns false when the queue is full, the timeout expires or the request is cancelled.
+// The wait function, if any, is called with 1 when the request enters the queue and -1 when it leaves it.
+func (s *semaphore) acquire(c *Context, queue int, timeout time.Duration, wait func(int64)) bool {
+	// Fast path
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	// Bounded queue
+	if atomic.AddInt32(&s.waiting, 1) > int32(queue) {
+		atomic.AddInt32(&s.waiting, -1)
+		return false
+	}
+	defer atomic.AddInt32(&s.waiting, -1)
+
+	if wait != nil {
+		wait(1)
+		defer wait(-1)
+	}
+
+	var expire <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expire = t.C
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	case <-expire:
+		return false
+	case <-c.Request.Context().Done():
+		return false
+	}
+}
+
+// release frees a slot taken by acquire.
+func (s *semaphore) release() {
+	<-s.slots
+}
+
+// ConcurrencyLimiter is a middleware that caps the number of requests being processed at the same time,
+// both for all the routes it covers and for each single route.
+// Requests exceeding the limits wait in a bounded queue, and get a 503 error when the queue is full.
+// Insert it on the Yarf object to apply the limits globally, or into a group to apply them to its routes only.
+// Global limiters, added with Yarf.Use, run before the routes are matched, so they look up the route of each request
+// themselves, through the route cache, to apply the route limits and count the route load.
+// Requests not matching any route only take global slots.
+// The requests in flight and in queue of each route are reported by Load(), the yarf_route_in_flight
+// and yarf_route_queued gauges, and on the debug dashboard when set as its Limiter field.
+type ConcurrencyLimiter struct {
+	Middleware
+
+	// MaxInFlight is the maximum number of concurrent requests for all routes. 0 means no limit.
+	MaxInFlight int
+
+	// MaxPerRoute is the maximum number of concurrent requests for each route. 0 means no limit.
+	MaxPerRoute int
+
+	// MaxQueue is the maximum number of requests waiting for a slot on each limit.
+	MaxQueue int
+
+	// QueueTimeout is the maximum time a request waits in queue. 0 means wait until a slot is free.
+	QueueTimeout time.Duration
+
+	global *semaphore
+	routes map[*route]*semaphore
+	load   map[string]*RouteLoad
+
+	sync.Mutex
+}
+
+// NewConcurrencyLimiter creates a new ConcurrencyLimiter with the global limit and queue size provided.
+func NewConcurrencyLimiter(maxInFlight, maxQueue int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		MaxInFlight: maxInFlight,
+		MaxQueue:    maxQueue,
+	}
+}
+
+// route returns the route of the request and its pattern, or nil if no route matches.
+// Global limiters run before the route is matched, so they take it from the route cache,
+// or match it on a scratch Context, leaving the request one untouched, and cache it for the dispatch.
+func (l *ConcurrencyLimiter) route(c *Context) (*route, string) {
+	if c.route != nil || c.app == nil {
+		return c.route, c.RoutePattern()
+	}
+
+	path, ok := c.app.routePath(c.Request.URL.Path)
+	if !ok {
+		return nil, ""
+	}
+	if c.app.UseCache {
+		if cache, ok := c.app.cache.Get(path); ok {
+			return leafRoute(cache.route), routePattern(cache.route)
+		}
+	}
+	m := NewContext(c.Request, c.Response)
+	m.app = c.app
+	if !c.app.matchRoute(path, m) {
+		return nil, ""
+	}
+
+	return m.route, routePattern(m.groupDispatch)
+}
+
+// semaphores returns the route and global semaphores applying to the request, in acquisition order.
+// The route slot is taken first, so the requests queued on a busy route don't hold global slots.
+func (l *ConcurrencyLimiter) semaphores(r *route) (sems []*semaphore) {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.MaxPerRoute > 0 && r != nil {
+		if l.routes == nil {
+			l.routes = make(map[*route]*semaphore)
+		}
+		s, ok := l.routes[r]
+		if !ok {
+			s = newSemaphore(l.MaxPerRoute)
+			l.routes[r] = s
+		}
+		sems = append(sems, s)
+	}
+
+	if l.MaxInFlight > 0 {
+		if l.global == nil {
+			l.global = newSemaphore(l.MaxInFlight)
+		}
+		sems = append(sems, l.global)
+	}
+
+	return
+}
+
+// routeLoad returns the load counters of the route pattern.
+func (l *ConcurrencyLimiter) routeLoad(pattern string) *RouteLoad {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.load == nil {
+		l.load = make(map[string]*RouteLoad)
+	}
+	rl, ok := l.load[pattern]
+	if !ok {
+		rl = new(RouteLoad)
+		l.load[pattern] = rl
+	}
+
+	return rl
+}
+
+// Load returns the requests in flight and in queue of each route pattern served.
+func (l *ConcurrencyLimiter) Load() map[string]RouteLoad {
+	l.Lock()
+	defer l.Unlock()
+
+	load := make(map[string]RouteLoad, len(l.load))
+	for pattern, rl := range l.load {
+		load[pattern] = RouteLoad{
+			InFlight: atomic.LoadInt64(&rl.InFlight),
+			Queued:   atomic.LoadInt64(&rl.Queued),
+		}
+	}
+
+	return load
+}
+
+// PreDispatch takes the slots needed by the request or returns a 503 error on overflow.
+func (l *ConcurrencyLimiter) PreDispatch(c *Context) error {
+	var held []*semaphore
+
+	r, pattern := l.route(c)
+	var rl *RouteLoad
+	var wait func(int64)
+	if r != nil {
+		rl = l.routeLoad(pattern)
+		queued := c.metrics().Gauge("yarf_route_queued", "Requests waiting for a concurrency slot.", "route")
+		wait = func(delta int64) {
+			atomic.AddInt64(&rl.Queued, delta)
+			queued.Add(float64(delta), pattern)
+		}
+	}
+
+	for _, s := range l.semaphores(r) {
+		if !s.acquire(c, l.MaxQueue, l.QueueTimeout, wait) {
+			for _, h := range held {
+				h.release()
+			}
+			if r != nil {
+				c.metrics().Counter("yarf_concurrency_rejected_total", "Requests rejected by the concurrency limits.", "route").Add(1, pattern)
+			}
+			return ErrorServiceUnavailable()
+		}
+		held = append(held, s)
+	}
+
+	c.set(concurrencyKey{l}, &concurrencyState{held: held, pattern: pattern, load: rl})
+
+	if rl != nil {
+		atomic.AddInt64(&rl.InFlight, 1)
+		c.metrics().Gauge("yarf_route_in_flight", "Requests holding a concurrency slot.", "route").Add(1, pattern)
+	}
+
+	return nil
+}
+
+// End releases the slots held by the request, and uncounts it from the route load it was counted on.
+func (l *ConcurrencyLimiter) End(c *Context) error {
+	s, ok := c.get(concurrencyKey{l}).(*concurrencyState)
+	if !ok {
+		return nil
+	}
+	for _, h := range s.held {
+		h.release()
+	}
+	c.set(concurrencyKey{l}, nil)
+
+	if s.load != nil {
+		atomic.AddInt64(&s.load.InFlight, -1)
+		c.metrics().Gauge("yarf_route_in_flight", "Requests holding a concurrency slot.", "route").Add(-1, s.pattern)
+	}
+
+	return nil
+}
@@ -0,0 +1,278 @@
+package yarf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type BlockingResource struct {
+	Resource
+
+	started chan bool
+	release chan bool
+}
+
+func (r *BlockingResource) Get(c *Context) error {
+	r.started <- true
+	<-r.release
+
+	return nil
+}
+
+func TestConcurrencyLimiterOverflow(t *testing.T) {
+	r := &BlockingResource{started: make(chan bool), release: make(chan bool)}
+
+	y := New()
+	y.Add("/block", r)
+	y.Insert(NewConcurrencyLimiter(1, 0))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		req, _ := http.NewRequest("GET", "http://localhost:8080/block", nil)
+		y.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	<-r.started
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/block", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 503 {
+		t.Errorf("Request over the limit should return 503, got %d", res.Code)
+	}
+
+	r.release <- true
+	wg.Wait()
+
+	// Slot should be free again
+	go func() {
+		<-r.started
+		r.release <- true
+	}()
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 200 {
+		t.Errorf("Request after release should return 200, got %d", res.Code)
+	}
+}
+
+func TestConcurrencyLimiterQueue(t *testing.T) {
+	r := &BlockingResource{started: make(chan bool), release: make(chan bool)}
+
+	l := NewConcurrencyLimiter(1, 1)
+	l.QueueTimeout = time.Second
+
+	y := New()
+	y.Add("/block", r)
+	y.Insert(l)
+
+	codes := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			req, _ := http.NewRequest("GET", "http://localhost:8080/block", nil)
+			res := httptest.NewRecorder()
+			y.ServeHTTP(res, req)
+			codes <- res.Code
+		}()
+	}
+
+	// First request runs while the second one waits in queue.
+	<-r.started
+	r.release <- true
+	<-r.started
+	r.release <- true
+
+	for i := 0; i < 2; i++ {
+		if code := <-codes; code != 200 {
+			t.Errorf("Queued request should return 200, got %d", code)
+		}
+	}
+}
+
+func TestConcurrencyLimiterPerRoute(t *testing.T) {
+	r := &BlockingResource{started: make(chan bool), release: make(chan bool)}
+
+	l := new(ConcurrencyLimiter)
+	l.MaxPerRoute = 1
+
+	y := New()
+	y.Add("/block", r)
+	y.Add("/free", new(MockResource))
+	y.Insert(l)
+
+	done := make(chan bool)
+	go func() {
+		req, _ := http.NewRequest("GET", "http://localhost:8080/block", nil)
+		y.ServeHTTP(httptest.NewRecorder(), req)
+		done <- true
+	}()
+	<-r.started
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/free", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code == 503 {
+		t.Error("Per route limit shouldn't affect other routes")
+	}
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/block", nil)
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 503 {
+		t.Errorf("Request over the route limit should return 503, got %d", res.Code)
+	}
+
+	r.release <- true
+	<-done
+}
+
+func TestConcurrencyLimiterLoad(t *testing.T) {
+	r := &BlockingResource{started: make(chan bool), release: make(chan bool)}
+
+	l := NewConcurrencyLimiter(1, 1)
+	m := newTestMetrics()
+
+	y := New()
+	y.Metrics = m
+	y.Add("/block", r)
+	y.Insert(l)
+	y.EnableDashboard("/_dashboard").Limiter = l
+
+	done := make(chan bool)
+	for i := 0; i < 2; i++ {
+		go func() {
+			req, _ := http.NewRequest("GET", "http://localhost:8080/block", nil)
+			y.ServeHTTP(httptest.NewRecorder(), req)
+			done <- true
+		}()
+	}
+	<-r.started
+
+	// Wait for the second request to be queued
+	for i := 0; l.Load()["/block"].Queued != 1; i++ {
+		if i == 100 {
+			t.Fatalf("Expected a queued request, got %+v", l.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if load := l.Load()["/block"]; load.InFlight != 1 {
+		t.Errorf("Unexpected load %+v", load)
+	}
+	if m.get("yarf_route_in_flight{/block}") != 1 || m.get("yarf_route_queued{/block}") != 1 {
+		t.Errorf("Unexpected gauges %v", m.values)
+	}
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/_dashboard", nil))
+	var s DashboardSnapshot
+	json.Unmarshal(res.Body.Bytes(), &s)
+	for _, dr := range s.Routes {
+		if dr.Pattern == "/block" && (dr.InFlight != 1 || dr.Queued != 1) {
+			t.Errorf("Unexpected dashboard route %+v", dr)
+		}
+	}
+
+	r.release <- true
+	<-r.started
+	r.release <- true
+	<-done
+	<-done
+
+	if load := l.Load()["/block"]; load.InFlight != 0 || load.Queued != 0 {
+		t.Errorf("Unexpected load after the requests %+v", load)
+	}
+	if m.get("yarf_route_in_flight{/block}") != 0 || m.get("yarf_route_queued{/block}") != 0 {
+		t.Errorf("Unexpected gauges after the requests %v", m.values)
+	}
+}
+
+func TestConcurrencyLimiterGlobalPerRoute(t *testing.T) {
+	r := &BlockingResource{started: make(chan bool), release: make(chan bool)}
+
+	l := NewConcurrencyLimiter(2, 5)
+	l.MaxPerRoute = 1
+	l.QueueTimeout = 100 * time.Millisecond
+
+	y := New()
+	y.Add("/block", r)
+	y.Add("/free", new(OKResource))
+	y.Use(l)
+
+	codes := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			res := httptest.NewRecorder()
+			y.ServeHTTP(res, httptest.NewRequest("GET", "/block", nil))
+			codes <- res.Code
+		}()
+	}
+	<-r.started
+
+	// Wait for the second request to be queued on the route slot
+	for i := 0; l.Load()["/block"].Queued != 1; i++ {
+		if i == 100 {
+			t.Fatalf("Expected a queued request, got %+v", l.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The queued request doesn't hold a global slot
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/free", nil))
+	if res.Code != 200 {
+		t.Errorf("Requests queued on a route shouldn't starve other routes, got %d", res.Code)
+	}
+
+	if code := <-codes; code != 503 {
+		t.Errorf("Expected the request queued on the global route limit to time out, got %d", code)
+	}
+	r.release <- true
+	if code := <-codes; code != 200 {
+		t.Errorf("Expected the first request to succeed, got %d", code)
+	}
+}
+
+func TestConcurrencyLimiterLoadKeys(t *testing.T) {
+	l := NewConcurrencyLimiter(10, 0)
+
+	y := New()
+	y.Add("/items/:id", new(OKResource))
+	y.Use(l)
+
+	for _, path := range []string{"/items/1", "/missing"} {
+		y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	for pattern, load := range l.Load() {
+		if load.InFlight != 0 || load.Queued != 0 {
+			t.Errorf("%q: expected the load drained, got %+v", pattern, load)
+		}
+	}
+	if _, ok := l.Load()["/items/:id"]; !ok || len(l.Load()) != 1 {
+		t.Errorf("Expected the load counted on the route pattern only, got %+v", l.Load())
+	}
+
+	// The route looked up by the limiter is cached for the dispatch
+	if len(y.cache.storage) != 1 {
+		t.Errorf("Expected 1 cached route, got %d", len(y.cache.storage))
+	}
+	y.Add("/other", new(OKResource))
+	c := NewContext(httptest.NewRequest("GET", "/other", nil), httptest.NewRecorder())
+	y.Match("/other", c)
+	y.cache.Set("/items/1", newRouteCache(c))
+
+	testRequest(y, "GET", "/items/1", nil)
+	if _, ok := l.Load()["/other"]; !ok {
+		t.Errorf("Expected the load counted on the cached route, got %+v", l.Load())
+	}
+}
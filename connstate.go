@@ -0,0 +1,88 @@
+package yarf
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+// ConnStats is a snapshot of the connections and requests handled by the servers of a Yarf instance.
+type ConnStats struct {
+	Open     int64 // Open connections, in any state
+	Active   int64 // Connections reading or serving a request
+	Idle     int64 // Keep-alive connections waiting for a new request
+	InFlight int64 // Requests being served
+}
+
+// connState keeps the connection counters of the Yarf servers.
+type connState struct {
+	states   map[net.Conn]http.ConnState
+	open     int64
+	active   int64
+	idle     int64
+	inFlight int64
+}
+
+// OnConnState registers a function to be called when a client connection changes state,
+// as with http.Server.ConnState. Use it to observe active and idle connections.
+// Register the hooks before starting the servers.
+func (y *Yarf) OnConnState(fn func(net.Conn, http.ConnState)) {
+	y.lock.Lock()
+	defer y.lock.Unlock()
+
+	y.onConnState = append(y.onConnState, fn)
+}
+
+// trackConn updates the connection counters and runs the OnConnState hooks.
+// It's set as the ConnState callback of the servers started by Yarf.
+func (y *Yarf) trackConn(conn net.Conn, state http.ConnState) {
+	y.lock.Lock()
+	cs := &y.conns
+	if cs.states == nil {
+		cs.states = make(map[net.Conn]http.ConnState)
+	}
+
+	prev, known := cs.states[conn]
+	if known {
+		cs.count(prev, -1)
+	}
+
+	switch state {
+	case http.StateHijacked, http.StateClosed:
+		delete(cs.states, conn)
+	default:
+		cs.states[conn] = state
+		cs.count(state, 1)
+	}
+
+	hooks := y.onConnState
+	y.lock.Unlock()
+
+	for _, fn := range hooks {
+		fn(conn, state)
+	}
+}
+
+// count adds n to the counters of the state.
+func (cs *connState) count(state http.ConnState, n int64) {
+	atomic.AddInt64(&cs.open, n)
+
+	switch state {
+	case http.StateActive:
+		atomic.AddInt64(&cs.active, n)
+	case http.StateIdle:
+		atomic.AddInt64(&cs.idle, n)
+	}
+}
+
+// ConnStats returns the current connection counters and the number of requests in flight.
+// Connection counters include only servers started by Yarf, while requests in flight include all the requests
+// served by the instance. During shutdown, operators can watch them drain after deregistering from load balancers.
+func (y *Yarf) ConnStats() ConnStats {
+	return ConnStats{
+		Open:     atomic.LoadInt64(&y.conns.open),
+		Active:   atomic.LoadInt64(&y.conns.active),
+		Idle:     atomic.LoadInt64(&y.conns.idle),
+		InFlight: atomic.LoadInt64(&y.conns.inFlight),
+	}
+}
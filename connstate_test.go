@@ -0,0 +1,61 @@
+package yarf
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestConnStats(t *testing.T) {
+	r := &BlockingResource{started: make(chan bool), release: make(chan bool)}
+
+	y := New()
+	y.Add("/block", r)
+
+	var lock sync.Mutex
+	states := map[http.ConnState]int{}
+	y.OnConnState(func(c net.Conn, s http.ConnState) {
+		lock.Lock()
+		defer lock.Unlock()
+		states[s]++
+	})
+
+	address := freeAddress(t)
+	go y.Start(address)
+	waitServer(t, address)
+
+	done := make(chan bool)
+	go func() {
+		res, err := http.Get("http://" + address + "/block")
+		if err == nil {
+			ioutil.ReadAll(res.Body)
+			res.Body.Close()
+		}
+		done <- true
+	}()
+	<-r.started
+
+	stats := y.ConnStats()
+	if stats.InFlight != 1 || stats.Active != 1 {
+		t.Errorf("Expected 1 request in flight on 1 active connection, got %+v", stats)
+	}
+
+	r.release <- true
+	<-done
+
+	y.Shutdown(context.Background())
+
+	stats = y.ConnStats()
+	if stats.InFlight != 0 || stats.Active != 0 {
+		t.Errorf("Expected no active requests after shutdown, got %+v", stats)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if states[http.StateNew] == 0 || states[http.StateActive] == 0 {
+		t.Errorf("OnConnState hooks should be called, got %v", states)
+	}
+}
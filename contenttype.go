@@ -0,0 +1,53 @@
+package yarf
+
+import (
+	"net/http"
+)
+
+// MetaContentType is the RouteMeta key holding the default response Content-Type of the route.
+const MetaContentType = "content_type"
+
+// ContentType declares the Content-Type of the route responses that don't set one,
+// and returns the RouteMeta to allow chaining.
+//
+//	y.AddRoute("/feed", new(Feed)).ContentType("application/atom+xml")
+func (m *RouteMeta) ContentType(ct string) *RouteMeta {
+	return m.Set(MetaContentType, ct)
+}
+
+// ContentType declares the Content-Type of the responses that don't set one, for all the routes in the group.
+// Nested groups and routes can declare their own.
+func (g *GroupRoute) ContentType(ct string) {
+	g.contentType = ct
+}
+
+// contentTypeKey is the Context storage key for the default Content-Type of the matched route.
+type contentTypeKey struct{}
+
+// setContentType selects the default Content-Type for the request.
+func setContentType(c *Context, ct string) {
+	if ct != "" {
+		c.set(contentTypeKey{}, ct)
+	}
+}
+
+// defaultContentType sets the default Content-Type on responses sent without one, right before the headers are sent.
+// Responses without body, and the ones explicitly disabling it with a nil Content-Type, are left alone.
+func defaultContentType(c *Context, code int) {
+	if code == http.StatusNoContent || code == http.StatusNotModified || code == http.StatusSwitchingProtocols {
+		return
+	}
+
+	h := c.Response.Header()
+	if _, ok := h["Content-Type"]; ok {
+		return
+	}
+
+	ct, _ := c.get(contentTypeKey{}).(string)
+	if ct == "" && c.app != nil {
+		ct = c.app.ContentType
+	}
+	if ct != "" {
+		h.Set("Content-Type", ct)
+	}
+}
@@ -0,0 +1,64 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type ContentTypeResource struct {
+	Resource
+}
+
+func (r *ContentTypeResource) Get(c *Context) error {
+	switch c.Request.URL.Query().Get("case") {
+	case "set":
+		c.Response.Header().Set("Content-Type", "text/csv")
+	case "none":
+		c.Response.Header()["Content-Type"] = nil
+	case "empty":
+		c.Status(http.StatusNoContent)
+		return nil
+	}
+	c.Render("{}")
+
+	return nil
+}
+
+func TestDefaultContentType(t *testing.T) {
+	y := New()
+	y.Add("/plain", new(ContentTypeResource))
+	y.AddRoute("/feed", new(ContentTypeResource)).ContentType("application/atom+xml")
+
+	g := RouteGroup("/v1")
+	g.ContentType("application/vnd.api+json")
+	g.Add("/items", new(ContentTypeResource))
+	y.AddGroup(g)
+
+	get := func(url string) string {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+url, nil)
+		y.ServeHTTP(res, req)
+		return res.Header().Get("Content-Type")
+	}
+
+	// No default: the Content-Type is detected from the body
+	if ct := get("/plain"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Expected detected Content-Type without default, got %q", ct)
+	}
+
+	y.ContentType = "application/json; charset=utf-8"
+	for url, expected := range map[string]string{
+		"/plain":            "application/json; charset=utf-8",
+		"/plain?case=set":   "text/csv",
+		"/plain?case=none":  "",
+		"/plain?case=empty": "",
+		"/feed":             "application/atom+xml",
+		"/v1/items":         "application/vnd.api+json",
+		"/nope":             "application/json; charset=utf-8",
+	} {
+		if ct := get(url); ct != expected {
+			t.Errorf("%s: expected %q, got %q", url, expected, ct)
+		}
+	}
+}
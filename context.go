@@ -1,7 +1,9 @@
 package yarf
 
 import (
+	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"net/http"
@@ -53,6 +55,9 @@ func (p Params) Del(key string) {
 // Context is the data/status storage of every YARF request.
 // Every request will instantiate a new Context object and fill in with all the request data.
 // Each request Context will be shared along the entire request life to ensure accesibility of its data at all levels.
+// Context objects are reused between requests when Yarf.UsePool is enabled (default),
+// so neither the Context nor its Params should be kept after the request ends.
+// Copy the values needed by goroutines that outlive the request.
 type Context struct {
 	// The *http.Request object as received by the HandleFunc.
 	Request *http.Request
@@ -68,6 +73,28 @@ type Context struct {
 
 	// Group route storage for dispatch
 	groupDispatch []Router
+
+	// Params collected while matching, copied into Params once a route matches
+	candidate Params
+
+	// Final route matched by the request
+	route *route
+
+	// Routers matched by the request, from the final route to the outer group.
+	// It keeps the full groupDispatch list, which dispatch shortens without overwriting.
+	matched []Router
+
+	// Error that stopped the request flow
+	err error
+
+	// Yarf instance serving the request
+	app *Yarf
+
+	// Context of the original request, cancelled when the client goes away
+	client context.Context
+
+	// Internal storage for framework components
+	values map[interface{}]interface{}
 }
 
 // NewContext creates a new *Context object with default values and returns it.
@@ -79,11 +106,136 @@ func NewContext(r *http.Request, rw http.ResponseWriter) *Context {
 	}
 }
 
+// reset clears the Context to be reused for a new request.
+func (c *Context) reset(r *http.Request, rw http.ResponseWriter) {
+	c.Request = r
+	c.Response = rw
+	c.Data = nil
+	c.route = nil
+	c.matched = nil
+	c.err = nil
+	c.app = nil
+	c.client = nil
+	c.groupDispatch = c.groupDispatch[:0]
+
+	if c.Params == nil {
+		c.Params = Params{}
+	}
+	for k := range c.Params {
+		delete(c.Params, k)
+	}
+	for k := range c.candidate {
+		delete(c.candidate, k)
+	}
+	for k := range c.values {
+		delete(c.values, k)
+	}
+}
+
+// set stores framework internal data for the request life.
+func (c *Context) set(key, value interface{}) {
+	if c.values == nil {
+		c.values = make(map[interface{}]interface{})
+	}
+
+	c.values[key] = value
+}
+
+// get retrieves framework internal data stored by set.
+func (c *Context) get(key interface{}) interface{} {
+	if c.values == nil {
+		return nil
+	}
+
+	return c.values[key]
+}
+
+// RouteMeta returns the metadata of the route matched by the request.
+// Returns nil if no route matched, which is safe to use with all RouteMeta methods.
+func (c *Context) RouteMeta() *RouteMeta {
+	if c.route == nil {
+		return nil
+	}
+
+	return c.route.meta
+}
+
+// RoutePattern returns the full pattern of the route matched by the request, like "/users/:id".
+// It returns an empty string if no route matched.
+func (c *Context) RoutePattern() string {
+	return routePattern(c.matched)
+}
+
+// Err returns the error that stopped the request flow, if any.
+// It's mostly useful for End middleware, as they run even after errors.
+func (c *Context) Err() error {
+	return c.err
+}
+
 // Status sets the HTTP status code to be returned on the response.
 func (c *Context) Status(code int) {
 	c.Response.WriteHeader(code)
 }
 
+// Created sends a 201 response with the Location of the new resource.
+// The body is written as is for strings and []byte, encoded with RenderJSON for other values, and skipped if nil.
+func (c *Context) Created(location string, body interface{}) {
+	if location != "" {
+		c.Response.Header().Set("Location", c.location(location))
+	}
+	c.renderStatus(http.StatusCreated, body)
+}
+
+// Accepted sends a 202 response for requests processed asynchronously,
+// with the Location of the status resource the client can poll, if any.
+func (c *Context) Accepted(statusURL string) {
+	if statusURL != "" {
+		c.Response.Header().Set("Location", c.location(statusURL))
+	}
+	c.Response.WriteHeader(http.StatusAccepted)
+}
+
+// NoContent sends a 204 response without body.
+func (c *Context) NoContent() {
+	c.clearContentHeaders()
+	c.Response.WriteHeader(http.StatusNoContent)
+}
+
+// NotModified sends a 304 response without body, for conditional requests matching the current resource.
+// Validators like ETag and caching headers set before are kept.
+func (c *Context) NotModified() {
+	c.clearContentHeaders()
+	c.Response.WriteHeader(http.StatusNotModified)
+}
+
+// clearContentHeaders removes the headers describing a body, for responses without one.
+func (c *Context) clearContentHeaders() {
+	h := c.Response.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	h.Del("Content-Encoding")
+}
+
+// renderStatus writes the status code and the body.
+func (c *Context) renderStatus(code int, body interface{}) {
+	switch b := body.(type) {
+	case nil:
+		c.Response.WriteHeader(code)
+	case string:
+		c.Response.WriteHeader(code)
+		c.Render(b)
+	case []byte:
+		c.Response.WriteHeader(code)
+		c.Response.Write(b)
+	default:
+		if c.Response.Header().Get("Content-Type") == "" {
+			c.Response.Header().Set("Content-Type", "application/json")
+		}
+		c.Response.WriteHeader(code)
+		c.RenderJSON(b)
+	}
+}
+
 // Param is a wrapper for c.Params.Get()
 func (c *Context) Param(name string) string {
 	return c.Params.Get(name)
@@ -103,7 +255,12 @@ func (c *Context) QueryValue(name string) string {
 
 // GetClientIP retrieves the client IP address from the request information.
 // It detects common proxy headers to return the actual client's IP and not the proxy's.
+// If trusted proxies are configured with Yarf.TrustProxies(), only the headers set by them are used.
 func (c *Context) GetClientIP() (ip string) {
+	if t, _ := c.proxied(); t != nil {
+		return c.trustedClientIP(t)
+	}
+
 	var pIPs string
 	var pIPList []string
 
@@ -139,9 +296,12 @@ func (c *Context) GetClientIP() (ip string) {
 }
 
 // Redirect sends the corresponding HTTP redirect response with the provided URL and status code.
-// It's just a wrapper for net/http.Redirect()
+// It's a wrapper for net/http.Redirect(), so relative URLs are resolved against the request path,
+// and made absolute with the base URL when one is set with SetBaseURL().
+// Codes that aren't redirections are replaced by 302 Found.
+// To stop the request flow after redirecting, return ErrorRedirect() or RedirectToRoute() instead.
 func (c *Context) Redirect(url string, code int) {
-	http.Redirect(c.Response, c.Request, url, code)
+	c.redirect(url, code)
 }
 
 // Render writes a string to the http.ResponseWriter.
@@ -179,13 +339,14 @@ func (c *Context) RenderGzip(content []byte) error {
 }
 
 // RenderJSON takes a interface{} object and writes the JSON encoded string of it.
+// When partial responses are enabled, the encoded JSON is pruned to the fields requested by the client.
 func (c *Context) RenderJSON(data interface{}) {
 	// Set content
 	encoded, err := json.Marshal(data)
 	if err != nil {
 		c.Response.Write([]byte(err.Error()))
 	} else {
-		c.Response.Write(encoded)
+		c.Response.Write(c.Fields().PruneJSON(encoded))
 	}
 }
 
@@ -195,9 +356,18 @@ func (c *Context) RenderJSONIndent(data interface{}) {
 	encoded, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		c.Response.Write([]byte(err.Error()))
-	} else {
-		c.Response.Write(encoded)
+		return
+	}
+
+	// Partial responses
+	if mask := c.Fields(); mask != nil {
+		var buf bytes.Buffer
+		if json.Indent(&buf, mask.PruneJSON(encoded), "", "  ") == nil {
+			encoded = buf.Bytes()
+		}
 	}
+
+	c.Response.Write(encoded)
 }
 
 // RenderGzipJSON takes a interface{} object and writes the JSON verion through RenderGzip.
@@ -208,7 +378,7 @@ func (c *Context) RenderGzipJSON(data interface{}) {
 		c.Response.Write([]byte(err.Error()))
 	}
 
-	c.RenderGzip(encoded)
+	c.RenderGzip(c.Fields().PruneJSON(encoded))
 }
 
 // RenderXML takes a interface{} object and writes the XML encoded string of it.
@@ -122,3 +122,79 @@ func TestRenderXMLIndent(t *testing.T) {
 		t.Errorf("'%s' sent to RenderXMLIndent() method, '%s' found on Response object", "TEST", c.Response.(*httptest.ResponseRecorder).Body.String())
 	}
 }
+
+type PatternResource struct {
+	Resource
+}
+
+func (r *PatternResource) Get(c *Context) error {
+	c.Render(c.RoutePattern())
+	return nil
+}
+
+func TestRoutePattern(t *testing.T) {
+	inner := RouteGroup("/v1")
+	inner.Add("/users/:id", new(PatternResource))
+
+	g := RouteGroup("/api")
+	g.AddGroup(inner)
+
+	y := New()
+	y.AddGroup(g)
+
+	// Twice, to go through the route cache
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "http://localhost:8080/api/v1/users/7", nil)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Body.String() != "/api/v1/users/:id" {
+			t.Errorf("Expected pattern '/api/v1/users/:id', got '%s'", res.Body.String())
+		}
+	}
+}
+
+func TestStatusHelpers(t *testing.T) {
+	tests := []struct {
+		name     string
+		fn       func(c *Context)
+		status   int
+		location string
+		ct       string
+		body     string
+	}{
+		{"created", func(c *Context) { c.Created("/users/1", map[string]int{"id": 1}) }, 201, "/users/1", "application/json", `{"id":1}`},
+		{"created string", func(c *Context) { c.Created("/users/1", "done") }, 201, "/users/1", "", "done"},
+		{"created bytes", func(c *Context) { c.Created("", []byte("raw")) }, 201, "", "", "raw"},
+		{"created empty", func(c *Context) { c.Created("/users/1", nil) }, 201, "/users/1", "", ""},
+		{"accepted", func(c *Context) { c.Accepted("/jobs/5") }, 202, "/jobs/5", "", ""},
+		{"no content", func(c *Context) {
+			c.Response.Header().Set("Content-Type", "application/json")
+			c.NoContent()
+		}, 204, "", "", ""},
+		{"not modified", func(c *Context) {
+			c.Response.Header().Set("Content-Type", "application/json")
+			c.Response.Header().Set("ETag", `"v1"`)
+			c.NotModified()
+		}, 304, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		res := httptest.NewRecorder()
+		c := NewContext(httptest.NewRequest("GET", "/", nil), res)
+		tt.fn(c)
+
+		if res.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, res.Code)
+		}
+		if l := res.Header().Get("Location"); l != tt.location {
+			t.Errorf("%s: expected Location %q, got %q", tt.name, tt.location, l)
+		}
+		if ct := res.Header().Get("Content-Type"); ct != tt.ct {
+			t.Errorf("%s: expected Content-Type %q, got %q", tt.name, tt.ct, ct)
+		}
+		if res.Body.String() != tt.body {
+			t.Errorf("%s: expected body %q, got %q", tt.name, tt.body, res.Body.String())
+		}
+	}
+}
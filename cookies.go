@@ -0,0 +1,225 @@
+package yarf
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Secure cookie errors
+var (
+	ErrInvalidCookie = errors.New("yarf: invalid cookie")
+	ErrExpiredCookie = errors.New("yarf: expired cookie")
+	ErrCookieTooLong = errors.New("yarf: cookie too long")
+)
+
+// maxCookieSize is the size limit of cookie values accepted by browsers.
+const maxCookieSize = 4096
+
+// SecureCookie encrypts and authenticates cookie values with AES-GCM, for stateless sessions and remember-me tokens.
+// Values are encoded as JSON with their creation time, and bound to the cookie name,
+// so they can't be read, changed or moved to another cookie by the client.
+//
+// Keys form a ring for rotation: values are encrypted with the newest key and decrypted with any of them,
+// so a new key can be added while the cookies encrypted with the previous ones stay valid.
+//
+//	sc, err := yarf.NewSecureCookie(newKey, oldKey)
+//	c.SetSecureCookie(sc, "session", Session{UserID: id})
+//
+//	var s Session
+//	if err := c.SecureCookie(sc, "session", &s); err != nil { ... }
+type SecureCookie struct {
+	// MaxAge is the lifetime of the values, and the Max-Age of the cookies. 0 makes session cookies without expiration.
+	MaxAge time.Duration
+
+	// Path, Domain, Secure, HTTPOnly and SameSite are the attributes of the cookies.
+	Path     string
+	Domain   string
+	Secure   bool
+	HTTPOnly bool
+	SameSite http.SameSite
+
+	// Clock tells the time to date the values and check their MaxAge. nil uses the system clock.
+	Clock Clock
+
+	keys []cipher.AEAD
+	lock sync.RWMutex
+}
+
+// NewSecureCookie creates a SecureCookie with the keys provided, the newest first.
+// Keys must be 16, 24 or 32 bytes long, for AES-128, AES-192 or AES-256.
+// Cookies are secure, HTTP only, SameSite=Lax and valid for the whole site by default.
+func NewSecureCookie(keys ...[]byte) (*SecureCookie, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("yarf: secure cookie without keys")
+	}
+
+	s := &SecureCookie{
+		Path:     "/",
+		Secure:   true,
+		HTTPOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	for i := len(keys) - 1; i >= 0; i-- {
+		if err := s.Rotate(keys[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Rotate adds a new key to encrypt the values. Previous keys are kept to decrypt existing cookies
+// until RetireKeys() removes them.
+func (s *SecureCookie) Rotate(key []byte) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.keys = append([]cipher.AEAD{aead}, s.keys...)
+
+	return nil
+}
+
+// RetireKeys removes the oldest keys, keeping the n newest ones.
+// Cookies encrypted with the removed keys become invalid.
+func (s *SecureCookie) RetireKeys(n int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if n > 0 && n < len(s.keys) {
+		s.keys = s.keys[:n]
+	}
+}
+
+// Encode encrypts a value for the cookie name provided.
+func (s *SecureCookie) Encode(name string, v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	s.lock.RLock()
+	aead := s.keys[0]
+	s.lock.RUnlock()
+
+	// Creation time, then the JSON value
+	plain := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint64(plain, uint64(clockNow(s.Clock).Unix()))
+	plain = append(plain, data...)
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	value := base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plain, []byte(name)))
+	if len(name)+len(value) > maxCookieSize {
+		return "", ErrCookieTooLong
+	}
+
+	return value, nil
+}
+
+// Decode decrypts the value of the cookie name provided into v.
+// It returns ErrInvalidCookie if the value can't be decrypted with any key, and ErrExpiredCookie if it's older than MaxAge.
+func (s *SecureCookie) Decode(name, value string, v interface{}) error {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return ErrInvalidCookie
+	}
+
+	s.lock.RLock()
+	keys := s.keys
+	s.lock.RUnlock()
+
+	var plain []byte
+	for _, aead := range keys {
+		if len(sealed) < aead.NonceSize() {
+			return ErrInvalidCookie
+		}
+		n := aead.NonceSize()
+		if plain, err = aead.Open(nil, sealed[:n], sealed[n:], []byte(name)); err == nil {
+			break
+		}
+	}
+	if err != nil || len(plain) < 8 {
+		return ErrInvalidCookie
+	}
+
+	created := time.Unix(int64(binary.BigEndian.Uint64(plain)), 0)
+	if s.MaxAge > 0 && clockNow(s.Clock).Sub(created) > s.MaxAge {
+		return ErrExpiredCookie
+	}
+
+	if err := json.Unmarshal(plain[8:], v); err != nil {
+		return ErrInvalidCookie
+	}
+
+	return nil
+}
+
+// cookie creates a cookie with the attributes of the SecureCookie.
+func (s *SecureCookie) cookie(name, value string) *http.Cookie {
+	ck := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     s.Path,
+		Domain:   s.Domain,
+		Secure:   s.Secure,
+		HttpOnly: s.HTTPOnly,
+		SameSite: s.SameSite,
+	}
+	if s.MaxAge > 0 {
+		ck.MaxAge = int(s.MaxAge / time.Second)
+	}
+
+	return ck
+}
+
+// SetSecureCookie sets a cookie with the value encrypted by s.
+func (c *Context) SetSecureCookie(s *SecureCookie, name string, v interface{}) error {
+	value, err := s.Encode(name, v)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(c.Response, s.cookie(name, value))
+
+	return nil
+}
+
+// SecureCookie decrypts the request cookie encrypted by s into v.
+// It returns http.ErrNoCookie if the cookie is missing.
+func (c *Context) SecureCookie(s *SecureCookie, name string, v interface{}) error {
+	ck, err := c.Request.Cookie(name)
+	if err != nil {
+		return err
+	}
+
+	return s.Decode(name, ck.Value, v)
+}
+
+// ClearSecureCookie removes a cookie set with SetSecureCookie, like on logout.
+func (c *Context) ClearSecureCookie(s *SecureCookie, name string) {
+	ck := s.cookie(name, "")
+	ck.MaxAge = -1
+	ck.Expires = time.Unix(0, 0)
+
+	http.SetCookie(c.Response, ck)
+}
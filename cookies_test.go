@@ -0,0 +1,193 @@
+package yarf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testSession struct {
+	UserID int      `json:"uid"`
+	Roles  []string `json:"roles"`
+}
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestSecureCookieEncoding(t *testing.T) {
+	sc, err := NewSecureCookie(testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := sc.Encode("session", testSession{UserID: 10, Roles: []string{"admin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(value, "admin") {
+		t.Error("Values should be encrypted")
+	}
+
+	var s testSession
+	if err := sc.Decode("session", value, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.UserID != 10 || len(s.Roles) != 1 || s.Roles[0] != "admin" {
+		t.Errorf("Unexpected value %+v", s)
+	}
+
+	// Values are bound to the cookie name
+	if err := sc.Decode("other", value, &s); err != ErrInvalidCookie {
+		t.Errorf("Expected ErrInvalidCookie for another name, got %v", err)
+	}
+
+	// Tampered values
+	raw, _ := base64.RawURLEncoding.DecodeString(value)
+	raw[len(raw)-1] ^= 1
+	for _, v := range []string{base64.RawURLEncoding.EncodeToString(raw), "", "not base64!", "YQ"} {
+		if err := sc.Decode("session", v, &s); err != ErrInvalidCookie {
+			t.Errorf("%q: expected ErrInvalidCookie, got %v", v, err)
+		}
+	}
+
+	// Too long values
+	if _, err := sc.Encode("session", strings.Repeat("x", 4096)); err != ErrCookieTooLong {
+		t.Errorf("Expected ErrCookieTooLong, got %v", err)
+	}
+
+	if _, err := NewSecureCookie(); err == nil {
+		t.Error("NewSecureCookie() without keys should fail")
+	}
+	if _, err := NewSecureCookie([]byte("short")); err == nil {
+		t.Error("NewSecureCookie() with an invalid key should fail")
+	}
+}
+
+func TestSecureCookieRotation(t *testing.T) {
+	old, _ := NewSecureCookie(testKey(1))
+	value, _ := old.Encode("session", testSession{UserID: 1})
+
+	// Old values are decrypted after a rotation, new ones use the newest key
+	sc, _ := NewSecureCookie(testKey(2), testKey(1))
+	var s testSession
+	if err := sc.Decode("session", value, &s); err != nil || s.UserID != 1 {
+		t.Errorf("Old key: %+v, %v", s, err)
+	}
+
+	newValue, _ := sc.Encode("session", testSession{UserID: 2})
+	if err := old.Decode("session", newValue, &s); err != ErrInvalidCookie {
+		t.Errorf("New values should use the newest key, got %v", err)
+	}
+
+	// Rotate adds the newest key
+	if err := old.Rotate(testKey(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.Decode("session", newValue, &s); err != nil || s.UserID != 2 {
+		t.Errorf("Rotated key: %+v, %v", s, err)
+	}
+
+	// Retired keys
+	sc.RetireKeys(1)
+	if err := sc.Decode("session", value, &s); err != ErrInvalidCookie {
+		t.Errorf("Retired keys shouldn't decrypt, got %v", err)
+	}
+}
+
+func TestSecureCookieMaxAge(t *testing.T) {
+	sc, _ := NewSecureCookie(testKey(1))
+	value, _ := sc.Encode("session", testSession{UserID: 1})
+
+	sc.MaxAge = -time.Second
+	var s testSession
+	if err := sc.Decode("session", value, &s); err != nil {
+		t.Errorf("Negative MaxAge shouldn't expire values, got %v", err)
+	}
+
+	clock := newTestClock()
+	sc.Clock = clock
+	value, _ = sc.Encode("session", testSession{UserID: 1})
+	sc.MaxAge = time.Hour
+	if err := sc.Decode("session", value, &s); err != nil {
+		t.Errorf("Values within MaxAge should decode, got %v", err)
+	}
+
+	clock.Advance(2 * time.Hour)
+	if err := sc.Decode("session", value, &s); err != ErrExpiredCookie {
+		t.Errorf("Expected ErrExpiredCookie, got %v", err)
+	}
+}
+
+// SessionResource sets and reads a secure cookie.
+type SessionResource struct {
+	Resource
+	sc *SecureCookie
+}
+
+func (r *SessionResource) Get(c *Context) error {
+	var s testSession
+	if err := c.SecureCookie(r.sc, "session", &s); err != nil {
+		if errors.Is(err, http.ErrNoCookie) {
+			return ErrorUnauthorized()
+		}
+		return err
+	}
+
+	c.RenderJSON(s)
+	return nil
+}
+
+func (r *SessionResource) Post(c *Context) error {
+	return c.SetSecureCookie(r.sc, "session", testSession{UserID: 7})
+}
+
+func (r *SessionResource) Delete(c *Context) error {
+	c.ClearSecureCookie(r.sc, "session")
+	return nil
+}
+
+func TestContextSecureCookie(t *testing.T) {
+	sc, _ := NewSecureCookie(testKey(1))
+	sc.MaxAge = time.Hour
+
+	y := New()
+	y.Add("/session", &SessionResource{sc: sc})
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("POST", "/session", nil))
+
+	cookies := res.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Expected 1 cookie, got %d", len(cookies))
+	}
+	ck := cookies[0]
+	if !ck.Secure || !ck.HttpOnly || ck.SameSite != http.SameSiteLaxMode || ck.Path != "/" || ck.MaxAge != 3600 {
+		t.Errorf("Unexpected cookie attributes: %+v", ck)
+	}
+
+	req := httptest.NewRequest("GET", "/session", nil)
+	req.AddCookie(ck)
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+	if !jsonEqual(res.Body.String(), `{"uid": 7, "roles": null}`) {
+		t.Errorf("Unexpected session %q", res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/session", nil))
+	if res.Code != 401 {
+		t.Errorf("Missing cookie: status %d, expected 401", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("DELETE", "/session", nil))
+	if cookies := res.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge != -1 || cookies[0].Value != "" {
+		t.Errorf("Unexpected cleared cookie: %v", res.Header()["Set-Cookie"])
+	}
+}
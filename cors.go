@@ -0,0 +1,196 @@
+package yarf
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetaCORS is the RouteMeta key holding the CORSOverride of a route.
+const MetaCORS = "cors"
+
+// CORS is a middleware implementing Cross-Origin Resource Sharing.
+// It can be used as global middleware or inserted into groups,
+// and routes can override the preflight policy with RouteMeta.CORS().
+// Preflight requests are answered by the framework, so resources don't need to implement OPTIONS.
+//
+//	cors := yarf.NewCORS("https://app.example.com")
+//	cors.AllowCredentials = true
+//	y.Use(cors)
+type CORS struct {
+	Middleware
+
+	// AllowOrigins are the origins allowed to make requests. "*" allows any origin.
+	AllowOrigins []string
+
+	// AllowMethods are the methods allowed on preflight requests.
+	AllowMethods []string
+
+	// AllowHeaders are the request headers allowed on preflight requests.
+	// If empty, the headers requested by the client are allowed.
+	AllowHeaders []string
+
+	// ExposeHeaders are the response headers the client can read.
+	ExposeHeaders []string
+
+	// AllowCredentials allows requests with cookies and authorization headers.
+	AllowCredentials bool
+
+	// MaxAge is how long clients can cache preflight responses. 0 omits the header.
+	MaxAge time.Duration
+}
+
+// NewCORS creates a CORS middleware for the origins provided, allowing the common methods
+// and caching preflight responses for 10 minutes.
+func NewCORS(origins ...string) *CORS {
+	return &CORS{
+		AllowOrigins: origins,
+		AllowMethods: []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+		MaxAge:       10 * time.Minute,
+	}
+}
+
+// Phase runs CORS with the security middleware.
+func (m *CORS) Phase() Phase {
+	return PhaseSecurity
+}
+
+// corsKey is the Context storage key for the CORS middleware handling the request.
+type corsKey struct{}
+
+// PreDispatch enables CORS for the request. Headers are set when the route is dispatched,
+// as they depend on the route overrides.
+func (m *CORS) PreDispatch(c *Context) error {
+	c.set(corsKey{}, m)
+
+	return nil
+}
+
+// CORSOverride changes the preflight policy of the CORS middleware for a route.
+// Empty fields keep the middleware settings.
+type CORSOverride struct {
+	// AllowMethods replaces the methods allowed on preflight requests.
+	AllowMethods []string
+
+	// AllowHeaders replaces the request headers allowed on preflight requests.
+	AllowHeaders []string
+
+	// MaxAge replaces how long clients can cache preflight responses.
+	MaxAge time.Duration
+}
+
+// CORS declares the CORS preflight policy of the route, layered on the CORS middleware,
+// and returns the RouteMeta to allow chaining. It has no effect if no CORS middleware covers the route.
+//
+//	y.AddRoute("/files", new(Upload)).CORS(yarf.CORSOverride{
+//		AllowMethods: []string{"PUT"},
+//		AllowHeaders: []string{"Content-Type", "Content-Range"},
+//		MaxAge:       time.Minute,
+//	})
+func (m *RouteMeta) CORS(o CORSOverride) *RouteMeta {
+	return m.Set(MetaCORS, o)
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for the origin, or "" if it isn't allowed.
+func (m *CORS) allowOrigin(origin string) string {
+	for _, o := range m.AllowOrigins {
+		if o == "*" {
+			if m.AllowCredentials {
+				return origin
+			}
+			return "*"
+		}
+		if strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+
+	return ""
+}
+
+// cors sets the CORS headers of the response, and reports if the request is a preflight that has been answered.
+func (r *route) cors(c *Context) bool {
+	m, ok := c.get(corsKey{}).(*CORS)
+	if !ok {
+		return false
+	}
+
+	origin := c.Request.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+
+	h := c.Response.Header()
+	preflight := isPreflight(c.Request)
+	if preflight {
+		h.Add("Vary", "Origin")
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		defer c.Response.WriteHeader(http.StatusNoContent)
+	} else {
+		h.Add("Vary", "Origin")
+	}
+
+	allowed := m.allowOrigin(origin)
+	if allowed == "" {
+		return preflight
+	}
+
+	methods, headers, maxAge := m.AllowMethods, m.AllowHeaders, m.MaxAge
+	if o, ok := r.meta.Get(MetaCORS).(CORSOverride); ok {
+		if len(o.AllowMethods) > 0 {
+			methods = o.AllowMethods
+		}
+		if len(o.AllowHeaders) > 0 {
+			headers = o.AllowHeaders
+		}
+		if o.MaxAge > 0 {
+			maxAge = o.MaxAge
+		}
+	}
+
+	if preflight && !containsFold(methods, c.Request.Header.Get("Access-Control-Request-Method")) {
+		return true
+	}
+
+	h.Set("Access-Control-Allow-Origin", allowed)
+	if m.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !preflight {
+		if len(m.ExposeHeaders) > 0 {
+			h.Set("Access-Control-Expose-Headers", strings.Join(m.ExposeHeaders, ", "))
+		}
+		return false
+	}
+
+	h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if len(headers) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	} else if req := c.Request.Header.Get("Access-Control-Request-Headers"); req != "" {
+		h.Set("Access-Control-Allow-Headers", req)
+	}
+	if maxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge/time.Second)))
+	}
+
+	return true
+}
+
+// isPreflight checks if the request is a CORS preflight request.
+func isPreflight(r *http.Request) bool {
+	return r.Method == "OPTIONS" && r.Header.Get("Origin") != "" && r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// containsFold checks if s is in list, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,122 @@
+package yarf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	y := New()
+
+	cors := NewCORS("https://app.example.com")
+	cors.ExposeHeaders = []string{"X-Total"}
+	y.Use(cors)
+
+	y.Add("/users", new(OKResource))
+	y.AddRoute("/files", new(OKResource)).CORS(CORSOverride{
+		AllowMethods: []string{"PUT"},
+		AllowHeaders: []string{"Content-Type", "Content-Range"},
+		MaxAge:       time.Minute,
+	})
+
+	// Preflight requests
+	res := testRequest(y, "OPTIONS", "/users", map[string]string{
+		"Origin":                         "https://app.example.com",
+		"Access-Control-Request-Method":  "POST",
+		"Access-Control-Request-Headers": "Authorization",
+	})
+
+	if res.Code != 204 {
+		t.Errorf("Preflight status %d, expected 204", res.Code)
+	}
+	for k, v := range map[string]string{
+		"Access-Control-Allow-Origin":  "https://app.example.com",
+		"Access-Control-Allow-Methods": "GET, HEAD, POST, PUT, PATCH, DELETE",
+		"Access-Control-Allow-Headers": "Authorization",
+		"Access-Control-Max-Age":       "600",
+	} {
+		if res.Header().Get(k) != v {
+			t.Errorf("%s = %q, expected %q", k, res.Header().Get(k), v)
+		}
+	}
+	if res.Body.Len() != 0 {
+		t.Errorf("Preflight body should be empty, got %q", res.Body.String())
+	}
+
+	// Route overrides
+	res = testRequest(y, "OPTIONS", "/files", map[string]string{
+		"Origin":                        "https://app.example.com",
+		"Access-Control-Request-Method": "PUT",
+	})
+
+	for k, v := range map[string]string{
+		"Access-Control-Allow-Origin":  "https://app.example.com",
+		"Access-Control-Allow-Methods": "PUT",
+		"Access-Control-Allow-Headers": "Content-Type, Content-Range",
+		"Access-Control-Max-Age":       "60",
+	} {
+		if res.Header().Get(k) != v {
+			t.Errorf("%s = %q, expected %q", k, res.Header().Get(k), v)
+		}
+	}
+
+	// Methods not allowed by the route
+	res = testRequest(y, "OPTIONS", "/files", map[string]string{
+		"Origin":                        "https://app.example.com",
+		"Access-Control-Request-Method": "DELETE",
+	})
+	if res.Code != 204 || res.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Disallowed method: status %d, headers %v", res.Code, res.Header())
+	}
+
+	// Simple requests
+	res = testRequest(y, "GET", "/users", map[string]string{"Origin": "https://app.example.com"})
+	if res.Body.String() != "OK" {
+		t.Errorf("Handler should run, got %q", res.Body.String())
+	}
+	if res.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		res.Header().Get("Access-Control-Expose-Headers") != "X-Total" ||
+		res.Header().Get("Vary") != "Origin" {
+		t.Errorf("Unexpected headers: %v", res.Header())
+	}
+
+	// Other origins
+	res = testRequest(y, "GET", "/users", map[string]string{"Origin": "https://evil.example.com"})
+	if res.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Origin shouldn't be allowed: %v", res.Header())
+	}
+
+	// Same-origin requests
+	res = testRequest(y, "GET", "/users", nil)
+	if res.Header().Get("Vary") != "" || res.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Same-origin requests shouldn't get CORS headers: %v", res.Header())
+	}
+
+	// Plain OPTIONS requests reach the resource
+	res = testRequest(y, "OPTIONS", "/users", map[string]string{"Origin": "https://app.example.com"})
+	if res.Code != 405 {
+		t.Errorf("OPTIONS status %d, expected 405", res.Code)
+	}
+}
+
+func TestCORSWildcard(t *testing.T) {
+	y := New()
+	cors := NewCORS("*")
+	y.Use(cors)
+	y.Add("/", new(OKResource))
+
+	res := testRequest(y, "GET", "/", map[string]string{"Origin": "https://any.example.com"})
+	if v := res.Header().Get("Access-Control-Allow-Origin"); v != "*" {
+		t.Errorf("Allow-Origin = %q, expected *", v)
+	}
+
+	// Credentials require the origin
+	cors.AllowCredentials = true
+	res = testRequest(y, "GET", "/", map[string]string{"Origin": "https://any.example.com"})
+	if v := res.Header().Get("Access-Control-Allow-Origin"); v != "https://any.example.com" {
+		t.Errorf("Allow-Origin = %q, expected the origin", v)
+	}
+	if v := res.Header().Get("Access-Control-Allow-Credentials"); v != "true" {
+		t.Errorf("Allow-Credentials = %q", v)
+	}
+}
@@ -0,0 +1,101 @@
+package yarf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@every",
+		"@every -1m",
+		"@sometimes",
+	}
+
+	for _, spec := range invalid {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("Expected error parsing %q", spec)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2024, time.January, 10, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 10, 10, 8, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2024, 1, 10, 10, 10, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2024, 1, 10, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 10, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * *", time.Date(2024, 1, 11, 9, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 1, 10, 13, 0, 0, 0, time.UTC)},
+		{"15,45 10 * * *", time.Date(2024, 1, 10, 10, 15, 0, 0, time.UTC)},
+		{"0 0 * * mon-fri", time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * sun", time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Day of month or day of week when both are restricted
+		{"0 0 13 * fri", time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		s, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %q: %s", tt.spec, err)
+			continue
+		}
+		if next := s.Next(from); !next.Equal(tt.expected) {
+			t.Errorf("Expected %s for %q, got %s", tt.expected, tt.spec, next)
+		}
+	}
+}
+
+func TestCronNever(t *testing.T) {
+	s, _ := ParseCron("0 0 30 2 *")
+	if next := s.Next(time.Now()); !next.IsZero() {
+		t.Errorf("February 30th should never happen, got %s", next)
+	}
+}
+
+func TestCronEvery(t *testing.T) {
+	s, err := ParseCron("@every 90s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+	if next := s.Next(from); !next.Equal(from.Add(90 * time.Second)) {
+		t.Errorf("Unexpected next time %s", next)
+	}
+}
+
+func TestCronLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	s, _ := ParseCron("0 9 * * *")
+
+	from := time.Date(2024, 1, 10, 8, 0, 0, 0, loc)
+	expected := time.Date(2024, 1, 10, 9, 0, 0, 0, loc)
+	if next := s.Next(from); !next.Equal(expected) {
+		t.Errorf("Expected %s, got %s", expected, next)
+	}
+}
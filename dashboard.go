@@ -0,0 +1,320 @@
+package yarf
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencySamples is the number of latencies kept per route to compute the percentiles.
+const latencySamples = 1000
+
+// DashboardRoute are the details and stats of a route on the debug dashboard.
+type DashboardRoute struct {
+	Pattern    string   `json:"pattern"`
+	Methods    []string `json:"methods"`
+	Handler    string   `json:"handler"`
+	Middleware []string `json:"middleware"`
+	Hits       uint64   `json:"hits"`
+	Errors     uint64   `json:"errors"`
+	P50        float64  `json:"p50_ms"` // Median latency of the recent requests, in milliseconds
+	P99        float64  `json:"p99_ms"` // 99th percentile latency of the recent requests, in milliseconds
+	InFlight   int64    `json:"in_flight,omitempty"`
+	Queued     int64    `json:"queued,omitempty"`
+}
+
+// DashboardRequest is a request in flight on the debug dashboard.
+type DashboardRequest struct {
+	Method   string    `json:"method"`
+	URL      string    `json:"url"`
+	Started  time.Time `json:"started"`
+	Duration float64   `json:"duration_ms"`
+}
+
+// DashboardError is a failed request on the debug dashboard.
+type DashboardError struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id,omitempty"`
+	Method    string    `json:"method"`
+	URL       string    `json:"url"`
+	Route     string    `json:"route,omitempty"`
+	Status    int       `json:"status"`
+	Error     string    `json:"error"`
+}
+
+// DashboardSnapshot is the state rendered by the debug dashboard.
+type DashboardSnapshot struct {
+	Time     time.Time          `json:"time"`
+	Conns    ConnStats          `json:"conns"`
+	InFlight []DashboardRequest `json:"in_flight"`
+	Routes   []DashboardRoute   `json:"routes"`
+	Errors   []DashboardError   `json:"errors"`
+	Slow     []SlowRequest      `json:"slow,omitempty"`
+}
+
+// routeStats are the counters and recent latencies of a route.
+type routeStats struct {
+	hits, errors uint64
+	latencies    []time.Duration
+	next         int
+}
+
+// Dashboard collects the request stats rendered by the debug dashboard endpoint.
+// See Yarf.EnableDashboard.
+type Dashboard struct {
+	// MaxErrors is the number of recent errors kept.
+	MaxErrors int
+
+	// Slow lists the requests captured by a SlowCapture middleware on the dashboard.
+	Slow *SlowCapture
+
+	// Limiter reports the requests in flight and in queue of each route of a ConcurrencyLimiter on the dashboard.
+	Limiter *ConcurrencyLimiter
+
+	app      *Yarf
+	routes   map[string]*routeStats
+	inFlight map[*Context]DashboardRequest
+	errors   []DashboardError
+
+	lock sync.Mutex
+}
+
+// newDashboard creates a Dashboard for the Yarf instance, keeping the last 50 errors.
+func newDashboard(y *Yarf) *Dashboard {
+	return &Dashboard{
+		MaxErrors: 50,
+		app:       y,
+		routes:    make(map[string]*routeStats),
+		inFlight:  make(map[*Context]DashboardRequest),
+	}
+}
+
+// track registers the request in flight and returns the function recording it once it's done.
+func (d *Dashboard) track(c *Context) func() {
+	start := time.Now()
+
+	d.lock.Lock()
+	d.inFlight[c] = DashboardRequest{
+		Method:  c.Request.Method,
+		URL:     c.redactor().URL(c.Request.URL),
+		Started: start,
+	}
+	d.lock.Unlock()
+
+	return func() {
+		elapsed := time.Since(start)
+		route := c.RoutePattern()
+		err := c.Err()
+
+		d.lock.Lock()
+		defer d.lock.Unlock()
+
+		delete(d.inFlight, c)
+
+		if route != "" {
+			s, ok := d.routes[route]
+			if !ok {
+				s = &routeStats{latencies: make([]time.Duration, 0, latencySamples)}
+				d.routes[route] = s
+			}
+			s.hits++
+			if err != nil {
+				s.errors++
+			}
+			if len(s.latencies) < latencySamples {
+				s.latencies = append(s.latencies, elapsed)
+			} else {
+				s.latencies[s.next] = elapsed
+				s.next = (s.next + 1) % latencySamples
+			}
+		}
+
+		if err != nil {
+			status := http.StatusInternalServerError
+			if yerr, ok := err.(YError); ok {
+				status = yerr.Code()
+			}
+
+			d.errors = append(d.errors, DashboardError{
+				Time:      time.Now(),
+				RequestID: c.Request.Header.Get("X-Request-Id"),
+				Method:    c.Request.Method,
+				URL:       c.redactor().URL(c.Request.URL),
+				Route:     route,
+				Status:    status,
+				Error:     err.Error(),
+			})
+			if over := len(d.errors) - d.MaxErrors; over > 0 {
+				d.errors = append(d.errors[:0], d.errors[over:]...)
+			}
+		}
+	}
+}
+
+// percentile returns the p percentile of the sorted durations, in milliseconds.
+func percentile(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	i := int(float64(len(sorted))*p+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+
+	return float64(sorted[i]) / float64(time.Millisecond)
+}
+
+// Snapshot returns the current routes stats, requests in flight, and recent errors and slow requests, the newest first.
+func (d *Dashboard) Snapshot() DashboardSnapshot {
+	now := time.Now()
+	s := DashboardSnapshot{
+		Time:  now,
+		Conns: d.app.ConnStats(),
+	}
+	routes := d.app.Routes()
+	if d.Slow != nil {
+		s.Slow = d.Slow.Requests()
+	}
+	var load map[string]RouteLoad
+	if d.Limiter != nil {
+		load = d.Limiter.Load()
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for _, r := range routes {
+		dr := DashboardRoute{
+			Pattern:    r.Pattern,
+			Methods:    r.Methods(),
+			Handler:    r.HandlerName(),
+			Middleware: r.middlewareNames(),
+		}
+		if st, ok := d.routes[r.Pattern]; ok {
+			sorted := append([]time.Duration(nil), st.latencies...)
+			sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+			dr.Hits, dr.Errors = st.hits, st.errors
+			dr.P50, dr.P99 = percentile(sorted, 0.5), percentile(sorted, 0.99)
+		}
+		if rl, ok := load[r.Pattern]; ok {
+			dr.InFlight, dr.Queued = rl.InFlight, rl.Queued
+		}
+		s.Routes = append(s.Routes, dr)
+	}
+
+	for _, r := range d.inFlight {
+		r.Duration = float64(now.Sub(r.Started)) / float64(time.Millisecond)
+		s.InFlight = append(s.InFlight, r)
+	}
+	sort.Slice(s.InFlight, func(i, j int) bool {
+		return s.InFlight[i].Started.Before(s.InFlight[j].Started)
+	})
+
+	for i := len(d.errors) - 1; i >= 0; i-- {
+		s.Errors = append(s.Errors, d.errors[i])
+	}
+
+	return s
+}
+
+// dashboardPage is the HTML template of the debug dashboard.
+var dashboardPage = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><title>Yarf dashboard</title>
+<style>body{font-family:sans-serif;margin:2em}table{border-collapse:collapse;margin-bottom:2em}th,td{text-align:left;padding:.2em 1em .2em 0;border-bottom:1px solid #ddd}small{color:#666}</style>
+</head>
+<body>
+<h1>Yarf dashboard</h1>
+<p>{{.Conns.InFlight}} requests in flight, {{.Conns.Open}} connections open <small>at {{.Time.Format "15:04:05"}}</small></p>
+<h2>Routes</h2>
+<table>
+<tr><th>Pattern</th><th>Methods</th><th>Handler</th><th>Hits</th><th>Errors</th><th>p50 ms</th><th>p99 ms</th><th>In flight</th><th>Queued</th><th>Middleware</th></tr>
+{{range .Routes}}<tr><td>{{.Pattern}}</td><td>{{join .Methods ", "}}</td><td>{{.Handler}}</td><td>{{.Hits}}</td><td>{{.Errors}}</td><td>{{printf "%.2f" .P50}}</td><td>{{printf "%.2f" .P99}}</td><td>{{.InFlight}}</td><td>{{.Queued}}</td><td><small>{{join .Middleware " → "}}</small></td></tr>
+{{end}}</table>
+<h2>In flight</h2>
+<table>
+<tr><th>Method</th><th>URL</th><th>Duration ms</th></tr>
+{{range .InFlight}}<tr><td>{{.Method}}</td><td>{{.URL}}</td><td>{{printf "%.0f" .Duration}}</td></tr>
+{{end}}</table>
+<h2>Recent errors</h2>
+<table>
+<tr><th>Time</th><th>Request ID</th><th>Method</th><th>URL</th><th>Status</th><th>Error</th></tr>
+{{range .Errors}}<tr><td>{{.Time.Format "15:04:05"}}</td><td>{{.RequestID}}</td><td>{{.Method}}</td><td>{{.URL}}</td><td>{{.Status}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+{{if .Slow}}<h2>Slow requests</h2>
+<table>
+<tr><th>Time</th><th>Method</th><th>URL</th><th>Duration ms</th><th>Details</th></tr>
+{{range .Slow}}<tr><td>{{.Time.Format "15:04:05"}}</td><td>{{.Method}}</td><td>{{.URL}}</td><td>{{printf "%.0f" .Duration}}</td>
+<td><details><summary>{{.Route}}</summary><pre>{{range $k, $v := .Header}}{{$k}}: {{join $v ", "}}
+{{end}}{{range $k, $v := .Params}}:{{$k}} = {{$v}}
+{{end}}{{if .Error}}Error: {{.Error}}
+{{end}}{{.Body}}</pre></details></td></tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+`))
+
+// dashboardResource renders the dashboard.
+type dashboardResource struct {
+	Resource
+
+	dashboard *Dashboard
+}
+
+// Get renders the dashboard as HTML for browsers and JSON for other clients.
+func (r *dashboardResource) Get(c *Context) error {
+	s := r.dashboard.Snapshot()
+
+	c.Response.Header().Set("Cache-Control", "no-store")
+
+	if strings.Contains(c.Request.Header.Get("Accept"), "text/html") {
+		c.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
+		return dashboardPage.Execute(c.Response, s)
+	}
+
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.Response.Write(encoded)
+
+	return nil
+}
+
+// EnableDashboard starts collecting request stats and adds the debug dashboard endpoint to the Yarf routes:
+// route table with hit counts and p50/p99 latencies, middleware chain of each route,
+// requests in flight and recent errors with their X-Request-Id.
+// It's rendered as HTML for browsers and JSON for other clients.
+// The auth middleware is inserted into the dashboard group, as with EnableDebug.
+// Stats are kept in memory and add some overhead to every request, so enable it on staging environments.
+//
+//	y.EnableDashboard("/_dashboard", new(AdminAuth))
+func (y *Yarf) EnableDashboard(path string, auth ...MiddlewareHandler) *Dashboard {
+	y.lock.Lock()
+	if y.dashboard == nil {
+		y.dashboard = newDashboard(y)
+	}
+	d := y.dashboard
+	y.lock.Unlock()
+
+	g := RouteGroup(path)
+	for _, m := range auth {
+		g.Insert(m)
+	}
+	g.Add("/", &dashboardResource{dashboard: d})
+	y.AddGroup(g)
+
+	return d
+}
@@ -0,0 +1,82 @@
+package yarf
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	if p := percentile(nil, 0.5); p != 0 {
+		t.Errorf("Empty percentile: %v", p)
+	}
+
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	if p := percentile(sorted, 0.5); p != 50 {
+		t.Errorf("p50 = %v, expected 50", p)
+	}
+	if p := percentile(sorted, 0.99); p != 99 {
+		t.Errorf("p99 = %v, expected 99", p)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	y := New()
+	y.Use(new(CountMiddleware))
+	y.Add("/items/:id", new(OKResource))
+	y.Add("/fail", new(FailingResource))
+	d := y.EnableDashboard("/_dashboard")
+	d.MaxErrors = 2
+
+	for _, p := range []string{"/items/1", "/items/2", "/fail", "/fail?token=abc", "/fail"} {
+		req := httptest.NewRequest("GET", p, nil)
+		req.Header.Set("X-Request-Id", "req"+p)
+		y.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/_dashboard", nil))
+
+	var s DashboardSnapshot
+	if err := json.Unmarshal(res.Body.Bytes(), &s); err != nil {
+		t.Fatalf("Expected JSON dashboard, got %q: %s", res.Body.String(), err)
+	}
+
+	routes := make(map[string]DashboardRoute)
+	for _, r := range s.Routes {
+		routes[r.Pattern] = r
+	}
+	items := routes["/items/:id"]
+	if items.Hits != 2 || items.Errors != 0 || items.Handler != "*yarf.OKResource" || len(items.Methods) != 1 {
+		t.Errorf("Unexpected route stats %+v", items)
+	}
+	if len(items.Middleware) != 1 || items.Middleware[0] != "*yarf.CountMiddleware" {
+		t.Errorf("Unexpected middleware %v", items.Middleware)
+	}
+	if routes["/fail"].Hits != 3 || routes["/fail"].Errors != 3 {
+		t.Errorf("Unexpected route stats %+v", routes["/fail"])
+	}
+
+	// The last errors, the newest first
+	if len(s.Errors) != 2 || s.Errors[0].RequestID != "req/fail" || s.Errors[1].URL != "/fail?token=[REDACTED]" || s.Errors[0].Status != 500 {
+		t.Errorf("Unexpected errors %+v", s.Errors)
+	}
+
+	// The dashboard request itself is in flight
+	if len(s.InFlight) != 1 || s.InFlight[0].URL != "/_dashboard" {
+		t.Errorf("Unexpected requests in flight %+v", s.InFlight)
+	}
+
+	req := httptest.NewRequest("GET", "/_dashboard/", nil)
+	req.Header.Set("Accept", "text/html")
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+	if !strings.Contains(res.Header().Get("Content-Type"), "text/html") || !strings.Contains(res.Body.String(), "/items/:id") {
+		t.Errorf("Expected HTML dashboard, got %q", res.Body.String())
+	}
+}
@@ -0,0 +1,50 @@
+package yarf
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+)
+
+// profileResource serves the named runtime profiles.
+type profileResource struct {
+	Resource
+}
+
+// Get serves the profile named by the route param.
+func (r *profileResource) Get(c *Context) error {
+	pprof.Handler(c.Param("profile")).ServeHTTP(c.Response, c.Request)
+	return nil
+}
+
+// DebugGroup creates a route group exposing the net/http/pprof profiles under prefix + "/pprof"
+// and the expvar variables under prefix + "/vars".
+// The auth middleware is inserted into the group, so profiling data isn't exposed publicly.
+func DebugGroup(prefix string, auth ...MiddlewareHandler) *GroupRoute {
+	g := RouteGroup(prefix)
+	for _, m := range auth {
+		g.Insert(m)
+	}
+
+	g.Add("/pprof", FromHTTPHandler(http.HandlerFunc(pprof.Index)))
+	g.Add("/pprof/cmdline", FromHTTPHandler(http.HandlerFunc(pprof.Cmdline)))
+	g.Add("/pprof/profile", FromHTTPHandler(http.HandlerFunc(pprof.Profile)))
+	g.Add("/pprof/symbol", FromHTTPHandler(http.HandlerFunc(pprof.Symbol)))
+	g.Add("/pprof/trace", FromHTTPHandler(http.HandlerFunc(pprof.Trace)))
+	g.Add("/pprof/:profile", new(profileResource))
+	g.Add("/vars", FromHTTPHandler(expvar.Handler()))
+
+	return g
+}
+
+// EnableDebug adds the pprof and expvar debug endpoints to the Yarf routes, under prefix,
+// and the RuntimeStats as JSON under prefix + "/stats". See DebugGroup.
+//
+//	y.EnableDebug("/debug", new(AdminAuth))
+func (y *Yarf) EnableDebug(prefix string, auth ...MiddlewareHandler) *GroupRoute {
+	g := DebugGroup(prefix, auth...)
+	g.Add("/stats", &statsResource{app: y})
+	y.AddGroup(g)
+
+	return g
+}
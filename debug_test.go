@@ -0,0 +1,51 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type DebugAuth struct {
+	Middleware
+}
+
+func (m *DebugAuth) PreDispatch(c *Context) error {
+	if c.Request.Header.Get("X-Token") != "secret" {
+		return &CustomError{HTTPCode: 401, ErrorMsg: "Unauthorized"}
+	}
+
+	return nil
+}
+
+func TestEnableDebug(t *testing.T) {
+	y := New()
+	y.EnableDebug("/_debug", new(DebugAuth))
+
+	tests := map[string]string{
+		"/_debug/pprof":                   "goroutine",
+		"/_debug/pprof/goroutine?debug=1": "goroutine profile:",
+		"/_debug/pprof/cmdline":           "yarf",
+		"/_debug/vars":                    "memstats",
+	}
+
+	for path, body := range tests {
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+path, nil)
+		req.Header.Set("X-Token", "secret")
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Code != 200 || !strings.Contains(res.Body.String(), body) {
+			t.Errorf("%s: expected 200 containing '%s', got %d: %.100s", path, body, res.Code, res.Body.String())
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/_debug/vars", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 401 {
+		t.Errorf("Expected 401 without auth, got %d", res.Code)
+	}
+}
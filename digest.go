@@ -0,0 +1,142 @@
+package yarf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MetaContentDigest is the RouteMeta key holding the DigestPolicy of the route.
+const MetaContentDigest = "content_digest"
+
+// digestAlgorithms are the Content-Digest algorithms verified, by their RFC 9530 names.
+var digestAlgorithms = map[string]func() hash.Hash{
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// DigestPolicy configures the Content-Digest (RFC 9530) support of a route.
+// Request digests are always verified when they're sent.
+type DigestPolicy struct {
+	// Require rejects the requests with a body but without Content-Digest.
+	Require bool
+
+	// Responses adds the SHA-256 Content-Digest to the responses.
+	// Responses are buffered to compute it, so it shouldn't be used with streaming handlers.
+	Responses bool
+}
+
+// ContentDigest enables the Content-Digest support of the route, and returns the RouteMeta to allow chaining.
+// Requests with a Content-Digest that doesn't match their body get a 400 error.
+//
+//	y.AddRoute("/transfers", new(Transfers)).ContentDigest(yarf.DigestPolicy{Require: true, Responses: true})
+func (m *RouteMeta) ContentDigest(p DigestPolicy) *RouteMeta {
+	return m.Set(MetaContentDigest, p)
+}
+
+// contentDigest returns the SHA-256 Content-Digest of a body.
+func contentDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+}
+
+// checkContentDigest checks a Content-Digest header against the body.
+// Every known algorithm listed must match, and at least one must be known.
+func checkContentDigest(header string, body []byte) bool {
+	checked := false
+	for _, member := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(member), "=")
+		newHash, known := digestAlgorithms[strings.ToLower(name)]
+		if !ok || !known {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) < 2 || value[0] != ':' || value[len(value)-1] != ':' {
+			return false
+		}
+		expected, err := base64.StdEncoding.DecodeString(value[1 : len(value)-1])
+		if err != nil {
+			return false
+		}
+
+		h := newHash()
+		h.Write(body)
+		if subtle.ConstantTimeCompare(h.Sum(nil), expected) != 1 {
+			return false
+		}
+		checked = true
+	}
+
+	return checked
+}
+
+// digestError creates the 400 error for a rejected request digest.
+func digestError(msg string) error {
+	e := ErrorBadRequest()
+	e.ErrorBody = msg
+
+	return e
+}
+
+// verifyDigest checks the Content-Digest of the request body, which is read and replaced with a copy.
+func (r *route) verifyDigest(c *Context) error {
+	p, ok := r.meta.Get(MetaContentDigest).(DigestPolicy)
+	if !ok {
+		return nil
+	}
+
+	header := c.Request.Header.Get("Content-Digest")
+	if header == "" {
+		if p.Require && c.Request.Body != nil && c.Request.Body != http.NoBody && c.Request.ContentLength != 0 {
+			return digestError("Missing Content-Digest")
+		}
+		return nil
+	}
+
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		if body, err = io.ReadAll(c.Request.Body); err != nil {
+			return err
+		}
+		c.Request.Body.Close()
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if !checkContentDigest(header, body) {
+		return digestError("Content-Digest mismatch")
+	}
+
+	return nil
+}
+
+// digestResponse runs next with the response buffered, and adds its Content-Digest
+// if the route policy asks for it. Responses without body, and failed requests, are written unchanged.
+func (r *route) digestResponse(c *Context, next func(*Context) error) error {
+	if p, _ := r.meta.Get(MetaContentDigest).(DigestPolicy); !p.Responses {
+		return next(c)
+	}
+
+	original := c.Response
+	buf := newBufferedResponse()
+	buf.header = original.Header()
+	c.Response = buf
+
+	err := next(c)
+	c.Response = original
+
+	if err == nil && buf.code != http.StatusNoContent && buf.code != http.StatusNotModified &&
+		c.Request.Method != "HEAD" && buf.header.Get("Content-Digest") == "" {
+		buf.header.Set("Content-Digest", contentDigest(buf.body.Bytes()))
+	}
+	buf.flush(original)
+
+	return err
+}
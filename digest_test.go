@@ -0,0 +1,108 @@
+package yarf
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type DigestResource struct {
+	Resource
+}
+
+func (r *DigestResource) Post(c *Context) error {
+	body, _ := io.ReadAll(c.Request.Body)
+	c.Render("received " + string(body))
+	return nil
+}
+
+func (r *DigestResource) Get(c *Context) error {
+	c.Render(`{"hello": "world"}`)
+	return nil
+}
+
+func (r *DigestResource) Delete(c *Context) error {
+	c.Response.WriteHeader(204)
+	return nil
+}
+
+func TestCheckContentDigest(t *testing.T) {
+	body := []byte(`{"hello": "world"}`)
+
+	for header, valid := range map[string]bool{
+		"sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:":                                             true,
+		"sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:": true,
+		"unixsum=:1234:, SHA-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:":                             true,
+		"sha-256=:RK/0qy18MlBSVnWgjwz6lZEWjP/lF5HF9bvEF8FabDg=:":                                             false,
+		"sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:, sha-512=:AAAA:":                             false,
+		"sha-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=":                                               false,
+		"unixsum=:1234:": false,
+	} {
+		if checkContentDigest(header, body) != valid {
+			t.Errorf("%s: expected valid %v", header, valid)
+		}
+	}
+}
+
+func TestContentDigestRequests(t *testing.T) {
+	y := New()
+	y.AddRoute("/transfers", new(DigestResource)).ContentDigest(DigestPolicy{Require: true})
+	y.AddRoute("/optional", new(DigestResource)).ContentDigest(DigestPolicy{})
+	y.Add("/plain", new(DigestResource))
+
+	body := `{"hello": "world"}`
+	valid := "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"
+	invalid := "sha-256=:RK/0qy18MlBSVnWgjwz6lZEWjP/lF5HF9bvEF8FabDg=:"
+
+	tests := []struct {
+		path, digest string
+		status       int
+		body         string
+	}{
+		{"/transfers", valid, 200, "received " + body},
+		{"/transfers", invalid, 400, "Content-Digest mismatch"},
+		{"/transfers", "", 400, "Missing Content-Digest"},
+		{"/optional", "", 200, "received " + body},
+		{"/optional", invalid, 400, "Content-Digest mismatch"},
+		{"/plain", invalid, 200, "received " + body},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", tt.path, strings.NewReader(body))
+		if tt.digest != "" {
+			req.Header.Set("Content-Digest", tt.digest)
+		}
+		res := serveRequest(y, req)
+		if res.Code != tt.status || res.Body.String() != tt.body {
+			t.Errorf("%s %s: expected %d '%s', got %d '%s'", tt.path, tt.digest, tt.status, tt.body, res.Code, res.Body.String())
+		}
+	}
+
+	if res := testRequest(y, "GET", "/transfers", nil); res.Code != 200 {
+		t.Errorf("Requests without body don't need a digest, got %d", res.Code)
+	}
+}
+
+func TestContentDigestResponses(t *testing.T) {
+	y := New()
+	y.AddRoute("/hello", new(DigestResource)).ContentDigest(DigestPolicy{Responses: true}).Header("X-Default", "1")
+	y.Add("/plain", new(DigestResource))
+
+	res := testRequest(y, "GET", "/hello", nil)
+	if res.Code != 200 || res.Body.String() != `{"hello": "world"}` || res.Header().Get("X-Default") != "1" {
+		t.Errorf("Unexpected response %d %v '%s'", res.Code, res.Header(), res.Body.String())
+	}
+	if d := res.Header().Get("Content-Digest"); d != "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:" {
+		t.Errorf("Unexpected Content-Digest %s", d)
+	}
+
+	if res := testRequest(y, "DELETE", "/hello", nil); res.Code != 204 || res.Header().Get("Content-Digest") != "" {
+		t.Errorf("Expected no digest without body, got %d %s", res.Code, res.Header().Get("Content-Digest"))
+	}
+	if res := testRequest(y, "GET", "/plain", nil); res.Header().Get("Content-Digest") != "" {
+		t.Error("Routes without policy shouldn't get digests")
+	}
+	if res := testRequest(y, "PUT", "/hello", nil); res.Code != 405 || res.Header().Get("Content-Digest") != "" {
+		t.Errorf("Expected failed requests without digest, got %d %s", res.Code, res.Header().Get("Content-Digest"))
+	}
+}
@@ -0,0 +1,56 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"syscall"
+)
+
+// Disconnected returns a channel closed when the client goes away, to stop the work done for it.
+// Unlike the request context, it isn't affected by the timeouts set by middleware.
+//
+//	select {
+//	case row := <-rows:
+//		...
+//	case <-c.Disconnected():
+//		return nil
+//	}
+func (c *Context) Disconnected() <-chan struct{} {
+	if c.client != nil {
+		return c.client.Done()
+	}
+
+	return c.Request.Context().Done()
+}
+
+// clientGone returns true if the client went away.
+func (c *Context) clientGone() bool {
+	select {
+	case <-c.Disconnected():
+		return true
+	default:
+		return false
+	}
+}
+
+// clientError maps the errors caused by the client going away to ClientClosedRequestError,
+// keeping the original message: broken pipes, connection resets,
+// and the cancellations returned once the client disconnected.
+// They're logged and measured with the 499 status, and aren't reported as server errors.
+func clientError(c *Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*ClientClosedRequestError); ok {
+		return err
+	}
+
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) ||
+		(errors.Is(err, context.Canceled) && c.clientGone()) {
+		e := ErrorClientClosedRequest()
+		e.ErrorMsg = err.Error()
+		return e
+	}
+
+	return err
+}
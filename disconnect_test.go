@@ -0,0 +1,121 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type DisconnectResource struct {
+	Resource
+	started chan struct{}
+}
+
+func (r *DisconnectResource) Get(c *Context) error {
+	close(r.started)
+	<-c.Disconnected()
+
+	return c.Request.Context().Err()
+}
+
+func TestDisconnected(t *testing.T) {
+	m := newTestMetrics()
+	reports := 0
+
+	y := New()
+	y.Metrics = m
+	y.ReportErrors(ErrorReporterFunc(func(r *ErrorReport) {
+		reports++
+	}))
+	res := &DisconnectResource{started: make(chan struct{})}
+	y.Add("/slow", res)
+
+	s := httptest.NewServer(y)
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", s.URL+"/slow", nil)
+	go func() {
+		<-res.started
+		cancel()
+	}()
+	if _, err := http.DefaultClient.Do(req); err == nil {
+		t.Fatal("Expected cancelled request")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for m.get("yarf_requests_total{GET,/slow,499}") == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if m.get("yarf_requests_total{GET,/slow,499}") != 1 {
+		t.Errorf("Expected a 499 request, got %v", m.values)
+	}
+	if reports != 0 {
+		t.Errorf("Disconnects shouldn't be reported, got %d reports", reports)
+	}
+}
+
+func TestDisconnectedIgnoresTimeouts(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	c := NewContext(req, httptest.NewRecorder())
+	c.client = req.Context()
+
+	ctx, cancel := context.WithCancel(req.Context())
+	cancel()
+	c.Request = req.WithContext(ctx)
+
+	if c.clientGone() {
+		t.Error("Request context cancellations aren't client disconnects")
+	}
+	if err := clientError(c, ctx.Err()); err != context.Canceled {
+		t.Errorf("Expected the original error, got %v", err)
+	}
+}
+
+func TestClientError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	gone := NewContext(httptest.NewRequest("GET", "/", nil).WithContext(ctx), httptest.NewRecorder())
+	here := NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+
+	for i, tc := range []struct {
+		c    *Context
+		err  error
+		code int
+	}{
+		{here, fmt.Errorf("write: %w", &os.SyscallError{Syscall: "write", Err: syscall.EPIPE}), StatusClientClosedRequest},
+		{here, syscall.ECONNRESET, StatusClientClosedRequest},
+		{here, context.Canceled, 0},
+		{gone, context.Canceled, StatusClientClosedRequest},
+		{gone, errors.New("database is down"), 0},
+		{gone, nil, 0},
+	} {
+		err := clientError(tc.c, tc.err)
+		cerr, ok := err.(*ClientClosedRequestError)
+		if tc.code == 0 {
+			if err != tc.err {
+				t.Errorf("%d: expected the original error, got %v", i, err)
+			}
+			continue
+		}
+		if !ok || cerr.Code() != tc.code || cerr.Error() != tc.err.Error() {
+			t.Errorf("%d: expected client closed request error, got %v", i, err)
+		}
+	}
+}
+
+func TestClientClosedRequestResponse(t *testing.T) {
+	y := New()
+	c := NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+	y.finish(c, ErrorClientClosedRequest())
+
+	if rec := c.Response.(*httptest.ResponseRecorder); rec.Body.Len() > 0 || rec.Code != 200 {
+		t.Errorf("Expected no response written, got %d %q", rec.Code, rec.Body.String())
+	}
+}
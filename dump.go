@@ -0,0 +1,166 @@
+package yarf
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// RequestDump is a sanitized copy of a request, to make bug reports reproducible.
+// Sensitive headers, params and body fields are masked by the application Redactor.
+type RequestDump struct {
+	Method    string      `json:"method"`
+	URL       string      `json:"url"`
+	Proto     string      `json:"proto"`
+	Header    http.Header `json:"header"`
+	Body      string      `json:"body,omitempty"`
+	Truncated bool        `json:"truncated,omitempty"` // The body is longer than the part captured
+}
+
+// dumpKey is the Context storage key for the request body captured for the dump.
+type dumpKey struct{}
+
+// teeBody copies the request body read by the handlers into a buffer.
+type teeBody struct {
+	io.ReadCloser
+	buf *limitedBuffer
+}
+
+// Read reads from the body and copies the data read.
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+
+	return n, err
+}
+
+// captureBody keeps the first max bytes of the request body read by the handlers, for Dump.
+func captureBody(c *Context, max int64) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return
+	}
+
+	buf := &limitedBuffer{max: max + 1}
+	c.Request.Body = &teeBody{c.Request.Body, buf}
+	c.set(dumpKey{}, buf)
+}
+
+// Dump returns a sanitized copy of the request, used by the debug error pages and the error reports.
+// The body is included up to Yarf.DumpBodySize bytes, as far as the handlers have read it.
+// JSON and form bodies get their sensitive fields masked, and other bodies of a masking Redactor are fully masked.
+func (c *Context) Dump() *RequestDump {
+	r := c.redactor()
+
+	u := *c.Request.URL
+	u.Scheme, u.Host = c.Scheme(), c.Host()
+
+	d := &RequestDump{
+		Method: c.Request.Method,
+		URL:    r.URL(&u),
+		Proto:  c.Request.Proto,
+		Header: r.Header(c.Request.Header),
+	}
+
+	if buf, ok := c.get(dumpKey{}).(*limitedBuffer); ok && buf.Len() > 0 {
+		body := buf.Bytes()
+		if int64(len(body)) >= buf.max {
+			body = body[:buf.max-1]
+			d.Truncated = true
+		}
+		d.Body = r.body(c.Request.Header.Get("Content-Type"), body, d.Truncated)
+	}
+
+	return d
+}
+
+// body returns the request body with the sensitive fields masked.
+func (r *Redactor) body(contentType string, body []byte, truncated bool) string {
+	if r == nil {
+		return string(body)
+	}
+
+	ct := strings.ToLower(contentType)
+	switch {
+	case strings.Contains(ct, "json") && !truncated:
+		var v interface{}
+		if json.Unmarshal(body, &v) == nil {
+			set := make(map[string]bool)
+			for _, k := range r.Fields {
+				set[k] = true
+			}
+			masked, _ := json.Marshal(redactKeys(v, set))
+			return string(masked)
+		}
+	case strings.HasPrefix(ct, "application/x-www-form-urlencoded"):
+		return r.Query(string(body))
+	case strings.HasPrefix(ct, "text/"):
+		return string(body)
+	}
+
+	return RedactMask
+}
+
+// String returns the request as sent on the wire.
+func (d *RequestDump) String() string {
+	target, host := d.URL, ""
+	if u, err := url.Parse(d.URL); err == nil {
+		target, host = u.RequestURI(), u.Host
+	}
+
+	var b strings.Builder
+	b.WriteString(d.Method + " " + target + " " + d.Proto + "\r\n")
+	b.WriteString("Host: " + host + "\r\n")
+	for _, k := range sortedKeys(d.Header) {
+		for _, v := range d.Header[k] {
+			b.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+	b.WriteString("\r\n")
+	b.WriteString(d.Body)
+
+	return b.String()
+}
+
+// Curl returns a curl command reproducing the request. Masked values have to be filled in by hand.
+func (d *RequestDump) Curl() string {
+	args := []string{"curl"}
+	if d.Method != "GET" {
+		args = append(args, "-X", d.Method)
+	}
+	args = append(args, shellQuote(d.URL))
+
+	for _, k := range sortedKeys(d.Header) {
+		switch k {
+		case "Content-Length", "Connection", "Accept-Encoding":
+			continue
+		}
+		for _, v := range d.Header[k] {
+			args = append(args, "-H", shellQuote(k+": "+v))
+		}
+	}
+
+	if d.Body != "" {
+		args = append(args, "--data-binary", shellQuote(d.Body))
+	}
+
+	return strings.Join(args, " ")
+}
+
+// sortedKeys returns the header names in order.
+func sortedKeys(h http.Header) []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
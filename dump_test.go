@@ -0,0 +1,128 @@
+package yarf
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type DumpResource struct {
+	Resource
+	dump *RequestDump
+}
+
+func (r *DumpResource) Post(c *Context) error {
+	io.ReadAll(c.Request.Body)
+	r.dump = c.Dump()
+
+	return nil
+}
+
+func TestDump(t *testing.T) {
+	r := new(DumpResource)
+	y := New()
+	y.DumpBodySize = 1024
+	y.Add("/items", r)
+
+	req := httptest.NewRequest("POST", "http://example.com/items?token=abc&page=2", strings.NewReader(`{"name":"it's","password":"secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer abc")
+	y.ServeHTTP(httptest.NewRecorder(), req)
+
+	d := r.dump
+	if d == nil {
+		t.Fatal("Expected a request dump")
+	}
+	if d.URL != "http://example.com/items?token=[REDACTED]&page=2" {
+		t.Errorf("Expected masked absolute URL, got '%s'", d.URL)
+	}
+	if d.Header.Get("Authorization") != RedactMask {
+		t.Errorf("Expected masked Authorization header, got '%s'", d.Header.Get("Authorization"))
+	}
+	if d.Body != `{"name":"it's","password":"[REDACTED]"}` || d.Truncated {
+		t.Errorf("Expected masked JSON body, got '%s'", d.Body)
+	}
+
+	s := d.String()
+	if !strings.HasPrefix(s, "POST /items?token=[REDACTED]&page=2 HTTP/1.1\r\nHost: example.com\r\n") || !strings.HasSuffix(s, "\r\n\r\n"+d.Body) {
+		t.Errorf("Unexpected wire dump '%s'", s)
+	}
+
+	curl := d.Curl()
+	expected := `curl -X POST 'http://example.com/items?token=[REDACTED]&page=2' -H 'Authorization: [REDACTED]' ` +
+		`-H 'Content-Type: application/json' --data-binary '{"name":"it'\''s","password":"[REDACTED]"}'`
+	if curl != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, curl)
+	}
+}
+
+func TestDumpBody(t *testing.T) {
+	for _, tc := range []struct {
+		size        int64
+		contentType string
+		body        string
+		expected    string
+		truncated   bool
+	}{
+		{0, "text/plain", "hello", "", false},
+		{1024, "text/plain", "hello", "hello", false},
+		{3, "text/plain", "hello", "hel", true},
+		{1024, "application/x-www-form-urlencoded", "user=me&password=secret", "user=me&password=[REDACTED]", false},
+		{1024, "application/octet-stream", "binary", RedactMask, false},
+		{1024, "application/json", "{broken", RedactMask, false},
+		{3, "application/json", `{"password":"secret"}`, RedactMask, true},
+	} {
+		r := new(DumpResource)
+		y := New()
+		y.DumpBodySize = tc.size
+		y.Add("/", r)
+
+		req := httptest.NewRequest("POST", "/", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		y.ServeHTTP(httptest.NewRecorder(), req)
+
+		if r.dump.Body != tc.expected || r.dump.Truncated != tc.truncated {
+			t.Errorf("%d %s: expected '%s' (%v), got '%s' (%v)", tc.size, tc.contentType, tc.expected, tc.truncated, r.dump.Body, r.dump.Truncated)
+		}
+	}
+
+	// Without a Redactor the body is kept as is
+	r := new(DumpResource)
+	y := New()
+	y.Redactor = nil
+	y.DumpBodySize = 1024
+	y.Add("/", r)
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("binary"))
+	y.ServeHTTP(httptest.NewRecorder(), req)
+
+	if r.dump.Body != "binary" {
+		t.Errorf("Expected unmasked body, got '%s'", r.dump.Body)
+	}
+}
+
+func TestDumpInErrorReports(t *testing.T) {
+	g := RouteGroup("/api")
+	g.Add("/items/:id", new(FailingResource))
+
+	var reported *RequestDump
+	y := New()
+	y.Debug = true
+	y.AddGroup(g)
+	y.ReportErrors(ErrorReporterFunc(func(r *ErrorReport) {
+		reported = r.Request
+	}))
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/api/items/3?token=abc", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	curl := "curl 'http://localhost:8080/api/items/3?token=[REDACTED]'"
+	if !strings.Contains(res.Body.String(), `"curl": "`+curl+`"`) {
+		t.Errorf("Expected curl reproduction in the debug page, got '%s'", res.Body.String())
+	}
+	if reported == nil || reported.Curl() != curl {
+		t.Errorf("Expected request dump in the error report, got %+v", reported)
+	}
+}
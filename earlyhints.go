@@ -0,0 +1,88 @@
+package yarf
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// preloadTypes are the preload destinations by file extension.
+var preloadTypes = map[string]string{
+	".css":   "style",
+	".js":    "script",
+	".mjs":   "script",
+	".woff":  "font",
+	".woff2": "font",
+	".ttf":   "font",
+	".otf":   "font",
+	".png":   "image",
+	".jpg":   "image",
+	".jpeg":  "image",
+	".gif":   "image",
+	".svg":   "image",
+	".webp":  "image",
+	".avif":  "image",
+	".json":  "fetch",
+}
+
+// linkHeader builds a Link header value with the relation type for the URL.
+// The preload destination is guessed from the extension, and fonts are requested anonymously, as browsers do.
+// Values already formatted as Link headers, starting with "<", are returned as they are.
+func linkHeader(rel, href string) string {
+	if strings.HasPrefix(href, "<") {
+		return href
+	}
+
+	v := "<" + href + ">; rel=" + rel
+	if as, ok := preloadTypes[strings.ToLower(path.Ext(strings.SplitN(href, "?", 2)[0]))]; ok {
+		v += "; as=" + as
+		if as == "font" || as == "fetch" {
+			v += "; crossorigin"
+		}
+	}
+
+	return v
+}
+
+// addLinkHeader adds a Link header value if it isn't already set.
+func addLinkHeader(h http.Header, v string) {
+	for _, l := range h.Values("Link") {
+		if l == v {
+			return
+		}
+	}
+	h.Add("Link", v)
+}
+
+// Preload adds Link headers asking the browser to preload the resources of the page, like stylesheets and scripts.
+// The destination is guessed from the extension. Link values, as in `</app.js>; rel=modulepreload`, are added as they are.
+func (c *Context) Preload(hrefs ...string) {
+	for _, href := range hrefs {
+		addLinkHeader(c.Response.Header(), linkHeader("preload", href))
+	}
+}
+
+// Prefetch adds Link headers asking the browser to fetch resources likely needed by the next navigation.
+func (c *Context) Prefetch(hrefs ...string) {
+	for _, href := range hrefs {
+		addLinkHeader(c.Response.Header(), linkHeader("prefetch", href))
+	}
+}
+
+// EarlyHints sends a 103 Early Hints response preloading the resources, so the browser fetches them
+// while the handler builds the page. The Link headers are kept in the final response too.
+// It can be called once the critical resources are known, before the slow work of the handler.
+// Clients not supporting HTTP/1.1 only get the final response headers.
+//
+//	func (r *Page) Get(c *yarf.Context) error {
+//		c.EarlyHints("/static/app.css", "/static/app.js")
+//		page := renderPage() // slow
+//		...
+//	}
+func (c *Context) EarlyHints(hrefs ...string) {
+	c.Preload(hrefs...)
+
+	if c.Request.ProtoAtLeast(1, 1) {
+		c.Response.WriteHeader(http.StatusEarlyHints)
+	}
+}
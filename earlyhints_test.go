@@ -0,0 +1,84 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"reflect"
+	"testing"
+)
+
+type EarlyHintsResource struct {
+	Resource
+}
+
+func (r *EarlyHintsResource) Get(c *Context) error {
+	c.EarlyHints("/static/app.css", "/static/app.js?v=2")
+	c.Preload("/static/font.woff2", "/static/app.css")
+	c.Prefetch("/next")
+	c.Render("page")
+
+	return nil
+}
+
+func TestEarlyHints(t *testing.T) {
+	m := newTestMetrics()
+	y := New()
+	y.Metrics = m
+	y.Add("/", new(EarlyHintsResource))
+
+	s := httptest.NewServer(y)
+	defer s.Close()
+
+	var hints []http.Header
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			if code == http.StatusEarlyHints {
+				hints = append(hints, http.Header(header))
+			}
+			return nil
+		},
+	}
+	req, _ := http.NewRequest("GET", s.URL+"/", nil)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	early := []string{
+		"</static/app.css>; rel=preload; as=style",
+		"</static/app.js?v=2>; rel=preload; as=script",
+	}
+	if len(hints) != 1 || !reflect.DeepEqual(hints[0].Values("Link"), early) {
+		t.Errorf("Unexpected early hints %v", hints)
+	}
+
+	final := append(early,
+		"</static/font.woff2>; rel=preload; as=font; crossorigin",
+		"</next>; rel=prefetch",
+	)
+	if res.StatusCode != http.StatusOK || !reflect.DeepEqual(res.Header.Values("Link"), final) {
+		t.Errorf("Unexpected final response %d %v", res.StatusCode, res.Header.Values("Link"))
+	}
+	if m.get("yarf_requests_total{GET,/,200}") != 1 {
+		t.Errorf("Early hints shouldn't be measured as the response status, got %v", m.values)
+	}
+}
+
+func TestEarlyHintsHTTP10(t *testing.T) {
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/1.0", 1, 0
+	c := NewContext(req, res)
+
+	c.EarlyHints(`</app.js>; rel=modulepreload`)
+	c.Render("page")
+
+	if res.Code != http.StatusOK || res.Header().Get("Link") != "</app.js>; rel=modulepreload" {
+		t.Errorf("Expected the links in the final response only, got %d %v", res.Code, res.Header())
+	}
+}
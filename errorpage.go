@@ -0,0 +1,135 @@
+package yarf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"runtime/debug"
+	"strings"
+)
+
+// PanicError is the error created from a recovered panic in debug mode, or in a middleware.
+type PanicError struct {
+	Value      interface{} // Value passed to panic()
+	Stack      []byte      // Stack trace of the panic
+	Middleware string      // Type of the middleware that panicked, empty for handler panics
+	Stage      string      // Middleware method that panicked: PreDispatch, PostDispatch or End
+}
+
+// Error describes the panic value, and the middleware that panicked.
+func (e *PanicError) Error() string {
+	if e.Middleware != "" {
+		return fmt.Sprintf("panic in %s %s: %v", e.Middleware, e.Stage, e.Value)
+	}
+
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// debugInfo holds the details rendered on debug error pages.
+type debugInfo struct {
+	Status  int               `json:"status"`
+	Error   string            `json:"error"`
+	Type    string            `json:"type"`
+	Body    string            `json:"body,omitempty"`
+	Causes  []string          `json:"causes,omitempty"`
+	Route   string            `json:"route,omitempty"`
+	Params  map[string]string `json:"params,omitempty"`
+	Request string            `json:"request"`
+	Curl    string            `json:"curl"`
+	Stack   string            `json:"stack,omitempty"`
+	Policy  []string          `json:"policy,omitempty"`
+}
+
+// newDebugInfo collects the error and request details.
+func newDebugInfo(c *Context, err error, yerr YError) *debugInfo {
+	info := &debugInfo{
+		Status: yerr.Code(),
+		Error:  err.Error(),
+		Type:   fmt.Sprintf("%T", err),
+		Body:   yerr.Body(),
+		Route:  c.RoutePattern(),
+	}
+
+	for e := errors.Unwrap(err); e != nil; e = errors.Unwrap(e) {
+		info.Causes = append(info.Causes, e.Error())
+	}
+
+	if len(c.Params) > 0 {
+		info.Params = c.redactor().RouteParams(c.Params)
+	}
+
+	dump := c.Dump()
+	info.Request = dump.String()
+	info.Curl = dump.Curl()
+
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		info.Stack = string(pe.Stack)
+	}
+
+	var pol *PolicyError
+	if errors.As(err, &pol) {
+		info.Policy = pol.Decision.Trace
+	}
+
+	return info
+}
+
+// debugPage is the HTML template of debug error pages.
+var debugPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.Status}} {{.Error}}</title>
+<style>body{font-family:sans-serif;margin:2em}pre{background:#f4f4f4;padding:1em;overflow:auto}th{text-align:left;padding-right:1em}</style>
+</head>
+<body>
+<h1>{{.Status}} {{.Error}}</h1>
+<table>
+<tr><th>Type</th><td>{{.Type}}</td></tr>
+{{if .Route}}<tr><th>Route</th><td>{{.Route}}</td></tr>{{end}}
+{{range $k, $v := .Params}}<tr><th>:{{$k}}</th><td>{{$v}}</td></tr>{{end}}
+</table>
+{{if .Causes}}<h2>Causes</h2><ul>{{range .Causes}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{if .Policy}}<h2>Policy trace</h2><ol>{{range .Policy}}<li>{{.}}</li>{{end}}</ol>{{end}}
+{{if .Body}}<h2>Body</h2><pre>{{.Body}}</pre>{{end}}
+{{if .Stack}}<h2>Stack trace</h2><pre>{{.Stack}}</pre>{{end}}
+<h2>Request</h2><pre>{{.Request}}</pre>
+<h2>Reproduce</h2><pre>{{.Curl}}</pre>
+</body>
+</html>
+`))
+
+// renderDebugError writes the debug error page, as HTML for browsers and JSON for other clients.
+func renderDebugError(c *Context, err error, yerr YError) {
+	info := newDebugInfo(c, err, yerr)
+
+	c.Response.Header().Del("Content-Length")
+	c.Response.Header().Set("Cache-Control", "no-store")
+
+	if strings.Contains(c.Request.Header.Get("Accept"), "text/html") {
+		c.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
+		c.Response.WriteHeader(yerr.Code())
+		debugPage.Execute(c.Response, info)
+		return
+	}
+
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.Response.WriteHeader(yerr.Code())
+	enc := json.NewEncoder(c.Response)
+	enc.SetIndent("", "  ")
+	enc.Encode(info)
+}
+
+// recoverDebug recovers panics in debug mode and renders them as errors.
+func (y *Yarf) recoverDebug(c *Context, local []MiddlewareHandler) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	err := &PanicError{Value: r, Stack: debug.Stack()}
+	y.finish(c, err)
+	y.reportError(c, err)
+	c.err = err
+	y.endDispatch(c, local)
+}
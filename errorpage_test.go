@@ -0,0 +1,67 @@
+package yarf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type PanicResource struct {
+	Resource
+}
+
+func (r *PanicResource) Get(c *Context) error {
+	panic("something broke")
+}
+
+func TestDebugErrorPages(t *testing.T) {
+	g := RouteGroup("/api")
+	g.Add("/items/:id", new(FailingResource))
+	g.Add("/panic", new(PanicResource))
+
+	y := New()
+	y.Debug = true
+	y.AddGroup(g)
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/api/items/3", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	var info debugInfo
+	if err := json.Unmarshal(res.Body.Bytes(), &info); err != nil {
+		t.Fatalf("Expected JSON error page, got '%s': %s", res.Body.String(), err)
+	}
+	if res.Code != 500 || info.Error != "database is down" || info.Route != "/api/items/:id" || info.Params["id"] != "3" {
+		t.Errorf("Unexpected debug info %d: %+v", res.Code, info)
+	}
+	if !strings.HasPrefix(info.Request, "GET /api/items/3") {
+		t.Errorf("Expected request dump, got '%s'", info.Request)
+	}
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/api/panic", nil)
+	req.Header.Set("Accept", "text/html")
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 500 || !strings.Contains(res.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("Expected HTML 500 error page, got %d %s", res.Code, res.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(res.Body.String(), "panic: something broke") || !strings.Contains(res.Body.String(), "Stack trace") {
+		t.Errorf("Expected panic details on the page, got '%s'", res.Body.String())
+	}
+}
+
+func TestProductionErrors(t *testing.T) {
+	y := New()
+	y.Add("/fail", new(FailingResource))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/fail", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 500 || res.Body.String() != "" {
+		t.Errorf("Error details shouldn't be sent outside debug mode, got %d '%s'", res.Code, res.Body.String())
+	}
+}
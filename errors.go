@@ -90,3 +90,239 @@ func ErrorNotFound() *NotFoundError {
 
 	return e
 }
+
+// RouteNotFoundError is the 404 error of the requests that don't match any route.
+// Handlers report missing resources with NotFoundError instead.
+type RouteNotFoundError struct {
+	CustomError
+}
+
+// ErrorRouteNotFound creates RouteNotFoundError
+func ErrorRouteNotFound() *RouteNotFoundError {
+	e := new(RouteNotFoundError)
+	e.HTTPCode = http.StatusNotFound
+	e.ErrorCode = 14
+	e.ErrorMsg = "Route not found"
+
+	return e
+}
+
+// ServiceUnavailableError is the HTTP 503 error equivalent.
+type ServiceUnavailableError struct {
+	CustomError
+}
+
+// ErrorServiceUnavailable creates ServiceUnavailableError
+func ErrorServiceUnavailable() *ServiceUnavailableError {
+	e := new(ServiceUnavailableError)
+	e.HTTPCode = http.StatusServiceUnavailable
+	e.ErrorCode = 3
+	e.ErrorMsg = "Service unavailable"
+
+	return e
+}
+
+// TooManyRequestsError is the HTTP 429 error equivalent.
+type TooManyRequestsError struct {
+	CustomError
+}
+
+// ErrorTooManyRequests creates TooManyRequestsError
+func ErrorTooManyRequests() *TooManyRequestsError {
+	e := new(TooManyRequestsError)
+	e.HTTPCode = http.StatusTooManyRequests
+	e.ErrorCode = 4
+	e.ErrorMsg = "Too many requests"
+
+	return e
+}
+
+// RedirectError stops the request flow and redirects the client to another URL.
+// It can be returned by resources and middleware.
+type RedirectError struct {
+	CustomError
+
+	URL string // Redirect location
+}
+
+// ErrorRedirect creates RedirectError for the URL and 3xx status code provided.
+// Codes that aren't redirections are replaced by 302 Found.
+func ErrorRedirect(url string, code int) *RedirectError {
+	e := new(RedirectError)
+	e.HTTPCode = redirectCode(code)
+	e.ErrorCode = 5
+	e.ErrorMsg = "Redirect to " + url
+	e.URL = url
+
+	return e
+}
+
+// BadGatewayError is the HTTP 502 error equivalent, used when an upstream service fails.
+type BadGatewayError struct {
+	CustomError
+}
+
+// ErrorBadGateway creates BadGatewayError
+func ErrorBadGateway() *BadGatewayError {
+	e := new(BadGatewayError)
+	e.HTTPCode = http.StatusBadGateway
+	e.ErrorCode = 6
+	e.ErrorMsg = "Bad gateway"
+
+	return e
+}
+
+// GatewayTimeoutError is the HTTP 504 error equivalent, used when an upstream service doesn't respond in time.
+type GatewayTimeoutError struct {
+	CustomError
+}
+
+// ErrorGatewayTimeout creates GatewayTimeoutError
+func ErrorGatewayTimeout() *GatewayTimeoutError {
+	e := new(GatewayTimeoutError)
+	e.HTTPCode = http.StatusGatewayTimeout
+	e.ErrorCode = 7
+	e.ErrorMsg = "Gateway timeout"
+
+	return e
+}
+
+// UnauthorizedError is the HTTP 401 error equivalent, used when the request credentials are missing or invalid.
+type UnauthorizedError struct {
+	CustomError
+}
+
+// ErrorUnauthorized creates UnauthorizedError
+func ErrorUnauthorized() *UnauthorizedError {
+	e := new(UnauthorizedError)
+	e.HTTPCode = http.StatusUnauthorized
+	e.ErrorCode = 8
+	e.ErrorMsg = "Unauthorized"
+
+	return e
+}
+
+// BadRequestError is the HTTP 400 error equivalent, used when the request can't be processed as sent.
+type BadRequestError struct {
+	CustomError
+}
+
+// ErrorBadRequest creates BadRequestError
+func ErrorBadRequest() *BadRequestError {
+	e := new(BadRequestError)
+	e.HTTPCode = http.StatusBadRequest
+	e.ErrorCode = 9
+	e.ErrorMsg = "Bad request"
+
+	return e
+}
+
+// ForbiddenError is the HTTP 403 error equivalent, used when the request credentials don't grant access to the resource.
+type ForbiddenError struct {
+	CustomError
+}
+
+// ErrorForbidden creates ForbiddenError
+func ErrorForbidden() *ForbiddenError {
+	e := new(ForbiddenError)
+	e.HTTPCode = http.StatusForbidden
+	e.ErrorCode = 10
+	e.ErrorMsg = "Forbidden"
+
+	return e
+}
+
+// RequestHeaderFieldsTooLargeError is the HTTP 431 error equivalent, used when the request headers exceed the server limits.
+type RequestHeaderFieldsTooLargeError struct {
+	CustomError
+}
+
+// ErrorRequestHeaderFieldsTooLarge creates RequestHeaderFieldsTooLargeError
+func ErrorRequestHeaderFieldsTooLarge() *RequestHeaderFieldsTooLargeError {
+	e := new(RequestHeaderFieldsTooLargeError)
+	e.HTTPCode = http.StatusRequestHeaderFieldsTooLarge
+	e.ErrorCode = 11
+	e.ErrorMsg = "Request header fields too large"
+
+	return e
+}
+
+// URITooLongError is the HTTP 414 error equivalent, used when the request URL exceeds the server limits.
+type URITooLongError struct {
+	CustomError
+}
+
+// ErrorURITooLong creates URITooLongError
+func ErrorURITooLong() *URITooLongError {
+	e := new(URITooLongError)
+	e.HTTPCode = http.StatusRequestURITooLong
+	e.ErrorCode = 12
+	e.ErrorMsg = "URI too long"
+
+	return e
+}
+
+// PreconditionRequiredError is the HTTP 428 error equivalent, used when a required conditional header is missing.
+type PreconditionRequiredError struct {
+	CustomError
+}
+
+// ErrorPreconditionRequired creates PreconditionRequiredError
+func ErrorPreconditionRequired() *PreconditionRequiredError {
+	e := new(PreconditionRequiredError)
+	e.HTTPCode = http.StatusPreconditionRequired
+	e.ErrorCode = 15
+	e.ErrorMsg = "Precondition required"
+
+	return e
+}
+
+// ConflictError is the HTTP 409 error equivalent, used when the request conflicts with the current state of the resource.
+type ConflictError struct {
+	CustomError
+}
+
+// ErrorConflict creates ConflictError
+func ErrorConflict() *ConflictError {
+	e := new(ConflictError)
+	e.HTTPCode = http.StatusConflict
+	e.ErrorCode = 16
+	e.ErrorMsg = "Conflict"
+
+	return e
+}
+
+// UnprocessableEntityError is the HTTP 422 error equivalent, used when the request is well-formed but can't be processed.
+type UnprocessableEntityError struct {
+	CustomError
+}
+
+// ErrorUnprocessableEntity creates UnprocessableEntityError
+func ErrorUnprocessableEntity() *UnprocessableEntityError {
+	e := new(UnprocessableEntityError)
+	e.HTTPCode = http.StatusUnprocessableEntity
+	e.ErrorCode = 17
+	e.ErrorMsg = "Unprocessable entity"
+
+	return e
+}
+
+// StatusClientClosedRequest is the non-standard status of the requests abandoned by the client
+// before the response was sent, as recorded by nginx.
+const StatusClientClosedRequest = 499
+
+// ClientClosedRequestError is the 499 error of the requests whose client went away.
+// No response is written for it.
+type ClientClosedRequestError struct {
+	CustomError
+}
+
+// ErrorClientClosedRequest creates ClientClosedRequestError
+func ErrorClientClosedRequest() *ClientClosedRequestError {
+	e := new(ClientClosedRequestError)
+	e.HTTPCode = StatusClientClosedRequest
+	e.ErrorCode = 13
+	e.ErrorMsg = "Client closed request"
+
+	return e
+}
@@ -21,4 +21,74 @@ func TestErrors(t *testing.T) {
 	if e == nil {
 		t.Error("ErrorNotFound() should return an object. Nil value returned.")
 	}
+
+	e = ErrorRouteNotFound()
+	if e == nil {
+		t.Error("ErrorRouteNotFound() should return an object. Nil value returned.")
+	}
+
+	e = ErrorServiceUnavailable()
+	if e == nil {
+		t.Error("ErrorServiceUnavailable() should return an object. Nil value returned.")
+	}
+
+	e = ErrorTooManyRequests()
+	if e == nil {
+		t.Error("ErrorTooManyRequests() should return an object. Nil value returned.")
+	}
+
+	e = ErrorRedirect("/", 302)
+	if e == nil {
+		t.Error("ErrorRedirect() should return an object. Nil value returned.")
+	}
+
+	e = ErrorBadGateway()
+	if e == nil {
+		t.Error("ErrorBadGateway() should return an object. Nil value returned.")
+	}
+
+	e = ErrorGatewayTimeout()
+	if e == nil {
+		t.Error("ErrorGatewayTimeout() should return an object. Nil value returned.")
+	}
+
+	e = ErrorUnauthorized()
+	if e == nil {
+		t.Error("ErrorUnauthorized() should return an object. Nil value returned.")
+	}
+
+	e = ErrorBadRequest()
+	if e == nil {
+		t.Error("ErrorBadRequest() should return an object. Nil value returned.")
+	}
+
+	e = ErrorForbidden()
+	if e == nil {
+		t.Error("ErrorForbidden() should return an object. Nil value returned.")
+	}
+
+	e = ErrorRequestHeaderFieldsTooLarge()
+	if e == nil {
+		t.Error("ErrorRequestHeaderFieldsTooLarge() should return an object. Nil value returned.")
+	}
+
+	e = ErrorURITooLong()
+	if e == nil {
+		t.Error("ErrorURITooLong() should return an object. Nil value returned.")
+	}
+
+	e = ErrorClientClosedRequest()
+	if e == nil {
+		t.Error("ErrorClientClosedRequest() should return an object. Nil value returned.")
+	}
+
+	e = ErrorConflict()
+	if e == nil {
+		t.Error("ErrorConflict() should return an object. Nil value returned.")
+	}
+
+	e = ErrorUnprocessableEntity()
+	if e == nil {
+		t.Error("ErrorUnprocessableEntity() should return an object. Nil value returned.")
+	}
 }
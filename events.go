@@ -0,0 +1,164 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// EventHandler handles an event emitted on a Bus.
+type EventHandler func(ctx context.Context, payload interface{}) error
+
+// subscription is an EventHandler registered on a Bus.
+type subscription struct {
+	fn    EventHandler
+	async bool
+}
+
+// Bus is a lightweight in-process publish/subscribe bus, used to decouple side effects,
+// like sending emails or invalidating caches, from the handlers triggering them.
+// Sync handlers run in the Emit call, in registration order.
+// Async handlers run in the background, without blocking Emit.
+type Bus struct {
+	handlers map[string][]subscription
+
+	// enqueue runs the async handlers. Defaults to a new goroutine for each one.
+	enqueue func(Job) error
+
+	// app is the Yarf instance owning the bus, whose logger records the async handler failures.
+	app *Yarf
+
+	lock sync.RWMutex
+}
+
+// NewBus creates a Bus without handlers.
+func NewBus() *Bus {
+	return &Bus{
+		handlers: make(map[string][]subscription),
+	}
+}
+
+// On registers a handler running synchronously when the event is emitted.
+func (b *Bus) On(event string, fn EventHandler) {
+	b.subscribe(event, subscription{fn: fn})
+}
+
+// OnAsync registers a handler running in the background when the event is emitted.
+// Errors and panics of async handlers are logged with the framework logger, or slog.Default() for buses
+// outside an application, and don't reach the emitter.
+func (b *Bus) OnAsync(event string, fn EventHandler) {
+	b.subscribe(event, subscription{fn: fn, async: true})
+}
+
+// subscribe adds a subscription to the event.
+func (b *Bus) subscribe(event string, s subscription) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.handlers[event] = append(b.handlers[event], s)
+}
+
+// Emit delivers the event to its handlers. All sync handlers run, and their errors are returned together.
+// Async handlers receive a context that isn't canceled with ctx, as they may outlive it.
+func (b *Bus) Emit(ctx context.Context, event string, payload interface{}) error {
+	b.lock.RLock()
+	subs := b.handlers[event]
+	enqueue := b.enqueue
+	app := b.app
+	b.lock.RUnlock()
+
+	var errs []error
+
+	for _, s := range subs {
+		if !s.async {
+			if err := s.fn(ctx, payload); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		job := asyncEvent(event, s.fn, payload)
+		if enqueue == nil {
+			go func() {
+				if err := job.Run(context.WithoutCancel(ctx)); err != nil {
+					var log Logger = slog.Default()
+					if app != nil {
+						log = app.log()
+					}
+					log.Error("event handler failed", "error", err)
+				}
+			}()
+		} else if err := enqueue(job); err != nil {
+			errs = append(errs, fmt.Errorf("event %s: %w", event, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// asyncEvent wraps an async handler into a Job, recovering panics.
+func asyncEvent(event string, fn EventHandler, payload interface{}) JobFunc {
+	return func(ctx context.Context) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("event %s: handler panic: %v", event, r)
+			}
+		}()
+
+		if err = fn(ctx, payload); err != nil {
+			err = fmt.Errorf("event %s: %w", event, err)
+		}
+
+		return err
+	}
+}
+
+// busKey is the Context storage key for the event bus.
+type busKey struct{}
+
+// eventBus returns the bus of the Yarf instance, creating it on first use.
+// Async handlers run on the job queue.
+func (y *Yarf) eventBus() *Bus {
+	y.lock.Lock()
+	defer y.lock.Unlock()
+
+	if y.bus == nil {
+		y.bus = NewBus()
+		y.bus.enqueue = y.Enqueue
+		y.bus.app = y
+	}
+
+	return y.bus
+}
+
+// On registers a handler running synchronously when the event is emitted.
+//
+//	y.On("user.created", func(ctx context.Context, payload interface{}) error {
+//		return cache.Invalidate("users")
+//	})
+func (y *Yarf) On(event string, fn EventHandler) {
+	y.eventBus().On(event, fn)
+}
+
+// OnAsync registers a handler running on the job queue when the event is emitted.
+func (y *Yarf) OnAsync(event string, fn EventHandler) {
+	y.eventBus().OnAsync(event, fn)
+}
+
+// Emit delivers the event to the handlers registered on the Yarf instance.
+func (y *Yarf) Emit(event string, payload interface{}) error {
+	return y.eventBus().Emit(context.Background(), event, payload)
+}
+
+// Emit delivers the event to the handlers registered on the Yarf instance serving the request,
+// with the request context. It does nothing if no handlers were registered.
+func (c *Context) Emit(event string, payload interface{}) error {
+	b, _ := c.get(busKey{}).(*Bus)
+	if b == nil {
+		return nil
+	}
+
+	return b.Emit(c.Request.Context(), event, payload)
+}
@@ -0,0 +1,160 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBusSync(t *testing.T) {
+	b := NewBus()
+
+	var calls []string
+	b.On("user.created", func(ctx context.Context, payload interface{}) error {
+		calls = append(calls, "first:"+payload.(string))
+		return errors.New("first failed")
+	})
+	b.On("user.created", func(ctx context.Context, payload interface{}) error {
+		calls = append(calls, "second:"+payload.(string))
+		return nil
+	})
+	b.On("user.deleted", func(ctx context.Context, payload interface{}) error {
+		calls = append(calls, "deleted")
+		return nil
+	})
+
+	err := b.Emit(context.Background(), "user.created", "jane")
+	if err == nil || !strings.Contains(err.Error(), "first failed") {
+		t.Errorf("Expected sync handler error, got %v", err)
+	}
+	if strings.Join(calls, ",") != "first:jane,second:jane" {
+		t.Errorf("All sync handlers should run in order, got %v", calls)
+	}
+
+	if err := b.Emit(context.Background(), "unknown", nil); err != nil {
+		t.Errorf("Events without handlers shouldn't fail, got %v", err)
+	}
+}
+
+func TestBusAsync(t *testing.T) {
+	b := NewBus()
+
+	done := make(chan interface{}, 2)
+	b.OnAsync("order.paid", func(ctx context.Context, payload interface{}) error {
+		done <- payload
+		return errors.New("ignored")
+	})
+	b.OnAsync("order.paid", func(ctx context.Context, payload interface{}) error {
+		panic("boom")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := b.Emit(ctx, "order.paid", 42); err != nil {
+		t.Errorf("Async handler errors shouldn't reach the emitter, got %v", err)
+	}
+	cancel()
+
+	select {
+	case p := <-done:
+		if p != 42 {
+			t.Errorf("Unexpected payload %v", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Async handler didn't run")
+	}
+}
+
+func TestAsyncEventJob(t *testing.T) {
+	job := asyncEvent("boom", func(ctx context.Context, payload interface{}) error {
+		panic("boom")
+	}, nil)
+
+	if err := job.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "panic") {
+		t.Errorf("Expected panic error, got %v", err)
+	}
+}
+
+// EmitResource emits an event with the request path.
+type EmitResource struct {
+	Resource
+}
+
+func (r *EmitResource) Get(c *Context) error {
+	return c.Emit("visited", c.Request.URL.Path)
+}
+
+func TestYarfEvents(t *testing.T) {
+	q := new(recordQueue)
+
+	y := New()
+	y.SetJobQueue(q)
+	y.Add("/page", new(EmitResource))
+
+	// Without handlers
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/page", nil))
+	if res.Code != 200 {
+		t.Errorf("Expected status 200 without handlers, got %d", res.Code)
+	}
+
+	var visited string
+	y.On("visited", func(ctx context.Context, payload interface{}) error {
+		visited = payload.(string)
+		return nil
+	})
+	y.OnAsync("visited", func(ctx context.Context, payload interface{}) error {
+		return nil
+	})
+
+	y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/page", nil))
+	if visited != "/page" {
+		t.Errorf("Sync handler should receive the payload, got %s", visited)
+	}
+	if q.len() != 1 {
+		t.Errorf("Async handlers should run on the job queue, got %d jobs", q.len())
+	}
+
+	if err := y.Emit("visited", "/direct"); err != nil || visited != "/direct" {
+		t.Errorf("Unexpected Emit result %v, %s", err, visited)
+	}
+
+	y.On("failing", func(ctx context.Context, payload interface{}) error {
+		return errors.New("failed")
+	})
+	if err := y.Emit("failing", nil); err == nil {
+		t.Error("Expected error from sync handler")
+	}
+}
+
+// chanLogger sends the messages of the errors logged to a channel.
+type chanLogger chan string
+
+func (l chanLogger) Debug(msg string, fields ...interface{}) {}
+func (l chanLogger) Info(msg string, fields ...interface{})  {}
+func (l chanLogger) Error(msg string, fields ...interface{}) { l <- msg }
+
+func TestBusLogger(t *testing.T) {
+	l := make(chanLogger, 1)
+	y := New()
+	y.Log = l
+
+	// Async handlers on their own goroutine, without the job queue
+	b := y.eventBus()
+	b.enqueue = nil
+	b.OnAsync("user.created", func(ctx context.Context, payload interface{}) error {
+		return errors.New("smtp down")
+	})
+	b.Emit(context.Background(), "user.created", nil)
+
+	select {
+	case msg := <-l:
+		if msg != "event handler failed" {
+			t.Errorf("Unexpected log message %q", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the handler failure logged with the framework logger")
+	}
+}
@@ -0,0 +1,236 @@
+package yarf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldMask is a parsed partial response selector, as in ?fields=name,address(city,zip),items/id.
+// Each key maps to the mask applied to its value, where a nil mask keeps the whole value.
+// The "*" key selects all the fields of an object.
+type FieldMask map[string]FieldMask
+
+// ParseFields parses a Google-style partial response selector:
+//
+//	name,email            top level fields
+//	address(city,zip)     sub-selection of an object, or of each object in an array
+//	address/city          same as address(city)
+//	items(*,author(name)) all fields, replacing some of them with a sub-selection
+//
+// An empty selector returns a nil mask, which keeps everything.
+func ParseFields(s string) (FieldMask, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	p := &fieldsParser{s: s}
+	m, err := p.list()
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.s) {
+		return nil, p.errorf("unexpected ')'")
+	}
+
+	return m, nil
+}
+
+// fieldsParser is a recursive descent parser for field selectors.
+type fieldsParser struct {
+	s string
+	i int
+}
+
+// errorf returns a syntax error at the current position.
+func (p *fieldsParser) errorf(msg string) error {
+	return errors.New("yarf: invalid fields selector at position " + strconv.Itoa(p.i) + ": " + msg)
+}
+
+// list parses a comma separated list of fields, stopping at ')' or the end.
+func (p *fieldsParser) list() (FieldMask, error) {
+	m := FieldMask{}
+
+	for {
+		if err := p.field(m); err != nil {
+			return nil, err
+		}
+
+		if p.i >= len(p.s) || p.s[p.i] == ')' {
+			return m, nil
+		}
+		p.i++ // ','
+	}
+}
+
+// field parses a field path with an optional sub-selection, and merges it into m.
+func (p *fieldsParser) field(m FieldMask) error {
+	start := p.i
+	for p.i < len(p.s) && !strings.ContainsRune(",()/", rune(p.s[p.i])) {
+		p.i++
+	}
+
+	name := strings.TrimSpace(p.s[start:p.i])
+	if name == "" {
+		return p.errorf("empty field name")
+	}
+
+	var sub FieldMask
+	if p.i < len(p.s) {
+		switch p.s[p.i] {
+		case '/':
+			p.i++
+			sub = FieldMask{}
+			if err := p.field(sub); err != nil {
+				return err
+			}
+		case '(':
+			p.i++
+			var err error
+			if sub, err = p.list(); err != nil {
+				return err
+			}
+			if p.i >= len(p.s) || p.s[p.i] != ')' {
+				return p.errorf("missing ')'")
+			}
+			p.i++
+		}
+	}
+
+	m.merge(name, sub)
+
+	return nil
+}
+
+// merge adds a field to the mask. Selecting the whole field takes precedence over sub-selections.
+func (m FieldMask) merge(name string, sub FieldMask) {
+	cur, ok := m[name]
+	switch {
+	case !ok:
+		m[name] = sub
+	case cur == nil || sub == nil:
+		m[name] = nil
+	default:
+		for k, v := range sub {
+			cur.merge(k, v)
+		}
+	}
+}
+
+// String returns the selector of the mask, with the fields sorted.
+func (m FieldMask) String() string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		if m[k] != nil {
+			keys[i] = k + "(" + m[k].String() + ")"
+		}
+	}
+
+	return strings.Join(keys, ",")
+}
+
+// Prune removes the fields not selected by the mask from a decoded JSON value.
+// Arrays are pruned item by item, and scalar values are kept.
+func (m FieldMask) Prune(v interface{}) interface{} {
+	if m == nil {
+		return v
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		all, wildcard := m["*"]
+		for k, e := range v {
+			sub, ok := m[k]
+			if !ok && !wildcard {
+				delete(v, k)
+				continue
+			}
+			if !ok {
+				sub = all
+			}
+			v[k] = sub.Prune(e)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = m.Prune(v[i])
+		}
+	}
+
+	return v
+}
+
+// PruneJSON prunes an encoded JSON document. Invalid documents are returned unchanged.
+func (m FieldMask) PruneJSON(data []byte) []byte {
+	if m == nil {
+		return data
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return data
+	}
+
+	pruned, err := json.Marshal(m.Prune(v))
+	if err != nil {
+		return data
+	}
+
+	return pruned
+}
+
+// fieldMaskKey is the Context storage key for the partial response field mask.
+type fieldMaskKey struct{}
+
+// Fields returns the field mask requested by the client, set by the PartialResponse middleware.
+// Returns nil if partial responses aren't enabled or the client didn't select any field.
+// Handlers can use it to skip loading data that won't be rendered.
+func (c *Context) Fields() FieldMask {
+	m, _ := c.get(fieldMaskKey{}).(FieldMask)
+
+	return m
+}
+
+// PartialResponse is a middleware that enables partial responses on the routes it covers.
+// Clients select the fields of JSON responses with a query param, as in ?fields=name,address(city),
+// and the JSON render methods prune the encoded response to those fields, without handler changes.
+// Invalid selectors get a 400 error.
+type PartialResponse struct {
+	Middleware
+
+	// Param is the query param holding the selector.
+	Param string
+}
+
+// NewPartialResponse creates a PartialResponse reading the "fields" query param.
+func NewPartialResponse() *PartialResponse {
+	return &PartialResponse{
+		Param: "fields",
+	}
+}
+
+// PreDispatch parses the selector.
+func (m *PartialResponse) PreDispatch(c *Context) error {
+	mask, err := ParseFields(c.QueryValue(m.Param))
+	if err != nil {
+		e := ErrorBadRequest()
+		e.ErrorBody = err.Error()
+		return e
+	}
+
+	if mask != nil {
+		c.set(fieldMaskKey{}, mask)
+	}
+
+	return nil
+}
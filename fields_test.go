@@ -0,0 +1,142 @@
+package yarf
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// ArticleResource renders a JSON list of articles.
+type ArticleResource struct {
+	Resource
+}
+
+func (r *ArticleResource) Get(c *Context) error {
+	articles := []map[string]interface{}{
+		{"id": 1, "title": "Hello", "body": "...", "author": map[string]interface{}{"name": "John", "email": "john@example.com"}},
+		{"id": 2, "title": "World", "body": "...", "author": map[string]interface{}{"name": "Jane", "email": "jane@example.com"}},
+	}
+
+	if c.QueryValue("indent") != "" {
+		c.RenderJSONIndent(articles)
+	} else {
+		c.RenderJSON(articles)
+	}
+	return nil
+}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+	}{
+		{"name", "name"},
+		{"name,email", "email,name"},
+		{" name , email ", "email,name"},
+		{"address(city,zip)", "address(city,zip)"},
+		{"address/city", "address(city)"},
+		{"address/city,address/zip", "address(city,zip)"},
+		{"address(city),address", "address"},
+		{"items(id,author(name)),total", "items(author(name),id),total"},
+		{"items/author/name", "items(author(name))"},
+		{"*,author(name)", "*,author(name)"},
+	}
+
+	for _, tt := range tests {
+		m, err := ParseFields(tt.in)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %q: %s", tt.in, err)
+			continue
+		}
+		if m.String() != tt.expected {
+			t.Errorf("Expected %q parsing %q, got %q", tt.expected, tt.in, m.String())
+		}
+	}
+
+	for _, in := range []string{"a,", ",a", "a(b", "a)", "a(b))", "a()", "a/", "(a)"} {
+		if _, err := ParseFields(in); err == nil {
+			t.Errorf("Expected error parsing %q", in)
+		}
+	}
+
+	if m, err := ParseFields(""); m != nil || err != nil {
+		t.Error("Empty selector should return a nil mask")
+	}
+}
+
+func TestFieldMaskPrune(t *testing.T) {
+	doc := `{"id":1,"name":"John","address":{"city":"Paris","zip":"75000"},"tags":[{"id":1,"label":"a"}]}`
+
+	tests := []struct {
+		fields   string
+		expected string
+	}{
+		{"", doc},
+		{"id,name", `{"id":1,"name":"John"}`},
+		{"address(city)", `{"address":{"city":"Paris"}}`},
+		{"name/first", `{"name":"John"}`},
+		{"tags/label,missing", `{"tags":[{"label":"a"}]}`},
+		{"*,address(zip)", `{"id":1,"name":"John","address":{"zip":"75000"},"tags":[{"id":1,"label":"a"}]}`},
+	}
+
+	for _, tt := range tests {
+		m, _ := ParseFields(tt.fields)
+		if res := m.PruneJSON([]byte(doc)); !jsonEqual(string(res), tt.expected) {
+			t.Errorf("Expected %s for %q, got %s", tt.expected, tt.fields, res)
+		}
+	}
+
+	m, _ := ParseFields("id")
+	if res := m.PruneJSON([]byte("not json")); string(res) != "not json" {
+		t.Error("Invalid JSON should be unchanged")
+	}
+	if res := m.PruneJSON([]byte(`{"id":12345678901234567890}`)); string(res) != `{"id":12345678901234567890}` {
+		t.Errorf("Numbers should keep their precision, got %s", res)
+	}
+}
+
+func TestPartialResponse(t *testing.T) {
+	y := New()
+	y.Use(NewPartialResponse())
+	y.Add("/articles", new(ArticleResource))
+
+	tests := []struct {
+		path     string
+		status   int
+		expected string
+	}{
+		{"/articles?fields=id,author(name)", 200, `[{"id":1,"author":{"name":"John"}},{"id":2,"author":{"name":"Jane"}}]`},
+		{"/articles?fields=title&indent=1", 200, `[{"title":"Hello"},{"title":"World"}]`},
+		{"/articles?fields=id(", 400, ""},
+	}
+
+	for _, tt := range tests {
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, httptest.NewRequest("GET", tt.path, nil))
+
+		if res.Code != tt.status {
+			t.Errorf("Expected status %d for %s, got %d", tt.status, tt.path, res.Code)
+		}
+		if tt.expected != "" && !jsonEqual(res.Body.String(), tt.expected) {
+			t.Errorf("Expected %s for %s, got %s", tt.expected, tt.path, res.Body.String())
+		}
+	}
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/articles?fields=title&indent=1", nil))
+	if expected := "[\n  {\n    \"title\": \"Hello\"\n  },\n  {\n    \"title\": \"World\"\n  }\n]"; res.Body.String() != expected {
+		t.Errorf("Indented responses should stay indented, got %s", res.Body.String())
+	}
+}
+
+func TestFieldsWithoutMiddleware(t *testing.T) {
+	y := New()
+	y.Add("/articles", new(ArticleResource))
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/articles?fields=id", nil))
+
+	if !strings.Contains(res.Body.String(), `"title"`) {
+		t.Errorf("Responses shouldn't be pruned without the middleware, got %s", res.Body.String())
+	}
+}
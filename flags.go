@@ -0,0 +1,321 @@
+package yarf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetaRequireFlags is the RouteMeta key holding the feature flags required by a route.
+const MetaRequireFlags = "flags.require"
+
+// FlagTarget identifies who a feature flag is evaluated for.
+type FlagTarget struct {
+	// Key identifies the user or client, used for targeting and percentage rollouts.
+	Key string
+
+	// Tenant the request belongs to, if any.
+	Tenant string
+
+	// Attributes for custom providers, like a plan or a country.
+	Attributes map[string]string
+}
+
+// FlagProvider evaluates feature flags.
+type FlagProvider interface {
+	// Enabled returns true if the flag is enabled for the target.
+	Enabled(ctx context.Context, flag string, t FlagTarget) (bool, error)
+}
+
+// FlagProviderFunc adapts a function into a FlagProvider.
+type FlagProviderFunc func(ctx context.Context, flag string, t FlagTarget) (bool, error)
+
+// Enabled calls f(ctx, flag, t).
+func (f FlagProviderFunc) Enabled(ctx context.Context, flag string, t FlagTarget) (bool, error) {
+	return f(ctx, flag, t)
+}
+
+// FlagRule describes who a feature flag is enabled for.
+// A flag is enabled if Enabled is set, if the target key or tenant is listed,
+// or if the target key falls into the rollout Percentage.
+type FlagRule struct {
+	Enabled    bool     `json:"enabled"`
+	Percentage int      `json:"percentage,omitempty"`
+	Keys       []string `json:"keys,omitempty"`
+	Tenants    []string `json:"tenants,omitempty"`
+}
+
+// evaluate applies the rule to the target.
+func (r FlagRule) evaluate(flag string, t FlagTarget) bool {
+	if r.Enabled {
+		return true
+	}
+	for _, k := range r.Keys {
+		if t.Key != "" && k == t.Key {
+			return true
+		}
+	}
+	for _, tenant := range r.Tenants {
+		if t.Tenant != "" && tenant == t.Tenant {
+			return true
+		}
+	}
+
+	if r.Percentage >= 100 {
+		return true
+	}
+	if r.Percentage > 0 && t.Key != "" {
+		// The same key always gets the same result for a flag
+		h := fnv.New32a()
+		h.Write([]byte(flag + ":" + t.Key))
+		return int(h.Sum32()%100) < r.Percentage
+	}
+
+	return false
+}
+
+// StaticFlags is a FlagProvider with fixed rules. Unknown flags are disabled.
+type StaticFlags map[string]FlagRule
+
+// Enabled evaluates the rule of the flag.
+func (f StaticFlags) Enabled(ctx context.Context, flag string, t FlagTarget) (bool, error) {
+	r, ok := f[flag]
+	if !ok {
+		return false, nil
+	}
+
+	return r.evaluate(flag, t), nil
+}
+
+// EnvFlags is a FlagProvider reading flags from environment variables, like FEATURE_NEW_CHECKOUT for "new-checkout".
+// Values are booleans ("true", "1", "on") or rollout percentages ("25%").
+type EnvFlags struct {
+	// Prefix of the variable names. Defaults to "FEATURE_".
+	Prefix string
+}
+
+// Enabled reads the variable of the flag.
+func (f EnvFlags) Enabled(ctx context.Context, flag string, t FlagTarget) (bool, error) {
+	prefix := f.Prefix
+	if prefix == "" {
+		prefix = "FEATURE_"
+	}
+
+	name := prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(flag))
+	v := strings.TrimSpace(os.Getenv(name))
+	if v == "" {
+		return false, nil
+	}
+
+	if strings.HasSuffix(v, "%") {
+		p, err := strconv.Atoi(strings.TrimSuffix(v, "%"))
+		if err != nil {
+			return false, fmt.Errorf("invalid percentage %q in %s", v, name)
+		}
+		return FlagRule{Percentage: p}.evaluate(flag, t), nil
+	}
+
+	switch strings.ToLower(v) {
+	case "1", "true", "on", "yes":
+		return true, nil
+	case "0", "false", "off", "no":
+		return false, nil
+	}
+
+	return false, fmt.Errorf("invalid value %q in %s", v, name)
+}
+
+// RemoteFlags is a FlagProvider fetching the rules as a JSON object of FlagRule by flag name from a URL,
+// and refreshing them after the Interval. If a refresh fails, the last rules are kept.
+type RemoteFlags struct {
+	// URL of the JSON document.
+	URL string
+
+	// Interval between refreshes. Defaults to 1 minute.
+	Interval time.Duration
+
+	// Client for the requests. Defaults to a client with a 10 seconds timeout.
+	Client *http.Client
+
+	rules   StaticFlags
+	fetched time.Time
+	lock    sync.Mutex
+}
+
+// NewRemoteFlags creates a RemoteFlags provider.
+func NewRemoteFlags(url string) *RemoteFlags {
+	return &RemoteFlags{
+		URL:      url,
+		Interval: time.Minute,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Enabled evaluates the rule of the flag, refreshing the rules if they're stale.
+func (f *RemoteFlags) Enabled(ctx context.Context, flag string, t FlagTarget) (bool, error) {
+	rules, err := f.load(ctx)
+	if rules == nil {
+		return false, err
+	}
+
+	enabled, _ := rules.Enabled(ctx, flag, t)
+
+	return enabled, err
+}
+
+// load returns the rules, fetching them if they're stale.
+func (f *RemoteFlags) load(ctx context.Context) (StaticFlags, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	interval := f.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if f.rules != nil && time.Since(f.fetched) < interval {
+		return f.rules, nil
+	}
+
+	// Don't retry on every evaluation after failures
+	f.fetched = time.Now()
+
+	rules, err := f.fetch(ctx)
+	if err != nil {
+		return f.rules, err
+	}
+	f.rules = rules
+
+	return rules, nil
+}
+
+// fetch downloads the rules.
+func (f *RemoteFlags) fetch(ctx context.Context) (StaticFlags, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", f.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("flags: unexpected status %d from %s", res.StatusCode, f.URL)
+	}
+
+	rules := make(StaticFlags)
+	if err := json.NewDecoder(res.Body).Decode(&rules); err != nil {
+		return nil, fmt.Errorf("flags: %w", err)
+	}
+
+	return rules, nil
+}
+
+// Flags evaluates feature flags for the requests of a Yarf instance.
+type Flags struct {
+	// Provider evaluates the flags.
+	Provider FlagProvider
+
+	// Target resolves who the flags are evaluated for.
+	// Defaults to the client IP as key and the request tenant.
+	Target func(*Context) FlagTarget
+
+	log func() Logger
+}
+
+// flagsKey is the Context storage key for the Flags, and flagResultsKey for the evaluated flags.
+type (
+	flagsKey       struct{}
+	flagResultsKey struct{}
+)
+
+// EnableFlags sets the feature flag provider evaluated by Context.Feature() and route gating,
+// and returns the Flags object to configure the targeting.
+//
+//	f := y.EnableFlags(yarf.EnvFlags{})
+//	f.Target = func(c *yarf.Context) yarf.FlagTarget {
+//		return yarf.FlagTarget{Key: currentUser(c).ID}
+//	}
+func (y *Yarf) EnableFlags(p FlagProvider) *Flags {
+	f := &Flags{
+		Provider: p,
+		log:      y.log,
+	}
+
+	y.lock.Lock()
+	defer y.lock.Unlock()
+
+	y.flags = f
+
+	return f
+}
+
+// Feature returns true if the feature flag is enabled for the request.
+// Each flag is evaluated once per request, so the result is consistent while it runs.
+// Provider errors are logged, and the flag is considered disabled.
+func (c *Context) Feature(flag string) bool {
+	f, _ := c.get(flagsKey{}).(*Flags)
+	if f == nil || f.Provider == nil {
+		return false
+	}
+
+	results, _ := c.get(flagResultsKey{}).(map[string]bool)
+	if enabled, ok := results[flag]; ok {
+		return enabled
+	}
+
+	t := FlagTarget{Key: c.GetClientIP(), Tenant: c.TenantID()}
+	if f.Target != nil {
+		t = f.Target(c)
+	}
+
+	enabled, err := f.Provider.Enabled(c.Request.Context(), flag, t)
+	if err != nil {
+		f.log().Error("feature flag evaluation failed", "flag", flag, "error", err)
+		enabled = false
+	}
+
+	if results == nil {
+		results = make(map[string]bool)
+		c.set(flagResultsKey{}, results)
+	}
+	results[flag] = enabled
+
+	return enabled
+}
+
+// RequireFlag gates the route behind feature flags. Requests get a 404 error
+// unless all the flags are enabled for them.
+//
+//	y.AddRoute("/checkout/v2", new(Checkout)).RequireFlag("new-checkout")
+func (m *RouteMeta) RequireFlag(flags ...string) *RouteMeta {
+	existing, _ := m.Get(MetaRequireFlags).([]string)
+
+	return m.Set(MetaRequireFlags, append(existing, flags...))
+}
+
+// flagsAllowed checks the flags required by the route.
+func (r *route) flagsAllowed(c *Context) bool {
+	flags, _ := r.meta.Get(MetaRequireFlags).([]string)
+	for _, flag := range flags {
+		if !c.Feature(flag) {
+			return false
+		}
+	}
+
+	return true
+}
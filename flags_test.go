@@ -0,0 +1,219 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFlagRule(t *testing.T) {
+	if !(FlagRule{Enabled: true}).evaluate("f", FlagTarget{}) {
+		t.Error("Enabled rule should be enabled for everyone")
+	}
+	if (FlagRule{}).evaluate("f", FlagTarget{Key: "u1"}) {
+		t.Error("Empty rule should be disabled")
+	}
+	if !(FlagRule{Keys: []string{"u1"}}).evaluate("f", FlagTarget{Key: "u1"}) {
+		t.Error("Listed keys should be enabled")
+	}
+	if !(FlagRule{Tenants: []string{"acme"}}).evaluate("f", FlagTarget{Tenant: "acme"}) {
+		t.Error("Listed tenants should be enabled")
+	}
+	if (FlagRule{Keys: []string{""}}).evaluate("f", FlagTarget{}) {
+		t.Error("Empty keys shouldn't match")
+	}
+
+	// Rollouts are stable and close to the percentage
+	rule := FlagRule{Percentage: 30}
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		target := FlagTarget{Key: fmt.Sprintf("user-%d", i)}
+		result := rule.evaluate("rollout", target)
+		if result != rule.evaluate("rollout", target) {
+			t.Fatal("Rollout should be stable for the same key")
+		}
+		if result {
+			enabled++
+		}
+	}
+	if enabled < 250 || enabled > 350 {
+		t.Errorf("Expected around 300 enabled targets, got %d", enabled)
+	}
+}
+
+func TestEnvFlags(t *testing.T) {
+	t.Setenv("FEATURE_NEW_CHECKOUT", "on")
+	t.Setenv("FEATURE_OLD_CHECKOUT", "false")
+	t.Setenv("FEATURE_EVERYONE", "100%")
+	t.Setenv("FEATURE_BROKEN", "maybe")
+	t.Setenv("APP_BETA", "1")
+
+	f := EnvFlags{}
+	ctx := context.Background()
+
+	tests := map[string]bool{
+		"new-checkout": true,
+		"old-checkout": false,
+		"everyone":     true,
+		"missing":      false,
+	}
+	for flag, expected := range tests {
+		if enabled, err := f.Enabled(ctx, flag, FlagTarget{Key: "u1"}); enabled != expected || err != nil {
+			t.Errorf("Expected %v for %s, got %v, %v", expected, flag, enabled, err)
+		}
+	}
+
+	if _, err := f.Enabled(ctx, "broken", FlagTarget{}); err == nil {
+		t.Error("Invalid values should fail")
+	}
+	if enabled, _ := (EnvFlags{Prefix: "APP_"}).Enabled(ctx, "beta", FlagTarget{}); !enabled {
+		t.Error("Custom prefix should be used")
+	}
+}
+
+func TestRemoteFlags(t *testing.T) {
+	var requests int32
+	var fail int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"beta": {"keys": ["u1"]}, "dark-mode": {"enabled": true}}`))
+	}))
+	defer srv.Close()
+
+	f := NewRemoteFlags(srv.URL)
+	ctx := context.Background()
+
+	if enabled, err := f.Enabled(ctx, "beta", FlagTarget{Key: "u1"}); !enabled || err != nil {
+		t.Errorf("Expected beta enabled for u1, got %v, %v", enabled, err)
+	}
+	if enabled, _ := f.Enabled(ctx, "beta", FlagTarget{Key: "u2"}); enabled {
+		t.Error("Expected beta disabled for u2")
+	}
+	if enabled, _ := f.Enabled(ctx, "dark-mode", FlagTarget{}); !enabled {
+		t.Error("Expected dark-mode enabled")
+	}
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Rules should be cached, got %d requests", requests)
+	}
+
+	// Failed refreshes keep the last rules
+	f.Interval = time.Nanosecond
+	atomic.StoreInt32(&fail, 1)
+	time.Sleep(time.Millisecond)
+	enabled, err := f.Enabled(ctx, "dark-mode", FlagTarget{})
+	if !enabled || err == nil {
+		t.Errorf("Expected last rules and refresh error, got %v, %v", enabled, err)
+	}
+
+	if _, err := NewRemoteFlags("http://127.0.0.1:1").Enabled(ctx, "beta", FlagTarget{}); err == nil {
+		t.Error("Unreachable URL should fail")
+	}
+}
+
+// FeatureResource renders the evaluation of the flag param.
+type FeatureResource struct {
+	Resource
+}
+
+func (r *FeatureResource) Get(c *Context) error {
+	c.Render(fmt.Sprint(c.Feature(c.Param("flag"))))
+	return nil
+}
+
+func TestContextFeature(t *testing.T) {
+	y := New()
+	y.Add("/feature/:flag", new(FeatureResource))
+
+	get := func(path string, user string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("X-User", user)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+		return res
+	}
+
+	if res := get("/feature/beta", "u1"); res.Body.String() != "false" {
+		t.Errorf("Flags should be disabled without provider, got %s", res.Body.String())
+	}
+
+	var evaluations int32
+	f := y.EnableFlags(FlagProviderFunc(func(ctx context.Context, flag string, t FlagTarget) (bool, error) {
+		atomic.AddInt32(&evaluations, 1)
+		if flag == "broken" {
+			return true, errors.New("provider down")
+		}
+		return StaticFlags{"beta": {Keys: []string{"u1"}}}.Enabled(ctx, flag, t)
+	}))
+	f.Target = func(c *Context) FlagTarget {
+		return FlagTarget{Key: c.Request.Header.Get("X-User")}
+	}
+	y.Log = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	if res := get("/feature/beta", "u1"); res.Body.String() != "true" {
+		t.Errorf("Expected beta enabled for u1, got %s", res.Body.String())
+	}
+	if res := get("/feature/beta", "u2"); res.Body.String() != "false" {
+		t.Errorf("Expected beta disabled for u2, got %s", res.Body.String())
+	}
+	if res := get("/feature/broken", "u1"); res.Body.String() != "false" {
+		t.Errorf("Provider errors should disable the flag, got %s", res.Body.String())
+	}
+
+	// Evaluated once per request
+	c := NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+	c.set(flagsKey{}, f)
+	atomic.StoreInt32(&evaluations, 0)
+	c.Feature("beta")
+	c.Feature("beta")
+	if atomic.LoadInt32(&evaluations) != 1 {
+		t.Errorf("Expected one evaluation per request, got %d", evaluations)
+	}
+}
+
+func TestRequireFlag(t *testing.T) {
+	y := New()
+	y.Add("/stable", new(OKResource))
+	y.AddRoute("/beta", new(OKResource)).RequireFlag("beta")
+	y.AddRoute("/both", new(OKResource)).RequireFlag("beta").RequireFlag("dark-mode")
+
+	f := y.EnableFlags(StaticFlags{
+		"beta":      {Keys: []string{"u1"}},
+		"dark-mode": {Enabled: true},
+	})
+	f.Target = func(c *Context) FlagTarget {
+		return FlagTarget{Key: c.Request.Header.Get("X-User")}
+	}
+
+	tests := []struct {
+		path, user string
+		expected   int
+	}{
+		{"/stable", "u2", http.StatusOK},
+		{"/beta", "u1", http.StatusOK},
+		{"/beta", "u2", http.StatusNotFound},
+		{"/both", "u1", http.StatusOK},
+		{"/both", "u2", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", tt.path, nil)
+		req.Header.Set("X-User", tt.user)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Code != tt.expected {
+			t.Errorf("Expected status %d for %s as %s, got %d", tt.expected, tt.path, tt.user, res.Code)
+		}
+	}
+}
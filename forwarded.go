@@ -0,0 +1,207 @@
+package yarf
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// trustKey is the Context storage key for the trusted proxies of the Yarf instance.
+type trustKey struct{}
+
+// proxyTrust is the list of networks of the trusted proxies.
+type proxyTrust []netip.Prefix
+
+// trusts returns true if the address belongs to a trusted proxy.
+func (t proxyTrust) trusts(addr string) bool {
+	ip, err := netip.ParseAddr(strings.Trim(addr, "[]"))
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+
+	for _, p := range t {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TrustProxies sets the IP addresses or CIDR networks of the proxies in front of the application.
+// Once set, the Forwarded and X-Forwarded-* headers are only used when the request comes from a trusted proxy,
+// and the client IP is the first untrusted address in the forwarding chain.
+// Context.Scheme(), Context.Host() and Context.GetClientIP() use this policy,
+// and so do the access log and the HTTPSRedirect middleware.
+// Call it before starting the servers.
+//
+//	y.TrustProxies("10.0.0.0/8", "127.0.0.1")
+func (y *Yarf) TrustProxies(proxies ...string) error {
+	var t proxyTrust
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip, err := netip.ParseAddr(p)
+			if err != nil {
+				return err
+			}
+			ip = ip.Unmap()
+			t = append(t, netip.PrefixFrom(ip, ip.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			return err
+		}
+		t = append(t, prefix.Masked())
+	}
+
+	y.trusted = t
+
+	return nil
+}
+
+// forwardedElement is a hop of the Forwarded header (RFC 7239).
+type forwardedElement map[string]string
+
+// parseForwarded parses the Forwarded headers into its hops, from the client to the last proxy.
+func parseForwarded(headers []string) (hops []forwardedElement) {
+	for _, h := range headers {
+		for _, elem := range strings.Split(h, ",") {
+			e := forwardedElement{}
+			for _, pair := range strings.Split(elem, ";") {
+				k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					continue
+				}
+				e[strings.ToLower(k)] = strings.Trim(v, `"`)
+			}
+			hops = append(hops, e)
+		}
+	}
+
+	return
+}
+
+// splitList splits the comma separated values of the headers provided.
+func splitList(headers []string) (values []string) {
+	for _, h := range headers {
+		for _, v := range strings.Split(h, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+
+	return
+}
+
+// remoteHost returns the host part of the request remote address.
+func (c *Context) remoteHost() string {
+	host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		return c.Request.RemoteAddr
+	}
+
+	return host
+}
+
+// peerIP returns the client IP for security decisions: the remote address of the connection,
+// or the client IP reported by the trusted proxies when they're configured with Yarf.TrustProxies().
+// Unlike GetClientIP(), it never trusts the forwarding headers sent by the clients themselves.
+func (c *Context) peerIP() string {
+	if t, _ := c.proxied(); t != nil {
+		return c.trustedClientIP(t)
+	}
+
+	return c.remoteHost()
+}
+
+// proxied returns the proxy trust policy, and whether the request comes from a trusted proxy.
+func (c *Context) proxied() (proxyTrust, bool) {
+	t, _ := c.get(trustKey{}).(proxyTrust)
+	if t == nil {
+		return nil, false
+	}
+
+	return t, t.trusts(c.remoteHost())
+}
+
+// forwardedValue returns the first value of a Forwarded parameter or X-Forwarded-* header
+// if the request comes from a trusted proxy. An empty param only checks the header.
+func (c *Context) forwardedValue(param, header string) string {
+	if _, ok := c.proxied(); !ok {
+		return ""
+	}
+
+	if param != "" {
+		for _, hop := range parseForwarded(c.Request.Header.Values("Forwarded")) {
+			if v := hop[param]; v != "" {
+				return v
+			}
+		}
+	}
+
+	if v := splitList(c.Request.Header.Values(header)); len(v) > 0 {
+		return v[0]
+	}
+
+	return ""
+}
+
+// Scheme returns the scheme used by the client, "http" or "https".
+// When trusted proxies are configured, it's taken from the forwarding headers of trusted proxies.
+func (c *Context) Scheme() string {
+	if proto := strings.ToLower(c.forwardedValue("proto", "X-Forwarded-Proto")); proto == "http" || proto == "https" {
+		return proto
+	}
+
+	if c.Request.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+// Host returns the host requested by the client, including the port if it isn't the default one.
+// When trusted proxies are configured, it's taken from the forwarding headers of trusted proxies.
+func (c *Context) Host() string {
+	host := c.forwardedValue("host", "X-Forwarded-Host")
+	if host == "" {
+		return c.Request.Host
+	}
+
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		port := c.forwardedValue("", "X-Forwarded-Port")
+		if port != "" && !(port == "80" && c.Scheme() == "http") && !(port == "443" && c.Scheme() == "https") {
+			host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+		}
+	}
+
+	return host
+}
+
+// trustedClientIP returns the first untrusted address of the forwarding chain, from the closest hop.
+func (c *Context) trustedClientIP(t proxyTrust) string {
+	ip := c.remoteHost()
+
+	var chain []string
+	if hops := parseForwarded(c.Request.Header.Values("Forwarded")); len(hops) > 0 {
+		for _, hop := range hops {
+			addr := hop["for"]
+			if h, _, err := net.SplitHostPort(addr); err == nil {
+				addr = h
+			}
+			chain = append(chain, strings.Trim(addr, "[]"))
+		}
+	} else {
+		chain = splitList(c.Request.Header.Values("X-Forwarded-For"))
+	}
+
+	for i := len(chain) - 1; i >= 0 && t.trusts(ip); i-- {
+		ip = chain[i]
+	}
+
+	return ip
+}
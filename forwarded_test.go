@@ -0,0 +1,79 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type ForwardedResource struct {
+	Resource
+}
+
+func (r *ForwardedResource) Get(c *Context) error {
+	c.Render(c.Scheme() + "://" + c.Host() + " " + c.GetClientIP())
+	return nil
+}
+
+func TestTrustProxies(t *testing.T) {
+	y := New()
+	y.Add("/", new(ForwardedResource))
+
+	if err := y.TrustProxies("10.0.0.0/8", "192.168.1.1", "invalid"); err == nil {
+		t.Error("TrustProxies should fail on invalid addresses")
+	}
+	if err := y.TrustProxies("10.0.0.0/8", "192.168.1.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		remote  string
+		headers map[string]string
+		result  string
+	}{
+		// Untrusted remote, headers ignored
+		{"1.2.3.4:1000", map[string]string{"X-Forwarded-For": "5.6.7.8", "X-Forwarded-Proto": "https"}, "http://example.com 1.2.3.4"},
+		// Trusted proxy chain
+		{"10.0.0.1:1000", map[string]string{
+			"X-Forwarded-For":   "6.6.6.6, 5.6.7.8, 192.168.1.1",
+			"X-Forwarded-Proto": "https",
+			"X-Forwarded-Host":  "api.example.com",
+			"X-Forwarded-Port":  "8443",
+		}, "https://api.example.com:8443 5.6.7.8"},
+		// Forwarded header
+		{"10.0.0.1:1000", map[string]string{
+			"Forwarded": `for="[2001:db8::1]:4711";proto=https;host=www.example.com, for=10.1.1.1`,
+		}, "https://www.example.com 2001:db8::1"},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "http://example.com/", nil)
+		req.RemoteAddr = test.remote
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Body.String() != test.result {
+			t.Errorf("Expected '%s', got '%s'", test.result, res.Body.String())
+		}
+	}
+}
+
+func TestHTTPSRedirectBehindProxy(t *testing.T) {
+	y := New()
+	y.Use(new(HTTPSRedirect))
+	y.Add("/", new(OKResource))
+	y.TrustProxies("10.0.0.1")
+
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	req.RemoteAddr = "10.0.0.1:1000"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 200 {
+		t.Errorf("Requests forwarded as https shouldn't be redirected, got %d", res.Code)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/yarf-framework/yarf
+
+go 1.24.0
+
+require (
+	golang.org/x/crypto v0.48.0
+	golang.org/x/text v0.34.0
+)
+
+require golang.org/x/net v0.50.0 // indirect
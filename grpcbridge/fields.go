@@ -0,0 +1,177 @@
+package grpcbridge
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// fieldPath resolves a dot separated field path, using proto or JSON field names.
+func fieldPath(md protoreflect.MessageDescriptor, path string) ([]protoreflect.FieldDescriptor, error) {
+	var fields []protoreflect.FieldDescriptor
+
+	for _, name := range strings.Split(path, ".") {
+		if md == nil {
+			return nil, fmt.Errorf("field %s isn't a message", strings.Join(fieldNames(fields), "."))
+		}
+
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(name)
+		}
+		if fd == nil {
+			return nil, fmt.Errorf("unknown field %s", path)
+		}
+
+		fields = append(fields, fd)
+
+		md = nil
+		if fd.Kind() == protoreflect.MessageKind && !fd.IsList() && !fd.IsMap() {
+			md = fd.Message()
+		}
+	}
+
+	return fields, nil
+}
+
+// fieldNames returns the names of the fields.
+func fieldNames(fields []protoreflect.FieldDescriptor) []string {
+	names := make([]string, len(fields))
+	for i, fd := range fields {
+		names[i] = string(fd.Name())
+	}
+
+	return names
+}
+
+// mutableField returns the message at the end of the path, creating the intermediate messages.
+func mutableField(m protoreflect.Message, path []protoreflect.FieldDescriptor) protoreflect.Message {
+	for _, fd := range path {
+		m = m.Mutable(fd).Message()
+	}
+
+	return m
+}
+
+// setField parses the values and sets them into the field at the path.
+// Repeated fields accept several values, and other fields take the last one.
+func setField(msg *dynamicpb.Message, path string, values []string) error {
+	fields, err := fieldPath(msg.Descriptor(), path)
+	if err != nil {
+		return err
+	}
+
+	m := mutableField(msg, fields[:len(fields)-1])
+	fd := fields[len(fields)-1]
+
+	if fd.IsMap() || (fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind) {
+		return fmt.Errorf("field %s can't be set from a string", path)
+	}
+
+	if fd.IsList() {
+		list := m.Mutable(fd).List()
+		for _, s := range values {
+			v, err := parseValue(fd, s)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", path, err)
+			}
+			list.Append(v)
+		}
+		return nil
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	v, err := parseValue(fd, values[len(values)-1])
+	if err != nil {
+		return fmt.Errorf("field %s: %w", path, err)
+	}
+	m.Set(fd, v)
+
+	return nil
+}
+
+// parseValue parses a scalar or enum value of the field kind.
+func parseValue(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+
+	case protoreflect.BytesKind:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			b, err = base64.URLEncoding.DecodeString(s)
+		}
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid base64 value %q", s)
+		}
+		return protoreflect.ValueOfBytes(b), nil
+
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid boolean value %q", s)
+		}
+		return protoreflect.ValueOfBool(b), nil
+
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil || fd.Enum().Values().ByNumber(protoreflect.EnumNumber(n)) == nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid enum value %q", s)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid integer value %q", s)
+		}
+		return protoreflect.ValueOfInt32(int32(n)), nil
+
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid integer value %q", s)
+		}
+		return protoreflect.ValueOfInt64(n), nil
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid unsigned integer value %q", s)
+		}
+		return protoreflect.ValueOfUint32(uint32(n)), nil
+
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid unsigned integer value %q", s)
+		}
+		return protoreflect.ValueOfUint64(n), nil
+
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid number value %q", s)
+		}
+		return protoreflect.ValueOfFloat32(float32(f)), nil
+
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid number value %q", s)
+		}
+		return protoreflect.ValueOfFloat64(f), nil
+	}
+
+	return protoreflect.Value{}, fmt.Errorf("unsupported field type %s", fd.Kind())
+}
@@ -0,0 +1,94 @@
+package grpcbridge
+
+import (
+	"testing"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestSetField(t *testing.T) {
+	d := dynamicpb.NewMessage((&durationpb.Duration{}).ProtoReflect().Descriptor())
+
+	if err := setField(d, "seconds", []string{"1", "5"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := setField(d, "nanos", []string{"10"}); err != nil {
+		t.Fatal(err)
+	}
+	fields := d.Descriptor().Fields()
+	if d.Get(fields.ByName("seconds")).Int() != 5 || d.Get(fields.ByName("nanos")).Int() != 10 {
+		t.Errorf("Unexpected values %v", d)
+	}
+
+	if err := setField(d, "seconds", []string{"abc"}); err == nil {
+		t.Error("Invalid integer should fail")
+	}
+	if err := setField(d, "nanos", []string{"9999999999"}); err == nil {
+		t.Error("Out of range integer should fail")
+	}
+	if err := setField(d, "minutes", []string{"1"}); err == nil {
+		t.Error("Unknown field should fail")
+	}
+}
+
+func TestSetEnumField(t *testing.T) {
+	m := dynamicpb.NewMessage((&healthpb.HealthCheckResponse{}).ProtoReflect().Descriptor())
+	fd := m.Descriptor().Fields().ByName("status")
+
+	if err := setField(m, "status", []string{"SERVING"}); err != nil {
+		t.Fatal(err)
+	}
+	if m.Get(fd).Enum() != 1 {
+		t.Errorf("Expected SERVING enum value, got %d", m.Get(fd).Enum())
+	}
+
+	if err := setField(m, "status", []string{"2"}); err != nil {
+		t.Fatal(err)
+	}
+	if m.Get(fd).Enum() != 2 {
+		t.Errorf("Expected numeric enum value, got %d", m.Get(fd).Enum())
+	}
+
+	if err := setField(m, "status", []string{"BROKEN"}); err == nil {
+		t.Error("Unknown enum value should fail")
+	}
+}
+
+func TestSetRepeatedField(t *testing.T) {
+	m := dynamicpb.NewMessage((&fieldmaskpb.FieldMask{}).ProtoReflect().Descriptor())
+
+	if err := setField(m, "paths", []string{"name", "email"}); err != nil {
+		t.Fatal(err)
+	}
+	list := m.Get(m.Descriptor().Fields().ByName("paths")).List()
+	if list.Len() != 2 || list.Get(0).String() != "name" || list.Get(1).String() != "email" {
+		t.Errorf("Expected all values in repeated field, got %v", list)
+	}
+}
+
+func TestSetFieldNames(t *testing.T) {
+	v := dynamicpb.NewMessage((&structpb.Value{}).ProtoReflect().Descriptor())
+
+	if err := setField(v, "string_value", []string{"text"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := setField(v, "stringValue", []string{"json name"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := v.Get(v.Descriptor().Fields().ByName("string_value")).String(); got != "json name" {
+		t.Errorf("Expected field set by JSON name, got %s", got)
+	}
+	if err := setField(v, "bool_value", []string{"yes"}); err == nil {
+		t.Error("Invalid boolean should fail")
+	}
+	if err := setField(v, "list_value", []string{"1"}); err == nil {
+		t.Error("Message fields can't be set from strings")
+	}
+	if err := setField(v, "struct_value.fields", []string{"1"}); err == nil {
+		t.Error("Map fields can't be set from strings")
+	}
+}
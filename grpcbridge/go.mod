@@ -0,0 +1,18 @@
+module github.com/yarf-framework/yarf/grpcbridge
+
+go 1.24.0
+
+require (
+	github.com/yarf-framework/yarf v0.0.0
+	google.golang.org/grpc v1.64.0
+	google.golang.org/protobuf v1.34.2
+)
+
+require (
+	golang.org/x/net v0.50.0 // indirect
+	golang.org/x/sys v0.41.0 // indirect
+	golang.org/x/text v0.34.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
+)
+
+replace github.com/yarf-framework/yarf => ../
@@ -0,0 +1,296 @@
+// Package grpcbridge exposes gRPC service methods as Yarf routes, transcoding between JSON and protobuf.
+// Route params and query params are mapped into the fields of the request message,
+// and the request body is decoded as JSON into the message or one of its fields.
+//
+//	b := grpcbridge.New(conn)
+//	y.Add("/v1/users/:id", b.Handle("GET", "users.v1.Users/GetUser"))
+//	y.Add("/v1/users", b.Handle("POST", "users.v1.Users/CreateUser"))
+//
+// Method descriptors are looked up in the global protobuf registry,
+// so the generated code of the services has to be linked into the binary.
+package grpcbridge
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yarf-framework/yarf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// MetadataHeaderPrefix is the prefix of the request headers forwarded as gRPC metadata,
+// and of the response headers set from the gRPC header metadata.
+const MetadataHeaderPrefix = "Grpc-Metadata-"
+
+// Bridge calls gRPC methods on a client connection.
+type Bridge struct {
+	// Conn is the gRPC client connection to the backend.
+	Conn grpc.ClientConnInterface
+
+	// Files resolves the method descriptors. Defaults to protoregistry.GlobalFiles.
+	Files *protoregistry.Files
+
+	// Marshal options to encode response messages.
+	Marshal protojson.MarshalOptions
+
+	// Unmarshal options to decode request bodies.
+	Unmarshal protojson.UnmarshalOptions
+
+	// Metadata builds the outgoing gRPC metadata for a request.
+	// Defaults to DefaultMetadata.
+	Metadata func(*yarf.Context) metadata.MD
+}
+
+// New creates a Bridge calling methods on conn.
+func New(conn grpc.ClientConnInterface) *Bridge {
+	return &Bridge{
+		Conn:     conn,
+		Files:    protoregistry.GlobalFiles,
+		Metadata: DefaultMetadata,
+	}
+}
+
+// DefaultMetadata forwards the Authorization header and the headers starting with MetadataHeaderPrefix
+// as gRPC metadata.
+func DefaultMetadata(c *yarf.Context) metadata.MD {
+	md := metadata.MD{}
+
+	if auth := c.Request.Header.Get("Authorization"); auth != "" {
+		md.Set("authorization", auth)
+	}
+
+	for k, v := range c.Request.Header {
+		if strings.HasPrefix(k, MetadataHeaderPrefix) {
+			md.Append(strings.TrimPrefix(k, MetadataHeaderPrefix), v...)
+		}
+	}
+
+	return md
+}
+
+// Binding maps a HTTP method to a gRPC method.
+type Binding struct {
+	// Method is the full gRPC method name, like "users.v1.Users/GetUser".
+	Method string
+
+	// Body is the request message field receiving the request body, "*" for the whole message,
+	// or "-" to ignore the body. Defaults to "*" for POST, PUT and PATCH, and "-" for other methods.
+	Body string
+}
+
+// binding is a resolved Binding.
+type binding struct {
+	path   string
+	input  protoreflect.MessageDescriptor
+	output protoreflect.MessageDescriptor
+	body   []protoreflect.FieldDescriptor // nil for the whole message
+	noBody bool
+}
+
+// Handler is a ResourceHandler calling gRPC methods for the bound HTTP methods.
+// Other HTTP methods return a 405 error.
+type Handler struct {
+	yarf.Resource
+
+	bridge   *Bridge
+	bindings map[string]*binding
+	err      error
+}
+
+// Handle creates a Handler calling the gRPC method for a single HTTP method.
+func (b *Bridge) Handle(httpMethod, grpcMethod string) *Handler {
+	return b.Handler(map[string]Binding{httpMethod: {Method: grpcMethod}})
+}
+
+// Handler creates a Handler for several HTTP methods on the same route.
+// Invalid bindings, like unknown or streaming gRPC methods, make the handler fail on every request
+// with a 500 error, and Err() returns the reason.
+//
+//	y.Add("/v1/users/:id", b.Handler(map[string]grpcbridge.Binding{
+//		"GET":   {Method: "users.v1.Users/GetUser"},
+//		"PATCH": {Method: "users.v1.Users/UpdateUser", Body: "user"},
+//	}))
+func (b *Bridge) Handler(bindings map[string]Binding) *Handler {
+	h := &Handler{
+		bridge:   b,
+		bindings: make(map[string]*binding, len(bindings)),
+	}
+
+	for method, bd := range bindings {
+		method = strings.ToUpper(method)
+
+		resolved, err := b.resolve(method, bd)
+		if err != nil {
+			h.err = err
+			return h
+		}
+		h.bindings[method] = resolved
+	}
+
+	return h
+}
+
+// Err returns the error found resolving the bindings, if any.
+func (h *Handler) Err() error {
+	return h.err
+}
+
+// resolve finds the descriptors of a binding.
+func (b *Bridge) resolve(httpMethod string, bd Binding) (*binding, error) {
+	files := b.Files
+	if files == nil {
+		files = protoregistry.GlobalFiles
+	}
+
+	name := strings.TrimPrefix(bd.Method, "/")
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		return nil, fmt.Errorf("grpcbridge: invalid method name %q", bd.Method)
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(name[:i] + "." + name[i+1:]))
+	if err != nil {
+		return nil, fmt.Errorf("grpcbridge: method %s: %w", bd.Method, err)
+	}
+	md, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("grpcbridge: %s isn't a method", bd.Method)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("grpcbridge: streaming method %s isn't supported", bd.Method)
+	}
+
+	r := &binding{
+		path:   "/" + name,
+		input:  md.Input(),
+		output: md.Output(),
+	}
+
+	body := bd.Body
+	if body == "" {
+		body = "-"
+		if httpMethod == "POST" || httpMethod == "PUT" || httpMethod == "PATCH" {
+			body = "*"
+		}
+	}
+
+	switch body {
+	case "-":
+		r.noBody = true
+	case "*":
+	default:
+		r.body, err = fieldPath(r.input, body)
+		if err != nil {
+			return nil, err
+		}
+		if last := r.body[len(r.body)-1]; last.Kind() != protoreflect.MessageKind || last.IsList() || last.IsMap() {
+			return nil, fmt.Errorf("grpcbridge: body field %s isn't a message", body)
+		}
+	}
+
+	return r, nil
+}
+
+// call transcodes the request and calls the bound gRPC method.
+func (h *Handler) call(c *yarf.Context) error {
+	if h.err != nil {
+		return h.err
+	}
+
+	bd, ok := h.bindings[c.Request.Method]
+	if !ok {
+		return yarf.ErrorMethodNotImplemented()
+	}
+
+	req := dynamicpb.NewMessage(bd.input)
+
+	if !bd.noBody {
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return err
+		}
+		if len(data) > 0 {
+			target := req.ProtoReflect()
+			if bd.body != nil {
+				target = mutableField(target, bd.body)
+			}
+			if err := h.bridge.Unmarshal.Unmarshal(data, target.Interface()); err != nil {
+				return badRequest(c, "invalid body: "+err.Error())
+			}
+		}
+	}
+
+	for k, v := range c.Request.URL.Query() {
+		if err := setField(req, k, v); err != nil {
+			return badRequest(c, err.Error())
+		}
+	}
+
+	// Route params are applied last, so they can't be overridden
+	for k, v := range c.Params {
+		if err := setField(req, k, []string{v}); err != nil {
+			return badRequest(c, err.Error())
+		}
+	}
+
+	ctx := c.Request.Context()
+	if h.bridge.Metadata != nil {
+		ctx = metadata.NewOutgoingContext(ctx, h.bridge.Metadata(c))
+	}
+
+	res := dynamicpb.NewMessage(bd.output)
+	var header metadata.MD
+
+	err := h.bridge.Conn.Invoke(ctx, bd.path, req, res, grpc.Header(&header))
+
+	for k, v := range header {
+		for _, s := range v {
+			c.Response.Header().Add(MetadataHeaderPrefix+k, s)
+		}
+	}
+
+	if err != nil {
+		return statusError(c, err)
+	}
+
+	data, err := h.bridge.Marshal.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.Response.Write(data)
+
+	return nil
+}
+
+// Get calls the gRPC method bound to GET.
+func (h *Handler) Get(c *yarf.Context) error {
+	return h.call(c)
+}
+
+// Post calls the gRPC method bound to POST.
+func (h *Handler) Post(c *yarf.Context) error {
+	return h.call(c)
+}
+
+// Put calls the gRPC method bound to PUT.
+func (h *Handler) Put(c *yarf.Context) error {
+	return h.call(c)
+}
+
+// Patch calls the gRPC method bound to PATCH.
+func (h *Handler) Patch(c *yarf.Context) error {
+	return h.call(c)
+}
+
+// Delete calls the gRPC method bound to DELETE.
+func (h *Handler) Delete(c *yarf.Context) error {
+	return h.call(c)
+}
@@ -0,0 +1,203 @@
+package grpcbridge
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const checkMethod = "grpc.health.v1.Health/Check"
+
+// metadataHealth echoes the incoming metadata as header metadata.
+type metadataHealth struct {
+	*health.Server
+}
+
+func (h metadataHealth) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		out := metadata.MD{}
+		for _, k := range []string{"authorization", "tenant"} {
+			if v := md.Get(k); len(v) > 0 {
+				out.Set("echo-"+k, v...)
+			}
+		}
+		grpc.SetHeader(ctx, out)
+	}
+
+	return h.Server.Check(ctx, req)
+}
+
+func newTestBridge(t *testing.T) *Bridge {
+	lis := bufconn.Listen(1 << 20)
+
+	hs := health.NewServer()
+	hs.SetServingStatus("users", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus("orders", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, metadataHealth{hs})
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return New(conn)
+}
+
+func request(y *yarf.Yarf, method, url, body string, header http.Header) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	return res
+}
+
+func TestRouteParams(t *testing.T) {
+	b := newTestBridge(t)
+
+	y := yarf.New()
+	y.Add("/health/:service", b.Handle("GET", checkMethod))
+
+	res := request(y, "GET", "http://localhost/health/users", "", nil)
+	if res.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", res.Code, res.Body.String())
+	}
+	if strings.ReplaceAll(res.Body.String(), " ", "") != `{"status":"SERVING"}` {
+		t.Errorf("Unexpected body %s", res.Body.String())
+	}
+	if res.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected JSON content type, got %s", res.Header().Get("Content-Type"))
+	}
+
+	res = request(y, "GET", "http://localhost/health/orders", "", nil)
+	if strings.ReplaceAll(res.Body.String(), " ", "") != `{"status":"NOT_SERVING"}` {
+		t.Errorf("Unexpected body %s", res.Body.String())
+	}
+}
+
+func TestQueryAndBody(t *testing.T) {
+	b := newTestBridge(t)
+
+	y := yarf.New()
+	y.Add("/check", b.Handler(map[string]Binding{
+		"GET":  {Method: checkMethod},
+		"POST": {Method: checkMethod},
+	}))
+
+	res := request(y, "GET", "http://localhost/check?service=users", "", nil)
+	if !strings.Contains(res.Body.String(), `"SERVING"`) {
+		t.Errorf("Query param should be mapped, got %s", res.Body.String())
+	}
+
+	res = request(y, "POST", "http://localhost/check", `{"service":"users"}`, nil)
+	if !strings.Contains(res.Body.String(), `"SERVING"`) {
+		t.Errorf("Body should be mapped, got %s", res.Body.String())
+	}
+
+	res = request(y, "POST", "http://localhost/check", `{"service":`, nil)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid body, got %d", res.Code)
+	}
+
+	res = request(y, "GET", "http://localhost/check?unknown=1", "", nil)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown field, got %d", res.Code)
+	}
+
+	res = request(y, "DELETE", "http://localhost/check", "", nil)
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for unbound method, got %d", res.Code)
+	}
+}
+
+func TestStatusErrors(t *testing.T) {
+	b := newTestBridge(t)
+
+	y := yarf.New()
+	y.Add("/health/:service", b.Handle("GET", checkMethod))
+
+	res := request(y, "GET", "http://localhost/health/unknown", "", nil)
+	if res.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for NotFound gRPC status, got %d", res.Code)
+	}
+	if !strings.Contains(res.Body.String(), `"code":5`) {
+		t.Errorf("Expected gRPC status in body, got %s", res.Body.String())
+	}
+	if res.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected JSON content type, got %s", res.Header().Get("Content-Type"))
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	b := newTestBridge(t)
+
+	y := yarf.New()
+	y.Add("/health/:service", b.Handle("GET", checkMethod))
+
+	res := request(y, "GET", "http://localhost/health/users", "", http.Header{
+		"Authorization":        {"Bearer token"},
+		"Grpc-Metadata-Tenant": {"acme"},
+	})
+
+	if res.Header().Get("Grpc-Metadata-Echo-Authorization") != "Bearer token" {
+		t.Errorf("Authorization header should be forwarded, got %v", res.Header())
+	}
+	if res.Header().Get("Grpc-Metadata-Echo-Tenant") != "acme" {
+		t.Errorf("Metadata headers should be forwarded, got %v", res.Header())
+	}
+}
+
+func TestInvalidBindings(t *testing.T) {
+	b := New(nil)
+
+	invalid := []Binding{
+		{Method: "Check"},
+		{Method: "grpc.health.v1.Health/Missing"},
+		{Method: "grpc.health.v1.Health/Watch"},
+		{Method: checkMethod, Body: "service"},
+		{Method: checkMethod, Body: "missing"},
+	}
+
+	for _, bd := range invalid {
+		h := b.Handler(map[string]Binding{"POST": bd})
+		if h.Err() == nil {
+			t.Errorf("Binding %+v should be rejected", bd)
+		}
+	}
+
+	h := b.Handle("GET", checkMethod)
+	if h.Err() != nil {
+		t.Errorf("Valid binding rejected: %s", h.Err())
+	}
+
+	y := yarf.New()
+	y.Add("/watch", b.Handle("GET", "grpc.health.v1.Health/Watch"))
+	res := request(y, "GET", "http://localhost/watch", "", nil)
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 for invalid binding, got %d", res.Code)
+	}
+}
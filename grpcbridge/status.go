@@ -0,0 +1,65 @@
+package grpcbridge
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/yarf-framework/yarf"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HTTPStatus maps a gRPC status code to the equivalent HTTP status code.
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusInternalServerError
+}
+
+// statusBody is the JSON error body.
+type statusBody struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// statusError converts a gRPC error into a Yarf error with the status as JSON body.
+func statusError(c *yarf.Context, err error) error {
+	st := status.Convert(err)
+
+	body, _ := json.Marshal(statusBody{Code: int(st.Code()), Message: st.Message()})
+	c.Response.Header().Set("Content-Type", "application/json")
+
+	return &yarf.CustomError{
+		HTTPCode:  HTTPStatus(st.Code()),
+		ErrorMsg:  st.Message(),
+		ErrorBody: string(body),
+	}
+}
+
+// badRequest returns an InvalidArgument error for requests that can't be transcoded.
+func badRequest(c *yarf.Context, msg string) error {
+	return statusError(c, status.Error(codes.InvalidArgument, msg))
+}
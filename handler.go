@@ -0,0 +1,101 @@
+package yarf
+
+import (
+	"context"
+	"net/http"
+)
+
+// Yarf can be mounted under other muxes.
+var _ http.Handler = (*Yarf)(nil)
+
+// paramsKey is the request context key holding the route params for http.Handler routes.
+type paramsKey struct{}
+
+// ParamsFromContext returns the route params of a request served by a handler registered with FromHTTPHandler.
+func ParamsFromContext(ctx context.Context) Params {
+	p, _ := ctx.Value(paramsKey{}).(Params)
+	return p
+}
+
+// URLParam returns a route param of a request served by a handler registered with FromHTTPHandler.
+func URLParam(r *http.Request, name string) string {
+	return ParamsFromContext(r.Context()).Get(name)
+}
+
+// handlerResource serves all methods with a http.Handler.
+type handlerResource struct {
+	h http.Handler
+}
+
+// FromHTTPHandler adapts a http.Handler or http.HandlerFunc into a ResourceHandler serving all methods,
+// so handlers from other packages can be registered as routes and run with the Yarf middleware.
+// The route params are available to the handler through ParamsFromContext() and URLParam().
+//
+//	y.Add("/files/:name", yarf.FromHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//		fmt.Fprint(w, yarf.URLParam(r, "name"))
+//	})))
+func FromHTTPHandler(h http.Handler) ResourceHandler {
+	return &handlerResource{h: h}
+}
+
+// serve calls the handler with the route params in the request context.
+func (r *handlerResource) serve(c *Context) error {
+	req := c.Request
+	if len(c.Params) > 0 {
+		// Params are copied, as the Context may be reused
+		p := make(Params, len(c.Params))
+		for k, v := range c.Params {
+			p[k] = v
+		}
+		req = req.WithContext(context.WithValue(req.Context(), paramsKey{}, p))
+	}
+
+	r.h.ServeHTTP(c.Response, req)
+
+	return nil
+}
+
+// Get calls the handler.
+func (r *handlerResource) Get(c *Context) error {
+	return r.serve(c)
+}
+
+// Post calls the handler.
+func (r *handlerResource) Post(c *Context) error {
+	return r.serve(c)
+}
+
+// Put calls the handler.
+func (r *handlerResource) Put(c *Context) error {
+	return r.serve(c)
+}
+
+// Patch calls the handler.
+func (r *handlerResource) Patch(c *Context) error {
+	return r.serve(c)
+}
+
+// Delete calls the handler.
+func (r *handlerResource) Delete(c *Context) error {
+	return r.serve(c)
+}
+
+// Options calls the handler.
+func (r *handlerResource) Options(c *Context) error {
+	return r.serve(c)
+}
+
+// Head calls the handler.
+func (r *handlerResource) Head(c *Context) error {
+	return r.serve(c)
+}
+
+// Trace calls the handler.
+func (r *handlerResource) Trace(c *Context) error {
+	return r.serve(c)
+}
+
+// Connect calls the handler.
+func (r *handlerResource) Connect(c *Context) error {
+	return r.serve(c)
+}
@@ -0,0 +1,48 @@
+package yarf
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromHTTPHandler(t *testing.T) {
+	m := new(CountMiddleware)
+
+	y := New()
+	y.Insert(m)
+	y.Add("/files/:name", FromHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.Method, URLParam(r, "name"))
+	})))
+
+	for _, method := range []string{"GET", "DELETE"} {
+		req, _ := http.NewRequest(method, "http://localhost:8080/files/report.pdf", nil)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Body.String() != method+" report.pdf" {
+			t.Errorf("Expected '%s report.pdf', got '%s'", method, res.Body.String())
+		}
+	}
+
+	if m.pre != 2 {
+		t.Errorf("Middleware should run for http.Handler routes, ran %d times", m.pre)
+	}
+}
+
+func TestMountUnderServeMux(t *testing.T) {
+	y := New()
+	y.Add("/users/:id", new(ParamsResource))
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", http.StripPrefix("/api", y))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/api/users/5", nil)
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, req)
+
+	if res.Code != 200 || res.Body.String() != `{"id":"5"}` {
+		t.Errorf("Expected params from the mounted app, got %d '%s'", res.Code, res.Body.String())
+	}
+}
@@ -0,0 +1,55 @@
+package yarf
+
+import (
+	"net/http"
+)
+
+// MetaHeaders is the RouteMeta key holding the default response headers of the route, as a http.Header.
+const MetaHeaders = "headers"
+
+// Header declares a default response header for the route, and returns the RouteMeta to allow chaining.
+// Default headers are set before the handler runs, so handlers can still change them.
+//
+//	y.AddRoute("/account", new(Account)).Header("Cache-Control", "no-store")
+func (m *RouteMeta) Header(key, value string) *RouteMeta {
+	h := m.headers()
+	if h == nil {
+		h = make(http.Header)
+		m.Set(MetaHeaders, h)
+	}
+	h.Set(key, value)
+
+	return m
+}
+
+// headers returns the default response headers of the route.
+func (m *RouteMeta) headers() http.Header {
+	h, _ := m.Get(MetaHeaders).(http.Header)
+
+	return h
+}
+
+// Header declares a default response header for all the routes in the group, including nested groups.
+// Headers are set before the group middleware runs, and headers declared by nested groups and routes
+// replace the ones of their parent groups.
+//
+//	admin := yarf.RouteGroup("/admin")
+//	admin.Header("X-Frame-Options", "DENY")
+func (g *GroupRoute) Header(key, value string) {
+	if g.headers == nil {
+		g.headers = make(http.Header)
+	}
+	g.headers.Set(key, value)
+}
+
+// setHeaders sets the default headers on the response.
+func setHeaders(c *Context, h http.Header) {
+	if len(h) == 0 {
+		return
+	}
+
+	dst := c.Response.Header()
+	for k, v := range h {
+		dst[k] = append([]string(nil), v...)
+	}
+}
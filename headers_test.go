@@ -0,0 +1,81 @@
+package yarf
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// HeaderOverrideResource changes a default header.
+type HeaderOverrideResource struct {
+	Resource
+}
+
+func (r *HeaderOverrideResource) Get(c *Context) error {
+	c.Response.Header().Set("Cache-Control", "private")
+	c.Render("OK")
+	return nil
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	y := New()
+	y.Header("X-Content-Type-Options", "nosniff")
+
+	api := RouteGroup("/api")
+	api.Header("Cache-Control", "no-cache")
+	api.Header("X-Frame-Options", "DENY")
+
+	v1 := RouteGroup("/v1")
+	v1.Header("Cache-Control", "public, max-age=60")
+	v1.Add("/item", new(OKResource))
+	v1.AddRoute("/account", new(OKResource)).Header("Cache-Control", "no-store").Header("X-Account", "1")
+	v1.AddRoute("/override", new(HeaderOverrideResource)).Header("Cache-Control", "no-store")
+	api.AddGroup(v1)
+	api.Add("/status", new(OKResource))
+
+	y.AddGroup(api)
+	y.Add("/", new(OKResource))
+
+	tests := []struct {
+		path     string
+		header   string
+		expected string
+	}{
+		{"/", "X-Content-Type-Options", "nosniff"},
+		{"/", "Cache-Control", ""},
+		{"/api/status", "Cache-Control", "no-cache"},
+		{"/api/status", "X-Content-Type-Options", "nosniff"},
+		{"/api/v1/item", "Cache-Control", "public, max-age=60"},
+		{"/api/v1/item", "X-Frame-Options", "DENY"},
+		{"/api/v1/account", "Cache-Control", "no-store"},
+		{"/api/v1/account", "X-Account", "1"},
+		{"/api/v1/override", "Cache-Control", "private"},
+	}
+
+	for _, tt := range tests {
+		// Run twice to cover cached routes
+		for i := 0; i < 2; i++ {
+			res := httptest.NewRecorder()
+			y.ServeHTTP(res, httptest.NewRequest("GET", tt.path, nil))
+
+			if v := res.Header().Get(tt.header); v != tt.expected {
+				t.Errorf("Expected %s: %q for %s, got %q", tt.header, tt.expected, tt.path, v)
+			}
+		}
+	}
+}
+
+func TestDefaultHeadersOnErrors(t *testing.T) {
+	g := RouteGroup("/private")
+	g.Header("Cache-Control", "no-store")
+	g.Add("/", new(MockResource))
+
+	y := New()
+	y.AddGroup(g)
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/private", nil))
+
+	if res.Code != 405 || res.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("Expected 405 with default headers, got %d %q", res.Code, res.Header().Get("Cache-Control"))
+	}
+}
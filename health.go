@@ -0,0 +1,261 @@
+package yarf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheck checks the status of an application dependency, like a database or a downstream service.
+// It should return an error if the dependency isn't healthy, and respect the context deadline.
+type HealthCheck func(ctx context.Context) error
+
+// namedCheck is a HealthCheck registered under a name.
+type namedCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// CheckStatus is the result of a single health check.
+type CheckStatus struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// HealthStatus is the aggregated result of the health checks, rendered as JSON by the health endpoints.
+type HealthStatus struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckStatus `json:"checks,omitempty"`
+}
+
+// Health status values
+const (
+	HealthOK       = "ok"
+	HealthError    = "error"
+	HealthNotReady = "not ready"
+)
+
+// Health manages the liveness and readiness status of the application.
+// Readiness checks run on every request to the readiness endpoint, concurrently and with a timeout.
+// Liveness checks should be limited to the process itself, as failures usually lead to restarts.
+type Health struct {
+	// Timeout for each check. Defaults to 5 seconds.
+	Timeout time.Duration
+
+	// DrainDelay is the time the application keeps serving requests after being marked as not ready on Shutdown,
+	// so load balancers have time to notice it.
+	DrainDelay time.Duration
+
+	readiness []namedCheck
+	liveness  []namedCheck
+	notReady  int32
+
+	sync.RWMutex
+}
+
+// NewHealth creates a new Health object, ready and without checks.
+func NewHealth() *Health {
+	return &Health{
+		Timeout: 5 * time.Second,
+	}
+}
+
+// AddCheck registers a readiness check.
+func (h *Health) AddCheck(name string, check HealthCheck) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.readiness = append(h.readiness, namedCheck{name, check})
+}
+
+// AddLivenessCheck registers a liveness check.
+func (h *Health) AddLivenessCheck(name string, check HealthCheck) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.liveness = append(h.liveness, namedCheck{name, check})
+}
+
+// SetReady marks the application as ready or not ready to receive traffic.
+func (h *Health) SetReady(ready bool) {
+	v := int32(0)
+	if !ready {
+		v = 1
+	}
+	atomic.StoreInt32(&h.notReady, v)
+}
+
+// Ready returns false if the application was marked as not ready.
+func (h *Health) Ready() bool {
+	return atomic.LoadInt32(&h.notReady) == 0
+}
+
+// run executes the checks concurrently and aggregates their results.
+func (h *Health) run(ctx context.Context, checks []namedCheck) HealthStatus {
+	status := HealthStatus{Status: HealthOK}
+	if len(checks) == 0 {
+		return status
+	}
+
+	status.Checks = make(map[string]CheckStatus, len(checks))
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+
+			cctx, cancel := context.WithTimeout(ctx, h.Timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := runCheck(cctx, nc.check)
+			cs := CheckStatus{Status: HealthOK, Duration: time.Since(start).String()}
+			if err != nil {
+				cs.Status = HealthError
+				cs.Error = err.Error()
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			status.Checks[nc.name] = cs
+			if err != nil {
+				status.Status = HealthError
+			}
+		}(nc)
+	}
+
+	wg.Wait()
+
+	return status
+}
+
+// runCheck runs a check, returning the context error if it doesn't finish in time.
+func runCheck(ctx context.Context, check HealthCheck) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Liveness runs the liveness checks.
+func (h *Health) Liveness(ctx context.Context) HealthStatus {
+	h.RLock()
+	checks := h.liveness
+	h.RUnlock()
+
+	return h.run(ctx, checks)
+}
+
+// Readiness runs the readiness checks. If the application was marked as not ready, checks don't run.
+func (h *Health) Readiness(ctx context.Context) HealthStatus {
+	if !h.Ready() {
+		return HealthStatus{Status: HealthNotReady}
+	}
+
+	h.RLock()
+	checks := h.readiness
+	h.RUnlock()
+
+	return h.run(ctx, checks)
+}
+
+// healthResource renders the liveness or readiness status.
+type healthResource struct {
+	Resource
+
+	health *Health
+	live   bool
+}
+
+// Get renders the status as JSON, with a 503 status code if it isn't ok.
+func (r *healthResource) Get(c *Context) error {
+	var status HealthStatus
+	if r.live {
+		status = r.health.Liveness(c.Request.Context())
+	} else {
+		status = r.health.Readiness(c.Request.Context())
+	}
+
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.Response.Header().Set("Cache-Control", "no-store")
+	if status.Status != HealthOK {
+		c.Status(http.StatusServiceUnavailable)
+	}
+
+	encoded, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	c.Response.Write(encoded)
+
+	return nil
+}
+
+// Head is the same as Get, without the body.
+func (r *healthResource) Head(c *Context) error {
+	return r.Get(c)
+}
+
+// EnableHealth adds the liveness and readiness endpoints to the Yarf routes and returns the Health object
+// to register the checks. Empty paths disable the corresponding endpoint.
+// The application is marked as not ready when Shutdown starts.
+//
+//	h := y.EnableHealth("/healthz", "/readyz")
+//	h.AddCheck("db", func(ctx context.Context) error {
+//		return db.PingContext(ctx)
+//	})
+func (y *Yarf) EnableHealth(livePath, readyPath string) *Health {
+	y.lock.Lock()
+	if y.health == nil {
+		y.health = NewHealth()
+	}
+	h := y.health
+	y.lock.Unlock()
+
+	if livePath != "" {
+		y.Add(livePath, &healthResource{health: h, live: true})
+	}
+	if readyPath != "" {
+		y.Add(readyPath, &healthResource{health: h})
+	}
+
+	return h
+}
+
+// drain marks the application as not ready and waits for the drain delay, if health is enabled.
+func (y *Yarf) drain(ctx context.Context) {
+	y.lock.Lock()
+	h := y.health
+	y.lock.Unlock()
+
+	if h == nil {
+		return
+	}
+
+	h.SetReady(false)
+
+	if h.DrainDelay > 0 {
+		t := time.NewTimer(h.DrainDelay)
+		defer t.Stop()
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package yarf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func getHealth(t *testing.T, y *Yarf, path string) (int, HealthStatus) {
+	req, _ := http.NewRequest("GET", "http://localhost:8080"+path, nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	var status HealthStatus
+	if err := json.Unmarshal(res.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Invalid health response '%s': %s", res.Body.String(), err)
+	}
+
+	return res.Code, status
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	y := New()
+	h := y.EnableHealth("/healthz", "/readyz")
+	h.Timeout = 50 * time.Millisecond
+
+	h.AddCheck("db", func(ctx context.Context) error {
+		return nil
+	})
+
+	code, status := getHealth(t, y, "/readyz")
+	if code != 200 || status.Status != HealthOK || status.Checks["db"].Status != HealthOK {
+		t.Errorf("Healthy app should be ready, got %d: %+v", code, status)
+	}
+
+	h.AddCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	h.AddCheck("cache", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+
+	code, status = getHealth(t, y, "/readyz")
+	if code != 503 || status.Status != HealthError {
+		t.Errorf("Failing checks should return 503, got %d: %+v", code, status)
+	}
+	if status.Checks["cache"].Error != "connection refused" || status.Checks["slow"].Status != HealthError {
+		t.Errorf("Unexpected check results: %+v", status.Checks)
+	}
+
+	// Liveness doesn't depend on readiness checks
+	code, status = getHealth(t, y, "/healthz")
+	if code != 200 || status.Status != HealthOK {
+		t.Errorf("Liveness should be ok, got %d: %+v", code, status)
+	}
+}
+
+func TestHealthShutdown(t *testing.T) {
+	y := New()
+	y.EnableHealth("/healthz", "/readyz")
+
+	y.Shutdown(context.Background())
+
+	code, status := getHealth(t, y, "/readyz")
+	if code != 503 || status.Status != HealthNotReady {
+		t.Errorf("App should be not ready after Shutdown, got %d: %+v", code, status)
+	}
+}
@@ -0,0 +1,132 @@
+package yarf
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrHijackWritten is returned by Context.Hijack once the response has been written.
+var ErrHijackWritten = errors.New("yarf: can't hijack a connection after writing the response")
+
+// HijackedConn is a client connection taken over from the HTTP server by Context.Hijack.
+// Reads return the data already buffered by the server first, like bytes the client sent right after the handshake.
+// It's closed on application Shutdown if the handler didn't close it.
+type HijackedConn struct {
+	net.Conn
+
+	r    *bufio.Reader
+	y    *Yarf
+	once sync.Once
+	err  error
+}
+
+// Read reads the data buffered by the server, then the connection.
+func (hc *HijackedConn) Read(b []byte) (int, error) {
+	if hc.r != nil {
+		if hc.r.Buffered() > 0 {
+			return hc.r.Read(b)
+		}
+		hc.r = nil
+	}
+
+	return hc.Conn.Read(b)
+}
+
+// Close closes the connection once and stops tracking it.
+func (hc *HijackedConn) Close() error {
+	hc.once.Do(func() {
+		if hc.y != nil {
+			hc.y.lock.Lock()
+			delete(hc.y.hijacked, hc)
+			hc.y.lock.Unlock()
+		}
+		hc.err = hc.Conn.Close()
+	})
+
+	return hc.err
+}
+
+// findGuard returns the writeGuard of the response, unwrapping the middleware writers.
+func findGuard(w http.ResponseWriter) *writeGuard {
+	for w != nil {
+		if g, ok := w.(interface{ guard() *writeGuard }); ok {
+			return g.guard()
+		}
+
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		w = u.Unwrap()
+	}
+
+	return nil
+}
+
+// Hijack takes over the client connection, for routes implementing their own protocol after an HTTP handshake,
+// like raw tunnels. The handler is responsible for the connection from then on:
+// the deadlines set by the server are cleared, and the writes to c.Response are ignored.
+// The connection is closed on application Shutdown if the handler didn't close it.
+// It returns ErrHijackWritten once the response has been written,
+// and an error matching http.ErrNotSupported on connections that can't be hijacked, like HTTP/2 ones.
+//
+//	conn, err := c.Hijack()
+//	if err != nil {
+//		return err
+//	}
+//	defer conn.Close()
+//	conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: tunnel\r\nConnection: Upgrade\r\n\r\n"))
+//	...
+func (c *Context) Hijack() (*HijackedConn, error) {
+	if g := findGuard(c.Response); g != nil {
+		g.lock.Lock()
+		written := g.status != 0 || g.closed
+		g.lock.Unlock()
+
+		if written {
+			return nil, ErrHijackWritten
+		}
+	}
+
+	// The writeGuard marks the response as hijacked
+	conn, rw, err := http.NewResponseController(c.Response).Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Time{})
+	rw.Writer.Flush()
+
+	hc := &HijackedConn{Conn: conn, r: rw.Reader, y: c.app}
+	if hc.y != nil {
+		hc.y.lock.Lock()
+		if hc.y.hijacked == nil {
+			hc.y.hijacked = make(map[*HijackedConn]struct{})
+		}
+		hc.y.hijacked[hc] = struct{}{}
+		hc.y.lock.Unlock()
+	}
+
+	return hc, nil
+}
+
+// closeHijacked closes the hijacked connections still open.
+func (y *Yarf) closeHijacked() {
+	y.lock.Lock()
+	conns := make([]*HijackedConn, 0, len(y.hijacked))
+	for hc := range y.hijacked {
+		conns = append(conns, hc)
+	}
+	y.lock.Unlock()
+
+	if len(conns) > 0 {
+		y.log().Info("closing hijacked connections", "count", len(conns))
+	}
+	for _, hc := range conns {
+		hc.Close()
+	}
+}
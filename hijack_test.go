@@ -0,0 +1,98 @@
+package yarf
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type EchoResource struct {
+	Resource
+	conns chan *HijackedConn
+}
+
+func (r *EchoResource) Get(c *Context) error {
+	conn, err := c.Hijack()
+	if err != nil {
+		return err
+	}
+	conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n"))
+	r.conns <- conn
+
+	go io.Copy(conn, conn)
+
+	return nil
+}
+
+func TestHijack(t *testing.T) {
+	r := &EchoResource{conns: make(chan *HijackedConn, 1)}
+	y := New()
+	y.Add("/echo", r)
+
+	s := httptest.NewServer(y)
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", s.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// Data sent along with the handshake is buffered by the server
+	conn.Write([]byte("GET /echo HTTP/1.1\r\nHost: localhost\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\nhello"))
+
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, nil)
+	if err != nil || res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Unexpected handshake response %v %v", res, err)
+	}
+
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(br, buf); err != nil || string(buf) != "hello" {
+		t.Errorf("Expected the buffered data echoed, got %q %v", buf, err)
+	}
+	conn.Write([]byte("world"))
+	if _, err := io.ReadFull(br, buf); err != nil || string(buf) != "world" {
+		t.Errorf("Expected echo, got %q %v", buf, err)
+	}
+
+	hc := <-r.conns
+	y.lock.Lock()
+	_, tracked := y.hijacked[hc]
+	y.lock.Unlock()
+	if !tracked {
+		t.Error("Hijacked connections should be tracked")
+	}
+
+	// Shutdown closes the connections left open
+	y.Shutdown(context.Background())
+	if _, err := br.ReadByte(); err != io.EOF {
+		t.Errorf("Expected the connection closed on shutdown, got %v", err)
+	}
+	if len(y.hijacked) != 0 {
+		t.Errorf("Closed connections should be untracked, got %d", len(y.hijacked))
+	}
+}
+
+func TestHijackErrors(t *testing.T) {
+	y := New()
+	c := NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+	c.app = y
+	guardResponse(y, c)
+
+	if _, err := c.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("Expected http.ErrNotSupported, got %v", err)
+	}
+
+	c.Render("written")
+	if _, err := c.Hijack(); err != ErrHijackWritten {
+		t.Errorf("Expected ErrHijackWritten, got %v", err)
+	}
+}
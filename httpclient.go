@@ -0,0 +1,222 @@
+package yarf
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClientOptions configures the outbound clients returned by Context.HTTPClient().
+type ClientOptions struct {
+	// Transport sends the requests. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// Timeout limits each outbound request, including retries. Zero means no timeout,
+	// other than the request deadline.
+	Timeout time.Duration
+
+	// ForwardHeaders are the request headers copied to the outbound requests. Defaults to X-Request-Id.
+	ForwardHeaders []string
+
+	// ForwardAuth are the hosts receiving the Authorization header of the request, like "api.example.com".
+	// A leading "*." matches the subdomains. Other hosts never receive it.
+	ForwardAuth []string
+
+	// Retries is the number of times failed requests are sent again.
+	// Only requests that can be replayed are retried: without body or with GetBody set.
+	Retries int
+
+	// RetryIf decides if an attempt failed. Defaults to transport errors and 502, 503 and 504 responses.
+	RetryIf func(res *http.Response, err error) bool
+
+	// Backoff is the delay before the first retry, doubled on each further attempt. Defaults to 100ms.
+	Backoff time.Duration
+
+	// OnRequest is called after each attempt, along with the yarf_client_requests_total
+	// and yarf_client_request_duration_seconds metrics.
+	OnRequest func(req *http.Request, res *http.Response, err error, elapsed time.Duration)
+}
+
+// defaultRetryIf retries the transport errors and the unavailable upstream responses.
+func defaultRetryIf(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// clientTransport sends the outbound requests of a Context.
+// It keeps a copy of the request data, as the Context may be reused once the request ends.
+type clientTransport struct {
+	opts    *ClientOptions
+	base    http.RoundTripper
+	ctx     context.Context
+	header  http.Header
+	auth    string
+	metrics Metrics
+}
+
+// HTTPClient returns a client bound to the request, for calls to downstream services.
+// The outbound requests carry the trace context, the forwarded headers like X-Request-Id,
+// and the Authorization header for the hosts allowed by the ClientOptions of the application.
+// They're cancelled when the request is, and inherit its deadline.
+// Failed requests are retried according to the options, and measured through the application Metrics.
+//
+//	res, err := c.HTTPClient().Get("http://billing.internal/invoices/" + c.Param("id"))
+func (c *Context) HTTPClient() *http.Client {
+	opts := new(ClientOptions)
+	if c.app != nil && c.app.Client != nil {
+		opts = c.app.Client
+	}
+
+	t := &clientTransport{
+		opts:    opts,
+		base:    opts.Transport,
+		ctx:     c.Request.Context(),
+		header:  make(http.Header),
+		auth:    c.Request.Header.Get("Authorization"),
+		metrics: c.metrics(),
+	}
+	if t.base == nil {
+		t.base = http.DefaultTransport
+	}
+
+	forward := opts.ForwardHeaders
+	if forward == nil {
+		forward = []string{"X-Request-Id"}
+	}
+	for _, h := range forward {
+		if v := c.Request.Header.Values(h); len(v) > 0 {
+			t.header[http.CanonicalHeaderKey(h)] = append([]string(nil), v...)
+		}
+	}
+	c.InjectTrace(t.header)
+
+	return &http.Client{
+		Transport: t,
+		Timeout:   opts.Timeout,
+	}
+}
+
+// forwardAuth returns true if the host may receive the Authorization header of the request.
+func (t *clientTransport) forwardAuth(host string) bool {
+	for _, h := range t.opts.ForwardAuth {
+		if h == host || (strings.HasPrefix(h, "*.") && strings.HasSuffix(host, h[1:])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RoundTrip sends the request with the request data and retries it if needed.
+func (t *clientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Bind the outbound request to the inbound one
+	ctx := req.Context()
+	var cancel context.CancelFunc
+	if d, ok := t.ctx.Deadline(); ok {
+		ctx, cancel = context.WithDeadline(ctx, d)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	stop := context.AfterFunc(t.ctx, cancel)
+	release := func() {
+		stop()
+		cancel()
+	}
+
+	out := req.Clone(ctx)
+	for k, v := range t.header {
+		if _, ok := out.Header[k]; !ok {
+			out.Header[k] = v
+		}
+	}
+	if t.auth != "" && out.Header.Get("Authorization") == "" && t.forwardAuth(out.URL.Hostname()) {
+		out.Header.Set("Authorization", t.auth)
+	}
+
+	retryIf := t.opts.RetryIf
+	if retryIf == nil {
+		retryIf = defaultRetryIf
+	}
+	backoff := t.opts.Backoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+	replayable := out.Body == nil || out.Body == http.NoBody || out.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		res, err := t.send(out)
+		if attempt >= t.opts.Retries || !replayable || !retryIf(res, err) {
+			if err != nil {
+				release()
+				return nil, err
+			}
+			res.Body = &releaseBody{res.Body, release}
+			return res, nil
+		}
+		if res != nil {
+			res.Body.Close()
+		}
+
+		select {
+		case <-time.After(backoff << uint(attempt)):
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+
+		if out.GetBody != nil {
+			body, err := out.GetBody()
+			if err != nil {
+				release()
+				return nil, err
+			}
+			out.Body = body
+		}
+	}
+}
+
+// releaseBody releases the context of an outbound request once its response body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close closes the body and releases the context.
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+
+	return err
+}
+
+// send runs an attempt and measures it.
+func (t *clientTransport) send(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(res.StatusCode)
+	}
+	host := req.URL.Host
+	t.metrics.Counter("yarf_client_requests_total", "Outbound requests sent by the handlers.", "host", "method", "status").Add(1, host, req.Method, status)
+	t.metrics.Histogram("yarf_client_request_duration_seconds", "Duration of the outbound requests.", "host", "method").Observe(elapsed.Seconds(), host, req.Method)
+
+	if t.opts.OnRequest != nil {
+		t.opts.OnRequest(req, res, err, elapsed)
+	}
+
+	return res, err
+}
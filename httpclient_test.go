@@ -0,0 +1,124 @@
+package yarf
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ClientResource calls the upstream URL of the query with the request HTTP client.
+type ClientResource struct {
+	Resource
+}
+
+func (r *ClientResource) Get(c *Context) error {
+	res, err := c.HTTPClient().Get(c.QueryValue("url"))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	c.Status(res.StatusCode)
+	io.Copy(c.Response, res.Body)
+
+	return nil
+}
+
+func TestHTTPClientPropagation(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("X-Request-Id")+"|"+r.Header.Get("Authorization")+"|"+r.Header.Get(HeaderTraceParent))
+	}))
+	defer upstream.Close()
+
+	y := New()
+	y.Add("/call", new(ClientResource))
+
+	req := httptest.NewRequest("GET", "/call?url="+upstream.URL, nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set(HeaderTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	parts := strings.Split(res.Body.String(), "|")
+	if len(parts) != 3 || parts[0] != "req-1" || parts[1] != "" || !strings.Contains(parts[2], "4bf92f3577b34da6a3ce929d0e0e4736") {
+		t.Errorf("Unexpected upstream headers %q", res.Body.String())
+	}
+
+	// Authorization is only forwarded to the allowed hosts
+	y.Client = &ClientOptions{ForwardAuth: []string{"127.0.0.1"}}
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if parts := strings.Split(res.Body.String(), "|"); parts[1] != "Bearer abc" {
+		t.Errorf("Unexpected upstream headers %q", res.Body.String())
+	}
+}
+
+func TestHTTPClientRetries(t *testing.T) {
+	var calls int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+
+	m := newTestMetrics()
+	var attempts int32
+	y := New()
+	y.Metrics = m
+	y.Client = &ClientOptions{
+		Retries: 2,
+		Backoff: time.Millisecond,
+		OnRequest: func(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+			atomic.AddInt32(&attempts, 1)
+		},
+	}
+	y.Add("/call", new(ClientResource))
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/call?url="+upstream.URL, nil))
+
+	if res.Code != 200 || res.Body.String() != "ok" || attempts != 3 {
+		t.Errorf("Expected 200 after 3 attempts, got %d %q after %d", res.Code, res.Body.String(), attempts)
+	}
+
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	if m.get("yarf_client_requests_total{"+host+",GET,503}") != 2 || m.get("yarf_client_requests_total{"+host+",GET,200}") != 1 {
+		t.Errorf("Unexpected metrics %v", m.values)
+	}
+
+	// Retries exhausted
+	atomic.StoreInt32(&calls, -10)
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/call?url="+upstream.URL, nil))
+	if res.Code != 503 {
+		t.Errorf("Expected the last 503 response, got %d", res.Code)
+	}
+}
+
+func TestHTTPClientCancellation(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer upstream.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	c := NewContext(httptest.NewRequest("GET", "/", nil).WithContext(ctx), httptest.NewRecorder())
+
+	start := time.Now()
+	_, err := c.HTTPClient().Get(upstream.URL)
+	if err == nil || time.Since(start) > 2*time.Second {
+		t.Errorf("Expected the request deadline to cancel the call, got %v", err)
+	}
+}
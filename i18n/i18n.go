@@ -0,0 +1,430 @@
+// Package i18n loads translation bundles and binds them to requests in the locale negotiated with the client,
+// so handlers can localize messages with Context.T().
+//
+//	b := i18n.NewBundle("en")
+//	b.LoadFS(translations, "locales/*.json")
+//	y.Use(i18n.NewMiddleware(b))
+//
+//	func (r *Hello) Get(c *yarf.Context) error {
+//		c.Render(c.T("greeting", "name", "Jane"))
+//		return nil
+//	}
+//
+// Messages are strings with {placeholders}, or objects with plural forms selected by the "count" arg:
+//
+//	{
+//		"greeting": "Hello, {name}!",
+//		"cart": {
+//			"items": {"one": "{count} item", "other": "{count} items"}
+//		}
+//	}
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// message is a translation, with plural forms if it depends on a count.
+type message struct {
+	text   string
+	plural map[string]string
+}
+
+// Bundle holds the translations of all locales.
+type Bundle struct {
+	// DefaultLocale is used when the client doesn't accept any available locale,
+	// and as fallback for missing messages.
+	DefaultLocale string
+
+	messages map[string]map[string]message
+	plurals  map[string]PluralRule
+	matcher  language.Matcher
+	tags     []string
+	lock     sync.RWMutex
+}
+
+// NewBundle creates an empty Bundle.
+func NewBundle(defaultLocale string) *Bundle {
+	return &Bundle{
+		DefaultLocale: defaultLocale,
+		messages:      make(map[string]map[string]message),
+		plurals:       make(map[string]PluralRule),
+	}
+}
+
+// normalize returns the canonical form of a language tag, like "pt-BR".
+func normalize(locale string) string {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return locale
+	}
+
+	return tag.String()
+}
+
+// AddMessages adds translations to a locale. Values are strings, objects with plural forms,
+// or nested objects, whose keys are joined with dots.
+func (b *Bundle) AddMessages(locale string, messages map[string]interface{}) error {
+	flat := make(map[string]message)
+	if err := flatten("", messages, flat); err != nil {
+		return fmt.Errorf("i18n: %s: %w", locale, err)
+	}
+
+	locale = normalize(locale)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.messages[locale] == nil {
+		b.messages[locale] = make(map[string]message)
+	}
+	for k, m := range flat {
+		b.messages[locale][k] = m
+	}
+	b.matcher = nil
+
+	return nil
+}
+
+// flatten converts nested messages into dotted keys.
+func flatten(prefix string, values map[string]interface{}, out map[string]message) error {
+	for k, v := range values {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		switch v := v.(type) {
+		case string:
+			out[key] = message{text: v}
+		case map[string]interface{}:
+			if forms, ok := pluralForms(v); ok {
+				out[key] = message{plural: forms}
+				continue
+			}
+			if err := flatten(key, v, out); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("invalid message %s of type %T", key, v)
+		}
+	}
+
+	return nil
+}
+
+// pluralForms returns the plural forms if all the keys are plural categories and values are strings.
+func pluralForms(values map[string]interface{}) (map[string]string, bool) {
+	if len(values) == 0 {
+		return nil, false
+	}
+
+	forms := make(map[string]string, len(values))
+	for k, v := range values {
+		s, ok := v.(string)
+		if !ok || !isPluralCategory(k) {
+			return nil, false
+		}
+		forms[k] = s
+	}
+
+	return forms, true
+}
+
+// LoadJSON adds the translations of a JSON document to a locale.
+func (b *Bundle) LoadJSON(locale string, data []byte) error {
+	var messages map[string]interface{}
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return fmt.Errorf("i18n: %s: %w", locale, err)
+	}
+
+	return b.AddMessages(locale, messages)
+}
+
+// LoadTOML adds the translations of a TOML document to a locale.
+// Tables nest keys and plural forms, and only string values are supported.
+func (b *Bundle) LoadTOML(locale string, data []byte) error {
+	messages, err := parseTOML(string(data))
+	if err != nil {
+		return fmt.Errorf("i18n: %s: %w", locale, err)
+	}
+
+	return b.AddMessages(locale, messages)
+}
+
+// load adds a translation file, taking the locale from its name, like "pt-BR.json" or "fr.toml".
+func (b *Bundle) load(name string, data []byte) error {
+	ext := path.Ext(name)
+	locale := strings.TrimSuffix(path.Base(name), ext)
+
+	switch strings.ToLower(ext) {
+	case ".json":
+		return b.LoadJSON(locale, data)
+	case ".toml":
+		return b.LoadTOML(locale, data)
+	}
+
+	return fmt.Errorf("i18n: unsupported file %s", name)
+}
+
+// LoadFile adds a JSON or TOML translation file, taking the locale from its name, like "pt-BR.json".
+func (b *Bundle) LoadFile(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	return b.load(filepath.ToSlash(name), data)
+}
+
+// LoadFS adds the translation files matching the pattern in fsys, like an embed.FS.
+func (b *Bundle) LoadFS(fsys fs.FS, pattern string) error {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if err := b.load(name, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Locales returns the locales with translations, sorted.
+func (b *Bundle) Locales() []string {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	locales := make([]string, 0, len(b.messages))
+	for l := range b.messages {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+
+	return locales
+}
+
+// SetPluralRule sets the plural rule of a language, overriding the built-in rules.
+func (b *Bundle) SetPluralRule(lang string, rule PluralRule) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.plurals[normalize(lang)] = rule
+}
+
+// Match returns the available locale that best matches the preferences,
+// in order of priority, like a query param and the Accept-Language header.
+// It returns the default locale if none matches.
+func (b *Bundle) Match(preferences ...string) string {
+	b.lock.Lock()
+	if b.matcher == nil {
+		// The default locale goes first, as the matcher fallback
+		def := normalize(b.DefaultLocale)
+		b.tags = []string{def}
+		for l := range b.messages {
+			if l != def {
+				b.tags = append(b.tags, l)
+			}
+		}
+		sort.Strings(b.tags[1:])
+
+		tags := make([]language.Tag, len(b.tags))
+		for i, l := range b.tags {
+			tags[i] = language.Make(l)
+		}
+		b.matcher = language.NewMatcher(tags)
+	}
+	matcher, tags := b.matcher, b.tags
+	b.lock.Unlock()
+
+	_, i := language.MatchStrings(matcher, preferences...)
+
+	return tags[i]
+}
+
+// Localizer returns a Localizer for the locale.
+// Missing messages fall back to the parent locales, like "pt" for "pt-BR", and then to the default locale.
+func (b *Bundle) Localizer(locale string) *Localizer {
+	locale = normalize(locale)
+
+	l := &Localizer{bundle: b, locale: locale}
+	for tag := language.Make(locale); ; {
+		l.chain = append(l.chain, tag.String())
+		parent := tag.Parent()
+		if parent == language.Und || parent == tag {
+			break
+		}
+		tag = parent
+	}
+	if def := normalize(b.DefaultLocale); def != locale {
+		l.chain = append(l.chain, def)
+	}
+
+	return l
+}
+
+// Localizer translates messages to a locale. It implements the yarf.Localizer interface.
+type Localizer struct {
+	bundle *Bundle
+	locale string
+	chain  []string
+}
+
+// Locale returns the locale of the Localizer.
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// T returns the message for the key, or the key itself if it's missing in all the fallback locales.
+// Args are name and value pairs replacing the {name} placeholders, or a single map[string]interface{}.
+// Plural forms are selected by the "count" arg.
+func (l *Localizer) T(key string, args ...interface{}) string {
+	values := argValues(args)
+
+	l.bundle.lock.RLock()
+	defer l.bundle.lock.RUnlock()
+
+	for _, locale := range l.chain {
+		m, ok := l.bundle.messages[locale][key]
+		if !ok {
+			continue
+		}
+
+		text := m.text
+		if m.plural != nil {
+			text = m.plural[l.bundle.plural(locale, values["count"])]
+			if text == "" {
+				text = m.plural["other"]
+			}
+		}
+
+		return format(text, values)
+	}
+
+	return key
+}
+
+// plural returns the plural category of the count in the locale.
+func (b *Bundle) plural(locale string, count interface{}) string {
+	n, ok := toInt(count)
+	if !ok {
+		return "other"
+	}
+
+	for tag := language.Make(locale); ; {
+		if rule, ok := b.plurals[tag.String()]; ok {
+			return rule(n)
+		}
+		base, _ := tag.Base()
+		if rule, ok := pluralRules[base.String()]; ok {
+			return rule(n)
+		}
+		parent := tag.Parent()
+		if parent == language.Und || parent == tag {
+			break
+		}
+		tag = parent
+	}
+
+	return pluralOneOther(n)
+}
+
+// argValues converts the T args into a map.
+func argValues(args []interface{}) map[string]interface{} {
+	if len(args) == 1 {
+		if m, ok := args[0].(map[string]interface{}); ok {
+			return m
+		}
+	}
+
+	values := make(map[string]interface{}, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		values[fmt.Sprint(args[i])] = args[i+1]
+	}
+
+	return values
+}
+
+// format replaces the {name} placeholders. Unknown placeholders are kept.
+func format(text string, values map[string]interface{}) string {
+	if len(values) == 0 || !strings.Contains(text, "{") {
+		return text
+	}
+
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(text, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(text[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		sb.WriteString(text[:start])
+		if v, ok := values[text[start+1:end]]; ok {
+			sb.WriteString(fmt.Sprint(v))
+		} else {
+			sb.WriteString(text[start : end+1])
+		}
+		text = text[end+1:]
+	}
+	sb.WriteString(text)
+
+	return sb.String()
+}
+
+// toInt converts a count arg into an integer.
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int8:
+		return int(n), true
+	case int16:
+		return int(n), true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case uint:
+		return int(n), true
+	case uint8:
+		return int(n), true
+	case uint16:
+		return int(n), true
+	case uint32:
+		return int(n), true
+	case uint64:
+		return int(n), true
+	case float32:
+		return int(n), n == float32(int(n))
+	case float64:
+		return int(n), n == float64(int(n))
+	case string:
+		i, err := strconv.Atoi(n)
+		return i, err == nil
+	}
+
+	return 0, false
+}
@@ -0,0 +1,179 @@
+package i18n
+
+import (
+	"os"
+	"testing"
+)
+
+func newTestBundle(t *testing.T) *Bundle {
+	b := NewBundle("en")
+	if err := b.LoadFS(os.DirFS("testdata"), "*"); err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func TestLoad(t *testing.T) {
+	b := newTestBundle(t)
+
+	locales := b.Locales()
+	if len(locales) != 3 || locales[0] != "en" || locales[1] != "pt" || locales[2] != "pt-BR" {
+		t.Errorf("Unexpected locales %v", locales)
+	}
+
+	if err := b.LoadFile("testdata/en.json"); err != nil {
+		t.Errorf("Unexpected error loading file: %s", err)
+	}
+	if err := b.LoadFile("i18n.go"); err == nil {
+		t.Error("Unsupported files should fail")
+	}
+	if err := b.LoadJSON("en", []byte(`{"invalid": 1}`)); err == nil {
+		t.Error("Non string messages should fail")
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	b := newTestBundle(t)
+
+	tests := []struct {
+		locale, key string
+		args        []interface{}
+		expected    string
+	}{
+		{"en", "greeting", []interface{}{"name", "Jane"}, "Hello, Jane!"},
+		{"en", "greeting", nil, "Hello, {name}!"},
+		{"en", "greeting", []interface{}{map[string]interface{}{"name": "Ann"}}, "Hello, Ann!"},
+		{"en", "cart.items", []interface{}{"count", 1}, "1 item"},
+		{"en", "cart.items", []interface{}{"count", 3}, "3 items"},
+		{"en", "cart.items", []interface{}{"count", 1.5}, "1.5 items"},
+		{"en", "missing", nil, "missing"},
+		{"pt", "greeting", []interface{}{"name", "Jane"}, "Olá, Jane!"},
+		{"pt", "cart.items", []interface{}{"count", 2}, "2 itens"},
+		// Fallback to the parent locale, then the default one
+		{"pt-BR", "greeting", []interface{}{"name", "Jane"}, "Oi, Jane!"},
+		{"pt-BR", "cart.items", []interface{}{"count", 2}, "2 itens"},
+		{"pt-BR", "farewell", nil, "Goodbye"},
+		{"de", "farewell", nil, "Goodbye"},
+	}
+
+	for _, tt := range tests {
+		l := b.Localizer(tt.locale)
+		if got := l.T(tt.key, tt.args...); got != tt.expected {
+			t.Errorf("Expected %q for %s in %s, got %q", tt.expected, tt.key, tt.locale, got)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	b := newTestBundle(t)
+
+	tests := []struct {
+		prefs    []string
+		expected string
+	}{
+		{nil, "en"},
+		{[]string{"de-DE,de;q=0.9"}, "en"},
+		{[]string{"pt-BR,pt;q=0.9,en;q=0.8"}, "pt-BR"},
+		{[]string{"pt-PT"}, "pt"},
+		{[]string{"fr;q=0.9,pt;q=0.8"}, "pt"},
+		{[]string{"en", "pt-BR"}, "en"},
+		{[]string{"", "pt-BR"}, "pt-BR"},
+	}
+
+	for _, tt := range tests {
+		if got := b.Match(tt.prefs...); got != tt.expected {
+			t.Errorf("Expected %s for %v, got %s", tt.expected, tt.prefs, got)
+		}
+	}
+}
+
+func TestPluralRules(t *testing.T) {
+	tests := []struct {
+		lang     string
+		n        int
+		expected string
+	}{
+		{"en", 0, Other},
+		{"en", 1, One},
+		{"fr", 0, One},
+		{"fr", 2, Other},
+		{"ja", 1, Other},
+		{"ru", 1, One},
+		{"ru", 21, One},
+		{"ru", 11, Many},
+		{"ru", 3, Few},
+		{"ru", 13, Many},
+		{"ru", 5, Many},
+		{"pl", 1, One},
+		{"pl", 22, Few},
+		{"pl", 21, Many},
+		{"cs", 3, Few},
+		{"cs", 5, Other},
+		{"ar", 0, Zero},
+		{"ar", 2, Two},
+		{"ar", 105, Few},
+		{"ar", 111, Many},
+		{"ar", 100, Other},
+	}
+
+	b := NewBundle("en")
+	for _, tt := range tests {
+		if got := b.plural(tt.lang, tt.n); got != tt.expected {
+			t.Errorf("Expected %s for %d in %s, got %s", tt.expected, tt.n, tt.lang, got)
+		}
+	}
+
+	b.SetPluralRule("en", func(n int) string { return Many })
+	if got := b.plural("en-US", 1); got != Many {
+		t.Errorf("Custom plural rule should be used, got %s", got)
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	doc := `
+# Comment
+title = "Hello \"world\"" # trailing comment
+path = 'C:\temp'
+"quoted key" = "quoted"
+nested.key = "dotted"
+
+[section]
+a = "1"
+
+[section.sub]
+b = "2"
+`
+	m, err := parseTOML(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m["title"] != `Hello "world"` || m["path"] != `C:\temp` || m["quoted key"] != "quoted" {
+		t.Errorf("Unexpected values %v", m)
+	}
+	if m["nested"].(map[string]interface{})["key"] != "dotted" {
+		t.Errorf("Unexpected dotted key %v", m["nested"])
+	}
+	section := m["section"].(map[string]interface{})
+	if section["a"] != "1" || section["sub"].(map[string]interface{})["b"] != "2" {
+		t.Errorf("Unexpected tables %v", section)
+	}
+
+	invalid := []string{
+		"key",
+		"key = 1",
+		`key = "unterminated`,
+		`key = "a" "b"`,
+		`[table`,
+		`[[array]]`,
+		"a = \"1\"\na = \"2\"",
+		"a = \"1\"\n[a]",
+		`bad key = "1"`,
+	}
+	for _, doc := range invalid {
+		if _, err := parseTOML(doc); err == nil {
+			t.Errorf("Expected error parsing %q", doc)
+		}
+	}
+}
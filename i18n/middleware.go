@@ -0,0 +1,55 @@
+package i18n
+
+import (
+	"github.com/yarf-framework/yarf"
+)
+
+// Middleware negotiates the locale of each request and binds a Localizer for it to the Context,
+// used by Context.T(). Preferences are taken, in order, from the Query param, the Cookie
+// and the Accept-Language header. The Content-Language header is set to the negotiated locale.
+type Middleware struct {
+	yarf.Middleware
+
+	// Bundle with the translations.
+	Bundle *Bundle
+
+	// Query is the name of a query param choosing the locale, like "lang". Empty to ignore.
+	Query string
+
+	// Cookie is the name of a cookie choosing the locale. Empty to ignore.
+	Cookie string
+}
+
+// NewMiddleware creates a Middleware negotiating the locale with the Accept-Language header.
+func NewMiddleware(b *Bundle) *Middleware {
+	return &Middleware{
+		Bundle: b,
+	}
+}
+
+// PreDispatch negotiates the locale and binds the Localizer.
+func (m *Middleware) PreDispatch(c *yarf.Context) error {
+	var prefs []string
+
+	if m.Query != "" {
+		if v := c.Request.URL.Query().Get(m.Query); v != "" {
+			prefs = append(prefs, v)
+		}
+	}
+	if m.Cookie != "" {
+		if cookie, err := c.Request.Cookie(m.Cookie); err == nil && cookie.Value != "" {
+			prefs = append(prefs, cookie.Value)
+		}
+	}
+	if v := c.Request.Header.Get("Accept-Language"); v != "" {
+		prefs = append(prefs, v)
+	}
+
+	l := m.Bundle.Localizer(m.Bundle.Match(prefs...))
+	c.SetLocalizer(l)
+
+	c.Response.Header().Set("Content-Language", l.Locale())
+	c.Response.Header().Add("Vary", "Accept-Language")
+
+	return nil
+}
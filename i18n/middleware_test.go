@@ -0,0 +1,62 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+)
+
+type greeting struct {
+	yarf.Resource
+}
+
+func (r *greeting) Get(c *yarf.Context) error {
+	c.Render(c.Locale() + ": " + c.T("greeting", "name", "Jane"))
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	m := NewMiddleware(newTestBundle(t))
+	m.Query = "lang"
+	m.Cookie = "lang"
+
+	y := yarf.New()
+	y.Use(m)
+	y.Add("/", new(greeting))
+
+	tests := []struct {
+		url, cookie, accept, expected string
+	}{
+		{"/", "", "", "en: Hello, Jane!"},
+		{"/", "", "pt-BR,pt;q=0.9", "pt-BR: Oi, Jane!"},
+		{"/", "pt", "en", "pt: Olá, Jane!"},
+		{"/?lang=en", "pt", "pt", "en: Hello, Jane!"},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "http://localhost"+tt.url, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept-Language", tt.accept)
+		}
+		if tt.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+		}
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Body.String() != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, res.Body.String())
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "http://localhost/", nil)
+	req.Header.Set("Accept-Language", "pt")
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Header().Get("Content-Language") != "pt" || res.Header().Get("Vary") != "Accept-Language" {
+		t.Errorf("Unexpected headers %v", res.Header())
+	}
+}
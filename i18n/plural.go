@@ -0,0 +1,140 @@
+package i18n
+
+// Plural categories, as defined by the Unicode CLDR.
+const (
+	Zero  = "zero"
+	One   = "one"
+	Two   = "two"
+	Few   = "few"
+	Many  = "many"
+	Other = "other"
+)
+
+// isPluralCategory returns true if s is a plural category.
+func isPluralCategory(s string) bool {
+	switch s {
+	case Zero, One, Two, Few, Many, Other:
+		return true
+	}
+
+	return false
+}
+
+// PluralRule returns the plural category of a count.
+type PluralRule func(n int) string
+
+// pluralRules are the built-in cardinal rules, by base language.
+// Languages not listed use the English rule.
+var pluralRules = map[string]PluralRule{
+	// No plural forms
+	"ja": pluralOther,
+	"zh": pluralOther,
+	"ko": pluralOther,
+	"vi": pluralOther,
+	"th": pluralOther,
+	"id": pluralOther,
+	"ms": pluralOther,
+
+	// One for 0 and 1
+	"fr": pluralZeroOne,
+	"hi": pluralZeroOne,
+
+	// Slavic languages
+	"ru": pluralEastSlavic,
+	"uk": pluralEastSlavic,
+	"be": pluralEastSlavic,
+	"pl": pluralPolish,
+	"cs": pluralCzech,
+	"sk": pluralCzech,
+
+	"ar": pluralArabic,
+}
+
+// pluralOther is the rule of languages without plural forms.
+func pluralOther(n int) string {
+	return Other
+}
+
+// pluralOneOther is the rule of English and most Western European languages.
+func pluralOneOther(n int) string {
+	if n == 1 {
+		return One
+	}
+
+	return Other
+}
+
+// pluralZeroOne is the rule of languages using the singular for 0, like French.
+func pluralZeroOne(n int) string {
+	if n == 0 || n == 1 {
+		return One
+	}
+
+	return Other
+}
+
+// pluralEastSlavic is the rule of Russian, Ukrainian and Belarusian.
+func pluralEastSlavic(n int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	switch mod10, mod100 := n%10, n%100; {
+	case mod10 == 1 && mod100 != 11:
+		return One
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return Few
+	}
+
+	return Many
+}
+
+// pluralPolish is the rule of Polish.
+func pluralPolish(n int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	switch mod10, mod100 := n%10, n%100; {
+	case n == 1:
+		return One
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return Few
+	}
+
+	return Many
+}
+
+// pluralCzech is the rule of Czech and Slovak.
+func pluralCzech(n int) string {
+	switch {
+	case n == 1:
+		return One
+	case n >= 2 && n <= 4:
+		return Few
+	}
+
+	return Other
+}
+
+// pluralArabic is the rule of Arabic.
+func pluralArabic(n int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	switch mod100 := n % 100; {
+	case n == 0:
+		return Zero
+	case n == 1:
+		return One
+	case n == 2:
+		return Two
+	case mod100 >= 3 && mod100 <= 10:
+		return Few
+	case mod100 >= 11:
+		return Many
+	}
+
+	return Other
+}
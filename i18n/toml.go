@@ -0,0 +1,191 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML parses the subset of TOML used by translation files:
+// comments, [table] headers with dotted names, and key = "string" pairs with bare, quoted or dotted keys.
+// Basic strings support escapes, and literal strings are taken as they are.
+func parseTOML(doc string) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	table := root
+
+	for n, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.LastIndexByte(line, ']')
+			if end < 0 || strings.HasPrefix(line, "[[") || strings.TrimSpace(stripComment(line[end+1:])) != "" {
+				return nil, fmt.Errorf("line %d: invalid table header", n+1)
+			}
+			keys, err := parseKey(line[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			if table, err = subtable(root, keys); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			continue
+		}
+
+		eq := keyEnd(line)
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", n+1)
+		}
+		keys, err := parseKey(line[:eq])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		value, rest, err := parseString(strings.TrimSpace(line[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		if strings.TrimSpace(stripComment(rest)) != "" {
+			return nil, fmt.Errorf("line %d: unexpected %q after value", n+1, rest)
+		}
+
+		parent, err := subtable(table, keys[:len(keys)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		last := keys[len(keys)-1]
+		if _, ok := parent[last]; ok {
+			return nil, fmt.Errorf("line %d: duplicated key %s", n+1, last)
+		}
+		parent[last] = value
+	}
+
+	return root, nil
+}
+
+// stripComment removes a trailing comment.
+func stripComment(s string) string {
+	if i := strings.IndexByte(s, '#'); i >= 0 {
+		return s[:i]
+	}
+
+	return s
+}
+
+// keyEnd returns the position of the = separating the key, skipping quoted keys.
+func keyEnd(line string) int {
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '=':
+			return i
+		}
+	}
+
+	return -1
+}
+
+// parseKey splits a dotted key into its parts.
+func parseKey(s string) ([]string, error) {
+	var keys []string
+
+	s = strings.TrimSpace(s)
+	for {
+		var key string
+		if s != "" && (s[0] == '"' || s[0] == '\'') {
+			var err error
+			if key, s, err = parseString(s); err != nil {
+				return nil, err
+			}
+		} else {
+			end := strings.IndexByte(s, '.')
+			if end < 0 {
+				end = len(s)
+			}
+			key = strings.TrimSpace(s[:end])
+			s = s[end:]
+			for _, c := range key {
+				if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+					return nil, fmt.Errorf("invalid key %q", key)
+				}
+			}
+			if key == "" {
+				return nil, fmt.Errorf("empty key")
+			}
+		}
+		keys = append(keys, key)
+
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return keys, nil
+		}
+		if s[0] != '.' {
+			return nil, fmt.Errorf("invalid key near %q", s)
+		}
+		s = strings.TrimSpace(s[1:])
+	}
+}
+
+// parseString parses a basic or literal string, returning the rest of the input.
+func parseString(s string) (string, string, error) {
+	if s == "" {
+		return "", "", fmt.Errorf("missing value")
+	}
+
+	switch s[0] {
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+
+	case '"':
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				value, err := strconv.Unquote(s[:i+1])
+				if err != nil {
+					return "", "", fmt.Errorf("invalid string %s", s[:i+1])
+				}
+				return value, s[i+1:], nil
+			}
+		}
+		return "", "", fmt.Errorf("unterminated string")
+	}
+
+	return "", "", fmt.Errorf("only string values are supported, got %q", s)
+}
+
+// subtable returns the table at the keys, creating the missing ones.
+func subtable(root map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	t := root
+	for _, k := range keys {
+		v, ok := t[k]
+		if !ok {
+			next := make(map[string]interface{})
+			t[k] = next
+			t = next
+			continue
+		}
+
+		next, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("key %s is already a value", k)
+		}
+		t = next
+	}
+
+	return t, nil
+}
@@ -0,0 +1,290 @@
+package yarf
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IdempotencyRecord is the state of an idempotency key: reserved by a running request, or holding its response.
+type IdempotencyRecord struct {
+	// Fingerprint identifies the request that used the key, so the key can't be reused for another request.
+	Fingerprint string
+
+	// Done is true once the response is stored.
+	Done bool
+
+	// Status, Header and Body are the stored response.
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// IdempotencyStore keeps the idempotency keys of the requests and their responses.
+type IdempotencyStore interface {
+	// Reserve records the key as running the request with the fingerprint for ttl, and returns nil.
+	// If the key is already recorded and hasn't expired, it returns its record instead.
+	// It must be atomic, so concurrent requests with the same key can't both run.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error)
+
+	// Complete stores the response of the key for ttl.
+	Complete(ctx context.Context, key string, r *IdempotencyRecord, ttl time.Duration) error
+
+	// Release removes the key, so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// idempotencyEntry is a MemoryIdempotencyStore record with expiration time.
+type idempotencyEntry struct {
+	record  IdempotencyRecord
+	expires time.Time
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore implementation, for single instance deployments.
+type MemoryIdempotencyStore struct {
+	// Clock tells the time to expire the keys. nil uses the system clock.
+	Clock Clock
+
+	keys map[string]idempotencyEntry
+	next time.Time
+	lock sync.Mutex
+}
+
+// NewMemoryIdempotencyStore creates a new empty MemoryIdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		keys: make(map[string]idempotencyEntry),
+	}
+}
+
+// Reserve records the key for ttl, or returns a copy of its record if it already exists.
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := clockNow(s.Clock)
+	if e, ok := s.keys[key]; ok && now.Before(e.expires) {
+		r := e.record
+		return &r, nil
+	}
+	s.keys[key] = idempotencyEntry{record: IdempotencyRecord{Fingerprint: fingerprint}, expires: now.Add(ttl)}
+
+	// Cleanup expired keys, at most once per ttl
+	if now.After(s.next) {
+		for k, e := range s.keys {
+			if !now.Before(e.expires) {
+				delete(s.keys, k)
+			}
+		}
+		s.next = now.Add(ttl)
+	}
+
+	return nil, nil
+}
+
+// Complete stores the response of the key for ttl.
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, key string, r *IdempotencyRecord, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.keys[key] = idempotencyEntry{record: *r, expires: clockNow(s.Clock).Add(ttl)}
+
+	return nil
+}
+
+// Release removes the key.
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.keys, key)
+
+	return nil
+}
+
+// IdempotentResource wraps a ResourceHandler so POST and PATCH requests carrying an idempotency key
+// run only once: retries with the same key get the stored response instead of running the handler again.
+//
+//	y.Add("/payments", yarf.Idempotent(new(Payments), yarf.NewMemoryIdempotencyStore()))
+//
+// A key reused for a different request gets a 422 error, and a retry while the first request
+// is still running gets a 409 error. Failed requests and 5xx responses aren't stored, so they can be retried.
+// Request bodies are read in memory to fingerprint the requests, and responses are buffered.
+// Use a shared store, like the redis or sqlstore ones, with several instances.
+type IdempotentResource struct {
+	ResourceHandler
+
+	// Store keeps the keys and the responses.
+	Store IdempotencyStore
+
+	// Header is the request header carrying the idempotency key.
+	Header string
+
+	// Required rejects the requests without key with a 400 error.
+	Required bool
+
+	// MaxKeyLength bounds the keys accepted, to keep the store small.
+	MaxKeyLength int
+
+	// TTL is the time the responses are replayed.
+	TTL time.Duration
+
+	// Timeout is the time a key stays reserved by a running request, so a crashed instance doesn't lock it forever.
+	Timeout time.Duration
+
+	// Scope returns the namespace of the request keys, like the client ID, so clients can't read each other's responses.
+	// If nil, keys are global.
+	Scope func(*Context) string
+}
+
+// Idempotent creates an IdempotentResource wrapping the handler provided, reading the Idempotency-Key header,
+// with keys up to 255 characters, responses replayed for 24 hours and reservations expiring after a minute.
+func Idempotent(h ResourceHandler, store IdempotencyStore) *IdempotentResource {
+	return &IdempotentResource{
+		ResourceHandler: h,
+		Store:           store,
+		Header:          "Idempotency-Key",
+		MaxKeyLength:    255,
+		TTL:             24 * time.Hour,
+		Timeout:         time.Minute,
+	}
+}
+
+// Post executes the wrapped Post method once per idempotency key.
+func (r *IdempotentResource) Post(c *Context) error {
+	return r.serve(c, r.ResourceHandler.Post)
+}
+
+// Patch executes the wrapped Patch method once per idempotency key.
+func (r *IdempotentResource) Patch(c *Context) error {
+	return r.serve(c, r.ResourceHandler.Patch)
+}
+
+// fingerprint hashes the method, the URL and the body of the request, restoring the body for the handler.
+func fingerprint(c *Context) (string, error) {
+	h := sha256.New()
+	io.WriteString(h, c.Request.Method+" "+c.Request.URL.RequestURI()+"\n")
+
+	if c.Request.Body != nil {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", err
+		}
+		c.Request.Body.Close()
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// serve writes the stored response of the request key, or runs next and stores its response.
+func (r *IdempotentResource) serve(c *Context, next func(*Context) error) error {
+	key := strings.TrimSpace(c.Request.Header.Get(r.Header))
+	if key == "" {
+		if r.Required {
+			e := ErrorBadRequest()
+			e.ErrorBody = "Missing idempotency key"
+			return e
+		}
+		return next(c)
+	}
+	if r.MaxKeyLength > 0 && len(key) > r.MaxKeyLength {
+		e := ErrorBadRequest()
+		e.ErrorBody = "Invalid idempotency key"
+		return e
+	}
+	if r.Scope != nil {
+		key = r.Scope(c) + ":" + key
+	}
+
+	fp, err := fingerprint(c)
+	if err != nil {
+		return err
+	}
+
+	results := c.metrics().Counter("yarf_idempotency_requests_total", "Requests with an idempotency key, by result.", "result")
+	ctx := c.Request.Context()
+
+	rec, err := r.Store.Reserve(ctx, key, fp, r.Timeout)
+	if err != nil {
+		return err
+	}
+	if rec != nil {
+		if rec.Fingerprint != fp {
+			results.Add(1, "mismatch")
+			e := ErrorUnprocessableEntity()
+			e.ErrorBody = "Idempotency key already used for another request"
+			return e
+		}
+		if !rec.Done {
+			results.Add(1, "conflict")
+			e := ErrorConflict()
+			e.ErrorBody = "Request with the same idempotency key in progress"
+			return e
+		}
+
+		results.Add(1, "replayed")
+		h := c.Response.Header()
+		for k, v := range rec.Header {
+			h[k] = append([]string(nil), v...)
+		}
+		h.Set("Idempotent-Replayed", "true")
+		c.Response.WriteHeader(rec.Status)
+		c.Response.Write(rec.Body)
+
+		return nil
+	}
+	results.Add(1, "new")
+
+	// Run the handler against a buffer, releasing the key if it fails or panics
+	buf := newBufferedResponse()
+	rw := c.Response
+	c.Response = buf
+	stored := false
+	defer func() {
+		c.Response = rw
+		if !stored {
+			if err := r.Store.Release(ctx, key); err != nil && c.app != nil {
+				c.app.log().Error("idempotency key release failed", "error", err)
+			}
+		}
+	}()
+
+	err = next(c)
+	c.Response = rw
+	buf.flush(c.Response)
+
+	status := buf.code
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if err != nil || status >= 500 {
+		return err
+	}
+
+	rec = &IdempotencyRecord{
+		Fingerprint: fp,
+		Done:        true,
+		Status:      status,
+		Header:      buf.header.Clone(),
+		Body:        buf.body.Bytes(),
+	}
+
+	// The response is already sent, so a store failure only releases the key
+	if err := r.Store.Complete(ctx, key, rec, r.TTL); err != nil {
+		if c.app != nil {
+			c.app.log().Error("idempotency response store failed", "error", err)
+		}
+		return nil
+	}
+	stored = true
+
+	return nil
+}
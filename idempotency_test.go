@@ -0,0 +1,160 @@
+package yarf
+
+import (
+	"context"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// PaymentResource counts the payments created, and fails on demand.
+type PaymentResource struct {
+	Resource
+
+	calls int
+}
+
+func (r *PaymentResource) Post(c *Context) error {
+	r.calls++
+	if c.QueryValue("fail") != "" {
+		return ErrorServiceUnavailable()
+	}
+	c.Response.Header().Set("X-Payment", strconv.Itoa(r.calls))
+	c.Response.WriteHeader(201)
+	c.Render("payment " + strconv.Itoa(r.calls))
+
+	return nil
+}
+
+func TestIdempotentResource(t *testing.T) {
+	r := new(PaymentResource)
+	m := newTestMetrics()
+
+	y := New()
+	y.Metrics = m
+	y.Add("/payments", Idempotent(r, NewMemoryIdempotencyStore()))
+
+	post := func(target, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", target, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+		return res
+	}
+
+	post("/payments", "k1", "10")
+	res := post("/payments", "k1", "10")
+	if r.calls != 1 || res.Code != 201 || res.Body.String() != "payment 1" || res.Header().Get("X-Payment") != "1" ||
+		res.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Expected replayed response, got %d calls: %d %v %q", r.calls, res.Code, res.Header(), res.Body.String())
+	}
+
+	if res := post("/payments", "k1", "20"); res.Code != 422 || r.calls != 1 {
+		t.Errorf("Expected 422 for a reused key, got %d", res.Code)
+	}
+
+	// Requests without key always run
+	post("/payments", "", "10")
+	post("/payments", "", "10")
+	if r.calls != 3 {
+		t.Errorf("Expected requests without key to run, got %d calls", r.calls)
+	}
+
+	// Failed requests can be retried
+	if res := post("/payments?fail=1", "k2", "10"); res.Code != 503 {
+		t.Errorf("Expected failed request, got %d", res.Code)
+	}
+	if res := post("/payments?fail=1", "k2", "10"); res.Code != 503 || r.calls != 5 {
+		t.Errorf("Expected retried request, got %d with %d calls", res.Code, r.calls)
+	}
+
+	if res := post("/payments", strings.Repeat("k", 256), "10"); res.Code != 400 {
+		t.Errorf("Expected 400 for a long key, got %d", res.Code)
+	}
+
+	if m.get("yarf_idempotency_requests_total{replayed}") != 1 || m.get("yarf_idempotency_requests_total{mismatch}") != 1 ||
+		m.get("yarf_idempotency_requests_total{new}") != 3 {
+		t.Errorf("Unexpected metrics %v", m.values)
+	}
+}
+
+func TestIdempotentResourceOptions(t *testing.T) {
+	r := new(PaymentResource)
+	store := NewMemoryIdempotencyStore()
+	res := Idempotent(r, store)
+	res.Required = true
+	res.Scope = func(c *Context) string { return c.Request.Header.Get("X-Client") }
+
+	y := New()
+	y.Add("/payments", res)
+
+	post := func(client, key string) int {
+		req := httptest.NewRequest("POST", "/payments", strings.NewReader("10"))
+		req.Header.Set("X-Client", client)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		y.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := post("a", ""); code != 400 || r.calls != 0 {
+		t.Errorf("Expected 400 without key, got %d", code)
+	}
+
+	// Scoped keys
+	post("a", "k")
+	post("b", "k")
+	if r.calls != 2 {
+		t.Errorf("Expected a request per client, got %d calls", r.calls)
+	}
+
+	// Running requests lock the key
+	store.Reserve(context.Background(), "a:running", "", time.Minute)
+	if code := post("a", "running"); code != 422 {
+		t.Errorf("Expected 422 for another request, got %d", code)
+	}
+	c := NewContext(httptest.NewRequest("POST", "/payments", strings.NewReader("10")), httptest.NewRecorder())
+	c.Request.Header.Set("Idempotency-Key", "busy")
+	c.Request.Header.Set("X-Client", "a")
+	fp, _ := fingerprint(c)
+	store.Reserve(context.Background(), "a:busy", fp, time.Minute)
+	if code := post("a", "busy"); code != 409 {
+		t.Errorf("Expected 409 for a running request, got %d", code)
+	}
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	clock := newTestClock()
+	s := NewMemoryIdempotencyStore()
+	s.Clock = clock
+	ctx := context.Background()
+
+	if r, err := s.Reserve(ctx, "k", "fp", time.Minute); r != nil || err != nil {
+		t.Errorf("Expected reserved key, got %v %v", r, err)
+	}
+	if r, _ := s.Reserve(ctx, "k", "other", time.Minute); r == nil || r.Fingerprint != "fp" || r.Done {
+		t.Errorf("Expected running record, got %+v", r)
+	}
+
+	s.Complete(ctx, "k", &IdempotencyRecord{Fingerprint: "fp", Done: true, Status: 200}, time.Hour)
+	clock.Advance(2 * time.Minute)
+	if r, _ := s.Reserve(ctx, "k", "fp", time.Minute); r == nil || !r.Done || r.Status != 200 {
+		t.Errorf("Expected stored response, got %+v", r)
+	}
+
+	s.Release(ctx, "k")
+	if r, _ := s.Reserve(ctx, "k", "fp", time.Minute); r != nil {
+		t.Errorf("Expected released key, got %+v", r)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if r, _ := s.Reserve(ctx, "k", "fp", time.Minute); r != nil {
+		t.Errorf("Expected expired key, got %+v", r)
+	}
+}
@@ -0,0 +1,251 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Job queue errors
+var (
+	ErrQueueFull   = errors.New("yarf: job queue full")
+	ErrQueueClosed = errors.New("yarf: job queue closed")
+)
+
+// Job is a unit of work to run in the background.
+type Job interface {
+	Run(ctx context.Context) error
+}
+
+// JobFunc adapts a function into a Job.
+type JobFunc func(ctx context.Context) error
+
+// Run calls f(ctx).
+func (f JobFunc) Run(ctx context.Context) error {
+	return f(ctx)
+}
+
+// JobQueue runs jobs in the background.
+// WorkerPool runs them in-process, and other implementations can send them to external queues.
+type JobQueue interface {
+	Enqueue(job Job) error
+}
+
+// JobStats are the counters exposed by WorkerPool.
+type JobStats struct {
+	Queued    int    // Jobs waiting for a worker
+	Running   int64  // Jobs running
+	Succeeded uint64 // Jobs finished without error
+	Failed    uint64 // Jobs finished with error or panic
+	Rejected  uint64 // Jobs rejected because the queue was full or closed
+}
+
+// WorkerPool is an in-process JobQueue running jobs on a fixed number of goroutines.
+// Jobs receive a context canceled when the pool is closed, and panics are recovered as failures.
+type WorkerPool struct {
+	// OnError is called with the jobs that fail. Defaults to logging the error with the framework logger
+	// of the application the pool is set on, or slog.Default() before that.
+	OnError func(Job, error)
+
+	app     *Yarf
+	jobs    chan Job
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	closed  bool
+	running int64
+	stats   JobStats
+	lock    sync.RWMutex
+}
+
+// NewWorkerPool creates a WorkerPool with the number of workers and the maximum number of jobs waiting for them.
+func NewWorkerPool(workers, size int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &WorkerPool{
+		jobs: make(chan Job, size),
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+
+	return p
+}
+
+// Enqueue adds a job to the queue without blocking. It returns ErrQueueFull if all workers are busy
+// and the queue is full, or ErrQueueClosed after Close.
+func (p *WorkerPool) Enqueue(job Job) error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.closed {
+		atomic.AddUint64(&p.stats.Rejected, 1)
+		return ErrQueueClosed
+	}
+
+	select {
+	case p.jobs <- job:
+		return nil
+	default:
+		atomic.AddUint64(&p.stats.Rejected, 1)
+		return ErrQueueFull
+	}
+}
+
+// work runs the queued jobs until the pool is closed and the queue drained.
+func (p *WorkerPool) work() {
+	defer p.wg.Done()
+
+	for job := range p.jobs {
+		p.run(job)
+	}
+}
+
+// run executes a job, recovering panics.
+func (p *WorkerPool) run(job Job) {
+	atomic.AddInt64(&p.running, 1)
+	defer atomic.AddInt64(&p.running, -1)
+
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job panic: %v", r)
+			}
+		}()
+		err = job.Run(p.ctx)
+	}()
+
+	if err == nil {
+		atomic.AddUint64(&p.stats.Succeeded, 1)
+		return
+	}
+
+	atomic.AddUint64(&p.stats.Failed, 1)
+	if p.OnError != nil {
+		p.OnError(job, err)
+	} else {
+		p.log().Error("job failed", "error", err)
+	}
+}
+
+// log returns the logger of the application the pool is set on, or slog.Default().
+func (p *WorkerPool) log() Logger {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.app != nil {
+		return p.app.log()
+	}
+
+	return slog.Default()
+}
+
+// Stats returns a snapshot of the pool counters.
+func (p *WorkerPool) Stats() JobStats {
+	return JobStats{
+		Queued:    len(p.jobs),
+		Running:   atomic.LoadInt64(&p.running),
+		Succeeded: atomic.LoadUint64(&p.stats.Succeeded),
+		Failed:    atomic.LoadUint64(&p.stats.Failed),
+		Rejected:  atomic.LoadUint64(&p.stats.Rejected),
+	}
+}
+
+// Close stops accepting jobs and waits for the queued and running jobs to finish.
+// If ctx is done first, the context of the running jobs is canceled and ctx.Err() is returned.
+func (p *WorkerPool) Close(ctx context.Context) error {
+	p.lock.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.lock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+// SetJobQueue sets the queue used by Enqueue, like an adapter to an external queue.
+// Its lifecycle is managed by the caller. A WorkerPool logs the failed jobs with the framework logger.
+func (y *Yarf) SetJobQueue(q JobQueue) {
+	if p, ok := q.(*WorkerPool); ok {
+		p.lock.Lock()
+		p.app = y
+		p.lock.Unlock()
+	}
+
+	y.lock.Lock()
+	defer y.lock.Unlock()
+
+	y.jobs = q
+}
+
+// jobQueue returns the job queue, creating a WorkerPool with a worker per CPU if none was set.
+// The default pool is closed on Shutdown, waiting for the queued jobs.
+func (y *Yarf) jobQueue() JobQueue {
+	y.lock.Lock()
+	defer y.lock.Unlock()
+
+	if y.jobs == nil {
+		p := NewWorkerPool(runtime.NumCPU(), 1024)
+		p.app = y
+		y.jobs = p
+		y.onStop = append(y.onStop, p.Close)
+	}
+
+	return y.jobs
+}
+
+// Enqueue runs a job in the background through the job queue.
+func (y *Yarf) Enqueue(job Job) error {
+	return y.jobQueue().Enqueue(job)
+}
+
+// afterKey is the Context storage key for the jobs to enqueue after the response.
+type afterKey struct{}
+
+// After schedules a job to be enqueued once the response was sent and the End middleware ran,
+// so handlers can offload slow follow-up work, like sending emails.
+// Jobs are only enqueued if the request didn't fail.
+// The job must not use the Context, as it may be reused by then.
+func (c *Context) After(job Job) {
+	jobs, _ := c.get(afterKey{}).([]Job)
+	c.set(afterKey{}, append(jobs, job))
+}
+
+// enqueueAfter enqueues the jobs scheduled with Context.After.
+func (y *Yarf) enqueueAfter(c *Context) {
+	jobs, _ := c.get(afterKey{}).([]Job)
+	if len(jobs) == 0 || c.err != nil {
+		return
+	}
+
+	q := y.jobQueue()
+	for _, job := range jobs {
+		if err := q.Enqueue(job); err != nil {
+			y.log().Error("job enqueue failed", "error", err, "method", c.Request.Method, "path", c.Request.URL.Path)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool(t *testing.T) {
+	p := NewWorkerPool(2, 10)
+
+	var lock sync.Mutex
+	var failed []error
+	p.OnError = func(job Job, err error) {
+		lock.Lock()
+		failed = append(failed, err)
+		lock.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		p.Enqueue(JobFunc(func(ctx context.Context) error {
+			wg.Done()
+			return nil
+		}))
+	}
+	wg.Wait()
+
+	p.Enqueue(JobFunc(func(ctx context.Context) error {
+		return errors.New("failed")
+	}))
+	p.Enqueue(JobFunc(func(ctx context.Context) error {
+		panic("boom")
+	}))
+
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := p.Stats()
+	if stats.Succeeded != 3 || stats.Failed != 2 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if len(failed) != 2 {
+		t.Errorf("Expected OnError for failures and panics, got %v", failed)
+	}
+
+	if err := p.Enqueue(JobFunc(func(ctx context.Context) error { return nil })); err != ErrQueueClosed {
+		t.Errorf("Expected ErrQueueClosed, got %v", err)
+	}
+}
+
+func TestWorkerPoolFull(t *testing.T) {
+	p := NewWorkerPool(1, 1)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	p.Enqueue(JobFunc(func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}))
+	<-started
+
+	noop := JobFunc(func(ctx context.Context) error { return nil })
+	if err := p.Enqueue(noop); err != nil {
+		t.Errorf("Queue should accept one job, got %v", err)
+	}
+	if err := p.Enqueue(noop); err != ErrQueueFull {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+
+	stats := p.Stats()
+	if stats.Queued != 1 || stats.Running != 1 || stats.Rejected != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	close(release)
+	p.Close(context.Background())
+}
+
+func TestWorkerPoolCloseTimeout(t *testing.T) {
+	p := NewWorkerPool(1, 1)
+
+	canceled := make(chan struct{})
+	started := make(chan struct{})
+	p.Enqueue(JobFunc(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+		return ctx.Err()
+	}))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := p.Close(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline error, got %v", err)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("Running jobs should be canceled when Close times out")
+	}
+}
+
+// recordQueue is a JobQueue recording the jobs, like an external queue adapter.
+type recordQueue struct {
+	jobs []Job
+	sync.Mutex
+}
+
+func (q *recordQueue) Enqueue(job Job) error {
+	q.Lock()
+	defer q.Unlock()
+
+	q.jobs = append(q.jobs, job)
+	return nil
+}
+
+func (q *recordQueue) len() int {
+	q.Lock()
+	defer q.Unlock()
+
+	return len(q.jobs)
+}
+
+// AfterResource schedules a job after the response.
+type AfterResource struct {
+	Resource
+}
+
+func (r *AfterResource) Get(c *Context) error {
+	c.After(JobFunc(func(ctx context.Context) error { return nil }))
+	if c.Param("fail") != "" {
+		return ErrorNotFound()
+	}
+	return nil
+}
+
+func TestContextAfter(t *testing.T) {
+	q := new(recordQueue)
+
+	y := New()
+	y.SetJobQueue(q)
+	y.Add("/ok", new(AfterResource))
+	y.Add("/fail/:fail", new(AfterResource))
+
+	y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ok", nil))
+	if q.len() != 1 {
+		t.Errorf("Expected job enqueued after the response, got %d", q.len())
+	}
+
+	y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fail/1", nil))
+	if q.len() != 1 {
+		t.Errorf("Jobs of failed requests shouldn't be enqueued, got %d", q.len())
+	}
+}
+
+func TestYarfEnqueue(t *testing.T) {
+	y := New()
+
+	done := make(chan struct{})
+	if err := y.Enqueue(JobFunc(func(ctx context.Context) error {
+		close(done)
+		return nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Job didn't run on the default worker pool")
+	}
+
+	// The default pool is closed on Shutdown
+	if err := y.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := y.Enqueue(JobFunc(func(ctx context.Context) error { return nil })); err != ErrQueueClosed {
+		t.Errorf("Expected ErrQueueClosed after Shutdown, got %v", err)
+	}
+}
+
+func TestWorkerPoolLogger(t *testing.T) {
+	l := new(MockLogger)
+	y := New()
+	y.Log = l
+
+	p := NewWorkerPool(1, 10)
+	y.SetJobQueue(p)
+	y.Enqueue(JobFunc(func(ctx context.Context) error {
+		return errors.New("smtp down")
+	}))
+	p.Close(context.Background())
+
+	if len(l.entries) != 1 || l.entries[0].msg != "job failed" {
+		t.Errorf("Expected the failure logged with the framework logger, got %+v", l.entries)
+	}
+}
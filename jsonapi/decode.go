@@ -0,0 +1,109 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// requestDocument is a JSON:API document with a single resource, as sent by clients.
+type requestDocument struct {
+	Data *struct {
+		Type       string                     `json:"type"`
+		ID         string                     `json:"id"`
+		Attributes map[string]json.RawMessage `json:"attributes"`
+	} `json:"data"`
+}
+
+// Decode reads a JSON:API document with a single resource from the request body into v,
+// a pointer to a tagged struct. The resource id, if any, and the attributes present are set,
+// so v can be prefilled with the current values for partial updates.
+// Invalid documents return an *Error with status 400, or 409 for a wrong resource type,
+// to be rendered with RenderError.
+//
+//	var a Article
+//	if err := jsonapi.Decode(c, &a); err != nil {
+//		return jsonapi.RenderError(c, err)
+//	}
+func Decode(c *yarf.Context, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("jsonapi: Decode needs a pointer to a struct")
+	}
+	rv = rv.Elem()
+
+	md, err := modelOf(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+
+	var doc requestDocument
+	if err := json.Unmarshal(data, &doc); err != nil || doc.Data == nil {
+		return decodeError(http.StatusBadRequest, "Invalid document", "The request must be a JSON:API document with a resource object", "")
+	}
+	if doc.Data.Type != md.typ {
+		return decodeError(http.StatusConflict, "Invalid type", "Expected resource type "+md.typ, "/data/type")
+	}
+
+	if doc.Data.ID != "" {
+		if err := setID(rv.FieldByIndex(md.id), doc.Data.ID); err != nil {
+			return decodeError(http.StatusBadRequest, "Invalid id", err.Error(), "/data/id")
+		}
+	}
+
+	for _, f := range md.attrs {
+		raw, ok := doc.Data.Attributes[f.name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, rv.FieldByIndex(f.index).Addr().Interface()); err != nil {
+			return decodeError(http.StatusBadRequest, "Invalid attribute", err.Error(), "/data/attributes/"+f.name)
+		}
+	}
+
+	return nil
+}
+
+// setID sets a string or integer id field.
+func setID(v reflect.Value, id string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(id)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	default:
+		return errors.New("unsupported id type " + v.Type().String())
+	}
+
+	return nil
+}
+
+// decodeError creates the Error of an invalid request document.
+func decodeError(status int, title, detail, pointer string) *Error {
+	e := &Error{Status: strconv.Itoa(status), Title: title, Detail: detail}
+	if pointer != "" {
+		e.Source = &ErrorSource{Pointer: pointer}
+	}
+
+	return e
+}
@@ -0,0 +1,95 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Error is a JSON:API error object.
+type Error struct {
+	ID     string                 `json:"id,omitempty"`
+	Status string                 `json:"status,omitempty"`
+	Code   string                 `json:"code,omitempty"`
+	Title  string                 `json:"title,omitempty"`
+	Detail string                 `json:"detail,omitempty"`
+	Source *ErrorSource           `json:"source,omitempty"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+}
+
+// Error returns the error title and detail.
+func (e *Error) Error() string {
+	if e.Detail == "" {
+		return e.Title
+	}
+
+	return e.Title + ": " + e.Detail
+}
+
+// ErrorSource points to the part of the request that caused the error.
+type ErrorSource struct {
+	Pointer   string `json:"pointer,omitempty"`   // JSON pointer to the request document, like "/data/attributes/title"
+	Parameter string `json:"parameter,omitempty"` // Query param name
+	Header    string `json:"header,omitempty"`    // Request header name
+}
+
+// errorsDocument is a JSON:API document with errors, which can't have data.
+type errorsDocument struct {
+	Errors []*Error `json:"errors"`
+}
+
+// RenderErrors writes a JSON:API errors document with the status code provided.
+// Errors without status get it from the response status code.
+//
+//	return jsonapi.RenderErrors(c, 422, &jsonapi.Error{
+//		Title:  "Invalid attribute",
+//		Detail: "Title can't be empty",
+//		Source: &jsonapi.ErrorSource{Pointer: "/data/attributes/title"},
+//	})
+func RenderErrors(c *yarf.Context, status int, errs ...*Error) error {
+	for _, e := range errs {
+		if e.Status == "" {
+			e.Status = strconv.Itoa(status)
+		}
+	}
+
+	encoded, err := json.Marshal(errorsDocument{errs})
+	if err != nil {
+		return err
+	}
+
+	c.Response.Header().Set("Content-Type", MediaType)
+	c.Response.WriteHeader(status)
+	c.Response.Write(encoded)
+
+	return nil
+}
+
+// RenderError writes any error as a JSON:API errors document.
+// Yarf errors keep their status code, with their body as detail, and other errors are sent as 500 errors
+// without details.
+//
+//	if err := save(article); err != nil {
+//		return jsonapi.RenderError(c, err)
+//	}
+func RenderError(c *yarf.Context, err error) error {
+	if e, ok := err.(*Error); ok {
+		status, _ := strconv.Atoi(e.Status)
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		return RenderErrors(c, status, e)
+	}
+
+	status := http.StatusInternalServerError
+	e := new(Error)
+	if yerr, ok := err.(yarf.YError); ok {
+		status = yerr.Code()
+		e.Detail = yerr.Body()
+	}
+	e.Title = http.StatusText(status)
+
+	return RenderErrors(c, status, e)
+}
@@ -0,0 +1,126 @@
+// Package jsonapi renders Yarf responses following the JSON:API media type (https://jsonapi.org).
+// Documents are built from structs with jsonapi tags:
+//
+//	type Article struct {
+//		ID       int        `jsonapi:"primary,articles"`
+//		Title    string     `jsonapi:"attr,title"`
+//		Draft    bool       `jsonapi:"attr,draft,omitempty"`
+//		Author   *Person    `jsonapi:"relation,author"`
+//		Comments []*Comment `jsonapi:"relation,comments"`
+//	}
+//
+//	func (r *Articles) Get(c *yarf.Context) error {
+//		return jsonapi.Render(c, articles)
+//	}
+//
+// Related resources are added to the "included" member when requested with ?include=author,comments.author,
+// and sparse fieldsets are supported with ?fields[articles]=title,author.
+package jsonapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// MediaType is the JSON:API media type, used as the Content-Type of the responses.
+const MediaType = "application/vnd.api+json"
+
+// Document is a JSON:API top level document.
+type Document struct {
+	Data     interface{}            `json:"data"` // *Resource, []*Resource or nil
+	Included []*Resource            `json:"included,omitempty"`
+	Meta     map[string]interface{} `json:"meta,omitempty"`
+	Links    map[string]string      `json:"links,omitempty"`
+}
+
+// Resource is a JSON:API resource object.
+type Resource struct {
+	Type          string                   `json:"type"`
+	ID            string                   `json:"id,omitempty"`
+	Attributes    map[string]interface{}   `json:"attributes,omitempty"`
+	Relationships map[string]*Relationship `json:"relationships,omitempty"`
+}
+
+// Relationship is a JSON:API relationship object.
+type Relationship struct {
+	Data interface{} `json:"data"` // *Identifier, []*Identifier or nil
+}
+
+// Identifier is a JSON:API resource identifier object.
+type Identifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Options control how documents are built.
+type Options struct {
+	// Include are the relationship paths added to the included resources, like "comments.author".
+	Include []string
+
+	// Fields are the sparse fieldsets, with the attributes and relationships rendered for each type.
+	Fields map[string][]string
+}
+
+// OptionsFromRequest reads the include and fields[type] query params of the request.
+func OptionsFromRequest(c *yarf.Context) Options {
+	var opts Options
+
+	q := c.Request.URL.Query()
+	if inc := q.Get("include"); inc != "" {
+		opts.Include = strings.Split(inc, ",")
+	}
+
+	for k, v := range q {
+		if !strings.HasPrefix(k, "fields[") || !strings.HasSuffix(k, "]") || len(v) == 0 {
+			continue
+		}
+		if opts.Fields == nil {
+			opts.Fields = make(map[string][]string)
+		}
+		opts.Fields[k[len("fields["):len(k)-1]] = strings.Split(v[0], ",")
+	}
+
+	return opts
+}
+
+// Render writes v as a JSON:API document, with the include and sparse fieldsets of the request.
+// v is a tagged struct, a pointer to it or a slice of them.
+// Invalid include paths get a 400 error, and untagged values a 500 error.
+func Render(c *yarf.Context, v interface{}) error {
+	return RenderStatus(c, http.StatusOK, v)
+}
+
+// RenderStatus is Render with a custom status code, like 201 for created resources.
+func RenderStatus(c *yarf.Context, status int, v interface{}) error {
+	doc, err := Marshal(v, OptionsFromRequest(c))
+	if err != nil {
+		if _, ok := err.(*IncludeError); ok {
+			return RenderErrors(c, http.StatusBadRequest, &Error{
+				Status: "400",
+				Title:  "Invalid include",
+				Detail: err.Error(),
+				Source: &ErrorSource{Parameter: "include"},
+			})
+		}
+		return err
+	}
+
+	return RenderDocument(c, status, doc)
+}
+
+// RenderDocument writes a document built with Marshal, after adding top level meta or links to it.
+func RenderDocument(c *yarf.Context, status int, doc *Document) error {
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	c.Response.Header().Set("Content-Type", MediaType)
+	c.Response.WriteHeader(status)
+	c.Response.Write(encoded)
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// ArticlesResource renders the test articles and creates new ones.
+type ArticlesResource struct {
+	yarf.Resource
+}
+
+func (r *ArticlesResource) Get(c *yarf.Context) error {
+	return Render(c, testArticles())
+}
+
+func (r *ArticlesResource) Post(c *yarf.Context) error {
+	a := &Article{Title: "Default"}
+	if err := Decode(c, a); err != nil {
+		return RenderError(c, err)
+	}
+	a.ID = 3
+
+	return RenderStatus(c, 201, a)
+}
+
+func testServer() *yarf.Yarf {
+	y := yarf.New()
+	y.Add("/articles", new(ArticlesResource))
+
+	return y
+}
+
+func TestRender(t *testing.T) {
+	res := httptest.NewRecorder()
+	testServer().ServeHTTP(res, httptest.NewRequest("GET", "/articles?include=author&fields[articles]=title,author&fields[people]=name", nil))
+
+	if res.Code != 200 || res.Header().Get("Content-Type") != MediaType {
+		t.Fatalf("Expected 200 with JSON:API media type, got %d %s", res.Code, res.Header().Get("Content-Type"))
+	}
+
+	var doc map[string]interface{}
+	json.Unmarshal(res.Body.Bytes(), &doc)
+
+	first := doc["data"].([]interface{})[0]
+	expectJSON(t, first, `{
+		"type": "articles",
+		"id": "1",
+		"attributes": {"title": "Hello"},
+		"relationships": {"author": {"data": {"type": "people", "id": "9"}}}
+	}`)
+	if n := len(doc["included"].([]interface{})); n != 2 {
+		t.Errorf("Expected 2 included people, got %d", n)
+	}
+}
+
+func TestRenderInvalidInclude(t *testing.T) {
+	res := httptest.NewRecorder()
+	testServer().ServeHTTP(res, httptest.NewRequest("GET", "/articles?include=missing", nil))
+
+	if res.Code != 400 || res.Header().Get("Content-Type") != MediaType {
+		t.Fatalf("Expected 400 with JSON:API media type, got %d %s", res.Code, res.Header().Get("Content-Type"))
+	}
+	expectJSON(t, decodeBody(t, res), `{"errors": [{
+		"status": "400",
+		"title": "Invalid include",
+		"detail": "jsonapi: invalid include path missing",
+		"source": {"parameter": "include"}
+	}]}`)
+}
+
+func TestDecode(t *testing.T) {
+	body := `{"data": {"type": "articles", "attributes": {"draft": true, "unknown": 1}}}`
+
+	res := httptest.NewRecorder()
+	testServer().ServeHTTP(res, httptest.NewRequest("POST", "/articles", strings.NewReader(body)))
+
+	if res.Code != 201 {
+		t.Fatalf("Expected 201, got %d: %s", res.Code, res.Body.String())
+	}
+
+	doc := decodeBody(t, res)
+	attrs := doc["data"].(map[string]interface{})["attributes"]
+	expectJSON(t, attrs, `{"created": "", "title": "Default", "draft": true}`)
+}
+
+func TestDecodeID(t *testing.T) {
+	var a Article
+
+	c := yarf.NewContext(httptest.NewRequest("POST", "/", strings.NewReader(`{"data": {"type": "articles", "id": "42"}}`)), httptest.NewRecorder())
+	if err := Decode(c, &a); err != nil || a.ID != 42 {
+		t.Errorf("Expected id 42, got %d %v", a.ID, err)
+	}
+
+	var p Person
+	c = yarf.NewContext(httptest.NewRequest("POST", "/", strings.NewReader(`{"data": {"type": "people", "id": "x1"}}`)), httptest.NewRecorder())
+	if err := Decode(c, &p); err != nil || p.ID != "x1" {
+		t.Errorf("Expected id x1, got %s %v", p.ID, err)
+	}
+
+	if err := Decode(c, p); err == nil {
+		t.Error("Expected error decoding into a non pointer")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		body    string
+		status  int
+		pointer string
+	}{
+		{`not json`, 400, ""},
+		{`{"meta": {}}`, 400, ""},
+		{`{"data": {"type": "people"}}`, 409, "/data/type"},
+		{`{"data": {"type": "articles", "id": "x"}}`, 400, "/data/id"},
+		{`{"data": {"type": "articles", "attributes": {"title": 1}}}`, 400, "/data/attributes/title"},
+	}
+
+	for _, tt := range tests {
+		res := httptest.NewRecorder()
+		testServer().ServeHTTP(res, httptest.NewRequest("POST", "/articles", strings.NewReader(tt.body)))
+
+		if res.Code != tt.status || res.Header().Get("Content-Type") != MediaType {
+			t.Errorf("Expected %d with JSON:API media type for %s, got %d", tt.status, tt.body, res.Code)
+			continue
+		}
+
+		e := decodeBody(t, res)["errors"].([]interface{})[0].(map[string]interface{})
+		if e["status"] != res.Result().Status[:3] {
+			t.Errorf("Expected error status %d, got %v", tt.status, e["status"])
+		}
+		if tt.pointer != "" {
+			if src, _ := e["source"].(map[string]interface{}); src == nil || src["pointer"] != tt.pointer {
+				t.Errorf("Expected pointer %s for %s, got %v", tt.pointer, tt.body, e["source"])
+			}
+		}
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	tests := []struct {
+		err      error
+		status   int
+		expected string
+	}{
+		{yarf.ErrorNotFound(), 404, `{"errors": [{"status": "404", "title": "Not Found"}]}`},
+		{&yarf.CustomError{HTTPCode: 422, ErrorBody: "Invalid title"}, 422, `{"errors": [{"status": "422", "title": "Unprocessable Entity", "detail": "Invalid title"}]}`},
+		{errors.New("database password leaked"), 500, `{"errors": [{"status": "500", "title": "Internal Server Error"}]}`},
+		{&Error{Status: "409", Code: "conflict", Title: "Conflict"}, 409, `{"errors": [{"status": "409", "code": "conflict", "title": "Conflict"}]}`},
+		{&Error{Title: "No status"}, 500, `{"errors": [{"status": "500", "title": "No status"}]}`},
+	}
+
+	for _, tt := range tests {
+		res := httptest.NewRecorder()
+		c := yarf.NewContext(httptest.NewRequest("GET", "/", nil), res)
+
+		if err := RenderError(c, tt.err); err != nil {
+			t.Fatal(err)
+		}
+		if res.Code != tt.status {
+			t.Errorf("Expected status %d, got %d", tt.status, res.Code)
+		}
+		expectJSON(t, decodeBody(t, res), tt.expected)
+	}
+}
+
+func decodeBody(t *testing.T, res *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(res.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Invalid JSON %s: %s", res.Body.String(), err)
+	}
+
+	return doc
+}
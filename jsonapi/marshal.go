@@ -0,0 +1,363 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ErrNoPrimary is returned when marshaling a struct without a primary tag.
+var ErrNoPrimary = errors.New("jsonapi: struct without primary field")
+
+// IncludeError is returned when an include path isn't a relationship of the resources.
+type IncludeError struct {
+	Path string
+}
+
+// Error describes the invalid path.
+func (e *IncludeError) Error() string {
+	return "jsonapi: invalid include path " + e.Path
+}
+
+// field is a tagged struct field.
+type field struct {
+	index     []int
+	name      string
+	omitempty bool
+}
+
+// model is the JSON:API description of a struct type.
+type model struct {
+	typ   string
+	id    []int
+	attrs []field
+	rels  []field
+}
+
+// models caches the struct descriptions by type.
+var models sync.Map
+
+// modelOf parses the jsonapi tags of a struct type.
+func modelOf(t reflect.Type) (*model, error) {
+	if m, ok := models.Load(t); ok {
+		return m.(*model), nil
+	}
+
+	m := new(model)
+	for _, f := range reflect.VisibleFields(t) {
+		tag, ok := f.Tag.Lookup("jsonapi")
+		if !ok || !f.IsExported() {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("jsonapi: invalid tag %q on %s.%s", tag, t.Name(), f.Name)
+		}
+
+		tf := field{index: f.Index, name: parts[1]}
+		for _, opt := range parts[2:] {
+			if opt == "omitempty" {
+				tf.omitempty = true
+			}
+		}
+
+		switch parts[0] {
+		case "primary":
+			m.typ, m.id = parts[1], f.Index
+		case "attr":
+			m.attrs = append(m.attrs, tf)
+		case "relation":
+			m.rels = append(m.rels, tf)
+		default:
+			return nil, fmt.Errorf("jsonapi: invalid tag %q on %s.%s", tag, t.Name(), f.Name)
+		}
+	}
+
+	if m.id == nil {
+		return nil, ErrNoPrimary
+	}
+
+	models.Store(t, m)
+
+	return m, nil
+}
+
+// relation returns the relationship field by name.
+func (m *model) relation(name string) (field, bool) {
+	for _, f := range m.rels {
+		if f.name == name {
+			return f, true
+		}
+	}
+
+	return field{}, false
+}
+
+// elemType returns the struct type of a value, a pointer or a slice.
+func elemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// marshaler builds a document, collecting the included resources.
+type marshaler struct {
+	opts     Options
+	include  map[string]bool
+	fields   map[string]map[string]bool
+	included []*Resource
+	seen     map[string]bool
+}
+
+// Marshal builds a JSON:API document from a tagged struct, a pointer to it or a slice of them.
+// A nil pointer renders null data, and an empty slice an empty array.
+func Marshal(v interface{}, opts Options) (*Document, error) {
+	m := &marshaler{
+		opts:    opts,
+		include: make(map[string]bool),
+		fields:  make(map[string]map[string]bool),
+		seen:    make(map[string]bool),
+	}
+
+	for typ, names := range opts.Fields {
+		set := make(map[string]bool, len(names))
+		for _, n := range names {
+			set[strings.TrimSpace(n)] = true
+		}
+		m.fields[typ] = set
+	}
+
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return &Document{}, nil
+	}
+
+	if err := m.validateIncludes(rv.Type()); err != nil {
+		return nil, err
+	}
+
+	doc := new(Document)
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		// Primary resources are never included
+		for i := 0; i < rv.Len(); i++ {
+			if id, err := identifier(rv.Index(i)); err == nil && id != nil {
+				m.seen[id.Type+":"+id.ID] = true
+			}
+		}
+
+		list := make([]*Resource, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			r, err := m.resource(rv.Index(i), "")
+			if err != nil {
+				return nil, err
+			}
+			if r != nil {
+				list = append(list, r)
+			}
+		}
+		doc.Data = list
+
+	default:
+		if id, err := identifier(rv); err == nil && id != nil {
+			m.seen[id.Type+":"+id.ID] = true
+		}
+
+		r, err := m.resource(rv, "")
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			doc.Data = r
+		}
+	}
+
+	doc.Included = m.included
+
+	return doc, nil
+}
+
+// validateIncludes checks the include paths against the relationships of the primary type.
+func (m *marshaler) validateIncludes(t reflect.Type) error {
+	for _, path := range m.opts.Include {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		et := elemType(t)
+		for i, name := range strings.Split(path, ".") {
+			if et.Kind() != reflect.Struct {
+				return &IncludeError{path}
+			}
+			md, err := modelOf(et)
+			if err != nil {
+				return err
+			}
+			f, ok := md.relation(name)
+			if !ok {
+				return &IncludeError{path}
+			}
+			et = elemType(et.FieldByIndex(f.index).Type)
+
+			// Intermediate paths are included too
+			m.include[strings.Join(strings.Split(path, ".")[:i+1], ".")] = true
+		}
+	}
+
+	return nil
+}
+
+// allowed checks the sparse fieldset of a type.
+func (m *marshaler) allowed(typ, name string) bool {
+	set, ok := m.fields[typ]
+
+	return !ok || set[name]
+}
+
+// resource builds the resource object of a struct value, or nil for nil pointers.
+func (m *marshaler) resource(v reflect.Value, path string) (*Resource, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("jsonapi: can't marshal %s", v.Type())
+	}
+
+	md, err := modelOf(v.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Resource{
+		Type: md.typ,
+		ID:   idString(v.FieldByIndex(md.id)),
+	}
+
+	for _, f := range md.attrs {
+		fv := v.FieldByIndex(f.index)
+		if !m.allowed(md.typ, f.name) || (f.omitempty && fv.IsZero()) {
+			continue
+		}
+		if r.Attributes == nil {
+			r.Attributes = make(map[string]interface{})
+		}
+		r.Attributes[f.name] = fv.Interface()
+	}
+
+	for _, f := range md.rels {
+		fv := v.FieldByIndex(f.index)
+		if !m.allowed(md.typ, f.name) || (f.omitempty && fv.IsZero()) {
+			continue
+		}
+
+		rel, err := m.relationship(fv, path+f.name)
+		if err != nil {
+			return nil, err
+		}
+		if r.Relationships == nil {
+			r.Relationships = make(map[string]*Relationship)
+		}
+		r.Relationships[f.name] = rel
+	}
+
+	return r, nil
+}
+
+// relationship builds the relationship object of a field, including the related resources if requested.
+func (m *marshaler) relationship(v reflect.Value, path string) (*Relationship, error) {
+	include := m.include[path]
+	rel := new(Relationship)
+
+	related := []reflect.Value{v}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		related = related[:0]
+		ids := make([]*Identifier, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			id, err := identifier(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			if id != nil {
+				ids = append(ids, id)
+				related = append(related, v.Index(i))
+			}
+		}
+		rel.Data = ids
+	} else {
+		id, err := identifier(v)
+		if err != nil {
+			return nil, err
+		}
+		if id != nil {
+			rel.Data = id
+		}
+	}
+
+	if !include {
+		return rel, nil
+	}
+
+	for _, rv := range related {
+		id, _ := identifier(rv)
+		if id == nil {
+			continue
+		}
+
+		key := id.Type + ":" + id.ID
+		if m.seen[key] {
+			continue
+		}
+		m.seen[key] = true
+
+		// Keep the slot so resources are included before their own relationships
+		i := len(m.included)
+		m.included = append(m.included, nil)
+
+		r, err := m.resource(rv, path+".")
+		if err != nil {
+			return nil, err
+		}
+		m.included[i] = r
+	}
+
+	return rel, nil
+}
+
+// identifier returns the resource identifier of a struct value, or nil for nil pointers.
+func identifier(v reflect.Value) (*Identifier, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("jsonapi: can't marshal %s", v.Type())
+	}
+
+	md, err := modelOf(v.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identifier{Type: md.typ, ID: idString(v.FieldByIndex(md.id))}, nil
+}
+
+// idString formats an id field.
+func idString(v reflect.Value) string {
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,190 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type Person struct {
+	ID    string `jsonapi:"primary,people"`
+	Name  string `jsonapi:"attr,name"`
+	Email string `jsonapi:"attr,email,omitempty"`
+}
+
+type Comment struct {
+	ID     int     `jsonapi:"primary,comments"`
+	Body   string  `jsonapi:"attr,body"`
+	Author *Person `jsonapi:"relation,author"`
+}
+
+type Timestamps struct {
+	Created string `jsonapi:"attr,created"`
+}
+
+type Article struct {
+	Timestamps
+	ID       int        `jsonapi:"primary,articles"`
+	Title    string     `jsonapi:"attr,title"`
+	Draft    bool       `jsonapi:"attr,draft,omitempty"`
+	Author   *Person    `jsonapi:"relation,author"`
+	Editor   *Person    `jsonapi:"relation,editor"`
+	Comments []*Comment `jsonapi:"relation,comments"`
+	internal string
+}
+
+func testArticles() []*Article {
+	john := &Person{ID: "9", Name: "John", Email: "john@example.com"}
+	jane := &Person{ID: "10", Name: "Jane"}
+
+	return []*Article{
+		{
+			Timestamps: Timestamps{"2024-01-01"},
+			ID:         1,
+			Title:      "Hello",
+			Author:     john,
+			Comments: []*Comment{
+				{ID: 5, Body: "First", Author: jane},
+				{ID: 6, Body: "Second", Author: john},
+			},
+		},
+		{ID: 2, Title: "World", Draft: true, Author: jane, Comments: []*Comment{}},
+	}
+}
+
+// marshalJSON marshals a document and decodes it back into a generic value.
+func marshalJSON(t *testing.T, v interface{}, opts Options) map[string]interface{} {
+	doc, err := Marshal(v, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, _ := json.Marshal(doc)
+
+	var res map[string]interface{}
+	json.Unmarshal(encoded, &res)
+
+	return res
+}
+
+// expectJSON compares a decoded document with the expected JSON.
+func expectJSON(t *testing.T, got interface{}, expected string) {
+	t.Helper()
+
+	var e interface{}
+	if err := json.Unmarshal([]byte(expected), &e); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, e) {
+		encoded, _ := json.Marshal(got)
+		t.Errorf("Expected %s, got %s", expected, encoded)
+	}
+}
+
+func TestMarshalSingle(t *testing.T) {
+	doc := marshalJSON(t, testArticles()[1], Options{})
+
+	expectJSON(t, doc, `{
+		"data": {
+			"type": "articles",
+			"id": "2",
+			"attributes": {"created": "", "title": "World", "draft": true},
+			"relationships": {
+				"author": {"data": {"type": "people", "id": "10"}},
+				"editor": {"data": null},
+				"comments": {"data": []}
+			}
+		}
+	}`)
+}
+
+func TestMarshalIncluded(t *testing.T) {
+	doc := marshalJSON(t, testArticles(), Options{Include: []string{"comments.author"}})
+
+	data := doc["data"].([]interface{})
+	if len(data) != 2 {
+		t.Fatalf("Expected 2 resources, got %d", len(data))
+	}
+
+	included := doc["included"].([]interface{})
+	var keys []string
+	for _, r := range included {
+		r := r.(map[string]interface{})
+		keys = append(keys, r["type"].(string)+":"+r["id"].(string))
+	}
+
+	expected := []string{"comments:5", "people:10", "comments:6", "people:9"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected included %v, got %v", expected, keys)
+	}
+}
+
+func TestMarshalSparseFieldsets(t *testing.T) {
+	doc := marshalJSON(t, testArticles()[0], Options{
+		Include: []string{"author"},
+		Fields:  map[string][]string{"articles": {"title", "author"}, "people": {"name"}},
+	})
+
+	expectJSON(t, doc, `{
+		"data": {
+			"type": "articles",
+			"id": "1",
+			"attributes": {"title": "Hello"},
+			"relationships": {"author": {"data": {"type": "people", "id": "9"}}}
+		},
+		"included": [{"type": "people", "id": "9", "attributes": {"name": "John"}}]
+	}`)
+}
+
+func TestMarshalPrimaryNotIncluded(t *testing.T) {
+	a, b := &Person{ID: "1", Name: "A"}, &Person{ID: "2", Name: "B"}
+
+	type Friend struct {
+		ID     string  `jsonapi:"primary,people"`
+		Friend *Person `jsonapi:"relation,friend"`
+	}
+
+	doc := marshalJSON(t, []*Friend{{ID: "1", Friend: b}, {ID: "2", Friend: a}}, Options{Include: []string{"friend"}})
+	if _, ok := doc["included"]; ok {
+		t.Errorf("Primary resources shouldn't be included, got %v", doc["included"])
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	if _, err := Marshal(testArticles(), Options{Include: []string{"author.comments"}}); err == nil {
+		t.Error("Expected error for invalid include path")
+	} else if _, ok := err.(*IncludeError); !ok {
+		t.Errorf("Expected IncludeError, got %v", err)
+	}
+
+	if _, err := Marshal(testArticles(), Options{Include: []string{"title"}}); err == nil {
+		t.Error("Expected error for attribute include path")
+	}
+
+	type Untagged struct {
+		Name string
+	}
+	if _, err := Marshal(&Untagged{}, Options{}); err != ErrNoPrimary {
+		t.Errorf("Expected ErrNoPrimary, got %v", err)
+	}
+
+	type BadTag struct {
+		ID string `jsonapi:"primary"`
+	}
+	if _, err := Marshal(&BadTag{}, Options{}); err == nil {
+		t.Error("Expected error for invalid tag")
+	}
+
+	if _, err := Marshal([]string{"x"}, Options{}); err == nil {
+		t.Error("Expected error for non struct values")
+	}
+}
+
+func TestMarshalNil(t *testing.T) {
+	var a *Article
+	doc := marshalJSON(t, a, Options{})
+	expectJSON(t, doc, `{"data": null}`)
+
+	doc = marshalJSON(t, []*Article{}, Options{})
+	expectJSON(t, doc, `{"data": []}`)
+}
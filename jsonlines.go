@@ -0,0 +1,155 @@
+package yarf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// MaxJSONLineSize is the maximum size of a record of a JSON lines request body.
+var MaxJSONLineSize = 1 << 20
+
+// ErrJSONLinesCallback is returned by BindJSONLines when it doesn't receive a func(T) error callback.
+var ErrJSONLinesCallback = errors.New("yarf: JSON lines callback must be a func(T) error")
+
+// LineError is the error of a record of a JSON lines request body, that couldn't be decoded or was rejected.
+type LineError struct {
+	Line int // Line number, starting at 1
+	Err  error
+}
+
+// Error returns the message with the line number.
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the record error.
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON encodes the error as {"line": 3, "error": "message"}, to be reported to the client.
+func (e *LineError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Line  int    `json:"line"`
+		Error string `json:"error"`
+	}{e.Line, e.Err.Error()})
+}
+
+// BindJSONLines reads a newline delimited JSON request body (application/x-ndjson) one record at a time,
+// for bulk ingest endpoints. fn is a func(T) error, called with each record decoded into a new T.
+// Blank lines are skipped. The records that can't be decoded, and the ones fn returns an error for,
+// are collected as LineErrors instead of stopping the import.
+// It returns the number of records accepted, the record errors, and the error that stopped the reading,
+// like a line over MaxJSONLineSize.
+//
+//	n, failed, err := c.BindJSONLines(func(u *User) error {
+//		return users.Insert(u)
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	c.RenderJSON(map[string]interface{}{"imported": n, "errors": failed})
+func (c *Context) BindJSONLines(fn interface{}) (int, []*LineError, error) {
+	f := reflect.ValueOf(fn)
+	if f.Kind() != reflect.Func {
+		return 0, nil, ErrJSONLinesCallback
+	}
+	t := f.Type()
+	if t.NumIn() != 1 || t.NumOut() != 1 || t.Out(0) != reflect.TypeOf((*error)(nil)).Elem() {
+		return 0, nil, ErrJSONLinesCallback
+	}
+
+	// Records are decoded into new values of the param type, or of the pointed type
+	in := t.In(0)
+	ptr := in.Kind() == reflect.Ptr
+	if ptr {
+		in = in.Elem()
+	}
+
+	scanner := bufio.NewScanner(c.Request.Body)
+	size := 4096
+	if size > MaxJSONLineSize {
+		size = MaxJSONLineSize
+	}
+	scanner.Buffer(make([]byte, 0, size), MaxJSONLineSize)
+
+	n := 0
+	var failed []*LineError
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		v := reflect.New(in)
+		if err := json.Unmarshal(data, v.Interface()); err != nil {
+			failed = append(failed, &LineError{line, err})
+			continue
+		}
+		if !ptr {
+			v = v.Elem()
+		}
+
+		if err, _ := f.Call([]reflect.Value{v})[0].Interface().(error); err != nil {
+			failed = append(failed, &LineError{line, err})
+			continue
+		}
+		n++
+	}
+
+	return n, failed, scanner.Err()
+}
+
+// JSONLinesEncoder writes records to a newline delimited JSON response, flushing them periodically.
+type JSONLinesEncoder struct {
+	c       *Context
+	flushed time.Time
+}
+
+// JSONLinesEncoder returns an encoder writing records to the response as newline delimited JSON,
+// for handlers producing records from a loop rather than a channel.
+// The response is flushed every StreamFlushInterval, and by Flush.
+//
+//	enc := c.JSONLinesEncoder()
+//	for rows.Next() {
+//		if err := enc.Encode(scan(rows)); err != nil {
+//			return err
+//		}
+//	}
+//	enc.Flush()
+func (c *Context) JSONLinesEncoder() *JSONLinesEncoder {
+	if c.Response.Header().Get("Content-Type") == "" {
+		c.Response.Header().Set("Content-Type", "application/x-ndjson")
+	}
+
+	return &JSONLinesEncoder{c: c, flushed: time.Now()}
+}
+
+// Encode writes the JSON encoded record in its own line.
+// When partial responses are enabled, it's pruned to the fields requested by the client.
+func (e *JSONLinesEncoder) Encode(v interface{}) error {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err := e.c.Response.Write(append(e.c.Fields().PruneJSON(encoded), '\n')); err != nil {
+		return err
+	}
+	if time.Since(e.flushed) >= StreamFlushInterval {
+		e.Flush()
+	}
+
+	return nil
+}
+
+// Flush sends the records written to the client.
+func (e *JSONLinesEncoder) Flush() {
+	e.c.flush()
+	e.flushed = time.Now()
+}
@@ -0,0 +1,124 @@
+package yarf
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type lineUser struct {
+	Name string `json:"name"`
+}
+
+type ImportResource struct {
+	Resource
+	imported []string
+}
+
+func (r *ImportResource) Post(c *Context) error {
+	n, failed, err := c.BindJSONLines(func(u *lineUser) error {
+		if u.Name == "" {
+			return errors.New("name is required")
+		}
+		r.imported = append(r.imported, u.Name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.RenderJSON(map[string]interface{}{"imported": n, "errors": failed})
+	return nil
+}
+
+func (r *ImportResource) Get(c *Context) error {
+	enc := c.JSONLinesEncoder()
+	for _, name := range []string{"ann", "bob"} {
+		if err := enc.Encode(lineUser{name}); err != nil {
+			return err
+		}
+	}
+	enc.Flush()
+
+	return nil
+}
+
+func TestBindJSONLines(t *testing.T) {
+	r := new(ImportResource)
+	y := New()
+	y.Add("/users", r)
+
+	body := "{\"name\":\"ann\"}\n\n{\"name\":\"\"}\n{broken\n  {\"name\":\"bob\"}  \n{\"name\":\"cid\"}"
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "http://localhost:8080/users", strings.NewReader(body))
+	y.ServeHTTP(res, req)
+
+	var result struct {
+		Imported int
+		Errors   []struct {
+			Line  int
+			Error string
+		}
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Unexpected response %s: %v", res.Body.String(), err)
+	}
+
+	if result.Imported != 3 || strings.Join(r.imported, ",") != "ann,bob,cid" {
+		t.Errorf("Unexpected imported records %d %v", result.Imported, r.imported)
+	}
+	if len(result.Errors) != 2 || result.Errors[0].Line != 3 || result.Errors[0].Error != "name is required" || result.Errors[1].Line != 4 {
+		t.Errorf("Unexpected record errors %+v", result.Errors)
+	}
+}
+
+func TestBindJSONLinesValues(t *testing.T) {
+	c := NewContext(httptest.NewRequest("POST", "/", strings.NewReader("1\n2\n3\n")), httptest.NewRecorder())
+
+	sum := 0
+	n, failed, err := c.BindJSONLines(func(v int) error {
+		sum += v
+		return nil
+	})
+	if n != 3 || len(failed) != 0 || err != nil || sum != 6 {
+		t.Errorf("Unexpected result %d %v %v, sum %d", n, failed, err, sum)
+	}
+}
+
+func TestBindJSONLinesErrors(t *testing.T) {
+	c := NewContext(httptest.NewRequest("POST", "/", strings.NewReader("")), httptest.NewRecorder())
+	for _, fn := range []interface{}{nil, 1, func(int) {}, func(a, b int) error { return nil }, func(int) bool { return true }} {
+		if _, _, err := c.BindJSONLines(fn); err != ErrJSONLinesCallback {
+			t.Errorf("Expected ErrJSONLinesCallback for %T, got %v", fn, err)
+		}
+	}
+
+	size := MaxJSONLineSize
+	MaxJSONLineSize = 16
+	defer func() { MaxJSONLineSize = size }()
+
+	c = NewContext(httptest.NewRequest("POST", "/", strings.NewReader("1\n\"a very long string\"\n")), httptest.NewRecorder())
+	n, _, err := c.BindJSONLines(func(v interface{}) error { return nil })
+	if n != 1 || err == nil {
+		t.Errorf("Expected the reading to stop on long lines, got %d %v", n, err)
+	}
+}
+
+func TestJSONLinesEncoder(t *testing.T) {
+	y := New()
+	y.Add("/users", new(ImportResource))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://localhost:8080/users", nil)
+	y.ServeHTTP(res, req)
+
+	if res.Body.String() != "{\"name\":\"ann\"}\n{\"name\":\"bob\"}\n" || !res.Flushed {
+		t.Errorf("Unexpected response %q", res.Body.String())
+	}
+	if ct := res.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Unexpected Content-Type %q", ct)
+	}
+}
@@ -0,0 +1,251 @@
+// Package jsonrpc serves JSON-RPC 2.0 methods from a single Yarf route.
+//
+//	type AddParams struct {
+//		A, B int
+//	}
+//
+//	s := jsonrpc.NewServer()
+//	s.Register("add", func(c *yarf.Context, p AddParams) (int, error) {
+//		return p.A + p.B, nil
+//	})
+//	y.Add("/rpc", s)
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Version of the JSON-RPC protocol.
+const Version = "2.0"
+
+// Error codes defined by the specification.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Error is a JSON-RPC error. Methods can return it to send their own error codes and data.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewError creates an Error.
+func NewError(code int, message string, data interface{}) *Error {
+	return &Error{Code: code, Message: message, Data: data}
+}
+
+// request is a JSON-RPC request object.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// response is a JSON-RPC response object.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// method is a registered method.
+type method struct {
+	fn     reflect.Value
+	params reflect.Type // nil if the method doesn't take params
+}
+
+var (
+	contextType = reflect.TypeOf((*yarf.Context)(nil))
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Server is a ResourceHandler serving JSON-RPC 2.0 requests, including batches, on POST.
+type Server struct {
+	yarf.Resource
+
+	methods map[string]method
+	lock    sync.RWMutex
+}
+
+// NewServer creates a Server without methods.
+func NewServer() *Server {
+	return &Server{
+		methods: make(map[string]method),
+	}
+}
+
+// Register adds a method to the server. fn must be a function with one of these signatures:
+//
+//	func(c *yarf.Context, params P) (R, error)
+//	func(c *yarf.Context) (R, error)
+//
+// Params are decoded from the request into P, so it can be a struct for named params
+// or a slice or array for positional params. The result R is encoded as JSON.
+// Errors of type *Error are sent as they are, and other errors as internal errors.
+func (s *Server) Register(name string, fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+
+	if t.Kind() != reflect.Func || t.NumIn() < 1 || t.NumIn() > 2 || t.In(0) != contextType ||
+		t.NumOut() != 2 || t.Out(1) != errorType {
+		return fmt.Errorf("jsonrpc: invalid signature %s for method %s", t, name)
+	}
+
+	m := method{fn: v}
+	if t.NumIn() == 2 {
+		m.params = t.In(1)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.methods[name] = m
+
+	return nil
+}
+
+// Post serves the JSON-RPC request or batch.
+func (s *Server) Post(c *yarf.Context) error {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	body = bytes.TrimSpace(body)
+
+	var result interface{}
+
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			result = errorResponse(nil, CodeParseError, "Parse error")
+		} else if len(batch) == 0 {
+			result = errorResponse(nil, CodeInvalidRequest, "Invalid Request")
+		} else {
+			var responses []*response
+			for _, raw := range batch {
+				if res := s.call(c, raw); res != nil {
+					responses = append(responses, res)
+				}
+			}
+			if len(responses) > 0 {
+				result = responses
+			}
+		}
+	} else if res := s.call(c, body); res != nil {
+		result = res
+	}
+
+	// Only notifications
+	if result == nil {
+		c.Response.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.RenderJSON(result)
+
+	return nil
+}
+
+// errorResponse creates an error response.
+func errorResponse(id json.RawMessage, code int, message string) *response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+
+	return &response{
+		JSONRPC: Version,
+		Error:   &Error{Code: code, Message: message},
+		ID:      id,
+	}
+}
+
+// call runs a single request. It returns nil for notifications.
+func (s *Server) call(c *yarf.Context, raw json.RawMessage) *response {
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) {
+			return errorResponse(nil, CodeParseError, "Parse error")
+		}
+		return errorResponse(nil, CodeInvalidRequest, "Invalid Request")
+	}
+
+	if req.JSONRPC != Version || req.Method == "" {
+		return errorResponse(req.ID, CodeInvalidRequest, "Invalid Request")
+	}
+
+	notification := req.ID == nil
+
+	res := s.run(c, &req)
+	if notification {
+		return nil
+	}
+
+	res.ID = req.ID
+
+	return res
+}
+
+// run executes the method of a request.
+func (s *Server) run(c *yarf.Context, req *request) *response {
+	s.lock.RLock()
+	m, ok := s.methods[req.Method]
+	s.lock.RUnlock()
+
+	if !ok {
+		return errorResponse(nil, CodeMethodNotFound, "Method not found")
+	}
+
+	args := []reflect.Value{reflect.ValueOf(c)}
+	if m.params != nil {
+		p := reflect.New(m.params)
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, p.Interface()); err != nil {
+				res := errorResponse(nil, CodeInvalidParams, "Invalid params")
+				res.Error.Data = err.Error()
+				return res
+			}
+		}
+		args = append(args, p.Elem())
+	}
+
+	out := m.fn.Call(args)
+
+	if err, _ := out[1].Interface().(error); err != nil {
+		var rpcErr *Error
+		if errors.As(err, &rpcErr) {
+			return &response{JSONRPC: Version, Error: rpcErr}
+		}
+		return errorResponse(nil, CodeInternalError, "Internal error")
+	}
+
+	result := out[0].Interface()
+	if result == nil {
+		// The result member is required on success
+		result = json.RawMessage("null")
+	}
+
+	return &response{JSONRPC: Version, Result: result}
+}
@@ -0,0 +1,184 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+)
+
+type addParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func newTestServer(t *testing.T) *yarf.Yarf {
+	s := NewServer()
+
+	methods := map[string]interface{}{
+		"add": func(c *yarf.Context, p addParams) (int, error) {
+			return p.A + p.B, nil
+		},
+		"sum": func(c *yarf.Context, p []int) (int, error) {
+			total := 0
+			for _, v := range p {
+				total += v
+			}
+			return total, nil
+		},
+		"ping": func(c *yarf.Context) (string, error) {
+			return "pong", nil
+		},
+		"fail": func(c *yarf.Context) (interface{}, error) {
+			return nil, errors.New("database is down")
+		},
+		"custom": func(c *yarf.Context) (interface{}, error) {
+			return nil, NewError(42, "Custom error", "details")
+		},
+		"nothing": func(c *yarf.Context) (interface{}, error) {
+			return nil, nil
+		},
+	}
+	for name, fn := range methods {
+		if err := s.Register(name, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	y := yarf.New()
+	y.Add("/rpc", s)
+
+	return y
+}
+
+func post(y *yarf.Yarf, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "http://localhost/rpc", strings.NewReader(body))
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	return res
+}
+
+func TestRegisterInvalidSignature(t *testing.T) {
+	s := NewServer()
+
+	invalid := []interface{}{
+		"not a function",
+		func() (int, error) { return 0, nil },
+		func(c *yarf.Context, p int) int { return 0 },
+		func(c *yarf.Context, p int) (int, int) { return 0, 0 },
+		func(p int) (int, error) { return 0, nil },
+		func(c *yarf.Context, a, b int) (int, error) { return 0, nil },
+	}
+
+	for _, fn := range invalid {
+		if err := s.Register("invalid", fn); err == nil {
+			t.Errorf("%T should be rejected", fn)
+		}
+	}
+}
+
+func TestCalls(t *testing.T) {
+	y := newTestServer(t)
+
+	tests := []struct {
+		body, expected string
+	}{
+		{`{"jsonrpc":"2.0","method":"add","params":{"a":1,"b":2},"id":1}`, `{"jsonrpc":"2.0","result":3,"id":1}`},
+		{`{"jsonrpc":"2.0","method":"sum","params":[1,2,3],"id":"abc"}`, `{"jsonrpc":"2.0","result":6,"id":"abc"}`},
+		{`{"jsonrpc":"2.0","method":"ping","id":2}`, `{"jsonrpc":"2.0","result":"pong","id":2}`},
+		{`{"jsonrpc":"2.0","method":"nothing","id":3}`, `{"jsonrpc":"2.0","result":null,"id":3}`},
+		{`{"jsonrpc":"2.0","method":"missing","id":4}`, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":4}`},
+		{`{"jsonrpc":"2.0","method":"fail","id":5}`, `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":5}`},
+		{`{"jsonrpc":"2.0","method":"custom","id":6}`, `{"jsonrpc":"2.0","error":{"code":42,"message":"Custom error","data":"details"},"id":6}`},
+		{`{"jsonrpc":"1.0","method":"ping","id":7}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":7}`},
+		{`{"jsonrpc":"2.0","method":1,"id":8}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`},
+		{`{"jsonrpc":"2.0","method":"ping"`, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`},
+		{`[]`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`},
+	}
+
+	for _, tt := range tests {
+		res := post(y, tt.body)
+
+		if res.Code != http.StatusOK {
+			t.Errorf("Expected status 200 for %s, got %d", tt.body, res.Code)
+		}
+		if res.Body.String() != tt.expected {
+			t.Errorf("Expected %s for %s, got %s", tt.expected, tt.body, res.Body.String())
+		}
+	}
+}
+
+func TestInvalidParams(t *testing.T) {
+	y := newTestServer(t)
+
+	res := post(y, `{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1}`)
+
+	var r struct {
+		Error *Error `json:"error"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Error == nil || r.Error.Code != CodeInvalidParams {
+		t.Errorf("Expected invalid params error, got %s", res.Body.String())
+	}
+	if r.Error != nil && r.Error.Data == nil {
+		t.Error("Invalid params error should include the decoding error as data")
+	}
+}
+
+func TestNotification(t *testing.T) {
+	y := newTestServer(t)
+
+	res := post(y, `{"jsonrpc":"2.0","method":"ping"}`)
+
+	if res.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 for notification, got %d", res.Code)
+	}
+	if res.Body.Len() != 0 {
+		t.Errorf("Expected empty body for notification, got %s", res.Body.String())
+	}
+}
+
+func TestBatch(t *testing.T) {
+	y := newTestServer(t)
+
+	res := post(y, `[
+		{"jsonrpc":"2.0","method":"add","params":{"a":1,"b":2},"id":1},
+		{"jsonrpc":"2.0","method":"ping"},
+		{"jsonrpc":"2.0","method":"missing","id":2},
+		1
+	]`)
+
+	expected := `[{"jsonrpc":"2.0","result":3,"id":1},` +
+		`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":2},` +
+		`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}]`
+	if res.Body.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, res.Body.String())
+	}
+	if res.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected JSON content type, got %s", res.Header().Get("Content-Type"))
+	}
+
+	res = post(y, `[{"jsonrpc":"2.0","method":"ping"},{"jsonrpc":"2.0","method":"ping"}]`)
+	if res.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 for notification batch, got %d", res.Code)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	y := newTestServer(t)
+
+	req, _ := http.NewRequest("GET", "http://localhost/rpc", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 on GET, got %d", res.Code)
+	}
+}
@@ -0,0 +1,413 @@
+package yarf
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // SHA-256 for RS256, PS256, ES256 and HS256
+	_ "crypto/sha512" // SHA-384 and SHA-512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token validation errors
+var (
+	ErrInvalidToken = errors.New("yarf: invalid token")
+	ErrUnknownKey   = errors.New("yarf: unknown token signing key")
+)
+
+// KeySet provides the keys verifying token signatures.
+type KeySet interface {
+	// Key returns the key with the id provided.
+	// Keys are *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey, or []byte for HMAC algorithms.
+	Key(ctx context.Context, kid string) (interface{}, error)
+}
+
+// StaticKeys is a KeySet of fixed keys by id. Tokens without key id use the only key of the set.
+type StaticKeys map[string]interface{}
+
+// Key returns the key with the id provided.
+func (k StaticKeys) Key(ctx context.Context, kid string) (interface{}, error) {
+	if key, ok := k[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(k) == 1 {
+		for _, key := range k {
+			return key, nil
+		}
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// JWKS is a KeySet fetching the keys from a JSON Web Key Set URL, like the jwks_uri of an OIDC provider.
+// Keys are fetched on first use and refetched when an unknown key id is requested, to follow key rotations.
+type JWKS struct {
+	// URL of the key set.
+	URL string
+
+	// Client fetches the key set. Defaults to a client with a 10 seconds timeout.
+	Client *http.Client
+
+	// MinRefresh is the minimum time between fetches, so unknown key ids can't flood the provider.
+	MinRefresh time.Duration
+
+	keys    map[string]interface{}
+	fetched time.Time
+	lock    sync.Mutex
+}
+
+// NewJWKS creates a JWKS for the URL provided, refetched at most once per minute.
+func NewJWKS(url string) *JWKS {
+	return &JWKS{
+		URL:        url,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+		MinRefresh: time.Minute,
+	}
+}
+
+// Key returns the key with the id provided, refetching the key set if it's unknown.
+func (j *JWKS) Key(ctx context.Context, kid string) (interface{}, error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if key, err := StaticKeys(j.keys).Key(ctx, kid); err == nil {
+		return key, nil
+	}
+
+	if !j.fetched.IsZero() && time.Since(j.fetched) < j.MinRefresh {
+		return nil, ErrUnknownKey
+	}
+
+	keys, err := j.fetch(ctx)
+	j.fetched = time.Now()
+	if err != nil {
+		return nil, err
+	}
+	j.keys = keys
+
+	return StaticKeys(j.keys).Key(ctx, kid)
+}
+
+// jwk is a JSON Web Key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetch downloads and parses the key set. Keys not used for signatures or of unsupported types are skipped.
+func (j *JWKS) fetch(ctx context.Context) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := getJSON(ctx, j.Client, j.URL, &set); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+
+	return keys, nil
+}
+
+// publicKey decodes the public key of a JWK.
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err1 := decodeBigInt(k.N)
+		e, err2 := decodeBigInt(k.E)
+		if err1 != nil || err2 != nil || !e.IsInt64() {
+			return nil, errors.New("jwks: invalid RSA key")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Crv]
+		x, err1 := decodeBigInt(k.X)
+		y, err2 := decodeBigInt(k.Y)
+		if !ok || err1 != nil || err2 != nil {
+			return nil, errors.New("jwks: invalid EC key")
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		if _, err := key.ECDH(); err != nil {
+			return nil, errors.New("jwks: invalid EC key")
+		}
+		return key, nil
+
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("jwks: invalid OKP key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, errors.New("jwks: unsupported key type " + k.Kty)
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer.
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid integer")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// getJSON fetches and decodes a JSON document.
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// OIDCConfig is the part of an OpenID Connect discovery document used to validate tokens.
+type OIDCConfig struct {
+	Issuer                string `json:"issuer"`
+	JWKSURI               string `json:"jwks_uri"`
+	IntrospectionEndpoint string `json:"introspection_endpoint"`
+}
+
+// DiscoverOIDC fetches the discovery document of an OpenID Connect issuer,
+// from its /.well-known/openid-configuration path.
+func DiscoverOIDC(ctx context.Context, issuer string) (*OIDCConfig, error) {
+	cfg := new(OIDCConfig)
+	u := strings.TrimRight(issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, &http.Client{Timeout: 10 * time.Second}, u, cfg); err != nil {
+		return nil, fmt.Errorf("oidc: %w", err)
+	}
+
+	if cfg.Issuer != issuer {
+		return nil, fmt.Errorf("oidc: issuer mismatch: %s", cfg.Issuer)
+	}
+	if cfg.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery document without jwks_uri")
+	}
+
+	return cfg, nil
+}
+
+// NewOIDCValidator creates a JWTValidator for the tokens of an OpenID Connect issuer,
+// with the keys of its discovery document, accepting tokens issued for the audience provided.
+//
+//	v, err := yarf.NewOIDCValidator(ctx, "https://accounts.example.com", "orders-api")
+func NewOIDCValidator(ctx context.Context, issuer, audience string) (*JWTValidator, error) {
+	cfg, err := DiscoverOIDC(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWTValidator{
+		Issuer:   cfg.Issuer,
+		Audience: audience,
+		Keys:     NewJWKS(cfg.JWKSURI),
+	}, nil
+}
+
+// JWTValidator is a TokenValidator for signed JSON Web Tokens.
+// It checks the signature, the expiration and not-before times, the issuer and the audience.
+type JWTValidator struct {
+	// Issuer required in the "iss" claim. Not checked if empty.
+	Issuer string
+
+	// Audience required in the "aud" claim. Not checked if empty.
+	Audience string
+
+	// Keys verifying the signatures.
+	Keys KeySet
+
+	// Algorithms accepted. Defaults to the RSA, ECDSA and EdDSA ones.
+	// HMAC algorithms (HS256, HS384, HS512) must be listed explicitly.
+	Algorithms []string
+
+	// Leeway tolerates clock differences on the exp, nbf and iat claims.
+	Leeway time.Duration
+}
+
+// defaultAlgorithms are the algorithms accepted when none is configured.
+var defaultAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// Validate verifies the token and returns its claims.
+func (v *JWTValidator) Validate(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	algs := v.Algorithms
+	if len(algs) == 0 {
+		algs = defaultAlgorithms
+	}
+	if !containsString(algs, header.Alg) {
+		return nil, fmt.Errorf("%w: algorithm %q not allowed", ErrInvalidToken, header.Alg)
+	}
+
+	key, err := v.Keys.Key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// checkClaims validates the registered claims.
+func (v *JWTValidator) checkClaims(claims Claims) error {
+	now := time.Now()
+
+	if exp, ok := claims.time("exp"); ok && now.After(exp.Add(v.Leeway)) {
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	if nbf, ok := claims.time("nbf"); ok && now.Before(nbf.Add(-v.Leeway)) {
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+	if iat, ok := claims.time("iat"); ok && now.Before(iat.Add(-v.Leeway)) {
+		return fmt.Errorf("%w: issued in the future", ErrInvalidToken)
+	}
+
+	if v.Issuer != "" && claims.String("iss") != v.Issuer {
+		return fmt.Errorf("%w: invalid issuer", ErrInvalidToken)
+	}
+	if v.Audience != "" && !containsString(claims.Strings("aud"), v.Audience) {
+		return fmt.Errorf("%w: invalid audience", ErrInvalidToken)
+	}
+
+	return nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a token.
+func decodeSegment(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.UseNumber()
+
+	return dec.Decode(v)
+}
+
+// verifySignature checks the signature of the signed content with the algorithm and key provided.
+// The key type must match the algorithm, so a public key can't be used as an HMAC secret.
+func verifySignature(alg string, key interface{}, signed, sig []byte) error {
+	var h crypto.Hash
+	switch alg[len(alg)-min(len(alg), 3):] {
+	case "256":
+		h = crypto.SHA256
+	case "384":
+		h = crypto.SHA384
+	case "512":
+		h = crypto.SHA512
+	}
+
+	digest := func() []byte {
+		d := h.New()
+		d.Write(signed)
+		return d.Sum(nil)
+	}
+
+	valid := false
+	switch {
+	case alg == "EdDSA":
+		k, ok := key.(ed25519.PublicKey)
+		valid = ok && ed25519.Verify(k, signed, sig)
+
+	case strings.HasPrefix(alg, "HS"):
+		k, ok := key.([]byte)
+		if ok && h != 0 {
+			m := hmac.New(h.New, k)
+			m.Write(signed)
+			valid = hmac.Equal(m.Sum(nil), sig)
+		}
+
+	case strings.HasPrefix(alg, "RS"):
+		k, ok := key.(*rsa.PublicKey)
+		valid = ok && h != 0 && rsa.VerifyPKCS1v15(k, h, digest(), sig) == nil
+
+	case strings.HasPrefix(alg, "PS"):
+		k, ok := key.(*rsa.PublicKey)
+		valid = ok && h != 0 && rsa.VerifyPSS(k, h, digest(), sig, nil) == nil
+
+	case strings.HasPrefix(alg, "ES"):
+		k, ok := key.(*ecdsa.PublicKey)
+		if ok && h != 0 && len(sig)%2 == 0 {
+			n := len(sig) / 2
+			r, s := new(big.Int).SetBytes(sig[:n]), new(big.Int).SetBytes(sig[n:])
+			valid = ecdsa.Verify(k, digest(), r, s)
+		}
+	}
+
+	if !valid {
+		return fmt.Errorf("%w: invalid signature", ErrInvalidToken)
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package yarf
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// signJWT creates a token signed with the key provided.
+func signJWT(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	var err error
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest[:])
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(signed))
+	case []byte:
+		m := hmac.New(sha256.New, k)
+		m.Write([]byte(signed))
+		sig = m.Sum(nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// validClaims returns claims accepted by testValidator.
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   "https://issuer.example.com",
+		"aud":   []string{"api", "other"},
+		"sub":   "user-1",
+		"scope": "read:users write:users",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+	}
+}
+
+func TestJWTValidatorAlgorithms(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	secret := []byte("secret")
+
+	v := &JWTValidator{
+		Issuer:   "https://issuer.example.com",
+		Audience: "api",
+		Keys: StaticKeys{
+			"rsa":  &rsaKey.PublicKey,
+			"ec":   &ecKey.PublicKey,
+			"ed":   edPub,
+			"hmac": secret,
+		},
+	}
+
+	for _, tc := range []struct {
+		alg, kid string
+		key      interface{}
+	}{
+		{"RS256", "rsa", rsaKey},
+		{"ES256", "ec", ecKey},
+		{"EdDSA", "ed", edKey},
+	} {
+		claims, err := v.Validate(context.Background(), signJWT(t, tc.alg, tc.kid, tc.key, validClaims()))
+		if err != nil {
+			t.Errorf("%s: %v", tc.alg, err)
+			continue
+		}
+		if claims.Subject() != "user-1" {
+			t.Errorf("%s: sub = %q", tc.alg, claims.Subject())
+		}
+	}
+
+	// HMAC must be enabled explicitly
+	token := signJWT(t, "HS256", "hmac", secret, validClaims())
+	if _, err := v.Validate(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("HS256 should be rejected by default, got %v", err)
+	}
+	v.Algorithms = []string{"HS256"}
+	if _, err := v.Validate(context.Background(), token); err != nil {
+		t.Errorf("HS256: %v", err)
+	}
+
+	// Public keys can't be used as HMAC secrets
+	v.Algorithms = []string{"HS256", "RS256"}
+	token = signJWT(t, "HS256", "rsa", []byte("forged"), validClaims())
+	if _, err := v.Validate(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Algorithm confusion should be rejected, got %v", err)
+	}
+
+	// Unsigned tokens
+	token = signJWT(t, "none", "rsa", nil, validClaims())
+	if _, err := v.Validate(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Unsigned tokens should be rejected, got %v", err)
+	}
+}
+
+func TestJWTValidatorClaims(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	v := &JWTValidator{
+		Issuer:   "https://issuer.example.com",
+		Audience: "api",
+		Keys:     StaticKeys{"k": &key.PublicKey},
+		Leeway:   time.Minute,
+	}
+
+	for name, change := range map[string]func(map[string]interface{}){
+		"expired":    func(c map[string]interface{}) { c["exp"] = time.Now().Add(-2 * time.Minute).Unix() },
+		"not before": func(c map[string]interface{}) { c["nbf"] = time.Now().Add(2 * time.Minute).Unix() },
+		"issuer":     func(c map[string]interface{}) { c["iss"] = "https://other.example.com" },
+		"audience":   func(c map[string]interface{}) { c["aud"] = "other" },
+	} {
+		claims := validClaims()
+		change(claims)
+		if _, err := v.Validate(context.Background(), signJWT(t, "ES256", "k", key, claims)); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+
+	// Leeway
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-30 * time.Second).Unix()
+	if _, err := v.Validate(context.Background(), signJWT(t, "ES256", "k", key, claims)); err != nil {
+		t.Errorf("Leeway should accept the token: %v", err)
+	}
+
+	// Unknown key and malformed tokens
+	if _, err := v.Validate(context.Background(), signJWT(t, "ES256", "other", key, validClaims())); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey, got %v", err)
+	}
+	for _, token := range []string{"", "a.b", "a.b.c", "!!.!!.!!"} {
+		if _, err := v.Validate(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%q: expected ErrInvalidToken, got %v", token, err)
+		}
+	}
+}
+
+// oidcServer serves a discovery document and a key set with the RSA key provided.
+func oidcServer(key *rsa.PublicKey, fetches *int) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{
+				"issuer":   srv.URL,
+				"jwks_uri": srv.URL + "/keys",
+			})
+		case "/keys":
+			*fetches++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"keys": []map[string]string{
+					{"kty": "RSA", "kid": "k1", "use": "sig", "n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()), "e": "AQAB"},
+					{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+					{"kty": "oct", "kid": "oct", "k": "c2VjcmV0"},
+				},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	return srv
+}
+
+func TestOIDCValidator(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	fetches := 0
+	srv := oidcServer(&key.PublicKey, &fetches)
+	defer srv.Close()
+
+	v, err := NewOIDCValidator(context.Background(), srv.URL, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := validClaims()
+	claims["iss"] = srv.URL
+	if _, err := v.Validate(context.Background(), signJWT(t, "RS256", "k1", key, claims)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Validate(context.Background(), signJWT(t, "RS256", "k1", key, claims)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown keys don't refetch before MinRefresh
+	if _, err := v.Validate(context.Background(), signJWT(t, "RS256", "k2", key, claims)); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey, got %v", err)
+	}
+	if fetches != 1 {
+		t.Errorf("Key set fetched %d times, expected 1", fetches)
+	}
+
+	// Only signature keys of supported types are loaded
+	jwks := v.Keys.(*JWKS)
+	if len(jwks.keys) != 1 {
+		t.Errorf("Expected 1 key, got %d", len(jwks.keys))
+	}
+
+	if _, err := DiscoverOIDC(context.Background(), srv.URL+"/other"); err == nil {
+		t.Error("Discovery of a missing issuer should fail")
+	}
+}
@@ -0,0 +1,165 @@
+package yarf
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Rejection reasons of the RequestLimits middleware, set on LimitError.
+const (
+	LimitHeaderCount = "header_count"
+	LimitHeaderSize  = "header_size"
+	LimitURLLength   = "url_length"
+	LimitQueryParams = "query_params"
+)
+
+// LimitStats are the rejection counters exposed by the RequestLimits middleware, one per reason.
+type LimitStats struct {
+	HeaderCount uint64 // Requests with too many header fields
+	HeaderSize  uint64 // Requests with too large headers
+	URLLength   uint64 // Requests with too long URLs
+	QueryParams uint64 // Requests with too many query params
+}
+
+// LimitError is the error returned for requests over a RequestLimits limit.
+// It's sent as a 431 error for the header limits, and as a 414 error for the URL ones.
+type LimitError struct {
+	CustomError
+
+	// Reason is the limit exceeded, like LimitHeaderCount.
+	Reason string
+
+	// Limit is the value of the limit exceeded.
+	Limit int
+}
+
+// RequestLimits is a middleware rejecting pathological requests before routing,
+// like requests with thousands of headers or query params built to exhaust the server resources.
+// Use it as global middleware, so the limits apply before route matching:
+//
+//	l := yarf.NewRequestLimits()
+//	l.MaxQueryParams = 20
+//	y.Use(l)
+//
+// A limit of 0 disables it.
+type RequestLimits struct {
+	Middleware
+
+	// MaxHeaderCount is the maximum number of header fields, counting every value.
+	MaxHeaderCount int
+
+	// MaxHeaderSize is the maximum size of the headers in bytes, as sent on the wire.
+	MaxHeaderSize int
+
+	// MaxURLLength is the maximum length of the request URL, with the query.
+	MaxURLLength int
+
+	// MaxQueryParams is the maximum number of query params, counting every value.
+	MaxQueryParams int
+
+	stats LimitStats
+}
+
+// NewRequestLimits creates a RequestLimits allowing 100 header fields, 32KB of headers,
+// 8KB URLs and 100 query params.
+func NewRequestLimits() *RequestLimits {
+	return &RequestLimits{
+		MaxHeaderCount: 100,
+		MaxHeaderSize:  32 << 10,
+		MaxURLLength:   8 << 10,
+		MaxQueryParams: 100,
+	}
+}
+
+// Phase runs the limits with the security middleware.
+func (m *RequestLimits) Phase() Phase {
+	return PhaseSecurity
+}
+
+// Stats returns a snapshot of the rejection counters.
+func (m *RequestLimits) Stats() LimitStats {
+	return LimitStats{
+		HeaderCount: atomic.LoadUint64(&m.stats.HeaderCount),
+		HeaderSize:  atomic.LoadUint64(&m.stats.HeaderSize),
+		URLLength:   atomic.LoadUint64(&m.stats.URLLength),
+		QueryParams: atomic.LoadUint64(&m.stats.QueryParams),
+	}
+}
+
+// PreDispatch checks the request against the limits.
+func (m *RequestLimits) PreDispatch(c *Context) error {
+	if m.MaxURLLength > 0 {
+		uri := c.Request.RequestURI
+		if uri == "" {
+			uri = c.Request.URL.RequestURI()
+		}
+		if len(uri) > m.MaxURLLength {
+			return m.reject(c, LimitURLLength, m.MaxURLLength)
+		}
+	}
+
+	if m.MaxQueryParams > 0 && queryParamCount(c.Request.URL.RawQuery) > m.MaxQueryParams {
+		return m.reject(c, LimitQueryParams, m.MaxQueryParams)
+	}
+
+	if m.MaxHeaderCount > 0 || m.MaxHeaderSize > 0 {
+		count, size := 0, 0
+		for k, values := range c.Request.Header {
+			count += len(values)
+			for _, v := range values {
+				// "Name: value\r\n"
+				size += len(k) + len(v) + 4
+			}
+		}
+		if m.MaxHeaderCount > 0 && count > m.MaxHeaderCount {
+			return m.reject(c, LimitHeaderCount, m.MaxHeaderCount)
+		}
+		if m.MaxHeaderSize > 0 && size > m.MaxHeaderSize {
+			return m.reject(c, LimitHeaderSize, m.MaxHeaderSize)
+		}
+	}
+
+	return nil
+}
+
+// reject counts the rejection and returns its LimitError.
+func (m *RequestLimits) reject(c *Context, reason string, limit int) error {
+	e := &LimitError{Reason: reason, Limit: limit}
+	c.metrics().Counter("yarf_limits_rejected_total", "Requests rejected by the request limits.", "reason").Add(1, reason)
+
+	switch reason {
+	case LimitHeaderCount:
+		atomic.AddUint64(&m.stats.HeaderCount, 1)
+		e.CustomError = ErrorRequestHeaderFieldsTooLarge().CustomError
+		e.ErrorBody = "Too many header fields, the limit is " + strconv.Itoa(limit)
+	case LimitHeaderSize:
+		atomic.AddUint64(&m.stats.HeaderSize, 1)
+		e.CustomError = ErrorRequestHeaderFieldsTooLarge().CustomError
+		e.ErrorBody = "Request headers too large, the limit is " + strconv.Itoa(limit) + " bytes"
+	case LimitURLLength:
+		atomic.AddUint64(&m.stats.URLLength, 1)
+		e.CustomError = ErrorURITooLong().CustomError
+		e.ErrorBody = "URL too long, the limit is " + strconv.Itoa(limit) + " bytes"
+	case LimitQueryParams:
+		atomic.AddUint64(&m.stats.QueryParams, 1)
+		e.CustomError = ErrorURITooLong().CustomError
+		e.ErrorBody = "Too many query params, the limit is " + strconv.Itoa(limit)
+	}
+
+	return e
+}
+
+// queryParamCount counts the params of a raw query without parsing it.
+func queryParamCount(raw string) int {
+	n := 0
+	for raw != "" {
+		var p string
+		p, raw, _ = strings.Cut(raw, "&")
+		if p != "" {
+			n++
+		}
+	}
+
+	return n
+}
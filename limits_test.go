@@ -0,0 +1,91 @@
+package yarf
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// limitReasonMiddleware records the reason of LimitError values.
+type limitReasonMiddleware struct {
+	Middleware
+
+	reason string
+}
+
+func (m *limitReasonMiddleware) End(c *Context) error {
+	var e *LimitError
+	if errors.As(c.Err(), &e) {
+		m.reason = e.Reason
+	}
+	return nil
+}
+
+func TestQueryParamCount(t *testing.T) {
+	for raw, expected := range map[string]int{
+		"":          0,
+		"a=1":       1,
+		"a=1&a=2":   2,
+		"a=1&&b":    2,
+		"&&&":       0,
+		"a=1;b=2&c": 2,
+	} {
+		if n := queryParamCount(raw); n != expected {
+			t.Errorf("queryParamCount(%q) = %d, expected %d", raw, n, expected)
+		}
+	}
+}
+
+func TestRequestLimits(t *testing.T) {
+	l := NewRequestLimits()
+	l.MaxHeaderCount = 5
+	l.MaxHeaderSize = 200
+	l.MaxURLLength = 64
+	l.MaxQueryParams = 3
+
+	rm := new(limitReasonMiddleware)
+	y := New()
+	y.Use(l)
+	y.Use(rm)
+	y.Add("/", new(OKResource))
+
+	for name, tc := range map[string]struct {
+		target  string
+		headers int
+		value   string
+		code    int
+		reason  string
+	}{
+		"valid":        {"/?a=1&b=2", 2, "x", 200, ""},
+		"url":          {"/?q=" + strings.Repeat("x", 64), 0, "", 414, LimitURLLength},
+		"query":        {"/?a=1&b=2&c=3&d=4", 0, "", 414, LimitQueryParams},
+		"header count": {"/", 6, "x", 431, LimitHeaderCount},
+		"header size":  {"/", 1, strings.Repeat("x", 200), 431, LimitHeaderSize},
+	} {
+		rm.reason = ""
+		req := httptest.NewRequest("GET", tc.target, nil)
+		for i := 0; i < tc.headers; i++ {
+			req.Header.Add("X-Test-"+strconv.Itoa(i), tc.value)
+		}
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Code != tc.code || rm.reason != tc.reason {
+			t.Errorf("%s: %d %q, expected %d %q", name, res.Code, rm.reason, tc.code, tc.reason)
+		}
+	}
+
+	if s := l.Stats(); s != (LimitStats{HeaderCount: 1, HeaderSize: 1, URLLength: 1, QueryParams: 1}) {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+
+	// Disabled limits
+	l.MaxQueryParams = 0
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/?a&b&c&d&e", nil))
+	if res.Code != 200 {
+		t.Errorf("Disabled limit: status %d, expected 200", res.Code)
+	}
+}
@@ -0,0 +1,166 @@
+package yarf
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// MetaName is the RouteMeta key holding the route name, used to build URLs with Yarf.URL().
+const MetaName = "name"
+
+// ErrRouteNotFound is returned when building the URL of a route name that isn't declared.
+var ErrRouteNotFound = errors.New("yarf: route name not found")
+
+// Name declares the route name, used to build its URL with Yarf.URL() and Context.Link(),
+// and returns the RouteMeta to allow chaining.
+//
+//	y.AddRoute("/users/:id", new(User)).Name("users.show")
+func (m *RouteMeta) Name(name string) *RouteMeta {
+	return m.Set(MetaName, name)
+}
+
+// URL builds the path of the named route, replacing the :param segments with the params provided.
+// The catch-all "*" segment is replaced by the "*" param, and dropped if it's missing at the end of the route.
+// The path prefix of the base URL set with SetBaseURL() and the global prefix set with StripPrefix() are added.
+//
+//	u, err := y.URL("users.show", yarf.Params{"id": "10"}) // "/users/10"
+func (y *Yarf) URL(name string, params Params) (string, error) {
+	pattern, ok := y.routePattern(name)
+	if !ok {
+		return "", ErrRouteNotFound
+	}
+
+	u, err := buildURL(pattern, params)
+	if err != nil {
+		return "", err
+	}
+
+	return y.basePath() + u, nil
+}
+
+// routePattern returns the pattern of the named route from the names index.
+// The index is built on the first call, and rebuilt when a name is missing,
+// so routes added or named after it are found.
+func (y *Yarf) routePattern(name string) (string, bool) {
+	y.namesLock.RLock()
+	pattern, ok := y.names[name]
+	y.namesLock.RUnlock()
+	if ok {
+		return pattern, true
+	}
+
+	names := make(map[string]string)
+	for _, r := range y.Routes() {
+		if n := r.Meta.String(MetaName); n != "" {
+			if _, ok := names[n]; !ok {
+				names[n] = r.Pattern
+			}
+		}
+	}
+
+	y.namesLock.Lock()
+	y.names = names
+	y.namesLock.Unlock()
+
+	pattern, ok = names[name]
+
+	return pattern, ok
+}
+
+// buildURL replaces the params of a route pattern.
+func buildURL(pattern string, params Params) (string, error) {
+	parts := prepareURL(pattern)
+
+	for i := 0; i < len(parts); i++ {
+		p := parts[i]
+
+		switch {
+		case p == "*":
+			v := params.Get("*")
+			if v == "" {
+				if i == len(parts)-1 {
+					parts = parts[:i]
+					break
+				}
+				return "", errors.New("yarf: missing param * for route " + pattern)
+			}
+
+			segments := strings.Split(strings.Trim(v, "/"), "/")
+			for j, s := range segments {
+				segments[j] = url.PathEscape(s)
+			}
+			parts[i] = strings.Join(segments, "/")
+
+		case p[0] == ':':
+			v := params.Get(p[1:])
+			if v == "" {
+				return "", errors.New("yarf: missing param " + p[1:] + " for route " + pattern)
+			}
+			parts[i] = url.PathEscape(v)
+		}
+	}
+
+	return "/" + strings.Join(parts, "/"), nil
+}
+
+// URL builds the path of a named route of the Yarf instance serving the request.
+func (c *Context) URL(name string, params Params) (string, error) {
+	if c.app == nil {
+		return "", ErrRouteNotFound
+	}
+
+	return c.app.URL(name, params)
+}
+
+// Link is a hypermedia link to a resource.
+type Link struct {
+	Href  string `json:"href"`
+	Title string `json:"title,omitempty"`
+}
+
+// Links are the hypermedia links of a response by relation type, rendered like HAL "_links":
+//
+//	type UserResponse struct {
+//		*User
+//		Links yarf.Links `json:"_links"`
+//	}
+type Links map[string]Link
+
+// linksKey is the Context storage key for the links added to the response.
+type linksKey struct{}
+
+// Link adds a hypermedia link to the named route, with the relation type provided.
+// The link is sent in the Link response header, and returned by Context.Links() to be rendered in the body.
+//
+//	c.Link("self", "users.show", yarf.Params{"id": id})
+//	c.Link("orders", "users.orders", yarf.Params{"id": id})
+func (c *Context) Link(rel, name string, params Params) error {
+	href, err := c.URL(name, params)
+	if err != nil {
+		return err
+	}
+
+	c.AddLink(rel, Link{Href: href})
+
+	return nil
+}
+
+// AddLink adds a hypermedia link with any URL, like links to external resources.
+func (c *Context) AddLink(rel string, l Link) {
+	links := c.Links()
+	if links == nil {
+		links = make(Links)
+		c.set(linksKey{}, links)
+	}
+	links[rel] = l
+
+	c.Response.Header().Add("Link", "<"+l.Href+`>; rel="`+rel+`"`)
+}
+
+// Links returns the links added to the response, or nil if there are none.
+func (c *Context) Links() Links {
+	links, _ := c.get(linksKey{}).(Links)
+
+	return links
+}
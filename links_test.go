@@ -0,0 +1,139 @@
+package yarf
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+// LinkedUserResource renders a user with links.
+type LinkedUserResource struct {
+	Resource
+}
+
+func (r *LinkedUserResource) Get(c *Context) error {
+	if err := c.Link("self", "users.show", Params{"id": c.Param("id")}); err != nil {
+		return err
+	}
+	if err := c.Link("orders", "users.orders", Params{"id": c.Param("id")}); err != nil {
+		return err
+	}
+	c.AddLink("docs", Link{Href: "https://example.com/docs", Title: "Docs"})
+
+	c.RenderJSON(struct {
+		ID    string `json:"id"`
+		Links Links  `json:"_links"`
+	}{c.Param("id"), c.Links()})
+	return nil
+}
+
+func TestYarfURL(t *testing.T) {
+	y := New()
+
+	g := RouteGroup("/api")
+	g.AddRoute("/users/:id", new(LinkedUserResource)).Name("users.show")
+	g.AddRoute("/users/:id/orders", new(OKResource)).Name("users.orders")
+	g.AddRoute("/files/*", new(OKResource)).Name("files")
+	y.AddGroup(g)
+	y.AddRoute("/broken", new(LinkedUserResource)).Name("broken")
+
+	tests := []struct {
+		name     string
+		params   Params
+		expected string
+	}{
+		{"users.show", Params{"id": "10"}, "/api/users/10"},
+		{"users.show", Params{"id": "a b/c"}, "/api/users/a%20b%2Fc"},
+		{"users.orders", Params{"id": "10", "extra": "x"}, "/api/users/10/orders"},
+		{"files", Params{"*": "docs/a b.pdf"}, "/api/files/docs/a%20b.pdf"},
+		{"files", nil, "/api/files"},
+	}
+
+	for _, tt := range tests {
+		u, err := y.URL(tt.name, tt.params)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %s", tt.name, err)
+			continue
+		}
+		if u != tt.expected {
+			t.Errorf("Expected %s for %s, got %s", tt.expected, tt.name, u)
+		}
+	}
+
+	if _, err := y.URL("missing", nil); err != ErrRouteNotFound {
+		t.Errorf("Expected ErrRouteNotFound, got %v", err)
+	}
+	if _, err := y.URL("users.show", nil); err == nil {
+		t.Error("Expected error for missing params")
+	}
+
+	// Routes named after the index is built
+	if len(y.names) != 4 {
+		t.Errorf("Expected 4 indexed names, got %v", y.names)
+	}
+	y.AddRoute("/late", new(OKResource)).Name("late")
+	if u, err := y.URL("late", nil); err != nil || u != "/late" {
+		t.Errorf("Expected /late, got %s %v", u, err)
+	}
+}
+
+func BenchmarkYarfURL(b *testing.B) {
+	y := New()
+	y.AddRoute("/api/users/:id/orders", new(OKResource)).Name("users.orders")
+
+	for i := 0; i < b.N; i++ {
+		y.URL("users.orders", Params{"id": "10"})
+	}
+}
+
+func TestContextLink(t *testing.T) {
+	y := New()
+
+	g := RouteGroup("/api")
+	g.AddRoute("/users/:id", new(LinkedUserResource)).Name("users.show")
+	g.AddRoute("/users/:id/orders", new(OKResource)).Name("users.orders")
+	y.AddGroup(g)
+
+	res := testRequest(y, "GET", "/api/users/7", nil)
+
+	var body struct {
+		ID    string `json:"id"`
+		Links Links  `json:"_links"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Links{
+		"self":   {Href: "/api/users/7"},
+		"orders": {Href: "/api/users/7/orders"},
+		"docs":   {Href: "https://example.com/docs", Title: "Docs"},
+	}
+	for rel, l := range expected {
+		if body.Links[rel] != l {
+			t.Errorf("Expected link %s %+v, got %+v", rel, l, body.Links[rel])
+		}
+	}
+
+	headers := res.Header().Values("Link")
+	if len(headers) != 3 || headers[0] != `</api/users/7>; rel="self"` {
+		t.Errorf("Unexpected Link headers %v", headers)
+	}
+}
+
+func TestContextLinkErrors(t *testing.T) {
+	y := New()
+	y.Add("/users/:id", new(LinkedUserResource))
+
+	if res := testRequest(y, "GET", "/users/7", nil); res.Code != 500 {
+		t.Errorf("Expected status 500 for unknown route names, got %d", res.Code)
+	}
+
+	c := NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+	if _, err := c.URL("users.show", nil); err != ErrRouteNotFound {
+		t.Errorf("Expected ErrRouteNotFound outside Yarf, got %v", err)
+	}
+	if c.Links() != nil {
+		t.Error("Expected nil links")
+	}
+}
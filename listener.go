@@ -0,0 +1,153 @@
+package yarf
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Listener describes an address to be served by the Yarf instance through StartAll().
+type Listener struct {
+	// Network is "tcp" (default) or "unix".
+	Network string
+
+	// Address to listen on. For unix sockets it's the socket file path.
+	Address string
+
+	// Mode is the file mode for unix sockets.
+	Mode os.FileMode
+
+	// CertFile and KeyFile enable TLS on the listener.
+	CertFile string
+	KeyFile  string
+
+	// TLSConfig enables TLS on the listener with a custom configuration.
+	// Listeners using CertFile and KeyFile get the Yarf TLSConfig, if any.
+	TLSConfig *tls.Config
+
+	// Middleware runs only for requests received by this listener, before the global middleware.
+	Middleware []MiddlewareHandler
+}
+
+// tls returns true if the listener has TLS enabled.
+func (l Listener) tls() bool {
+	return l.CertFile != "" || l.TLSConfig != nil
+}
+
+// listenerHandler is the http.Handler of each listener started by StartAll().
+type listenerHandler struct {
+	y          *Yarf
+	middleware []MiddlewareHandler
+}
+
+// ServeHTTP serves the request with the listener middleware.
+func (h *listenerHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	h.y.serve(res, req, h.middleware)
+}
+
+// StartAll serves the Yarf instance on several listeners at once,
+// e.g. a plain http address, a TLS address and a unix socket.
+// All listeners are shut down together by Shutdown(), and if one of them fails, the others are closed.
+// It returns when all servers stop, with the first error found.
+func (y *Yarf) StartAll(listeners ...Listener) error {
+	var servers []*http.Server
+	var nls []net.Listener
+
+	closeAll := func() {
+		for _, s := range servers {
+			s.Close()
+		}
+		for _, nl := range nls {
+			nl.Close()
+		}
+	}
+
+	// Listen on all addresses before serving
+	for _, l := range listeners {
+		network := l.Network
+		if network == "" {
+			network = "tcp"
+		}
+
+		nl, err := y.listen(network, l.Address, l.Mode)
+		if err != nil {
+			closeAll()
+			return err
+		}
+		nls = append(nls, nl)
+	}
+
+	for i, l := range listeners {
+		s, err := y.newServer(nls[i].Addr().String())
+		if err != nil {
+			closeAll()
+			return err
+		}
+		s.Handler = &listenerHandler{y, l.Middleware}
+		if l.TLSConfig != nil {
+			s.TLSConfig = l.TLSConfig
+		}
+		servers = append(servers, s)
+	}
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var first error
+
+	for i, l := range listeners {
+		wg.Add(1)
+		go func(s *http.Server, nl net.Listener, l Listener) {
+			defer wg.Done()
+
+			y.log().Info("server started", "address", nl.Addr().String(), "tls", l.tls())
+
+			var err error
+			if l.tls() {
+				err = s.ServeTLS(nl, l.CertFile, l.KeyFile)
+			} else {
+				err = s.Serve(nl)
+			}
+
+			if err = y.serveResult(err, nl.Addr().String()); err != nil {
+				once.Do(func() {
+					first = err
+					closeAll()
+				})
+			}
+		}(servers[i], nls[i], l)
+	}
+
+	wg.Wait()
+
+	return first
+}
+
+// HTTPSRedirect is a middleware redirecting all requests to https.
+// Use it as listener middleware on the plain http listener.
+type HTTPSRedirect struct {
+	Middleware
+
+	// Port of the https server, if it isn't the default 443.
+	Port string
+}
+
+// PreDispatch redirects the request to the https URL.
+// Requests forwarded by trusted proxies that already use https aren't redirected.
+func (m *HTTPSRedirect) PreDispatch(c *Context) error {
+	if c.Scheme() == "https" {
+		return nil
+	}
+
+	host := c.Host()
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if m.Port != "" && m.Port != "443" {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), m.Port)
+	}
+
+	return ErrorRedirect("https://"+host+c.Request.URL.RequestURI(), http.StatusPermanentRedirect)
+}
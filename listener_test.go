@@ -0,0 +1,92 @@
+package yarf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestHTTPSRedirect(t *testing.T) {
+	y := New()
+	y.Add("/", new(OKResource))
+
+	h := &listenerHandler{y, []MiddlewareHandler{&HTTPSRedirect{Port: "8443"}}}
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/path?q=1", nil)
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+
+	if res.Code != http.StatusPermanentRedirect {
+		t.Errorf("Expected redirect status, got %d", res.Code)
+	}
+	if res.Header().Get("Location") != "https://localhost:8443/path?q=1" {
+		t.Errorf("Unexpected redirect location: %s", res.Header().Get("Location"))
+	}
+
+	// Other listeners aren't affected
+	req, _ = http.NewRequest("GET", "http://localhost:8080/", nil)
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Errorf("Request without listener middleware should return 200, got %d", res.Code)
+	}
+}
+
+func TestStartAll(t *testing.T) {
+	y := New()
+	y.Add("/", new(OKResource))
+
+	plain := freeAddress(t)
+	other := freeAddress(t)
+	sock := filepath.Join(t.TempDir(), "yarf.sock")
+
+	result := make(chan error)
+	go func() {
+		result <- y.StartAll(
+			Listener{Address: plain, Middleware: []MiddlewareHandler{new(HTTPSRedirect)}},
+			Listener{Address: other},
+			Listener{Network: "unix", Address: sock, Mode: 0600},
+		)
+	}()
+	waitServer(t, plain)
+	waitServer(t, other)
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	res, err := client.Get("http://" + plain + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusPermanentRedirect {
+		t.Errorf("Plain listener should redirect, got %d", res.StatusCode)
+	}
+
+	res, err = client.Get("http://" + other + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Errorf("Other listener should return 200, got %d", res.StatusCode)
+	}
+
+	y.Shutdown(context.Background())
+	if err := <-result; err != nil {
+		t.Errorf("StartAll should return nil after Shutdown, got: %s", err)
+	}
+}
+
+func TestStartAllListenError(t *testing.T) {
+	y := New()
+
+	if err := y.StartAll(Listener{Address: "invalid:address:here"}); err == nil {
+		t.Error("StartAll should fail on invalid addresses")
+	}
+}
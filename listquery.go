@@ -0,0 +1,350 @@
+package yarf
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilterOp is a comparison operator of a list filter.
+type FilterOp string
+
+// Filter operators, used as filter[field][op]=value. A filter without operator uses FilterEq.
+const (
+	FilterEq   FilterOp = "eq"
+	FilterNe   FilterOp = "ne"
+	FilterGt   FilterOp = "gt"
+	FilterGte  FilterOp = "gte"
+	FilterLt   FilterOp = "lt"
+	FilterLte  FilterOp = "lte"
+	FilterIn   FilterOp = "in"   // Comma separated values
+	FilterLike FilterOp = "like" // Substring match, for strings only
+)
+
+// FilterKind is the type filter values are converted to.
+type FilterKind int
+
+// Filter value kinds
+const (
+	FilterString FilterKind = iota // string
+	FilterInt                      // int64
+	FilterFloat                    // float64
+	FilterBool                     // bool
+	FilterTime                     // time.Time, in RFC 3339 format
+)
+
+// FilterField declares a filterable field of a list endpoint.
+type FilterField struct {
+	// Kind of the filter values.
+	Kind FilterKind
+
+	// Ops are the operators allowed. Defaults to FilterEq only.
+	Ops []FilterOp
+}
+
+// allows checks if the operator is allowed on the field.
+func (f FilterField) allows(op FilterOp) bool {
+	if len(f.Ops) == 0 {
+		return op == FilterEq
+	}
+	for _, o := range f.Ops {
+		if o == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parse converts a raw filter value to the field kind.
+func (f FilterField) parse(s string) (interface{}, error) {
+	switch f.Kind {
+	case FilterInt:
+		return strconv.ParseInt(s, 10, 64)
+	case FilterFloat:
+		return strconv.ParseFloat(s, 64)
+	case FilterBool:
+		return strconv.ParseBool(s)
+	case FilterTime:
+		return time.Parse(time.RFC3339, s)
+	}
+
+	return s, nil
+}
+
+// ListSpec declares the sorting, filtering and pagination accepted by a list endpoint.
+// Fields not declared are rejected, so queries can be safely mapped to database columns.
+//
+//	var usersList = &yarf.ListSpec{
+//		Sort:        []string{"name", "created_at"},
+//		DefaultSort: "-created_at",
+//		Filters: map[string]yarf.FilterField{
+//			"status":     {},
+//			"created_at": {Kind: yarf.FilterTime, Ops: []yarf.FilterOp{yarf.FilterGte, yarf.FilterLt}},
+//		},
+//		DefaultLimit: 20,
+//		MaxLimit:     100,
+//	}
+type ListSpec struct {
+	// Sort are the sortable fields.
+	Sort []string
+
+	// DefaultSort is used when the request doesn't sort, as in "-created_at,id".
+	DefaultSort string
+
+	// MaxSort caps the number of sort fields. 0 means no limit.
+	MaxSort int
+
+	// Filters are the filterable fields.
+	Filters map[string]FilterField
+
+	// MaxFilters caps the number of filters. 0 means no limit.
+	MaxFilters int
+
+	// DefaultLimit is the page size when the request doesn't set one.
+	DefaultLimit int
+
+	// MaxLimit caps the page size. 0 means no limit.
+	MaxLimit int
+}
+
+// SortField is a field to sort by.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Filter is a validated filter condition. Value is converted to the field kind,
+// and is a slice of values for FilterIn.
+type Filter struct {
+	Field string
+	Op    FilterOp
+	Value interface{}
+}
+
+// ListQuery is the validated sorting, filtering and pagination of a list request.
+type ListQuery struct {
+	Sort    []SortField
+	Filters []Filter // Sorted by field and operator
+	Limit   int
+	Offset  int
+}
+
+// Filter returns the first filter on a field.
+func (q *ListQuery) Filter(field string) (Filter, bool) {
+	for _, f := range q.Filters {
+		if f.Field == field {
+			return f, true
+		}
+	}
+
+	return Filter{}, false
+}
+
+// listError returns a 400 error with the message as body.
+func listError(msg string) error {
+	e := ErrorBadRequest()
+	e.ErrorBody = msg
+
+	return e
+}
+
+// Parse validates the query params against the spec:
+//
+//	?sort=-created_at,name&filter[status]=active&filter[age][gte]=18&limit=20&offset=40
+//
+// Invalid params return a 400 error describing the problem.
+func (s *ListSpec) Parse(q url.Values) (*ListQuery, error) {
+	lq := &ListQuery{
+		Limit: s.DefaultLimit,
+	}
+
+	if err := s.parseSort(lq, q.Get("sort")); err != nil {
+		return nil, err
+	}
+	if err := s.parseFilters(lq, q); err != nil {
+		return nil, err
+	}
+	if err := s.parsePage(lq, q); err != nil {
+		return nil, err
+	}
+
+	return lq, nil
+}
+
+// parseSort parses the sort fields, or the default ones.
+func (s *ListSpec) parseSort(lq *ListQuery, param string) error {
+	if param == "" {
+		param = s.DefaultSort
+	}
+	if param == "" {
+		return nil
+	}
+
+	for _, f := range strings.Split(param, ",") {
+		sf := SortField{Field: strings.TrimSpace(f)}
+		if strings.HasPrefix(sf.Field, "-") {
+			sf.Field, sf.Desc = sf.Field[1:], true
+		}
+
+		if !containsString(s.Sort, sf.Field) {
+			return listError("Invalid sort field: " + sf.Field)
+		}
+		lq.Sort = append(lq.Sort, sf)
+	}
+
+	if s.MaxSort > 0 && len(lq.Sort) > s.MaxSort {
+		return listError("Too many sort fields")
+	}
+
+	return nil
+}
+
+// parseFilters parses the filter[field] and filter[field][op] params.
+func (s *ListSpec) parseFilters(lq *ListQuery, q url.Values) error {
+	for key, values := range q {
+		if !strings.HasPrefix(key, "filter[") {
+			continue
+		}
+
+		field, op, ok := parseFilterKey(key)
+		if !ok {
+			return listError("Invalid filter: " + key)
+		}
+
+		ff, ok := s.Filters[field]
+		if !ok {
+			return listError("Invalid filter field: " + field)
+		}
+		if !ff.allows(op) {
+			return listError("Invalid filter operator for " + field + ": " + string(op))
+		}
+		if op == FilterLike && ff.Kind != FilterString {
+			return listError("Invalid filter operator for " + field + ": " + string(op))
+		}
+
+		for _, v := range values {
+			f := Filter{Field: field, Op: op}
+
+			if op == FilterIn {
+				var list []interface{}
+				for _, item := range strings.Split(v, ",") {
+					val, err := ff.parse(item)
+					if err != nil {
+						return listError("Invalid filter value for " + field + ": " + item)
+					}
+					list = append(list, val)
+				}
+				f.Value = list
+			} else {
+				val, err := ff.parse(v)
+				if err != nil {
+					return listError("Invalid filter value for " + field + ": " + v)
+				}
+				f.Value = val
+			}
+
+			lq.Filters = append(lq.Filters, f)
+		}
+	}
+
+	if s.MaxFilters > 0 && len(lq.Filters) > s.MaxFilters {
+		return listError("Too many filters")
+	}
+
+	sort.SliceStable(lq.Filters, func(i, j int) bool {
+		if lq.Filters[i].Field != lq.Filters[j].Field {
+			return lq.Filters[i].Field < lq.Filters[j].Field
+		}
+		return lq.Filters[i].Op < lq.Filters[j].Op
+	})
+
+	return nil
+}
+
+// parseFilterKey splits "filter[field]" or "filter[field][op]".
+func parseFilterKey(key string) (string, FilterOp, bool) {
+	rest := strings.TrimPrefix(key, "filter[")
+
+	i := strings.IndexByte(rest, ']')
+	if i <= 0 {
+		return "", "", false
+	}
+	field, rest := rest[:i], rest[i+1:]
+
+	if rest == "" {
+		return field, FilterEq, true
+	}
+	if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") || len(rest) < 3 {
+		return "", "", false
+	}
+
+	return field, FilterOp(rest[1 : len(rest)-1]), true
+}
+
+// parsePage parses the limit and offset params.
+func (s *ListSpec) parsePage(lq *ListQuery, q url.Values) error {
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return listError("Invalid limit: " + v)
+		}
+		lq.Limit = n
+	}
+	if s.MaxLimit > 0 && (lq.Limit > s.MaxLimit || lq.Limit == 0) {
+		lq.Limit = s.MaxLimit
+	}
+
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return listError("Invalid offset: " + v)
+		}
+		lq.Offset = n
+	}
+
+	return nil
+}
+
+// containsString checks if s is in list.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// listQueryKey is the Context storage key for the ListQuery parsed with a spec.
+type listQueryKey struct {
+	spec *ListSpec
+}
+
+// ListQuery parses the sorting, filtering and pagination params of the request with the spec provided.
+// The result is kept for the request, so middleware and handlers can share it.
+// Invalid params return a 400 error, which handlers can return as is.
+//
+//	func (r *Users) Get(c *yarf.Context) error {
+//		q, err := c.ListQuery(usersList)
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *Context) ListQuery(spec *ListSpec) (*ListQuery, error) {
+	if lq, ok := c.get(listQueryKey{spec}).(*ListQuery); ok {
+		return lq, nil
+	}
+
+	lq, err := spec.Parse(c.Request.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	c.set(listQueryKey{spec}, lq)
+
+	return lq, nil
+}
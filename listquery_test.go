@@ -0,0 +1,152 @@
+package yarf
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testListSpec = &ListSpec{
+	Sort:        []string{"name", "created_at"},
+	DefaultSort: "-created_at",
+	MaxSort:     2,
+	Filters: map[string]FilterField{
+		"status":     {},
+		"name":       {Ops: []FilterOp{FilterEq, FilterLike}},
+		"age":        {Kind: FilterInt, Ops: []FilterOp{FilterGte, FilterLt, FilterIn}},
+		"active":     {Kind: FilterBool},
+		"created_at": {Kind: FilterTime, Ops: []FilterOp{FilterGte}},
+	},
+	MaxFilters:   3,
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+func TestListSpecParse(t *testing.T) {
+	q, _ := url.ParseQuery("sort=name,-created_at&filter[status]=active&filter[age][gte]=18&filter[age][lt]=65&limit=500&offset=40")
+
+	lq, err := testListSpec.Parse(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &ListQuery{
+		Sort: []SortField{{"name", false}, {"created_at", true}},
+		Filters: []Filter{
+			{"age", FilterGte, int64(18)},
+			{"age", FilterLt, int64(65)},
+			{"status", FilterEq, "active"},
+		},
+		Limit:  100,
+		Offset: 40,
+	}
+	if !reflect.DeepEqual(lq, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, lq)
+	}
+
+	if f, ok := lq.Filter("status"); !ok || f.Value != "active" {
+		t.Errorf("Expected status filter, got %+v", f)
+	}
+	if _, ok := lq.Filter("name"); ok {
+		t.Error("Unexpected name filter")
+	}
+}
+
+func TestListSpecDefaults(t *testing.T) {
+	lq, err := testListSpec.Parse(url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(lq.Sort, []SortField{{"created_at", true}}) {
+		t.Errorf("Expected default sort, got %+v", lq.Sort)
+	}
+	if lq.Limit != 20 || lq.Offset != 0 || len(lq.Filters) != 0 {
+		t.Errorf("Unexpected defaults %+v", lq)
+	}
+}
+
+func TestListSpecTypedFilters(t *testing.T) {
+	q, _ := url.ParseQuery("filter[active]=true&filter[created_at][gte]=2024-01-02T03:04:05Z&filter[age][in]=1,2")
+
+	lq, err := testListSpec.Parse(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f, _ := lq.Filter("active"); f.Value != true {
+		t.Errorf("Expected bool value, got %#v", f.Value)
+	}
+	if f, _ := lq.Filter("created_at"); !f.Value.(time.Time).Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("Expected time value, got %#v", f.Value)
+	}
+	if f, _ := lq.Filter("age"); !reflect.DeepEqual(f.Value, []interface{}{int64(1), int64(2)}) {
+		t.Errorf("Expected list of ints, got %#v", f.Value)
+	}
+}
+
+func TestListSpecErrors(t *testing.T) {
+	tests := []string{
+		"sort=password",
+		"sort=name,created_at,-name",
+		"filter[password]=x",
+		"filter[status][ne]=x",
+		"filter[age]=18",
+		"filter[age][gte]=old",
+		"filter[age][in]=1,x",
+		"filter[active]=maybe",
+		"filter[status=x",
+		"filter[status][gte=x",
+		"filter[]=x",
+		"filter[status]=a&filter[name]=b&filter[active]=true&filter[age][gte]=1",
+		"limit=0",
+		"limit=x",
+		"offset=-1",
+	}
+
+	for _, tt := range tests {
+		q, _ := url.ParseQuery(tt)
+		_, err := testListSpec.Parse(q)
+		if err == nil {
+			t.Errorf("Expected error for %s", tt)
+			continue
+		}
+		if yerr, ok := err.(YError); !ok || yerr.Code() != 400 || yerr.Body() == "" {
+			t.Errorf("Expected 400 error with body for %s, got %v", tt, err)
+		}
+	}
+}
+
+func TestListSpecLikeOnlyOnStrings(t *testing.T) {
+	spec := &ListSpec{
+		Filters: map[string]FilterField{"age": {Kind: FilterInt, Ops: []FilterOp{FilterLike}}},
+	}
+
+	q, _ := url.ParseQuery("filter[age][like]=1")
+	if _, err := spec.Parse(q); err == nil {
+		t.Error("Like operator should be rejected on non string fields")
+	}
+}
+
+func TestContextListQuery(t *testing.T) {
+	c := NewContext(httptest.NewRequest("GET", "/?sort=name&filter[name][like]=jo", nil), httptest.NewRecorder())
+
+	lq, err := c.ListQuery(testListSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, _ := lq.Filter("name"); f.Op != FilterLike || f.Value != "jo" {
+		t.Errorf("Unexpected filter %+v", f)
+	}
+
+	if again, _ := c.ListQuery(testListSpec); again != lq {
+		t.Error("ListQuery should be kept for the request")
+	}
+
+	c = NewContext(httptest.NewRequest("GET", "/?sort=password", nil), httptest.NewRecorder())
+	if _, err := c.ListQuery(testListSpec); err == nil {
+		t.Error("Expected error for invalid sort field")
+	}
+}
@@ -0,0 +1,46 @@
+package yarf
+
+// Localizer translates messages to the locale negotiated for a request.
+// The i18n package provides an implementation loading translation bundles.
+type Localizer interface {
+	// Locale returns the language tag of the translations, like "en" or "pt-BR".
+	Locale() string
+
+	// T returns the message for the key, with the args replacing its placeholders.
+	T(key string, args ...interface{}) string
+}
+
+// localizerKey is the Context storage key for the request Localizer.
+type localizerKey struct{}
+
+// SetLocalizer binds a Localizer to the request, usually from a middleware negotiating the locale.
+func (c *Context) SetLocalizer(l Localizer) {
+	c.set(localizerKey{}, l)
+}
+
+// Localizer returns the Localizer bound to the request, or nil.
+func (c *Context) Localizer() Localizer {
+	l, _ := c.get(localizerKey{}).(Localizer)
+	return l
+}
+
+// Locale returns the locale negotiated for the request, or an empty string if no Localizer is bound.
+func (c *Context) Locale() string {
+	if l := c.Localizer(); l != nil {
+		return l.Locale()
+	}
+
+	return ""
+}
+
+// T translates a message key to the locale negotiated for the request.
+// If no Localizer is bound, the key is returned.
+//
+//	c.Render(c.T("greeting", "name", user.Name))
+func (c *Context) T(key string, args ...interface{}) string {
+	if l := c.Localizer(); l != nil {
+		return l.T(key, args...)
+	}
+
+	return key
+}
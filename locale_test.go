@@ -0,0 +1,36 @@
+package yarf
+
+import (
+	"testing"
+)
+
+// upperLocalizer translates keys to upper case.
+type upperLocalizer struct{}
+
+func (l upperLocalizer) Locale() string {
+	return "xx"
+}
+
+func (l upperLocalizer) T(key string, args ...interface{}) string {
+	if len(args) > 0 {
+		return "UPPER " + key
+	}
+	return "upper " + key
+}
+
+func TestContextLocalizer(t *testing.T) {
+	c := NewContext(nil, nil)
+
+	if c.T("hello") != "hello" || c.Locale() != "" || c.Localizer() != nil {
+		t.Error("Without localizer, keys should be returned")
+	}
+
+	c.SetLocalizer(upperLocalizer{})
+
+	if c.Locale() != "xx" {
+		t.Errorf("Unexpected locale %s", c.Locale())
+	}
+	if c.T("hello") != "upper hello" || c.T("hello", "name", "Jane") != "UPPER hello" {
+		t.Errorf("Unexpected translations %s, %s", c.T("hello"), c.T("hello", "name", "Jane"))
+	}
+}
@@ -0,0 +1,48 @@
+package yarf
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// Logger is the structured logging interface used by the framework for its own messages:
+// server startup and shutdown, upgrades, panics and dispatch errors.
+// Fields are passed as alternating key and value pairs.
+// *slog.Logger implements it, and it's the default implementation used.
+type Logger interface {
+	Debug(msg string, fields ...interface{})
+	Info(msg string, fields ...interface{})
+	Error(msg string, fields ...interface{})
+}
+
+// log returns the Logger for the framework messages.
+func (y *Yarf) log() Logger {
+	if y.Log != nil {
+		return y.Log
+	}
+
+	return slog.Default()
+}
+
+// errorLogWriter sends the lines written by net/http servers into the framework Logger.
+type errorLogWriter struct {
+	y *Yarf
+}
+
+// Write logs each line as an error.
+func (w errorLogWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		msg := strings.TrimPrefix(string(line), "http: ")
+		w.y.log().Error("http server error", "error", msg)
+	}
+
+	return len(p), nil
+}
+
+// serverErrorLog creates the *log.Logger used by the servers started by Yarf,
+// so net/http errors, including recovered panics, go through the framework Logger.
+func (y *Yarf) serverErrorLog() *log.Logger {
+	return log.New(errorLogWriter{y}, "", 0)
+}
@@ -0,0 +1,72 @@
+package yarf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type logEntry struct {
+	level  string
+	msg    string
+	fields []interface{}
+}
+
+type MockLogger struct {
+	entries []logEntry
+}
+
+func (l *MockLogger) Debug(msg string, fields ...interface{}) {
+	l.entries = append(l.entries, logEntry{"debug", msg, fields})
+}
+
+func (l *MockLogger) Info(msg string, fields ...interface{}) {
+	l.entries = append(l.entries, logEntry{"info", msg, fields})
+}
+
+func (l *MockLogger) Error(msg string, fields ...interface{}) {
+	l.entries = append(l.entries, logEntry{"error", msg, fields})
+}
+
+type FailingResource struct {
+	Resource
+}
+
+func (r *FailingResource) Get(c *Context) error {
+	return errors.New("database is down")
+}
+
+func TestLoggerDispatchErrors(t *testing.T) {
+	l := new(MockLogger)
+
+	y := New()
+	y.Log = l
+	y.Add("/fail", new(FailingResource))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/fail", nil)
+	y.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(l.entries) != 1 || l.entries[0].level != "error" || l.entries[0].msg != "dispatch error" {
+		t.Fatalf("Server errors should be logged as errors, got: %+v", l.entries)
+	}
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/not/found", nil)
+	y.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(l.entries) != 2 || l.entries[1].level != "debug" {
+		t.Errorf("Client errors should be logged as debug, got: %+v", l.entries)
+	}
+}
+
+func TestLoggerServerErrorLog(t *testing.T) {
+	l := new(MockLogger)
+
+	y := New()
+	y.Log = l
+	y.serverErrorLog().Print("http: panic serving 127.0.0.1: boom")
+
+	if len(l.entries) != 1 || l.entries[0].fields[1] != "panic serving 127.0.0.1: boom" {
+		t.Errorf("Server errors should go through the Logger, got: %+v", l.entries)
+	}
+}
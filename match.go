@@ -0,0 +1,39 @@
+package yarf
+
+// matchParams returns the empty candidate set collecting the params while matching.
+// The set is kept in the Context to be reused.
+func (c *Context) matchParams() Params {
+	if c.candidate == nil {
+		c.candidate = Params{}
+	}
+	for k := range c.candidate {
+		delete(c.candidate, k)
+	}
+
+	return c.candidate
+}
+
+// commitMatch copies the candidate params into c.Params if the route matched.
+// Params of the routes tried without matching never reach the handlers.
+func commitMatch(c *Context, matched bool) bool {
+	if matched {
+		for k, v := range c.candidate {
+			c.Params[k] = v
+		}
+	}
+
+	return matched
+}
+
+// matchRouter matches r into the candidate set p. Routers other than the built-in ones
+// write their params straight into c.Params through their Match method.
+func matchRouter(r Router, url string, c *Context, p Params) bool {
+	switch rt := r.(type) {
+	case *route:
+		return rt.match(url, c, p)
+	case *GroupRoute:
+		return rt.match(url, c, p)
+	}
+
+	return r.Match(url, c)
+}
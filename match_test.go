@@ -0,0 +1,71 @@
+package yarf
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// spyRouter records the params visible when it's matched.
+type spyRouter struct {
+	seen Params
+}
+
+func (r *spyRouter) Match(url string, c *Context) bool {
+	r.seen = Params{}
+	for k, v := range c.Params {
+		r.seen[k] = v
+	}
+
+	return false
+}
+
+func (r *spyRouter) Dispatch(c *Context) error {
+	return nil
+}
+
+func TestMatchCandidateParams(t *testing.T) {
+	h := new(Handler)
+	c := &Context{Params: Params{}}
+
+	// The inner group matches its prefix and params, but none of its routes
+	users := RouteGroup("/:org/users/:id")
+	users.Add("/posts/:post", h)
+
+	spy := new(spyRouter)
+	g := RouteGroup("/")
+	g.AddGroup(users)
+	g.routes = append(g.routes, spy)
+	g.Add("/:org/users/:name/settings", h)
+
+	if !g.Match("/acme/users/joe/settings", c) {
+		t.Fatal("Expected a match")
+	}
+	if len(spy.seen) != 0 {
+		t.Errorf("Params of routes tried without matching shouldn't be visible, got %v", spy.seen)
+	}
+	if len(c.Params) != 2 || c.Params["org"] != "acme" || c.Params["name"] != "joe" {
+		t.Errorf("Expected only the params of the matched route, got %v", c.Params)
+	}
+
+	// Failed matches don't commit anything
+	c.Params = Params{}
+	if g.Match("/acme/users/joe/posts", c) || len(c.Params) != 0 {
+		t.Errorf("Expected no params without match, got %v", c.Params)
+	}
+}
+
+func TestMatchParamsPool(t *testing.T) {
+	r := new(NamespaceResource)
+	y := New()
+	y.UsePool = true
+	y.Add("/users/:id", r)
+	y.Add("/users", r)
+
+	for _, tc := range []struct{ path, body string }{{"/users/1", "id=1"}, {"/users", ""}, {"/users/2", "id=2"}} {
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, httptest.NewRequest("GET", tc.path, nil))
+		if res.Body.String() != tc.body {
+			t.Errorf("%s: expected '%s', got '%s'", tc.path, tc.body, res.Body.String())
+		}
+	}
+}
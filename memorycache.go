@@ -0,0 +1,258 @@
+package yarf
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MemoryCacheStats are the counters of a MemoryCache.
+type MemoryCacheStats struct {
+	Hits      uint64 // Lookups finding a fresh value
+	Misses    uint64 // Lookups finding no value or an expired one
+	Evictions uint64 // Values removed to make room for new ones
+	Loads     uint64 // Loader calls made by Load
+}
+
+// memoryEntry is a value stored in a MemoryCache.
+type memoryEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// memoryLoad is a Load call shared by the concurrent lookups of a key.
+type memoryLoad struct {
+	wg    sync.WaitGroup
+	value interface{}
+	err   error
+}
+
+// MemoryCache is a concurrent in-memory cache with expiration and least recently used eviction.
+// Load runs a single loader for the concurrent lookups of a missing key.
+// A nil *MemoryCache stores nothing, and its Load always calls the loader.
+//
+//	user, err := c.MemoryCache().Load("user:"+id, func() (interface{}, error) {
+//		return db.FindUser(id)
+//	})
+type MemoryCache struct {
+	// MaxEntries is the maximum number of values. The least recently used values are evicted over it.
+	// 0 means no limit.
+	MaxEntries int
+
+	// TTL is the lifetime of the values stored without one. 0 means they don't expire.
+	TTL time.Duration
+
+	// Clock tells the time to expire the values. nil uses the system clock.
+	Clock Clock
+
+	entries map[string]*list.Element
+	lru     *list.List
+	loads   map[string]*memoryLoad
+	stats   MemoryCacheStats
+	lock    sync.Mutex
+}
+
+// NewMemoryCache creates a MemoryCache keeping up to maxEntries values for ttl.
+func NewMemoryCache(maxEntries int, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		MaxEntries: maxEntries,
+		TTL:        ttl,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		loads:      make(map[string]*memoryLoad),
+	}
+}
+
+// Get returns the value stored under key, if it hasn't expired.
+func (m *MemoryCache) Get(key string) (interface{}, bool) {
+	if m == nil {
+		return nil, false
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.get(key, clockNow(m.Clock))
+}
+
+// get looks the key up and records the hit or miss. The cache must be locked.
+func (m *MemoryCache) get(key string, now time.Time) (interface{}, bool) {
+	el, ok := m.entries[key]
+	if !ok {
+		atomic.AddUint64(&m.stats.Misses, 1)
+		return nil, false
+	}
+
+	e := el.Value.(*memoryEntry)
+	if !e.expires.IsZero() && now.After(e.expires) {
+		m.remove(el)
+		atomic.AddUint64(&m.stats.Misses, 1)
+		return nil, false
+	}
+
+	m.lru.MoveToFront(el)
+	atomic.AddUint64(&m.stats.Hits, 1)
+
+	return e.value, true
+}
+
+// Set stores the value under key for the cache TTL.
+func (m *MemoryCache) Set(key string, value interface{}) {
+	if m == nil {
+		return
+	}
+
+	m.SetTTL(key, value, m.TTL)
+}
+
+// SetTTL stores the value under key for ttl. 0 means it doesn't expire.
+func (m *MemoryCache) SetTTL(key string, value interface{}, ttl time.Duration) {
+	if m == nil {
+		return
+	}
+
+	e := &memoryEntry{key: key, value: value}
+	if ttl > 0 {
+		e.expires = clockNow(m.Clock).Add(ttl)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		el.Value = e
+		m.lru.MoveToFront(el)
+		return
+	}
+
+	m.entries[key] = m.lru.PushFront(e)
+	for m.MaxEntries > 0 && m.lru.Len() > m.MaxEntries {
+		m.remove(m.lru.Back())
+		atomic.AddUint64(&m.stats.Evictions, 1)
+	}
+}
+
+// Del removes the value stored under key.
+func (m *MemoryCache) Del(key string) {
+	if m == nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		m.remove(el)
+	}
+}
+
+// remove deletes an entry. The cache must be locked.
+func (m *MemoryCache) remove(el *list.Element) {
+	m.lru.Remove(el)
+	delete(m.entries, el.Value.(*memoryEntry).key)
+}
+
+// Load returns the value stored under key, or calls load to get it and stores it for the cache TTL.
+// Concurrent lookups of the same missing key wait for a single load call and share its result.
+// Errors aren't cached.
+func (m *MemoryCache) Load(key string, load func() (interface{}, error)) (interface{}, error) {
+	if m == nil {
+		return load()
+	}
+
+	m.lock.Lock()
+	if v, ok := m.get(key, clockNow(m.Clock)); ok {
+		m.lock.Unlock()
+		return v, nil
+	}
+	if l, ok := m.loads[key]; ok {
+		m.lock.Unlock()
+		l.wg.Wait()
+		return l.value, l.err
+	}
+
+	l := new(memoryLoad)
+	l.wg.Add(1)
+	m.loads[key] = l
+	m.lock.Unlock()
+
+	// Release the waiters even if the loader panics.
+	defer func() {
+		m.lock.Lock()
+		delete(m.loads, key)
+		m.lock.Unlock()
+		l.wg.Done()
+	}()
+
+	atomic.AddUint64(&m.stats.Loads, 1)
+	l.err = ErrorUnexpected()
+	l.value, l.err = load()
+	if l.err == nil {
+		m.Set(key, l.value)
+	}
+
+	return l.value, l.err
+}
+
+// Len returns the number of values stored, including the expired ones not removed yet.
+func (m *MemoryCache) Len() int {
+	if m == nil {
+		return 0
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.lru.Len()
+}
+
+// Purge removes all the values.
+func (m *MemoryCache) Purge() {
+	if m == nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.entries = make(map[string]*list.Element)
+	m.lru.Init()
+}
+
+// Stats returns the current counters.
+func (m *MemoryCache) Stats() MemoryCacheStats {
+	if m == nil {
+		return MemoryCacheStats{}
+	}
+
+	return MemoryCacheStats{
+		Hits:      atomic.LoadUint64(&m.stats.Hits),
+		Misses:    atomic.LoadUint64(&m.stats.Misses),
+		Evictions: atomic.LoadUint64(&m.stats.Evictions),
+		Loads:     atomic.LoadUint64(&m.stats.Loads),
+	}
+}
+
+// MemoryCache returns the MemoryCache shared by the application, created on first use
+// keeping up to 10000 values for 5 minutes. Its settings can be changed before serving requests.
+func (y *Yarf) MemoryCache() *MemoryCache {
+	y.lock.Lock()
+	defer y.lock.Unlock()
+
+	if y.memory == nil {
+		y.memory = NewMemoryCache(10000, 5*time.Minute)
+	}
+
+	return y.memory
+}
+
+// MemoryCache returns the MemoryCache shared by the application, or nil outside an application.
+func (c *Context) MemoryCache() *MemoryCache {
+	if c.app == nil {
+		return nil
+	}
+
+	return c.app.MemoryCache()
+}
@@ -0,0 +1,143 @@
+package yarf
+
+import (
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheLRU(t *testing.T) {
+	m := NewMemoryCache(2, 0)
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Get("a")
+	m.Set("c", 3)
+
+	if _, ok := m.Get("b"); ok {
+		t.Error("The least recently used value should be evicted")
+	}
+	if v, ok := m.Get("a"); !ok || v != 1 {
+		t.Errorf("Expected a=1, got %v %v", v, ok)
+	}
+	if m.Len() != 2 || m.Stats().Evictions != 1 {
+		t.Errorf("Unexpected len %d and stats %+v", m.Len(), m.Stats())
+	}
+
+	m.Del("a")
+	if _, ok := m.Get("a"); ok {
+		t.Error("Deleted value still cached")
+	}
+	m.Purge()
+	if m.Len() != 0 {
+		t.Error("Expected empty cache after Purge")
+	}
+}
+
+func TestMemoryCacheTTL(t *testing.T) {
+	clock := newTestClock()
+	m := NewMemoryCache(0, time.Minute)
+	m.Clock = clock
+	m.Set("a", 1)
+	m.SetTTL("b", 2, time.Hour)
+
+	clock.Advance(2 * time.Minute)
+
+	if _, ok := m.Get("a"); ok {
+		t.Error("Expected expired value")
+	}
+	if _, ok := m.Get("b"); !ok {
+		t.Error("Expected value with its own TTL")
+	}
+	if s := m.Stats(); s.Hits != 1 || s.Misses != 1 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+}
+
+func TestMemoryCacheLoad(t *testing.T) {
+	m := NewMemoryCache(0, time.Minute)
+
+	var calls int32
+	release := make(chan bool)
+	load := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := m.Load("k", load); v != "value" || err != nil {
+				t.Errorf("Unexpected load result %v %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 || m.Stats().Loads != 1 {
+		t.Errorf("Expected a single load, got %d", calls)
+	}
+
+	// Errors aren't cached
+	fail := errors.New("fail")
+	for i := 0; i < 2; i++ {
+		if _, err := m.Load("err", func() (interface{}, error) { return nil, fail }); err != fail {
+			t.Errorf("Expected load error, got %v", err)
+		}
+	}
+	if m.Stats().Loads != 3 {
+		t.Errorf("Expected failed loads to be retried, got %+v", m.Stats())
+	}
+}
+
+func TestMemoryCacheNil(t *testing.T) {
+	var m *MemoryCache
+	m.Set("a", 1)
+	if _, ok := m.Get("a"); ok || m.Len() != 0 {
+		t.Error("Nil cache shouldn't store values")
+	}
+	if v, err := m.Load("a", func() (interface{}, error) { return 1, nil }); v != 1 || err != nil {
+		t.Errorf("Nil cache should call the loader, got %v %v", v, err)
+	}
+
+	c := NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+	if c.MemoryCache() != nil {
+		t.Error("Expected nil cache outside an application")
+	}
+}
+
+type MemoryCacheResource struct {
+	Resource
+}
+
+func (r *MemoryCacheResource) Get(c *Context) error {
+	v, _ := c.MemoryCache().Load("greeting", func() (interface{}, error) {
+		return "hello " + c.Param("name"), nil
+	})
+	c.Render(v.(string))
+
+	return nil
+}
+
+func TestContextMemoryCache(t *testing.T) {
+	y := New()
+	y.Add("/greet/:name", new(MemoryCacheResource))
+
+	for _, name := range []string{"ann", "bob"} {
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, httptest.NewRequest("GET", "/greet/"+name, nil))
+		if res.Body.String() != "hello ann" {
+			t.Errorf("Expected the shared cached value, got %q", res.Body.String())
+		}
+	}
+	if y.MemoryCache() != y.MemoryCache() {
+		t.Error("Expected a single application cache")
+	}
+}
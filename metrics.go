@@ -0,0 +1,117 @@
+package yarf
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics creates the instruments used by the framework and the built-in middleware to report measurements,
+// so applications can plug their metrics stack: the prometheus and statsd subpackages and the otel module implement it,
+// and other backends only need an adapter for these interfaces.
+// Instruments are identified by name, so implementations must return the same instrument for the same name.
+// Label names are set when the instrument is created, and label values, in the same order, when recording.
+type Metrics interface {
+	Counter(name, help string, labels ...string) Counter
+	Histogram(name, help string, labels ...string) Histogram
+	Gauge(name, help string, labels ...string) Gauge
+}
+
+// Counter is a metric that only goes up, like the number of requests.
+type Counter interface {
+	Add(delta float64, labelValues ...string)
+}
+
+// Histogram is a metric sampling observations, like request durations.
+type Histogram interface {
+	Observe(v float64, labelValues ...string)
+}
+
+// Gauge is a metric that goes up and down, like the number of requests in flight.
+type Gauge interface {
+	Set(v float64, labelValues ...string)
+	Add(delta float64, labelValues ...string)
+}
+
+// nopMetrics discards all measurements, used when no Metrics is set.
+type nopMetrics struct{}
+
+func (nopMetrics) Counter(name, help string, labels ...string) Counter     { return nopMetrics{} }
+func (nopMetrics) Histogram(name, help string, labels ...string) Histogram { return nopMetrics{} }
+func (nopMetrics) Gauge(name, help string, labels ...string) Gauge         { return nopMetrics{} }
+func (nopMetrics) Add(delta float64, labelValues ...string)                {}
+func (nopMetrics) Observe(v float64, labelValues ...string)                {}
+func (nopMetrics) Set(v float64, labelValues ...string)                    {}
+
+// metrics returns the Metrics of the application serving the request, or a no-op implementation.
+func (c *Context) metrics() Metrics {
+	if c.app == nil || c.app.Metrics == nil {
+		return nopMetrics{}
+	}
+
+	return c.app.Metrics
+}
+
+// statusRecorder is a http.ResponseWriter wrapper keeping the response status code, for the request metrics.
+type statusRecorder struct {
+	http.ResponseWriter
+
+	code int
+}
+
+// WriteHeader keeps the first final status code sent.
+func (w *statusRecorder) WriteHeader(code int) {
+	if w.code == 0 && !informational(code) {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write sets the implicit 200 status if no status was sent.
+func (w *statusRecorder) Write(data []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush flushes the response if supported.
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the original http.ResponseWriter, as used by http.ResponseController.
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// requestMetrics starts measuring a request and returns the function recording it once the response is sent:
+// the yarf_requests_total counter and the yarf_request_duration_seconds histogram by method, route and status,
+// and the yarf_requests_in_flight gauge.
+func (y *Yarf) requestMetrics(c *Context) func() {
+	m := y.Metrics
+	start := time.Now()
+	inFlight := m.Gauge("yarf_requests_in_flight", "Requests being served.")
+	inFlight.Add(1)
+
+	rec := &statusRecorder{ResponseWriter: c.Response}
+	c.Response = rec
+
+	return func() {
+		inFlight.Add(-1)
+
+		code := rec.code
+		if _, ok := c.err.(*ClientClosedRequestError); ok {
+			code = StatusClientClosedRequest
+		} else if code == 0 {
+			code = http.StatusOK
+		}
+		method, route, status := c.Request.Method, c.RoutePattern(), strconv.Itoa(code)
+
+		m.Counter("yarf_requests_total", "Requests served.", "method", "route", "status").Add(1, method, route, status)
+		m.Histogram("yarf_request_duration_seconds", "Request durations in seconds.", "method", "route", "status").
+			Observe(time.Since(start).Seconds(), method, route, status)
+	}
+}
@@ -0,0 +1,105 @@
+package yarf
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testMetrics records the measurements by name and label values, like "yarf_requests_total{GET,/,200}".
+type testMetrics struct {
+	values map[string]float64
+	sync.Mutex
+}
+
+func newTestMetrics() *testMetrics {
+	return &testMetrics{values: make(map[string]float64)}
+}
+
+func (m *testMetrics) Counter(name, help string, labels ...string) Counter {
+	return testInstrument{m, name}
+}
+
+func (m *testMetrics) Histogram(name, help string, labels ...string) Histogram {
+	return testInstrument{m, name}
+}
+
+func (m *testMetrics) Gauge(name, help string, labels ...string) Gauge {
+	return testInstrument{m, name}
+}
+
+func (m *testMetrics) get(key string) float64 {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.values[key]
+}
+
+type testInstrument struct {
+	m    *testMetrics
+	name string
+}
+
+func (i testInstrument) key(values []string) string {
+	return i.name + "{" + strings.Join(values, ",") + "}"
+}
+
+func (i testInstrument) Add(delta float64, labelValues ...string) {
+	i.m.Lock()
+	defer i.m.Unlock()
+	i.m.values[i.key(labelValues)] += delta
+}
+
+func (i testInstrument) Set(v float64, labelValues ...string) {
+	i.m.Lock()
+	defer i.m.Unlock()
+	i.m.values[i.key(labelValues)] = v
+}
+
+// Observe counts the observations.
+func (i testInstrument) Observe(v float64, labelValues ...string) {
+	i.Add(1, labelValues...)
+}
+
+type CreatedResource struct {
+	Resource
+}
+
+func (r *CreatedResource) Post(c *Context) error {
+	c.Status(201)
+	return nil
+}
+
+func TestRequestMetrics(t *testing.T) {
+	m := newTestMetrics()
+	y := New()
+	y.Metrics = m
+	y.Use(NewRequestLimits())
+	y.Add("/items", new(CreatedResource))
+	y.Add("/items/:id", new(OKResource))
+
+	for _, r := range []struct{ method, target string }{
+		{"GET", "/items/1"},
+		{"GET", "/items/2"},
+		{"POST", "/items"},
+		{"GET", "/missing"},
+		{"GET", "/items/1?" + strings.Repeat("a&", 101)},
+	} {
+		y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(r.method, r.target, nil))
+	}
+
+	for key, expected := range map[string]float64{
+		"yarf_requests_total{GET,/items/:id,200}":              2,
+		"yarf_request_duration_seconds{GET,/items/:id,200}":    2,
+		"yarf_requests_total{POST,/items,201}":                 1,
+		"yarf_requests_total{GET,,404}":                        1,
+		"yarf_requests_total{GET,,414}":                        1,
+		"yarf_limits_rejected_total{" + LimitQueryParams + "}": 1,
+		"yarf_requests_in_flight{}":                            0,
+	} {
+		if v := m.get(key); v != expected {
+			t.Errorf("%s = %v, expected %v", key, v, expected)
+		}
+	}
+}
@@ -1,5 +1,12 @@
 package yarf
 
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
 // MiddlewareHandler interface provides the methods for request filters
 // that needs to run before, or after, every request Resource is executed.
 type MiddlewareHandler interface {
@@ -27,3 +34,128 @@ func (m *Middleware) PostDispatch(c *Context) error {
 func (m *Middleware) End(c *Context) error {
 	return nil
 }
+
+// Phase defines the execution order of middleware inside a group.
+// Middleware with a lower phase runs first. Middleware sharing a phase runs in insertion order.
+// Phases only sort middleware inside the same group, parent group middleware always runs before its children's.
+type Phase int
+
+// Predefined middleware phases.
+// Values are spaced so custom phases can be placed between them.
+const (
+	PhaseSecurity Phase = 100
+	PhaseLogging  Phase = 200
+	PhaseBusiness Phase = 300
+)
+
+// String returns the phase name.
+func (p Phase) String() string {
+	switch p {
+	case PhaseSecurity:
+		return "security"
+	case PhaseLogging:
+		return "logging"
+	case PhaseBusiness:
+		return "business"
+	}
+
+	return fmt.Sprintf("phase(%d)", int(p))
+}
+
+// PhasedMiddleware is implemented by middleware declaring the phase they belong to.
+// Middleware not implementing it runs on PhaseBusiness.
+type PhasedMiddleware interface {
+	MiddlewareHandler
+	Phase() Phase
+}
+
+// phaseOf returns the declared phase of a middleware.
+func phaseOf(m MiddlewareHandler) Phase {
+	if pm, ok := m.(PhasedMiddleware); ok {
+		return pm.Phase()
+	}
+
+	return PhaseBusiness
+}
+
+// MiddlewarePanicPolicy decides what happens to a request when a middleware panics.
+// Panics are always recovered and logged with the middleware type and method,
+// and raised again into the application PanicHandler, if any, before the policy applies.
+type MiddlewarePanicPolicy int
+
+// Middleware panic policies
+const (
+	// FailOnMiddlewarePanic fails the request with a 500 error, as if the middleware returned a PanicError.
+	FailOnMiddlewarePanic MiddlewarePanicPolicy = iota
+
+	// SkipPanickingMiddleware ignores the panic and continues the chain, for non essential middleware.
+	// Security middleware still fails the request, so a panic never bypasses it.
+	SkipPanickingMiddleware
+)
+
+// Middleware stages
+const (
+	stagePre  = "PreDispatch"
+	stagePost = "PostDispatch"
+	stageEnd  = "End"
+)
+
+// callMiddleware runs a stage of a middleware, recovering its panics according to the application policy.
+func callMiddleware(c *Context, m MiddlewareHandler, stage string) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if r == http.ErrAbortHandler {
+			panic(r)
+		}
+
+		err = middlewarePanic(c, m, &PanicError{
+			Value:      r,
+			Stack:      debug.Stack(),
+			Middleware: fmt.Sprintf("%T", m),
+			Stage:      stage,
+		})
+	}()
+
+	switch stage {
+	case stagePre:
+		return m.PreDispatch(c)
+	case stagePost:
+		return m.PostDispatch(c)
+	default:
+		return m.End(c)
+	}
+}
+
+// middlewarePanic logs a middleware panic, passes it to the application PanicHandler,
+// and returns the error of the request, if any, as the policy decides.
+func middlewarePanic(c *Context, m MiddlewareHandler, perr *PanicError) error {
+	policy := FailOnMiddlewarePanic
+	var log Logger = slog.Default()
+	if c.app != nil {
+		policy = c.app.MiddlewarePanics
+		log = c.app.log()
+	}
+
+	log.Error("middleware panic", "middleware", perr.Middleware, "stage", perr.Stage, "panic", perr.Value,
+		"method", c.Request.Method, "path", c.Request.URL.Path, "route", c.RoutePattern())
+
+	if c.app != nil && c.app.PanicHandler != nil && !c.app.Debug {
+		c.app.handlePanic(perr.Value)
+	}
+
+	if policy == SkipPanickingMiddleware && phaseOf(m) != PhaseSecurity {
+		return nil
+	}
+
+	return perr
+}
+
+// handlePanic raises a recovered panic again into the PanicHandler, which recovers it.
+// If the handler doesn't recover, the panic goes on as it would without the chain recovering it.
+func (y *Yarf) handlePanic(v interface{}) {
+	defer y.PanicHandler()
+	panic(v)
+}
@@ -1,8 +1,10 @@
 package yarf
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -35,3 +37,190 @@ func TestMiddlewareDefaultResponse(t *testing.T) {
 		t.Error("Default PostDispatch() implementation should return nil")
 	}
 }
+
+type SecurityMiddleware struct {
+	Middleware
+}
+
+func (m *SecurityMiddleware) Phase() Phase {
+	return PhaseSecurity
+}
+
+type LoggingMiddleware struct {
+	Middleware
+}
+
+func TestMiddlewarePhaseOrder(t *testing.T) {
+	g := RouteGroup("/api")
+
+	business := new(MockMiddleware)
+	logging := new(LoggingMiddleware)
+	security := new(SecurityMiddleware)
+
+	g.Insert(business)
+	g.InsertPhase(PhaseLogging, logging)
+	g.Insert(security)
+
+	expected := []MiddlewareHandler{security, logging, business}
+	for i, m := range expected {
+		if g.middleware[i] != m {
+			t.Fatalf("Middleware %d should be %T, found %T", i, m, g.middleware[i])
+		}
+	}
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	y := New()
+	y.Insert(new(MockMiddleware))
+	y.Insert(new(SecurityMiddleware))
+	y.Add("/", new(MockResource))
+
+	g := RouteGroup("/v2")
+	g.InsertPhase(PhaseLogging, new(LoggingMiddleware))
+	g.Add("/hello/:name", new(MockResource))
+	y.AddGroup(g)
+
+	chains := y.Chain()
+	if len(chains["/"]) != 2 {
+		t.Fatalf("Route / should have 2 middleware, found %d", len(chains["/"]))
+	}
+	if chains["/"][0].Phase != PhaseSecurity {
+		t.Error("Security middleware should run first")
+	}
+
+	chain := chains["/v2/hello/:name"]
+	if len(chain) != 3 {
+		t.Fatalf("Route /v2/hello/:name should have 3 middleware, found %d", len(chain))
+	}
+	if _, ok := chain[2].Middleware.(*LoggingMiddleware); !ok {
+		t.Error("Group middleware should run after parent middleware")
+	}
+
+	var buf bytes.Buffer
+	y.PrintChain(&buf)
+	if !strings.Contains(buf.String(), "/v2/hello/:name: [security] *yarf.SecurityMiddleware -> [business] *yarf.MockMiddleware -> [logging] *yarf.LoggingMiddleware") {
+		t.Errorf("Unexpected chain output: %s", buf.String())
+	}
+}
+
+type PanicMiddleware struct {
+	Middleware
+	phase Phase
+}
+
+func (m *PanicMiddleware) PreDispatch(c *Context) error {
+	panic("middleware broke")
+}
+
+func (m *PanicMiddleware) Phase() Phase {
+	return m.phase
+}
+
+func TestMiddlewarePanic(t *testing.T) {
+	for _, tc := range []struct {
+		policy MiddlewarePanicPolicy
+		phase  Phase
+		global bool
+		code   int
+	}{
+		{FailOnMiddlewarePanic, PhaseBusiness, false, 500},
+		{FailOnMiddlewarePanic, PhaseBusiness, true, 500},
+		{SkipPanickingMiddleware, PhaseBusiness, false, 200},
+		{SkipPanickingMiddleware, PhaseBusiness, true, 200},
+		{SkipPanickingMiddleware, PhaseSecurity, false, 500},
+	} {
+		l := new(MockLogger)
+		end := new(CountMiddleware)
+
+		y := New()
+		y.Log = l
+		y.MiddlewarePanics = tc.policy
+		m := &PanicMiddleware{phase: tc.phase}
+		if tc.global {
+			y.Use(m)
+			y.Add("/", new(OKResource))
+		} else {
+			g := RouteGroup("/")
+			g.Insert(m)
+			g.Insert(end)
+			g.Add("/", new(OKResource))
+			y.AddGroup(g)
+		}
+
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+		y.ServeHTTP(res, req)
+
+		if res.Code != tc.code {
+			t.Errorf("%+v: expected %d, got %d", tc, tc.code, res.Code)
+		}
+		if !tc.global && end.end != 1 {
+			t.Errorf("%+v: End middleware should run after panics", tc)
+		}
+
+		found := false
+		for _, e := range l.entries {
+			if e.msg == "middleware panic" {
+				found = true
+				if e.fields[1] != "*yarf.PanicMiddleware" || e.fields[3] != "PreDispatch" {
+					t.Errorf("%+v: expected the middleware identified, got %v", tc, e.fields)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%+v: expected the panic to be logged, got %+v", tc, l.entries)
+		}
+	}
+}
+
+func TestMiddlewarePanicHandler(t *testing.T) {
+	var recovered []interface{}
+
+	y := New()
+	y.Log = new(MockLogger)
+	y.PanicHandler = func() {
+		if r := recover(); r != nil {
+			recovered = append(recovered, r)
+		}
+	}
+	y.Use(new(PanicMiddleware))
+	y.Add("/", new(OKResource))
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	if res.Code != 500 || len(recovered) != 1 || recovered[0] != "middleware broke" {
+		t.Errorf("Expected the panic passed to the handler and the request failed, got %d %v", res.Code, recovered)
+	}
+
+	// Debug mode renders panics without the handler
+	y.Debug = true
+	y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if len(recovered) != 1 {
+		t.Errorf("Expected no handler call in debug mode, got %v", recovered)
+	}
+}
+
+func TestMiddlewarePanicError(t *testing.T) {
+	c := NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+	err := callMiddleware(c, new(PanicMiddleware), stagePre)
+
+	perr, ok := err.(*PanicError)
+	if !ok || perr.Error() != "panic in *yarf.PanicMiddleware PreDispatch: middleware broke" || len(perr.Stack) == 0 {
+		t.Errorf("Unexpected panic error %v", err)
+	}
+
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("http.ErrAbortHandler should propagate")
+		}
+	}()
+	callMiddleware(c, new(AbortMiddleware), stageEnd)
+}
+
+type AbortMiddleware struct {
+	Middleware
+}
+
+func (m *AbortMiddleware) End(c *Context) error {
+	panic(http.ErrAbortHandler)
+}
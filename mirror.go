@@ -0,0 +1,229 @@
+package yarf
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MirrorStats are the counters exposed by the Mirror middleware.
+type MirrorStats struct {
+	Mirrored uint64 // Requests sent to the shadow
+	Skipped  uint64 // Sampled requests not mirrored because of the body size or the concurrency limit
+	Failed   uint64 // Shadow requests failed or panicked
+}
+
+// Mirror is a middleware that duplicates a percentage of the requests to a shadow handler or upstream URL,
+// to test new implementations with production traffic.
+// Shadow requests run asynchronously with a copy of the request body, and their responses are discarded,
+// so they never affect the primary request.
+//
+//	shadow, _ := url.Parse("http://orders-v2.internal:8080")
+//	m := yarf.NewMirror(shadow)
+//	m.Percentage = 10
+//	y.Use(m)
+type Mirror struct {
+	Middleware
+
+	// Target is the upstream receiving the shadow requests, keeping the request path and query.
+	Target *url.URL
+
+	// Handler receives the shadow requests when Target is nil.
+	Handler http.Handler
+
+	// Percentage of requests mirrored, from 0 to 100.
+	Percentage float64
+
+	// MaxBodySize is the largest request body copied. Requests with larger bodies aren't mirrored.
+	MaxBodySize int64
+
+	// MaxConcurrent caps the shadow requests in flight. Requests over the limit aren't mirrored. 0 means no limit.
+	MaxConcurrent int
+
+	// Timeout limits each shadow request.
+	Timeout time.Duration
+
+	// Client sends the shadow requests to Target. If nil, http.DefaultClient is used.
+	Client *http.Client
+
+	// SetHeaders are headers set on every shadow request, so the shadow can tell them apart.
+	SetHeaders map[string]string
+
+	inFlight int64
+	stats    MirrorStats
+	wg       sync.WaitGroup
+}
+
+// NewMirror creates a Mirror sending all requests to target,
+// with bodies up to 1MB, 100 concurrent shadow requests and a 30 seconds timeout.
+func NewMirror(target *url.URL) *Mirror {
+	return &Mirror{
+		Target:        target,
+		Percentage:    100,
+		MaxBodySize:   1 << 20,
+		MaxConcurrent: 100,
+		Timeout:       30 * time.Second,
+		SetHeaders:    map[string]string{"X-Mirrored": "true"},
+	}
+}
+
+// Stats returns a snapshot of the mirror counters.
+func (m *Mirror) Stats() MirrorStats {
+	return MirrorStats{
+		Mirrored: atomic.LoadUint64(&m.stats.Mirrored),
+		Skipped:  atomic.LoadUint64(&m.stats.Skipped),
+		Failed:   atomic.LoadUint64(&m.stats.Failed),
+	}
+}
+
+// Wait blocks until the shadow requests in flight are done.
+func (m *Mirror) Wait() {
+	m.wg.Wait()
+}
+
+// PreDispatch samples the request and starts the shadow request.
+func (m *Mirror) PreDispatch(c *Context) error {
+	if m.Target == nil && m.Handler == nil {
+		return nil
+	}
+	if m.Percentage < 100 && rand.Float64()*100 >= m.Percentage {
+		return nil
+	}
+
+	body, ok, err := m.copyBody(c)
+	if err != nil {
+		return err
+	}
+	results := c.metrics().Counter("yarf_mirror_requests_total", "Sampled requests by mirror result.", "result")
+	if !ok {
+		atomic.AddUint64(&m.stats.Skipped, 1)
+		results.Add(1, "skipped")
+		return nil
+	}
+
+	if m.MaxConcurrent > 0 && atomic.AddInt64(&m.inFlight, 1) > int64(m.MaxConcurrent) {
+		atomic.AddInt64(&m.inFlight, -1)
+		atomic.AddUint64(&m.stats.Skipped, 1)
+		results.Add(1, "skipped")
+		return nil
+	}
+
+	req := m.shadowRequest(c, body)
+
+	atomic.AddUint64(&m.stats.Mirrored, 1)
+	results.Add(1, "mirrored")
+	m.wg.Add(1)
+	go m.send(req, results)
+
+	return nil
+}
+
+// copyBody reads the request body, leaving an equivalent body in place for the primary request.
+// Returns false if the body is larger than MaxBodySize.
+func (m *Mirror) copyBody(c *Context) ([]byte, bool, error) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, true, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, m.MaxBodySize+1))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if int64(len(data)) > m.MaxBodySize {
+		c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(data), c.Request.Body), c.Request.Body}
+		return nil, false, nil
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+
+	return data, true, nil
+}
+
+// shadowRequest builds the shadow copy of the request, detached from the primary request cancellation.
+func (m *Mirror) shadowRequest(c *Context, body []byte) *http.Request {
+	ctx := context.WithoutCancel(c.Request.Context())
+
+	req := c.Request.Clone(ctx)
+	req.Body = http.NoBody
+	if body != nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	if m.Target != nil {
+		req.RequestURI = ""
+		req.URL.Scheme = m.Target.Scheme
+		req.URL.Host = m.Target.Host
+		req.URL.Path = strings.TrimSuffix(m.Target.Path, "/") + req.URL.Path
+		req.URL.RawPath = ""
+		req.Host = m.Target.Host
+	}
+
+	c.InjectTrace(req.Header)
+	for k, v := range m.SetHeaders {
+		req.Header.Set(k, v)
+	}
+
+	return req
+}
+
+// send runs the shadow request and discards the response, counting the failures into results.
+func (m *Mirror) send(req *http.Request, results Counter) {
+	defer m.wg.Done()
+	if m.MaxConcurrent > 0 {
+		defer atomic.AddInt64(&m.inFlight, -1)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddUint64(&m.stats.Failed, 1)
+			results.Add(1, "failed")
+		}
+	}()
+
+	if m.Timeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), m.Timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+
+	if m.Target == nil {
+		m.Handler.ServeHTTP(newBufferedResponse(), req)
+		return
+	}
+
+	client := m.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		atomic.AddUint64(&m.stats.Failed, 1)
+		results.Add(1, "failed")
+		return
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	if res.StatusCode >= 500 {
+		atomic.AddUint64(&m.stats.Failed, 1)
+		results.Add(1, "failed")
+	}
+}
+
+// readCloser combines a Reader with the Closer of the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
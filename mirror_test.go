@@ -0,0 +1,147 @@
+package yarf
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// EchoBodyResource renders the request body.
+type EchoBodyResource struct {
+	Resource
+}
+
+func (r *EchoBodyResource) Post(c *Context) error {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	c.Render(string(body))
+	return nil
+}
+
+// shadowRecorder records the requests received by a shadow handler.
+type shadowRecorder struct {
+	paths  []string
+	bodies []string
+	header []http.Header
+	sync.Mutex
+}
+
+func (s *shadowRecorder) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	s.Lock()
+	defer s.Unlock()
+	s.paths = append(s.paths, req.URL.RequestURI())
+	s.bodies = append(s.bodies, string(body))
+	s.header = append(s.header, req.Header)
+}
+
+func TestMirrorTarget(t *testing.T) {
+	rec := new(shadowRecorder)
+	upstream := httptest.NewServer(rec)
+	defer upstream.Close()
+
+	target, _ := url.Parse(upstream.URL + "/shadow")
+	m := NewMirror(target)
+
+	y := New()
+	y.Use(m)
+	y.Add("/echo", new(EchoBodyResource))
+
+	req := httptest.NewRequest("POST", "/echo?x=1", strings.NewReader("payload"))
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+	m.Wait()
+
+	if res.Body.String() != "payload" {
+		t.Errorf("Primary request body should be preserved, got %q", res.Body.String())
+	}
+	if len(rec.paths) != 1 {
+		t.Fatalf("Expected 1 shadow request, got %d", len(rec.paths))
+	}
+	if rec.paths[0] != "/shadow/echo?x=1" {
+		t.Errorf("Expected shadow path /shadow/echo?x=1, got %s", rec.paths[0])
+	}
+	if rec.bodies[0] != "payload" {
+		t.Errorf("Expected shadow body payload, got %q", rec.bodies[0])
+	}
+	if rec.header[0].Get("X-Mirrored") != "true" {
+		t.Error("Expected X-Mirrored header on shadow requests")
+	}
+	if s := m.Stats(); s.Mirrored != 1 || s.Failed != 0 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+}
+
+func TestMirrorHandler(t *testing.T) {
+	rec := new(shadowRecorder)
+	m := &Mirror{Handler: rec, Percentage: 100, MaxBodySize: 4}
+
+	y := New()
+	y.Use(m)
+	y.Add("/echo", new(EchoBodyResource))
+
+	for _, body := range []string{"abc", "too large"} {
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, httptest.NewRequest("POST", "/echo", strings.NewReader(body)))
+		if res.Body.String() != body {
+			t.Errorf("Primary request body should be preserved, got %q", res.Body.String())
+		}
+	}
+	m.Wait()
+
+	if len(rec.bodies) != 1 || rec.bodies[0] != "abc" {
+		t.Errorf("Only the small body should be mirrored, got %v", rec.bodies)
+	}
+	if s := m.Stats(); s.Mirrored != 1 || s.Skipped != 1 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+}
+
+func TestMirrorPercentage(t *testing.T) {
+	rec := new(shadowRecorder)
+	m := &Mirror{Handler: rec, Percentage: 0}
+
+	y := New()
+	y.Use(m)
+	y.Add("/", new(OKResource))
+
+	for i := 0; i < 20; i++ {
+		y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+	m.Wait()
+
+	if len(rec.paths) != 0 {
+		t.Errorf("Expected no shadow requests at 0%%, got %d", len(rec.paths))
+	}
+}
+
+func TestMirrorFailuresDontAffectPrimary(t *testing.T) {
+	m := &Mirror{
+		Handler: http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			panic("shadow failed")
+		}),
+		Percentage: 100,
+	}
+
+	y := New()
+	y.Use(m)
+	y.Add("/", new(OKResource))
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	m.Wait()
+
+	if res.Code != 200 || res.Body.String() != "OK" {
+		t.Errorf("Primary request shouldn't be affected, got %d %q", res.Code, res.Body.String())
+	}
+	if s := m.Stats(); s.Failed != 1 {
+		t.Errorf("Expected 1 failure, got %+v", s)
+	}
+}
@@ -0,0 +1,129 @@
+package yarf
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+)
+
+// MutualTLSConfig creates a TLS configuration requiring client certificates signed by the CAs of the PEM file provided,
+// for Yarf.TLSConfig or Listener.TLSConfig. Set ClientAuth to tls.VerifyClientCertIfGiven to make them optional.
+//
+//	y.TLSConfig, err = yarf.MutualTLSConfig("/etc/certs/clients-ca.pem")
+//	y.Use(yarf.NewClientCertAuth(nil))
+//	y.StartTLS(":8443", "server.pem", "server-key.pem")
+func MutualTLSConfig(caFile string) (*tls.Config, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("yarf: no certificates found in " + caFile)
+	}
+
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// ClientCertificate returns the client certificate verified by the TLS server, or nil if there is none.
+// Certificates sent by the client aren't returned unless the server verified them against its ClientCAs.
+func (c *Context) ClientCertificate() *x509.Certificate {
+	if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 || len(c.Request.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	return c.Request.TLS.VerifiedChains[0][0]
+}
+
+// CertMapper maps a verified client certificate to the principal of the request.
+// Returning a nil Identity rejects the certificate with a 403 error.
+type CertMapper func(cert *x509.Certificate) (*Identity, error)
+
+// CertSubject returns the principal name of a certificate: its first URI SAN, like a SPIFFE ID,
+// or its first DNS SAN, or its common name.
+func CertSubject(cert *x509.Certificate) string {
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+
+	return cert.Subject.CommonName
+}
+
+// CertRoles creates a CertMapper granting roles to known principals by CertSubject().
+// Unknown principals are rejected.
+//
+//	yarf.CertRoles(map[string][]string{
+//		"spiffe://example.com/billing": {"invoices.reader"},
+//	})
+func CertRoles(principals map[string][]string) CertMapper {
+	return func(cert *x509.Certificate) (*Identity, error) {
+		sub := CertSubject(cert)
+		roles, ok := principals[sub]
+		if !ok {
+			return nil, nil
+		}
+
+		return &Identity{Subject: sub, Roles: roles}, nil
+	}
+}
+
+// ClientCertAuth is a middleware authenticating requests with the client certificates verified by the TLS server,
+// for zero-trust internal APIs. It sets the request Identity with the certificate mapped by Mapper.
+// Requests without a verified certificate get a 401 error, and certificates rejected by the mapper a 403 error.
+type ClientCertAuth struct {
+	Middleware
+
+	// Mapper maps the certificates to principals. Defaults to CertSubject() without roles.
+	Mapper CertMapper
+
+	// Optional lets requests without certificate through, without identity.
+	Optional bool
+}
+
+// NewClientCertAuth creates a ClientCertAuth middleware with the mapper provided, or the default one if nil.
+func NewClientCertAuth(mapper CertMapper) *ClientCertAuth {
+	return &ClientCertAuth{
+		Mapper: mapper,
+	}
+}
+
+// Phase runs ClientCertAuth with the security middleware.
+func (m *ClientCertAuth) Phase() Phase {
+	return PhaseSecurity
+}
+
+// PreDispatch maps the client certificate to the request Identity.
+func (m *ClientCertAuth) PreDispatch(c *Context) error {
+	cert := c.ClientCertificate()
+	if cert == nil {
+		if m.Optional {
+			return nil
+		}
+		return ErrorUnauthorized()
+	}
+
+	id := &Identity{Subject: CertSubject(cert)}
+	if m.Mapper != nil {
+		var err error
+		if id, err = m.Mapper(cert); err != nil {
+			return err
+		}
+		if id == nil {
+			return ErrorForbidden()
+		}
+	}
+	id.Source = "mtls"
+	id.Certificate = cert
+	c.SetIdentity(id)
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package yarf
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert creates a certificate signed by the parent, or self-signed if parent is nil.
+func testCert(t *testing.T, tmpl *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
+
+// testCA creates a CA certificate.
+func testCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	return testCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+}
+
+// IdentityResource renders the request identity.
+type IdentityResource struct {
+	Resource
+}
+
+func (r *IdentityResource) Get(c *Context) error {
+	id := c.Identity()
+	if id == nil {
+		c.Render("anonymous")
+		return nil
+	}
+
+	c.Render(id.Source + ":" + id.Subject)
+	return nil
+}
+
+// certRequest returns a TLS request verified with the client certificate provided, if any.
+func certRequest(cert *x509.Certificate) *http.Request {
+	req := httptest.NewRequest("GET", "https://localhost/me", nil)
+	if cert != nil {
+		req.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+
+	return req
+}
+
+func TestCertSubject(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.com/billing")
+
+	for expected, cert := range map[string]*x509.Certificate{
+		"spiffe://example.com/billing": {URIs: []*url.URL{spiffe}, DNSNames: []string{"billing.internal"}},
+		"billing.internal":             {DNSNames: []string{"billing.internal"}, Subject: pkix.Name{CommonName: "billing"}},
+		"billing":                      {Subject: pkix.Name{CommonName: "billing"}},
+	} {
+		if s := CertSubject(cert); s != expected {
+			t.Errorf("CertSubject() = %q, expected %q", s, expected)
+		}
+	}
+}
+
+func TestClientCertAuth(t *testing.T) {
+	ca, caKey := testCA(t)
+	billing, _ := testCert(t, &x509.Certificate{DNSNames: []string{"billing.internal"}}, ca, caKey)
+	unknown, _ := testCert(t, &x509.Certificate{DNSNames: []string{"unknown.internal"}}, ca, caKey)
+
+	m := NewClientCertAuth(CertRoles(map[string][]string{"billing.internal": {"invoices.reader"}}))
+	y := New()
+	y.Use(m)
+	y.Add("/me", new(IdentityResource))
+
+	if res := serveRequest(y, certRequest(billing)); res.Code != 200 || res.Body.String() != "mtls:billing.internal" {
+		t.Errorf("Known principal: %d %q", res.Code, res.Body.String())
+	}
+	if res := serveRequest(y, certRequest(unknown)); res.Code != 403 {
+		t.Errorf("Unknown principal: status %d, expected 403", res.Code)
+	}
+	if res := serveRequest(y, certRequest(nil)); res.Code != 401 {
+		t.Errorf("Missing certificate: status %d, expected 401", res.Code)
+	}
+
+	m.Optional = true
+	if res := serveRequest(y, certRequest(nil)); res.Code != 200 || res.Body.String() != "anonymous" {
+		t.Errorf("Optional certificate: %d %q", res.Code, res.Body.String())
+	}
+
+	// Default mapper
+	m.Mapper = nil
+	if res := serveRequest(y, certRequest(unknown)); res.Code != 200 || res.Body.String() != "mtls:unknown.internal" {
+		t.Errorf("Default mapper: %d %q", res.Code, res.Body.String())
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca, caKey := testCA(t)
+	server, serverKey := testCert(t, &x509.Certificate{
+		DNSNames:    []string{"localhost"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	client, clientKey := testCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "worker"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MutualTLSConfig(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("Missing CA file should fail")
+	}
+	cfg, err := MutualTLSConfig(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Certificates = []tls.Certificate{{Certificate: [][]byte{server.Raw}, PrivateKey: serverKey}}
+
+	y := New()
+	y.Use(NewClientCertAuth(nil))
+	y.Add("/me", new(IdentityResource))
+
+	srv := httptest.NewUnstartedServer(y)
+	srv.TLS = cfg
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	// With a client certificate
+	c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      roots,
+		ServerName:   "localhost",
+		Certificates: []tls.Certificate{{Certificate: [][]byte{client.Raw}, PrivateKey: clientKey}},
+	}}}
+	res, err := c.Get(srv.URL + "/me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "mtls:worker" {
+		t.Errorf("Unexpected identity %q", body)
+	}
+
+	// Without: the handshake fails
+	c = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "localhost"}}}
+	if res, err := c.Get(srv.URL + "/me"); err == nil {
+		res.Body.Close()
+		t.Error("Requests without client certificate should fail")
+	}
+}
@@ -0,0 +1,158 @@
+package yarf
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// MultiError aggregates the errors of a multi-step handler, like the items of a batch validation,
+// into a single response listing all the issues:
+//
+//	{"errors": [{"status": 400, "code": 9, "message": "name is required"}, ...]}
+//
+// The messages are the bodies of the YErrors. Other errors are listed without message, as internal errors.
+// The response status is the one shared by all the errors, 500 if any is a server error, or 400 otherwise.
+type MultiError struct {
+	Errors []error
+}
+
+// Add appends the errors, flattening other MultiErrors. Nil errors are ignored.
+func (e *MultiError) Add(errs ...error) {
+	for _, err := range errs {
+		switch err := err.(type) {
+		case nil:
+		case *MultiError:
+			e.Add(err.Errors...)
+		default:
+			e.Errors = append(e.Errors, err)
+		}
+	}
+}
+
+// Error joins the error messages.
+func (e *MultiError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors, for errors.Is() and errors.As().
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+// errorStatus returns the HTTP status of an error.
+func errorStatus(err error) int {
+	if yerr, ok := err.(YError); ok {
+		return yerr.Code()
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Code returns the status shared by all the errors, 500 if any is a server error, or 400 otherwise.
+func (e *MultiError) Code() int {
+	code := 0
+	for _, err := range e.Errors {
+		s := errorStatus(err)
+		switch {
+		case s >= 500:
+			return http.StatusInternalServerError
+		case code == 0:
+			code = s
+		case code != s:
+			code = http.StatusBadRequest
+		}
+	}
+	if code == 0 {
+		code = http.StatusBadRequest
+	}
+
+	return code
+}
+
+// ID returns the error ID of MultiError.
+func (e *MultiError) ID() int {
+	return 15
+}
+
+// Msg returns the joined error messages.
+func (e *MultiError) Msg() string {
+	return e.Error()
+}
+
+// multiErrorItem is an error listed in the MultiError body.
+type multiErrorItem struct {
+	Status  int    `json:"status"`
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// Body returns the JSON list of the errors.
+func (e *MultiError) Body() string {
+	items := make([]multiErrorItem, len(e.Errors))
+	for i, err := range e.Errors {
+		items[i].Status = errorStatus(err)
+		if yerr, ok := err.(YError); ok {
+			items[i].Code = yerr.ID()
+			items[i].Message = yerr.Body()
+		}
+	}
+
+	body, _ := json.Marshal(map[string][]multiErrorItem{"errors": items})
+
+	return string(body)
+}
+
+// errorsKey is the Context storage key for the errors added by the handlers.
+type errorsKey struct{}
+
+// AddError records an error of the request without stopping it, so a handler can report all the issues found
+// in a batch at once. Once the request handler returns, the errors added fail the request as a MultiError,
+// along with the error returned, if any. Nil errors are ignored.
+//
+//	for i, item := range items {
+//		if item.Name == "" {
+//			e := yarf.ErrorBadRequest()
+//			e.ErrorBody = fmt.Sprintf("item %d: name is required", i)
+//			c.AddError(e)
+//		}
+//	}
+//	if c.Errors() != nil {
+//		return nil
+//	}
+func (c *Context) AddError(err error) {
+	if err == nil {
+		return
+	}
+
+	m := c.Errors()
+	if m == nil {
+		m = new(MultiError)
+		c.set(errorsKey{}, m)
+	}
+	m.Add(err)
+}
+
+// Errors returns the errors added with AddError, or nil if there are none.
+func (c *Context) Errors() *MultiError {
+	m, _ := c.get(errorsKey{}).(*MultiError)
+
+	return m
+}
+
+// joinErrors adds the error returned by the request flow to the errors added with AddError.
+func (c *Context) joinErrors(err error) error {
+	m := c.Errors()
+	if m == nil || err == m {
+		return err
+	}
+
+	m.Add(err)
+
+	return m
+}
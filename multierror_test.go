@@ -0,0 +1,76 @@
+package yarf
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type BatchResource struct {
+	Resource
+}
+
+func (r *BatchResource) Post(c *Context) error {
+	for i, name := range c.Request.URL.Query()["name"] {
+		if name == "" {
+			e := ErrorBadRequest()
+			e.ErrorBody = fmt.Sprintf("item %d: name is required", i)
+			c.AddError(e)
+		}
+	}
+
+	if c.Request.URL.Query().Get("fail") != "" {
+		return ErrorNotFound()
+	}
+
+	return nil
+}
+
+func TestAddError(t *testing.T) {
+	y := New()
+	y.Add("/batch", new(BatchResource))
+
+	for url, expected := range map[string]struct {
+		code int
+		body string
+	}{
+		"/batch?name=a&name=b":      {200, ""},
+		"/batch?name=&name=b&name=": {400, `{"errors":[{"status":400,"code":9,"message":"item 0: name is required"},{"status":400,"code":9,"message":"item 2: name is required"}]}`},
+		"/batch?name=&fail=1":       {400, `{"errors":[{"status":400,"code":9,"message":"item 0: name is required"},{"status":404,"code":2}]}`},
+	} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "http://localhost:8080"+url, nil)
+		y.ServeHTTP(res, req)
+
+		if res.Code != expected.code || res.Body.String() != expected.body {
+			t.Errorf("%s: expected %d %s, got %d %s", url, expected.code, expected.body, res.Code, res.Body.String())
+		}
+		if expected.body != "" && res.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s: unexpected Content-Type %q", url, res.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestMultiError(t *testing.T) {
+	dbErr := errors.New("database is down")
+
+	m := new(MultiError)
+	m.Add(nil, ErrorNotFound(), &MultiError{Errors: []error{ErrorNotFound()}})
+	if len(m.Errors) != 2 || m.Code() != 404 {
+		t.Errorf("Expected 2 flattened 404 errors, got %v %d", m.Errors, m.Code())
+	}
+
+	m.Add(dbErr)
+	if m.Code() != 500 || !errors.Is(m, dbErr) || m.Error() != "Not found; Not found; database is down" {
+		t.Errorf("Unexpected multi error %d %q", m.Code(), m.Error())
+	}
+	if m.Body() != `{"errors":[{"status":404,"code":2},{"status":404,"code":2},{"status":500,"code":0}]}` {
+		t.Errorf("Internal errors shouldn't leak their message, got %s", m.Body())
+	}
+
+	if (&MultiError{}).Code() != 400 {
+		t.Error("Empty multi errors should be bad requests")
+	}
+}
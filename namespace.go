@@ -0,0 +1,97 @@
+package yarf
+
+import (
+	"fmt"
+)
+
+// Namespace stores the params of the group prefix under "name.param" too, like "user.id",
+// so the routes inside the group can declare the same param names and still reach the group ones.
+// Without namespace, the group params replace the ones declared by the routes inside it.
+// With a namespace, the group params only keep their plain name when the inner routes don't declare it.
+// It returns the group to allow chaining.
+//
+//	users := yarf.RouteGroup("/users/:id").Namespace("user")
+//	users.Add("/posts/:id", new(Post)) // c.Param("id") is the post, c.Param("user.id") the user
+func (g *GroupRoute) Namespace(name string) *GroupRoute {
+	g.namespace = name
+	return g
+}
+
+// storeParams writes the params of the group prefix into the candidate set p, after the inner routes matched,
+// so the group params win. Namespaced groups keep the params already set by the inner routes.
+func (g *GroupRoute) storeParams(p Params, requestParts []string) {
+	for i, part := range g.routeParts {
+		if part[0] != ':' {
+			continue
+		}
+
+		name := part[1:]
+		if g.namespace != "" {
+			p.Set(g.namespace+"."+name, requestParts[i])
+			if _, ok := p[name]; ok {
+				continue
+			}
+		}
+		p.Set(name, requestParts[i])
+	}
+}
+
+// ParamConflicts returns a *PatternError for each route or group declaring a param already declared
+// by an enclosing group without namespace, which hides the inner param from the handlers.
+// Yarf logs them when the servers start.
+func (g *GroupRoute) ParamConflicts() []error {
+	var errs []error
+	g.paramConflicts("", nil, &errs)
+
+	return errs
+}
+
+// paramConflicts checks the group and its children against the params of the enclosing groups, by name.
+func (g *GroupRoute) paramConflicts(prefix string, outer map[string]string, errs *[]error) {
+	prefix = joinURL(prefix, g.prefix)
+	if outer != nil {
+		checkParams(prefix, g.routeParts, outer, errs)
+	}
+
+	if g.namespace == "" {
+		scope := make(map[string]string, len(outer))
+		for k, v := range outer {
+			scope[k] = v
+		}
+		for _, p := range g.routeParts {
+			if p[0] == ':' {
+				scope[p[1:]] = prefix
+			}
+		}
+		outer = scope
+	}
+
+	for _, r := range g.routes {
+		switch rt := r.(type) {
+		case *route:
+			checkParams(joinURL(prefix, rt.path), rt.routeParts, outer, errs)
+		case *GroupRoute:
+			rt.paramConflicts(prefix, outer, errs)
+		}
+	}
+}
+
+// checkParams adds an error for each param of parts already in outer. pattern is the full pattern ending with parts.
+func checkParams(pattern string, parts []string, outer map[string]string, errs *[]error) {
+	full := prepareURL(pattern)
+	offset := len(full) - len(parts)
+
+	for i, p := range parts {
+		if p[0] != ':' {
+			continue
+		}
+		if group, ok := outer[p[1:]]; ok {
+			*errs = append(*errs, &PatternError{
+				Pattern: pattern,
+				Segment: offset + i + 1,
+				Part:    p,
+				Reason:  fmt.Sprintf("param %q is hidden by the one of group %q", p[1:], group),
+			})
+		}
+	}
+}
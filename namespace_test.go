@@ -0,0 +1,100 @@
+package yarf
+
+import (
+	"strings"
+	"testing"
+)
+
+// NamespaceResource renders the plain and namespaced request params.
+type NamespaceResource struct {
+	Resource
+}
+
+func (r *NamespaceResource) Get(c *Context) error {
+	var keys []string
+	for _, k := range []string{"id", "user.id", "org.id", "org.slug"} {
+		if v, ok := c.Params[k]; ok {
+			keys = append(keys, k+"="+v)
+		}
+	}
+	c.Render(strings.Join(keys, " "))
+
+	return nil
+}
+
+func TestGroupParamsShadowing(t *testing.T) {
+	users := RouteGroup("/users/:id")
+	users.Add("/posts/:id", new(NamespaceResource))
+	users.Add("/profile", new(NamespaceResource))
+
+	y := New()
+	y.AddGroup(users)
+
+	if body := testRequest(y, "GET", "/users/1/posts/2", nil).Body.String(); body != "id=1" {
+		t.Errorf("Expected the group param to win, got '%s'", body)
+	}
+	if body := testRequest(y, "GET", "/users/1/profile", nil).Body.String(); body != "id=1" {
+		t.Errorf("Expected the group param, got '%s'", body)
+	}
+
+	errs := y.ParamConflicts()
+	if len(errs) != 1 || errs[0].Error() != `yarf: invalid route pattern "/users/:id/posts/:id": param "id" is hidden by the one of group "/users/:id" in segment 4 ":id"` {
+		t.Errorf("Expected a param conflict, got %v", errs)
+	}
+}
+
+func TestGroupParamsNamespace(t *testing.T) {
+	orgs := RouteGroup("/orgs/:slug/:id").Namespace("org")
+	users := RouteGroup("/users/:id").Namespace("user")
+	users.Add("/posts/:id", new(NamespaceResource))
+	orgs.AddGroup(users)
+
+	y := New()
+	y.AddGroup(orgs)
+
+	if body := testRequest(y, "GET", "/orgs/acme/7/users/1/posts/2", nil).Body.String(); body != "id=2 user.id=1 org.id=7 org.slug=acme" {
+		t.Errorf("Expected namespaced params, got '%s'", body)
+	}
+	if errs := y.ParamConflicts(); len(errs) != 0 {
+		t.Errorf("Namespaced params shouldn't conflict, got %v", errs)
+	}
+
+	// Groups without namespace keep their params, inside namespaced ones too
+	mixed := RouteGroup("/orgs/:id").Namespace("org")
+	teams := RouteGroup("/teams/:id")
+	teams.Add("/posts/:id", new(NamespaceResource))
+	mixed.AddGroup(teams)
+
+	y = New()
+	y.AddGroup(mixed)
+	if body := testRequest(y, "GET", "/orgs/7/teams/3/posts/2", nil).Body.String(); body != "id=3 org.id=7" {
+		t.Errorf("Expected the group param to win, got '%s'", body)
+	}
+
+	// Conflicts with groups without namespace
+	outer := RouteGroup("/orgs/:id")
+	inner := RouteGroup("/teams/:id")
+	inner.Add("/", new(NamespaceResource))
+	outer.AddGroup(inner)
+
+	errs := outer.ParamConflicts()
+	if len(errs) != 1 || errs[0].(*PatternError).Segment != 4 || errs[0].(*PatternError).Pattern != "/orgs/:id/teams/:id" {
+		t.Errorf("Expected a group param conflict, got %v", errs)
+	}
+}
+
+func TestParamConflictsLogged(t *testing.T) {
+	l := new(MockLogger)
+	y := New()
+	y.Log = l
+	g := RouteGroup("/users/:id")
+	g.Add("/posts/:id", new(NamespaceResource))
+	y.AddGroup(g)
+
+	if err := y.start(); err != nil {
+		t.Fatal(err)
+	}
+	if len(l.entries) != 1 || l.entries[0].msg != "route param conflict" {
+		t.Errorf("Expected the conflict logged on start, got %+v", l.entries)
+	}
+}
@@ -0,0 +1,157 @@
+package yarf
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// nonceHeader returns the nonce and timestamp headers of a request, without the timestamp if ts is zero.
+func nonceHeader(nonce string, ts time.Time) map[string]string {
+	h := map[string]string{"X-Request-Nonce": nonce}
+	if !ts.IsZero() {
+		h["X-Request-Timestamp"] = strconv.FormatInt(ts.Unix(), 10)
+	}
+
+	return h
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	clock := newTestClock()
+	s := NewMemoryNonceStore()
+	s.Clock = clock
+
+	if ok, err := s.Add("abc", time.Minute); !ok || err != nil {
+		t.Fatalf("Expected new nonce, got %v %v", ok, err)
+	}
+	if ok, _ := s.Add("abc", time.Minute); ok {
+		t.Error("Expected replayed nonce")
+	}
+
+	clock.Advance(2 * time.Minute)
+	if ok, _ := s.Add("abc", time.Minute); !ok {
+		t.Error("Expected expired nonce to be accepted again")
+	}
+	if len(s.nonces) != 1 {
+		t.Errorf("Expected expired nonces cleaned up, got %d", len(s.nonces))
+	}
+}
+
+func TestMemoryNonceStoreConcurrent(t *testing.T) {
+	s := NewMemoryNonceStore()
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	accepted := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := s.Add("abc", time.Minute); ok {
+				lock.Lock()
+				accepted++
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted != 1 {
+		t.Errorf("Expected the nonce accepted once, got %d", accepted)
+	}
+}
+
+func TestReplayGuard(t *testing.T) {
+	clock := newTestClock()
+	store := NewMemoryNonceStore()
+	store.Clock = clock
+	g := NewReplayGuard(store)
+	g.Clock = clock
+
+	y := New()
+	y.Use(g)
+	y.Add("/payments", new(OverrideResource))
+
+	nonce := "0123456789abcdef"
+	if res := testRequest(y, "POST", "/payments", nonceHeader(nonce, clock.Now())); res.Code != 200 {
+		t.Fatalf("Expected first request accepted, got %d '%s'", res.Code, res.Body.String())
+	}
+	if res := testRequest(y, "POST", "/payments", nonceHeader(nonce, clock.Now())); res.Code != 401 || res.Body.String() != "Request already processed" {
+		t.Errorf("Expected replay rejected, got %d '%s'", res.Code, res.Body.String())
+	}
+
+	for name, tc := range map[string]struct {
+		nonce string
+		ts    time.Time
+		body  string
+	}{
+		"missing nonce":  {"", clock.Now(), "Missing request nonce"},
+		"short nonce":    {"abc", clock.Now(), "Invalid request nonce"},
+		"missing time":   {"fedcba9876543210", time.Time{}, "Invalid request timestamp"},
+		"old timestamp":  {"fedcba9876543211", clock.Now().Add(-6 * time.Minute), "Request timestamp outside the allowed window"},
+		"future request": {"fedcba9876543212", clock.Now().Add(6 * time.Minute), "Request timestamp outside the allowed window"},
+	} {
+		if res := testRequest(y, "POST", "/payments", nonceHeader(tc.nonce, tc.ts)); res.Code != 400 || res.Body.String() != tc.body {
+			t.Errorf("%s: expected 400 '%s', got %d '%s'", name, tc.body, res.Code, res.Body.String())
+		}
+	}
+
+	// Nonces are remembered as long as their timestamps are accepted
+	clock.Advance(9 * time.Minute)
+	if res := testRequest(y, "POST", "/payments", nonceHeader(nonce, clock.Now().Add(-4*time.Minute))); res.Code != 401 {
+		t.Errorf("Expected replay within the window rejected, got %d", res.Code)
+	}
+}
+
+func TestReplayGuardScope(t *testing.T) {
+	g := NewReplayGuard(NewMemoryNonceStore())
+	g.TimestampHeader = ""
+	g.Scope = func(c *Context) string {
+		return c.Request.Header.Get("X-Client")
+	}
+
+	y := New()
+	y.Use(g)
+	y.Add("/payments", new(OverrideResource))
+
+	send := func(client string) int {
+		req := httptest.NewRequest("POST", "/payments", nil)
+		req.Header.Set("X-Request-Nonce", "0123456789abcdef")
+		req.Header.Set("X-Client", client)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		return res.Code
+	}
+
+	if code := send("ann"); code != 200 {
+		t.Errorf("Expected first request accepted, got %d", code)
+	}
+	if code := send("bob"); code != 200 {
+		t.Errorf("Expected the same nonce accepted for another client, got %d", code)
+	}
+	if code := send("ann"); code != 401 {
+		t.Errorf("Expected replay rejected, got %d", code)
+	}
+}
+
+type failingNonceStore struct{}
+
+func (failingNonceStore) Add(string, time.Duration) (bool, error) {
+	return false, errors.New("store down")
+}
+
+func TestReplayGuardStoreError(t *testing.T) {
+	g := NewReplayGuard(failingNonceStore{})
+
+	y := New()
+	y.Use(g)
+	y.Add("/payments", new(OverrideResource))
+
+	if res := testRequest(y, "POST", "/payments", nonceHeader("0123456789abcdef", time.Now())); res.Code != 500 {
+		t.Errorf("Expected store errors to fail the request, got %d", res.Code)
+	}
+}
@@ -0,0 +1,213 @@
+package yarf
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidEncoding is returned by the normalization steps for paths with invalid percent-encodings.
+var ErrInvalidEncoding = errors.New("yarf: invalid percent-encoding in path")
+
+// NormalizeStep is a step of the URL normalization pipeline.
+// It receives the escaped request path, as sent by the client, and returns it normalized,
+// or an error rejecting the request: YError values are sent as is, other errors as 400 errors.
+type NormalizeStep func(p string) (string, error)
+
+// URLNormalizer is the URL normalization pipeline, run on the request path before the global middleware
+// and route matching, so every route, log and cache key sees the same canonical path.
+// StrictURLs() only applies the RFC 3986 normalizations that don't change the meaning of the path,
+// while LenientURLs() also fixes the paths sent by legacy clients.
+//
+//	y.URLs = yarf.LenientURLs()
+//	y.URLs.Steps = append(y.URLs.Steps, yarf.NormalizeUnicode(norm.NFC.String))
+type URLNormalizer struct {
+	// Steps are run in order on the escaped path.
+	Steps []NormalizeStep
+
+	// Redirect sends a redirect to the normalized URL when the path changed, instead of routing it.
+	// GET and HEAD requests get a 301 response, other methods a 308 one, so clients repeat the body.
+	Redirect bool
+}
+
+// StrictURLs creates a URLNormalizer applying the RFC 3986 normalizations:
+// percent-encoded unreserved characters are decoded, other percent-encodings uppercased,
+// and dot-segments removed. Invalid percent-encodings are rejected.
+func StrictURLs() *URLNormalizer {
+	return &URLNormalizer{
+		Steps: []NormalizeStep{DecodeUnreserved, RemoveDotSegments},
+	}
+}
+
+// LenientURLs creates a URLNormalizer tolerating legacy clients: on top of the StrictURLs() steps,
+// backslashes are converted into slashes and duplicate slashes collapsed.
+func LenientURLs() *URLNormalizer {
+	return &URLNormalizer{
+		Steps: []NormalizeStep{Backslashes, DecodeUnreserved, CollapseSlashes, RemoveDotSegments},
+	}
+}
+
+// normalize runs the steps on the escaped path.
+func (n *URLNormalizer) normalize(p string) (string, error) {
+	var err error
+	for _, step := range n.Steps {
+		if p, err = step(p); err != nil {
+			return "", sanitizeResult(err)
+		}
+	}
+
+	return p, nil
+}
+
+// normalizeURL runs the application URLNormalizer on the request path.
+func (y *Yarf) normalizeURL(c *Context) error {
+	if y.URLs == nil {
+		return nil
+	}
+
+	u := c.Request.URL
+	escaped := u.EscapedPath()
+	p, err := y.URLs.normalize(escaped)
+	if err != nil {
+		return err
+	}
+	if p == escaped {
+		return nil
+	}
+
+	decoded, err := url.PathUnescape(p)
+	if err != nil {
+		return sanitizeResult(ErrInvalidEncoding)
+	}
+
+	if y.URLs.Redirect {
+		target := *u
+		target.Path, target.RawPath = decoded, p
+
+		code := http.StatusPermanentRedirect
+		if c.Request.Method == "GET" || c.Request.Method == "HEAD" {
+			code = http.StatusMovedPermanently
+		}
+		return ErrorRedirect(target.RequestURI(), code)
+	}
+
+	u.Path, u.RawPath = decoded, p
+
+	return nil
+}
+
+// unreserved returns true for the characters RFC 3986 allows unencoded anywhere.
+func unreserved(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' ||
+		b == '-' || b == '.' || b == '_' || b == '~'
+}
+
+// unhex returns the value of a hexadecimal digit, or -1.
+func unhex(b byte) int {
+	switch {
+	case '0' <= b && b <= '9':
+		return int(b - '0')
+	case 'a' <= b && b <= 'f':
+		return int(b - 'a' + 10)
+	case 'A' <= b && b <= 'F':
+		return int(b - 'A' + 10)
+	}
+
+	return -1
+}
+
+// DecodeUnreserved decodes the percent-encoded unreserved characters, like "%7E" into "~",
+// and uppercases the other percent-encodings, like "%2f" into "%2F".
+// It returns ErrInvalidEncoding for invalid percent-encodings.
+func DecodeUnreserved(p string) (string, error) {
+	if !strings.Contains(p, "%") {
+		return p, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		if p[i] != '%' {
+			b.WriteByte(p[i])
+			continue
+		}
+		if i+2 >= len(p) || unhex(p[i+1]) < 0 || unhex(p[i+2]) < 0 {
+			return "", ErrInvalidEncoding
+		}
+
+		if v := byte(unhex(p[i+1])<<4 | unhex(p[i+2])); unreserved(v) {
+			b.WriteByte(v)
+		} else {
+			b.WriteString(strings.ToUpper(p[i : i+3]))
+		}
+		i += 2
+	}
+
+	return b.String(), nil
+}
+
+// RemoveDotSegments removes the "." and ".." segments, like "/static/../admin/." into "/admin/",
+// as in RFC 3986 section 5.2.4. Empty segments are kept.
+func RemoveDotSegments(p string) (string, error) {
+	if !strings.HasPrefix(p, "/") {
+		return p, nil
+	}
+
+	segments := strings.Split(p[1:], "/")
+	out := make([]string, 0, len(segments))
+
+	for i, s := range segments {
+		switch s {
+		case ".":
+		case "..":
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+		default:
+			out = append(out, s)
+			continue
+		}
+
+		// Keep the trailing slash of paths ending with a dot-segment
+		if i == len(segments)-1 {
+			out = append(out, "")
+		}
+	}
+
+	return "/" + strings.Join(out, "/"), nil
+}
+
+// CollapseSlashes replaces the runs of slashes with a single one, like "//users///42" into "/users/42".
+func CollapseSlashes(p string) (string, error) {
+	for strings.Contains(p, "//") {
+		p = strings.ReplaceAll(p, "//", "/")
+	}
+
+	return p, nil
+}
+
+// Backslashes converts the backslashes, raw or percent-encoded, into slashes, as sent by some Windows clients.
+func Backslashes(p string) (string, error) {
+	p = strings.ReplaceAll(p, `\`, "/")
+	p = strings.ReplaceAll(p, "%5C", "/")
+
+	return strings.ReplaceAll(p, "%5c", "/"), nil
+}
+
+// NormalizeUnicode creates a NormalizeStep applying a Unicode normalization form to the decoded path,
+// like norm.NFC.String from golang.org/x/text/unicode/norm, so equivalent characters route the same way.
+func NormalizeUnicode(form func(string) string) NormalizeStep {
+	return func(p string) (string, error) {
+		decoded, err := url.PathUnescape(p)
+		if err != nil {
+			return "", ErrInvalidEncoding
+		}
+
+		normalized := form(decoded)
+		if normalized == decoded {
+			return p, nil
+		}
+
+		return (&url.URL{Path: normalized}).EscapedPath(), nil
+	}
+}
@@ -0,0 +1,122 @@
+package yarf
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSteps(t *testing.T) {
+	for _, tc := range []struct {
+		step     NormalizeStep
+		path     string
+		expected string
+	}{
+		{DecodeUnreserved, "/users/%7Ejoe", "/users/~joe"},
+		{DecodeUnreserved, "/a%2fb/%c3%a9", "/a%2Fb/%C3%A9"},
+		{DecodeUnreserved, "/%2E%2e/admin", "/../admin"},
+		{RemoveDotSegments, "/static/../admin", "/admin"},
+		{RemoveDotSegments, "/a/./b/.", "/a/b/"},
+		{RemoveDotSegments, "/a/b/..", "/a/"},
+		{RemoveDotSegments, "/../../etc", "/etc"},
+		{RemoveDotSegments, "/a//b/../c", "/a//c"},
+		{RemoveDotSegments, "*", "*"},
+		{CollapseSlashes, "//users///42/", "/users/42/"},
+		{Backslashes, `/a\b%5cc%5Cd`, "/a/b/c/d"},
+		{NormalizeUnicode(strings.ToLower), "/Caf%C3%A9", "/caf%C3%A9"},
+		{NormalizeUnicode(strings.ToLower), "/cafe", "/cafe"},
+	} {
+		if p, err := tc.step(tc.path); p != tc.expected || err != nil {
+			t.Errorf("%s: expected '%s', got '%s' %v", tc.path, tc.expected, p, err)
+		}
+	}
+
+	for _, p := range []string{"/a%", "/a%2", "/a%zz"} {
+		if _, err := DecodeUnreserved(p); err != ErrInvalidEncoding {
+			t.Errorf("%s: expected ErrInvalidEncoding, got %v", p, err)
+		}
+	}
+}
+
+// PathResource renders the request path.
+type PathResource struct {
+	Resource
+}
+
+func (r *PathResource) Get(c *Context) error {
+	c.Render(c.Request.URL.Path + " " + c.Param("name"))
+	return nil
+}
+
+func (r *PathResource) Post(c *Context) error {
+	return r.Get(c)
+}
+
+func TestURLNormalizer(t *testing.T) {
+	y := New()
+	y.Add("/users/:name", new(PathResource))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+		return res
+	}
+
+	// Without normalization, dot-segments don't match
+	if res := get("/static/../users/joe"); res.Code != 404 {
+		t.Errorf("Expected 404 without normalization, got %d", res.Code)
+	}
+
+	y.URLs = StrictURLs()
+	if res := get("/static/../users/%7Ejoe"); res.Body.String() != "/users/~joe ~joe" {
+		t.Errorf("Expected normalized path, got %d '%s'", res.Code, res.Body.String())
+	}
+	if res := get(`/users\joe`); res.Code != 404 {
+		t.Errorf("Strict normalization shouldn't convert backslashes, got %d", res.Code)
+	}
+
+	y.URLs = LenientURLs()
+	if res := get(`//users\joe`); res.Body.String() != "/users/joe joe" {
+		t.Errorf("Expected lenient normalization, got %d '%s'", res.Code, res.Body.String())
+	}
+
+	// Redirects
+	y.URLs.Redirect = true
+	res := get("//users/./joe?tab=1")
+	if res.Code != 301 || res.Header().Get("Location") != "/users/joe?tab=1" {
+		t.Errorf("Expected redirect to the normalized URL, got %d '%s'", res.Code, res.Header().Get("Location"))
+	}
+	if res := get("/users/joe"); res.Code != 200 {
+		t.Errorf("Normalized paths shouldn't redirect, got %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("POST", "/users//joe", nil))
+	if res.Code != 308 {
+		t.Errorf("Expected 308 redirect for POST requests, got %d", res.Code)
+	}
+}
+
+func TestURLNormalizerReject(t *testing.T) {
+	y := New()
+	y.Add("/users/:name", new(PathResource))
+	y.URLs = StrictURLs()
+	y.URLs.Steps = append(y.URLs.Steps, func(p string) (string, error) {
+		if strings.Contains(p, "/admin") {
+			return "", ErrorForbidden()
+		}
+		if strings.Contains(p, ";") {
+			return "", errors.New("Path params not supported")
+		}
+		return p, nil
+	})
+
+	for path, code := range map[string]int{"/static/../admin": 403, "/users/joe;v=1": 400, "/users/joe": 200} {
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, httptest.NewRequest("GET", path, nil))
+		if res.Code != code {
+			t.Errorf("%s: expected %d, got %d", path, code, res.Code)
+		}
+	}
+}
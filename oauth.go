@@ -0,0 +1,384 @@
+package yarf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetaScopes is the RouteMeta key holding the OAuth2 scopes required by a route.
+const MetaScopes = "oauth.scopes"
+
+// Claims are the claims of a validated access token.
+// Numbers are decoded as json.Number.
+type Claims map[string]interface{}
+
+// String returns a string claim, or "" if it's missing or isn't a string.
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+
+	return s
+}
+
+// Strings returns a claim that can be a string or an array of strings, like "aud".
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+
+	return nil
+}
+
+// Subject returns the "sub" claim.
+func (c Claims) Subject() string {
+	return c.String("sub")
+}
+
+// Scopes returns the scopes granted to the token, from the space separated "scope" claim
+// or the "scp" array used by some providers.
+func (c Claims) Scopes() []string {
+	if s := c.String("scope"); s != "" {
+		return strings.Fields(s)
+	}
+
+	return c.Strings("scp")
+}
+
+// HasScope checks if the token was granted the scope.
+func (c Claims) HasScope(scope string) bool {
+	return containsString(c.Scopes(), scope)
+}
+
+// time returns a NumericDate claim.
+func (c Claims) time(name string) (time.Time, bool) {
+	var secs float64
+	switch v := c[name].(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		secs = f
+	case float64:
+		secs = v
+	default:
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, int64(secs*float64(time.Second))), true
+}
+
+// claimsKey is the Context storage key for the claims of the request token.
+type claimsKey struct{}
+
+// Claims returns the claims of the access token validated by the OAuth2 middleware,
+// or of the key authenticated by the APIKeyAuth middleware, or nil if there is none.
+// They can be used to resolve tenants with TenantFromClaim:
+//
+//	yarf.TenantFromClaim(func(c *yarf.Context) map[string]interface{} { return c.Claims() }, "tenant")
+func (c *Context) Claims() Claims {
+	claims, _ := c.get(claimsKey{}).(Claims)
+
+	return claims
+}
+
+// TokenValidator validates access tokens.
+type TokenValidator interface {
+	// Validate returns the claims of a valid token, or an error otherwise.
+	Validate(ctx context.Context, token string) (Claims, error)
+}
+
+// TokenValidatorFunc adapts a function into a TokenValidator.
+type TokenValidatorFunc func(ctx context.Context, token string) (Claims, error)
+
+// Validate calls f(ctx, token).
+func (f TokenValidatorFunc) Validate(ctx context.Context, token string) (Claims, error) {
+	return f(ctx, token)
+}
+
+// OAuth2 is a middleware implementing an OAuth2 resource server.
+// It validates the bearer token of the requests with a JWTValidator, an Introspection endpoint
+// or any TokenValidator, and stores the token claims in the Context.
+// The request Identity is set with the "sub" claim as subject and the "roles" claim as roles.
+// Routes declare the scopes they require with RouteMeta.Scopes().
+//
+//	v, err := yarf.NewOIDCValidator(ctx, "https://accounts.example.com", "orders-api")
+//	y.Use(yarf.NewOAuth2(v))
+//	y.AddRoute("/users", new(Users)).Scopes("read:users")
+//
+// Requests without a valid token get a 401 error and requests lacking a scope a 403 error,
+// with the WWW-Authenticate header describing the problem.
+type OAuth2 struct {
+	Middleware
+
+	// Validator checks the tokens.
+	Validator TokenValidator
+
+	// Optional lets requests without token through, without claims. Invalid tokens are still rejected.
+	Optional bool
+
+	// Realm sent in the WWW-Authenticate header.
+	Realm string
+}
+
+// NewOAuth2 creates an OAuth2 middleware requiring tokens validated by v.
+func NewOAuth2(v TokenValidator) *OAuth2 {
+	return &OAuth2{
+		Validator: v,
+	}
+}
+
+// Phase runs OAuth2 with the security middleware.
+func (m *OAuth2) Phase() Phase {
+	return PhaseSecurity
+}
+
+// PreDispatch validates the bearer token. CORS preflight requests, which never carry credentials, aren't checked.
+func (m *OAuth2) PreDispatch(c *Context) error {
+	if isPreflight(c.Request) {
+		return nil
+	}
+
+	token, ok := bearerToken(c.Request)
+	if !ok {
+		if m.Optional {
+			return nil
+		}
+		return bearerError(c, http.StatusUnauthorized, m.Realm, "", "")
+	}
+
+	claims, err := m.Validator.Validate(c.Request.Context(), token)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrUnknownKey) {
+			return bearerError(c, http.StatusUnauthorized, m.Realm, "invalid_token", "")
+		}
+		return err
+	}
+
+	c.set(claimsKey{}, claims)
+	c.SetIdentity(&Identity{
+		Subject: claims.Subject(),
+		Roles:   claims.Strings("roles"),
+		Source:  "oauth2",
+		Claims:  claims,
+	})
+
+	return nil
+}
+
+// bearerToken returns the bearer token of the Authorization header.
+func bearerToken(r *http.Request) (string, bool) {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return "", false
+	}
+
+	token := strings.TrimSpace(auth[7:])
+
+	return token, token != ""
+}
+
+// bearerError sets the WWW-Authenticate header and returns the 401 or 403 error.
+func bearerError(c *Context, status int, realm, code, scope string) error {
+	var params []string
+	if realm != "" {
+		params = append(params, `realm="`+realm+`"`)
+	}
+	if code != "" {
+		params = append(params, `error="`+code+`"`)
+	}
+	if scope != "" {
+		params = append(params, `scope="`+scope+`"`)
+	}
+
+	v := "Bearer"
+	if len(params) > 0 {
+		v += " " + strings.Join(params, ", ")
+	}
+	c.Response.Header().Set("WWW-Authenticate", v)
+
+	if status == http.StatusForbidden {
+		return ErrorForbidden()
+	}
+
+	return ErrorUnauthorized()
+}
+
+// Scopes declares the OAuth2 scopes required by the route, and returns the RouteMeta to allow chaining.
+// The token must be granted all of them. Routes requiring scopes reject requests without claims,
+// so they fail closed if no OAuth2 middleware covers them.
+//
+//	y.AddRoute("/users", new(Users)).Scopes("read:users")
+func (m *RouteMeta) Scopes(scopes ...string) *RouteMeta {
+	existing, _ := m.Get(MetaScopes).([]string)
+
+	return m.Set(MetaScopes, append(existing, scopes...))
+}
+
+// checkScopes checks the scopes required by the route against the request claims.
+func (r *route) checkScopes(c *Context) error {
+	scopes, _ := r.meta.Get(MetaScopes).([]string)
+	if len(scopes) == 0 {
+		return nil
+	}
+
+	claims := c.Claims()
+	if claims == nil {
+		return bearerError(c, http.StatusUnauthorized, "", "", "")
+	}
+
+	for _, s := range scopes {
+		if !claims.HasScope(s) {
+			return bearerError(c, http.StatusForbidden, "", "insufficient_scope", strings.Join(scopes, " "))
+		}
+	}
+
+	return nil
+}
+
+// Introspection is a TokenValidator asking an OAuth2 token introspection endpoint (RFC 7662) about the tokens.
+// Active tokens are cached until they expire or for CacheTTL, whichever comes first.
+type Introspection struct {
+	// URL of the introspection endpoint.
+	URL string
+
+	// ClientID and ClientSecret authenticate the resource server on the endpoint.
+	ClientID     string
+	ClientSecret string
+
+	// Client calls the endpoint. Defaults to a client with a 10 seconds timeout.
+	Client *http.Client
+
+	// CacheTTL is how long active tokens are cached. 0 disables the cache.
+	CacheTTL time.Duration
+
+	cache *MemoryCache
+	lock  sync.Mutex
+}
+
+// introspectionCacheSize is the number of active tokens cached.
+const introspectionCacheSize = 10000
+
+// NewIntrospection creates an Introspection validator for the endpoint and client credentials provided,
+// caching active tokens for a minute.
+func NewIntrospection(endpoint, clientID, clientSecret string) *Introspection {
+	return &Introspection{
+		URL:          endpoint,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Client:       &http.Client{Timeout: 10 * time.Second},
+		CacheTTL:     time.Minute,
+	}
+}
+
+// Validate asks the endpoint about the token, and returns its claims if it's active.
+func (v *Introspection) Validate(ctx context.Context, token string) (Claims, error) {
+	if claims, ok := v.cached(token); ok {
+		return claims, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", v.URL, strings.NewReader(url.Values{
+		"token":           {token},
+		"token_type_hint": {"access_token"},
+	}.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if v.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(v.ClientID), url.QueryEscape(v.ClientSecret))
+	}
+
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("introspection: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspection: status %d", res.StatusCode)
+	}
+
+	var claims Claims
+	dec := json.NewDecoder(res.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&claims); err != nil {
+		return nil, fmt.Errorf("introspection: %w", err)
+	}
+
+	if active, _ := claims["active"].(bool); !active {
+		return nil, fmt.Errorf("%w: inactive", ErrInvalidToken)
+	}
+	if exp, ok := claims.time("exp"); ok && time.Now().After(exp) {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+
+	v.store(token, claims)
+
+	return claims, nil
+}
+
+// tokens returns the cache of the active tokens, created on first use.
+func (v *Introspection) tokens() *MemoryCache {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if v.cache == nil {
+		v.cache = NewMemoryCache(introspectionCacheSize, v.CacheTTL)
+	}
+
+	return v.cache
+}
+
+// cached returns the cached claims of a token.
+func (v *Introspection) cached(token string) (Claims, bool) {
+	if v.CacheTTL <= 0 {
+		return nil, false
+	}
+
+	claims, ok := v.tokens().Get(token)
+	if !ok {
+		return nil, false
+	}
+
+	return claims.(Claims), true
+}
+
+// store caches the claims of an active token until it expires or for CacheTTL.
+func (v *Introspection) store(token string, claims Claims) {
+	if v.CacheTTL <= 0 {
+		return
+	}
+
+	ttl := v.CacheTTL
+	if exp, ok := claims.time("exp"); ok && time.Until(exp) < ttl {
+		ttl = time.Until(exp)
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	v.tokens().SetTTL(token, claims, ttl)
+}
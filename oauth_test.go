@@ -0,0 +1,193 @@
+package yarf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testTokens is a TokenValidator with fixed tokens.
+var testTokens = TokenValidatorFunc(func(ctx context.Context, token string) (Claims, error) {
+	switch token {
+	case "reader":
+		return Claims{"sub": "1", "scope": "read:users"}, nil
+	case "admin":
+		return Claims{"sub": "2", "scp": []interface{}{"read:users", "write:users"}}, nil
+	case "broken":
+		return nil, errors.New("validator down")
+	}
+
+	return nil, ErrInvalidToken
+})
+
+// ClaimsResource renders the subject of the token.
+type ClaimsResource struct {
+	Resource
+}
+
+func (r *ClaimsResource) Get(c *Context) error {
+	c.Render("sub:" + c.Claims().Subject())
+	return nil
+}
+
+func (r *ClaimsResource) Post(c *Context) error {
+	c.Render("created")
+	return nil
+}
+
+func TestOAuth2(t *testing.T) {
+	y := New()
+	m := NewOAuth2(testTokens)
+	m.Realm = "api"
+	y.Use(m)
+	y.Add("/me", new(ClaimsResource))
+
+	res := testRequest(y, "GET", "/me", map[string]string{"Authorization": "Bearer reader"})
+	if res.Code != 200 || res.Body.String() != "sub:1" {
+		t.Errorf("Valid token: %d %q", res.Code, res.Body.String())
+	}
+
+	res = testRequest(y, "GET", "/me", nil)
+	if res.Code != 401 || res.Header().Get("WWW-Authenticate") != `Bearer realm="api"` {
+		t.Errorf("Missing token: %d %q", res.Code, res.Header().Get("WWW-Authenticate"))
+	}
+
+	res = testRequest(y, "GET", "/me", map[string]string{"Authorization": "Bearer forged"})
+	if res.Code != 401 || res.Header().Get("WWW-Authenticate") != `Bearer realm="api", error="invalid_token"` {
+		t.Errorf("Invalid token: %d %q", res.Code, res.Header().Get("WWW-Authenticate"))
+	}
+
+	res = testRequest(y, "GET", "/me", map[string]string{"Authorization": "Bearer broken"})
+	if res.Code != 500 {
+		t.Errorf("Validator errors: status %d, expected 500", res.Code)
+	}
+
+	// Optional tokens
+	m.Optional = true
+	res = testRequest(y, "GET", "/me", nil)
+	if res.Code != 200 || res.Body.String() != "sub:" {
+		t.Errorf("Optional token: %d %q", res.Code, res.Body.String())
+	}
+	res = testRequest(y, "GET", "/me", map[string]string{"Authorization": "Bearer forged"})
+	if res.Code != 401 {
+		t.Errorf("Optional invalid token: status %d, expected 401", res.Code)
+	}
+}
+
+func TestOAuth2Scopes(t *testing.T) {
+	y := New()
+	y.Use(NewOAuth2(testTokens))
+	y.Use(NewCORS("*"))
+	y.AddRoute("/users", new(ClaimsResource)).Scopes("read:users")
+	y.AddRoute("/admin", new(ClaimsResource)).Scopes("read:users").Scopes("write:users")
+
+	for _, tc := range []struct {
+		path, token string
+		status      int
+	}{
+		{"/users", "reader", 200},
+		{"/users", "admin", 200},
+		{"/admin", "reader", 403},
+		{"/admin", "admin", 200},
+	} {
+		res := testRequest(y, "GET", tc.path, map[string]string{"Authorization": "Bearer " + tc.token})
+		if res.Code != tc.status {
+			t.Errorf("%s with %s: status %d, expected %d", tc.path, tc.token, res.Code, tc.status)
+		}
+		if res.Code == 403 && res.Header().Get("WWW-Authenticate") != `Bearer error="insufficient_scope", scope="read:users write:users"` {
+			t.Errorf("Unexpected WWW-Authenticate: %q", res.Header().Get("WWW-Authenticate"))
+		}
+	}
+
+	// Preflight requests skip authentication
+	res := testRequest(y, "OPTIONS", "/admin", map[string]string{
+		"Origin":                        "https://app.example.com",
+		"Access-Control-Request-Method": "GET",
+	})
+	if res.Code != 204 {
+		t.Errorf("Preflight: status %d, expected 204", res.Code)
+	}
+}
+
+func TestScopesFailClosed(t *testing.T) {
+	y := New()
+	y.AddRoute("/users", new(ClaimsResource)).Scopes("read:users")
+
+	res := testRequest(y, "GET", "/users", nil)
+	if res.Code != 401 {
+		t.Errorf("Status %d, expected 401", res.Code)
+	}
+}
+
+func TestIntrospection(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if id, secret, _ := r.BasicAuth(); id != "api" || secret != "s3cret" {
+			w.WriteHeader(401)
+			return
+		}
+		r.ParseForm()
+		if r.Form.Get("token") == "good" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"active": true,
+				"sub":    "user-1",
+				"scope":  "read:users",
+				"exp":    time.Now().Add(time.Hour).Unix(),
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]bool{"active": false})
+	}))
+	defer srv.Close()
+
+	v := NewIntrospection(srv.URL, "api", "s3cret")
+
+	for i := 0; i < 2; i++ {
+		claims, err := v.Validate(context.Background(), "good")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !claims.HasScope("read:users") || claims.Subject() != "user-1" {
+			t.Errorf("Unexpected claims: %v", claims)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Endpoint called %d times, expected 1", calls)
+	}
+
+	if _, err := v.Validate(context.Background(), "bad"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken, got %v", err)
+	}
+
+	v.ClientSecret = "wrong"
+	v.CacheTTL = 0
+	if _, err := v.Validate(context.Background(), "other"); err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Endpoint errors should be returned as is, got %v", err)
+	}
+}
+
+func TestClaims(t *testing.T) {
+	c := Claims{
+		"scope": "a b",
+		"aud":   []interface{}{"x", 1, "y"},
+		"exp":   json.Number("1700000000"),
+	}
+
+	if s := c.Scopes(); len(s) != 2 || !c.HasScope("b") || c.HasScope("c") {
+		t.Errorf("Unexpected scopes: %v", s)
+	}
+	if a := c.Strings("aud"); len(a) != 2 || a[1] != "y" {
+		t.Errorf("Unexpected audience: %v", a)
+	}
+	if exp, ok := c.time("exp"); !ok || exp.Unix() != 1700000000 {
+		t.Errorf("Unexpected exp: %v", exp)
+	}
+	if c.String("missing") != "" || c.Strings("missing") != nil {
+		t.Error("Missing claims should be empty")
+	}
+}
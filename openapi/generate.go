@@ -0,0 +1,211 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Documented is implemented by resources describing their methods for the OpenAPI document.
+type Documented interface {
+	Docs() Docs
+}
+
+// Docs maps the HTTP methods implemented by a resource to their documentation.
+type Docs map[string]Method
+
+// Method documents a resource method.
+// Request and Response are sample values of the body types, like User{} or []User{}.
+type Method struct {
+	Summary     string
+	Description string
+	OperationID string
+	Tags        []string
+	Deprecated  bool
+
+	// Query parameters
+	Query []Param
+
+	// Request body type
+	Request interface{}
+
+	// Success response body type and status code, 200 by default.
+	Response interface{}
+	Status   int
+
+	// Error responses descriptions, by status code.
+	Errors map[int]string
+}
+
+// Param documents a query parameter. Type is a sample value of the parameter type, string by default.
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+	Type        interface{}
+}
+
+// RouteLister lists the routes to document. It's implemented by *yarf.Yarf and *yarf.GroupRoute.
+type RouteLister interface {
+	Routes() []yarf.RouteInfo
+}
+
+// Generate builds the OpenAPI document for the routes provided.
+// It can run offline, to write the document into a file at build time.
+func Generate(routes RouteLister, info Info) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+	}
+	s := newSchemas()
+
+	for _, r := range routes.Routes() {
+		// Skip the documentation routes
+		switch r.Handler.(type) {
+		case *Resource, *UI:
+			continue
+		}
+
+		path, params := convertPattern(r.Pattern)
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{Parameters: params}
+			doc.Paths[path] = item
+		}
+		item.addHeaders(r.Meta.RequiredHeaders())
+
+		d, ok := r.Handler.(Documented)
+		if !ok {
+			continue
+		}
+
+		for method, m := range d.Docs() {
+			op := item.operation(strings.ToUpper(method))
+			if op == nil || *op != nil {
+				// Unknown method, or already documented by a previous route with the same path
+				continue
+			}
+			*op = newOperation(m, s)
+		}
+	}
+
+	if len(s.components) > 0 {
+		doc.Components = &Components{Schemas: s.components}
+	}
+
+	return doc
+}
+
+// convertPattern converts a route pattern into an OpenAPI path template and its parameters.
+// The catch-all wildcard becomes the {wildcard} parameter.
+func convertPattern(pattern string) (string, []*Parameter) {
+	var params []*Parameter
+
+	parts := strings.Split(pattern, "/")
+	for i, p := range parts {
+		name := ""
+		switch {
+		case strings.HasPrefix(p, ":"):
+			name = p[1:]
+		case p == "*":
+			name = "wildcard"
+		default:
+			continue
+		}
+
+		parts[i] = "{" + name + "}"
+		params = append(params, &Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	return strings.Join(parts, "/"), params
+}
+
+// addHeaders adds the request headers required by a route to the path parameters, once.
+func (p *PathItem) addHeaders(headers []yarf.HeaderRequirement) {
+	for _, h := range headers {
+		if p.hasHeader(h.Name) {
+			continue
+		}
+
+		param := &Parameter{
+			Name:     h.Name,
+			In:       "header",
+			Required: true,
+			Schema:   &Schema{Type: "string", Pattern: h.Regexp()},
+		}
+		if h.Pattern != "" {
+			param.Description = "Must match " + h.Pattern
+		}
+		p.Parameters = append(p.Parameters, param)
+	}
+}
+
+// hasHeader checks if the path parameters include the header.
+func (p *PathItem) hasHeader(name string) bool {
+	for _, param := range p.Parameters {
+		if param.In == "header" && param.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newOperation builds the operation for a documented method.
+func newOperation(m Method, s *schemas) *Operation {
+	op := &Operation{
+		Summary:     m.Summary,
+		Description: m.Description,
+		OperationID: m.OperationID,
+		Tags:        m.Tags,
+		Deprecated:  m.Deprecated,
+		Responses:   make(map[string]*Response),
+	}
+
+	for _, q := range m.Query {
+		schema := s.of(q.Type)
+		if schema == nil {
+			schema = &Schema{Type: "string"}
+		}
+
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        q.Name,
+			In:          "query",
+			Description: q.Description,
+			Required:    q.Required,
+			Schema:      schema,
+		})
+	}
+
+	if m.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: s.of(m.Request)}},
+		}
+	}
+
+	status := m.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	res := &Response{Description: http.StatusText(status)}
+	if m.Response != nil {
+		res.Content = map[string]*MediaType{"application/json": {Schema: s.of(m.Response)}}
+	}
+	op.Responses[strconv.Itoa(status)] = res
+
+	for code, desc := range m.Errors {
+		op.Responses[strconv.Itoa(code)] = &Response{Description: desc}
+	}
+
+	return op
+}
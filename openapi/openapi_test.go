@@ -0,0 +1,147 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yarf-framework/yarf"
+)
+
+type Address struct {
+	City string `json:"city"`
+}
+
+type User struct {
+	ID       int64             `json:"id" doc:"User ID"`
+	Name     string            `json:"name"`
+	Email    string            `json:"email,omitempty"`
+	Status   string            `json:"status" enum:"active,blocked"`
+	Created  time.Time         `json:"created"`
+	Address  *Address          `json:"address,omitempty"`
+	Friends  []*User           `json:"friends,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Password string            `json:"-"`
+}
+
+type UserResource struct {
+	yarf.Resource
+}
+
+func (r *UserResource) Docs() Docs {
+	return Docs{
+		"GET": {
+			Summary:  "Get a user",
+			Query:    []Param{{Name: "full", Type: true}},
+			Response: User{},
+			Errors:   map[int]string{404: "User not found"},
+		},
+		"put": {
+			Summary:  "Update a user",
+			Request:  User{},
+			Status:   204,
+			Response: nil,
+		},
+	}
+}
+
+type FilesResource struct {
+	yarf.Resource
+}
+
+func TestGenerate(t *testing.T) {
+	g := yarf.RouteGroup("/v1")
+	g.Add("/users/:id", new(UserResource))
+	g.Add("/files/*", new(FilesResource))
+
+	y := yarf.New()
+	y.AddGroup(g)
+
+	doc := Generate(y, Info{Title: "Test", Version: "1.0"})
+
+	item := doc.Paths["/v1/users/{id}"]
+	if item == nil || len(item.Parameters) != 1 || item.Parameters[0].Name != "id" || item.Parameters[0].In != "path" {
+		t.Fatalf("Unexpected path item: %+v", item)
+	}
+
+	get := item.Operation("GET")
+	if get == nil || get.Summary != "Get a user" || get.Parameters[0].Schema.Type != "boolean" {
+		t.Fatalf("Unexpected GET operation: %+v", get)
+	}
+	if get.Responses["200"].Content["application/json"].Schema.Ref != "#/components/schemas/User" {
+		t.Errorf("Response should reference the User schema: %+v", get.Responses["200"])
+	}
+	if get.Responses["404"].Description != "User not found" {
+		t.Error("Error responses should be documented")
+	}
+
+	put := item.Operation("PUT")
+	if put == nil || put.RequestBody == nil || put.Responses["204"] == nil {
+		t.Errorf("Unexpected PUT operation: %+v", put)
+	}
+
+	if _, ok := doc.Paths["/v1/files/{wildcard}"]; !ok {
+		t.Error("Undocumented routes should be listed")
+	}
+
+	user := doc.Components.Schemas["User"]
+	if user == nil || user.Properties["id"].Format != "int64" || user.Properties["id"].Description != "User ID" {
+		t.Fatalf("Unexpected User schema: %+v", user)
+	}
+	if user.Properties["created"].Format != "date-time" || len(user.Properties["status"].Enum) != 2 {
+		t.Errorf("Unexpected User properties: %+v", user.Properties)
+	}
+	if user.Properties["friends"].Items.Ref != "#/components/schemas/User" {
+		t.Error("Recursive types should reference their component")
+	}
+	if _, ok := user.Properties["Password"]; ok {
+		t.Error("Ignored fields shouldn't be documented")
+	}
+	if len(user.Required) != 4 {
+		t.Errorf("Expected 4 required fields, got %v", user.Required)
+	}
+	if doc.Components.Schemas["Address"] == nil {
+		t.Error("Nested structs should be components")
+	}
+}
+
+func TestGenerateRequiredHeaders(t *testing.T) {
+	y := yarf.New()
+	y.AddRoute("/users/:id", new(UserResource)).RequireHeader("X-Api-Version", "2.*").RequireHeader("if-match", "")
+
+	item := Generate(y, Info{Title: "Test", Version: "1.0"}).Paths["/users/{id}"]
+	if item == nil || len(item.Parameters) != 3 {
+		t.Fatalf("Unexpected path item: %+v", item)
+	}
+
+	version := item.Parameters[1]
+	if version.Name != "X-Api-Version" || version.In != "header" || !version.Required || version.Schema.Pattern != `^2\..*$` || version.Description != "Must match 2.*" {
+		t.Errorf("Unexpected version header: %+v %+v", version, version.Schema)
+	}
+	if match := item.Parameters[2]; match.Name != "If-Match" || match.Schema.Pattern != "" {
+		t.Errorf("Unexpected If-Match header: %+v %+v", match, match.Schema)
+	}
+}
+
+func TestResource(t *testing.T) {
+	y := yarf.New()
+	y.Add("/openapi.json", NewResource(y, Info{Title: "Test", Version: "1.0"}))
+	y.Add("/users/:id", new(UserResource))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/openapi.json", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	var doc Document
+	if err := json.Unmarshal(res.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != Version || doc.Info.Title != "Test" || doc.Paths["/users/{id}"] == nil {
+		t.Errorf("Unexpected document: %+v", doc)
+	}
+	if _, ok := doc.Paths["/openapi.json"]; ok {
+		t.Error("The document route shouldn't be documented")
+	}
+}
@@ -0,0 +1,51 @@
+package openapi
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Resource serves the OpenAPI document as JSON.
+// The document is generated on the first request, once all the routes have been added.
+type Resource struct {
+	yarf.Resource
+
+	routes RouteLister
+	info   Info
+
+	once    sync.Once
+	encoded []byte
+	err     error
+}
+
+// NewResource creates a Resource documenting the routes provided.
+//
+//	y.Add("/openapi.json", openapi.NewResource(y, openapi.Info{Title: "My API", Version: "1.0"}))
+func NewResource(routes RouteLister, info Info) *Resource {
+	return &Resource{
+		routes: routes,
+		info:   info,
+	}
+}
+
+// Document returns the generated OpenAPI document.
+func (r *Resource) Document() *Document {
+	return Generate(r.routes, r.info)
+}
+
+// Get renders the OpenAPI document.
+func (r *Resource) Get(c *yarf.Context) error {
+	r.once.Do(func() {
+		r.encoded, r.err = json.Marshal(r.Document())
+	})
+	if r.err != nil {
+		return r.err
+	}
+
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.Response.Write(r.encoded)
+
+	return nil
+}
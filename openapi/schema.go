@@ -0,0 +1,149 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemas builds Schema objects from Go types, keeping named structs as reusable components.
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+// newSchemas creates an empty schema builder.
+func newSchemas() *schemas {
+	return &schemas{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+// of returns the schema for the type of the sample value provided.
+func (s *schemas) of(sample interface{}) *Schema {
+	if sample == nil {
+		return nil
+	}
+
+	return s.schema(reflect.TypeOf(sample))
+}
+
+// schema returns the schema for a type.
+// Struct fields are named after their json tag, and described by the doc tag.
+// Fields without the omitempty option are required, and the enum tag lists the allowed values:
+//
+//	Status string `json:"status" doc:"Account status" enum:"active,blocked"`
+func (s *schemas) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer"}
+	case reflect.Int32, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + s.component(t)}
+	}
+
+	// Interfaces and other types accept any value
+	return &Schema{}
+}
+
+// component registers a named struct as a component and returns its name.
+func (s *schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := s.components[name]; taken {
+		name = strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + name
+	}
+
+	// Register before building, so recursive types reference it
+	s.names[t] = name
+	s.components[name] = &Schema{}
+	*s.components[name] = *s.object(t)
+
+	return name
+}
+
+// object builds the schema of a struct type.
+func (s *schemas) object(t reflect.Type) *Schema {
+	o := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.fields(t, o)
+
+	return o
+}
+
+// fields adds the struct fields to the object schema, flattening embedded structs.
+func (s *schemas) fields(t reflect.Type, o *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.fields(ft, o)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := s.schema(f.Type)
+		fs.Description = f.Tag.Get("doc")
+		for _, v := range strings.Split(f.Tag.Get("enum"), ",") {
+			if v != "" {
+				fs.Enum = append(fs.Enum, v)
+			}
+		}
+
+		o.Properties[name] = fs
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			o.Required = append(o.Required, name)
+		}
+	}
+}
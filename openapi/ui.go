@@ -0,0 +1,93 @@
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+	"strings"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Default asset locations of the API explorers.
+const (
+	SwaggerUIAssets = "https://unpkg.com/swagger-ui-dist@5"
+	RedocAssets     = "https://unpkg.com/redoc@2"
+)
+
+//go:embed ui.html
+var uiPage string
+
+var uiTemplate = template.Must(template.New("ui").Parse(uiPage))
+
+// UI serves an interactive API explorer page, Swagger UI or Redoc, bound to an OpenAPI document URL.
+// The page is embedded and loads the explorer assets from AssetsURL,
+// which can point to a self-hosted copy of the swagger-ui-dist or redoc packages.
+type UI struct {
+	yarf.Resource
+
+	// SpecURL is the URL of the OpenAPI document.
+	SpecURL string
+
+	// Title of the page.
+	Title string
+
+	// Redoc uses Redoc instead of Swagger UI.
+	Redoc bool
+
+	// AssetsURL is the base URL of the explorer assets. Defaults to SwaggerUIAssets or RedocAssets.
+	AssetsURL string
+}
+
+// NewUI creates a Swagger UI explorer for the OpenAPI document at specURL.
+func NewUI(specURL string) *UI {
+	return &UI{
+		SpecURL: specURL,
+		Title:   "API explorer",
+	}
+}
+
+// Get renders the explorer page.
+func (u *UI) Get(c *yarf.Context) error {
+	data := *u
+	if data.AssetsURL == "" {
+		data.AssetsURL = SwaggerUIAssets
+		if u.Redoc {
+			data.AssetsURL = RedocAssets
+		}
+	}
+	data.AssetsURL = strings.TrimSuffix(data.AssetsURL, "/")
+
+	var buf bytes.Buffer
+	if err := uiTemplate.Execute(&buf, &data); err != nil {
+		return err
+	}
+
+	c.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
+	c.Response.Write(buf.Bytes())
+
+	return nil
+}
+
+// Group creates a route group serving the API explorer on prefix, and the OpenAPI document of the routes
+// on prefix + "/openapi.json". The middleware provided is inserted into the group to protect both.
+//
+//	y.AddGroup(openapi.Group("/docs", y, openapi.Info{Title: "My API", Version: "1.0"}, new(AdminAuth)))
+func Group(prefix string, routes RouteLister, info Info, middleware ...yarf.MiddlewareHandler) *yarf.GroupRoute {
+	g := yarf.RouteGroup(prefix)
+	for _, m := range middleware {
+		g.Insert(m)
+	}
+
+	specURL := strings.TrimSuffix(prefix, "/") + "/openapi.json"
+
+	ui := NewUI(specURL)
+	if info.Title != "" {
+		ui.Title = info.Title
+	}
+
+	g.Add("/", ui)
+	g.Add("/openapi.json", NewResource(routes, info))
+
+	return g
+}
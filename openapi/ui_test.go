@@ -0,0 +1,70 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+)
+
+type DocsAuth struct {
+	yarf.Middleware
+}
+
+func (m *DocsAuth) PreDispatch(c *yarf.Context) error {
+	if c.Request.Header.Get("X-Token") != "secret" {
+		return &yarf.CustomError{HTTPCode: 401, ErrorMsg: "Unauthorized"}
+	}
+
+	return nil
+}
+
+func TestGroup(t *testing.T) {
+	y := yarf.New()
+	y.Add("/users/:id", new(UserResource))
+	y.AddGroup(Group("/docs", y, Info{Title: "Users API", Version: "1.0"}, new(DocsAuth)))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/docs", nil)
+	req.Header.Set("X-Token", "secret")
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	body := res.Body.String()
+	if res.Code != 200 || !strings.Contains(body, "SwaggerUIBundle") || !strings.Contains(body, `"\/docs\/openapi.json"`) {
+		t.Errorf("Unexpected explorer page %d: %s", res.Code, body)
+	}
+	if !strings.Contains(body, "<title>Users API</title>") {
+		t.Error("The page should use the API title")
+	}
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/docs/openapi.json", nil)
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 401 {
+		t.Errorf("Expected 401 without auth, got %d", res.Code)
+	}
+
+	doc := Generate(y, Info{})
+	if _, ok := doc.Paths["/docs"]; ok {
+		t.Error("The explorer route shouldn't be documented")
+	}
+}
+
+func TestRedocUI(t *testing.T) {
+	ui := NewUI("/openapi.json")
+	ui.Redoc = true
+
+	y := yarf.New()
+	y.Add("/docs", ui)
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/docs", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if !strings.Contains(res.Body.String(), RedocAssets+"/bundles/redoc.standalone.js") {
+		t.Errorf("Unexpected Redoc page: %s", res.Body.String())
+	}
+}
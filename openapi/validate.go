@@ -0,0 +1,578 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Parse decodes an OpenAPI document in JSON format.
+func Parse(data []byte) (*Document, error) {
+	doc := new(Document)
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// FieldError is a validation failure of a request or response value.
+type FieldError struct {
+	In      string `json:"in"`                // "path", "query", "header" or "body"
+	Param   string `json:"param,omitempty"`   // Parameter name
+	Pointer string `json:"pointer,omitempty"` // JSON pointer to the invalid body value
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by the Validator with the list of failures,
+// rendered as JSON to the client.
+type ValidationError struct {
+	yarf.CustomError
+
+	Errors []FieldError
+}
+
+// newValidationError creates a ValidationError with the status code provided.
+func newValidationError(code int, errs []FieldError) *ValidationError {
+	e := &ValidationError{Errors: errs}
+	e.HTTPCode = code
+	e.ErrorMsg = "Validation failed"
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"error":  e.ErrorMsg,
+		"errors": errs,
+	})
+	e.ErrorBody = string(body)
+
+	return e
+}
+
+// compiledPath is a document path split into segments for matching.
+type compiledPath struct {
+	parts []string
+	item  *PathItem
+}
+
+// match returns the path params if the request path matches.
+func (p compiledPath) match(parts []string) (map[string]string, bool) {
+	if len(parts) != len(p.parts) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, part := range p.parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			params[part[1:len(part)-1]] = parts[i]
+			continue
+		}
+		if part != parts[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// Validator is a middleware validating requests against an OpenAPI document.
+// Invalid parameters and malformed JSON bodies get a 400 error, and bodies not matching the schema a 422 error,
+// listing the failures with the parameter names and the JSON pointers to the invalid values.
+// Requests to paths or methods not declared in the document aren't validated.
+type Validator struct {
+	yarf.Middleware
+
+	// Responses enables the validation of JSON responses, replacing invalid ones with a 500 error.
+	// Responses are buffered, so enable it on development environments.
+	Responses bool
+
+	doc   *Document
+	paths []compiledPath
+
+	patterns map[string]*regexp.Regexp
+	lock     sync.Mutex
+}
+
+// NewValidator creates a Validator for the document provided.
+func NewValidator(doc *Document) *Validator {
+	v := &Validator{
+		doc:      doc,
+		patterns: make(map[string]*regexp.Regexp),
+	}
+
+	for path, item := range doc.Paths {
+		v.paths = append(v.paths, compiledPath{splitPath(path), item})
+	}
+
+	// Literal segments first, so /users/me matches before /users/{id}
+	sort.SliceStable(v.paths, func(i, j int) bool {
+		return strings.Count(strings.Join(v.paths[i].parts, "/"), "{") < strings.Count(strings.Join(v.paths[j].parts, "/"), "{")
+	})
+
+	return v
+}
+
+// splitPath splits a path into its non empty segments.
+func splitPath(path string) []string {
+	var parts []string
+	for _, p := range strings.Split(path, "/") {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+
+	return parts
+}
+
+// find returns the document path item and operation for the request, with the path params.
+func (v *Validator) find(r *http.Request) (*PathItem, *Operation, map[string]string) {
+	parts := splitPath(r.URL.Path)
+
+	for _, p := range v.paths {
+		params, ok := p.match(parts)
+		if !ok {
+			continue
+		}
+
+		op := p.item.Operation(r.Method)
+		if op == nil {
+			return nil, nil, nil
+		}
+
+		return p.item, op, params
+	}
+
+	return nil, nil, nil
+}
+
+// PreDispatch validates the request.
+func (v *Validator) PreDispatch(c *yarf.Context) error {
+	item, op, pathParams := v.find(c.Request)
+	if op == nil {
+		return nil
+	}
+
+	var errs []FieldError
+
+	// Parameters, operation level overriding path level
+	params := make(map[string]*Parameter)
+	for _, list := range [][]*Parameter{item.Parameters, op.Parameters} {
+		for _, p := range list {
+			params[p.In+":"+p.Name] = p
+		}
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		p := params[k]
+
+		var value string
+		var ok bool
+		switch p.In {
+		case "path":
+			value, ok = pathParams[p.Name]
+		case "query":
+			var values []string
+			values, ok = c.Request.URL.Query()[p.Name]
+			if ok {
+				value = values[0]
+			}
+		case "header":
+			value = c.Request.Header.Get(p.Name)
+			ok = value != ""
+		default:
+			continue
+		}
+
+		if !ok {
+			if p.Required {
+				errs = append(errs, FieldError{In: p.In, Param: p.Name, Message: "required parameter is missing"})
+			}
+			continue
+		}
+
+		if p.Schema != nil {
+			if msg := v.param(p.Schema, value); msg != "" {
+				errs = append(errs, FieldError{In: p.In, Param: p.Name, Message: msg})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return v.fail(c, http.StatusBadRequest, errs)
+	}
+
+	// Body
+	if op.RequestBody != nil {
+		if err := v.body(c, op.RequestBody); err != nil {
+			return err
+		}
+	}
+
+	if v.Responses {
+		c.Response = &recorder{ResponseWriter: c.Response, header: make(http.Header), op: op}
+	}
+
+	return nil
+}
+
+// body validates the request body.
+func (v *Validator) body(c *yarf.Context, rb *RequestBody) error {
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		if rb.Required {
+			return v.fail(c, http.StatusBadRequest, []FieldError{{In: "body", Message: "request body is required"}})
+		}
+		return nil
+	}
+
+	mt := mediaType(rb.Content, c.Request.Header.Get("Content-Type"))
+	if mt == nil || mt.Schema == nil {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return v.fail(c, http.StatusBadRequest, []FieldError{{In: "body", Message: "invalid JSON: " + err.Error()}})
+	}
+
+	var errs []FieldError
+	v.validate(mt.Schema, value, "", "body", &errs)
+	if len(errs) > 0 {
+		return v.fail(c, http.StatusUnprocessableEntity, errs)
+	}
+
+	return nil
+}
+
+// mediaType returns the JSON media type of a content map for the content type provided, if any.
+func mediaType(content map[string]*MediaType, contentType string) *MediaType {
+	ct, _, _ := strings.Cut(contentType, ";")
+	ct = strings.TrimSpace(ct)
+
+	if mt := content[ct]; mt != nil && strings.Contains(ct, "json") {
+		return mt
+	}
+
+	return content["application/json"]
+}
+
+// fail returns a ValidationError, setting the JSON content type on the response.
+func (v *Validator) fail(c *yarf.Context, code int, errs []FieldError) error {
+	c.Response.Header().Set("Content-Type", "application/json")
+	return newValidationError(code, errs)
+}
+
+// PostDispatch validates the response and sends it to the client.
+func (v *Validator) PostDispatch(c *yarf.Context) error {
+	rec, ok := c.Response.(*recorder)
+	if !ok {
+		return nil
+	}
+	c.Response = rec.ResponseWriter
+
+	if errs := v.response(rec.op, rec); len(errs) > 0 {
+		c.Response.Header().Set("Content-Type", "application/json")
+		return newValidationError(http.StatusInternalServerError, errs)
+	}
+
+	rec.flush()
+
+	return nil
+}
+
+// End sends the buffered response if the request failed before PostDispatch.
+func (v *Validator) End(c *yarf.Context) error {
+	if rec, ok := c.Response.(*recorder); ok {
+		c.Response = rec.ResponseWriter
+		rec.flush()
+	}
+
+	return nil
+}
+
+// response validates a recorded response against the operation responses.
+func (v *Validator) response(op *Operation, rec *recorder) []FieldError {
+	if op == nil {
+		return nil
+	}
+
+	code := rec.code
+	if code == 0 {
+		code = http.StatusOK
+	}
+
+	res := op.Responses[strconv.Itoa(code)]
+	if res == nil {
+		res = op.Responses[strconv.Itoa(code/100)+"XX"]
+	}
+	if res == nil {
+		res = op.Responses["default"]
+	}
+	if res == nil {
+		return []FieldError{{In: "response", Message: fmt.Sprintf("undocumented status code %d", code)}}
+	}
+
+	mt := mediaType(res.Content, rec.header.Get("Content-Type"))
+	if mt == nil || mt.Schema == nil || rec.body.Len() == 0 {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(rec.body.Bytes(), &value); err != nil {
+		return []FieldError{{In: "response", Message: "invalid JSON: " + err.Error()}}
+	}
+
+	var errs []FieldError
+	v.validate(mt.Schema, value, "", "response", &errs)
+
+	return errs
+}
+
+// param validates a parameter string value, converted to the schema type.
+func (v *Validator) param(s *Schema, value string) string {
+	s = v.resolve(s)
+
+	var typed interface{} = value
+	switch s.Type {
+	case "integer", "number":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return typeMessage(s.Type)
+		}
+		typed = f
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "must be a boolean"
+		}
+		typed = b
+	}
+
+	var errs []FieldError
+	v.validate(s, typed, "", "", &errs)
+	if len(errs) > 0 {
+		return errs[0].Message
+	}
+
+	return ""
+}
+
+// resolve follows the schema reference to the document components.
+func (v *Validator) resolve(s *Schema) *Schema {
+	for s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		if v.doc.Components == nil || v.doc.Components.Schemas[name] == nil {
+			return &Schema{}
+		}
+		s = v.doc.Components.Schemas[name]
+	}
+
+	return s
+}
+
+// pattern returns the compiled regular expression, cached.
+func (v *Validator) pattern(expr string) *regexp.Regexp {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	re, ok := v.patterns[expr]
+	if !ok {
+		re, _ = regexp.Compile(expr)
+		v.patterns[expr] = re
+	}
+
+	return re
+}
+
+// validate checks a decoded JSON value against the schema, adding the failures to errs.
+func (v *Validator) validate(s *Schema, value interface{}, ptr, in string, errs *[]FieldError) {
+	s = v.resolve(s)
+
+	fail := func(msg string, args ...interface{}) {
+		*errs = append(*errs, FieldError{In: in, Pointer: ptr, Message: fmt.Sprintf(msg, args...)})
+	}
+
+	if value == nil {
+		if s.Type != "" && !s.Nullable {
+			fail("must not be null")
+		}
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("must be one of %v", s.Enum)
+			return
+		}
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				*errs = append(*errs, FieldError{In: in, Pointer: ptr + "/" + escapePointer(name), Message: "required property is missing"})
+			}
+		}
+
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			ps := s.Properties[name]
+			if ps == nil {
+				ps = s.AdditionalProperties
+			}
+			if ps != nil {
+				v.validate(ps, obj[name], ptr+"/"+escapePointer(name), in, errs)
+			}
+		}
+
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				v.validate(s.Items, item, ptr+"/"+strconv.Itoa(i), in, errs)
+			}
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		n := len([]rune(str))
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("must be at least %d characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("must be at most %d characters long", *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			if re := v.pattern(s.Pattern); re != nil && !re.MatchString(str) {
+				fail("must match the pattern %s", s.Pattern)
+			}
+		}
+
+	case "integer", "number":
+		f, ok := value.(float64)
+		if !ok {
+			fail("%s", typeMessage(s.Type))
+			return
+		}
+		if s.Type == "integer" && f != math.Trunc(f) {
+			fail("must be an integer")
+			return
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			fail("must be greater than or equal to %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			fail("must be less than or equal to %v", *s.Maximum)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+		}
+	}
+}
+
+// typeMessage describes a numeric type mismatch.
+func typeMessage(t string) string {
+	if t == "integer" {
+		return "must be an integer"
+	}
+
+	return "must be a number"
+}
+
+// escapePointer escapes a JSON pointer reference token.
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// recorder buffers the response to validate it before sending it.
+type recorder struct {
+	http.ResponseWriter
+
+	header http.Header
+	code   int
+	body   bytes.Buffer
+	op     *Operation
+}
+
+// Header returns the buffered header map.
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+// WriteHeader stores the status code, only the first call takes effect.
+// Informational codes, like 103 Early Hints, aren't responses to validate and are dropped.
+func (r *recorder) WriteHeader(code int) {
+	if r.code == 0 && (code >= 200 || code == http.StatusSwitchingProtocols) {
+		r.code = code
+	}
+}
+
+// Write appends data to the buffered body.
+func (r *recorder) Write(data []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+
+	return r.body.Write(data)
+}
+
+// flush sends the buffered response. Nothing is written if the handler didn't respond,
+// so errors can still be rendered.
+func (r *recorder) flush() {
+	h := r.ResponseWriter.Header()
+	for k, v := range r.header {
+		h[k] = v
+	}
+
+	if r.code == 0 {
+		return
+	}
+	r.ResponseWriter.WriteHeader(r.code)
+	r.ResponseWriter.Write(r.body.Bytes())
+}
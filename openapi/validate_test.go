@@ -0,0 +1,160 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+)
+
+const petsDoc = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Pets", "version": "1.0"},
+  "paths": {
+    "/pets/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+      "get": {
+        "parameters": [{"name": "fields", "in": "query", "schema": {"type": "string", "enum": ["name", "all"]}}],
+        "responses": {"200": {"description": "OK", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}
+      },
+      "put": {
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}},
+        "responses": {"204": {"description": "No content"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Pet": {
+        "type": "object",
+        "required": ["name"],
+        "properties": {
+          "name": {"type": "string", "minLength": 1},
+          "age": {"type": "integer", "minimum": 0},
+          "tags": {"type": "array", "items": {"type": "string"}}
+        }
+      }
+    }
+  }
+}`
+
+type PetResource struct {
+	yarf.Resource
+}
+
+func (r *PetResource) Get(c *yarf.Context) error {
+	c.Response.Header().Set("Content-Type", "application/json")
+	if c.Param("id") == "13" {
+		c.Render(`{"age": "old"}`)
+	} else {
+		c.Render(`{"name": "Rex"}`)
+	}
+	return nil
+}
+
+func (r *PetResource) Put(c *yarf.Context) error {
+	c.Response.WriteHeader(204)
+	return nil
+}
+
+func validationErrors(t *testing.T, body string) []FieldError {
+	var res struct {
+		Errors []FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		t.Fatalf("Invalid error body '%s': %s", body, err)
+	}
+
+	return res.Errors
+}
+
+func TestValidator(t *testing.T) {
+	doc, err := Parse([]byte(petsDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y := yarf.New()
+	y.Insert(NewValidator(doc))
+	y.Add("/pets/:id", new(PetResource))
+
+	tests := []struct {
+		method, path, body string
+		code               int
+		errors             []FieldError
+	}{
+		{"GET", "/pets/1", "", 200, nil},
+		{"GET", "/pets/abc?fields=foo", "", 400, []FieldError{
+			{In: "path", Param: "id", Message: "must be an integer"},
+			{In: "query", Param: "fields", Message: "must be one of [name all]"},
+		}},
+		{"PUT", "/pets/1", `{"name": "Rex", "age": 2, "tags": ["good"]}`, 204, nil},
+		{"PUT", "/pets/1", "", 400, []FieldError{{In: "body", Message: "request body is required"}}},
+		{"PUT", "/pets/1", `{"name": `, 400, nil},
+		{"PUT", "/pets/1", `{"age": -1, "tags": ["ok", 2]}`, 422, []FieldError{
+			{In: "body", Pointer: "/name", Message: "required property is missing"},
+			{In: "body", Pointer: "/age", Message: "must be greater than or equal to 0"},
+			{In: "body", Pointer: "/tags/1", Message: "must be a string"},
+		}},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, "http://localhost:8080"+test.path, strings.NewReader(test.body))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Code != test.code {
+			t.Errorf("%s %s: expected %d, got %d: %s", test.method, test.path, test.code, res.Code, res.Body.String())
+			continue
+		}
+		if test.errors == nil {
+			continue
+		}
+
+		errs := validationErrors(t, res.Body.String())
+		if len(errs) != len(test.errors) {
+			t.Errorf("%s %s: expected errors %+v, got %+v", test.method, test.path, test.errors, errs)
+			continue
+		}
+		for i := range errs {
+			if errs[i] != test.errors[i] {
+				t.Errorf("%s %s: expected error %+v, got %+v", test.method, test.path, test.errors[i], errs[i])
+			}
+		}
+	}
+}
+
+func TestValidatorResponses(t *testing.T) {
+	doc, _ := Parse([]byte(petsDoc))
+
+	v := NewValidator(doc)
+	v.Responses = true
+
+	y := yarf.New()
+	y.Insert(v)
+	y.Add("/pets/:id", new(PetResource))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/pets/1", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 200 || res.Body.String() != `{"name": "Rex"}` {
+		t.Errorf("Valid responses should be sent, got %d: %s", res.Code, res.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/pets/13", nil)
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 500 {
+		t.Fatalf("Invalid responses should fail, got %d: %s", res.Code, res.Body.String())
+	}
+	errs := validationErrors(t, res.Body.String())
+	if len(errs) != 2 || errs[0].Pointer != "/name" || errs[1].Pointer != "/age" {
+		t.Errorf("Unexpected response errors: %+v", errs)
+	}
+}
@@ -0,0 +1,179 @@
+// Package otel implements yarf.Metrics on top of an OpenTelemetry Meter,
+// so the framework metrics are exported by the OpenTelemetry SDK configured by the application.
+// It's a separate module, so applications not using OpenTelemetry don't depend on it.
+//
+//	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+//	y.Metrics = otel.New(provider.Meter("yarf"))
+package otel
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Metrics creates the yarf instruments from a Meter: counters are Float64Counter instruments,
+// histograms Float64Histogram ones, and gauges Float64Gauge ones.
+// Label names are used as attribute keys.
+type Metrics struct {
+	// ErrorHandler receives the errors creating the instruments, like invalid names.
+	// Failed instruments discard their measurements. If nil, errors are ignored.
+	ErrorHandler func(error)
+
+	meter       metric.Meter
+	instruments map[string]interface{}
+	lock        sync.Mutex
+}
+
+// New creates a Metrics creating its instruments from the meter provided.
+func New(meter metric.Meter) *Metrics {
+	return &Metrics{
+		meter:       meter,
+		instruments: make(map[string]interface{}),
+	}
+}
+
+// Counter returns the counter named name, creating it if needed.
+func (m *Metrics) Counter(name, help string, labels ...string) yarf.Counter {
+	return m.instrument(name, func() interface{} {
+		c, err := m.meter.Float64Counter(name, metric.WithDescription(help), metric.WithUnit(unit(name)))
+		if err != nil {
+			m.fail(err)
+			c, _ = noop.Meter{}.Float64Counter(name)
+		}
+		return &counter{c, labels}
+	}).(yarf.Counter)
+}
+
+// Histogram returns the histogram named name, creating it if needed.
+func (m *Metrics) Histogram(name, help string, labels ...string) yarf.Histogram {
+	return m.instrument(name, func() interface{} {
+		h, err := m.meter.Float64Histogram(name, metric.WithDescription(help), metric.WithUnit(unit(name)))
+		if err != nil {
+			m.fail(err)
+			h, _ = noop.Meter{}.Float64Histogram(name)
+		}
+		return &histogram{h, labels}
+	}).(yarf.Histogram)
+}
+
+// Gauge returns the gauge named name, creating it if needed.
+func (m *Metrics) Gauge(name, help string, labels ...string) yarf.Gauge {
+	return m.instrument(name, func() interface{} {
+		g, err := m.meter.Float64Gauge(name, metric.WithDescription(help), metric.WithUnit(unit(name)))
+		if err != nil {
+			m.fail(err)
+			g, _ = noop.Meter{}.Float64Gauge(name)
+		}
+		return &gauge{g: g, labels: labels, values: make(map[attribute.Distinct]float64)}
+	}).(yarf.Gauge)
+}
+
+// instrument returns the instrument named name, creating it with create if needed.
+// Instruments keep the type and labels of their first creation.
+func (m *Metrics) instrument(name string, create func() interface{}) interface{} {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if i, ok := m.instruments[name]; ok {
+		return i
+	}
+
+	i := create()
+	m.instruments[name] = i
+
+	return i
+}
+
+// fail reports an instrument creation error.
+func (m *Metrics) fail(err error) {
+	if m.ErrorHandler != nil {
+		m.ErrorHandler(err)
+	}
+}
+
+// unit returns the unit of the metrics following the naming conventions of the framework, like "_seconds".
+func unit(name string) string {
+	switch {
+	case strings.HasSuffix(name, "_seconds"):
+		return "s"
+	case strings.HasSuffix(name, "_bytes"):
+		return "By"
+	}
+
+	return ""
+}
+
+// attributes pairs the label names with their values. Missing values are empty.
+func attributes(labels, values []string) attribute.Set {
+	kv := make([]attribute.KeyValue, len(labels))
+	for i, l := range labels {
+		v := ""
+		if i < len(values) {
+			v = values[i]
+		}
+		kv[i] = attribute.String(l, v)
+	}
+
+	return attribute.NewSet(kv...)
+}
+
+// counter is a yarf.Counter recording into a Float64Counter.
+type counter struct {
+	c      metric.Float64Counter
+	labels []string
+}
+
+// Add increments the counter.
+func (c *counter) Add(delta float64, labelValues ...string) {
+	c.c.Add(context.Background(), delta, metric.WithAttributeSet(attributes(c.labels, labelValues)))
+}
+
+// histogram is a yarf.Histogram recording into a Float64Histogram.
+type histogram struct {
+	h      metric.Float64Histogram
+	labels []string
+}
+
+// Observe records an observation.
+func (h *histogram) Observe(v float64, labelValues ...string) {
+	h.h.Record(context.Background(), v, metric.WithAttributeSet(attributes(h.labels, labelValues)))
+}
+
+// gauge is a yarf.Gauge recording into a Float64Gauge.
+// It keeps the current value of each attribute set, so Add can record the new value.
+type gauge struct {
+	g      metric.Float64Gauge
+	labels []string
+	values map[attribute.Distinct]float64
+	lock   sync.Mutex
+}
+
+// Set records the gauge value.
+func (g *gauge) Set(v float64, labelValues ...string) {
+	set := attributes(g.labels, labelValues)
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.values[set.Equivalent()] = v
+	g.g.Record(context.Background(), v, metric.WithAttributeSet(set))
+}
+
+// Add changes the gauge value by delta.
+func (g *gauge) Add(delta float64, labelValues ...string) {
+	set := attributes(g.labels, labelValues)
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	v := g.values[set.Equivalent()] + delta
+	g.values[set.Equivalent()] = v
+	g.g.Record(context.Background(), v, metric.WithAttributeSet(set))
+}
@@ -0,0 +1,105 @@
+package otel
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/yarf-framework/yarf"
+)
+
+type okResource struct {
+	yarf.Resource
+}
+
+func (r *okResource) Get(c *yarf.Context) error {
+	c.Render("OK")
+	return nil
+}
+
+// collect returns the metrics recorded, by name.
+func collect(t *testing.T, r *sdkmetric.ManualReader) map[string]metricdata.Metrics {
+	var rm metricdata.ResourceMetrics
+	if err := r.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := make(map[string]metricdata.Metrics)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m
+		}
+	}
+
+	return metrics
+}
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	m := New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("yarf"))
+
+	c := m.Counter("jobs_total", "Jobs done.", "queue")
+	c.Add(1, "mail")
+	c.Add(2, "mail")
+	m.Counter("jobs_total", "Ignored.", "queue").Add(1, "sms")
+
+	g := m.Gauge("workers", "Running workers.")
+	g.Set(5)
+	g.Add(-2)
+
+	h := m.Histogram("job_seconds", "Job durations.")
+	h.Observe(0.05)
+	h.Observe(3)
+
+	metrics := collect(t, reader)
+
+	sum, ok := metrics["jobs_total"].Data.(metricdata.Sum[float64])
+	if !ok || len(sum.DataPoints) != 2 || metrics["jobs_total"].Description != "Jobs done." {
+		t.Fatalf("Unexpected counter %+v", metrics["jobs_total"])
+	}
+	for _, dp := range sum.DataPoints {
+		queue, _ := dp.Attributes.Value(attribute.Key("queue"))
+		if queue.AsString() == "mail" && dp.Value != 3 || queue.AsString() == "sms" && dp.Value != 1 {
+			t.Errorf("Unexpected counter value %v for %s", dp.Value, queue.AsString())
+		}
+	}
+
+	gauge, ok := metrics["workers"].Data.(metricdata.Gauge[float64])
+	if !ok || len(gauge.DataPoints) != 1 || gauge.DataPoints[0].Value != 3 {
+		t.Errorf("Unexpected gauge %+v", metrics["workers"].Data)
+	}
+
+	hist, ok := metrics["job_seconds"].Data.(metricdata.Histogram[float64])
+	if !ok || len(hist.DataPoints) != 1 || hist.DataPoints[0].Count != 2 || metrics["job_seconds"].Unit != "s" {
+		t.Errorf("Unexpected histogram %+v", metrics["job_seconds"])
+	}
+}
+
+func TestMetricsRequests(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+
+	y := yarf.New()
+	y.Metrics = New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("yarf"))
+	y.Add("/items/:id", new(okResource))
+
+	y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items/1", nil))
+
+	if _, ok := collect(t, reader)["yarf_requests_total"]; !ok {
+		t.Error("Expected the request metrics recorded")
+	}
+}
+
+func TestMetricsInvalidName(t *testing.T) {
+	var errs []error
+	m := New(sdkmetric.NewMeterProvider().Meter("yarf"))
+	m.ErrorHandler = func(err error) { errs = append(errs, err) }
+
+	m.Counter("invalid name!", "").Add(1)
+	if len(errs) != 1 {
+		t.Errorf("Expected the creation error reported, got %v", errs)
+	}
+}
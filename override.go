@@ -0,0 +1,98 @@
+package yarf
+
+import (
+	"strings"
+)
+
+// MethodOverride is a middleware letting clients behind proxies that only forward GET and POST requests
+// send other methods, through the X-HTTP-Method-Override header. It's disabled unless added as global middleware,
+// so the method is changed before route matching:
+//
+//	y.Use(yarf.NewMethodOverride())
+//
+// Only requests with one of the Methods can be overridden, and only to one of the Targets.
+// Other overrides get a 400 error, so requests never run with a method the application didn't allow.
+// The headers are removed once applied, and every override is logged.
+type MethodOverride struct {
+	Middleware
+
+	// Headers carry the method, checked in order.
+	Headers []string
+
+	// Methods are the request methods that can be overridden.
+	Methods []string
+
+	// Targets are the methods allowed as overrides.
+	Targets []string
+}
+
+// NewMethodOverride creates a MethodOverride reading the X-HTTP-Method-Override header,
+// allowing POST requests to be overridden as PUT, PATCH or DELETE.
+func NewMethodOverride() *MethodOverride {
+	return &MethodOverride{
+		Headers: []string{"X-HTTP-Method-Override"},
+		Methods: []string{"POST"},
+		Targets: []string{"PUT", "PATCH", "DELETE"},
+	}
+}
+
+// Phase runs the override with the security middleware, before authorization checks the method.
+func (m *MethodOverride) Phase() Phase {
+	return PhaseSecurity
+}
+
+// overrideKey is the Context storage key for the method of the request before the override.
+type overrideKey struct{}
+
+// PreDispatch applies the method override of the request, if any.
+func (m *MethodOverride) PreDispatch(c *Context) error {
+	method := ""
+	for _, h := range m.Headers {
+		if v := strings.TrimSpace(c.Request.Header.Get(h)); v != "" && method == "" {
+			method = strings.ToUpper(v)
+		}
+		c.Request.Header.Del(h)
+	}
+	if method == "" || method == c.Request.Method {
+		return nil
+	}
+
+	from := c.Request.Method
+	if !containsMethod(m.Methods, from) || !containsMethod(m.Targets, method) {
+		if c.app != nil {
+			c.app.log().Info("method override rejected", "from", from, "to", method, "path", c.Request.URL.Path, "client_ip", c.GetClientIP())
+		}
+
+		e := ErrorBadRequest()
+		e.ErrorBody = "Method override not allowed"
+		return e
+	}
+
+	if c.app != nil {
+		c.app.log().Info("method override", "from", from, "to", method, "path", c.Request.URL.Path, "client_ip", c.GetClientIP())
+	}
+	c.set(overrideKey{}, from)
+	c.Request.Method = method
+
+	return nil
+}
+
+// containsMethod returns true if methods has the method.
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OriginalMethod returns the method the request was sent with, before a MethodOverride changed it.
+func (c *Context) OriginalMethod() string {
+	if m, ok := c.get(overrideKey{}).(string); ok {
+		return m
+	}
+
+	return c.Request.Method
+}
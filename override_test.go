@@ -0,0 +1,103 @@
+package yarf
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// OverrideResource renders the method handling the request and the original one.
+type OverrideResource struct {
+	Resource
+}
+
+func (r *OverrideResource) render(c *Context) error {
+	c.Render(c.Request.Method + " " + c.OriginalMethod())
+	return nil
+}
+
+func (r *OverrideResource) Get(c *Context) error    { return r.render(c) }
+func (r *OverrideResource) Post(c *Context) error   { return r.render(c) }
+func (r *OverrideResource) Delete(c *Context) error { return r.render(c) }
+func (r *OverrideResource) Patch(c *Context) error  { return r.render(c) }
+
+func TestMethodOverrideOptIn(t *testing.T) {
+	y := New()
+	y.Add("/items/:id", new(OverrideResource))
+
+	if res := testRequest(y, "POST", "/items/1", map[string]string{"X-HTTP-Method-Override": "DELETE"}); res.Body.String() != "POST POST" {
+		t.Errorf("Method override should be disabled by default, got '%s'", res.Body.String())
+	}
+}
+
+func TestMethodOverride(t *testing.T) {
+	l := new(MockLogger)
+	y := New()
+	y.Log = l
+	y.Use(NewMethodOverride())
+	y.Add("/items/:id", new(OverrideResource))
+
+	for _, tc := range []struct {
+		method, override string
+		code             int
+		body             string
+	}{
+		{"POST", "delete", 200, "DELETE POST"},
+		{"POST", "PATCH", 200, "PATCH POST"},
+		{"POST", "", 200, "POST POST"},
+		{"POST", "POST", 200, "POST POST"},
+		{"GET", "DELETE", 400, "Method override not allowed"},
+		{"POST", "CONNECT", 400, "Method override not allowed"},
+		{"POST", "<script>", 400, "Method override not allowed"},
+	} {
+		res := testRequest(y, tc.method, "/items/1", map[string]string{"X-HTTP-Method-Override": tc.override})
+		if res.Code != tc.code || res.Body.String() != tc.body {
+			t.Errorf("%s as %s: expected %d '%s', got %d '%s'", tc.method, tc.override, tc.code, tc.body, res.Code, res.Body.String())
+		}
+	}
+
+	var logged []string
+	for _, e := range l.entries {
+		if e.level == "info" {
+			logged = append(logged, e.msg)
+		}
+	}
+	if len(logged) != 5 || logged[0] != "method override" || logged[2] != "method override rejected" {
+		t.Errorf("Expected the overrides logged, got %+v", l.entries)
+	}
+}
+
+func TestMethodOverrideHeaders(t *testing.T) {
+	m := NewMethodOverride()
+	m.Headers = []string{"X-HTTP-Method", "X-HTTP-Method-Override"}
+
+	var seen string
+	y := New()
+	y.Use(m)
+	y.Add("/items/:id", new(OverrideResource))
+	y.Use(&headerSpy{name: "X-HTTP-Method-Override", value: &seen})
+
+	req := httptest.NewRequest("POST", "/items/1", nil)
+	req.Header.Set("X-HTTP-Method", "PATCH")
+	req.Header.Set("X-HTTP-Method-Override", "DELETE")
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Body.String() != "PATCH POST" {
+		t.Errorf("Expected the first header to win, got '%s'", res.Body.String())
+	}
+	if seen != "" {
+		t.Errorf("Expected the override headers removed, got '%s'", seen)
+	}
+}
+
+// headerSpy records a request header seen by the middleware.
+type headerSpy struct {
+	Middleware
+	name  string
+	value *string
+}
+
+func (m *headerSpy) PreDispatch(c *Context) error {
+	*m.value = c.Request.Header.Get(m.name)
+	return nil
+}
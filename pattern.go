@@ -0,0 +1,79 @@
+package yarf
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// PatternError describes an invalid route pattern, pointing to the segment at fault.
+type PatternError struct {
+	Pattern string // Route pattern or group prefix
+	Segment int    // Position of the invalid segment, from 1, ignoring empty segments
+	Part    string // Invalid segment
+	Reason  string
+}
+
+// Error returns the pattern, the invalid segment and the reason.
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("yarf: invalid route pattern %q: %s in segment %d %q", e.Pattern, e.Reason, e.Segment, e.Part)
+}
+
+// ValidatePattern checks a route pattern, as Add() does before registering it:
+// params need a name made of letters, digits and underscores, unique in the pattern,
+// and "*" wildcards must be whole segments.
+func ValidatePattern(pattern string) error {
+	return validatePattern(pattern, false)
+}
+
+// validatePattern checks a route pattern or, if group is true, a group prefix, which can't end with a catch-all.
+func validatePattern(pattern string, group bool) error {
+	parts := prepareURL(pattern)
+	params := make(map[string]int)
+
+	fail := func(i int, reason string) error {
+		return &PatternError{Pattern: pattern, Segment: i + 1, Part: parts[i], Reason: reason}
+	}
+
+	for i, p := range parts {
+		switch {
+		case p == "*":
+			if group && i == len(parts)-1 {
+				return fail(i, "catch-all in group prefix")
+			}
+
+		case p[0] == ':':
+			name := p[1:]
+			if name == "" {
+				return fail(i, "empty param name")
+			}
+			for _, r := range name {
+				if r == '*' {
+					return fail(i, "catch-all mixed with param")
+				}
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+					return fail(i, fmt.Sprintf("invalid character %q in param name", r))
+				}
+			}
+			if j, ok := params[name]; ok {
+				return fail(i, fmt.Sprintf("param %q already declared in segment %d", name, j+1))
+			}
+			params[name] = i
+
+		default:
+			for _, r := range p {
+				if r == '*' {
+					return fail(i, "catch-all mixed with other characters")
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// mustPattern panics with a *PatternError if the pattern is invalid.
+func mustPattern(pattern string, group bool) {
+	if err := validatePattern(pattern, group); err != nil {
+		panic(err)
+	}
+}
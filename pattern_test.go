@@ -0,0 +1,53 @@
+package yarf
+
+import (
+	"testing"
+)
+
+func TestValidatePattern(t *testing.T) {
+	for _, p := range []string{
+		"/", "", "/users/:id", "/users/:user_id/posts/:id", "/files/*", "/a/*/:param", "/a/b/*/d",
+		"/v1/items:batchGet", "//users//:id/", "/:名前",
+	} {
+		if err := ValidatePattern(p); err != nil {
+			t.Errorf("%s: unexpected error %v", p, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		pattern string
+		segment int
+		msg     string
+	}{
+		{"//:/", 1, `yarf: invalid route pattern "//:/": empty param name in segment 1 ":"`},
+		{"/users/:/posts", 2, `yarf: invalid route pattern "/users/:/posts": empty param name in segment 2 ":"`},
+		{"/users/:user-id", 2, `yarf: invalid route pattern "/users/:user-id": invalid character '-' in param name in segment 2 ":user-id"`},
+		{"/users/:id/posts/:id", 4, `yarf: invalid route pattern "/users/:id/posts/:id": param "id" already declared in segment 2 in segment 4 ":id"`},
+		{"/files/:path*", 2, `yarf: invalid route pattern "/files/:path*": catch-all mixed with param in segment 2 ":path*"`},
+		{"/assets/*.js", 2, `yarf: invalid route pattern "/assets/*.js": catch-all mixed with other characters in segment 2 "*.js"`},
+	} {
+		err := ValidatePattern(tc.pattern)
+		e, ok := err.(*PatternError)
+		if !ok || e.Segment != tc.segment || err.Error() != tc.msg {
+			t.Errorf("%s: expected '%s', got %v", tc.pattern, tc.msg, err)
+		}
+	}
+}
+
+func TestPatternPanics(t *testing.T) {
+	expectPanic := func(name string, f func()) {
+		defer func() {
+			if _, ok := recover().(*PatternError); !ok {
+				t.Errorf("%s: expected a *PatternError panic", name)
+			}
+		}()
+		f()
+	}
+
+	expectPanic("route", func() { New().Add("/users/:", new(OKResource)) })
+	expectPanic("group", func() { RouteGroup("/api/:v/:v") })
+	expectPanic("group catch-all", func() { RouteGroup("/static/*") })
+
+	// Catch-all in the middle of group prefixes are single segment wildcards
+	RouteGroup("/tenants/*/api")
+}
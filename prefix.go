@@ -0,0 +1,44 @@
+package yarf
+
+import (
+	"strings"
+)
+
+// StripPrefix sets a global path prefix, like "/service-a", for applications deployed behind an ingress path
+// that forwards the full request path. The router removes the prefix before matching the routes,
+// and Yarf.URL() and Context.URL() add it back, so routes are declared without it.
+// Requests outside the prefix don't match any route.
+// An empty prefix, the default, serves the routes at the root.
+// Call it before starting the servers.
+//
+//	y.StripPrefix(os.Getenv("PATH_PREFIX"))
+//	y.Add("/users/:id", new(User)) // Served at /service-a/users/:id
+func (y *Yarf) StripPrefix(prefix string) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix = "/" + prefix
+	}
+
+	y.prefix = prefix
+}
+
+// Prefix returns the global path prefix set with StripPrefix().
+func (y *Yarf) Prefix() string {
+	return y.prefix
+}
+
+// routePath removes the global prefix from a request path, and reports if the path is under it.
+func (y *Yarf) routePath(path string) (string, bool) {
+	if y.prefix == "" {
+		return path, true
+	}
+
+	if path == y.prefix {
+		return "/", true
+	}
+	if strings.HasPrefix(path, y.prefix+"/") {
+		return path[len(y.prefix):], true
+	}
+
+	return "", false
+}
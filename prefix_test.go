@@ -0,0 +1,94 @@
+package yarf
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// PrefixResource renders the route URL and the request URL.
+type PrefixResource struct {
+	Resource
+}
+
+func (r *PrefixResource) Get(c *Context) error {
+	u, err := c.URL("users.show", Params{"id": c.Param("id")})
+	if err != nil {
+		return err
+	}
+
+	c.Render(u + " " + c.RequestURL().Path)
+	return nil
+}
+
+func TestStripPrefixNormalization(t *testing.T) {
+	y := New()
+
+	for in, expected := range map[string]string{
+		"":            "",
+		"/":           "",
+		"service-a":   "/service-a",
+		"/service-a/": "/service-a",
+		"/a/b":        "/a/b",
+	} {
+		y.StripPrefix(in)
+		if y.Prefix() != expected {
+			t.Errorf("StripPrefix(%q): Prefix() = %q, expected %q", in, y.Prefix(), expected)
+		}
+	}
+}
+
+func TestStripPrefix(t *testing.T) {
+	for _, cache := range []bool{true, false} {
+		y := New()
+		y.UseCache = cache
+		y.StripPrefix("/service-a")
+		y.Add("/", new(OKResource))
+		y.AddRoute("/users/:id", new(PrefixResource)).Name("users.show")
+
+		for path, expected := range map[string]int{
+			"/service-a":           200,
+			"/service-a/":          200,
+			"/service-a/users/1":   200,
+			"/users/1":             404,
+			"/service-ab/users/1":  404,
+			"/other/service-a/":    404,
+			"/service-a/users/1/x": 404,
+		} {
+			// Twice, for the route cache
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest("GET", path, nil)
+				res := httptest.NewRecorder()
+				y.ServeHTTP(res, req)
+
+				if res.Code != expected {
+					t.Errorf("cache %v, %s: status %d, expected %d", cache, path, res.Code, expected)
+				}
+			}
+		}
+
+		req := httptest.NewRequest("GET", "/service-a/users/1", nil)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if body := res.Body.String(); body != "/service-a/users/1 /service-a/users/1" {
+			t.Errorf("Unexpected URLs: %s", body)
+		}
+	}
+}
+
+func TestStripPrefixWithBaseURL(t *testing.T) {
+	y := New()
+	y.StripPrefix("/service-a")
+	if err := y.SetBaseURL("https://example.com/edge"); err != nil {
+		t.Fatal(err)
+	}
+	y.AddRoute("/users/:id", new(PrefixResource)).Name("users.show")
+
+	req := httptest.NewRequest("GET", "/service-a/users/1", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if body := res.Body.String(); body != "/edge/service-a/users/1 /edge/service-a/users/1" {
+		t.Errorf("Unexpected URLs: %s", body)
+	}
+}
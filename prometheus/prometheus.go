@@ -0,0 +1,242 @@
+// Package prometheus implements yarf.Metrics, exposing the metrics in the Prometheus text format
+// without depending on the Prometheus client library.
+//
+//	reg := prometheus.NewRegistry()
+//	y.Metrics = reg
+//	y.Add("/metrics", yarf.FromHTTPHandler(reg))
+package prometheus
+
+import (
+	"bufio"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// DefaultBuckets are the histogram buckets used by default, in seconds, suited to request durations.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metric types
+const (
+	counterType   = "counter"
+	gaugeType     = "gauge"
+	histogramType = "histogram"
+)
+
+// Registry holds the metrics and serves them to Prometheus scrapes as a http.Handler.
+type Registry struct {
+	// Buckets are the upper bounds of the histogram buckets, sorted. If nil, DefaultBuckets are used.
+	Buckets []float64
+
+	metrics map[string]*metric
+	lock    sync.Mutex
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: make(map[string]*metric),
+	}
+}
+
+// Counter returns the counter named name, creating it if needed.
+func (r *Registry) Counter(name, help string, labels ...string) yarf.Counter {
+	return r.metric(name, help, counterType, labels)
+}
+
+// Histogram returns the histogram named name, creating it if needed.
+func (r *Registry) Histogram(name, help string, labels ...string) yarf.Histogram {
+	return r.metric(name, help, histogramType, labels)
+}
+
+// Gauge returns the gauge named name, creating it if needed.
+func (r *Registry) Gauge(name, help string, labels ...string) yarf.Gauge {
+	return r.metric(name, help, gaugeType, labels)
+}
+
+// metric returns the metric named name, creating it if needed.
+// Metrics keep the type and labels of their first creation.
+func (r *Registry) metric(name, help, kind string, labels []string) *metric {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if m, ok := r.metrics[name]; ok {
+		return m
+	}
+
+	m := &metric{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: r.Buckets,
+		series:  make(map[string]*series),
+	}
+	if m.buckets == nil {
+		m.buckets = DefaultBuckets
+	}
+	r.metrics[name] = m
+
+	return m
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	r.lock.Lock()
+	metrics := make([]*metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.lock.Unlock()
+
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].name < metrics[j].name
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	bw.Flush()
+}
+
+// series is a metric value for a set of label values.
+type series struct {
+	values []string
+
+	// Counters and gauges
+	value float64
+
+	// Histograms
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// metric implements the counters, gauges and histograms.
+type metric struct {
+	name, help, kind string
+	labels           []string
+	buckets          []float64
+
+	series map[string]*series
+	lock   sync.Mutex
+}
+
+// get returns the series of the label values, creating it if needed. The metric must be locked.
+func (m *metric) get(values []string) *series {
+	key := strings.Join(values, "\xff")
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		if m.kind == histogramType {
+			s.counts = make([]uint64, len(m.buckets))
+		}
+		m.series[key] = s
+	}
+
+	return s
+}
+
+// Add adds delta to a counter or gauge.
+func (m *metric) Add(delta float64, labelValues ...string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.get(labelValues).value += delta
+}
+
+// Set sets the value of a gauge.
+func (m *metric) Set(v float64, labelValues ...string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.get(labelValues).value = v
+}
+
+// Observe adds an observation to a histogram.
+func (m *metric) Observe(v float64, labelValues ...string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	s := m.get(labelValues)
+	for i, b := range m.buckets {
+		if v <= b {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+// write writes the metric in the text format.
+func (m *metric) write(w *bufio.Writer) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	keys := make([]string, 0, len(m.series))
+	for k := range m.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w.WriteString("# HELP " + m.name + " " + strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(m.help) + "\n")
+	w.WriteString("# TYPE " + m.name + " " + m.kind + "\n")
+
+	for _, k := range keys {
+		s := m.series[k]
+		if m.kind != histogramType {
+			w.WriteString(m.name + m.labelSet(s.values, "") + " " + formatFloat(s.value) + "\n")
+			continue
+		}
+
+		for i, b := range m.buckets {
+			w.WriteString(m.name + "_bucket" + m.labelSet(s.values, formatFloat(b)) + " " + strconv.FormatUint(s.counts[i], 10) + "\n")
+		}
+		w.WriteString(m.name + "_bucket" + m.labelSet(s.values, "+Inf") + " " + strconv.FormatUint(s.count, 10) + "\n")
+		w.WriteString(m.name + "_sum" + m.labelSet(s.values, "") + " " + formatFloat(s.sum) + "\n")
+		w.WriteString(m.name + "_count" + m.labelSet(s.values, "") + " " + strconv.FormatUint(s.count, 10) + "\n")
+	}
+}
+
+// labelValue escapes label values.
+var labelValue = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelSet formats the labels of a series, with the le label of histogram buckets if provided.
+func (m *metric) labelSet(values []string, le string) string {
+	var pairs []string
+	for i, l := range m.labels {
+		v := ""
+		if i < len(values) {
+			v = values[i]
+		}
+		pairs = append(pairs, l+`="`+labelValue.Replace(v)+`"`)
+	}
+	if le != "" {
+		pairs = append(pairs, `le="`+le+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatFloat formats values as Prometheus does.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
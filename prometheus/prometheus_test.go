@@ -0,0 +1,87 @@
+package prometheus
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+)
+
+type okResource struct {
+	yarf.Resource
+}
+
+func (r *okResource) Get(c *yarf.Context) error {
+	c.Render("OK")
+	return nil
+}
+
+func scrape(r *Registry) string {
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+
+	return res.Body.String()
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.Buckets = []float64{0.1, 1}
+
+	c := r.Counter("jobs_total", "Jobs done.", "queue")
+	c.Add(1, "mail")
+	c.Add(2, "mail")
+	r.Counter("jobs_total", "Ignored.", "queue").Add(1, `a"b`)
+
+	g := r.Gauge("workers", "Running workers.")
+	g.Set(5)
+	g.Add(-2)
+
+	h := r.Histogram("job_seconds", "Job durations.")
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(3)
+
+	expected := `# HELP job_seconds Job durations.
+# TYPE job_seconds histogram
+job_seconds_bucket{le="0.1"} 1
+job_seconds_bucket{le="1"} 2
+job_seconds_bucket{le="+Inf"} 3
+job_seconds_sum 3.55
+job_seconds_count 3
+# HELP jobs_total Jobs done.
+# TYPE jobs_total counter
+jobs_total{queue="a\"b"} 1
+jobs_total{queue="mail"} 3
+# HELP workers Running workers.
+# TYPE workers gauge
+workers 3
+`
+	if out := scrape(r); out != expected {
+		t.Errorf("Unexpected output:\n%s", out)
+	}
+}
+
+func TestRequestMetrics(t *testing.T) {
+	r := NewRegistry()
+
+	y := yarf.New()
+	y.Metrics = r
+	y.Add("/items/:id", new(okResource))
+
+	y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items/1", nil))
+	y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items/2", nil))
+	y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+
+	out := scrape(r)
+	for _, line := range []string{
+		`yarf_requests_total{method="GET",route="/items/:id",status="200"} 2`,
+		`yarf_requests_total{method="GET",route="",status="404"} 1`,
+		`yarf_request_duration_seconds_count{method="GET",route="/items/:id",status="200"} 2`,
+		`yarf_requests_in_flight 0`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("Missing %q in:\n%s", line, out)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProxyOptions configures the ProxyHandler.
+type ProxyOptions struct {
+	// Path is the upstream path, with :param placeholders replaced by the route params.
+	// If empty, the request path is forwarded.
+	//
+	//	y.Add("/users/:id", yarf.ProxyHandler(target, yarf.ProxyOptions{Path: "/v2/accounts/:id"}))
+	Path string
+
+	// StripPrefix is removed from the request path before forwarding it. Ignored if Path is set.
+	StripPrefix string
+
+	// PreserveHost forwards the request Host header instead of the target host.
+	PreserveHost bool
+
+	// Forwarded sets the X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto headers.
+	Forwarded bool
+
+	// RemoveHeaders are request headers not forwarded upstream, like cookies or internal credentials.
+	RemoveHeaders []string
+
+	// SetHeaders are request headers set on every upstream request.
+	SetHeaders map[string]string
+
+	// Timeout limits the whole upstream request, including the response body. Zero means no timeout.
+	Timeout time.Duration
+
+	// FlushInterval for streaming responses. Negative means flushing after each write.
+	FlushInterval time.Duration
+
+	// Transport used for upstream requests. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// ModifyResponse optionally changes the upstream response before it's copied to the client.
+	ModifyResponse func(*http.Response) error
+}
+
+// proxyErrKey is the request context key holding the upstream error.
+type proxyErrKey struct{}
+
+// proxyResource forwards requests to an upstream service.
+type proxyResource struct {
+	target *url.URL
+	opts   ProxyOptions
+	proxy  *httputil.ReverseProxy
+}
+
+// ProxyHandler creates a ResourceHandler forwarding all methods to the target URL, so Yarf can act as a gateway.
+// Request and response bodies are streamed.
+// Upstream failures return a 502 error, and upstream timeouts a 504 error, through the regular error handling.
+// The trace context and baggage are forwarded with the request span as the parent.
+func ProxyHandler(target *url.URL, opts ProxyOptions) ResourceHandler {
+	r := &proxyResource{
+		target: target,
+		opts:   opts,
+	}
+
+	r.proxy = &httputil.ReverseProxy{
+		Rewrite:        r.rewrite,
+		Transport:      opts.Transport,
+		FlushInterval:  opts.FlushInterval,
+		ModifyResponse: opts.ModifyResponse,
+		ErrorHandler: func(rw http.ResponseWriter, req *http.Request, err error) {
+			if p, ok := req.Context().Value(proxyErrKey{}).(*error); ok {
+				*p = err
+			}
+		},
+	}
+
+	return r
+}
+
+// rewrite builds the upstream request.
+func (r *proxyResource) rewrite(pr *httputil.ProxyRequest) {
+	pr.SetURL(r.target)
+
+	if r.opts.PreserveHost {
+		pr.Out.Host = pr.In.Host
+	}
+	if r.opts.Forwarded {
+		pr.SetXForwarded()
+	}
+
+	for _, h := range r.opts.RemoveHeaders {
+		pr.Out.Header.Del(h)
+	}
+	for k, v := range r.opts.SetHeaders {
+		pr.Out.Header.Set(k, v)
+	}
+}
+
+// path resolves the upstream request path.
+func (r *proxyResource) path(c *Context) string {
+	if r.opts.Path == "" {
+		return strings.TrimPrefix(c.Request.URL.Path, r.opts.StripPrefix)
+	}
+
+	parts := strings.Split(r.opts.Path, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, ":") {
+			parts[i] = url.PathEscape(c.Param(p[1:]))
+		}
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// forward sends the request upstream and maps the errors.
+func (r *proxyResource) forward(c *Context) error {
+	var proxyErr error
+
+	ctx := context.WithValue(c.Request.Context(), proxyErrKey{}, &proxyErr)
+	if r.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.opts.Timeout)
+		defer cancel()
+	}
+
+	req := c.Request.Clone(ctx)
+	req.URL.Path = r.path(c)
+	req.URL.RawPath = ""
+	c.InjectTrace(req.Header)
+
+	r.proxy.ServeHTTP(c.Response, req)
+
+	if proxyErr == nil {
+		return nil
+	}
+
+	var ne net.Error
+	if errors.Is(proxyErr, context.DeadlineExceeded) || (errors.As(proxyErr, &ne) && ne.Timeout()) {
+		return ErrorGatewayTimeout()
+	}
+
+	return ErrorBadGateway()
+}
+
+// Get forwards the request.
+func (r *proxyResource) Get(c *Context) error {
+	return r.forward(c)
+}
+
+// Post forwards the request.
+func (r *proxyResource) Post(c *Context) error {
+	return r.forward(c)
+}
+
+// Put forwards the request.
+func (r *proxyResource) Put(c *Context) error {
+	return r.forward(c)
+}
+
+// Patch forwards the request.
+func (r *proxyResource) Patch(c *Context) error {
+	return r.forward(c)
+}
+
+// Delete forwards the request.
+func (r *proxyResource) Delete(c *Context) error {
+	return r.forward(c)
+}
+
+// Options forwards the request.
+func (r *proxyResource) Options(c *Context) error {
+	return r.forward(c)
+}
+
+// Head forwards the request.
+func (r *proxyResource) Head(c *Context) error {
+	return r.forward(c)
+}
+
+// Trace forwards the request.
+func (r *proxyResource) Trace(c *Context) error {
+	return r.forward(c)
+}
+
+// Connect forwards the request.
+func (r *proxyResource) Connect(c *Context) error {
+	return r.forward(c)
+}
@@ -0,0 +1,90 @@
+package yarf
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxyHandler(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Path", r.URL.Path)
+		w.Header().Set("X-Query", r.URL.RawQuery)
+		w.Header().Set("X-Cookie", r.Header.Get("Cookie"))
+		w.Header().Set("X-Internal", r.Header.Get("X-Internal"))
+		w.WriteHeader(201)
+		w.Write(body)
+	}))
+	defer upstream.Close()
+
+	target, _ := url.Parse(upstream.URL)
+
+	y := New()
+	y.Add("/users/:id", ProxyHandler(target, ProxyOptions{
+		Path:          "/v2/accounts/:id",
+		RemoveHeaders: []string{"Cookie"},
+		SetHeaders:    map[string]string{"X-Internal": "gateway"},
+	}))
+	y.Add("/api/*", ProxyHandler(target, ProxyOptions{StripPrefix: "/api"}))
+
+	req, _ := http.NewRequest("POST", "http://localhost:8080/users/42?full=1", strings.NewReader("payload"))
+	req.Header.Set("Cookie", "session=secret")
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 201 || res.Body.String() != "payload" {
+		t.Errorf("Expected upstream response 201 'payload', got %d '%s'", res.Code, res.Body.String())
+	}
+	if p := res.Header().Get("X-Path"); p != "/v2/accounts/42" {
+		t.Errorf("Expected upstream path /v2/accounts/42, got %s", p)
+	}
+	if q := res.Header().Get("X-Query"); q != "full=1" {
+		t.Errorf("Expected query to be forwarded, got '%s'", q)
+	}
+	if res.Header().Get("X-Cookie") != "" || res.Header().Get("X-Internal") != "gateway" {
+		t.Errorf("Header rules not applied: %v", res.Header())
+	}
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/api/items/1", nil)
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if p := res.Header().Get("X-Path"); p != "/items/1" {
+		t.Errorf("Expected upstream path /items/1, got %s", p)
+	}
+}
+
+func TestProxyHandlerErrors(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	target, _ := url.Parse(slow.URL)
+
+	down := httptest.NewServer(nil)
+	downTarget, _ := url.Parse(down.URL)
+	down.Close()
+
+	y := New()
+	y.Add("/slow", ProxyHandler(target, ProxyOptions{Timeout: 20 * time.Millisecond}))
+	y.Add("/down", ProxyHandler(downTarget, ProxyOptions{}))
+
+	for path, code := range map[string]int{"/slow": 504, "/down": 502} {
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+path, nil)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Code != code {
+			t.Errorf("%s: expected %d, got %d", path, code, res.Code)
+		}
+	}
+}
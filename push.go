@@ -0,0 +1,89 @@
+package yarf
+
+import (
+	"errors"
+	"net/http"
+)
+
+// MetaPush is the RouteMeta key holding the paths pushed with the responses of the route.
+const MetaPush = "push"
+
+// Push declares the push manifest of the route: the assets pushed with its GET responses over HTTP/2,
+// like the stylesheets and scripts of a page. It returns the RouteMeta to allow chaining.
+//
+//	y.AddRoute("/", new(Home)).Push("/static/app.css", "/static/app.js")
+func (m *RouteMeta) Push(paths ...string) *RouteMeta {
+	return m.Set(MetaPush, paths)
+}
+
+// pushKey is the Context storage key for the paths already pushed.
+type pushKey struct{}
+
+// responsePusher returns the http.Pusher of the response, unwrapping the middleware writers,
+// or nil if the connection doesn't support server push.
+func responsePusher(w http.ResponseWriter) http.Pusher {
+	for w != nil {
+		if p, ok := w.(http.Pusher); ok {
+			return p
+		}
+
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		w = u.Unwrap()
+	}
+
+	return nil
+}
+
+// Push initiates HTTP/2 server pushes of the paths, so the client gets them along with the response.
+// The pushed requests carry the Accept-Encoding and Accept-Language headers of the request.
+// It does nothing on connections without push support, like HTTP/1.x ones or clients that disabled it,
+// and each path is pushed once per request.
+// Call it before writing the response.
+func (c *Context) Push(paths ...string) error {
+	p := responsePusher(c.Response)
+	if p == nil {
+		return nil
+	}
+
+	pushed, _ := c.get(pushKey{}).(map[string]bool)
+	if pushed == nil {
+		pushed = make(map[string]bool)
+		c.set(pushKey{}, pushed)
+	}
+
+	opts := &http.PushOptions{Header: make(http.Header)}
+	for _, h := range []string{"Accept-Encoding", "Accept-Language"} {
+		if v := c.Request.Header.Get(h); v != "" {
+			opts.Header.Set(h, v)
+		}
+	}
+
+	for _, path := range paths {
+		if pushed[path] {
+			continue
+		}
+
+		err := p.Push(path, opts)
+		if errors.Is(err, http.ErrNotSupported) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		pushed[path] = true
+	}
+
+	return nil
+}
+
+// push sends the push manifest of the route with the GET responses.
+func (r *route) push(c *Context) {
+	if paths, ok := r.meta.Get(MetaPush).([]string); ok && c.Request.Method == "GET" {
+		if err := c.Push(paths...); err != nil && c.app != nil {
+			c.app.log().Debug("server push failed", "error", err, "route", c.RoutePattern())
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed  []string
+	headers []http.Header
+	err     error
+}
+
+func (r *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.pushed = append(r.pushed, target)
+	r.headers = append(r.headers, opts.Header)
+
+	return nil
+}
+
+type PushResource struct {
+	Resource
+}
+
+func (r *PushResource) Get(c *Context) error {
+	c.Push("/static/app.js", "/static/logo.svg")
+	c.Render("page")
+
+	return nil
+}
+
+func TestPush(t *testing.T) {
+	y := New()
+	y.Metrics = newTestMetrics()
+	y.AddRoute("/", new(PushResource)).Push("/static/app.css", "/static/app.js")
+
+	res := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	y.ServeHTTP(res, req)
+
+	if !reflect.DeepEqual(res.pushed, []string{"/static/app.css", "/static/app.js", "/static/logo.svg"}) {
+		t.Errorf("Unexpected pushed paths %v", res.pushed)
+	}
+	if res.headers[0].Get("Accept-Encoding") != "gzip" {
+		t.Errorf("Expected Accept-Encoding to be forwarded, got %v", res.headers[0])
+	}
+	if res.Body.String() != "page" {
+		t.Errorf("Unexpected response %q", res.Body.String())
+	}
+
+	res = &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	req, _ = http.NewRequest("POST", "http://localhost:8080/", nil)
+	y.ServeHTTP(res, req)
+	if len(res.pushed) != 0 {
+		t.Errorf("Only GET responses should push the manifest, got %v", res.pushed)
+	}
+}
+
+func TestPushNotSupported(t *testing.T) {
+	c := NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+	if err := c.Push("/app.js"); err != nil {
+		t.Errorf("Push should be a no-op without http.Pusher, got %v", err)
+	}
+
+	res := &pushRecorder{ResponseRecorder: httptest.NewRecorder(), err: http.ErrNotSupported}
+	c = NewContext(httptest.NewRequest("GET", "/", nil), res)
+	if err := c.Push("/app.js"); err != nil {
+		t.Errorf("Push should ignore clients disabling it, got %v", err)
+	}
+}
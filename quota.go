@@ -0,0 +1,315 @@
+package yarf
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// QuotaPeriod is the calendar period a quota applies to, in UTC.
+type QuotaPeriod string
+
+// Quota periods.
+const (
+	QuotaHour  QuotaPeriod = "hour"
+	QuotaDay   QuotaPeriod = "day"
+	QuotaMonth QuotaPeriod = "month"
+)
+
+// window returns the start and end of the period including t.
+func (p QuotaPeriod) window(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+
+	switch p {
+	case QuotaHour:
+		start := t.Truncate(time.Hour)
+		return start, start.Add(time.Hour)
+	case QuotaMonth:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// Quota is the number of requests allowed per period.
+type Quota struct {
+	Limit  int64
+	Period QuotaPeriod
+}
+
+// QuotaUsage is the state of a quota for the current period.
+type QuotaUsage struct {
+	Quota
+
+	// Used is the number of requests counted in the period.
+	Used int64
+
+	// Reset is the end of the period, when the usage is reset.
+	Reset time.Time
+}
+
+// Remaining returns the number of requests left in the period.
+func (u QuotaUsage) Remaining() int64 {
+	if u.Used >= u.Limit {
+		return 0
+	}
+
+	return u.Limit - u.Used
+}
+
+// QuotaStore counts the usage of the quotas.
+type QuotaStore interface {
+	// Incr adds n to the counter of the key and returns the new value.
+	// The counter expires at expire, starting again from 0.
+	Incr(key string, n int64, expire time.Time) (int64, error)
+
+	// Get returns the value of the counter of the key, or 0 if it doesn't exist.
+	Get(key string) (int64, error)
+}
+
+// quotaCounter is a MemoryQuotaStore counter.
+type quotaCounter struct {
+	n      int64
+	expire time.Time
+}
+
+// MemoryQuotaStore is an in-memory QuotaStore implementation, for single instance deployments.
+type MemoryQuotaStore struct {
+	// Clock tells the time to expire the counters. nil uses the system clock.
+	Clock Clock
+
+	counters map[string]*quotaCounter
+	lock     sync.Mutex
+}
+
+// NewMemoryQuotaStore creates a new empty MemoryQuotaStore.
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{
+		counters: make(map[string]*quotaCounter),
+	}
+}
+
+// Incr adds n to the counter of the key and returns the new value.
+func (s *MemoryQuotaStore) Incr(key string, n int64, expire time.Time) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := clockNow(s.Clock)
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expire) {
+		// Drop the expired counters when a new one starts
+		for k, c := range s.counters {
+			if !now.Before(c.expire) {
+				delete(s.counters, k)
+			}
+		}
+		c = &quotaCounter{expire: expire}
+		s.counters[key] = c
+	}
+	c.n += n
+
+	return c.n, nil
+}
+
+// Get returns the value of the counter of the key.
+func (s *MemoryQuotaStore) Get(key string) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok || !clockNow(s.Clock).Before(c.expire) {
+		return 0, nil
+	}
+
+	return c.n, nil
+}
+
+// QuotaError is the 429 error returned for the requests over quota.
+type QuotaError struct {
+	CustomError
+
+	// Usage is the exceeded quota.
+	Usage QuotaUsage
+}
+
+// quotaKey is the Context storage key for the request quota usage.
+type quotaKey struct{}
+
+// Quota returns the usage of the quotas applied to the request by the QuotaMiddleware, including the request,
+// or nil if no quota applies. Use it to report the usage in responses or for metered billing.
+func (c *Context) Quota() []QuotaUsage {
+	u, _ := c.get(quotaKey{}).([]QuotaUsage)
+	return u
+}
+
+// QuotaMiddleware counts the requests of each tenant or API key against daily or monthly quotas,
+// on top of the instantaneous rate limits. Requests over quota get a 429 error until the period resets.
+// The usage of the most constrained quota is sent in the X-Quota-Limit, X-Quota-Remaining and X-Quota-Reset headers.
+//
+//	q := yarf.NewQuotaMiddleware(yarf.NewMemoryQuotaStore())
+//	q.Quotas = []yarf.Quota{{Limit: 10000, Period: yarf.QuotaDay}}
+//	y.Use(yarf.NewTenantMiddleware(yarf.TenantFromHeader("X-Tenant-ID")))
+//	y.Use(q)
+//
+// Requests rejected don't count against the quotas.
+type QuotaMiddleware struct {
+	Middleware
+
+	// Store counts the usage. Use a shared store with several instances.
+	Store QuotaStore
+
+	// Key returns the key the quotas apply to, like the tenant ID or the API key.
+	// Requests without key aren't counted.
+	Key func(*Context) string
+
+	// Quotas are the quotas applied to every key.
+	Quotas []Quota
+
+	// Limits returns the quotas of a key, like the quotas of its plan, overriding Quotas.
+	// If nil, the Quotas of the request tenant are used if it has any.
+	Limits func(c *Context, key string) []Quota
+
+	// Headers sends the quota headers on responses.
+	Headers bool
+
+	// Clock tells the time to find the current periods. nil uses the system clock.
+	Clock Clock
+}
+
+// NewQuotaMiddleware creates a QuotaMiddleware with the store provided, counting requests by tenant ID
+// and sending the quota headers. Set the Quotas, or the Quotas of the tenants.
+func NewQuotaMiddleware(store QuotaStore) *QuotaMiddleware {
+	return &QuotaMiddleware{
+		Store: store,
+		Key: func(c *Context) string {
+			return c.TenantID()
+		},
+		Headers: true,
+	}
+}
+
+// Phase runs the quotas with the security middleware, after the tenant resolution.
+func (m *QuotaMiddleware) Phase() Phase {
+	return PhaseSecurity
+}
+
+// limits returns the quotas of a key.
+func (m *QuotaMiddleware) limits(c *Context, key string) []Quota {
+	if m.Limits != nil {
+		return m.Limits(c, key)
+	}
+	if t := c.Tenant(); t != nil && len(t.Quotas) > 0 {
+		return t.Quotas
+	}
+
+	return m.Quotas
+}
+
+// counter returns the store key of the quota counter for the period including now, and the end of the period.
+func (m *QuotaMiddleware) counter(key string, q Quota, now time.Time) (string, time.Time) {
+	start, end := q.Period.window(now)
+	return key + ":" + string(q.Period) + ":" + strconv.FormatInt(start.Unix(), 10), end
+}
+
+// Usage returns the usage of the quotas of a key for the current periods, without counting a request.
+// The quotas are the Quotas, unless provided.
+func (m *QuotaMiddleware) Usage(key string, quotas ...Quota) ([]QuotaUsage, error) {
+	if len(quotas) == 0 {
+		quotas = m.Quotas
+	}
+
+	now := clockNow(m.Clock)
+	usage := make([]QuotaUsage, len(quotas))
+	for i, q := range quotas {
+		k, end := m.counter(key, q, now)
+		n, err := m.Store.Get(k)
+		if err != nil {
+			return nil, err
+		}
+		usage[i] = QuotaUsage{Quota: q, Used: n, Reset: end}
+	}
+
+	return usage, nil
+}
+
+// PreDispatch counts the request against the quotas of its key.
+func (m *QuotaMiddleware) PreDispatch(c *Context) error {
+	key := m.Key(c)
+	if key == "" {
+		return nil
+	}
+	quotas := m.limits(c, key)
+	if len(quotas) == 0 {
+		return nil
+	}
+
+	now := clockNow(m.Clock)
+	usage := make([]QuotaUsage, 0, len(quotas))
+	var exceeded *QuotaUsage
+	for _, q := range quotas {
+		k, end := m.counter(key, q, now)
+		n, err := m.Store.Incr(k, 1, end)
+		if err != nil {
+			m.rollback(key, usage, now)
+			return err
+		}
+		usage = append(usage, QuotaUsage{Quota: q, Used: n, Reset: end})
+		if n > q.Limit && exceeded == nil {
+			exceeded = &usage[len(usage)-1]
+		}
+	}
+
+	if exceeded != nil {
+		m.rollback(key, usage, now)
+		for i := range usage {
+			usage[i].Used--
+		}
+		m.headers(c, usage)
+		c.Response.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(exceeded.Reset.Sub(now).Seconds()))))
+		c.metrics().Counter("yarf_quota_exceeded_total", "Requests rejected over quota.", "period").Add(1, string(exceeded.Period))
+
+		e := &QuotaError{
+			CustomError: ErrorTooManyRequests().CustomError,
+			Usage:       *exceeded,
+		}
+		e.ErrorMsg = "Quota exceeded"
+		e.ErrorBody = "Quota of " + strconv.FormatInt(exceeded.Limit, 10) + " requests per " + string(exceeded.Period) + " exceeded"
+
+		return e
+	}
+
+	m.headers(c, usage)
+	c.set(quotaKey{}, usage)
+
+	return nil
+}
+
+// rollback uncounts the request from the quotas counted.
+func (m *QuotaMiddleware) rollback(key string, usage []QuotaUsage, now time.Time) {
+	for _, u := range usage {
+		k, end := m.counter(key, u.Quota, now)
+		m.Store.Incr(k, -1, end)
+	}
+}
+
+// headers sends the usage of the most constrained quota.
+func (m *QuotaMiddleware) headers(c *Context, usage []QuotaUsage) {
+	if !m.Headers || len(usage) == 0 {
+		return
+	}
+
+	min := usage[0]
+	for _, u := range usage[1:] {
+		if u.Remaining() < min.Remaining() {
+			min = u
+		}
+	}
+
+	h := c.Response.Header()
+	h.Set("X-Quota-Limit", strconv.FormatInt(min.Limit, 10))
+	h.Set("X-Quota-Remaining", strconv.FormatInt(min.Remaining(), 10))
+	h.Set("X-Quota-Reset", strconv.FormatInt(min.Reset.Unix(), 10))
+}
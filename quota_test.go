@@ -0,0 +1,111 @@
+package yarf
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+type QuotaResource struct {
+	Resource
+	used []QuotaUsage
+}
+
+func (r *QuotaResource) Get(c *Context) error {
+	r.used = c.Quota()
+	return nil
+}
+
+func TestQuotaPeriodWindow(t *testing.T) {
+	now := time.Date(2024, 2, 29, 13, 45, 10, 0, time.UTC)
+
+	for period, want := range map[QuotaPeriod][2]time.Time{
+		QuotaHour:  {time.Date(2024, 2, 29, 13, 0, 0, 0, time.UTC), time.Date(2024, 2, 29, 14, 0, 0, 0, time.UTC)},
+		QuotaDay:   {time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		QuotaMonth: {time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		if start, end := period.window(now); !start.Equal(want[0]) || !end.Equal(want[1]) {
+			t.Errorf("%s: expected %v - %v, got %v - %v", period, want[0], want[1], start, end)
+		}
+	}
+}
+
+func TestQuotaMiddleware(t *testing.T) {
+	clock := newTestClock()
+	store := NewMemoryQuotaStore()
+	store.Clock = clock
+	q := NewQuotaMiddleware(store)
+	q.Clock = clock
+	q.Quotas = []Quota{{Limit: 2, Period: QuotaDay}, {Limit: 100, Period: QuotaMonth}}
+
+	y := New()
+	y.Use(NewTenantMiddleware(TenantFromHeader("X-Tenant-ID")))
+	y.Use(q)
+	y.Add("/items/:id", new(OverrideResource))
+
+	_, reset := QuotaDay.window(clock.Now())
+	for i, remaining := range []string{"1", "0"} {
+		res := testRequest(y, "GET", "/items/1", map[string]string{"X-Tenant-ID": "acme"})
+		if res.Code != 200 || res.Header().Get("X-Quota-Limit") != "2" || res.Header().Get("X-Quota-Remaining") != remaining {
+			t.Errorf("Request %d: expected 200 with %s remaining, got %d %s", i, remaining, res.Code, res.Header().Get("X-Quota-Remaining"))
+		}
+		if res.Header().Get("X-Quota-Reset") != strconv.FormatInt(reset.Unix(), 10) {
+			t.Errorf("Expected reset at %d, got %s", reset.Unix(), res.Header().Get("X-Quota-Reset"))
+		}
+	}
+
+	res := testRequest(y, "GET", "/items/1", map[string]string{"X-Tenant-ID": "acme"})
+	if res.Code != 429 || res.Body.String() != "Quota of 2 requests per day exceeded" || res.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 429 error, got %d '%s' '%s'", res.Code, res.Body.String(), res.Header().Get("Retry-After"))
+	}
+	if res := testRequest(y, "GET", "/items/1", map[string]string{"X-Tenant-ID": "globex"}); res.Code != 200 {
+		t.Errorf("Quotas should apply per tenant, got %d", res.Code)
+	}
+
+	// Rejected requests aren't counted
+	usage, err := q.Usage("acme")
+	if err != nil || len(usage) != 2 || usage[0].Used != 2 || usage[1].Used != 2 || usage[1].Remaining() != 98 {
+		t.Errorf("Unexpected usage %+v %v", usage, err)
+	}
+
+	clock.Advance(24 * time.Hour)
+	if res := testRequest(y, "GET", "/items/1", map[string]string{"X-Tenant-ID": "acme"}); res.Code != 200 || res.Header().Get("X-Quota-Remaining") != "1" {
+		t.Errorf("Expected the daily quota reset, got %d %s", res.Code, res.Header().Get("X-Quota-Remaining"))
+	}
+}
+
+func TestQuotaMiddlewareLimits(t *testing.T) {
+	tenants := StaticTenants{
+		"acme":   {Quotas: []Quota{{Limit: 1, Period: QuotaHour}}},
+		"globex": {},
+	}
+	q := NewQuotaMiddleware(NewMemoryQuotaStore())
+	q.Quotas = []Quota{{Limit: 5, Period: QuotaDay}}
+
+	r := new(QuotaResource)
+	y := New()
+	tm := NewTenantMiddleware(TenantFromHeader("X-Tenant-ID"))
+	tm.Store = tenants
+	y.Use(tm)
+	y.Use(q)
+	y.Add("/items/:id", r)
+
+	if res := testRequest(y, "GET", "/items/1", map[string]string{"X-Tenant-ID": "acme"}); res.Code != 200 || len(r.used) != 1 || r.used[0].Period != QuotaHour || r.used[0].Remaining() != 0 {
+		t.Errorf("Expected tenant quotas, got %d %+v", res.Code, r.used)
+	}
+	if res := testRequest(y, "GET", "/items/1", map[string]string{"X-Tenant-ID": "acme"}); res.Code != 429 {
+		t.Errorf("Expected tenant quota exceeded, got %d", res.Code)
+	}
+	if res := testRequest(y, "GET", "/items/1", map[string]string{"X-Tenant-ID": "globex"}); res.Code != 200 || len(r.used) != 1 || r.used[0].Limit != 5 || r.used[0].Used != 1 {
+		t.Errorf("Expected default quotas, got %d %+v", res.Code, r.used)
+	}
+
+	q.Limits = func(c *Context, key string) []Quota {
+		return nil
+	}
+	for i := 0; i < 3; i++ {
+		if res := testRequest(y, "GET", "/items/1", map[string]string{"X-Tenant-ID": "acme"}); res.Code != 200 || res.Header().Get("X-Quota-Limit") != "" {
+			t.Errorf("Expected no quota, got %d %s", res.Code, res.Header().Get("X-Quota-Limit"))
+		}
+	}
+}
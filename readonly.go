@@ -0,0 +1,147 @@
+package yarf
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ReadOnlyError is the error returned for the requests with unsafe methods rejected by ReadOnlyMode.
+// It's sent as a 503 error, or as a 405 error if ReadOnlyMode.Status says so.
+type ReadOnlyError struct {
+	CustomError
+
+	// Reason is the reason given to Enable().
+	Reason string
+}
+
+// ReadOnlyMode is a middleware rejecting the requests with unsafe methods while it's enabled,
+// during database failovers, migrations or incident response. GET, HEAD, OPTIONS and TRACE requests keep working.
+// Use it as global middleware, or in the groups that write to the affected storage:
+//
+//	ro := yarf.NewReadOnlyMode("/login", "/admin/*")
+//	y.Use(ro)
+//
+//	ro.Enable("Database failover in progress")
+//	defer ro.Disable()
+//
+// It's disabled when created.
+type ReadOnlyMode struct {
+	Middleware
+
+	// Status is the status code of the rejected requests: 503 Service Unavailable, with a Retry-After header,
+	// or 405 Method Not Allowed, with an Allow header listing the safe methods.
+	Status int
+
+	// RetryAfter is the time clients are told to wait with 503 errors. 0 omits the Retry-After header.
+	RetryAfter time.Duration
+
+	allow   []*route // Paths accepting every method, compiled from the NewReadOnlyMode patterns
+	enabled bool
+	reason  string
+	lock    sync.RWMutex
+}
+
+// NewReadOnlyMode creates a disabled ReadOnlyMode, rejecting requests with 503 errors and a 30 seconds Retry-After.
+// The request paths matching the allow route patterns, like "/login" or "/admin/*", accept every method in read-only mode.
+// It panics with a *PatternError if a pattern is invalid.
+func NewReadOnlyMode(allow ...string) *ReadOnlyMode {
+	m := &ReadOnlyMode{
+		Status:     http.StatusServiceUnavailable,
+		RetryAfter: 30 * time.Second,
+	}
+	for _, p := range allow {
+		m.allow = append(m.allow, Route(p, nil).(*route))
+	}
+
+	return m
+}
+
+// Phase runs the read-only mode with the security middleware.
+func (m *ReadOnlyMode) Phase() Phase {
+	return PhaseSecurity
+}
+
+// Enable starts rejecting the unsafe requests. The reason is sent in the error body, if not empty.
+func (m *ReadOnlyMode) Enable(reason string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.enabled, m.reason = true, reason
+}
+
+// Disable accepts every request again.
+func (m *ReadOnlyMode) Disable() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.enabled, m.reason = false, ""
+}
+
+// Enabled returns true while the unsafe requests are rejected, and the reason given to Enable().
+func (m *ReadOnlyMode) Enabled() (bool, string) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.enabled, m.reason
+}
+
+// safeMethod returns true for the methods that don't change the server state.
+func safeMethod(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "TRACE":
+		return true
+	}
+
+	return false
+}
+
+// allowed returns true if the request path matches an allowed pattern.
+func (m *ReadOnlyMode) allowed(c *Context) bool {
+	if len(m.allow) == 0 {
+		return false
+	}
+
+	path := c.Request.URL.Path
+	if c.app != nil {
+		path, _ = c.app.routePath(path)
+	}
+
+	for _, r := range m.allow {
+		if r.match(path, c, Params{}) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PreDispatch rejects the unsafe requests while the read-only mode is enabled.
+func (m *ReadOnlyMode) PreDispatch(c *Context) error {
+	enabled, reason := m.Enabled()
+	if !enabled || safeMethod(c.Request.Method) || m.allowed(c) {
+		return nil
+	}
+
+	c.metrics().Counter("yarf_readonly_rejected_total", "Requests rejected by the read-only mode.", "method").Add(1, c.Request.Method)
+
+	e := &ReadOnlyError{Reason: reason}
+	if m.Status == http.StatusMethodNotAllowed {
+		e.CustomError = ErrorMethodNotImplemented().CustomError
+		e.ErrorMsg = "Method not allowed in read-only mode"
+		c.Response.Header().Set("Allow", "GET, HEAD, OPTIONS, TRACE")
+	} else {
+		e.CustomError = ErrorServiceUnavailable().CustomError
+		e.ErrorMsg = "Service in read-only mode"
+		if m.RetryAfter > 0 {
+			c.Response.Header().Set("Retry-After", strconv.Itoa(int(m.RetryAfter/time.Second)))
+		}
+	}
+	e.ErrorBody = e.ErrorMsg
+	if reason != "" {
+		e.ErrorBody += ": " + reason
+	}
+
+	return e
+}
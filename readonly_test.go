@@ -0,0 +1,84 @@
+package yarf
+
+import (
+	"testing"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	ro := NewReadOnlyMode("/login", "/admin/*")
+
+	y := New()
+	y.Use(ro)
+	y.Add("/items/:id", new(OverrideResource))
+	y.Add("/login", new(OverrideResource))
+	y.Add("/admin/items/:id", new(OverrideResource))
+
+	if res := testRequest(y, "POST", "/items/1", nil); res.Code != 200 {
+		t.Errorf("Read-only mode should be disabled by default, got %d", res.Code)
+	}
+
+	ro.Enable("Database failover")
+	if enabled, reason := ro.Enabled(); !enabled || reason != "Database failover" {
+		t.Errorf("Expected read-only mode enabled, got %v '%s'", enabled, reason)
+	}
+
+	res := testRequest(y, "DELETE", "/items/1", nil)
+	if res.Code != 503 || res.Header().Get("Retry-After") != "30" || res.Body.String() != "Service in read-only mode: Database failover" {
+		t.Errorf("Expected 503 error, got %d '%s' '%s'", res.Code, res.Header().Get("Retry-After"), res.Body.String())
+	}
+	for _, path := range []string{"/login", "/admin/items/1"} {
+		if res := testRequest(y, "POST", path, nil); res.Code != 200 {
+			t.Errorf("%s: allowed paths should accept writes, got %d", path, res.Code)
+		}
+	}
+	if res := testRequest(y, "GET", "/items/1", nil); res.Code != 200 {
+		t.Errorf("Safe methods should pass, got %d", res.Code)
+	}
+
+	ro.Status = 405
+	ro.Enable("")
+	res = testRequest(y, "PATCH", "/items/1", nil)
+	if res.Code != 405 || res.Header().Get("Allow") != "GET, HEAD, OPTIONS, TRACE" || res.Body.String() != "Method not allowed in read-only mode" {
+		t.Errorf("Expected 405 error, got %d '%s' '%s'", res.Code, res.Header().Get("Allow"), res.Body.String())
+	}
+	ro.Disable()
+	if res := testRequest(y, "POST", "/items/1", nil); res.Code != 200 {
+		t.Errorf("Expected writes accepted once disabled, got %d", res.Code)
+	}
+}
+
+func TestReadOnlyModeGroup(t *testing.T) {
+	ro := NewReadOnlyMode("/billing/invoices/:id/pay")
+	ro.Enable("Billing database migration")
+
+	billing := RouteGroup("/billing")
+	billing.Insert(ro)
+	billing.Add("/invoices/:id", new(OverrideResource))
+	billing.Add("/invoices/:id/pay", new(OverrideResource))
+
+	y := New()
+	y.StripPrefix("/api")
+	y.AddGroup(billing)
+	y.Add("/items/:id", new(OverrideResource))
+
+	if res := testRequest(y, "POST", "/api/billing/invoices/1", nil); res.Code != 503 {
+		t.Errorf("Expected the group in read-only mode, got %d", res.Code)
+	}
+	if res := testRequest(y, "POST", "/api/items/1", nil); res.Code != 200 {
+		t.Errorf("Routes outside the group should accept writes, got %d", res.Code)
+	}
+
+	if res := testRequest(y, "POST", "/api/billing/invoices/1/pay", nil); res.Code != 200 {
+		t.Errorf("Allow patterns should match without the global prefix, got %d", res.Code)
+	}
+}
+
+func TestReadOnlyModeInvalidAllow(t *testing.T) {
+	defer func() {
+		if _, ok := recover().(*PatternError); !ok {
+			t.Error("Expected a *PatternError panic for an invalid allow pattern")
+		}
+	}()
+
+	NewReadOnlyMode("/login", "/files/:/edit")
+}
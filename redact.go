@@ -0,0 +1,175 @@
+package yarf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// Redactor masks sensitive values in the access log, debug error pages, audit events and request dumps,
+// replacing them with RedactMask.
+// Header and param names are case-insensitive. Struct fields tagged as `redact:"true"` are masked too,
+// like card numbers:
+//
+//	type Payment struct {
+//		Amount int    `json:"amount"`
+//		Card   string `json:"card" redact:"true"`
+//	}
+//
+//	y.Log.Info("payment received", "payment", c.Redact(p))
+//
+// A nil *Redactor returns the values unchanged.
+type Redactor struct {
+	// Headers are the request headers masked, like Authorization.
+	Headers []string
+
+	// Params are the query and route params masked, like access_token.
+	Params []string
+
+	// Fields are the object keys masked in the values passed to Value().
+	Fields []string
+}
+
+// NewRedactor creates a Redactor masking the credential headers (Authorization, Cookie and API keys),
+// and the token, password and secret params and fields.
+func NewRedactor() *Redactor {
+	secrets := []string{
+		"access_token", "refresh_token", "id_token", "token",
+		"password", "secret", "client_secret", "api_key", "apikey",
+	}
+
+	return &Redactor{
+		Headers: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Auth-Token"},
+		Params:  secrets,
+		Fields:  secrets,
+	}
+}
+
+// Header returns a copy of h with the sensitive headers masked.
+func (r *Redactor) Header(h http.Header) http.Header {
+	if r == nil {
+		return h
+	}
+
+	out := h.Clone()
+	for k, values := range out {
+		if containsFold(r.Headers, k) {
+			masked := make([]string, len(values))
+			for i := range masked {
+				masked[i] = RedactMask
+			}
+			out[k] = masked
+		}
+	}
+
+	return out
+}
+
+// RouteParams returns a copy of the route params with the sensitive ones masked.
+// The copy stays valid once the Context is reused.
+func (r *Redactor) RouteParams(p map[string]string) map[string]string {
+	if p == nil {
+		return nil
+	}
+
+	out := make(map[string]string, len(p))
+	for k, v := range p {
+		if r != nil && containsFold(r.Params, k) {
+			v = RedactMask
+		}
+		out[k] = v
+	}
+
+	return out
+}
+
+// Query returns the raw query provided with the values of the sensitive params masked.
+func (r *Redactor) Query(raw string) string {
+	if r == nil || raw == "" {
+		return raw
+	}
+
+	parts := strings.Split(raw, "&")
+	for i, p := range parts {
+		k, _, ok := strings.Cut(p, "=")
+		if name, err := url.QueryUnescape(k); err == nil && ok && containsFold(r.Params, name) {
+			parts[i] = k + "=" + RedactMask
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// URL returns u as a string with the values of the sensitive query params masked.
+func (r *Redactor) URL(u *url.URL) string {
+	if r == nil || u.RawQuery == "" {
+		return u.String()
+	}
+
+	masked := *u
+	masked.RawQuery = r.Query(u.RawQuery)
+
+	return masked.String()
+}
+
+// Value returns v as a JSON-like value, made of maps, slices and scalars,
+// with the Fields keys and the fields tagged as `redact:"true"` masked at any depth.
+// Values that can't be encoded as JSON are fully masked.
+func (r *Redactor) Value(v interface{}) interface{} {
+	if r == nil {
+		return v
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return RedactMask
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return RedactMask
+	}
+
+	set := make(map[string]bool)
+	for _, k := range append(RedactedFields(reflect.TypeOf(v)), r.Fields...) {
+		set[k] = true
+	}
+
+	return redactKeys(out, set)
+}
+
+// Request dumps the request headers, as sent on the wire, with the sensitive headers and query params masked.
+func (r *Redactor) Request(req *http.Request) string {
+	masked := *req
+	if r != nil {
+		u := *req.URL
+		u.RawQuery = r.Query(u.RawQuery)
+		masked.URL = &u
+		masked.RequestURI = ""
+		masked.Header = r.Header(req.Header)
+	}
+
+	dump, err := httputil.DumpRequest(&masked, false)
+	if err != nil {
+		return ""
+	}
+
+	return string(dump)
+}
+
+// redactor returns the Redactor of the application serving the request, if any.
+func (c *Context) redactor() *Redactor {
+	if c.app == nil {
+		return nil
+	}
+
+	return c.app.Redactor
+}
+
+// Redact masks the sensitive fields of v with the application Redactor, to log it safely.
+// See Redactor.Value.
+func (c *Context) Redact(v interface{}) interface{} {
+	return c.redactor().Value(v)
+}
@@ -0,0 +1,83 @@
+package yarf
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type testPayment struct {
+	Amount int    `json:"amount"`
+	Card   string `json:"card" redact:"true"`
+	Owner  struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	} `json:"owner"`
+}
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor()
+
+	h := http.Header{"Authorization": {"Bearer abc"}, "Accept": {"*/*"}}
+	masked := r.Header(h)
+	if masked.Get("Authorization") != RedactMask || masked.Get("Accept") != "*/*" {
+		t.Errorf("Unexpected headers %v", masked)
+	}
+	if h.Get("Authorization") != "Bearer abc" {
+		t.Error("Header() shouldn't change the original headers")
+	}
+
+	if p := r.RouteParams(map[string]string{"id": "1", "Token": "abc"}); p["id"] != "1" || p["Token"] != RedactMask {
+		t.Errorf("Unexpected params %v", p)
+	}
+
+	u, _ := url.Parse("/login?user=bob&access_token=abc&PASSWORD=x&flag")
+	if s := r.URL(u); s != "/login?user=bob&access_token=[REDACTED]&PASSWORD=[REDACTED]&flag" {
+		t.Errorf("Unexpected URL %q", s)
+	}
+
+	var p testPayment
+	p.Amount, p.Card, p.Owner.Name, p.Owner.Password = 10, "4111111111111111", "bob", "secret"
+	data, _ := json.Marshal(r.Value(p))
+	if !jsonEqual(string(data), `{"amount": 10, "card": "[REDACTED]", "owner": {"name": "bob", "password": "[REDACTED]"}}`) {
+		t.Errorf("Unexpected value %s", data)
+	}
+	if r.Value(make(chan int)) != RedactMask {
+		t.Error("Values that can't be encoded should be masked")
+	}
+
+	// A nil Redactor doesn't mask anything
+	var none *Redactor
+	if none.URL(u) != u.String() || none.Header(h).Get("Authorization") != "Bearer abc" || none.Value(p) != p {
+		t.Error("A nil Redactor shouldn't mask values")
+	}
+}
+
+func TestRedactedOutput(t *testing.T) {
+	var logs bytes.Buffer
+	y := New()
+	y.Debug = true
+	y.Logger = log.New(&logs, "", 0)
+	y.Add("/items/:token", new(FailingResource))
+
+	req := httptest.NewRequest("GET", "/items/abc?access_token=xyz", nil)
+	req.Header.Set("Authorization", "Bearer xyz")
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	var info debugInfo
+	if err := json.Unmarshal(res.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Params["token"] != RedactMask || strings.Contains(info.Request, "xyz") || !strings.Contains(info.Request, "Authorization: [REDACTED]") {
+		t.Errorf("Unexpected debug info %+v", info)
+	}
+	if strings.Contains(logs.String(), "xyz") || !strings.Contains(logs.String(), "access_token=[REDACTED]") {
+		t.Errorf("Unexpected access log %q", logs.String())
+	}
+}
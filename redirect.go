@@ -0,0 +1,36 @@
+package yarf
+
+import (
+	"net/http"
+)
+
+// redirectCode returns the code if it's a redirection, or 302 Found otherwise.
+func redirectCode(code int) int {
+	switch code {
+	case http.StatusMultipleChoices, http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return code
+	}
+
+	return http.StatusFound
+}
+
+// RedirectToRoute returns a RedirectError to the URL of the named route, with a 302 Found status code.
+// Returning it from handlers or middleware stops the request flow and sends the redirect.
+// It returns ErrRouteNotFound or a params error if the URL can't be built.
+//
+//	return c.RedirectToRoute("users.show", yarf.Params{"id": id})
+func (c *Context) RedirectToRoute(name string, params Params) error {
+	return c.RedirectToRouteCode(name, params, http.StatusFound)
+}
+
+// RedirectToRouteCode is RedirectToRoute with a custom status code,
+// like 303 See Other after a POST or 301 Moved Permanently for old URLs.
+func (c *Context) RedirectToRouteCode(name string, params Params, code int) error {
+	u, err := c.URL(name, params)
+	if err != nil {
+		return err
+	}
+
+	return ErrorRedirect(u, code)
+}
@@ -0,0 +1,84 @@
+package yarf
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// RouteRedirectResource redirects to the user route.
+type RouteRedirectResource struct {
+	Resource
+}
+
+func (r *RouteRedirectResource) Get(c *Context) error {
+	if err := c.RedirectToRoute(c.QueryValue("to"), Params{"id": c.Param("id")}); err != nil {
+		return err
+	}
+
+	// Not reached
+	c.Render("rendered")
+	return nil
+}
+
+func (r *RouteRedirectResource) Post(c *Context) error {
+	return c.RedirectToRouteCode("users.show", Params{"id": c.Param("id")}, 303)
+}
+
+func TestRedirectToRoute(t *testing.T) {
+	y := New()
+	y.AddRoute("/users/:id", new(OKResource)).Name("users.show")
+	y.Add("/old/users/:id", new(RouteRedirectResource))
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/old/users/5?to=users.show", nil))
+
+	if res.Code != 302 || res.Header().Get("Location") != "/users/5" {
+		t.Errorf("Expected 302 to /users/5, got %d %s", res.Code, res.Header().Get("Location"))
+	}
+	if res.Body.String() == "rendered" {
+		t.Error("Rendering should stop after the redirect")
+	}
+
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("POST", "/old/users/5", nil))
+	if res.Code != 303 || res.Header().Get("Location") != "/users/5" {
+		t.Errorf("Expected 303 to /users/5, got %d %s", res.Code, res.Header().Get("Location"))
+	}
+
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/old/users/5?to=missing", nil))
+	if res.Code != 500 {
+		t.Errorf("Expected 500 for unknown routes, got %d", res.Code)
+	}
+}
+
+func TestRedirectCodes(t *testing.T) {
+	tests := map[int]int{
+		301: 301,
+		302: 302,
+		303: 303,
+		307: 307,
+		308: 308,
+		200: 302,
+		304: 302,
+		404: 302,
+		0:   302,
+	}
+
+	for code, expected := range tests {
+		if e := ErrorRedirect("/", code); e.Code() != expected {
+			t.Errorf("ErrorRedirect: expected %d for %d, got %d", expected, code, e.Code())
+		}
+
+		res := httptest.NewRecorder()
+		c := NewContext(httptest.NewRequest("GET", "/a/b", nil), res)
+		c.Redirect("c", code)
+
+		if res.Code != expected {
+			t.Errorf("Redirect: expected %d for %d, got %d", expected, code, res.Code)
+		}
+		if res.Header().Get("Location") != "/a/c" {
+			t.Errorf("Redirect: expected relative URL to be resolved, got %s", res.Header().Get("Location"))
+		}
+	}
+}
@@ -0,0 +1,256 @@
+// Package redis implements the yarf stores on Redis, so the middleware state is shared by all the instances
+// of an application: sessions, idempotency keys, rate limits, quotas, brute-force records, tenants, and a cache of JSON values.
+// The stores run their commands through a Doer: the bundled Client speaks the Redis protocol without dependencies,
+// and other clients are adapted with a DoerFunc.
+//
+//	rdb := redis.New("127.0.0.1:6379")
+//	guard := yarf.NewBruteForceGuard()
+//	guard.Store = redis.NewBruteForceStore(rdb)
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Doer runs Redis commands. Nil replies are returned as a nil value without error.
+type Doer interface {
+	Do(ctx context.Context, args ...interface{}) (interface{}, error)
+}
+
+// DoerFunc adapts a function into a Doer, like the client of another Redis library:
+//
+//	rdb := goredis.NewClient(&goredis.Options{Addr: addr})
+//	doer := redis.DoerFunc(func(ctx context.Context, args ...interface{}) (interface{}, error) {
+//		v, err := rdb.Do(ctx, args...).Result()
+//		if err == goredis.Nil {
+//			return nil, nil
+//		}
+//		return v, err
+//	})
+type DoerFunc func(ctx context.Context, args ...interface{}) (interface{}, error)
+
+// Do calls f(ctx, args...).
+func (f DoerFunc) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	return f(ctx, args...)
+}
+
+// Error is an error reply of the server.
+type Error string
+
+// Error returns the server message.
+func (e Error) Error() string {
+	return string(e)
+}
+
+// ErrProtocol is returned when the server reply can't be parsed.
+var ErrProtocol = errors.New("redis: protocol error")
+
+// Client is a minimal Redis client keeping a pool of idle connections.
+type Client struct {
+	// Addr is the TCP address of the server.
+	Addr string
+
+	// Password authenticates the connections, if set.
+	Password string
+
+	// DB is the database selected on the connections.
+	DB int
+
+	// DialTimeout limits the connection to the server.
+	DialTimeout time.Duration
+
+	// Timeout limits each command when the context has no deadline.
+	Timeout time.Duration
+
+	idle chan *conn
+}
+
+// conn is a connection to the server.
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// New creates a Client for the server address, with 5 seconds timeouts and up to 10 idle connections.
+func New(addr string) *Client {
+	return &Client{
+		Addr:        addr,
+		DialTimeout: 5 * time.Second,
+		Timeout:     5 * time.Second,
+		idle:        make(chan *conn, 10),
+	}
+}
+
+// dial opens a connection, authenticates it and selects the database.
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	d := net.Dialer{Timeout: c.DialTimeout}
+	nc, err := d.DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+
+	var setup [][]interface{}
+	if c.Password != "" {
+		setup = append(setup, []interface{}{"AUTH", c.Password})
+	}
+	if c.DB != 0 {
+		setup = append(setup, []interface{}{"SELECT", c.DB})
+	}
+	for _, args := range setup {
+		if _, err := c.run(ctx, cn, args); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+
+	return cn, nil
+}
+
+// Do runs a command on an idle connection, or a new one, and returns the reply:
+// a string, an int64, a []byte, a []interface{} or nil.
+func (c *Client) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	var cn *conn
+	select {
+	case cn = <-c.idle:
+	default:
+		var err error
+		if cn, err = c.dial(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	v, err := c.run(ctx, cn, args)
+	if _, ok := err.(Error); err != nil && !ok {
+		// The connection state is unknown
+		cn.Close()
+		return nil, err
+	}
+
+	select {
+	case c.idle <- cn:
+	default:
+		cn.Close()
+	}
+
+	return v, err
+}
+
+// Close closes the idle connections.
+func (c *Client) Close() error {
+	for {
+		select {
+		case cn := <-c.idle:
+			cn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// run writes the command and reads its reply.
+func (c *Client) run(ctx context.Context, cn *conn, args []interface{}) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok && c.Timeout > 0 {
+		deadline = time.Now().Add(c.Timeout)
+	}
+	cn.SetDeadline(deadline)
+
+	fmt.Fprintf(cn.w, "*%d\r\n", len(args))
+	for _, a := range args {
+		b := arg(a)
+		fmt.Fprintf(cn.w, "$%d\r\n", len(b))
+		cn.w.Write(b)
+		cn.w.WriteString("\r\n")
+	}
+	if err := cn.w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return readReply(cn.r)
+}
+
+// arg encodes a command argument.
+func arg(a interface{}) []byte {
+	switch v := a.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	case int:
+		return strconv.AppendInt(nil, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(nil, v, 10)
+	case float64:
+		return strconv.AppendFloat(nil, v, 'f', -1, 64)
+	default:
+		return []byte(fmt.Sprint(v))
+	}
+}
+
+// readReply reads a RESP reply.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, ErrProtocol
+	}
+	line = line[:len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, ErrProtocol
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, ErrProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, ErrProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			v, err := readReply(r)
+			if e, ok := err.(Error); ok {
+				v = e
+			} else if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+
+	return nil, ErrProtocol
+}
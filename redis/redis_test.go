@@ -0,0 +1,215 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer is an in-memory server answering a few Redis commands.
+type fakeServer struct {
+	ln       net.Listener
+	password string
+	values   map[string]string
+	expires  map[string]time.Time
+	commands []string
+	lock     sync.Mutex
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fakeServer{
+		ln:      ln,
+		values:  make(map[string]string),
+		expires: make(map[string]time.Time),
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(nc)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeServer) serve(nc net.Conn) {
+	defer nc.Close()
+	r := bufio.NewReader(nc)
+
+	for {
+		cmd, err := readReply(r)
+		if err != nil {
+			return
+		}
+
+		var args []string
+		for _, a := range cmd.([]interface{}) {
+			args = append(args, string(a.([]byte)))
+		}
+		fmt.Fprint(nc, s.run(args))
+	}
+}
+
+func (s *fakeServer) run(args []string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.commands = append(s.commands, strings.Join(args, " "))
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "AUTH":
+		if args[1] != s.password {
+			return "-WRONGPASS invalid password\r\n"
+		}
+		return "+OK\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		v, ok := s.values[args[1]]
+		if exp, ok := s.expires[args[1]]; ok && time.Now().After(exp) {
+			delete(s.values, args[1])
+			return "$-1\r\n"
+		}
+		if !ok {
+			return "$-1\r\n"
+		}
+		return "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+	case "SET":
+		var ttl time.Duration
+		nx := false
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "PX":
+				ms, _ := strconv.Atoi(args[i+1])
+				ttl = time.Duration(ms) * time.Millisecond
+				i++
+			case "NX":
+				nx = true
+			}
+		}
+		if _, ok := s.values[args[1]]; ok && nx {
+			if exp, ok := s.expires[args[1]]; !ok || time.Now().Before(exp) {
+				return "$-1\r\n"
+			}
+		}
+		s.values[args[1]] = args[2]
+		delete(s.expires, args[1])
+		if ttl > 0 {
+			s.expires[args[1]] = time.Now().Add(ttl)
+		}
+		return "+OK\r\n"
+	case "INCRBY":
+		if exp, ok := s.expires[args[1]]; ok && time.Now().After(exp) {
+			delete(s.values, args[1])
+			delete(s.expires, args[1])
+		}
+		v, _ := strconv.ParseInt(s.values[args[1]], 10, 64)
+		n, _ := strconv.ParseInt(args[2], 10, 64)
+		s.values[args[1]] = strconv.FormatInt(v+n, 10)
+		return ":" + s.values[args[1]] + "\r\n"
+	case "PEXPIREAT":
+		if _, ok := s.values[args[1]]; !ok {
+			return ":0\r\n"
+		}
+		ms, _ := strconv.ParseInt(args[2], 10, 64)
+		s.expires[args[1]] = time.Unix(0, ms*int64(time.Millisecond))
+		return ":1\r\n"
+	case "DEL":
+		n := 0
+		for _, k := range args[1:] {
+			if _, ok := s.values[k]; ok {
+				delete(s.values, k)
+				n++
+			}
+		}
+		return ":" + strconv.Itoa(n) + "\r\n"
+	case "MIXED":
+		return "*3\r\n:1\r\n$-1\r\n-ERR inner\r\n"
+	}
+
+	return "-ERR unknown command\r\n"
+}
+
+func TestClient(t *testing.T) {
+	s := newFakeServer(t)
+	s.password = "secret"
+
+	c := New(s.ln.Addr().String())
+	c.Password = "secret"
+	c.DB = 2
+	defer c.Close()
+	ctx := context.Background()
+
+	if v, err := c.Do(ctx, "PING"); v != "PONG" || err != nil {
+		t.Errorf("Unexpected PING reply %v %v", v, err)
+	}
+	if _, err := c.Do(ctx, "SET", "k", []byte("v"), "PX", 1000); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Do(ctx, "GET", "k"); string(v.([]byte)) != "v" || err != nil {
+		t.Errorf("Unexpected GET reply %v %v", v, err)
+	}
+	if v, err := c.Do(ctx, "GET", "missing"); v != nil || err != nil {
+		t.Errorf("Expected nil reply, got %v %v", v, err)
+	}
+	if v, err := c.Do(ctx, "DEL", "k", "missing"); v != int64(1) || err != nil {
+		t.Errorf("Unexpected DEL reply %v %v", v, err)
+	}
+	if _, err := c.Do(ctx, "NOPE"); err != Error("ERR unknown command") {
+		t.Errorf("Expected server error, got %v", err)
+	}
+	v, err := c.Do(ctx, "MIXED")
+	if values, ok := v.([]interface{}); !ok || err != nil || values[0] != int64(1) || values[1] != nil || values[2] != Error("ERR inner") {
+		t.Errorf("Unexpected array reply %#v %v", v, err)
+	}
+
+	// The connection is reused after server errors
+	s.lock.Lock()
+	auths := 0
+	for _, cmd := range s.commands {
+		if strings.HasPrefix(cmd, "AUTH") {
+			auths++
+		}
+	}
+	s.lock.Unlock()
+	if auths != 1 || s.commands[1] != "SELECT 2" {
+		t.Errorf("Expected a single authenticated connection, got %v", s.commands)
+	}
+
+	// Wrong password
+	bad := New(s.ln.Addr().String())
+	bad.Password = "wrong"
+	if _, err := bad.Do(ctx, "PING"); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Expected authentication error, got %v", err)
+	}
+}
+
+func TestDoerFunc(t *testing.T) {
+	var got []interface{}
+	d := DoerFunc(func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		got = args
+		return nil, nil
+	})
+
+	if err := NewCache(d).Del(context.Background(), "a", "b"); err != nil || len(got) != 3 || got[1] != "yarf:cache:a" {
+		t.Errorf("Unexpected command %v: %v", got, err)
+	}
+}
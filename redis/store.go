@@ -0,0 +1,447 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// get reads the JSON value of a key into v, and reports if the key exists.
+func get(ctx context.Context, db Doer, key string, v interface{}) (bool, error) {
+	reply, err := db.Do(ctx, "GET", key)
+	if err != nil || reply == nil {
+		return false, err
+	}
+
+	var data []byte
+	switch r := reply.(type) {
+	case []byte:
+		data = r
+	case string:
+		data = []byte(r)
+	default:
+		return false, ErrProtocol
+	}
+
+	return true, json.Unmarshal(data, v)
+}
+
+// set writes the JSON value of a key, expiring after ttl. 0 means it doesn't expire.
+func set(ctx context.Context, db Doer, key string, v interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{"SET", key, data}
+	if ttl > 0 {
+		ms := int64(ttl / time.Millisecond)
+		if ms < 1 {
+			ms = 1
+		}
+		args = append(args, "PX", ms)
+	}
+	_, err = db.Do(ctx, args...)
+
+	return err
+}
+
+// BruteForceStore is a yarf.BruteForceStore keeping the records as JSON values under Prefix,
+// so blocked IPs and accounts are shared by all the instances.
+type BruteForceStore struct {
+	DB     Doer
+	Prefix string
+}
+
+// NewBruteForceStore creates a BruteForceStore with the "yarf:bruteforce:" key prefix.
+func NewBruteForceStore(db Doer) *BruteForceStore {
+	return &BruteForceStore{
+		DB:     db,
+		Prefix: "yarf:bruteforce:",
+	}
+}
+
+// Get retrieves the record for a key, or an empty record if it doesn't exist.
+func (s *BruteForceStore) Get(key string) (yarf.BruteForceRecord, error) {
+	var r yarf.BruteForceRecord
+	_, err := get(context.Background(), s.DB, s.Prefix+key, &r)
+
+	return r, err
+}
+
+// Set saves the record for a key, to be discarded after ttl.
+func (s *BruteForceStore) Set(key string, r yarf.BruteForceRecord, ttl time.Duration) error {
+	return set(context.Background(), s.DB, s.Prefix+key, r, ttl)
+}
+
+// Del removes the record for a key.
+func (s *BruteForceStore) Del(key string) error {
+	_, err := s.DB.Do(context.Background(), "DEL", s.Prefix+key)
+	return err
+}
+
+// NonceStore is a yarf.NonceStore recording the nonces under Prefix with SET NX,
+// so a nonce is only accepted once across all the instances.
+type NonceStore struct {
+	DB     Doer
+	Prefix string
+}
+
+// NewNonceStore creates a NonceStore with the "yarf:nonce:" key prefix.
+func NewNonceStore(db Doer) *NonceStore {
+	return &NonceStore{
+		DB:     db,
+		Prefix: "yarf:nonce:",
+	}
+}
+
+// Add records the nonce for ttl, and reports if it wasn't recorded yet.
+func (s *NonceStore) Add(nonce string, ttl time.Duration) (bool, error) {
+	ms := int64(ttl / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	reply, err := s.DB.Do(context.Background(), "SET", s.Prefix+nonce, "1", "PX", ms, "NX")
+	if err != nil {
+		return false, err
+	}
+
+	// SET NX replies nil when the key exists
+	return reply != nil, nil
+}
+
+// APIKeyStore is a yarf.APIKeyStore keeping the keys as JSON values under Prefix, keyed by hash.
+type APIKeyStore struct {
+	DB     Doer
+	Prefix string
+}
+
+// NewAPIKeyStore creates an APIKeyStore with the "yarf:apikey:" key prefix.
+func NewAPIKeyStore(db Doer) *APIKeyStore {
+	return &APIKeyStore{
+		DB:     db,
+		Prefix: "yarf:apikey:",
+	}
+}
+
+// APIKey returns the key with the hash, or nil if it doesn't exist.
+func (s *APIKeyStore) APIKey(ctx context.Context, hash string) (*yarf.APIKey, error) {
+	k := new(yarf.APIKey)
+	ok, err := get(ctx, s.DB, s.Prefix+hash, k)
+	if !ok || err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// Save stores the key under its hash.
+func (s *APIKeyStore) Save(ctx context.Context, k *yarf.APIKey) error {
+	return set(ctx, s.DB, s.Prefix+k.Hash, k, 0)
+}
+
+// Touch records the last use of the key with the hash.
+// Concurrent updates of the key can be lost, as it's read and written back.
+func (s *APIKeyStore) Touch(ctx context.Context, hash string, t time.Time) error {
+	k, err := s.APIKey(ctx, hash)
+	if k == nil || err != nil {
+		return err
+	}
+	k.LastUsed = t
+
+	return s.Save(ctx, k)
+}
+
+// TenantStore is a yarf.TenantStore loading the tenants from JSON values under Prefix, keyed by ID.
+type TenantStore struct {
+	DB     Doer
+	Prefix string
+}
+
+// NewTenantStore creates a TenantStore with the "yarf:tenant:" key prefix.
+func NewTenantStore(db Doer) *TenantStore {
+	return &TenantStore{
+		DB:     db,
+		Prefix: "yarf:tenant:",
+	}
+}
+
+// Tenant returns the tenant with the ID, or nil if it doesn't exist.
+func (s *TenantStore) Tenant(ctx context.Context, id string) (*yarf.Tenant, error) {
+	t := new(yarf.Tenant)
+	ok, err := get(ctx, s.DB, s.Prefix+id, t)
+	if !ok || err != nil {
+		return nil, err
+	}
+	if t.ID == "" {
+		t.ID = id
+	}
+
+	return t, nil
+}
+
+// Save stores the tenant under its ID.
+func (s *TenantStore) Save(ctx context.Context, t *yarf.Tenant) error {
+	return set(ctx, s.DB, s.Prefix+t.ID, t, 0)
+}
+
+// Cache stores JSON values with expiration under Prefix, shared by all the instances,
+// as the MemoryCache does for a single one.
+type Cache struct {
+	DB     Doer
+	Prefix string
+
+	// TTL is the lifetime of the values stored by Load.
+	TTL time.Duration
+}
+
+// NewCache creates a Cache with the "yarf:cache:" key prefix, loading values for 5 minutes.
+func NewCache(db Doer) *Cache {
+	return &Cache{
+		DB:     db,
+		Prefix: "yarf:cache:",
+		TTL:    5 * time.Minute,
+	}
+}
+
+// Get decodes the value of the key into v, and reports if it was found.
+func (c *Cache) Get(ctx context.Context, key string, v interface{}) (bool, error) {
+	return get(ctx, c.DB, c.Prefix+key, v)
+}
+
+// Set stores the value of the key for ttl. 0 means it doesn't expire.
+func (c *Cache) Set(ctx context.Context, key string, v interface{}, ttl time.Duration) error {
+	return set(ctx, c.DB, c.Prefix+key, v, ttl)
+}
+
+// Del removes the keys.
+func (c *Cache) Del(ctx context.Context, keys ...string) error {
+	args := []interface{}{"DEL"}
+	for _, k := range keys {
+		args = append(args, c.Prefix+k)
+	}
+	_, err := c.DB.Do(ctx, args...)
+
+	return err
+}
+
+// Load decodes the value of the key into v, or calls load to fill v and stores it for the cache TTL.
+// Errors of load aren't cached.
+func (c *Cache) Load(ctx context.Context, key string, v interface{}, load func() error) error {
+	ok, err := c.Get(ctx, key, v)
+	if ok || err != nil {
+		return err
+	}
+
+	if err := load(); err != nil {
+		return err
+	}
+
+	return c.Set(ctx, key, v, c.TTL)
+}
+
+// QuotaStore is a yarf.QuotaStore counting the quota usage under Prefix with INCRBY,
+// so the quotas are shared by all the instances.
+type QuotaStore struct {
+	DB     Doer
+	Prefix string
+}
+
+// NewQuotaStore creates a QuotaStore with the "yarf:quota:" key prefix.
+func NewQuotaStore(db Doer) *QuotaStore {
+	return &QuotaStore{
+		DB:     db,
+		Prefix: "yarf:quota:",
+	}
+}
+
+// Incr adds n to the counter of the key and returns the new value, setting the counter expiration.
+func (s *QuotaStore) Incr(key string, n int64, expire time.Time) (int64, error) {
+	ctx := context.Background()
+	reply, err := s.DB.Do(ctx, "INCRBY", s.Prefix+key, n)
+	if err != nil {
+		return 0, err
+	}
+	v, ok := reply.(int64)
+	if !ok {
+		return 0, ErrProtocol
+	}
+
+	// Setting the expiration again is harmless, and heals counters left without one
+	_, err = s.DB.Do(ctx, "PEXPIREAT", s.Prefix+key, expire.UnixNano()/int64(time.Millisecond))
+
+	return v, err
+}
+
+// Get returns the value of the counter of the key.
+func (s *QuotaStore) Get(key string) (int64, error) {
+	reply, err := s.DB.Do(context.Background(), "GET", s.Prefix+key)
+	if err != nil || reply == nil {
+		return 0, err
+	}
+
+	var data string
+	switch r := reply.(type) {
+	case []byte:
+		data = string(r)
+	case string:
+		data = r
+	default:
+		return 0, ErrProtocol
+	}
+
+	return strconv.ParseInt(data, 10, 64)
+}
+
+// SessionStore is a yarf.SessionStore keeping the session values as JSON values under Prefix,
+// expiring with the sessions.
+type SessionStore struct {
+	DB     Doer
+	Prefix string
+}
+
+// NewSessionStore creates a SessionStore with the "yarf:session:" key prefix.
+func NewSessionStore(db Doer) *SessionStore {
+	return &SessionStore{
+		DB:     db,
+		Prefix: "yarf:session:",
+	}
+}
+
+// Load returns the values of the session, or nil if it doesn't exist or expired.
+func (s *SessionStore) Load(ctx context.Context, id string) (map[string]string, error) {
+	var values map[string]string
+	if ok, err := get(ctx, s.DB, s.Prefix+id, &values); !ok || err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = make(map[string]string)
+	}
+
+	return values, nil
+}
+
+// Save stores the values of the session for ttl.
+func (s *SessionStore) Save(ctx context.Context, id string, values map[string]string, ttl time.Duration) error {
+	return set(ctx, s.DB, s.Prefix+id, values, ttl)
+}
+
+// Delete removes the session.
+func (s *SessionStore) Delete(ctx context.Context, id string) error {
+	_, err := s.DB.Do(ctx, "DEL", s.Prefix+id)
+	return err
+}
+
+// IdempotencyStore is a yarf.IdempotencyStore keeping the idempotency keys and their responses as JSON values under Prefix.
+// Keys are reserved with SET NX, so concurrent retries run once across all the instances.
+type IdempotencyStore struct {
+	DB     Doer
+	Prefix string
+}
+
+// NewIdempotencyStore creates an IdempotencyStore with the "yarf:idempotency:" key prefix.
+func NewIdempotencyStore(db Doer) *IdempotencyStore {
+	return &IdempotencyStore{
+		DB:     db,
+		Prefix: "yarf:idempotency:",
+	}
+}
+
+// Reserve records the key for ttl and returns nil, or returns the record of the key if it already exists.
+func (s *IdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*yarf.IdempotencyRecord, error) {
+	data, err := json.Marshal(yarf.IdempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+	ms := int64(ttl / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+
+	// The key can expire between SET NX and GET, so the reservation is tried again once
+	for i := 0; i < 2; i++ {
+		reply, err := s.DB.Do(ctx, "SET", s.Prefix+key, data, "PX", ms, "NX")
+		if err != nil {
+			return nil, err
+		}
+		if reply != nil {
+			return nil, nil
+		}
+
+		r := new(yarf.IdempotencyRecord)
+		if ok, err := get(ctx, s.DB, s.Prefix+key, r); ok || err != nil {
+			return r, err
+		}
+	}
+
+	return nil, ErrProtocol
+}
+
+// Complete stores the response of the key for ttl.
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, r *yarf.IdempotencyRecord, ttl time.Duration) error {
+	return set(ctx, s.DB, s.Prefix+key, r, ttl)
+}
+
+// Release removes the key.
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	_, err := s.DB.Do(ctx, "DEL", s.Prefix+key)
+	return err
+}
+
+// RateLimitStore is a yarf.RateLimitStore counting the requests under Prefix with INCRBY, shared by all the instances.
+// Redis can't refill a token bucket without a script, so the bucket is approximated with fixed windows
+// of burst/rate seconds allowing burst requests each: the average rate is kept, but up to twice the burst
+// can pass around the end of a window.
+type RateLimitStore struct {
+	DB     Doer
+	Prefix string
+
+	// Clock tells the time to split the windows. nil uses the system clock.
+	Clock yarf.Clock
+}
+
+// NewRateLimitStore creates a RateLimitStore with the "yarf:ratelimit:" key prefix.
+func NewRateLimitStore(db Doer) *RateLimitStore {
+	return &RateLimitStore{
+		DB:     db,
+		Prefix: "yarf:ratelimit:",
+	}
+}
+
+// Take counts a request in the current window of the key, and returns the time left in the window if it's full.
+func (s *RateLimitStore) Take(key string, rate float64, burst int) (time.Duration, error) {
+	window := time.Duration(float64(burst) / rate * float64(time.Second))
+	if window < time.Millisecond {
+		window = time.Millisecond
+	}
+
+	now := time.Now()
+	if s.Clock != nil {
+		now = s.Clock.Now()
+	}
+	start := now.Truncate(window)
+	end := start.Add(window)
+	k := s.Prefix + key + ":" + strconv.FormatInt(start.UnixNano()/int64(time.Millisecond), 10)
+
+	ctx := context.Background()
+	reply, err := s.DB.Do(ctx, "INCRBY", k, 1)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, ErrProtocol
+	}
+	if _, err := s.DB.Do(ctx, "PEXPIREAT", k, end.UnixNano()/int64(time.Millisecond)); err != nil {
+		return 0, err
+	}
+
+	if n > int64(burst) {
+		return end.Sub(now), nil
+	}
+
+	return 0, nil
+}
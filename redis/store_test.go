@@ -0,0 +1,273 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yarf-framework/yarf"
+	"github.com/yarf-framework/yarf/yarftest"
+)
+
+func TestBruteForceStore(t *testing.T) {
+	s := newFakeServer(t)
+	store := NewBruteForceStore(New(s.ln.Addr().String()))
+
+	var _ yarf.BruteForceStore = store
+
+	if r, err := store.Get("ip:1.2.3.4"); err != nil || r.Failures != 0 {
+		t.Errorf("Expected empty record, got %+v %v", r, err)
+	}
+
+	until := time.Now().Add(time.Minute).Round(time.Second)
+	if err := store.Set("ip:1.2.3.4", yarf.BruteForceRecord{Failures: 3, Until: until}, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := store.Get("ip:1.2.3.4"); err != nil || r.Failures != 3 || !r.Until.Equal(until) {
+		t.Errorf("Unexpected record %+v %v", r, err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if r, _ := store.Get("ip:1.2.3.4"); r.Failures != 0 {
+		t.Errorf("Expected expired record, got %+v", r)
+	}
+
+	store.Set("account:ann", yarf.BruteForceRecord{Failures: 1}, time.Minute)
+	store.Del("account:ann")
+	if r, _ := store.Get("account:ann"); r.Failures != 0 {
+		t.Errorf("Expected deleted record, got %+v", r)
+	}
+}
+
+func TestNonceStore(t *testing.T) {
+	s := newFakeServer(t)
+	store := NewNonceStore(New(s.ln.Addr().String()))
+
+	var _ yarf.NonceStore = store
+
+	if ok, err := store.Add("abc", 20*time.Millisecond); err != nil || !ok {
+		t.Fatalf("Expected new nonce, got %v %v", ok, err)
+	}
+	if ok, err := store.Add("abc", 20*time.Millisecond); err != nil || ok {
+		t.Errorf("Expected replayed nonce, got %v %v", ok, err)
+	}
+	if ok, _ := store.Add("def", 20*time.Millisecond); !ok {
+		t.Error("Expected other nonce to be new")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if ok, _ := store.Add("abc", time.Minute); !ok {
+		t.Error("Expected expired nonce to be accepted again")
+	}
+}
+
+func TestQuotaStore(t *testing.T) {
+	s := newFakeServer(t)
+	store := NewQuotaStore(New(s.ln.Addr().String()))
+
+	var _ yarf.QuotaStore = store
+
+	if n, err := store.Get("acme:day"); err != nil || n != 0 {
+		t.Errorf("Expected empty counter, got %d %v", n, err)
+	}
+
+	expire := time.Now().Add(20 * time.Millisecond)
+	for i := 1; i <= 3; i++ {
+		if n, err := store.Incr("acme:day", 1, expire); err != nil || n != int64(i) {
+			t.Fatalf("Expected counter %d, got %d %v", i, n, err)
+		}
+	}
+	if n, _ := store.Incr("acme:day", -1, expire); n != 2 {
+		t.Errorf("Expected decremented counter, got %d", n)
+	}
+	if n, err := store.Get("acme:day"); err != nil || n != 2 {
+		t.Errorf("Expected counter 2, got %d %v", n, err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if n, _ := store.Incr("acme:day", 1, time.Now().Add(time.Minute)); n != 1 {
+		t.Errorf("Expected expired counter to start again, got %d", n)
+	}
+}
+
+func TestAPIKeyStore(t *testing.T) {
+	s := newFakeServer(t)
+	store := NewAPIKeyStore(New(s.ln.Addr().String()))
+	ctx := context.Background()
+
+	var _ yarf.APIKeyStore = store
+
+	if k, err := store.APIKey(ctx, "missing"); k != nil || err != nil {
+		t.Errorf("Expected no key, got %+v %v", k, err)
+	}
+
+	key, k, _ := yarf.GenerateAPIKey("sk_test")
+	k.Subject = "billing"
+	k.Scopes = []string{"invoices:read"}
+	if err := store.Save(ctx, k); err != nil {
+		t.Fatal(err)
+	}
+
+	used := time.Now().Round(time.Second)
+	if err := store.Touch(ctx, yarf.HashAPIKey(key), used); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.APIKey(ctx, yarf.HashAPIKey(key))
+	if err != nil || got.Subject != "billing" || !got.HasScope("invoices:read") || !got.LastUsed.Equal(used) {
+		t.Errorf("Unexpected key %+v %v", got, err)
+	}
+}
+
+func TestTenantStore(t *testing.T) {
+	s := newFakeServer(t)
+	store := NewTenantStore(New(s.ln.Addr().String()))
+	ctx := context.Background()
+
+	var _ yarf.TenantStore = store
+
+	if err := store.Save(ctx, &yarf.Tenant{ID: "acme", RateLimit: 10, Config: map[string]interface{}{"plan": "pro"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tenant, err := store.Tenant(ctx, "acme")
+	if err != nil || tenant.ID != "acme" || tenant.RateLimit != 10 || tenant.String("plan") != "pro" {
+		t.Errorf("Unexpected tenant %+v %v", tenant, err)
+	}
+	if tenant, err := store.Tenant(ctx, "missing"); tenant != nil || err != nil {
+		t.Errorf("Expected missing tenant, got %+v %v", tenant, err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	s := newFakeServer(t)
+	c := NewCache(New(s.ln.Addr().String()))
+	ctx := context.Background()
+
+	calls := 0
+	load := func(v *string) func() error {
+		return func() error {
+			calls++
+			*v = "loaded"
+			return nil
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		var v string
+		if err := c.Load(ctx, "k", &v, load(&v)); err != nil || v != "loaded" {
+			t.Errorf("Unexpected value %q %v", v, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single load, got %d", calls)
+	}
+
+	fail := errors.New("fail")
+	var v string
+	if err := c.Load(ctx, "err", &v, func() error { return fail }); err != fail {
+		t.Errorf("Expected load error, got %v", err)
+	}
+	if ok, _ := c.Get(ctx, "err", &v); ok {
+		t.Error("Load errors shouldn't be cached")
+	}
+
+	c.Del(ctx, "k")
+	if ok, _ := c.Get(ctx, "k", &v); ok {
+		t.Error("Expected deleted value")
+	}
+}
+
+func TestSessionStore(t *testing.T) {
+	s := newFakeServer(t)
+	store := NewSessionStore(New(s.ln.Addr().String()))
+	ctx := context.Background()
+
+	var _ yarf.SessionStore = store
+
+	if values, err := store.Load(ctx, "a"); values != nil || err != nil {
+		t.Errorf("Expected missing session, got %v %v", values, err)
+	}
+
+	if err := store.Save(ctx, "a", map[string]string{"user": "ann"}, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if values, err := store.Load(ctx, "a"); err != nil || values["user"] != "ann" {
+		t.Errorf("Unexpected session %v %v", values, err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if values, _ := store.Load(ctx, "a"); values != nil {
+		t.Errorf("Expected expired session, got %v", values)
+	}
+
+	store.Save(ctx, "b", map[string]string{}, time.Minute)
+	if values, _ := store.Load(ctx, "b"); values == nil {
+		t.Error("Expected empty session")
+	}
+	store.Delete(ctx, "b")
+	if values, _ := store.Load(ctx, "b"); values != nil {
+		t.Errorf("Expected deleted session, got %v", values)
+	}
+}
+
+func TestIdempotencyStore(t *testing.T) {
+	s := newFakeServer(t)
+	store := NewIdempotencyStore(New(s.ln.Addr().String()))
+	ctx := context.Background()
+
+	var _ yarf.IdempotencyStore = store
+
+	if r, err := store.Reserve(ctx, "k", "fp", 20*time.Millisecond); r != nil || err != nil {
+		t.Fatalf("Expected reserved key, got %+v %v", r, err)
+	}
+	if r, err := store.Reserve(ctx, "k", "other", time.Minute); err != nil || r == nil || r.Fingerprint != "fp" || r.Done {
+		t.Errorf("Expected running record, got %+v %v", r, err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if r, _ := store.Reserve(ctx, "k", "fp", time.Minute); r != nil {
+		t.Errorf("Expected expired key, got %+v", r)
+	}
+
+	rec := &yarf.IdempotencyRecord{Fingerprint: "fp", Done: true, Status: 201, Body: []byte("ok")}
+	if err := store.Complete(ctx, "k", rec, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := store.Reserve(ctx, "k", "fp", time.Minute); err != nil || r == nil || !r.Done || r.Status != 201 || string(r.Body) != "ok" {
+		t.Errorf("Expected stored response, got %+v %v", r, err)
+	}
+
+	store.Release(ctx, "k")
+	if r, _ := store.Reserve(ctx, "k", "fp", time.Minute); r != nil {
+		t.Errorf("Expected released key, got %+v", r)
+	}
+}
+
+func TestRateLimitStore(t *testing.T) {
+	s := newFakeServer(t)
+	store := NewRateLimitStore(New(s.ln.Addr().String()))
+	clock := yarftest.NewClock(time.Now().Truncate(time.Minute).Add(time.Minute))
+	store.Clock = clock
+
+	var _ yarf.RateLimitStore = store
+
+	// 2 requests per 4 seconds window
+	for i := 0; i < 2; i++ {
+		if wait, err := store.Take("tenant:acme", 0.5, 2); wait != 0 || err != nil {
+			t.Errorf("Request %d within burst should pass, got %v %v", i, wait, err)
+		}
+	}
+	clock.Advance(time.Second)
+	if wait, err := store.Take("tenant:acme", 0.5, 2); wait != 3*time.Second || err != nil {
+		t.Errorf("Expected to wait for the next window, got %v %v", wait, err)
+	}
+	if wait, _ := store.Take("tenant:other", 0.5, 2); wait != 0 {
+		t.Errorf("Expected separate keys, got %v", wait)
+	}
+
+	clock.Advance(3 * time.Second)
+	if wait, _ := store.Take("tenant:acme", 0.5, 2); wait != 0 {
+		t.Errorf("Expected a new window, got %v", wait)
+	}
+}
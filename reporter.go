@@ -0,0 +1,164 @@
+package yarf
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ErrorReport describes a server side error or a panic, as received by the ErrorReporter.
+type ErrorReport struct {
+	Time    time.Time
+	Context *Context     // Context of the failed request, only valid during the Report call
+	Error   error        // Error returned by the request flow, a *PanicError for panics
+	Panic   bool         // The request panicked
+	Stack   []byte       // Stack trace of the panic, or of the report call for errors
+	Status  int          // Status code sent to the client
+	Route   string       // Pattern of the matched route, if any
+	Meta    *RouteMeta   // Metadata of the matched route, if any
+	Request *RequestDump // Sanitized copy of the request, still valid after the Report call
+}
+
+// ErrorReporter receives every 5xx error and panic of the requests, to send them to crash reporting services.
+// Reports are sent synchronously from the request goroutine, so slow reporters should queue them.
+type ErrorReporter interface {
+	Report(*ErrorReport)
+}
+
+// ErrorReporterFunc adapts a function into an ErrorReporter.
+type ErrorReporterFunc func(*ErrorReport)
+
+// Report calls f(r).
+func (f ErrorReporterFunc) Report(r *ErrorReport) {
+	f(r)
+}
+
+// errorWriter is an ErrorReporter writing human readable reports.
+type errorWriter struct {
+	w io.Writer
+	sync.Mutex
+}
+
+// NewErrorWriter creates an ErrorReporter writing each report and its stack trace into w.
+// Request URLs are masked by the application Redactor.
+func NewErrorWriter(w io.Writer) ErrorReporter {
+	return &errorWriter{w: w}
+}
+
+// NewStderrReporter creates an ErrorReporter writing the reports into the standard error output.
+func NewStderrReporter() ErrorReporter {
+	return NewErrorWriter(os.Stderr)
+}
+
+// Report writes the report.
+func (w *errorWriter) Report(r *ErrorReport) {
+	w.Lock()
+	defer w.Unlock()
+
+	req := r.Context.Request
+	fmt.Fprintf(w.w, "%s %d %s %s route=%q: %s\n",
+		r.Time.Format(time.RFC3339), r.Status, req.Method, r.Context.redactor().URL(req.URL), r.Route, r.Error)
+	if r.Panic {
+		w.w.Write(r.Stack)
+	}
+}
+
+// SentryHub is the subset of a Sentry client used by NewSentryReporter.
+// The hub of the Sentry SDK is adapted with a few lines, keeping yarf free of the dependency:
+//
+//	type sentryHub struct{ *sentry.Hub }
+//
+//	func (h sentryHub) Capture(err error, tags map[string]string, req *http.Request) {
+//		h.Clone().WithScope(func(s *sentry.Scope) {
+//			s.SetTags(tags)
+//			s.SetRequest(req)
+//			h.CaptureException(err)
+//		})
+//	}
+//
+//	y.ReportErrors(yarf.NewSentryReporter(sentryHub{sentry.CurrentHub()}))
+type SentryHub interface {
+	Capture(err error, tags map[string]string, req *http.Request)
+}
+
+// NewSentryReporter creates an ErrorReporter sending the reports to a Sentry hub,
+// tagged with the route, status and method. The request headers are masked by the application Redactor.
+func NewSentryReporter(hub SentryHub) ErrorReporter {
+	return ErrorReporterFunc(func(r *ErrorReport) {
+		tags := map[string]string{
+			"route":  r.Route,
+			"status": fmt.Sprint(r.Status),
+			"method": r.Context.Request.Method,
+			"panic":  fmt.Sprint(r.Panic),
+		}
+
+		req := r.Context.Request.Clone(r.Context.Request.Context())
+		req.Header = r.Context.redactor().Header(req.Header)
+		req.URL.RawQuery = r.Context.redactor().Query(req.URL.RawQuery)
+
+		hub.Capture(r.Error, tags, req)
+	})
+}
+
+// ReportErrors registers a reporter receiving every 5xx error and panic of the requests.
+// Outside debug mode panics are re-raised after being reported, so PanicHandler still receives them.
+//
+//	y.ReportErrors(yarf.NewStderrReporter())
+func (y *Yarf) ReportErrors(r ErrorReporter) {
+	y.lock.Lock()
+	defer y.lock.Unlock()
+
+	y.reporters = append(y.reporters, r)
+}
+
+// reportError sends the error to the reporters if it's a server side error.
+func (y *Yarf) reportError(c *Context, err error) {
+	if err == nil || len(y.reporters) == 0 {
+		return
+	}
+
+	status := http.StatusInternalServerError
+	if yerr, ok := err.(YError); ok {
+		status = yerr.Code()
+	}
+	if status < 500 {
+		return
+	}
+
+	r := &ErrorReport{
+		Time:    time.Now(),
+		Context: c,
+		Error:   err,
+		Status:  status,
+		Route:   c.RoutePattern(),
+		Meta:    c.RouteMeta(),
+		Request: c.Dump(),
+	}
+	if perr, ok := err.(*PanicError); ok {
+		r.Panic = true
+		r.Stack = perr.Stack
+	} else {
+		r.Stack = debug.Stack()
+	}
+
+	for _, rep := range y.reporters {
+		rep.Report(r)
+	}
+}
+
+// reportPanic recovers the panics of the request outside debug mode, reports them, and panics again.
+func (y *Yarf) reportPanic(c *Context) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if v != http.ErrAbortHandler {
+		y.reportError(c, &PanicError{Value: v, Stack: debug.Stack()})
+	}
+
+	panic(v)
+}
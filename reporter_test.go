@@ -0,0 +1,115 @@
+package yarf
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReportErrors(t *testing.T) {
+	var reports []ErrorReport
+	y := New()
+	y.ReportErrors(ErrorReporterFunc(func(r *ErrorReport) {
+		reports = append(reports, *r)
+	}))
+	y.AddRoute("/fail", new(FailingResource)).Set("owner", "payments")
+	y.Add("/ok", new(OKResource))
+
+	for _, p := range []string{"/fail", "/ok", "/missing"} {
+		y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", p, nil))
+	}
+
+	// Only the 5xx error is reported
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 report, got %+v", reports)
+	}
+	r := reports[0]
+	if r.Status != 500 || r.Route != "/fail" || r.Panic || r.Error.Error() != "database is down" {
+		t.Errorf("Unexpected report %+v", r)
+	}
+	if r.Meta.Get("owner") != "payments" || len(r.Stack) == 0 {
+		t.Errorf("Expected route metadata and stack on report %+v", r)
+	}
+}
+
+func TestReportPanics(t *testing.T) {
+	var reports []ErrorReport
+	y := New()
+	y.ReportErrors(ErrorReporterFunc(func(r *ErrorReport) {
+		reports = append(reports, *r)
+	}))
+	y.Add("/panic", new(PanicResource))
+
+	// Outside debug mode the panic is reported and raised again
+	func() {
+		defer func() {
+			if v := recover(); v != "something broke" {
+				t.Errorf("Expected the panic to be raised again, got %v", v)
+			}
+		}()
+		y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	}()
+
+	// On debug mode the panic is recovered and reported
+	y.Debug = true
+	y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+
+	if len(reports) != 2 {
+		t.Fatalf("Expected 2 reports, got %+v", reports)
+	}
+	for _, r := range reports {
+		if !r.Panic || r.Status != 500 || r.Route != "/panic" || !bytes.Contains(r.Stack, []byte("PanicResource")) {
+			t.Errorf("Unexpected panic report %+v", r)
+		}
+	}
+}
+
+func TestErrorWriter(t *testing.T) {
+	var buf bytes.Buffer
+	y := New()
+	y.ReportErrors(NewErrorWriter(&buf))
+	y.Add("/fail", new(FailingResource))
+
+	y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fail?token=abc", nil))
+
+	out := buf.String()
+	if !strings.Contains(out, `500 GET /fail?token=[REDACTED] route="/fail": database is down`) {
+		t.Errorf("Unexpected report %q", out)
+	}
+}
+
+type testSentryHub struct {
+	err  error
+	tags map[string]string
+	req  *http.Request
+}
+
+func (h *testSentryHub) Capture(err error, tags map[string]string, req *http.Request) {
+	h.err, h.tags, h.req = err, tags, req
+}
+
+func TestSentryReporter(t *testing.T) {
+	hub := new(testSentryHub)
+	y := New()
+	y.ReportErrors(NewSentryReporter(hub))
+	y.Add("/fail", new(FailingResource))
+
+	req := httptest.NewRequest("GET", "/fail?token=abc", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	y.ServeHTTP(httptest.NewRecorder(), req)
+
+	if hub.err == nil || hub.err.Error() != "database is down" {
+		t.Fatalf("Unexpected error %v", hub.err)
+	}
+	if hub.tags["route"] != "/fail" || hub.tags["status"] != "500" || hub.tags["panic"] != "false" {
+		t.Errorf("Unexpected tags %v", hub.tags)
+	}
+	if hub.req.Header.Get("Authorization") != RedactMask || hub.req.URL.RawQuery != "token=[REDACTED]" {
+		t.Errorf("Expected a redacted request, got %v %v", hub.req.Header, hub.req.URL)
+	}
+	if req.Header.Get("Authorization") != "Bearer abc" {
+		t.Error("The original request was modified")
+	}
+}
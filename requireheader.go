@@ -0,0 +1,111 @@
+package yarf
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// MetaRequireHeaders is the RouteMeta key holding the request headers required by a route, as a []HeaderRequirement.
+const MetaRequireHeaders = "headers.require"
+
+// conditionalHeaders are the request headers whose absence is reported with a 428 error.
+var conditionalHeaders = []string{"If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since", "If-Range"}
+
+// HeaderRequirement is a request header required by a route.
+type HeaderRequirement struct {
+	// Name of the header.
+	Name string
+
+	// Pattern is the value expected, where "*" matches any characters, like "2.*". Empty accepts any value.
+	Pattern string
+}
+
+// Match checks if a header value matches the requirement pattern.
+func (h HeaderRequirement) Match(value string) bool {
+	if h.Pattern == "" {
+		return true
+	}
+
+	parts := strings.Split(h.Pattern, "*")
+	if len(parts) == 1 {
+		return value == h.Pattern
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+
+	// Match the middle parts as early as possible, leaving the rest for the last one
+	last := len(parts) - 1
+	for _, p := range parts[1:last] {
+		i := strings.Index(value, p)
+		if i < 0 {
+			return false
+		}
+		value = value[i+len(p):]
+	}
+
+	return strings.HasSuffix(value, parts[last])
+}
+
+// Regexp returns the pattern as a regular expression, like `^2\..*$`, for documentation tools.
+// It returns an empty string if any value is accepted.
+func (h HeaderRequirement) Regexp() string {
+	if h.Pattern == "" {
+		return ""
+	}
+
+	parts := strings.Split(h.Pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+
+	return "^" + strings.Join(parts, ".*") + "$"
+}
+
+// RequireHeader declares a request header required by the route, with a pattern its value must match,
+// and returns the RouteMeta to allow chaining. "*" in the pattern matches any characters, and an empty pattern any value.
+// Requests without the header get a 400 error, or a 428 error for conditional headers like If-Match,
+// and requests with a value not matching the pattern a 400 error, before the handler runs.
+//
+//	y.AddRoute("/orders", new(Orders)).RequireHeader("X-Api-Version", "2.*")
+//	y.AddRoute("/orders/:id", new(Order)).RequireHeader("If-Match", "")
+func (m *RouteMeta) RequireHeader(name, pattern string) *RouteMeta {
+	existing := m.RequiredHeaders()
+
+	return m.Set(MetaRequireHeaders, append(existing, HeaderRequirement{Name: http.CanonicalHeaderKey(name), Pattern: pattern}))
+}
+
+// RequiredHeaders returns the request headers required by the route.
+func (m *RouteMeta) RequiredHeaders() []HeaderRequirement {
+	h, _ := m.Get(MetaRequireHeaders).([]HeaderRequirement)
+
+	return h
+}
+
+// checkHeaders checks the request headers required by the route.
+func (r *route) checkHeaders(c *Context) error {
+	for _, h := range r.meta.RequiredHeaders() {
+		values := c.Request.Header.Values(h.Name)
+		if len(values) == 0 {
+			if containsString(conditionalHeaders, h.Name) {
+				e := ErrorPreconditionRequired()
+				e.ErrorBody = "Missing required header " + h.Name
+				return e
+			}
+
+			e := ErrorBadRequest()
+			e.ErrorBody = "Missing required header " + h.Name
+			return e
+		}
+
+		if !h.Match(strings.TrimSpace(values[0])) {
+			e := ErrorBadRequest()
+			e.ErrorBody = "Invalid " + h.Name + " header, expected " + h.Pattern
+			return e
+		}
+	}
+
+	return nil
+}
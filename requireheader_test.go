@@ -0,0 +1,74 @@
+package yarf
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeaderRequirementMatch(t *testing.T) {
+	tests := []struct {
+		pattern, value string
+		match          bool
+	}{
+		{"", "anything", true},
+		{"2", "2", true},
+		{"2", "2.1", false},
+		{"2.*", "2.1", true},
+		{"2.*", "2.", true},
+		{"2.*", "20.1", false},
+		{"*-beta", "2.1-beta", true},
+		{"*-beta", "2.1-beta.1", false},
+		{"v*.*", "v2.1", true},
+		{"v*.*", "v21", false},
+		{"*", "", true},
+		{"a*a", "a", false},
+	}
+
+	for _, tt := range tests {
+		if m := (HeaderRequirement{Pattern: tt.pattern}).Match(tt.value); m != tt.match {
+			t.Errorf("%q with %q: expected %v, got %v", tt.pattern, tt.value, tt.match, m)
+		}
+	}
+
+	if re := (HeaderRequirement{Pattern: "2.*"}).Regexp(); re != `^2\..*$` {
+		t.Errorf("Unexpected regexp %s", re)
+	}
+}
+
+func TestRequireHeader(t *testing.T) {
+	y := New()
+	y.AddRoute("/orders/:id", new(OverrideResource)).RequireHeader("x-api-version", "2.*").RequireHeader("If-Match", "")
+	y.Add("/items/:id", new(OverrideResource))
+
+	tests := []struct {
+		version, match string
+		status         int
+		body           string
+	}{
+		{"2.1", `"v1"`, 200, "GET GET"},
+		{"", `"v1"`, 400, "Missing required header X-Api-Version"},
+		{"1.9", `"v1"`, 400, "Invalid X-Api-Version header, expected 2.*"},
+		{"2.0", "", 428, "Missing required header If-Match"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/orders/1", nil)
+		if tt.version != "" {
+			req.Header.Set("X-Api-Version", tt.version)
+		}
+		if tt.match != "" {
+			req.Header.Set("If-Match", tt.match)
+		}
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		if res.Code != tt.status || res.Body.String() != tt.body {
+			t.Errorf("%q %q: expected %d '%s', got %d '%s'", tt.version, tt.match, tt.status, tt.body, res.Code, res.Body.String())
+		}
+	}
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/items/1", nil))
+	if res.Code != 200 {
+		t.Errorf("Routes without requirements shouldn't check headers, got %d", res.Code)
+	}
+}
@@ -0,0 +1,64 @@
+package yarf
+
+import (
+	"net/http"
+)
+
+// responseHook is a http.ResponseWriter wrapper that runs a function right before the response headers are sent.
+// It's used by middleware that needs to set headers once the handler has finished its work.
+type responseHook struct {
+	http.ResponseWriter
+
+	before func()
+	fired  bool
+}
+
+// hookResponse wraps the Context response so fn runs before the headers are sent.
+func hookResponse(c *Context, fn func()) *responseHook {
+	h := &responseHook{
+		ResponseWriter: c.Response,
+		before:         fn,
+	}
+	c.Response = h
+
+	return h
+}
+
+// fire runs the hook once.
+func (w *responseHook) fire() {
+	if w.fired {
+		return
+	}
+	w.fired = true
+
+	if w.before != nil {
+		w.before()
+	}
+}
+
+// WriteHeader runs the hook, unless the code is informational, and sends the status code.
+func (w *responseHook) WriteHeader(code int) {
+	if !informational(code) {
+		w.fire()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write runs the hook and writes data to the response.
+func (w *responseHook) Write(data []byte) (int, error) {
+	w.fire()
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush runs the hook and flushes the response if supported.
+func (w *responseHook) Flush() {
+	w.fire()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the original http.ResponseWriter, as used by http.ResponseController.
+func (w *responseHook) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
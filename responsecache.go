@@ -0,0 +1,330 @@
+package yarf
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MetaCache is the RouteMeta key holding the CachePolicy of the route.
+const MetaCache = "cache"
+
+// CachePolicy declares the responses of a route cacheable by the application ResponseCache.
+type CachePolicy struct {
+	// TTL is the time responses are served from the cache.
+	TTL time.Duration
+
+	// Keys are the surrogate keys of the responses, used to invalidate them.
+	// {name} placeholders are replaced by the route params, as in "user:{id}".
+	Keys []string
+
+	// Vary lists the request headers that make responses different, like Authorization or Accept-Language.
+	// Requests with Authorization or Cookie headers skip the cache unless they're listed here.
+	Vary []string
+}
+
+// Cacheable declares the GET and HEAD responses of the route cacheable for ttl, tagged with the surrogate keys,
+// and returns the RouteMeta to allow chaining.
+//
+//	y.AddRoute("/users/:id", new(User)).Cacheable(time.Minute, "user:{id}", "users")
+func (m *RouteMeta) Cacheable(ttl time.Duration, keys ...string) *RouteMeta {
+	return m.Set(MetaCache, CachePolicy{TTL: ttl, Keys: keys})
+}
+
+// CacheVary keeps a cached response per value of the request headers on a Cacheable route,
+// and returns the RouteMeta to allow chaining.
+// Listing Authorization or Cookie caches the responses of authenticated requests per credential.
+//
+//	y.AddRoute("/me", new(Profile)).Cacheable(time.Minute, "profiles").CacheVary("Authorization")
+func (m *RouteMeta) CacheVary(headers ...string) *RouteMeta {
+	p, _ := m.Get(MetaCache).(CachePolicy)
+	p.Vary = append(append([]string(nil), p.Vary...), headers...)
+
+	return m.Set(MetaCache, p)
+}
+
+// surrogateKey is the Context storage key for the surrogate keys added by the handler.
+type surrogateKey struct{}
+
+// SurrogateKeys tags the cached response of the request with more keys, like the ids of the listed items.
+// It has no effect on routes that aren't cacheable.
+func (c *Context) SurrogateKeys(keys ...string) {
+	current, _ := c.get(surrogateKey{}).([]string)
+	c.set(surrogateKey{}, append(current, keys...))
+}
+
+// InvalidateCache removes the responses tagged with any of the surrogate keys from the application ResponseCache.
+func (c *Context) InvalidateCache(keys ...string) {
+	if c.app != nil && c.app.Cache != nil {
+		c.app.Cache.Invalidate(keys...)
+	}
+}
+
+// ResponseCacheStats are the counters of a ResponseCache.
+type ResponseCacheStats struct {
+	Hits        uint64 // Responses served from the cache
+	Misses      uint64 // Requests handled because their response wasn't cached or expired
+	Invalidated uint64 // Responses removed by Invalidate
+}
+
+// cachedResponse is a response stored in the cache.
+type cachedResponse struct {
+	code    int
+	header  http.Header
+	body    []byte
+	keys    []string
+	created time.Time
+	expires time.Time
+}
+
+// ResponseCache stores in memory the responses of the routes declared Cacheable.
+// Only successful responses without cookies, private or no-store Cache-Control are stored.
+// Requests with Authorization or Cookie headers skip the cache, unless the route varies on them.
+// Middleware keeps running for every request, so cached responses are still authenticated,
+// and only the resource method is skipped.
+// Responses are buffered, so streaming handlers shouldn't be cacheable.
+// They're invalidated by their surrogate keys after writes, with y.Cache.Invalidate() or from the handlers:
+//
+//	func (r *User) Put(c *yarf.Context) error {
+//		...
+//		c.InvalidateCache("user:" + c.Param("id"), "users")
+//		return nil
+//	}
+type ResponseCache struct {
+	// MaxEntries is the maximum number of responses stored. Responses over the limit aren't cached.
+	MaxEntries int
+
+	// Clock tells the time to expire the responses. nil uses the system clock.
+	Clock Clock
+
+	entries map[string]*cachedResponse
+	index   map[string]map[string]struct{}
+	stats   ResponseCacheStats
+	lock    sync.Mutex
+}
+
+// NewResponseCache creates a ResponseCache storing up to 10000 responses.
+func NewResponseCache() *ResponseCache {
+	return &ResponseCache{
+		MaxEntries: 10000,
+		entries:    make(map[string]*cachedResponse),
+		index:      make(map[string]map[string]struct{}),
+	}
+}
+
+// Stats returns the current counters.
+func (rc *ResponseCache) Stats() ResponseCacheStats {
+	return ResponseCacheStats{
+		Hits:        atomic.LoadUint64(&rc.stats.Hits),
+		Misses:      atomic.LoadUint64(&rc.stats.Misses),
+		Invalidated: atomic.LoadUint64(&rc.stats.Invalidated),
+	}
+}
+
+// Invalidate removes the responses tagged with any of the surrogate keys, and returns how many were removed.
+func (rc *ResponseCache) Invalidate(keys ...string) int {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	n := 0
+	for _, k := range keys {
+		for id := range rc.index[k] {
+			if _, ok := rc.entries[id]; ok {
+				rc.remove(id)
+				n++
+			}
+		}
+	}
+	atomic.AddUint64(&rc.stats.Invalidated, uint64(n))
+
+	return n
+}
+
+// Purge removes all the responses.
+func (rc *ResponseCache) Purge() {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	rc.entries = make(map[string]*cachedResponse)
+	rc.index = make(map[string]map[string]struct{})
+}
+
+// remove deletes an entry and its index references. The cache must be locked.
+func (rc *ResponseCache) remove(id string) {
+	e, ok := rc.entries[id]
+	if !ok {
+		return
+	}
+	delete(rc.entries, id)
+
+	for _, k := range e.keys {
+		delete(rc.index[k], id)
+		if len(rc.index[k]) == 0 {
+			delete(rc.index, k)
+		}
+	}
+}
+
+// key builds the cache key of the request.
+func (rc *ResponseCache) key(c *Context, p CachePolicy) string {
+	k := c.Request.Method + " " + c.Request.URL.Path + "?" + c.Request.URL.RawQuery
+	for _, h := range p.Vary {
+		k += "\n" + h + ": " + strings.Join(c.Request.Header[http.CanonicalHeaderKey(h)], ",")
+	}
+
+	return k
+}
+
+// get returns the fresh response stored under id.
+func (rc *ResponseCache) get(id string, now time.Time) *cachedResponse {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	e, ok := rc.entries[id]
+	if !ok {
+		return nil
+	}
+	if now.After(e.expires) {
+		rc.remove(id)
+		return nil
+	}
+
+	return e
+}
+
+// store keeps the response under id, if there is room for it.
+func (rc *ResponseCache) store(id string, e *cachedResponse) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	rc.remove(id)
+	if rc.MaxEntries > 0 && len(rc.entries) >= rc.MaxEntries {
+		for k, old := range rc.entries {
+			if e.created.After(old.expires) {
+				rc.remove(k)
+			}
+		}
+		if len(rc.entries) >= rc.MaxEntries {
+			return
+		}
+	}
+
+	rc.entries[id] = e
+	for _, k := range e.keys {
+		if rc.index[k] == nil {
+			rc.index[k] = make(map[string]struct{})
+		}
+		rc.index[k][id] = struct{}{}
+	}
+}
+
+// credentialHeaders are the request headers making responses specific to the caller.
+var credentialHeaders = []string{"Authorization", "Cookie"}
+
+// sharedRequest returns true if the response of the request can be shared with other callers:
+// it has no credentials, or the policy keeps a response per credential.
+func sharedRequest(c *Context, p CachePolicy) bool {
+	for _, h := range credentialHeaders {
+		if c.Request.Header.Get(h) == "" {
+			continue
+		}
+		varies := false
+		for _, v := range p.Vary {
+			if strings.EqualFold(v, h) {
+				varies = true
+				break
+			}
+		}
+		if !varies {
+			return false
+		}
+	}
+
+	return true
+}
+
+// cacheableResponse returns true if the buffered response can be shared between requests.
+func cacheableResponse(b *bufferedResponse) bool {
+	if b.code != 0 && b.code != http.StatusOK {
+		return false
+	}
+	if len(b.header["Set-Cookie"]) > 0 {
+		return false
+	}
+
+	cc := strings.ToLower(b.header.Get("Cache-Control"))
+
+	return !strings.Contains(cc, "no-store") && !strings.Contains(cc, "private")
+}
+
+// serve writes the cached response of the request, or runs next and caches its response.
+func (rc *ResponseCache) serve(c *Context, p CachePolicy, next func(*Context) error) error {
+	if c.Request.Method != "GET" && c.Request.Method != "HEAD" {
+		return next(c)
+	}
+
+	results := c.metrics().Counter("yarf_cache_requests_total", "Requests to cacheable routes, by cache result.", "result")
+	if !sharedRequest(c, p) {
+		results.Add(1, "bypass")
+		return next(c)
+	}
+
+	id := rc.key(c, p)
+	now := clockNow(rc.Clock)
+
+	if e := rc.get(id, now); e != nil {
+		atomic.AddUint64(&rc.stats.Hits, 1)
+		results.Add(1, "hit")
+
+		h := c.Response.Header()
+		for k, v := range e.header {
+			h[k] = append([]string(nil), v...)
+		}
+		h.Set("Age", strconv.Itoa(int(now.Sub(e.created).Seconds())))
+		c.Response.WriteHeader(e.code)
+		c.Response.Write(e.body)
+
+		return nil
+	}
+	atomic.AddUint64(&rc.stats.Misses, 1)
+	results.Add(1, "miss")
+
+	// Run the handler against a buffer
+	buf := newBufferedResponse()
+	rw := c.Response
+	c.Response = buf
+	err := next(c)
+	c.Response = rw
+
+	buf.flush(c.Response)
+
+	if err != nil || !cacheableResponse(buf) {
+		return err
+	}
+
+	e := &cachedResponse{
+		code:    buf.code,
+		header:  buf.header.Clone(),
+		body:    buf.body.Bytes(),
+		created: now,
+		expires: now.Add(p.TTL),
+	}
+	if e.code == 0 {
+		e.code = http.StatusOK
+	}
+	for _, k := range p.Keys {
+		for name, value := range c.Params {
+			k = strings.ReplaceAll(k, "{"+name+"}", value)
+		}
+		e.keys = append(e.keys, k)
+	}
+	extra, _ := c.get(surrogateKey{}).([]string)
+	e.keys = append(e.keys, extra...)
+
+	rc.store(id, e)
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package yarf
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// CachedUserResource counts the calls of its Get method and invalidates the user on Put.
+type CachedUserResource struct {
+	Resource
+
+	calls int
+}
+
+func (r *CachedUserResource) Get(c *Context) error {
+	r.calls++
+	c.Response.Header().Set("X-Calls", strconv.Itoa(r.calls))
+	if c.QueryValue("cookie") != "" {
+		c.Response.Header().Set("Set-Cookie", "a=b")
+	}
+	c.SurrogateKeys("team:" + c.QueryValue("team"))
+	c.Render("user " + c.Param("id"))
+
+	return nil
+}
+
+func (r *CachedUserResource) Put(c *Context) error {
+	c.InvalidateCache("user:" + c.Param("id"))
+	return nil
+}
+
+func TestResponseCache(t *testing.T) {
+	r := new(CachedUserResource)
+	m := newTestMetrics()
+
+	y := New()
+	y.Metrics = m
+	y.AddRoute("/users/:id", r).Cacheable(time.Minute, "user:{id}", "users")
+
+	get := func(target string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, httptest.NewRequest("GET", target, nil))
+		return res
+	}
+
+	get("/users/1?team=a")
+	res := get("/users/1?team=a")
+	if r.calls != 1 || res.Body.String() != "user 1" || res.Header().Get("X-Calls") != "1" || res.Header().Get("Age") == "" {
+		t.Errorf("Expected cached response, got %d calls: %v %q", r.calls, res.Header(), res.Body.String())
+	}
+
+	get("/users/2?team=b")
+	if r.calls != 2 {
+		t.Errorf("Expected a call for another user, got %d", r.calls)
+	}
+
+	// Invalidated by the handler after a write
+	y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/users/1", nil))
+	if res := get("/users/1?team=a"); r.calls != 3 || res.Header().Get("X-Calls") != "3" {
+		t.Errorf("Expected invalidated response, got %d calls", r.calls)
+	}
+	if res := get("/users/2?team=b"); r.calls != 3 || res.Body.String() != "user 2" {
+		t.Errorf("Expected other user to stay cached, got %d calls", r.calls)
+	}
+
+	// Keys added by the handler and shared keys
+	if n := y.Cache.Invalidate("team:b"); n != 1 {
+		t.Errorf("Expected 1 response invalidated by the handler key, got %d", n)
+	}
+	if n := y.Cache.Invalidate("users"); n != 1 {
+		t.Errorf("Expected 1 response invalidated by the route key, got %d", n)
+	}
+
+	// Responses with cookies aren't cached
+	get("/users/3?cookie=1")
+	get("/users/3?cookie=1")
+	if r.calls != 5 {
+		t.Errorf("Responses with cookies shouldn't be cached, got %d calls", r.calls)
+	}
+
+	if s := y.Cache.Stats(); s.Hits != 2 || s.Misses != 5 || s.Invalidated != 3 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+	if m.get("yarf_cache_requests_total{hit}") != 2 || m.get("yarf_cache_requests_total{miss}") != 5 {
+		t.Errorf("Unexpected metrics %v", m.values)
+	}
+}
+
+func TestResponseCacheExpiration(t *testing.T) {
+	r := new(CachedUserResource)
+
+	clock := newTestClock()
+	y := New()
+	y.Cache.Clock = clock
+	y.AddRoute("/users/:id", r).Set(MetaCache, CachePolicy{TTL: time.Minute, Vary: []string{"Accept-Language"}})
+
+	get := func(lang string) {
+		req := httptest.NewRequest("GET", "/users/1", nil)
+		req.Header.Set("Accept-Language", lang)
+		y.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	get("en")
+	get("en")
+	get("fr")
+	if r.calls != 2 {
+		t.Errorf("Expected a response per language, got %d calls", r.calls)
+	}
+
+	clock.Advance(2 * time.Minute)
+	get("en")
+	if r.calls != 3 {
+		t.Errorf("Expected expired response, got %d calls", r.calls)
+	}
+
+	// Disabled cache
+	y.Cache = nil
+	get("en")
+	if r.calls != 4 {
+		t.Errorf("Expected no caching, got %d calls", r.calls)
+	}
+}
+
+func TestResponseCacheMaxEntries(t *testing.T) {
+	rc := NewResponseCache()
+	rc.MaxEntries = 1
+
+	now := time.Now()
+	rc.store("a", &cachedResponse{keys: []string{"k"}, created: now, expires: now.Add(time.Minute)})
+	rc.store("b", &cachedResponse{created: now, expires: now.Add(time.Minute)})
+	if rc.get("a", now) == nil || rc.get("b", now) != nil {
+		t.Error("Responses over the limit shouldn't be stored")
+	}
+
+	// Expired entries make room
+	later := now.Add(2 * time.Minute)
+	rc.store("b", &cachedResponse{created: later, expires: later.Add(time.Minute)})
+	if rc.get("b", later) == nil || len(rc.index) != 0 {
+		t.Errorf("Expected expired entry to be replaced, got %v", rc.index)
+	}
+}
+
+func TestResponseCacheCredentials(t *testing.T) {
+	r := new(CachedUserResource)
+	m := newTestMetrics()
+
+	y := New()
+	y.Metrics = m
+	y.AddRoute("/users/:id", r).Cacheable(time.Minute, "users")
+	meta := y.AddRoute("/me/:id", r).Cacheable(time.Minute, "users").CacheVary("authorization")
+
+	get := func(target, header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+		return res
+	}
+
+	// Credentials skip the cache unless the route varies on them
+	get("/users/1", "Authorization", "Bearer a")
+	get("/users/1", "Cookie", "session=a")
+	if res := get("/users/1", "", ""); res.Header().Get("X-Calls") != "3" {
+		t.Errorf("Expected credential responses not to be shared, got %v", res.Header())
+	}
+	if m.get("yarf_cache_requests_total{bypass}") != 2 {
+		t.Errorf("Unexpected metrics %v", m.values)
+	}
+
+	calls := r.calls
+	get("/me/1", "Authorization", "Bearer a")
+	get("/me/1", "Authorization", "Bearer a")
+	get("/me/1", "Authorization", "Bearer b")
+	if r.calls != calls+2 {
+		t.Errorf("Expected a response per credential, got %d calls", r.calls-calls)
+	}
+
+	p, _ := meta.Get(MetaCache).(CachePolicy)
+	if p.TTL != time.Minute || len(p.Vary) != 1 {
+		t.Errorf("Unexpected policy %+v", p)
+	}
+}
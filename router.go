@@ -2,6 +2,9 @@ package yarf
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
 	"strings"
 )
 
@@ -26,6 +29,45 @@ type route struct {
 	routeParts []string // parsed Route split into parts
 
 	handler ResourceHandler // Handler for the route
+
+	meta *RouteMeta // Route metadata
+}
+
+// RouteMeta stores metadata declared for a route.
+// Middleware can read the metadata of the matched route through Context.RouteMeta().
+// All methods are safe to use on a nil *RouteMeta.
+type RouteMeta struct {
+	values map[string]interface{}
+}
+
+// Set stores a metadata value under a key name and returns the RouteMeta to allow chaining.
+// On a nil *RouteMeta, like the one returned by Yarf.AddRoute() with a custom GroupRouter, the value is discarded.
+func (m *RouteMeta) Set(key string, value interface{}) *RouteMeta {
+	if m == nil {
+		return nil
+	}
+	if m.values == nil {
+		m.values = make(map[string]interface{})
+	}
+	m.values[key] = value
+
+	return m
+}
+
+// Get retrieves a metadata value by key name.
+func (m *RouteMeta) Get(key string) interface{} {
+	if m == nil {
+		return nil
+	}
+
+	return m.values[key]
+}
+
+// String retrieves a metadata value by key name as a string.
+// Returns an empty string if the value doesn't exist or isn't a string.
+func (m *RouteMeta) String(key string) string {
+	s, _ := m.Get(key).(string)
+	return s
 }
 
 // Route returns a new route object initialized with the provided data.
@@ -33,11 +75,15 @@ type route struct {
 //	- url string 		// The route path to handle
 //	- h	ResourceHandler	// The ResourceHandler object that will process the requests to the url.
 //
+// It panics with a *PatternError if the url isn't a valid pattern. See ValidatePattern().
 func Route(url string, h ResourceHandler) Router {
+	mustPattern(url, false)
+
 	return &route{
 		path:       url,
 		handler:    h,
 		routeParts: prepareURL(url),
+		meta:       new(RouteMeta),
 	}
 }
 
@@ -48,6 +94,11 @@ func Route(url string, h ResourceHandler) Router {
 // When a route matches the request URL, this method will parse and fill
 // the parameters parsed during the process into the Context object.
 func (r *route) Match(url string, c *Context) bool {
+	return commitMatch(c, r.match(url, c, c.matchParams()))
+}
+
+// match checks the request URL against the route, and stores the params into the candidate set p.
+func (r *route) match(url string, c *Context, p Params) bool {
 	requestParts := prepareURL(url)
 
 	// YARF router only accepts exact route matches, so check for part count.
@@ -63,14 +114,69 @@ func (r *route) Match(url string, c *Context) bool {
 		return false
 	}
 
-	storeParams(c, r.routeParts, requestParts)
+	storeParams(p, r.routeParts, requestParts)
 
 	return true
 }
 
 // Dispatch executes the right ResourceHandler method based on the HTTP request in the Context object.
 func (r *route) Dispatch(c *Context) error {
-	// Method dispatch
+	// Default headers
+	setHeaders(c, r.meta.headers())
+	setContentType(c, r.meta.String(MetaContentType))
+
+	// Param sanitization
+	if err := sanitizeParams(c); err != nil {
+		return err
+	}
+
+	// Feature gating
+	if !r.flagsAllowed(c) {
+		return ErrorRouteNotFound()
+	}
+
+	// CORS headers and preflight requests
+	if r.cors(c) {
+		return nil
+	}
+
+	// OAuth2 scopes
+	if err := r.checkScopes(c); err != nil {
+		return err
+	}
+
+	// Authorization policy
+	if err := r.authorize(c); err != nil {
+		return err
+	}
+
+	// Required request headers
+	if err := r.checkHeaders(c); err != nil {
+		return err
+	}
+
+	// Server push
+	r.push(c)
+
+	// Content digests
+	if err := r.verifyDigest(c); err != nil {
+		return err
+	}
+
+	return r.digestResponse(c, r.serve)
+}
+
+// serve executes the ResourceHandler method of the request, through the response cache if the route is cached.
+func (r *route) serve(c *Context) error {
+	if p, ok := r.meta.Get(MetaCache).(CachePolicy); ok && c.app != nil && c.app.Cache != nil {
+		return c.app.Cache.serve(c, p, r.dispatchMethod)
+	}
+
+	return r.dispatchMethod(c)
+}
+
+// dispatchMethod executes the ResourceHandler method of the request.
+func (r *route) dispatchMethod(c *Context) error {
 	switch c.Request.Method {
 	case "GET":
 		return r.handler.Get(c)
@@ -113,7 +219,15 @@ type GroupRoute struct {
 
 	middleware []MiddlewareHandler // Group middleware resources
 
+	phases []Phase // Phase of each middleware resource
+
 	routes []Router // Group routes
+
+	headers http.Header // Default response headers
+
+	contentType string // Default response Content-Type
+
+	namespace string // Name the prefix params are stored under too
 }
 
 // RouteGroup creates a new GroupRoute object and initializes it with the provided url prefix.
@@ -121,7 +235,10 @@ type GroupRoute struct {
 // Groups can be nested into each other,
 // so it's possible to add a GroupRoute as a route inside another GroupRoute.
 // Includes methods to work with middleware.
+// It panics with a *PatternError if the url isn't a valid pattern or ends with a catch-all.
 func RouteGroup(url string) *GroupRoute {
+	mustPattern(url, true)
+
 	return &GroupRoute{
 		prefix:     url,
 		routeParts: prepareURL(url),
@@ -133,6 +250,11 @@ func RouteGroup(url string) *GroupRoute {
 // to being able to dispatch it directly after a match without looping again.
 // Outside the box, works exactly the same as route.Match()
 func (g *GroupRoute) Match(url string, c *Context) bool {
+	return commitMatch(c, g.match(url, c, c.matchParams()))
+}
+
+// match looks for a route matching the request inside the group, and stores the params into the candidate set p.
+func (g *GroupRoute) match(url string, c *Context, p Params) bool {
 	urlParts := prepareURL(url)
 
 	// check if urlParts matches routeParts
@@ -145,10 +267,10 @@ func (g *GroupRoute) Match(url string, c *Context) bool {
 
 	// Now look for a match inside the routes collection
 	for _, r := range g.routes {
-		if r.Match(rURL, c) {
+		if matchRouter(r, rURL, c, p) {
 			// store the matching Router and params after a match is found
 			c.groupDispatch = append(c.groupDispatch, r)
-			storeParams(c, g.routeParts, urlParts)
+			g.storeParams(p, urlParts)
 			return true
 		}
 	}
@@ -160,16 +282,21 @@ func (g *GroupRoute) Match(url string, c *Context) bool {
 // Outside the box, works exactly the same as route.Dispatch().
 func (g *GroupRoute) Dispatch(c *Context) (err error) {
 	if len(c.groupDispatch) == 0 {
-		g.endDispatch(c)
-		return errors.New("No matching route found")
+		err = errors.New("No matching route found")
+		g.endDispatch(c, err)
+		return
 	}
 
+	// Default headers
+	setHeaders(c, g.headers)
+	setContentType(c, g.contentType)
+
 	// Pre-dispatch middleware
 	for _, m := range g.middleware {
 		// Dispatch
-		err = m.PreDispatch(c)
+		err = callMiddleware(c, m, stagePre)
 		if err != nil {
-			g.endDispatch(c)
+			g.endDispatch(c, err)
 			return
 		}
 	}
@@ -180,31 +307,36 @@ func (g *GroupRoute) Dispatch(c *Context) (err error) {
 	c.groupDispatch = c.groupDispatch[:n]
 	err = route.Dispatch(c)
 	if err != nil {
-		g.endDispatch(c)
+		g.endDispatch(c, err)
 		return
 	}
 
 	// Post-dispatch middleware
 	for _, m := range g.middleware {
 		// Dispatch
-		err = m.PostDispatch(c)
+		err = callMiddleware(c, m, stagePost)
 		if err != nil {
-			g.endDispatch(c)
+			g.endDispatch(c, err)
 			return
 		}
 	}
 
 	// End dispatch if no errors blocking...
-	g.endDispatch(c)
+	g.endDispatch(c, nil)
 
 	// Return success
 	return
 }
 
-func (g *GroupRoute) endDispatch(c *Context) (err error) {
+func (g *GroupRoute) endDispatch(c *Context, dispatchErr error) (err error) {
+	// Make the error available to End middleware
+	if dispatchErr != nil {
+		c.err = dispatchErr
+	}
+
 	// End dispatch middleware
 	for _, m := range g.middleware {
-		e := m.End(c)
+		e := callMiddleware(c, m, stageEnd)
 		if e != nil {
 			// If there are any error, only return the last to be sure we go through all middlewares.
 			err = e
@@ -216,7 +348,18 @@ func (g *GroupRoute) endDispatch(c *Context) (err error) {
 
 // Add inserts a new resource with it's associated route into the group object.
 func (g *GroupRoute) Add(url string, h ResourceHandler) {
-	g.routes = append(g.routes, Route(url, h))
+	g.AddRoute(url, h)
+}
+
+// AddRoute inserts a new resource with it's associated route into the group object,
+// and returns the route metadata so it can be declared right after:
+//
+//	y.AddRoute("/users/:id", new(User)).Set("key", "value")
+func (g *GroupRoute) AddRoute(url string, h ResourceHandler) *RouteMeta {
+	r := Route(url, h).(*route)
+	g.routes = append(g.routes, r)
+
+	return r.meta
 }
 
 // AddGroup inserts a GroupRoute into the routes list of the group object.
@@ -226,8 +369,138 @@ func (g *GroupRoute) AddGroup(r *GroupRoute) {
 }
 
 // Insert adds a MiddlewareHandler into the middleware list of the group object.
+// The middleware is placed according to the phase it declares, or PhaseBusiness if it doesn't.
 func (g *GroupRoute) Insert(m MiddlewareHandler) {
-	g.middleware = append(g.middleware, m)
+	g.InsertPhase(phaseOf(m), m)
+}
+
+// InsertPhase adds a MiddlewareHandler into the middleware list of the group object on the phase provided.
+// Middleware runs sorted by phase, and in insertion order within the same phase.
+func (g *GroupRoute) InsertPhase(p Phase, m MiddlewareHandler) {
+	i := len(g.phases)
+	for i > 0 && g.phases[i-1] > p {
+		i--
+	}
+
+	g.middleware = append(g.middleware, nil)
+	copy(g.middleware[i+1:], g.middleware[i:])
+	g.middleware[i] = m
+
+	g.phases = append(g.phases, 0)
+	copy(g.phases[i+1:], g.phases[i:])
+	g.phases[i] = p
+}
+
+// ChainItem describes a middleware in the effective chain of a route.
+type ChainItem struct {
+	Phase      Phase
+	Middleware MiddlewareHandler
+}
+
+// walk calls fn for every route inside the group and its children,
+// with the full route pattern and the effective middleware chain for it.
+func (g *GroupRoute) walk(prefix string, chain []ChainItem, fn func(pattern string, r *route, chain []ChainItem)) {
+	prefix = joinURL(prefix, g.prefix)
+
+	for i, m := range g.middleware {
+		chain = append(chain, ChainItem{g.phases[i], m})
+	}
+
+	for _, r := range g.routes {
+		switch rt := r.(type) {
+		case *route:
+			fn(joinURL(prefix, rt.path), rt, chain)
+		case *GroupRoute:
+			rt.walk(prefix, chain[:len(chain):len(chain)], fn)
+		}
+	}
+}
+
+// Chain returns the effective middleware chain, in execution order, for every route pattern inside the group.
+func (g *GroupRoute) Chain() map[string][]ChainItem {
+	chains := make(map[string][]ChainItem)
+
+	g.walk("", nil, func(pattern string, r *route, chain []ChainItem) {
+		chains[pattern] = append([]ChainItem(nil), chain...)
+	})
+
+	return chains
+}
+
+// RouteInfo describes a route registered inside a group, for introspection and documentation tools.
+type RouteInfo struct {
+	Pattern string          // Full route pattern, like "/users/:id"
+	Handler ResourceHandler // Resource handling the route
+	Meta    *RouteMeta      // Route metadata
+	Chain   []ChainItem     // Effective middleware chain, in execution order
+}
+
+// Routes returns the routes inside the group and its children, in matching order.
+func (g *GroupRoute) Routes() []RouteInfo {
+	var routes []RouteInfo
+
+	g.walk("", nil, func(pattern string, r *route, chain []ChainItem) {
+		routes = append(routes, RouteInfo{
+			Pattern: pattern,
+			Handler: r.handler,
+			Meta:    r.meta,
+			Chain:   append([]ChainItem(nil), chain...),
+		})
+	})
+
+	return routes
+}
+
+// PrintChain writes the effective middleware chain of each route inside the group, one route per line.
+func (g *GroupRoute) PrintChain(w io.Writer) {
+	g.walk("", nil, func(pattern string, r *route, chain []ChainItem) {
+		items := make([]string, len(chain))
+		for i, c := range chain {
+			items[i] = fmt.Sprintf("[%s] %T", c.Phase, c.Middleware)
+		}
+
+		fmt.Fprintf(w, "%s: %s\n", pattern, strings.Join(items, " -> "))
+	})
+}
+
+// leafRoute returns the final route from a group dispatch list.
+// Groups push the matched route first, so it's always at the bottom of the list.
+func leafRoute(dispatch []Router) *route {
+	if len(dispatch) == 0 {
+		return nil
+	}
+
+	r, _ := dispatch[0].(*route)
+	return r
+}
+
+// routePattern builds the full route pattern from a group dispatch list.
+func routePattern(dispatch []Router) string {
+	if len(dispatch) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(dispatch))
+	for i := len(dispatch) - 1; i >= 0; i-- {
+		switch r := dispatch[i].(type) {
+		case *GroupRoute:
+			parts = append(parts, r.prefix)
+		case *route:
+			parts = append(parts, r.path)
+		}
+	}
+
+	return joinURL(parts...)
+}
+
+// joinURL joins route paths into a single pattern.
+func joinURL(parts ...string) string {
+	var all []string
+	for _, p := range parts {
+		all = append(all, prepareURL(p)...)
+	}
+
+	return "/" + strings.Join(all, "/")
 }
 
 // prepareUrl trims leading and trailing slahses, splits url parts, and removes empty parts
@@ -278,11 +551,11 @@ func matches(routeParts, requestParts []string) bool {
 }
 
 // storeParams writes parts from requestParts that correspond with param names in
-// routeParts into c.Params.
-func storeParams(c *Context, routeParts, requestParts []string) {
+// routeParts into params.
+func storeParams(params Params, routeParts, requestParts []string) {
 	for i, p := range routeParts {
 		if p[0] == ':' {
-			c.Params.Set(p[1:], requestParts[i])
+			params.Set(p[1:], requestParts[i])
 		}
 	}
 }
@@ -629,6 +629,27 @@ func BenchmarkRouteMatch_emptyParts(b *testing.B) {
 	}
 }
 
+func TestRouteGroupRoutes(t *testing.T) {
+	g := RouteGroup("/v1")
+	g.Insert(new(MockMiddleware))
+	g.AddRoute("/users/:id", new(MockResource)).Set("name", "user")
+
+	y := New()
+	y.Add("/", new(Handler))
+	y.AddGroup(g)
+
+	routes := y.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("Expected 2 routes, got %d", len(routes))
+	}
+	if routes[0].Pattern != "/" || routes[1].Pattern != "/v1/users/:id" {
+		t.Errorf("Unexpected route patterns: %s, %s", routes[0].Pattern, routes[1].Pattern)
+	}
+	if _, ok := routes[1].Handler.(*MockResource); !ok || routes[1].Meta.String("name") != "user" || len(routes[1].Chain) != 1 {
+		t.Errorf("Unexpected route info: %+v", routes[1])
+	}
+}
+
 func BenchmarkRouteGroupMatch_short(b *testing.B) {
 	h := &Handler{}
 	c := &Context{}
@@ -0,0 +1,143 @@
+package yarf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+// resourceMethods lists the HTTP methods in the ResourceHandler interface, with their Go method names.
+var resourceMethods = []struct{ http, name string }{
+	{"GET", "Get"},
+	{"POST", "Post"},
+	{"PUT", "Put"},
+	{"PATCH", "Patch"},
+	{"DELETE", "Delete"},
+	{"OPTIONS", "Options"},
+	{"HEAD", "Head"},
+	{"TRACE", "Trace"},
+	{"CONNECT", "Connect"},
+}
+
+// Methods returns the HTTP methods implemented by the route handler.
+// Methods promoted from the embedded Resource type, which return a 405 error, aren't included.
+func (r RouteInfo) Methods() []string {
+	var methods []string
+
+	t := reflect.TypeOf(r.Handler)
+	if t == reflect.TypeOf(new(Resource)) {
+		return nil
+	}
+
+	for _, m := range resourceMethods {
+		method, ok := t.MethodByName(m.name)
+		if !ok || promoted(method) {
+			continue
+		}
+		methods = append(methods, m.http)
+	}
+
+	return methods
+}
+
+// promoted returns true if the method is a wrapper generated by the compiler for a method of an embedded type.
+func promoted(m reflect.Method) bool {
+	pc := m.Func.Pointer()
+	f := runtime.FuncForPC(pc)
+	if f == nil {
+		return false
+	}
+	file, _ := f.FileLine(pc)
+
+	return file == "<autogenerated>"
+}
+
+// HandlerName returns the type name of the route handler.
+func (r RouteInfo) HandlerName() string {
+	return fmt.Sprintf("%T", r.Handler)
+}
+
+// middlewareNames returns the type names of the route middleware, in execution order.
+func (r RouteInfo) middlewareNames() []string {
+	names := make([]string, len(r.Chain))
+	for i, c := range r.Chain {
+		names[i] = fmt.Sprintf("%T", c.Middleware)
+	}
+
+	return names
+}
+
+// printRoutes writes the routes as an aligned table, one row per method.
+func printRoutes(w io.Writer, routes []RouteInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tHANDLER\tMIDDLEWARE")
+
+	for _, r := range routes {
+		methods := r.Methods()
+		if len(methods) == 0 {
+			methods = []string{"-"}
+		}
+
+		for _, m := range methods {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", m, r.Pattern, r.HandlerName(), strings.Join(r.middlewareNames(), ", "))
+		}
+	}
+
+	return tw.Flush()
+}
+
+// routeJSON is the machine readable description of a route.
+type routeJSON struct {
+	Methods    []string `json:"methods"`
+	Pattern    string   `json:"pattern"`
+	Handler    string   `json:"handler"`
+	Middleware []string `json:"middleware"`
+}
+
+// printRoutesJSON writes the routes as a JSON array.
+func printRoutesJSON(w io.Writer, routes []RouteInfo) error {
+	list := make([]routeJSON, len(routes))
+	for i, r := range routes {
+		list[i] = routeJSON{
+			Methods:    r.Methods(),
+			Pattern:    r.Pattern,
+			Handler:    r.HandlerName(),
+			Middleware: r.middlewareNames(),
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(list)
+}
+
+// PrintRoutes writes a table with the method, pattern, handler type and middleware of each route inside the group.
+func (g *GroupRoute) PrintRoutes(w io.Writer) error {
+	return printRoutes(w, g.Routes())
+}
+
+// PrintRoutesJSON writes the routes inside the group as a JSON array.
+func (g *GroupRoute) PrintRoutesJSON(w io.Writer) error {
+	return printRoutesJSON(w, g.Routes())
+}
+
+// PrintRoutes writes a table with the method, pattern, handler type and middleware of each route,
+// including global middleware. Use it for startup logs or a -routes flag:
+//
+//	if *routes {
+//		y.PrintRoutes(os.Stdout)
+//		return
+//	}
+func (y *Yarf) PrintRoutes(w io.Writer) error {
+	return printRoutes(w, y.Routes())
+}
+
+// PrintRoutesJSON writes all the routes as a JSON array, including global middleware.
+func (y *Yarf) PrintRoutesJSON(w io.Writer) error {
+	return printRoutesJSON(w, y.Routes())
+}
@@ -0,0 +1,53 @@
+package yarf
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRouteMethods(t *testing.T) {
+	info := RouteInfo{Handler: new(LoginResource)}
+	if m := strings.Join(info.Methods(), ","); m != "POST" {
+		t.Errorf("Expected POST method, got '%s'", m)
+	}
+
+	info = RouteInfo{Handler: new(Resource)}
+	if len(info.Methods()) != 0 {
+		t.Errorf("Default Resource shouldn't implement methods, got %v", info.Methods())
+	}
+}
+
+func TestPrintRoutes(t *testing.T) {
+	y := New()
+	y.Insert(new(SecurityMiddleware))
+	y.Add("/", new(OKResource))
+	y.Add("/login", new(LoginResource))
+
+	var buf bytes.Buffer
+	if err := y.PrintRoutes(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected header and 2 routes, got:\n%s", buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "METHOD") || strings.Join(strings.Fields(lines[2]), " ") != "POST /login *yarf.LoginResource *yarf.SecurityMiddleware" {
+		t.Errorf("Unexpected routes table:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := y.PrintRoutesJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var routes []routeJSON
+	if err := json.Unmarshal(buf.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 2 || routes[0].Pattern != "/" || routes[0].Methods[0] != "GET" || routes[0].Handler != "*yarf.OKResource" {
+		t.Errorf("Unexpected JSON routes: %+v", routes)
+	}
+}
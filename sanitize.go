@@ -0,0 +1,158 @@
+package yarf
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// DangerousChars are the characters removed from route params by StripParamChars(DangerousChars):
+// HTML and quoting characters, shell separators and backslashes.
+const DangerousChars = "<>\"'`;|&$\\"
+
+// ParamPolicy checks or changes a route param before handlers see it.
+// Returning an error rejects the request: YError values are sent as is, other errors as 400 errors.
+type ParamPolicy func(name, value string) (string, error)
+
+// StripParamChars creates a ParamPolicy removing the characters provided from the params.
+func StripParamChars(chars string) ParamPolicy {
+	return func(name, value string) (string, error) {
+		return strings.Map(func(r rune) rune {
+			if strings.ContainsRune(chars, r) {
+				return -1
+			}
+			return r
+		}, value), nil
+	}
+}
+
+// RejectParamChars creates a ParamPolicy rejecting params containing any of the characters provided.
+func RejectParamChars(chars string) ParamPolicy {
+	return func(name, value string) (string, error) {
+		if strings.ContainsAny(value, chars) {
+			return "", sanitizeError("Invalid characters in param " + name)
+		}
+		return value, nil
+	}
+}
+
+// Sanitizer is a middleware hardening the request input before routing.
+// It rejects paths with null bytes, control characters or invalid UTF-8, like overlong encodings of "/" or ".",
+// and removes dot-segments and duplicate slashes with the CollapseSlashes and RemoveDotSegments steps,
+// so "/static/../admin" is routed as "/admin".
+// Use it as global middleware, so paths are sanitized before route matching:
+//
+//	s := yarf.NewSanitizer()
+//	s.Params = yarf.StripParamChars(yarf.DangerousChars)
+//	y.Use(s)
+type Sanitizer struct {
+	Middleware
+
+	// RedirectClean redirects requests with dot-segments to the clean path with a 301 response,
+	// instead of routing the clean path.
+	RedirectClean bool
+
+	// Path runs extra checks on the decoded path, after the built-in ones.
+	Path func(p string) error
+
+	// Params checks or changes the route params once the route is matched.
+	Params ParamPolicy
+}
+
+// NewSanitizer creates a Sanitizer with the built-in path checks.
+func NewSanitizer() *Sanitizer {
+	return new(Sanitizer)
+}
+
+// Phase runs the Sanitizer with the security middleware.
+func (m *Sanitizer) Phase() Phase {
+	return PhaseSecurity
+}
+
+// sanitizerKey is the Context storage key for the Sanitizer checking the route params.
+type sanitizerKey struct{}
+
+// sanitizeError returns a 400 error with the message as body.
+func sanitizeError(msg string) error {
+	e := ErrorBadRequest()
+	e.ErrorBody = msg
+
+	return e
+}
+
+// PreDispatch checks and cleans the request path.
+func (m *Sanitizer) PreDispatch(c *Context) error {
+	p := c.Request.URL.Path
+
+	if !utf8.ValidString(p) {
+		return sanitizeError("Invalid path encoding")
+	}
+	for _, r := range p {
+		if r < 0x20 || r == 0x7f {
+			return sanitizeError("Invalid characters in path")
+		}
+	}
+
+	if clean := cleanPath(p); clean != p {
+		if m.RedirectClean {
+			u := *c.Request.URL
+			u.Path, u.RawPath = clean, ""
+			return ErrorRedirect(u.RequestURI(), http.StatusMovedPermanently)
+		}
+		c.Request.URL.Path, c.Request.URL.RawPath = clean, ""
+	}
+
+	if m.Path != nil {
+		if err := m.Path(c.Request.URL.Path); err != nil {
+			return sanitizeResult(err)
+		}
+	}
+
+	if m.Params != nil {
+		c.set(sanitizerKey{}, m)
+	}
+
+	return nil
+}
+
+// cleanSteps are the URLNormalizer steps cleaning the decoded paths in the Sanitizer.
+var cleanSteps = &URLNormalizer{Steps: []NormalizeStep{CollapseSlashes, RemoveDotSegments}}
+
+// cleanPath removes the dot-segments and duplicated slashes of a path, keeping the trailing slash.
+func cleanPath(p string) string {
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+
+	// CollapseSlashes and RemoveDotSegments never fail
+	clean, _ := cleanSteps.normalize(p)
+
+	return clean
+}
+
+// sanitizeResult converts the errors of policies into YError values.
+func sanitizeResult(err error) error {
+	if _, ok := err.(YError); ok {
+		return err
+	}
+
+	return sanitizeError(err.Error())
+}
+
+// sanitizeParams runs the param policy of the Sanitizer covering the request, if any.
+func sanitizeParams(c *Context) error {
+	m, ok := c.get(sanitizerKey{}).(*Sanitizer)
+	if !ok {
+		return nil
+	}
+
+	for k, v := range c.Params {
+		clean, err := m.Params(k, v)
+		if err != nil {
+			return sanitizeResult(err)
+		}
+		c.Params[k] = clean
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package yarf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// EchoParamResource renders the name param.
+type EchoParamResource struct {
+	Resource
+}
+
+func (r *EchoParamResource) Get(c *Context) error {
+	c.Render(c.Param("name"))
+	return nil
+}
+
+// pathRequest returns a request for the raw path provided, which may not be a valid request target.
+func pathRequest(path string) *http.Request {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.URL.Path = path
+	req.URL.RawPath = ""
+
+	return req
+}
+
+func TestCleanPath(t *testing.T) {
+	for in, expected := range map[string]string{
+		"":                   "/",
+		"/":                  "/",
+		"/a/b":               "/a/b",
+		"/a/b/":              "/a/b/",
+		"/a/./b":             "/a/b",
+		"/a/../b":            "/b",
+		"/../../etc/passwd":  "/etc/passwd",
+		"//a//b//":           "/a/b/",
+		"/static/../admin/.": "/admin/",
+		"a/b":                "/a/b",
+	} {
+		if out := cleanPath(in); out != expected {
+			t.Errorf("cleanPath(%q) = %q, expected %q", in, out, expected)
+		}
+	}
+}
+
+func TestSanitizerPaths(t *testing.T) {
+	s := NewSanitizer()
+	y := New()
+	y.Use(s)
+	y.Add("/admin", new(OKResource))
+	y.Add("/admin/", new(OKResource))
+	y.Add("/hello/:name", new(EchoParamResource))
+
+	for p, expected := range map[string]int{
+		"/admin":              200,
+		"/static/../admin":    200,
+		"/static/../admin/":   200,
+		"/./admin":            200,
+		"/admin\x00.html":     400,
+		"/admin\r\nX-Bad: 1":  400,
+		"/\xc0\xaf/admin":     400,
+		"/hello/\xe0\x80\xae": 400,
+		"/hello/café":         200,
+	} {
+		if res := serveRequest(y, pathRequest(p)); res.Code != expected {
+			t.Errorf("%q: status %d, expected %d", p, res.Code, expected)
+		}
+	}
+
+	// Redirects to the clean path
+	s.RedirectClean = true
+	res := testRequest(y, "GET", "/static/../admin?x=1", nil)
+	if res.Code != 301 || res.Header().Get("Location") != "/admin?x=1" {
+		t.Errorf("Expected redirect to /admin?x=1, got %d %q", res.Code, res.Header().Get("Location"))
+	}
+}
+
+func TestSanitizerPolicies(t *testing.T) {
+	s := NewSanitizer()
+	s.Path = func(p string) error {
+		if strings.Contains(p, "\\") {
+			return errors.New("Backslashes not allowed")
+		}
+		return nil
+	}
+	s.Params = StripParamChars(DangerousChars)
+	y := New()
+	y.Use(s)
+	y.Add("/admin", new(OKResource))
+	y.Add("/admin/", new(OKResource))
+	y.Add("/hello/:name", new(EchoParamResource))
+
+	res := serveRequest(y, pathRequest(`/admin\x`))
+	if res.Code != 400 || res.Body.String() != "Backslashes not allowed" {
+		t.Errorf("Path policy: %d %q", res.Code, res.Body.String())
+	}
+
+	// Twice, for the route cache
+	for i := 0; i < 2; i++ {
+		res = serveRequest(y, pathRequest(`/hello/<img src=x onerror=alert('x')>`))
+		if res.Body.String() != "img src=x onerror=alert(x)" {
+			t.Errorf("Stripped param: %q", res.Body.String())
+		}
+	}
+
+	s.Params = RejectParamChars("<>")
+	res = serveRequest(y, pathRequest("/hello/<b>"))
+	if res.Code != 400 || res.Body.String() != "Invalid characters in param name" {
+		t.Errorf("Rejected param: %d %q", res.Code, res.Body.String())
+	}
+	res = testRequest(y, "GET", "/hello/bob", nil)
+	if res.Code != 200 || res.Body.String() != "bob" {
+		t.Errorf("Valid param: %d %q", res.Code, res.Body.String())
+	}
+
+	// Custom errors are sent as is
+	s.Params = func(name, value string) (string, error) {
+		return "", ErrorNotFound()
+	}
+	if res = testRequest(y, "GET", "/hello/bob", nil); res.Code != 404 {
+		t.Errorf("Custom error: status %d, expected 404", res.Code)
+	}
+}
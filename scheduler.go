@@ -0,0 +1,305 @@
+package yarf
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// TaskFunc is the function run by a scheduled task.
+type TaskFunc func(ctx context.Context) error
+
+// TaskStats are the counters of a scheduled task.
+type TaskStats struct {
+	Runs      uint64    // Finished runs
+	Failures  uint64    // Runs finished with error or panic
+	Skipped   uint64    // Activations skipped because the previous run was still running
+	LastRun   time.Time // Start of the last run
+	LastError string    // Error of the last run, if it failed
+	Next      time.Time // Next activation
+}
+
+// Task is a function scheduled to run periodically.
+// Its settings should be changed before the scheduler starts.
+type Task struct {
+	// Name identifies the task in the logs. Defaults to the schedule expression.
+	Name string
+
+	// Jitter delays each activation by a random duration up to this value,
+	// so instances of the same service don't run the task at the same time.
+	Jitter time.Duration
+
+	// AllowOverlap runs the task even if the previous run didn't finish.
+	// By default, activations are skipped while the task is running.
+	AllowOverlap bool
+
+	// Timeout limits each run through its context. Zero means no timeout.
+	Timeout time.Duration
+
+	schedule Schedule
+	fn       TaskFunc
+	running  int
+	stats    TaskStats
+	lock     sync.Mutex
+}
+
+// Stats returns a snapshot of the task counters.
+func (t *Task) Stats() TaskStats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.stats
+}
+
+// Scheduler runs tasks on their schedules, isolating their panics.
+// Stop waits for running tasks, so they can finish during a graceful shutdown.
+type Scheduler struct {
+	// Location for the schedule times. Defaults to time.Local.
+	Location *time.Location
+
+	// Log receives the task failures. If nil, slog.Default() is used.
+	Log Logger
+
+	// logger resolves the Logger when Log is nil, used to follow the Yarf Log
+	logger func() Logger
+
+	tasks   []*Task
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stop    chan struct{}
+	started bool
+	stopped bool
+	loops   sync.WaitGroup
+	runs    sync.WaitGroup
+	lock    sync.Mutex
+}
+
+// NewScheduler creates a Scheduler without tasks.
+func NewScheduler() *Scheduler {
+	s := &Scheduler{
+		stop: make(chan struct{}),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	return s
+}
+
+// Add schedules a task with a cron expression, as accepted by ParseCron.
+// Tasks added after Start are started right away.
+func (s *Scheduler) Add(spec string, fn TaskFunc) (*Task, error) {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.AddSchedule(spec, schedule, fn), nil
+}
+
+// AddSchedule adds a task with a custom Schedule.
+func (s *Scheduler) AddSchedule(name string, schedule Schedule, fn TaskFunc) *Task {
+	t := &Task{
+		Name:     name,
+		schedule: schedule,
+		fn:       fn,
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.tasks = append(s.tasks, t)
+	if s.started && !s.stopped {
+		s.loops.Add(1)
+		go s.loop(t)
+	}
+
+	return t
+}
+
+// Tasks returns the scheduled tasks.
+func (s *Scheduler) Tasks() []*Task {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]*Task(nil), s.tasks...)
+}
+
+// Start begins running the tasks on their schedules. Calls after the first one do nothing.
+func (s *Scheduler) Start() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.started {
+		return nil
+	}
+	s.started = true
+
+	for _, t := range s.tasks {
+		s.loops.Add(1)
+		go s.loop(t)
+	}
+
+	return nil
+}
+
+// now returns the current time in the scheduler location.
+func (s *Scheduler) now() time.Time {
+	if s.Location != nil {
+		return time.Now().In(s.Location)
+	}
+
+	return time.Now()
+}
+
+// loop waits for the activations of a task until the scheduler stops.
+func (s *Scheduler) loop(t *Task) {
+	defer s.loops.Done()
+
+	for {
+		next := t.schedule.Next(s.now())
+		if next.IsZero() {
+			return
+		}
+		if t.Jitter > 0 {
+			next = next.Add(time.Duration(rand.Int63n(int64(t.Jitter))))
+		}
+
+		t.lock.Lock()
+		t.stats.Next = next
+		t.lock.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			s.activate(t)
+		case <-s.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// activate runs the task in the background, unless it's running and overlaps aren't allowed.
+func (s *Scheduler) activate(t *Task) {
+	t.lock.Lock()
+	if t.running > 0 && !t.AllowOverlap {
+		t.stats.Skipped++
+		t.lock.Unlock()
+		return
+	}
+	t.running++
+	t.stats.LastRun = time.Now()
+	t.lock.Unlock()
+
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+
+		err := s.run(t)
+
+		t.lock.Lock()
+		defer t.lock.Unlock()
+
+		t.running--
+		t.stats.Runs++
+		t.stats.LastError = ""
+		if err != nil {
+			t.stats.Failures++
+			t.stats.LastError = err.Error()
+		}
+	}()
+}
+
+// run executes the task function, recovering panics.
+func (s *Scheduler) run(t *Task) (err error) {
+	ctx := s.ctx
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			s.log().Error("scheduled task panic", "task", t.Name, "panic", r, "stack", string(debug.Stack()))
+		}
+	}()
+
+	if err = t.fn(ctx); err != nil {
+		s.log().Error("scheduled task failed", "task", t.Name, "error", err)
+	}
+
+	return err
+}
+
+// log returns the scheduler Logger.
+func (s *Scheduler) log() Logger {
+	if s.Log != nil {
+		return s.Log
+	}
+
+	if s.logger != nil {
+		return s.logger()
+	}
+
+	return slog.Default()
+}
+
+// Stop stops scheduling tasks and waits for the running ones to finish.
+// If ctx is done first, the context of the running tasks is canceled and ctx.Err() is returned.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.lock.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.stop)
+	}
+	s.lock.Unlock()
+
+	s.loops.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
+
+// scheduler returns the scheduler of the Yarf instance, creating it on first use.
+// It starts with the servers and stops on Shutdown.
+func (y *Yarf) scheduler() *Scheduler {
+	y.lock.Lock()
+	defer y.lock.Unlock()
+
+	if y.sched == nil {
+		y.sched = NewScheduler()
+		y.sched.logger = y.log
+		y.onStart = append(y.onStart, y.sched.Start)
+		y.onStop = append(y.onStop, y.sched.Stop)
+	}
+
+	return y.sched
+}
+
+// Schedule runs fn periodically while the application is running, on a cron expression as accepted by ParseCron.
+// Tasks start with the servers, never overlap with their previous run unless configured,
+// and running tasks are waited for on Shutdown.
+//
+//	y.Schedule("*/5 * * * *", func(ctx context.Context) error {
+//		return purgeExpiredSessions(ctx)
+//	})
+func (y *Yarf) Schedule(spec string, fn TaskFunc) (*Task, error) {
+	return y.scheduler().Add(spec, fn)
+}
@@ -0,0 +1,182 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tickSchedule activates every few milliseconds.
+type tickSchedule time.Duration
+
+func (s tickSchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not reached in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerRuns(t *testing.T) {
+	s := NewScheduler()
+	s.Log = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var runs int32
+	task := s.AddSchedule("tick", tickSchedule(time.Millisecond), func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+	failing := s.AddSchedule("failing", tickSchedule(time.Millisecond), func(ctx context.Context) error {
+		return errors.New("failed")
+	})
+	panicking := s.AddSchedule("panicking", tickSchedule(time.Millisecond), func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	if atomic.LoadInt32(&runs) != 0 {
+		t.Error("Tasks shouldn't run before Start")
+	}
+
+	s.Start()
+	waitFor(t, func() bool {
+		return task.Stats().Runs >= 3 && failing.Stats().Failures >= 1 && panicking.Stats().Failures >= 1
+	})
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if failing.Stats().LastError != "failed" {
+		t.Errorf("Unexpected last error %q", failing.Stats().LastError)
+	}
+	if task.Stats().LastRun.IsZero() || task.Stats().Next.IsZero() {
+		t.Errorf("Expected last and next run times, got %+v", task.Stats())
+	}
+
+	after := atomic.LoadInt32(&runs)
+	time.Sleep(10 * time.Millisecond)
+	if atomic.LoadInt32(&runs) != after {
+		t.Error("Tasks shouldn't run after Stop")
+	}
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	s := NewScheduler()
+
+	release := make(chan struct{})
+	var running int32
+	var max int32
+	task := s.AddSchedule("slow", tickSchedule(time.Millisecond), func(ctx context.Context) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		if n > atomic.LoadInt32(&max) {
+			atomic.StoreInt32(&max, n)
+		}
+		<-release
+		return nil
+	})
+
+	s.Start()
+	waitFor(t, func() bool {
+		return task.Stats().Skipped >= 3
+	})
+	close(release)
+	s.Stop(context.Background())
+
+	if atomic.LoadInt32(&max) != 1 {
+		t.Errorf("Runs shouldn't overlap, got %d concurrent runs", max)
+	}
+}
+
+func TestSchedulerStopTimeout(t *testing.T) {
+	s := NewScheduler()
+
+	canceled := make(chan struct{})
+	var once int32
+	s.AddSchedule("stuck", tickSchedule(time.Millisecond), func(ctx context.Context) error {
+		if atomic.AddInt32(&once, 1) == 1 {
+			<-ctx.Done()
+			close(canceled)
+		}
+		return nil
+	})
+
+	s.Start()
+	waitFor(t, func() bool {
+		return atomic.LoadInt32(&once) > 0
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline error, got %v", err)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("Running tasks should be canceled when Stop times out")
+	}
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	s := NewScheduler()
+	task := s.AddSchedule("jitter", tickSchedule(time.Hour), func(ctx context.Context) error {
+		return nil
+	})
+	task.Jitter = time.Minute
+
+	start := time.Now()
+	s.Start()
+	waitFor(t, func() bool {
+		return !task.Stats().Next.IsZero()
+	})
+	s.Stop(context.Background())
+
+	next := task.Stats().Next
+	if next.Before(start.Add(time.Hour)) || next.After(time.Now().Add(time.Hour+time.Minute)) {
+		t.Errorf("Next run %s out of the jitter window", next)
+	}
+}
+
+func TestYarfSchedule(t *testing.T) {
+	y := New()
+	y.Log = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	if _, err := y.Schedule("bad", nil); err == nil {
+		t.Error("Invalid expressions should fail")
+	}
+
+	task, err := y.Schedule("@every 1ms", func(ctx context.Context) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if task.Stats().Runs != 0 {
+		t.Error("Tasks shouldn't run before the application starts")
+	}
+
+	if err := y.start(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		return task.Stats().Runs > 0
+	})
+
+	if err := y.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
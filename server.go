@@ -0,0 +1,335 @@
+package yarf
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ErrShuttingDown is returned when trying to start a server while the Yarf instance is shutting down.
+var ErrShuttingDown = errors.New("yarf: shutting down")
+
+// newServer creates a http.Server for the Yarf instance and keeps track of it for shutdown.
+func (y *Yarf) newServer(address string) (*http.Server, error) {
+	y.lock.Lock()
+	defer y.lock.Unlock()
+
+	if y.stopping {
+		return nil, ErrShuttingDown
+	}
+
+	s := &http.Server{
+		Addr:      address,
+		Handler:   y,
+		HTTP2:     y.HTTP2,
+		ErrorLog:  y.serverErrorLog(),
+		ConnState: y.trackConn,
+	}
+
+	if y.TLSConfig != nil {
+		s.TLSConfig = y.TLSConfig.Clone()
+	}
+
+	if y.H2C {
+		s.Protocols = new(http.Protocols)
+		s.Protocols.SetHTTP1(true)
+		s.Protocols.SetHTTP2(true)
+		s.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	y.servers = append(y.servers, s)
+
+	return s, nil
+}
+
+// serveResult logs the end of a server and hides the error returned by servers closed during a graceful shutdown.
+func (y *Yarf) serveResult(err error, address string) error {
+	if err == http.ErrServerClosed {
+		y.log().Info("server stopped", "address", address)
+		return nil
+	}
+
+	y.log().Error("server failed", "address", address, "error", err)
+	return err
+}
+
+// Start initiates a new http yarf server and start listening.
+// It's a shortcut for http.ListenAndServe(address, y)
+// that keeps track of the server to be stopped by Shutdown().
+// It returns nil when the server is closed by Shutdown().
+func (y *Yarf) Start(address string) error {
+	if address == "" {
+		address = ":http"
+	}
+
+	l, err := y.listen("tcp", address, 0)
+	if err != nil {
+		return err
+	}
+
+	return y.Serve(l)
+}
+
+// StartTLS initiates a new http yarf server and starts listening to HTTPS requests.
+// It is a shortcut for http.ListenAndServeTLS(address, cert, key, yarf)
+// that keeps track of the server to be stopped by Shutdown().
+// It returns nil when the server is closed by Shutdown().
+func (y *Yarf) StartTLS(address, cert, key string) error {
+	if address == "" {
+		address = ":https"
+	}
+
+	l, err := y.listen("tcp", address, 0)
+	if err != nil {
+		return err
+	}
+
+	s, err := y.newServer(address)
+	if err != nil {
+		l.Close()
+		return err
+	}
+
+	y.log().Info("server started", "address", l.Addr().String(), "tls", true)
+
+	return y.serveResult(s.ServeTLS(l, cert, key), l.Addr().String())
+}
+
+// Serve accepts incoming connections on the listener provided and serves them with the Yarf instance.
+// Use it to serve on custom or inherited listeners, like the ones from systemd socket activation.
+// It returns nil when the server is closed by Shutdown().
+func (y *Yarf) Serve(l net.Listener) error {
+	if err := y.start(); err != nil {
+		l.Close()
+		return err
+	}
+
+	s, err := y.newServer(l.Addr().String())
+	if err != nil {
+		l.Close()
+		return err
+	}
+
+	y.log().Info("server started", "address", l.Addr().String())
+
+	return y.serveResult(s.Serve(l), l.Addr().String())
+}
+
+// StartUnix initiates a new http yarf server listening on a Unix domain socket.
+// A stale socket file on the path is removed before listening, and the socket gets the file mode provided.
+// It returns nil when the server is closed by Shutdown().
+func (y *Yarf) StartUnix(path string, mode os.FileMode) error {
+	l, err := y.listen("unix", path, mode)
+	if err != nil {
+		return err
+	}
+
+	return y.Serve(l)
+}
+
+// listenUnix listens on a Unix domain socket, removing stale socket files and setting the file mode.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// CertManager provides certificates for TLS handshakes and answers the ACME HTTP-01 challenges.
+// It's implemented by *autocert.Manager from the golang.org/x/crypto/acme/autocert package.
+// Check the yarf/autotls package to create one.
+type CertManager interface {
+	GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	HTTPHandler(fallback http.Handler) http.Handler
+}
+
+// StartAutoTLS initiates a new https yarf server getting its certificates from the CertManager,
+// and a plain http server on httpAddress to answer the HTTP-01 challenges.
+// Other requests to the http server go to the fallback handler, or get redirected to https if it's nil.
+// An empty httpAddress disables the http server, so only TLS-ALPN-01 challenges can be used.
+// It returns when any of the servers stops, and nil when they're closed by Shutdown().
+func (y *Yarf) StartAutoTLS(address, httpAddress string, m CertManager, fallback http.Handler) error {
+	l, err := y.listen("tcp", address, 0)
+	if err != nil {
+		return err
+	}
+
+	s, err := y.newServer(address)
+	if err != nil {
+		l.Close()
+		return err
+	}
+	s.TLSConfig = &tls.Config{
+		GetCertificate: m.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1", "acme-tls/1"},
+	}
+
+	result := make(chan error, 2)
+
+	if httpAddress != "" {
+		hl, err := y.listen("tcp", httpAddress, 0)
+		if err != nil {
+			l.Close()
+			return err
+		}
+
+		hs, err := y.newServer(httpAddress)
+		if err != nil {
+			l.Close()
+			hl.Close()
+			return err
+		}
+		hs.Handler = m.HTTPHandler(fallback)
+
+		y.log().Info("server started", "address", hl.Addr().String())
+
+		go func() {
+			result <- y.serveResult(hs.Serve(hl), hl.Addr().String())
+		}()
+	}
+
+	y.log().Info("server started", "address", l.Addr().String(), "tls", true)
+
+	go func() {
+		result <- y.serveResult(s.ServeTLS(l, "", ""), l.Addr().String())
+	}()
+
+	return <-result
+}
+
+// OnStart registers a function to be executed before the first listener starts,
+// to open database pools, warm caches, etc.
+// Hooks run once, in registration order. If a hook fails, the remaining hooks don't run
+// and the Start method returns its error.
+func (y *Yarf) OnStart(fn func() error) {
+	y.lock.Lock()
+	defer y.lock.Unlock()
+
+	y.onStart = append(y.onStart, fn)
+}
+
+// start runs the OnStart hooks the first time it's called, and returns their result on every call.
+func (y *Yarf) start() error {
+	y.lock.Lock()
+	stopping := y.stopping
+	y.lock.Unlock()
+
+	if stopping {
+		return ErrShuttingDown
+	}
+
+	y.startOnce.Do(func() {
+		for _, err := range y.ParamConflicts() {
+			y.log().Info("route param conflict", "error", err)
+		}
+
+		y.lock.Lock()
+		hooks := y.onStart
+		y.lock.Unlock()
+
+		for _, fn := range hooks {
+			if err := fn(); err != nil {
+				y.log().Error("start hook failed", "error", err)
+				y.startErr = err
+				return
+			}
+		}
+	})
+
+	return y.startErr
+}
+
+// OnStop registers a function to be executed on Shutdown, after the servers stop handling requests.
+// Hooks run in registration order and receive the Shutdown context to respect its deadline.
+func (y *Yarf) OnStop(fn func(context.Context) error) {
+	y.lock.Lock()
+	defer y.lock.Unlock()
+
+	y.onStop = append(y.onStop, fn)
+}
+
+// Shutdown gracefully stops the servers started by the Yarf instance.
+// If health endpoints are enabled, the application is first marked as not ready.
+// It stops accepting new connections, waits for in-flight requests to finish until the context is done,
+// and then runs the OnStop hooks.
+// All errors found are returned together.
+func (y *Yarf) Shutdown(ctx context.Context) error {
+	// Stop receiving traffic from load balancers
+	y.drain(ctx)
+
+	y.lock.Lock()
+	y.stopping = true
+	servers := y.servers
+	hooks := y.onStop
+	y.servers = nil
+	y.lock.Unlock()
+
+	var errs []error
+
+	y.log().Info("shutting down", "servers", len(servers))
+
+	for _, s := range servers {
+		if err := s.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Servers don't track the hijacked connections
+	y.closeHijacked()
+
+	for _, fn := range hooks {
+		if err := fn(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		y.log().Error("shutdown failed", "error", err)
+	}
+
+	return err
+}
+
+// ShutdownOnSignal runs Shutdown when the process receives one of the signals provided,
+// or SIGINT and SIGTERM if none is provided.
+// In-flight requests are given the timeout to finish.
+// The returned channel receives the Shutdown result.
+func (y *Yarf) ShutdownOnSignal(timeout time.Duration, signals ...os.Signal) <-chan error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, signals...)
+
+	done := make(chan error, 1)
+	go func() {
+		<-sig
+		signal.Stop(sig)
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		done <- y.Shutdown(ctx)
+	}()
+
+	return done
+}
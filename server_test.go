@@ -0,0 +1,278 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// freeAddress returns a local address with a free port.
+func freeAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	return l.Addr().String()
+}
+
+// waitServer waits until the address accepts connections.
+func waitServer(t *testing.T, address string) {
+	for i := 0; i < 100; i++ {
+		conn, err := net.Dial("tcp", address)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Server on %s didn't start", address)
+}
+
+func TestShutdown(t *testing.T) {
+	r := &BlockingResource{started: make(chan bool), release: make(chan bool)}
+
+	y := New()
+	y.Add("/block", r)
+
+	var stopped bool
+	y.OnStop(func(ctx context.Context) error {
+		stopped = true
+		return nil
+	})
+
+	address := freeAddress(t)
+	result := make(chan error)
+	go func() {
+		result <- y.Start(address)
+	}()
+	waitServer(t, address)
+
+	// In-flight request
+	response := make(chan int)
+	go func() {
+		res, err := http.Get("http://" + address + "/block")
+		if err != nil {
+			response <- 0
+			return
+		}
+		ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		response <- res.StatusCode
+	}()
+	<-r.started
+
+	shutdown := make(chan error)
+	go func() {
+		shutdown <- y.Shutdown(context.Background())
+	}()
+
+	// Let the request finish
+	time.Sleep(50 * time.Millisecond)
+	r.release <- true
+
+	if code := <-response; code != 200 {
+		t.Errorf("In-flight request should finish with 200, got %d", code)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown returned error: %s", err)
+	}
+	if err := <-result; err != nil {
+		t.Errorf("Start should return nil after Shutdown, got: %s", err)
+	}
+	if !stopped {
+		t.Error("OnStop hooks should run on Shutdown")
+	}
+	if err := y.Start(address); err != ErrShuttingDown {
+		t.Errorf("Start after Shutdown should return ErrShuttingDown, got: %v", err)
+	}
+}
+
+func TestShutdownHookErrors(t *testing.T) {
+	y := New()
+
+	e := errors.New("hook error")
+	y.OnStop(func(ctx context.Context) error {
+		return e
+	})
+
+	if err := y.Shutdown(context.Background()); !errors.Is(err, e) {
+		t.Errorf("Shutdown should return hook errors, got: %v", err)
+	}
+}
+
+func TestStartHooks(t *testing.T) {
+	y := New()
+
+	var calls []string
+	y.OnStart(func() error {
+		calls = append(calls, "first")
+		return nil
+	})
+	y.OnStart(func() error {
+		calls = append(calls, "second")
+		return nil
+	})
+
+	address := freeAddress(t)
+	go y.Start(address)
+	waitServer(t, address)
+
+	// Hooks run only once
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go y.Serve(l)
+	waitServer(t, l.Addr().String())
+
+	y.Shutdown(context.Background())
+
+	if strings.Join(calls, ",") != "first,second" {
+		t.Errorf("Expected hooks to run once in order, got %v", calls)
+	}
+}
+
+func TestStartHookError(t *testing.T) {
+	y := New()
+
+	e := errors.New("db unavailable")
+	y.OnStart(func() error {
+		return e
+	})
+
+	if err := y.Start(freeAddress(t)); err != e {
+		t.Errorf("Start should return the hook error, got: %v", err)
+	}
+}
+
+type ProtoResource struct {
+	Resource
+}
+
+func (r *ProtoResource) Get(c *Context) error {
+	c.Render(c.Request.Proto)
+	return nil
+}
+
+func TestStartH2C(t *testing.T) {
+	y := New()
+	y.H2C = true
+	y.HTTP2 = &http.HTTP2Config{MaxConcurrentStreams: 10}
+	y.Add("/", new(ProtoResource))
+
+	address := freeAddress(t)
+	go y.Start(address)
+	defer y.Shutdown(context.Background())
+	waitServer(t, address)
+
+	tr := new(http.Transport)
+	tr.Protocols = new(http.Protocols)
+	tr.Protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: tr}
+	defer tr.CloseIdleConnections()
+
+	res, err := client.Get("http://" + address + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	if string(body) != "HTTP/2.0" {
+		t.Errorf("Request should be served over HTTP/2, got %s", body)
+	}
+
+	// HTTP/1 still works
+	res, err = http.Get("http://" + address + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	if string(body) != "HTTP/1.1" {
+		t.Errorf("HTTP/1.1 request should be served, got %s", body)
+	}
+}
+
+func TestStartUnix(t *testing.T) {
+	y := New()
+	y.Add("/", new(ProtoResource))
+
+	path := filepath.Join(t.TempDir(), "yarf.sock")
+	go y.StartUnix(path, 0660)
+	defer y.Shutdown(context.Background())
+
+	var conn net.Conn
+	var err error
+	for i := 0; i < 100; i++ {
+		if conn, err = net.Dial("unix", path); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0660 {
+		t.Errorf("Socket mode should be 0660, got %o", info.Mode().Perm())
+	}
+
+	tr := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return net.Dial("unix", path)
+		},
+	}
+	defer tr.CloseIdleConnections()
+
+	res, err := (&http.Client{Transport: tr}).Get("http://unix/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != 200 {
+		t.Errorf("Request through unix socket should return 200, got %d", res.StatusCode)
+	}
+}
+
+func TestServe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y := New()
+	y.Add("/", new(ProtoResource))
+
+	result := make(chan error)
+	go func() {
+		result <- y.Serve(l)
+	}()
+
+	res, err := http.Get("http://" + l.Addr().String() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	y.Shutdown(context.Background())
+	if err := <-result; err != nil {
+		t.Errorf("Serve should return nil after Shutdown, got: %s", err)
+	}
+}
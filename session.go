@@ -0,0 +1,311 @@
+package yarf
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Session is the server-side state of a client, identified by the random ID of its cookie.
+type Session struct {
+	// ID is the random identifier sent in the session cookie.
+	ID string
+
+	// Values are the session data.
+	Values map[string]string
+
+	changed bool
+}
+
+// Get returns a value of the session. It's safe to use on a nil *Session.
+func (s *Session) Get(key string) string {
+	if s == nil {
+		return ""
+	}
+
+	return s.Values[key]
+}
+
+// Set changes a value of the session, saved at the end of the request.
+func (s *Session) Set(key, value string) {
+	if s.Values == nil {
+		s.Values = make(map[string]string)
+	}
+	s.Values[key] = value
+	s.changed = true
+}
+
+// Delete removes a value of the session.
+func (s *Session) Delete(key string) {
+	if _, ok := s.Values[key]; ok {
+		delete(s.Values, key)
+		s.changed = true
+	}
+}
+
+// SessionStore keeps the session values by ID.
+type SessionStore interface {
+	// Load returns the values of the session, or nil if it doesn't exist or expired.
+	Load(ctx context.Context, id string) (map[string]string, error)
+
+	// Save stores the values of the session for ttl.
+	Save(ctx context.Context, id string, values map[string]string, ttl time.Duration) error
+
+	// Delete removes the session.
+	Delete(ctx context.Context, id string) error
+}
+
+// memorySession is a MemorySessionStore entry.
+type memorySession struct {
+	values  map[string]string
+	expires time.Time
+}
+
+// MemorySessionStore is an in-memory SessionStore implementation, for single instance deployments.
+type MemorySessionStore struct {
+	// Clock tells the time to expire the sessions. nil uses the system clock.
+	Clock Clock
+
+	sessions map[string]memorySession
+	next     time.Time
+	lock     sync.Mutex
+}
+
+// NewMemorySessionStore creates a new empty MemorySessionStore.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[string]memorySession),
+	}
+}
+
+// Load returns a copy of the values of the session, or nil if it doesn't exist or expired.
+func (s *MemorySessionStore) Load(ctx context.Context, id string) (map[string]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e, ok := s.sessions[id]
+	if !ok || !clockNow(s.Clock).Before(e.expires) {
+		return nil, nil
+	}
+
+	return copyValues(e.values), nil
+}
+
+// Save stores a copy of the values of the session for ttl.
+func (s *MemorySessionStore) Save(ctx context.Context, id string, values map[string]string, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := clockNow(s.Clock)
+	s.sessions[id] = memorySession{values: copyValues(values), expires: now.Add(ttl)}
+
+	// Cleanup expired sessions, at most once per ttl
+	if now.After(s.next) {
+		for k, e := range s.sessions {
+			if !now.Before(e.expires) {
+				delete(s.sessions, k)
+			}
+		}
+		s.next = now.Add(ttl)
+	}
+
+	return nil
+}
+
+// Delete removes the session.
+func (s *MemorySessionStore) Delete(ctx context.Context, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.sessions, id)
+
+	return nil
+}
+
+// copyValues returns a copy of the session values.
+func copyValues(values map[string]string) map[string]string {
+	c := make(map[string]string, len(values))
+	for k, v := range values {
+		c[k] = v
+	}
+
+	return c
+}
+
+// sessionKey is the Context storage key for the session state.
+type sessionKey struct{}
+
+// sessionState is the session of the request and the middleware handling it.
+type sessionState struct {
+	m       *SessionMiddleware
+	session *Session
+}
+
+// SessionMiddleware loads the server-side session of the request from its Store,
+// and saves it after the handler if it changed. Handlers access it with c.Session():
+//
+//	y.Insert(yarf.NewSessionMiddleware(yarf.NewMemorySessionStore()))
+//
+//	func (r *Login) Post(c *yarf.Context) error {
+//		...
+//		s := c.RenewSession()
+//		s.Set("user", id)
+//		return nil
+//	}
+//
+// Sessions are only stored once a value is set, and expire TTL after their last change.
+// Use a shared store, like the redis or sqlstore ones, with several instances.
+type SessionMiddleware struct {
+	Middleware
+
+	// Store keeps the sessions.
+	Store SessionStore
+
+	// CookieName is the name of the cookie holding the session ID.
+	CookieName string
+
+	// TTL is the lifetime of the sessions, and the Max-Age of their cookies.
+	TTL time.Duration
+
+	// Path, Domain, Secure, HTTPOnly and SameSite are the attributes of the cookie.
+	Path     string
+	Domain   string
+	Secure   bool
+	HTTPOnly bool
+	SameSite http.SameSite
+}
+
+// NewSessionMiddleware creates a SessionMiddleware with the store provided, using the "session" cookie,
+// and sessions valid for 24 hours. Cookies are secure, HTTP only, SameSite=Lax and valid for the whole site.
+func NewSessionMiddleware(store SessionStore) *SessionMiddleware {
+	return &SessionMiddleware{
+		Store:      store,
+		CookieName: "session",
+		TTL:        24 * time.Hour,
+		Path:       "/",
+		Secure:     true,
+		HTTPOnly:   true,
+		SameSite:   http.SameSiteLaxMode,
+	}
+}
+
+// PreDispatch loads the session of the request cookie.
+func (m *SessionMiddleware) PreDispatch(c *Context) error {
+	st := &sessionState{m: m}
+	c.set(sessionKey{}, st)
+
+	ck, err := c.Request.Cookie(m.CookieName)
+	if err != nil || ck.Value == "" {
+		return nil
+	}
+
+	values, err := m.Store.Load(c.Request.Context(), ck.Value)
+	if err != nil {
+		return err
+	}
+	if values != nil {
+		st.session = &Session{ID: ck.Value, Values: values}
+	}
+
+	return nil
+}
+
+// PostDispatch saves the session if the handler changed it.
+func (m *SessionMiddleware) PostDispatch(c *Context) error {
+	st, ok := c.get(sessionKey{}).(*sessionState)
+	if !ok || st.m != m || st.session == nil || !st.session.changed {
+		return nil
+	}
+	st.session.changed = false
+
+	return m.Store.Save(c.Request.Context(), st.session.ID, st.session.Values, m.TTL)
+}
+
+// cookie creates the session cookie with the attributes of the middleware.
+func (m *SessionMiddleware) cookie(value string) *http.Cookie {
+	ck := &http.Cookie{
+		Name:     m.CookieName,
+		Value:    value,
+		Path:     m.Path,
+		Domain:   m.Domain,
+		Secure:   m.Secure,
+		HttpOnly: m.HTTPOnly,
+		SameSite: m.SameSite,
+	}
+	if m.TTL > 0 {
+		ck.MaxAge = int(m.TTL / time.Second)
+	}
+
+	return ck
+}
+
+// create starts a new session and sends its cookie, so it must run before the response is written.
+func (m *SessionMiddleware) create(c *Context) *Session {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	s := &Session{ID: base64.RawURLEncoding.EncodeToString(b), Values: make(map[string]string)}
+	http.SetCookie(c.Response, m.cookie(s.ID))
+
+	return s
+}
+
+// Session returns the session of the request, starting a new one if the client doesn't have any.
+// New sessions send their cookie, so call it before writing the response.
+// It returns nil if the route isn't covered by a SessionMiddleware.
+func (c *Context) Session() *Session {
+	st, ok := c.get(sessionKey{}).(*sessionState)
+	if !ok {
+		return nil
+	}
+	if st.session == nil {
+		st.session = st.m.create(c)
+	}
+
+	return st.session
+}
+
+// RenewSession moves the values of the session to a new ID and removes the old one,
+// so a session ID known before the login can't be used after it.
+// It returns nil if the route isn't covered by a SessionMiddleware.
+func (c *Context) RenewSession() *Session {
+	st, ok := c.get(sessionKey{}).(*sessionState)
+	if !ok {
+		return nil
+	}
+
+	old := st.session
+	st.session = st.m.create(c)
+	if old != nil {
+		st.session.Values = old.Values
+		st.session.changed = true
+		if err := st.m.Store.Delete(c.Request.Context(), old.ID); err != nil && c.app != nil {
+			c.app.log().Error("session delete failed", "error", err)
+		}
+	}
+
+	return st.session
+}
+
+// DestroySession removes the session of the request from the store and the client, like on logout.
+func (c *Context) DestroySession() error {
+	st, ok := c.get(sessionKey{}).(*sessionState)
+	if !ok || st.session == nil {
+		return nil
+	}
+
+	id := st.session.ID
+	st.session = nil
+
+	ck := st.m.cookie("")
+	ck.MaxAge = -1
+	ck.Expires = time.Unix(0, 0)
+	http.SetCookie(c.Response, ck)
+
+	return st.m.Store.Delete(c.Request.Context(), id)
+}
@@ -0,0 +1,117 @@
+package yarf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// ServerSessionResource logs in, reads and logs out the session user.
+type ServerSessionResource struct {
+	Resource
+}
+
+func (r *ServerSessionResource) Get(c *Context) error {
+	c.Render(c.Session().Get("user"))
+	return nil
+}
+
+func (r *ServerSessionResource) Post(c *Context) error {
+	c.RenewSession().Set("user", c.QueryValue("user"))
+	return nil
+}
+
+func (r *ServerSessionResource) Delete(c *Context) error {
+	return c.DestroySession()
+}
+
+func TestSessionMiddleware(t *testing.T) {
+	store := NewMemorySessionStore()
+	m := NewSessionMiddleware(store)
+
+	y := New()
+	y.Insert(m)
+	y.Add("/session", new(ServerSessionResource))
+
+	send := func(method, target string, ck *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if ck != nil {
+			req.AddCookie(ck)
+		}
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+		return res
+	}
+	cookie := func(res *httptest.ResponseRecorder) *http.Cookie {
+		for _, ck := range res.Result().Cookies() {
+			if ck.Name == "session" {
+				return ck
+			}
+		}
+		return nil
+	}
+
+	// Reading creates an empty session that isn't stored
+	res := send("GET", "/session", nil)
+	anon := cookie(res)
+	if anon == nil || res.Body.String() != "" || !anon.Secure || !anon.HttpOnly || anon.MaxAge != 86400 {
+		t.Fatalf("Expected new session cookie, got %v", res.Header())
+	}
+	if values, _ := store.Load(context.Background(), anon.Value); values != nil {
+		t.Errorf("Unchanged sessions shouldn't be stored, got %v", values)
+	}
+
+	// Login renews the ID
+	login := cookie(send("POST", "/session?user=ann", anon))
+	if login == nil || login.Value == anon.Value {
+		t.Fatalf("Expected a new session ID, got %v", login)
+	}
+	if res := send("GET", "/session", login); res.Body.String() != "ann" || cookie(res) != nil {
+		t.Errorf("Expected the session user without new cookie, got %q %v", res.Body.String(), res.Header())
+	}
+
+	// Logout
+	res = send("DELETE", "/session", login)
+	if ck := cookie(res); ck == nil || ck.MaxAge != -1 {
+		t.Errorf("Expected removed cookie, got %v", res.Header())
+	}
+	if values, _ := store.Load(context.Background(), login.Value); values != nil {
+		t.Errorf("Expected deleted session, got %v", values)
+	}
+
+	// Routes without the middleware
+	c := NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+	if c.Session() != nil || c.RenewSession() != nil || c.DestroySession() != nil {
+		t.Error("Expected no session without middleware")
+	}
+	if c.Session().Get("user") != "" {
+		t.Error("Get should be safe on a nil session")
+	}
+}
+
+func TestMemorySessionStore(t *testing.T) {
+	clock := newTestClock()
+	s := NewMemorySessionStore()
+	s.Clock = clock
+	ctx := context.Background()
+
+	values := map[string]string{"user": "ann"}
+	s.Save(ctx, "a", values, time.Minute)
+	values["user"] = "bob"
+
+	if v, err := s.Load(ctx, "a"); err != nil || v["user"] != "ann" {
+		t.Errorf("Expected a copy of the values, got %v %v", v, err)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if v, _ := s.Load(ctx, "a"); v != nil {
+		t.Errorf("Expected expired session, got %v", v)
+	}
+
+	s.Save(ctx, "b", values, time.Minute)
+	if len(s.sessions) != 1 {
+		t.Errorf("Expected expired sessions cleaned up, got %d", len(s.sessions))
+	}
+}
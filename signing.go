@@ -0,0 +1,168 @@
+package yarf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidSignature is returned by ResponseSigner.Verify for responses without a valid signature.
+var ErrInvalidSignature = errors.New("yarf: invalid response signature")
+
+// SignatureFormat is the way a ResponseSigner signs the responses.
+type SignatureFormat int
+
+// Signature formats.
+const (
+	// SignHTTPMessage signs the responses with HTTP Message Signatures (RFC 9421), using the hmac-sha256 algorithm.
+	// The signature covers the status, the Content-Type header and the Content-Digest of the body.
+	SignHTTPMessage SignatureFormat = iota
+
+	// SignBody sends the hex encoded HMAC-SHA256 of the body in a header, like "X-Signature: sha256=...".
+	SignBody
+)
+
+// signatureLabel is the label of the HTTP message signatures.
+const signatureLabel = "sig1"
+
+// ResponseSigner is a Transformer signing the responses, so consumers can verify their integrity
+// when they pass through intermediaries. Insert it in a TransformPipeline to sign the responses of a group:
+//
+//	g.Insert(yarf.NewTransformPipeline(yarf.NewResponseSigner("2024-01", secret)))
+//
+// Responses of failed requests aren't transformed, so they're sent unsigned.
+type ResponseSigner struct {
+	// KeyID identifies the secret, so consumers can pick the key and secrets can be rotated.
+	KeyID string
+
+	// Secret is the HMAC key shared with the consumers.
+	Secret []byte
+
+	// Format is the signature format.
+	Format SignatureFormat
+
+	// Header is the header carrying the SignBody signatures.
+	Header string
+
+	// Components are the response components covered by the SignHTTPMessage signatures:
+	// "@status" and lower case header names. Headers missing from the response aren't covered.
+	Components []string
+
+	// Clock tells the signature creation time. nil uses the system clock.
+	Clock Clock
+}
+
+// NewResponseSigner creates a ResponseSigner with HTTP Message Signatures covering the status,
+// the Content-Type and the Content-Digest of the responses, or sending the SignBody signatures in the X-Signature header.
+func NewResponseSigner(keyID string, secret []byte) *ResponseSigner {
+	return &ResponseSigner{
+		KeyID:      keyID,
+		Secret:     secret,
+		Format:     SignHTTPMessage,
+		Header:     "X-Signature",
+		Components: []string{"@status", "content-type", "content-digest"},
+	}
+}
+
+// Transform signs the response.
+func (s *ResponseSigner) Transform(c *Context, res *TransformedResponse) error {
+	if s.Format == SignBody {
+		res.Header.Set(s.Header, "sha256="+hex.EncodeToString(s.mac(res.Body)))
+		return nil
+	}
+
+	if res.Header.Get("Content-Digest") == "" && containsString(s.Components, "content-digest") {
+		res.Header.Set("Content-Digest", contentDigest(res.Body))
+	}
+
+	var covered []string
+	for _, name := range s.Components {
+		if name == "@status" || res.Header.Get(name) != "" {
+			covered = append(covered, strconv.Quote(name))
+		}
+	}
+	params := "(" + strings.Join(covered, " ") + ");created=" + strconv.FormatInt(clockNow(s.Clock).Unix(), 10) +
+		";keyid=" + strconv.Quote(s.KeyID) + `;alg="hmac-sha256"`
+
+	base, err := signatureBase(res.Status, res.Header, params)
+	if err != nil {
+		return err
+	}
+	res.Header.Set("Signature-Input", signatureLabel+"="+params)
+	res.Header.Set("Signature", signatureLabel+"=:"+base64.StdEncoding.EncodeToString(s.mac([]byte(base)))+":")
+
+	return nil
+}
+
+// Verify checks the signature of a response, as a consumer would, returning ErrInvalidSignature if it's invalid.
+// With HTTP Message Signatures, it also checks the Content-Digest of the body if it's covered.
+func (s *ResponseSigner) Verify(status int, header http.Header, body []byte) error {
+	if s.Format == SignBody {
+		sig := strings.TrimPrefix(header.Get(s.Header), "sha256=")
+		if !hmac.Equal([]byte(sig), []byte(hex.EncodeToString(s.mac(body)))) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+
+	params := strings.TrimPrefix(header.Get("Signature-Input"), signatureLabel+"=")
+	if !strings.HasPrefix(params, "(") || !strings.Contains(params, ";keyid="+strconv.Quote(s.KeyID)) {
+		return ErrInvalidSignature
+	}
+	if strings.Contains(params, `"content-digest"`) && !checkContentDigest(header.Get("Content-Digest"), body) {
+		return ErrInvalidSignature
+	}
+
+	base, err := signatureBase(status, header, params)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	sig := signatureLabel + "=:" + base64.StdEncoding.EncodeToString(s.mac([]byte(base))) + ":"
+	if !hmac.Equal([]byte(header.Get("Signature")), []byte(sig)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// mac returns the HMAC-SHA256 of data.
+func (s *ResponseSigner) mac(data []byte) []byte {
+	m := hmac.New(sha256.New, s.Secret)
+	m.Write(data)
+
+	return m.Sum(nil)
+}
+
+// signatureBase builds the RFC 9421 signature base of a response, for the components listed in params.
+func signatureBase(status int, header http.Header, params string) (string, error) {
+	end := strings.IndexByte(params, ')')
+	if end < 0 {
+		return "", ErrInvalidSignature
+	}
+
+	var b strings.Builder
+	for _, name := range strings.Fields(params[1:end]) {
+		name, err := strconv.Unquote(name)
+		if err != nil {
+			return "", ErrInvalidSignature
+		}
+
+		value := strconv.Itoa(status)
+		if name != "@status" {
+			values := header.Values(name)
+			if len(values) == 0 {
+				return "", ErrInvalidSignature
+			}
+			value = strings.Join(values, ", ")
+		}
+		b.WriteString(strconv.Quote(name) + ": " + strings.TrimSpace(value) + "\n")
+	}
+	b.WriteString(`"@signature-params": ` + params)
+
+	return b.String(), nil
+}
@@ -0,0 +1,94 @@
+package yarf
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSignatureBase(t *testing.T) {
+	h := http.Header{}
+	h.Set("Content-Type", "application/json")
+	h.Set("Content-Digest", contentDigest([]byte(`{"hello": "world"}`)))
+
+	params := `("@status" "content-type" "content-digest");created=1618884473;keyid="test-key";alg="hmac-sha256"`
+	base, err := signatureBase(200, h, params)
+	expected := `"@status": 200
+"content-type": application/json
+"content-digest": sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:
+"@signature-params": ` + params
+	if err != nil || base != expected {
+		t.Errorf("Expected signature base:\n%s\ngot:\n%s %v", expected, base, err)
+	}
+
+	if _, err := signatureBase(200, http.Header{}, params); err != ErrInvalidSignature {
+		t.Errorf("Expected missing components rejected, got %v", err)
+	}
+}
+
+func TestResponseSigner(t *testing.T) {
+	s := NewResponseSigner("2024-01", []byte("secret"))
+	s.Clock = newTestClock()
+
+	g := RouteGroup("/signed")
+	g.Insert(NewTransformPipeline(s))
+	g.Add("/user", new(UserJSONResource))
+	g.Add("/missing", new(FailingJSONResource))
+
+	y := New()
+	y.AddGroup(g)
+	y.Add("/user", new(UserJSONResource))
+
+	res := testRequest(y, "GET", "/signed/user", nil)
+	if res.Header().Get("Signature-Input") != `sig1=("@status" "content-type" "content-digest");created=1577836800;keyid="2024-01";alg="hmac-sha256"` {
+		t.Errorf("Unexpected Signature-Input %s", res.Header().Get("Signature-Input"))
+	}
+	if res.Header().Get("Content-Digest") != contentDigest(res.Body.Bytes()) {
+		t.Errorf("Unexpected Content-Digest %s", res.Header().Get("Content-Digest"))
+	}
+	if err := s.Verify(res.Code, res.Header(), res.Body.Bytes()); err != nil {
+		t.Errorf("Expected valid signature, got %v", err)
+	}
+
+	if err := s.Verify(201, res.Header(), res.Body.Bytes()); err != ErrInvalidSignature {
+		t.Errorf("Expected changed status rejected, got %v", err)
+	}
+	if err := s.Verify(res.Code, res.Header(), []byte(`{"userId":2}`)); err != ErrInvalidSignature {
+		t.Errorf("Expected changed body rejected, got %v", err)
+	}
+	h := res.Header().Clone()
+	h.Set("Content-Type", "text/html")
+	if err := s.Verify(res.Code, h, res.Body.Bytes()); err != ErrInvalidSignature {
+		t.Errorf("Expected changed header rejected, got %v", err)
+	}
+	other := NewResponseSigner("2024-01", []byte("other"))
+	if err := other.Verify(res.Code, res.Header(), res.Body.Bytes()); err != ErrInvalidSignature {
+		t.Errorf("Expected other secrets rejected, got %v", err)
+	}
+
+	if res := testRequest(y, "GET", "/user", nil); res.Header().Get("Signature") != "" {
+		t.Error("Routes outside the group shouldn't be signed")
+	}
+	if res := testRequest(y, "GET", "/signed/missing", nil); res.Code != 404 || res.Header().Get("Signature") != "" {
+		t.Errorf("Expected failed responses unsigned, got %d %s", res.Code, res.Header().Get("Signature"))
+	}
+}
+
+func TestResponseSignerBody(t *testing.T) {
+	s := NewResponseSigner("", []byte("secret"))
+	s.Format = SignBody
+
+	y := New()
+	y.Use(NewTransformPipeline(s))
+	y.Add("/user", new(UserJSONResource))
+
+	res := testRequest(y, "GET", "/user", nil)
+	if len(res.Header().Get("X-Signature")) != len("sha256=")+64 || res.Header().Get("Signature") != "" {
+		t.Errorf("Unexpected signature headers %v", res.Header())
+	}
+	if err := s.Verify(res.Code, res.Header(), res.Body.Bytes()); err != nil {
+		t.Errorf("Expected valid signature, got %v", err)
+	}
+	if err := s.Verify(res.Code, res.Header(), []byte("{}")); err != ErrInvalidSignature {
+		t.Errorf("Expected changed body rejected, got %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package yarf
+
+import (
+	"sort"
+	"time"
+)
+
+// MetaSLO is the RouteMeta key holding the SLO of the route.
+const MetaSLO = "slo"
+
+// sloBuckets is the number of buckets of the SLO rolling windows.
+const sloBuckets = 60
+
+// SLO is a service level objective of a route: the fraction of requests that must be good,
+// answered in less than Latency and without a 5xx error, over a rolling window.
+// The remaining fraction is the error budget.
+type SLO struct {
+	// Latency is the maximum duration of good requests. 0 only counts errors.
+	Latency time.Duration
+
+	// Objective is the fraction of good requests, like 0.99. Defaults to 0.99.
+	Objective float64
+
+	// Window is the rolling window of the objective. Defaults to 1 hour.
+	Window time.Duration
+
+	// MinRequests is the number of requests in the window needed to evaluate the objective,
+	// so a single slow request doesn't burn the budget. Defaults to 10.
+	MinRequests int
+}
+
+// withDefaults returns the SLO with the default values set.
+func (o SLO) withDefaults() SLO {
+	if o.Objective <= 0 || o.Objective >= 1 {
+		o.Objective = 0.99
+	}
+	if o.Window <= 0 {
+		o.Window = time.Hour
+	}
+	if o.MinRequests <= 0 {
+		o.MinRequests = 10
+	}
+
+	return o
+}
+
+// SLO declares the objective of the route and returns the RouteMeta to allow chaining.
+//
+//	y.AddRoute("/search", new(Search)).SLO(yarf.SLO{Latency: 300 * time.Millisecond, Objective: 0.995})
+func (m *RouteMeta) SLO(o SLO) *RouteMeta {
+	return m.Set(MetaSLO, o)
+}
+
+// SLOEvent reports the state of a route objective over its window.
+type SLOEvent struct {
+	Route    string    `json:"route"`
+	SLO      SLO       `json:"slo"`
+	Total    int       `json:"total"`     // Requests in the window
+	Bad      int       `json:"bad"`       // Slow or failed requests in the window
+	BurnRate float64   `json:"burn_rate"` // Error budget consumption: 1 uses the whole budget over the window
+	Burned   bool      `json:"burned"`    // The error budget is exhausted
+	Time     time.Time `json:"time"`
+}
+
+// sloBucket counts the requests of a slice of the window.
+type sloBucket struct {
+	index      int64
+	total, bad int
+}
+
+// sloWindow tracks the requests of a route over the SLO window.
+type sloWindow struct {
+	route   string
+	slo     SLO
+	buckets [sloBuckets]sloBucket
+	burned  bool
+}
+
+// record adds a request to the window, and returns an event when the budget gets burned or recovers.
+// The window must be locked.
+func (w *sloWindow) record(now time.Time, bad bool) *SLOEvent {
+	idx := now.UnixNano() / int64(w.slo.Window/sloBuckets)
+	b := &w.buckets[idx%sloBuckets]
+	if b.index != idx {
+		*b = sloBucket{index: idx}
+	}
+	b.total++
+	if bad {
+		b.bad++
+	}
+
+	e := w.event(now)
+	if e.Total < w.slo.MinRequests || e.Burned == w.burned {
+		return nil
+	}
+	w.burned = e.Burned
+
+	return e
+}
+
+// event returns the state of the window. The window must be locked.
+func (w *sloWindow) event(now time.Time) *SLOEvent {
+	e := &SLOEvent{
+		Route: w.route,
+		SLO:   w.slo,
+		Time:  now,
+	}
+
+	idx := now.UnixNano() / int64(w.slo.Window/sloBuckets)
+	for _, b := range w.buckets {
+		if b.index > idx-sloBuckets {
+			e.Total += b.total
+			e.Bad += b.bad
+		}
+	}
+
+	if e.Total > 0 {
+		e.BurnRate = float64(e.Bad) / float64(e.Total) / (1 - w.slo.Objective)
+	}
+	// Tolerates the rounding errors of the objective fractions
+	e.Burned = e.Total >= w.slo.MinRequests && e.BurnRate > 1+1e-9
+
+	return e
+}
+
+// OnSLO registers a callback receiving an event when the error budget of a route is burned, and when it recovers.
+// Callbacks run in the goroutine of the request completing the change, so slow work should be started in the background.
+// Without callbacks, the events are logged.
+//
+//	y.OnSLO(func(e *yarf.SLOEvent) {
+//		if e.Burned {
+//			pager.Alert("SLO burned on " + e.Route)
+//		}
+//	})
+func (y *Yarf) OnSLO(fn func(*SLOEvent)) {
+	y.lock.Lock()
+	defer y.lock.Unlock()
+
+	y.onSLO = append(y.onSLO, fn)
+}
+
+// SLOReport returns the current state of the route objectives, sorted by route.
+// Routes are tracked from their first request.
+func (y *Yarf) SLOReport() []SLOEvent {
+	y.lock.Lock()
+	defer y.lock.Unlock()
+
+	now := time.Now()
+	report := make([]SLOEvent, 0, len(y.slos))
+	for _, w := range y.slos {
+		report = append(report, *w.event(now))
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Route < report[j].Route
+	})
+
+	return report
+}
+
+// trackSLO records a request into the window of its route objective, if it declares one.
+func (y *Yarf) trackSLO(c *Context, elapsed time.Duration) {
+	o, ok := c.RouteMeta().Get(MetaSLO).(SLO)
+	if !ok {
+		return
+	}
+	o = o.withDefaults()
+
+	bad := o.Latency > 0 && elapsed > o.Latency
+	if err := c.Err(); err != nil {
+		if yerr, ok := err.(YError); !ok || yerr.Code() >= 500 {
+			bad = true
+		}
+	}
+
+	route := c.RoutePattern()
+
+	y.lock.Lock()
+	if y.slos == nil {
+		y.slos = make(map[string]*sloWindow)
+	}
+	w, ok := y.slos[route]
+	if !ok {
+		w = &sloWindow{route: route, slo: o}
+		y.slos[route] = w
+	}
+	e := w.record(time.Now(), bad)
+	hooks := y.onSLO
+	y.lock.Unlock()
+
+	if e == nil {
+		return
+	}
+
+	if len(hooks) == 0 {
+		msg := "slo budget recovered"
+		if e.Burned {
+			msg = "slo budget burned"
+		}
+		y.log().Error(msg, "route", e.Route, "total", e.Total, "bad", e.Bad, "burn_rate", e.BurnRate)
+		return
+	}
+
+	for _, fn := range hooks {
+		fn(e)
+	}
+}
@@ -0,0 +1,67 @@
+package yarf
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSLOWindow(t *testing.T) {
+	w := &sloWindow{route: "/", slo: SLO{Objective: 0.9, Window: time.Minute, MinRequests: 10}}
+	now := time.Now()
+
+	// 1 bad request out of 10 uses the whole budget, without burning it
+	for i := 0; i < 9; i++ {
+		if e := w.record(now, false); e != nil {
+			t.Fatalf("Unexpected event %+v", e)
+		}
+	}
+	if e := w.record(now, true); e != nil {
+		t.Fatalf("Unexpected event %+v", e)
+	}
+
+	e := w.record(now, true)
+	if e == nil || !e.Burned || e.Total != 11 || e.Bad != 2 {
+		t.Fatalf("Expected burned event, got %+v", e)
+	}
+	if e := w.record(now, true); e != nil {
+		t.Errorf("Events should only be sent on changes, got %+v", e)
+	}
+
+	// Old requests leave the window
+	later := now.Add(time.Minute + time.Second)
+	for i := 0; i < 9; i++ {
+		w.record(later, false)
+	}
+	if e := w.record(later, false); e == nil || e.Burned || e.Total != 10 || e.Bad != 0 {
+		t.Errorf("Expected recovered event, got %+v", e)
+	}
+}
+
+func TestSLOTracking(t *testing.T) {
+	y := New()
+	y.AddRoute("/fail", new(FailingResource)).SLO(SLO{MinRequests: 3})
+	y.AddRoute("/slow", new(OKResource)).SLO(SLO{Latency: time.Nanosecond, MinRequests: 3})
+	y.AddRoute("/ok", new(OKResource)).SLO(SLO{Latency: time.Minute, MinRequests: 3})
+	y.Add("/none", new(OKResource))
+
+	var events []*SLOEvent
+	y.OnSLO(func(e *SLOEvent) {
+		events = append(events, e)
+	})
+
+	for i := 0; i < 3; i++ {
+		for _, p := range []string{"/fail", "/slow", "/ok", "/none"} {
+			y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", p, nil))
+		}
+	}
+
+	if len(events) != 2 || events[0].Route != "/fail" || events[1].Route != "/slow" || !events[0].Burned {
+		t.Errorf("Unexpected events %+v", events)
+	}
+
+	report := y.SLOReport()
+	if len(report) != 3 || report[1].Route != "/ok" || report[1].Total != 3 || report[1].Burned {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
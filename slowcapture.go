@@ -0,0 +1,171 @@
+package yarf
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SlowRequest are the details of a request captured by the SlowCapture middleware.
+// Sensitive headers and params are masked by the application Redactor.
+type SlowRequest struct {
+	Time     time.Time         `json:"time"`
+	Duration float64           `json:"duration_ms"`
+	Method   string            `json:"method"`
+	URL      string            `json:"url"`
+	Route    string            `json:"route,omitempty"`
+	Params   map[string]string `json:"params,omitempty"`
+	Header   http.Header       `json:"header"`
+	Body     string            `json:"body,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// slowKey is the Context storage key for the state of a request sampled by a SlowCapture.
+type slowKey struct {
+	m *SlowCapture
+}
+
+// slowState is the state of a sampled request.
+type slowState struct {
+	start time.Time
+	body  *limitedBuffer
+}
+
+// limitedBuffer keeps the first max bytes written into it.
+type limitedBuffer struct {
+	bytes.Buffer
+	max int64
+}
+
+// Write keeps the data up to the limit, and always reports the whole write as done.
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - int64(b.Len()); room > 0 {
+		if int64(len(p)) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+
+	return len(p), nil
+}
+
+// SlowCapture is a middleware capturing the details of the requests slower than a threshold:
+// headers, params and optionally the body, kept in memory in a ring buffer.
+// Requests are sampled before they start, so the overhead only applies to the sampled ones.
+// Captures are listed by Requests() and on the debug dashboard when set as its Slow field:
+//
+//	sc := yarf.NewSlowCapture(time.Second)
+//	sc.SampleRate = 0.1
+//	y.Use(sc)
+//	y.EnableDashboard("/_dashboard", new(AdminAuth)).Slow = sc
+type SlowCapture struct {
+	Middleware
+
+	// Threshold is the duration over which requests are captured.
+	Threshold time.Duration
+
+	// SampleRate is the fraction of requests sampled, from 0 to 1.
+	SampleRate float64
+
+	// CaptureBody keeps the request body, up to MaxBodySize bytes, as read by the handlers.
+	CaptureBody bool
+
+	// MaxBodySize is the maximum body size kept.
+	MaxBodySize int64
+
+	// Size is the number of captures kept. Older captures are discarded.
+	Size int
+
+	captures []SlowRequest
+	lock     sync.Mutex
+}
+
+// NewSlowCapture creates a SlowCapture keeping the last 100 requests slower than threshold,
+// sampling all the requests, without bodies.
+func NewSlowCapture(threshold time.Duration) *SlowCapture {
+	return &SlowCapture{
+		Threshold:   threshold,
+		SampleRate:  1,
+		MaxBodySize: 64 << 10,
+		Size:        100,
+	}
+}
+
+// Phase puts the capture with the logging middleware.
+func (m *SlowCapture) Phase() Phase {
+	return PhaseLogging
+}
+
+// PreDispatch samples the request and starts its timer.
+func (m *SlowCapture) PreDispatch(c *Context) error {
+	if m.SampleRate < 1 && rand.Float64() >= m.SampleRate {
+		return nil
+	}
+
+	s := &slowState{start: time.Now()}
+	if m.CaptureBody && c.Request.Body != nil && c.Request.Body != http.NoBody {
+		s.body = &limitedBuffer{max: m.MaxBodySize}
+		c.Request.Body = readCloser{io.TeeReader(c.Request.Body, s.body), c.Request.Body}
+	}
+	c.set(slowKey{m}, s)
+
+	return nil
+}
+
+// End captures the sampled request if it was slower than the threshold.
+func (m *SlowCapture) End(c *Context) error {
+	s, ok := c.get(slowKey{m}).(*slowState)
+	if !ok {
+		return nil
+	}
+	c.set(slowKey{m}, nil)
+
+	elapsed := time.Since(s.start)
+	if elapsed <= m.Threshold {
+		return nil
+	}
+
+	r := c.redactor()
+	sr := SlowRequest{
+		Time:     s.start,
+		Duration: float64(elapsed) / float64(time.Millisecond),
+		Method:   c.Request.Method,
+		URL:      r.URL(c.Request.URL),
+		Route:    c.RoutePattern(),
+		Params:   r.RouteParams(c.Params),
+		Header:   r.Header(c.Request.Header).Clone(),
+	}
+	if s.body != nil {
+		sr.Body = s.body.String()
+	}
+	if err := c.Err(); err != nil {
+		sr.Error = err.Error()
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.captures = append(m.captures, sr)
+	if over := len(m.captures) - m.Size; over > 0 {
+		m.captures = append(m.captures[:0], m.captures[over:]...)
+	}
+
+	return nil
+}
+
+// Requests returns the captured requests, the newest first.
+func (m *SlowCapture) Requests() []SlowRequest {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	requests := make([]SlowRequest, 0, len(m.captures))
+	for i := len(m.captures) - 1; i >= 0; i-- {
+		requests = append(requests, m.captures[i])
+	}
+
+	return requests
+}
@@ -0,0 +1,69 @@
+package yarf
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// SleepResource reads the request body and waits for the duration in the query.
+type SleepResource struct {
+	Resource
+}
+
+func (r *SleepResource) Post(c *Context) error {
+	io.ReadAll(c.Request.Body)
+
+	d, _ := time.ParseDuration(c.QueryValue("d"))
+	time.Sleep(d)
+
+	return nil
+}
+
+func TestSlowCapture(t *testing.T) {
+	sc := NewSlowCapture(20 * time.Millisecond)
+	sc.CaptureBody = true
+	sc.MaxBodySize = 5
+	sc.Size = 2
+
+	y := New()
+	y.Use(sc)
+	y.Add("/sleep/:id", new(SleepResource))
+	y.EnableDashboard("/_dashboard").Slow = sc
+
+	for _, target := range []string{"/sleep/1?d=0s", "/sleep/2?d=30ms", "/sleep/3?d=30ms&token=abc", "/sleep/4?d=30ms"} {
+		req := httptest.NewRequest("POST", target, strings.NewReader("hello world"))
+		req.Header.Set("Authorization", "Bearer abc")
+		y.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	requests := sc.Requests()
+	if len(requests) != 2 || requests[0].Params["id"] != "4" || requests[1].Params["id"] != "3" {
+		t.Fatalf("Unexpected captures %+v", requests)
+	}
+	r := requests[1]
+	if r.Body != "hello" || r.Header.Get("Authorization") != RedactMask || r.URL != "/sleep/3?d=30ms&token=[REDACTED]" {
+		t.Errorf("Unexpected capture %+v", r)
+	}
+	if r.Duration < 30 || r.Route != "/sleep/:id" || r.Method != "POST" {
+		t.Errorf("Unexpected capture %+v", r)
+	}
+
+	// On the dashboard
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/_dashboard", nil))
+	var s DashboardSnapshot
+	if err := json.Unmarshal(res.Body.Bytes(), &s); err != nil || len(s.Slow) != 2 {
+		t.Errorf("Expected slow requests on the dashboard, got %s", res.Body.String())
+	}
+
+	// Requests not sampled aren't captured
+	sc.SampleRate = 0
+	y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/sleep/5?d=30ms", nil))
+	if requests := sc.Requests(); requests[0].Params["id"] != "4" {
+		t.Errorf("Unexpected capture of a request not sampled %+v", requests[0])
+	}
+}
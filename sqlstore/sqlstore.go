@@ -0,0 +1,81 @@
+// Package sqlstore implements the yarf stores on database/sql, for applications sharing their state
+// through Postgres, MySQL or SQLite rather than Redis: sessions, idempotency keys, brute-force records and tenants.
+// Each store creates its table with Migrate, or exposes its Schema for the migration tool of the application.
+//
+//	db, _ := sql.Open("postgres", dsn)
+//	store := sqlstore.NewBruteForceStore(db, sqlstore.Postgres)
+//	if err := store.Migrate(ctx); err != nil {
+//		log.Fatal(err)
+//	}
+//	guard := yarf.NewBruteForceGuard()
+//	guard.Store = store
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Dialect is the SQL flavor of the database.
+type Dialect int
+
+// Supported dialects
+const (
+	Postgres Dialect = iota
+	MySQL
+	SQLite
+)
+
+// bind rewrites the ? placeholders of the query for the dialect.
+func (d Dialect) bind(query string) string {
+	if d != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// now returns the time of the clock, or the system time if it's nil.
+func now(c yarf.Clock) time.Time {
+	if c == nil {
+		return time.Now()
+	}
+
+	return c.Now()
+}
+
+// replace deletes the row of the key and inserts the new one in a transaction,
+// as the upsert syntax differs between the dialects.
+func replace(ctx context.Context, db *sql.DB, d Dialect, del string, key interface{}, insert string, args ...interface{}) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, d.bind(del), key); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, d.bind(insert), args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,211 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDB is an in-memory database answering the statements of the stores.
+type fakeDB struct {
+	tables  map[string]map[string]map[string]driver.Value
+	queries []string
+	lock    sync.Mutex
+}
+
+var (
+	fakeDBs  = make(map[string]*fakeDB)
+	fakeLock sync.Mutex
+)
+
+func init() {
+	sql.Register("fakesql", fakeDriver{})
+}
+
+// openFake opens a new fake database.
+func openFake(t *testing.T) (*sql.DB, *fakeDB) {
+	fakeLock.Lock()
+	f := &fakeDB{tables: make(map[string]map[string]map[string]driver.Value)}
+	fakeDBs[t.Name()] = f
+	fakeLock.Unlock()
+
+	db, err := sql.Open("fakesql", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db, f
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeLock.Lock()
+	defer fakeLock.Unlock()
+
+	return &fakeConn{db: fakeDBs[name]}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+var (
+	placeholderRe = regexp.MustCompile(`\$\d+`)
+	createRe      = regexp.MustCompile(`^CREATE TABLE IF NOT EXISTS (\w+) `)
+	selectRe      = regexp.MustCompile(`^SELECT (.+) FROM (\w+) WHERE (\w+) = \?$`)
+	insertRe      = regexp.MustCompile(`^INSERT INTO (\w+) \((.+)\) VALUES`)
+	deleteRe      = regexp.MustCompile(`^DELETE FROM (\w+) WHERE (\w+) = \?$`)
+	staleRe       = regexp.MustCompile(`^DELETE FROM (\w+) WHERE (\w+) = \? AND (\w+) <= \?$`)
+	expireRe      = regexp.MustCompile(`^DELETE FROM (\w+) WHERE (\w+) > 0 AND \w+ < \?$`)
+)
+
+// run executes the statement and returns the selected rows and the affected count.
+func (s *fakeStmt) run(args []driver.Value) ([]string, [][]driver.Value, int64, error) {
+	f := s.db
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.queries = append(f.queries, s.query)
+	q := placeholderRe.ReplaceAllString(s.query, "?")
+
+	if m := createRe.FindStringSubmatch(q); m != nil {
+		if f.tables[m[1]] == nil {
+			f.tables[m[1]] = make(map[string]map[string]driver.Value)
+		}
+		return nil, nil, 0, nil
+	}
+
+	var table string
+	for _, re := range []*regexp.Regexp{selectRe, insertRe, deleteRe, staleRe, expireRe} {
+		if m := re.FindStringSubmatch(q); m != nil {
+			table = m[1]
+			if re == selectRe {
+				table = m[2]
+			}
+		}
+	}
+	rows, ok := f.tables[table]
+	if !ok {
+		return nil, nil, 0, errors.New("no such table: " + table)
+	}
+
+	if m := selectRe.FindStringSubmatch(q); m != nil {
+		cols := strings.Split(m[1], ", ")
+		row, ok := rows[args[0].(string)]
+		if !ok {
+			return cols, nil, 0, nil
+		}
+		values := make([]driver.Value, len(cols))
+		for i, c := range cols {
+			values[i] = row[c]
+		}
+		return cols, [][]driver.Value{values}, 0, nil
+	}
+	if m := insertRe.FindStringSubmatch(q); m != nil {
+		if _, ok := rows[args[0].(string)]; ok {
+			return nil, nil, 0, errors.New("duplicate key: " + args[0].(string))
+		}
+		row := make(map[string]driver.Value)
+		for i, c := range strings.Split(m[2], ", ") {
+			row[c] = args[i]
+		}
+		rows[args[0].(string)] = row
+		return nil, nil, 1, nil
+	}
+	if deleteRe.MatchString(q) {
+		if _, ok := rows[args[0].(string)]; ok {
+			delete(rows, args[0].(string))
+			return nil, nil, 1, nil
+		}
+		return nil, nil, 0, nil
+	}
+	if m := staleRe.FindStringSubmatch(q); m != nil {
+		row, ok := rows[args[0].(string)]
+		if ok && row[m[3]].(int64) <= args[1].(int64) {
+			delete(rows, args[0].(string))
+			return nil, nil, 1, nil
+		}
+		return nil, nil, 0, nil
+	}
+	if m := expireRe.FindStringSubmatch(q); m != nil {
+		var n int64
+		for k, row := range rows {
+			if e := row[m[2]].(int64); e > 0 && e < args[0].(int64) {
+				delete(rows, k)
+				n++
+			}
+		}
+		return nil, nil, n, nil
+	}
+
+	return nil, nil, 0, errors.New("unsupported statement: " + s.query)
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	_, _, n, err := s.run(args)
+	return driver.RowsAffected(n), err
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	cols, rows, _, err := s.run(args)
+	return &fakeRows{cols: cols, rows: rows}, err
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+
+	return nil
+}
+
+func TestDialectBind(t *testing.T) {
+	q := "INSERT INTO t (a, b) VALUES (?, ?)"
+
+	if got := Postgres.bind(q); got != "INSERT INTO t (a, b) VALUES ($1, $2)" {
+		t.Errorf("Unexpected Postgres query %q", got)
+	}
+	if got := MySQL.bind(q); got != q {
+		t.Errorf("Unexpected MySQL query %q", got)
+	}
+	if got := SQLite.bind(q); got != q {
+		t.Errorf("Unexpected SQLite query %q", got)
+	}
+}
@@ -0,0 +1,342 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// BruteForceStore is a yarf.BruteForceStore keeping the records in a table,
+// so blocked IPs and accounts are shared by all the instances.
+// Expired records are ignored, and removed by Cleanup.
+type BruteForceStore struct {
+	DB      *sql.DB
+	Dialect Dialect
+	Table   string
+
+	// Clock tells the time to expire the records. nil uses the system clock.
+	Clock yarf.Clock
+}
+
+// NewBruteForceStore creates a BruteForceStore on the "yarf_bruteforce" table.
+func NewBruteForceStore(db *sql.DB, d Dialect) *BruteForceStore {
+	return &BruteForceStore{
+		DB:      db,
+		Dialect: d,
+		Table:   "yarf_bruteforce",
+	}
+}
+
+// Schema returns the statement creating the table.
+func (s *BruteForceStore) Schema() string {
+	return "CREATE TABLE IF NOT EXISTS " + s.Table + " (name VARCHAR(255) PRIMARY KEY, failures INTEGER NOT NULL, until_ns BIGINT NOT NULL, expires_ns BIGINT NOT NULL)"
+}
+
+// Migrate creates the table if it doesn't exist.
+func (s *BruteForceStore) Migrate(ctx context.Context) error {
+	_, err := s.DB.ExecContext(ctx, s.Schema())
+	return err
+}
+
+// Get retrieves the record for a key, or an empty record if it doesn't exist or expired.
+func (s *BruteForceStore) Get(key string) (yarf.BruteForceRecord, error) {
+	var r yarf.BruteForceRecord
+	var until, expires int64
+
+	q := "SELECT failures, until_ns, expires_ns FROM " + s.Table + " WHERE name = ?"
+	err := s.DB.QueryRow(s.Dialect.bind(q), key).Scan(&r.Failures, &until, &expires)
+	if err == sql.ErrNoRows {
+		return r, nil
+	}
+	if err != nil {
+		return r, err
+	}
+	if expires > 0 && now(s.Clock).UnixNano() > expires {
+		return yarf.BruteForceRecord{}, nil
+	}
+	if until > 0 {
+		r.Until = time.Unix(0, until)
+	}
+
+	return r, nil
+}
+
+// Set saves the record for a key, to be discarded after ttl. 0 means it doesn't expire.
+func (s *BruteForceStore) Set(key string, r yarf.BruteForceRecord, ttl time.Duration) error {
+	var until, expires int64
+	if !r.Until.IsZero() {
+		until = r.Until.UnixNano()
+	}
+	if ttl > 0 {
+		expires = now(s.Clock).Add(ttl).UnixNano()
+	}
+
+	return replace(context.Background(), s.DB, s.Dialect,
+		"DELETE FROM "+s.Table+" WHERE name = ?", key,
+		"INSERT INTO "+s.Table+" (name, failures, until_ns, expires_ns) VALUES (?, ?, ?, ?)", key, r.Failures, until, expires)
+}
+
+// Del removes the record for a key.
+func (s *BruteForceStore) Del(key string) error {
+	_, err := s.DB.Exec(s.Dialect.bind("DELETE FROM "+s.Table+" WHERE name = ?"), key)
+	return err
+}
+
+// Cleanup removes the expired records, and returns how many were removed.
+// Run it periodically to keep the table small.
+func (s *BruteForceStore) Cleanup(ctx context.Context) (int64, error) {
+	q := "DELETE FROM " + s.Table + " WHERE expires_ns > 0 AND expires_ns < ?"
+	res, err := s.DB.ExecContext(ctx, s.Dialect.bind(q), now(s.Clock).UnixNano())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// TenantStore is a yarf.TenantStore loading the tenants from a table, stored as JSON by ID.
+type TenantStore struct {
+	DB      *sql.DB
+	Dialect Dialect
+	Table   string
+}
+
+// NewTenantStore creates a TenantStore on the "yarf_tenants" table.
+func NewTenantStore(db *sql.DB, d Dialect) *TenantStore {
+	return &TenantStore{
+		DB:      db,
+		Dialect: d,
+		Table:   "yarf_tenants",
+	}
+}
+
+// Schema returns the statement creating the table.
+func (s *TenantStore) Schema() string {
+	return "CREATE TABLE IF NOT EXISTS " + s.Table + " (id VARCHAR(255) PRIMARY KEY, data TEXT NOT NULL)"
+}
+
+// Migrate creates the table if it doesn't exist.
+func (s *TenantStore) Migrate(ctx context.Context) error {
+	_, err := s.DB.ExecContext(ctx, s.Schema())
+	return err
+}
+
+// Tenant returns the tenant with the ID, or nil if it doesn't exist.
+func (s *TenantStore) Tenant(ctx context.Context, id string) (*yarf.Tenant, error) {
+	var data string
+	err := s.DB.QueryRowContext(ctx, s.Dialect.bind("SELECT data FROM "+s.Table+" WHERE id = ?"), id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t := new(yarf.Tenant)
+	if err := json.Unmarshal([]byte(data), t); err != nil {
+		return nil, err
+	}
+	t.ID = id
+
+	return t, nil
+}
+
+// Save stores the tenant under its ID.
+func (s *TenantStore) Save(ctx context.Context, t *yarf.Tenant) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	return replace(ctx, s.DB, s.Dialect,
+		"DELETE FROM "+s.Table+" WHERE id = ?", t.ID,
+		"INSERT INTO "+s.Table+" (id, data) VALUES (?, ?)", t.ID, string(data))
+}
+
+// SessionStore is a yarf.SessionStore keeping the session values in a table, stored as JSON by ID.
+// Expired sessions are ignored, and removed by Cleanup.
+type SessionStore struct {
+	DB      *sql.DB
+	Dialect Dialect
+	Table   string
+
+	// Clock tells the time to expire the sessions. nil uses the system clock.
+	Clock yarf.Clock
+}
+
+// NewSessionStore creates a SessionStore on the "yarf_sessions" table.
+func NewSessionStore(db *sql.DB, d Dialect) *SessionStore {
+	return &SessionStore{
+		DB:      db,
+		Dialect: d,
+		Table:   "yarf_sessions",
+	}
+}
+
+// Schema returns the statement creating the table.
+func (s *SessionStore) Schema() string {
+	return "CREATE TABLE IF NOT EXISTS " + s.Table + " (id VARCHAR(255) PRIMARY KEY, data TEXT NOT NULL, expires_ns BIGINT NOT NULL)"
+}
+
+// Migrate creates the table if it doesn't exist.
+func (s *SessionStore) Migrate(ctx context.Context) error {
+	_, err := s.DB.ExecContext(ctx, s.Schema())
+	return err
+}
+
+// Load returns the values of the session, or nil if it doesn't exist or expired.
+func (s *SessionStore) Load(ctx context.Context, id string) (map[string]string, error) {
+	var data string
+	var expires int64
+
+	q := "SELECT data, expires_ns FROM " + s.Table + " WHERE id = ?"
+	err := s.DB.QueryRowContext(ctx, s.Dialect.bind(q), id).Scan(&data, &expires)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if now(s.Clock).UnixNano() >= expires {
+		return nil, nil
+	}
+
+	values := make(map[string]string)
+	if err := json.Unmarshal([]byte(data), &values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// Save stores the values of the session for ttl.
+func (s *SessionStore) Save(ctx context.Context, id string, values map[string]string, ttl time.Duration) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	return replace(ctx, s.DB, s.Dialect,
+		"DELETE FROM "+s.Table+" WHERE id = ?", id,
+		"INSERT INTO "+s.Table+" (id, data, expires_ns) VALUES (?, ?, ?)", id, string(data), now(s.Clock).Add(ttl).UnixNano())
+}
+
+// Delete removes the session.
+func (s *SessionStore) Delete(ctx context.Context, id string) error {
+	_, err := s.DB.ExecContext(ctx, s.Dialect.bind("DELETE FROM "+s.Table+" WHERE id = ?"), id)
+	return err
+}
+
+// Cleanup removes the expired sessions, and returns how many were removed.
+// Run it periodically to keep the table small.
+func (s *SessionStore) Cleanup(ctx context.Context) (int64, error) {
+	q := "DELETE FROM " + s.Table + " WHERE expires_ns > 0 AND expires_ns < ?"
+	res, err := s.DB.ExecContext(ctx, s.Dialect.bind(q), now(s.Clock).UnixNano())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// IdempotencyStore is a yarf.IdempotencyStore keeping the idempotency keys and their responses in a table,
+// stored as JSON by key. Keys are reserved by inserting their row, so the primary key makes the reservation atomic.
+// Expired keys are replaced on reservation, and removed by Cleanup.
+type IdempotencyStore struct {
+	DB      *sql.DB
+	Dialect Dialect
+	Table   string
+
+	// Clock tells the time to expire the keys. nil uses the system clock.
+	Clock yarf.Clock
+}
+
+// NewIdempotencyStore creates an IdempotencyStore on the "yarf_idempotency" table.
+func NewIdempotencyStore(db *sql.DB, d Dialect) *IdempotencyStore {
+	return &IdempotencyStore{
+		DB:      db,
+		Dialect: d,
+		Table:   "yarf_idempotency",
+	}
+}
+
+// Schema returns the statement creating the table.
+func (s *IdempotencyStore) Schema() string {
+	return "CREATE TABLE IF NOT EXISTS " + s.Table + " (id VARCHAR(255) PRIMARY KEY, data TEXT NOT NULL, expires_ns BIGINT NOT NULL)"
+}
+
+// Migrate creates the table if it doesn't exist.
+func (s *IdempotencyStore) Migrate(ctx context.Context) error {
+	_, err := s.DB.ExecContext(ctx, s.Schema())
+	return err
+}
+
+// Reserve inserts the row of the key for ttl and returns nil, or returns the record of the key if it already exists.
+func (s *IdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*yarf.IdempotencyRecord, error) {
+	data, err := json.Marshal(yarf.IdempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+
+	t := now(s.Clock)
+	q := "DELETE FROM " + s.Table + " WHERE id = ? AND expires_ns <= ?"
+	if _, err := s.DB.ExecContext(ctx, s.Dialect.bind(q), key, t.UnixNano()); err != nil {
+		return nil, err
+	}
+
+	q = "INSERT INTO " + s.Table + " (id, data, expires_ns) VALUES (?, ?, ?)"
+	_, insertErr := s.DB.ExecContext(ctx, s.Dialect.bind(q), key, string(data), t.Add(ttl).UnixNano())
+	if insertErr == nil {
+		return nil, nil
+	}
+
+	// The insert fails on duplicate keys, whose error differs between the drivers, so the row is looked up
+	q = "SELECT data FROM " + s.Table + " WHERE id = ?"
+	err = s.DB.QueryRowContext(ctx, s.Dialect.bind(q), key).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, insertErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(yarf.IdempotencyRecord)
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Complete stores the response of the key for ttl.
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, r *yarf.IdempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	return replace(ctx, s.DB, s.Dialect,
+		"DELETE FROM "+s.Table+" WHERE id = ?", key,
+		"INSERT INTO "+s.Table+" (id, data, expires_ns) VALUES (?, ?, ?)", key, string(data), now(s.Clock).Add(ttl).UnixNano())
+}
+
+// Release removes the key.
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	_, err := s.DB.ExecContext(ctx, s.Dialect.bind("DELETE FROM "+s.Table+" WHERE id = ?"), key)
+	return err
+}
+
+// Cleanup removes the expired keys, and returns how many were removed.
+// Run it periodically to keep the table small.
+func (s *IdempotencyStore) Cleanup(ctx context.Context) (int64, error) {
+	q := "DELETE FROM " + s.Table + " WHERE expires_ns > 0 AND expires_ns < ?"
+	res, err := s.DB.ExecContext(ctx, s.Dialect.bind(q), now(s.Clock).UnixNano())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,186 @@
+package sqlstore
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yarf-framework/yarf"
+	"github.com/yarf-framework/yarf/yarftest"
+)
+
+func TestBruteForceStore(t *testing.T) {
+	db, f := openFake(t)
+	store := NewBruteForceStore(db, Postgres)
+	clock := yarftest.NewClock(time.Now())
+	store.Clock = clock
+	ctx := context.Background()
+
+	var _ yarf.BruteForceStore = store
+
+	if _, err := store.Get("ip:1.2.3.4"); err == nil {
+		t.Error("Expected error before the migration")
+	}
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if r, err := store.Get("ip:1.2.3.4"); err != nil || r.Failures != 0 {
+		t.Errorf("Expected empty record, got %+v %v", r, err)
+	}
+
+	until := time.Now().Add(time.Minute)
+	if err := store.Set("ip:1.2.3.4", yarf.BruteForceRecord{Failures: 3, Until: until}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("ip:1.2.3.4", yarf.BruteForceRecord{Failures: 4, Until: until}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := store.Get("ip:1.2.3.4"); err != nil || r.Failures != 4 || !r.Until.Equal(until) {
+		t.Errorf("Unexpected record %+v %v", r, err)
+	}
+
+	store.Set("account:ann", yarf.BruteForceRecord{Failures: 1}, time.Millisecond)
+	store.Set("account:bob", yarf.BruteForceRecord{Failures: 1}, 0)
+	clock.Advance(time.Second)
+	if r, _ := store.Get("account:ann"); r.Failures != 0 {
+		t.Errorf("Expected expired record, got %+v", r)
+	}
+	if n, err := store.Cleanup(ctx); n != 1 || err != nil {
+		t.Errorf("Expected a record cleaned up, got %d %v", n, err)
+	}
+	if r, _ := store.Get("account:bob"); r.Failures != 1 || !r.Until.IsZero() {
+		t.Errorf("Records without ttl shouldn't expire, got %+v", r)
+	}
+
+	store.Del("account:bob")
+	if r, _ := store.Get("account:bob"); r.Failures != 0 {
+		t.Errorf("Expected deleted record, got %+v", r)
+	}
+
+	for _, q := range f.queries {
+		if strings.Contains(q, "?") {
+			t.Errorf("Expected Postgres placeholders, got %q", q)
+		}
+	}
+}
+
+func TestTenantStore(t *testing.T) {
+	db, _ := openFake(t)
+	store := NewTenantStore(db, MySQL)
+	store.Table = "tenants"
+	ctx := context.Background()
+
+	var _ yarf.TenantStore = store
+
+	if !strings.HasPrefix(store.Schema(), "CREATE TABLE IF NOT EXISTS tenants ") {
+		t.Errorf("Unexpected schema %q", store.Schema())
+	}
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Save(ctx, &yarf.Tenant{ID: "acme", RateLimit: 10, Config: map[string]interface{}{"plan": "pro"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tenant, err := store.Tenant(ctx, "acme")
+	if err != nil || tenant.ID != "acme" || tenant.RateLimit != 10 || tenant.String("plan") != "pro" {
+		t.Errorf("Unexpected tenant %+v %v", tenant, err)
+	}
+	if tenant, err := store.Tenant(ctx, "missing"); tenant != nil || err != nil {
+		t.Errorf("Expected missing tenant, got %+v %v", tenant, err)
+	}
+}
+
+func TestSessionStore(t *testing.T) {
+	db, _ := openFake(t)
+	store := NewSessionStore(db, SQLite)
+	clock := yarftest.NewClock(time.Now())
+	store.Clock = clock
+	ctx := context.Background()
+
+	var _ yarf.SessionStore = store
+
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if values, err := store.Load(ctx, "missing"); values != nil || err != nil {
+		t.Errorf("Expected missing session, got %v %v", values, err)
+	}
+
+	store.Save(ctx, "a", map[string]string{"user": "ann"}, time.Minute)
+	if err := store.Save(ctx, "a", map[string]string{"user": "bob"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if values, err := store.Load(ctx, "a"); err != nil || values["user"] != "bob" {
+		t.Errorf("Unexpected session %v %v", values, err)
+	}
+
+	store.Save(ctx, "b", map[string]string{"user": "ann"}, time.Hour)
+	clock.Advance(2 * time.Minute)
+	if values, _ := store.Load(ctx, "a"); values != nil {
+		t.Errorf("Expected expired session, got %v", values)
+	}
+	if n, err := store.Cleanup(ctx); n != 1 || err != nil {
+		t.Errorf("Expected a session cleaned up, got %d %v", n, err)
+	}
+
+	store.Delete(ctx, "b")
+	if values, _ := store.Load(ctx, "b"); values != nil {
+		t.Errorf("Expected deleted session, got %v", values)
+	}
+}
+
+func TestIdempotencyStore(t *testing.T) {
+	db, _ := openFake(t)
+	store := NewIdempotencyStore(db, Postgres)
+	clock := yarftest.NewClock(time.Now())
+	store.Clock = clock
+	ctx := context.Background()
+
+	var _ yarf.IdempotencyStore = store
+
+	if _, err := store.Reserve(ctx, "k", "fp", time.Minute); err == nil {
+		t.Error("Expected error before the migration")
+	}
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if r, err := store.Reserve(ctx, "k", "fp", time.Minute); r != nil || err != nil {
+		t.Errorf("Expected reserved key, got %+v %v", r, err)
+	}
+	if r, err := store.Reserve(ctx, "k", "other", time.Minute); err != nil || r == nil || r.Fingerprint != "fp" || r.Done {
+		t.Errorf("Expected running record, got %+v %v", r, err)
+	}
+
+	rec := &yarf.IdempotencyRecord{Fingerprint: "fp", Done: true, Status: 201, Header: http.Header{"X-Id": {"1"}}, Body: []byte("ok")}
+	if err := store.Complete(ctx, "k", rec, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Minute)
+	r, err := store.Reserve(ctx, "k", "fp", time.Minute)
+	if err != nil || r == nil || !r.Done || r.Status != 201 || r.Header.Get("X-Id") != "1" || string(r.Body) != "ok" {
+		t.Errorf("Expected stored response, got %+v %v", r, err)
+	}
+
+	store.Release(ctx, "k")
+	if r, _ := store.Reserve(ctx, "k", "fp", time.Minute); r != nil {
+		t.Errorf("Expected released key, got %+v", r)
+	}
+
+	// Expired keys are reserved again
+	clock.Advance(2 * time.Minute)
+	if r, err := store.Reserve(ctx, "k", "fp", time.Minute); r != nil || err != nil {
+		t.Errorf("Expected expired key, got %+v %v", r, err)
+	}
+
+	store.Reserve(ctx, "old", "fp", time.Second)
+	clock.Advance(2 * time.Second)
+	if n, err := store.Cleanup(ctx); n != 1 || err != nil {
+		t.Errorf("Expected a key cleaned up, got %d %v", n, err)
+	}
+}
@@ -0,0 +1,103 @@
+package yarf
+
+import (
+	"encoding/json"
+	"expvar"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// PoolStats are the counters of the Context pool.
+type PoolStats struct {
+	Gets uint64 `json:"gets"` // Contexts taken from the pool
+	News uint64 `json:"news"` // Contexts allocated because the pool was empty
+}
+
+// GCStats summarizes the garbage collector pauses, in milliseconds.
+type GCStats struct {
+	NumGC      int64     `json:"num_gc"`
+	PauseTotal float64   `json:"pause_total_ms"`
+	LastPause  float64   `json:"last_pause_ms"`
+	LastGC     time.Time `json:"last_gc"`
+}
+
+// RuntimeStats are the framework internals and runtime state of a Yarf instance.
+type RuntimeStats struct {
+	Uptime     float64   `json:"uptime_s"`
+	Routes     int       `json:"routes"`
+	Requests   uint64    `json:"requests"` // Requests served since the start
+	Pool       PoolStats `json:"pool"`
+	Conns      ConnStats `json:"conns"`
+	Goroutines int       `json:"goroutines"`
+	GC         GCStats   `json:"gc"`
+}
+
+// runtimeCounters are the request counters behind the RuntimeStats.
+type runtimeCounters struct {
+	started  time.Time
+	requests uint64
+	poolGets uint64
+	poolNews uint64
+}
+
+// RuntimeStats returns the current framework internals and runtime state.
+func (y *Yarf) RuntimeStats() RuntimeStats {
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+
+	s := RuntimeStats{
+		Uptime:   time.Since(y.counters.started).Seconds(),
+		Routes:   len(y.Routes()),
+		Requests: atomic.LoadUint64(&y.counters.requests),
+		Pool: PoolStats{
+			Gets: atomic.LoadUint64(&y.counters.poolGets),
+			News: atomic.LoadUint64(&y.counters.poolNews),
+		},
+		Conns:      y.ConnStats(),
+		Goroutines: runtime.NumGoroutine(),
+		GC: GCStats{
+			NumGC:      gc.NumGC,
+			PauseTotal: float64(gc.PauseTotal) / float64(time.Millisecond),
+			LastGC:     gc.LastGC,
+		},
+	}
+	if len(gc.Pause) > 0 {
+		s.GC.LastPause = float64(gc.Pause[0]) / float64(time.Millisecond)
+	}
+
+	return s
+}
+
+// PublishStats publishes the RuntimeStats as an expvar variable under name,
+// served by the /vars debug endpoint with the memory stats.
+// As with expvar.Publish, it panics if the name is already in use.
+//
+//	y.PublishStats("yarf")
+func (y *Yarf) PublishStats(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return y.RuntimeStats()
+	}))
+}
+
+// statsResource serves the RuntimeStats as JSON.
+type statsResource struct {
+	Resource
+
+	app *Yarf
+}
+
+// Get renders the stats.
+func (r *statsResource) Get(c *Context) error {
+	encoded, err := json.Marshal(r.app.RuntimeStats())
+	if err != nil {
+		return err
+	}
+
+	c.Response.Header().Set("Cache-Control", "no-store")
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.Response.Write(encoded)
+
+	return nil
+}
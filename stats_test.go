@@ -0,0 +1,61 @@
+package yarf
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+func TestRuntimeStats(t *testing.T) {
+	y := New()
+	y.Add("/a", new(OKResource))
+	y.Add("/b", new(OKResource))
+
+	for i := 0; i < 3; i++ {
+		y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/a", nil))
+	}
+	runtime.GC()
+
+	s := y.RuntimeStats()
+	if s.Routes != 2 || s.Requests != 3 || s.Goroutines == 0 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+	if s.Pool.Gets != 3 || s.Pool.News == 0 || s.Pool.News > 3 {
+		t.Errorf("Unexpected pool stats %+v", s.Pool)
+	}
+	if s.GC.NumGC == 0 || s.GC.LastGC.IsZero() {
+		t.Errorf("Unexpected GC stats %+v", s.GC)
+	}
+}
+
+func TestPublishStats(t *testing.T) {
+	y := New()
+	y.Add("/a", new(OKResource))
+	y.PublishStats("yarf_test_stats")
+	y.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/a", nil))
+
+	var s RuntimeStats
+	if err := json.Unmarshal([]byte(expvar.Get("yarf_test_stats").String()), &s); err != nil || s.Requests != 1 {
+		t.Errorf("Unexpected published stats %+v: %v", s, err)
+	}
+}
+
+func TestStatsEndpoint(t *testing.T) {
+	y := New()
+	y.Add("/a", new(OKResource))
+	y.EnableDebug("/_debug")
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/_debug/stats", nil))
+
+	var s RuntimeStats
+	if err := json.Unmarshal(res.Body.Bytes(), &s); err != nil {
+		t.Fatalf("Expected JSON stats, got %q: %s", res.Body.String(), err)
+	}
+	// The debug routes are counted, and the stats request itself is being served
+	if s.Routes < 2 || s.Requests != 1 || s.Conns.InFlight != 1 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+}
@@ -0,0 +1,151 @@
+// Package statsd implements yarf.Metrics, sending the metrics to StatsD or DogStatsD agents over UDP.
+//
+//	c, err := statsd.New("127.0.0.1:8125")
+//	c.Prefix = "orders."
+//	c.Tags = true // DogStatsD
+//	y.Metrics = c
+package statsd
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Client sends each measurement as a StatsD line.
+// Counters are sent as "c" metrics, gauges as "g" metrics,
+// and histograms as "h" metrics with tags, or as "ms" timers for plain StatsD.
+type Client struct {
+	// Prefix is added to the metric names.
+	Prefix string
+
+	// Tags sends the labels as DogStatsD tags, like "#route:/items/:id".
+	// Otherwise label values are appended to the metric name, separated by dots.
+	Tags bool
+
+	w    io.Writer
+	lock sync.Mutex
+}
+
+// New creates a Client sending the metrics to the agent listening on the UDP address provided.
+func New(addr string) (*Client, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWriter(conn), nil
+}
+
+// NewWriter creates a Client writing the metrics into w, one line per write.
+func NewWriter(w io.Writer) *Client {
+	return &Client{w: w}
+}
+
+// Close closes the connection to the agent, if any.
+func (c *Client) Close() error {
+	if cl, ok := c.w.(io.Closer); ok {
+		return cl.Close()
+	}
+
+	return nil
+}
+
+// Counter returns the counter named name.
+func (c *Client) Counter(name, help string, labels ...string) yarf.Counter {
+	return &instrument{c, name, labels, "c"}
+}
+
+// Histogram returns the histogram named name.
+func (c *Client) Histogram(name, help string, labels ...string) yarf.Histogram {
+	return &instrument{c, name, labels, "h"}
+}
+
+// Gauge returns the gauge named name.
+func (c *Client) Gauge(name, help string, labels ...string) yarf.Gauge {
+	return &instrument{c, name, labels, "g"}
+}
+
+// send writes a measurement line. Errors are ignored, as UDP metrics are sent on a best effort basis.
+func (c *Client) send(name, value, kind string, labels, values []string) {
+	var b strings.Builder
+	b.WriteString(c.Prefix)
+	b.WriteString(name)
+
+	if !c.Tags {
+		if kind == "h" {
+			kind = "ms"
+		}
+		for _, v := range values {
+			b.WriteString(".")
+			b.WriteString(sanitize(v))
+		}
+	}
+
+	b.WriteString(":" + value + "|" + kind)
+
+	if c.Tags && len(labels) > 0 {
+		for i, l := range labels {
+			v := ""
+			if i < len(values) {
+				v = values[i]
+			}
+			if i == 0 {
+				b.WriteString("|#")
+			} else {
+				b.WriteString(",")
+			}
+			b.WriteString(l + ":" + strings.NewReplacer(",", "_", "|", "_").Replace(v))
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	io.WriteString(c.w, b.String()+"\n")
+}
+
+// sanitize replaces the characters reserved by the StatsD line format in label values appended to names.
+var sanitize = strings.NewReplacer(".", "_", ":", "_", "|", "_", "@", "_", "#", "_", " ", "_", "/", "_").Replace
+
+// instrument implements the counters, gauges and histograms.
+type instrument struct {
+	c      *Client
+	name   string
+	labels []string
+	kind   string
+}
+
+// Add sends a counter increment or a gauge delta.
+func (i *instrument) Add(delta float64, labelValues ...string) {
+	value := formatFloat(delta)
+	if i.kind == "g" && delta >= 0 {
+		value = "+" + value
+	}
+
+	i.c.send(i.name, value, i.kind, i.labels, labelValues)
+}
+
+// Set sends a gauge value. Negative values are sent as a reset to 0 followed by the delta,
+// as the StatsD format reads them as decrements.
+func (i *instrument) Set(v float64, labelValues ...string) {
+	if v < 0 {
+		i.c.send(i.name, "0", i.kind, i.labels, labelValues)
+	}
+
+	i.c.send(i.name, formatFloat(v), i.kind, i.labels, labelValues)
+}
+
+// Observe sends a histogram value.
+func (i *instrument) Observe(v float64, labelValues ...string) {
+	i.c.send(i.name, formatFloat(v), i.kind, i.labels, labelValues)
+}
+
+// formatFloat formats values without exponents.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,71 @@
+package statsd
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewWriter(&buf)
+	c.Prefix = "app."
+
+	c.Counter("requests_total", "", "method", "route").Add(1, "GET", "/items/:id")
+	c.Histogram("request_seconds", "", "route").Observe(0.25, "/")
+	c.Gauge("in_flight", "").Add(1)
+	c.Gauge("in_flight", "").Add(-1)
+	c.Gauge("temperature", "").Set(-3)
+
+	expected := `app.requests_total.GET._items__id:1|c
+app.request_seconds._:0.25|ms
+app.in_flight:+1|g
+app.in_flight:-1|g
+app.temperature:0|g
+app.temperature:-3|g
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected StatsD lines:\n%s", buf.String())
+	}
+
+	// DogStatsD tags
+	buf.Reset()
+	c.Tags = true
+	c.Counter("requests_total", "", "method", "route").Add(2, "GET", "/items/:id")
+	c.Histogram("request_seconds", "").Observe(1.5)
+
+	expected = `app.requests_total:2|c|#method:GET,route:/items/:id
+app.request_seconds:1.5|h
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected DogStatsD lines:\n%s", buf.String())
+	}
+}
+
+func TestUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	c, err := New(pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.Counter("hits", "").Add(1)
+
+	pc.SetReadDeadline(time.Now().Add(time.Second))
+	b := make([]byte, 512)
+	n, _, err := pc.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := strings.TrimSpace(string(b[:n])); line != "hits:1|c" {
+		t.Errorf("Unexpected packet %q", line)
+	}
+}
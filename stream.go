@@ -0,0 +1,129 @@
+package yarf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// StreamFlushInterval is the longest time the records rendered by the stream methods wait in the buffers
+// before being flushed to the client.
+var StreamFlushInterval = 100 * time.Millisecond
+
+// ErrStreamChannel is returned by the stream render methods when they don't receive a channel.
+var ErrStreamChannel = errors.New("yarf: stream needs a receive channel")
+
+// RenderJSONStream writes the values received from ch, a channel of any type, as a JSON array,
+// encoding them as they arrive so large result sets are never held in memory.
+// The response is flushed periodically, and the array closed once the channel is.
+// It returns early when the client goes away, so the producer should stop sending on c.Disconnected() too.
+// When partial responses are enabled, each value is pruned to the fields requested by the client.
+//
+//	rows := make(chan User)
+//	go func() {
+//		defer close(rows)
+//		for users.Next() {
+//			select {
+//			case rows <- users.Value():
+//			case <-c.Disconnected():
+//				return
+//			}
+//		}
+//	}()
+//	return c.RenderJSONStream(rows)
+func (c *Context) RenderJSONStream(ch interface{}) error {
+	if c.Response.Header().Get("Content-Type") == "" {
+		c.Response.Header().Set("Content-Type", "application/json")
+	}
+
+	return c.renderStream(ch, false)
+}
+
+// RenderJSONLinesStream writes the values received from ch, a channel of any type, as newline delimited JSON
+// (application/x-ndjson), one value per line. It works like RenderJSONStream.
+func (c *Context) RenderJSONLinesStream(ch interface{}) error {
+	if c.Response.Header().Get("Content-Type") == "" {
+		c.Response.Header().Set("Content-Type", "application/x-ndjson")
+	}
+
+	return c.renderStream(ch, true)
+}
+
+// flush flushes the response if supported.
+func (c *Context) flush() {
+	if f, ok := c.Response.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// renderStream writes the JSON encoded values of ch as an array, or one per line.
+func (c *Context) renderStream(ch interface{}, lines bool) error {
+	v := reflect.ValueOf(ch)
+	if v.Kind() != reflect.Chan || v.Type().ChanDir()&reflect.RecvDir == 0 {
+		return ErrStreamChannel
+	}
+
+	ticker := time.NewTicker(StreamFlushInterval)
+	defer ticker.Stop()
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: v},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c.Disconnected())},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ticker.C)},
+	}
+
+	if !lines {
+		if _, err := c.Response.Write([]byte("[")); err != nil {
+			return err
+		}
+	}
+
+	mask := c.Fields()
+	first := true
+	pending := false
+	for {
+		chosen, value, ok := reflect.Select(cases)
+		switch chosen {
+		case 1:
+			return context.Canceled
+		case 2:
+			if pending {
+				c.flush()
+				pending = false
+			}
+			continue
+		}
+		if !ok {
+			break
+		}
+
+		encoded, err := json.Marshal(value.Interface())
+		if err != nil {
+			return err
+		}
+		encoded = mask.PruneJSON(encoded)
+		if lines {
+			encoded = append(encoded, '\n')
+		} else if !first {
+			encoded = append([]byte(","), encoded...)
+		}
+		first = false
+
+		if _, err := c.Response.Write(encoded); err != nil {
+			return err
+		}
+		pending = true
+	}
+
+	if !lines {
+		if _, err := c.Response.Write([]byte("]")); err != nil {
+			return err
+		}
+	}
+	c.flush()
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package yarf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type streamItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type StreamResource struct {
+	Resource
+	lines bool
+}
+
+func (r *StreamResource) Get(c *Context) error {
+	ch := make(chan streamItem)
+	go func() {
+		defer close(ch)
+		for i := 1; i <= 3; i++ {
+			ch <- streamItem{i, "item"}
+		}
+	}()
+
+	if r.lines {
+		return c.RenderJSONLinesStream(ch)
+	}
+	return c.RenderJSONStream(ch)
+}
+
+func TestRenderJSONStream(t *testing.T) {
+	y := New()
+	y.Add("/items", new(StreamResource))
+	y.Add("/lines", &StreamResource{lines: true})
+	y.Use(NewPartialResponse())
+
+	for url, expected := range map[string]string{
+		"/items":           `[{"id":1,"name":"item"},{"id":2,"name":"item"},{"id":3,"name":"item"}]`,
+		"/items?fields=id": `[{"id":1},{"id":2},{"id":3}]`,
+		"/lines":           "{\"id\":1,\"name\":\"item\"}\n{\"id\":2,\"name\":\"item\"}\n{\"id\":3,\"name\":\"item\"}\n",
+	} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+url, nil)
+		y.ServeHTTP(res, req)
+
+		if res.Body.String() != expected {
+			t.Errorf("%s: expected %s, got %s", url, expected, res.Body.String())
+		}
+		if !res.Flushed {
+			t.Errorf("%s: expected flushed response", url)
+		}
+	}
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://localhost:8080/lines", nil)
+	y.ServeHTTP(res, req)
+	if ct := res.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Unexpected Content-Type %q", ct)
+	}
+}
+
+func TestRenderJSONStreamEmpty(t *testing.T) {
+	res := httptest.NewRecorder()
+	c := NewContext(httptest.NewRequest("GET", "/", nil), res)
+
+	ch := make(chan int)
+	close(ch)
+	if err := c.RenderJSONStream(ch); err != nil || res.Body.String() != "[]" {
+		t.Errorf("Expected empty array, got %q %v", res.Body.String(), err)
+	}
+	if ct := res.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Unexpected Content-Type %q", ct)
+	}
+}
+
+func TestRenderJSONStreamChannel(t *testing.T) {
+	c := NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+
+	if err := c.RenderJSONStream([]int{1, 2}); err != ErrStreamChannel {
+		t.Errorf("Expected ErrStreamChannel for slices, got %v", err)
+	}
+	if err := c.RenderJSONStream(make(chan<- int)); err != ErrStreamChannel {
+		t.Errorf("Expected ErrStreamChannel for send channels, got %v", err)
+	}
+}
+
+func TestRenderJSONStreamFlush(t *testing.T) {
+	interval := StreamFlushInterval
+	StreamFlushInterval = time.Millisecond
+	defer func() { StreamFlushInterval = interval }()
+
+	res := &flushRecorder{httptest.NewRecorder(), 0}
+	c := NewContext(httptest.NewRequest("GET", "/", nil), res)
+
+	ch := make(chan int)
+	go func() {
+		ch <- 1
+		time.Sleep(20 * time.Millisecond)
+		if atomic.LoadInt32(&res.flushes) == 0 {
+			t.Error("Pending values should be flushed while waiting")
+		}
+		close(ch)
+	}()
+
+	c.RenderJSONStream(ch)
+}
+
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushes int32
+}
+
+func (r *flushRecorder) Flush() {
+	atomic.AddInt32(&r.flushes, 1)
+}
+
+func TestRenderJSONStreamDisconnected(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	c := NewContext(req, httptest.NewRecorder())
+
+	ch := make(chan int)
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	err := c.RenderJSONStream(ch)
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if _, ok := clientError(c, err).(*ClientClosedRequestError); !ok {
+		t.Error("Expected the error to be a client disconnect")
+	}
+}
@@ -0,0 +1,294 @@
+package yarf
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tenant is the customer organization a request belongs to.
+type Tenant struct {
+	// ID identifies the tenant.
+	ID string
+
+	// Config holds per-tenant settings, like plan limits or branding.
+	Config map[string]interface{}
+
+	// RateLimit is the number of requests per second allowed for the tenant. 0 means no limit.
+	RateLimit float64
+
+	// Burst is the number of requests allowed above the rate. Defaults to the rate rounded up.
+	Burst int
+
+	// Quotas are the request quotas of the tenant, applied by the QuotaMiddleware.
+	Quotas []Quota
+}
+
+// Get returns a config value of the tenant. It's safe to use on a nil *Tenant.
+func (t *Tenant) Get(key string) interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return t.Config[key]
+}
+
+// String returns a config value of the tenant as a string.
+func (t *Tenant) String(key string) string {
+	s, _ := t.Get(key).(string)
+	return s
+}
+
+// TenantStore looks up tenants by ID.
+type TenantStore interface {
+	// Tenant returns the tenant with the ID, or nil if it doesn't exist.
+	Tenant(ctx context.Context, id string) (*Tenant, error)
+}
+
+// StaticTenants is a TenantStore with fixed tenants by ID.
+type StaticTenants map[string]*Tenant
+
+// Tenant returns the tenant with the ID.
+func (s StaticTenants) Tenant(ctx context.Context, id string) (*Tenant, error) {
+	t := s[id]
+	if t != nil && t.ID == "" {
+		t.ID = id
+	}
+
+	return t, nil
+}
+
+// TenantResolver extracts the tenant ID from a request. It returns an empty string if it can't.
+type TenantResolver func(*Context) string
+
+// TenantFromSubdomain resolves the tenant from the first label of the host under the domain,
+// like "acme" for "acme.example.com" with domain "example.com".
+func TenantFromSubdomain(domain string) TenantResolver {
+	suffix := "." + strings.TrimPrefix(strings.ToLower(domain), ".")
+
+	return func(c *Context) string {
+		host := strings.ToLower(c.Host())
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+
+		sub := strings.TrimSuffix(host, suffix)
+		if i := strings.LastIndexByte(sub, '.'); i >= 0 {
+			sub = sub[i+1:]
+		}
+
+		return sub
+	}
+}
+
+// TenantFromHeader resolves the tenant from a request header, like "X-Tenant-ID".
+func TenantFromHeader(name string) TenantResolver {
+	return func(c *Context) string {
+		return strings.TrimSpace(c.Request.Header.Get(name))
+	}
+}
+
+// TenantFromParam resolves the tenant from a route param, like ":tenant" in "/t/:tenant/users".
+// Params are only available to group middleware, as global middleware runs before route matching.
+func TenantFromParam(name string) TenantResolver {
+	return func(c *Context) string {
+		return c.Param(name)
+	}
+}
+
+// TenantFromClaim resolves the tenant from a claim of the authentication token.
+// claims returns the claims of the token validated for the request, by the authentication middleware.
+func TenantFromClaim(claims func(*Context) map[string]interface{}, name string) TenantResolver {
+	return func(c *Context) string {
+		s, _ := claims(c)[name].(string)
+		return s
+	}
+}
+
+// tenantKey is the Context storage key for the request tenant.
+type tenantKey struct{}
+
+// Tenant returns the tenant resolved for the request by the TenantMiddleware, or nil.
+func (c *Context) Tenant() *Tenant {
+	t, _ := c.get(tenantKey{}).(*Tenant)
+	return t
+}
+
+// TenantID returns the ID of the tenant resolved for the request, or an empty string.
+// Use it as label to split logs and metrics by tenant.
+func (c *Context) TenantID() string {
+	if t := c.Tenant(); t != nil {
+		return t.ID
+	}
+
+	return ""
+}
+
+// RateLimitStore keeps the token buckets of the rate limits.
+type RateLimitStore interface {
+	// Take takes a token from the bucket of the key, refilled with rate tokens per second up to burst.
+	// It returns the time to wait for a token if there isn't any.
+	Take(key string, rate float64, burst int) (time.Duration, error)
+}
+
+// tokenBucket is a rate limiter allowing bursts.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryRateLimitStore is an in-memory RateLimitStore implementation, for single instance deployments.
+type MemoryRateLimitStore struct {
+	// Clock tells the time to refill the buckets. nil uses the system clock.
+	Clock Clock
+
+	buckets map[string]*tokenBucket
+	lock    sync.Mutex
+}
+
+// NewMemoryRateLimitStore creates a new MemoryRateLimitStore with full buckets.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Take takes a token from the bucket of the key.
+func (s *MemoryRateLimitStore) Take(key string, rate float64, burst int) (time.Duration, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := clockNow(s.Clock)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / rate * float64(time.Second)), nil
+	}
+	b.tokens--
+
+	return 0, nil
+}
+
+// TenantMiddleware resolves the tenant of each request, exposed by Context.Tenant(),
+// and applies the tenant rate limits.
+// Resolvers are tried in order until one returns an ID. With a Store, tenants are loaded from it
+// and unknown tenants get a 404 error. Requests over the tenant rate limit get a 429 error.
+type TenantMiddleware struct {
+	Middleware
+
+	// Resolvers extract the tenant ID, tried in order.
+	Resolvers []TenantResolver
+
+	// Store loads the tenants. If nil, any resolved ID is accepted, without config.
+	Store TenantStore
+
+	// Required rejects requests without tenant with a 400 error.
+	Required bool
+
+	// RateLimits keeps the rate limit buckets of the tenants. Use a shared store with several instances.
+	// If nil, buckets are kept in memory.
+	RateLimits RateLimitStore
+
+	// Clock tells the time to refill the in-memory rate limit buckets. nil uses the system clock.
+	Clock Clock
+
+	buckets *MemoryRateLimitStore
+	lock    sync.Mutex
+}
+
+// NewTenantMiddleware creates a TenantMiddleware with the resolvers, requiring a tenant on every request.
+//
+//	y.Use(yarf.NewTenantMiddleware(yarf.TenantFromSubdomain("example.com"), yarf.TenantFromHeader("X-Tenant-ID")))
+func NewTenantMiddleware(resolvers ...TenantResolver) *TenantMiddleware {
+	return &TenantMiddleware{
+		Resolvers: resolvers,
+		Required:  true,
+	}
+}
+
+// Phase puts the tenant middleware with the security middleware.
+func (m *TenantMiddleware) Phase() Phase {
+	return PhaseSecurity
+}
+
+// PreDispatch resolves the tenant and checks its rate limit.
+func (m *TenantMiddleware) PreDispatch(c *Context) error {
+	id := ""
+	for _, r := range m.Resolvers {
+		if id = r(c); id != "" {
+			break
+		}
+	}
+
+	if id == "" {
+		if m.Required {
+			e := ErrorBadRequest()
+			e.ErrorMsg = "Tenant required"
+			return e
+		}
+		return nil
+	}
+
+	t := &Tenant{ID: id}
+	if m.Store != nil {
+		var err error
+		if t, err = m.Store.Tenant(c.Request.Context(), id); err != nil {
+			return err
+		}
+		if t == nil {
+			return ErrorNotFound()
+		}
+	}
+
+	wait, err := m.allow(t)
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		c.Response.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		return ErrorTooManyRequests()
+	}
+
+	c.set(tenantKey{}, t)
+
+	return nil
+}
+
+// allow takes a token from the tenant bucket. It returns the time to wait for a token if there isn't any.
+func (m *TenantMiddleware) allow(t *Tenant) (time.Duration, error) {
+	if t.RateLimit <= 0 {
+		return 0, nil
+	}
+
+	burst := t.Burst
+	if burst <= 0 {
+		burst = int(math.Ceil(t.RateLimit))
+	}
+
+	store := m.RateLimits
+	if store == nil {
+		m.lock.Lock()
+		if m.buckets == nil {
+			m.buckets = NewMemoryRateLimitStore()
+			m.buckets.Clock = m.Clock
+		}
+		store = m.buckets
+		m.lock.Unlock()
+	}
+
+	return store.Take("tenant:"+t.ID, t.RateLimit, burst)
+}
@@ -0,0 +1,210 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TenantResource renders the tenant of the request.
+type TenantResource struct {
+	Resource
+}
+
+func (r *TenantResource) Get(c *Context) error {
+	c.Render(c.TenantID() + ":" + c.Tenant().String("plan"))
+	return nil
+}
+
+func TestTenantResolvers(t *testing.T) {
+	y := New()
+	y.Use(NewTenantMiddleware(TenantFromSubdomain("example.com"), TenantFromHeader("X-Tenant-ID")))
+	y.Add("/", new(TenantResource))
+
+	tests := []struct {
+		host     string
+		header   map[string]string
+		status   int
+		expected string
+	}{
+		{"acme.example.com", nil, 200, "acme:"},
+		{"ACME.example.com:8080", nil, 200, "acme:"},
+		{"api.acme.example.com", nil, 200, "acme:"},
+		{"example.com", map[string]string{"X-Tenant-ID": "globex"}, 200, "globex:"},
+		{"acme.example.com", map[string]string{"X-Tenant-ID": "globex"}, 200, "acme:"},
+		{"example.com", nil, 400, ""},
+		{"acme.other.com", nil, 400, ""},
+	}
+
+	for _, tt := range tests {
+		res := testRequest(y, "GET", "http://"+tt.host+"/", tt.header)
+		if res.Code != tt.status {
+			t.Errorf("Expected status %d for %s, got %d", tt.status, tt.host, res.Code)
+		}
+		if tt.status == 200 && res.Body.String() != tt.expected {
+			t.Errorf("Expected %q for %s, got %q", tt.expected, tt.host, res.Body.String())
+		}
+	}
+}
+
+func TestTenantFromParam(t *testing.T) {
+	g := RouteGroup("/t/:tenant")
+	g.Insert(NewTenantMiddleware(TenantFromParam("tenant")))
+	g.Add("/home", new(TenantResource))
+
+	y := New()
+	y.AddGroup(g)
+
+	if res := testRequest(y, "GET", "http://localhost/t/acme/home", nil); res.Body.String() != "acme:" {
+		t.Errorf("Expected tenant from param, got %q", res.Body.String())
+	}
+}
+
+func TestTenantFromClaim(t *testing.T) {
+	claims := func(c *Context) map[string]interface{} {
+		if c.Request.Header.Get("Authorization") == "" {
+			return nil
+		}
+		return map[string]interface{}{"org": "initech"}
+	}
+
+	m := NewTenantMiddleware(TenantFromClaim(claims, "org"))
+	m.Required = false
+
+	y := New()
+	y.Use(m)
+	y.Add("/", new(TenantResource))
+
+	if res := testRequest(y, "GET", "http://localhost/", map[string]string{"Authorization": "Bearer x"}); res.Body.String() != "initech:" {
+		t.Errorf("Expected tenant from claim, got %q", res.Body.String())
+	}
+	if res := testRequest(y, "GET", "http://localhost/", nil); res.Code != 200 || res.Body.String() != ":" {
+		t.Errorf("Tenant shouldn't be required, got %d %q", res.Code, res.Body.String())
+	}
+}
+
+func TestTenantStore(t *testing.T) {
+	m := NewTenantMiddleware(TenantFromHeader("X-Tenant-ID"))
+	m.Store = StaticTenants{
+		"acme": {Config: map[string]interface{}{"plan": "pro"}},
+	}
+
+	y := New()
+	y.Use(m)
+	y.Add("/", new(TenantResource))
+
+	if res := testRequest(y, "GET", "http://localhost/", map[string]string{"X-Tenant-ID": "acme"}); res.Body.String() != "acme:pro" {
+		t.Errorf("Expected tenant config, got %q", res.Body.String())
+	}
+	if res := testRequest(y, "GET", "http://localhost/", map[string]string{"X-Tenant-ID": "unknown"}); res.Code != 404 {
+		t.Errorf("Expected status 404 for unknown tenant, got %d", res.Code)
+	}
+
+	m.Store = tenantStoreFunc(func(ctx context.Context, id string) (*Tenant, error) {
+		return nil, errors.New("database is down")
+	})
+	if res := testRequest(y, "GET", "http://localhost/", map[string]string{"X-Tenant-ID": "acme"}); res.Code != 500 {
+		t.Errorf("Expected status 500 on store errors, got %d", res.Code)
+	}
+}
+
+type tenantStoreFunc func(ctx context.Context, id string) (*Tenant, error)
+
+func (f tenantStoreFunc) Tenant(ctx context.Context, id string) (*Tenant, error) {
+	return f(ctx, id)
+}
+
+func TestTenantRateLimit(t *testing.T) {
+	m := NewTenantMiddleware(TenantFromHeader("X-Tenant-ID"))
+	m.Store = StaticTenants{
+		"small": {RateLimit: 0.5, Burst: 2},
+		"free":  {},
+	}
+	clock := newTestClock()
+	m.Clock = clock
+
+	y := New()
+	y.Use(m)
+	y.Add("/", new(TenantResource))
+
+	small := map[string]string{"X-Tenant-ID": "small"}
+	for i := 0; i < 2; i++ {
+		if res := testRequest(y, "GET", "http://localhost/", small); res.Code != 200 {
+			t.Errorf("Request %d within burst should pass, got %d", i, res.Code)
+		}
+	}
+
+	res := testRequest(y, "GET", "http://localhost/", small)
+	if res.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 over the limit, got %d", res.Code)
+	}
+	if res.Header().Get("Retry-After") != "2" {
+		t.Errorf("Expected Retry-After header of 2 seconds, got '%s'", res.Header().Get("Retry-After"))
+	}
+
+	// The bucket refills over time
+	clock.Advance(2 * time.Second)
+	if res := testRequest(y, "GET", "http://localhost/", small); res.Code != 200 {
+		t.Errorf("Expected a token after 2 seconds, got %d", res.Code)
+	}
+	if res := testRequest(y, "GET", "http://localhost/", small); res.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 with the bucket empty again, got %d", res.Code)
+	}
+
+	for i := 0; i < 10; i++ {
+		if res := testRequest(y, "GET", "http://localhost/", map[string]string{"X-Tenant-ID": "free"}); res.Code != 200 {
+			t.Fatalf("Tenants without limit shouldn't be limited, got %d", res.Code)
+		}
+	}
+}
+
+type rateLimitStoreFunc func(key string, rate float64, burst int) (time.Duration, error)
+
+func (f rateLimitStoreFunc) Take(key string, rate float64, burst int) (time.Duration, error) {
+	return f(key, rate, burst)
+}
+
+func TestTenantRateLimitStore(t *testing.T) {
+	var taken []string
+	m := NewTenantMiddleware(TenantFromHeader("X-Tenant-ID"))
+	m.Store = StaticTenants{"acme": {RateLimit: 2.5}}
+	m.RateLimits = rateLimitStoreFunc(func(key string, rate float64, burst int) (time.Duration, error) {
+		taken = append(taken, fmt.Sprintf("%s %v %d", key, rate, burst))
+		if len(taken) > 1 {
+			return 0, errors.New("store down")
+		}
+		return 0, nil
+	})
+
+	y := New()
+	y.Use(m)
+	y.Add("/", new(TenantResource))
+
+	acme := map[string]string{"X-Tenant-ID": "acme"}
+	if res := testRequest(y, "GET", "http://localhost/", acme); res.Code != 200 {
+		t.Errorf("Expected request within the limit, got %d", res.Code)
+	}
+	if res := testRequest(y, "GET", "http://localhost/", acme); res.Code != 500 {
+		t.Errorf("Expected status 500 on store errors, got %d", res.Code)
+	}
+	if len(taken) != 2 || taken[0] != "tenant:acme 2.5 3" {
+		t.Errorf("Unexpected tokens taken %v", taken)
+	}
+}
+
+func TestTenantFlagTarget(t *testing.T) {
+	y := New()
+	y.Use(NewTenantMiddleware(TenantFromHeader("X-Tenant-ID")))
+	y.EnableFlags(StaticFlags{"beta": {Tenants: []string{"acme"}}})
+	y.Add("/feature/:flag", new(FeatureResource))
+
+	if res := testRequest(y, "GET", "http://localhost/feature/beta", map[string]string{"X-Tenant-ID": "acme"}); res.Body.String() != "true" {
+		t.Errorf("Flags should target the request tenant by default, got %s", res.Body.String())
+	}
+	if res := testRequest(y, "GET", "http://localhost/feature/beta", map[string]string{"X-Tenant-ID": "globex"}); res.Body.String() != "false" {
+		t.Errorf("Expected flag disabled for other tenants, got %s", res.Body.String())
+	}
+}
@@ -0,0 +1,45 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WithTimeout returns a context derived from the request context that expires after d,
+// to bound the calls made by handlers to databases and other services.
+// The request cancellation propagates to the derived context.
+// Handlers can return the context errors as they are: context.DeadlineExceeded is sent as a 504 error
+// and context.Canceled as a 503 error.
+//
+//	ctx, cancel := c.WithTimeout(2 * time.Second)
+//	defer cancel()
+//
+//	row := db.QueryRowContext(ctx, query, id)
+func (c *Context) WithTimeout(d time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.Request.Context(), d)
+}
+
+// contextError maps the context errors returned by handlers to their HTTP errors,
+// keeping the original message. Other errors are returned unchanged.
+func contextError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(YError); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		e := ErrorGatewayTimeout()
+		e.ErrorMsg = err.Error()
+		return e
+	case errors.Is(err, context.Canceled):
+		e := ErrorServiceUnavailable()
+		e.ErrorMsg = err.Error()
+		return e
+	}
+
+	return err
+}
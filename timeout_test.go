@@ -0,0 +1,97 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// SlowResource waits on a context derived with WithTimeout.
+type SlowResource struct {
+	Resource
+}
+
+func (r *SlowResource) Get(c *Context) error {
+	ctx, cancel := c.WithTimeout(10 * time.Millisecond)
+	defer cancel()
+
+	select {
+	case <-time.After(time.Second):
+		c.Render("done")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("query failed: %w", ctx.Err())
+	}
+}
+
+// ErrResource returns the error stored in its field.
+type ErrResource struct {
+	Resource
+	err error
+}
+
+func (r *ErrResource) Get(c *Context) error {
+	return r.err
+}
+
+func TestContextWithTimeout(t *testing.T) {
+	y := New()
+	y.Add("/slow", new(SlowResource))
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/slow", nil))
+
+	if res.Code != 504 {
+		t.Errorf("Expected status 504 on deadline exceeded, got %d", res.Code)
+	}
+}
+
+func TestContextWithTimeoutPropagatesCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewContext(httptest.NewRequest("GET", "/", nil).WithContext(ctx), httptest.NewRecorder())
+
+	derived, stop := c.WithTimeout(time.Minute)
+	defer stop()
+
+	cancel()
+	<-derived.Done()
+
+	if !errors.Is(derived.Err(), context.Canceled) {
+		t.Errorf("Expected canceled context, got %v", derived.Err())
+	}
+	if _, ok := derived.Deadline(); !ok {
+		t.Error("Expected deadline on derived context")
+	}
+}
+
+func TestContextErrorMapping(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{context.DeadlineExceeded, 504},
+		{fmt.Errorf("wrapped: %w", context.DeadlineExceeded), 504},
+		{context.Canceled, 503},
+		{errors.New("other"), 500},
+		{ErrorNotFound(), 404},
+	}
+
+	for _, tt := range tests {
+		y := New()
+		y.Add("/", &ErrResource{err: tt.err})
+
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+
+		if res.Code != tt.status {
+			t.Errorf("Expected status %d for %v, got %d", tt.status, tt.err, res.Code)
+		}
+	}
+
+	if e, ok := contextError(context.Canceled).(YError); !ok || e.Msg() != context.Canceled.Error() {
+		t.Error("Mapped errors should keep the original message")
+	}
+}
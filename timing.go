@@ -0,0 +1,108 @@
+package yarf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timingKey is the Context storage key for Server-Timing segments.
+type timingKey struct{}
+
+// timing is a named Server-Timing segment.
+type timing struct {
+	name string
+	desc string
+	dur  time.Duration
+}
+
+// timings returns the list of segments stored in the Context.
+func (c *Context) timings() *[]timing {
+	t, ok := c.get(timingKey{}).(*[]timing)
+	if !ok {
+		t = new([]timing)
+		c.set(timingKey{}, t)
+	}
+
+	return t
+}
+
+// Timing adds a named segment to the Server-Timing response header.
+// Segments have to be added before the response is written to be sent.
+// The ServerTiming middleware needs to be present to send the header.
+func (c *Context) Timing(name, desc string, d time.Duration) {
+	t := c.timings()
+	*t = append(*t, timing{name, desc, d})
+}
+
+// StartTiming starts measuring a Server-Timing segment and returns the function that ends it.
+//
+//	stop := c.StartTiming("db", "Database query")
+//	rows := query()
+//	stop()
+func (c *Context) StartTiming(name, desc string) func() {
+	start := time.Now()
+
+	return func() {
+		c.Timing(name, desc, time.Since(start))
+	}
+}
+
+// serverTimingKey is the Context storage key for the request start time.
+type serverTimingKey struct{}
+
+// ServerTiming is a middleware that sets the X-Response-Time and Server-Timing headers on every response.
+// The Server-Timing header includes the segments added by handlers through Context.Timing()
+// and a "total" segment with the processing time.
+type ServerTiming struct {
+	Middleware
+}
+
+// Phase puts the timing middleware with the logging middleware.
+func (m *ServerTiming) Phase() Phase {
+	return PhaseLogging
+}
+
+// PreDispatch starts measuring the request and sets the headers right before the response is sent.
+func (m *ServerTiming) PreDispatch(c *Context) error {
+	start := time.Now()
+
+	h := hookResponse(c, func() {
+		m.setHeaders(c, time.Since(start))
+	})
+	c.set(serverTimingKey{}, h)
+
+	return nil
+}
+
+// End sets the headers if the response wasn't written yet.
+func (m *ServerTiming) End(c *Context) error {
+	if h, ok := c.get(serverTimingKey{}).(*responseHook); ok {
+		h.fire()
+	}
+
+	return nil
+}
+
+// setHeaders writes the timing headers.
+func (m *ServerTiming) setHeaders(c *Context, total time.Duration) {
+	c.Response.Header().Set("X-Response-Time", fmt.Sprintf("%.3fms", durationMs(total)))
+
+	var segments []string
+	for _, t := range *c.timings() {
+		s := t.name
+		if t.desc != "" {
+			s += ";desc=" + strconv.Quote(t.desc)
+		}
+		segments = append(segments, s+fmt.Sprintf(";dur=%.3f", durationMs(t.dur)))
+	}
+	segments = append(segments, fmt.Sprintf("total;dur=%.3f", durationMs(total)))
+
+	c.Response.Header().Set("Server-Timing", strings.Join(segments, ", "))
+}
+
+// durationMs returns the duration in milliseconds.
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
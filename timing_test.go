@@ -0,0 +1,52 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type TimedResource struct {
+	Resource
+}
+
+func (r *TimedResource) Get(c *Context) error {
+	c.Timing("db", "Database", 5*time.Millisecond)
+	stop := c.StartTiming("cache", "")
+	stop()
+
+	c.Render("OK")
+
+	return nil
+}
+
+func TestServerTiming(t *testing.T) {
+	y := New()
+	y.Insert(new(ServerTiming))
+	y.Add("/timed", new(TimedResource))
+	y.Add("/empty", new(MockResource))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/timed", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if !strings.HasSuffix(res.Header().Get("X-Response-Time"), "ms") {
+		t.Errorf("Unexpected X-Response-Time header: '%s'", res.Header().Get("X-Response-Time"))
+	}
+
+	st := res.Header().Get("Server-Timing")
+	if !strings.HasPrefix(st, `db;desc="Database";dur=5.000, cache;dur=`) || !strings.Contains(st, ", total;dur=") {
+		t.Errorf("Unexpected Server-Timing header: '%s'", st)
+	}
+
+	// Error responses also get the headers
+	req, _ = http.NewRequest("GET", "http://localhost:8080/empty", nil)
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Header().Get("Server-Timing") == "" {
+		t.Error("Server-Timing header should be set on error responses")
+	}
+}
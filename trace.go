@@ -0,0 +1,225 @@
+package yarf
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// W3C Trace Context and Baggage headers
+const (
+	HeaderTraceParent = "Traceparent"
+	HeaderTraceState  = "Tracestate"
+	HeaderBaggage     = "Baggage"
+)
+
+// TraceSampled is the trace flag telling the callee that the caller may have recorded the trace.
+const TraceSampled byte = 1
+
+// ErrInvalidTraceParent is returned when parsing a malformed traceparent header.
+var ErrInvalidTraceParent = errors.New("yarf: invalid traceparent")
+
+// TraceContext identifies a span of a distributed trace, following the W3C Trace Context specification.
+type TraceContext struct {
+	TraceID  string // 32 hex characters identifying the whole trace
+	SpanID   string // 16 hex characters identifying the span
+	ParentID string // Span of the caller, empty for new traces
+	Flags    byte   // Trace flags, like TraceSampled
+	State    string // Vendor specific tracestate, forwarded as is
+}
+
+// randomHex returns n random bytes hex encoded.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// NewTrace starts a new sampled trace.
+func NewTrace() TraceContext {
+	return TraceContext{
+		TraceID: randomHex(16),
+		SpanID:  randomHex(8),
+		Flags:   TraceSampled,
+	}
+}
+
+// isHexID returns true if s is a lowercase hex id of n characters that isn't all zeros.
+func isHexID(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+
+	zero := true
+	for _, r := range s {
+		switch {
+		case r == '0':
+		case r >= '1' && r <= '9', r >= 'a' && r <= 'f':
+			zero = false
+		default:
+			return false
+		}
+	}
+
+	return !zero
+}
+
+// ParseTraceParent parses the traceparent and tracestate headers of a caller.
+// The SpanID of the result is the span of the caller: use Child() to continue the trace.
+func ParseTraceParent(traceparent, tracestate string) (TraceContext, error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+	if _, err := hex.DecodeString(parts[0]); err != nil {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+	if !isHexID(parts[1], 32) || !isHexID(parts[2], 16) || len(parts[3]) != 2 {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+
+	return TraceContext{
+		TraceID: parts[1],
+		SpanID:  parts[2],
+		Flags:   flags[0],
+		State:   strings.TrimSpace(tracestate),
+	}, nil
+}
+
+// Child returns a new span of the trace, child of t.
+func (t TraceContext) Child() TraceContext {
+	return TraceContext{
+		TraceID:  t.TraceID,
+		SpanID:   randomHex(8),
+		ParentID: t.SpanID,
+		Flags:    t.Flags,
+		State:    t.State,
+	}
+}
+
+// Sampled returns true if the TraceSampled flag is set.
+func (t TraceContext) Sampled() bool {
+	return t.Flags&TraceSampled != 0
+}
+
+// TraceParent returns the traceparent header value, with t as the parent span.
+func (t TraceContext) TraceParent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", t.TraceID, t.SpanID, t.Flags)
+}
+
+// Inject sets the traceparent and tracestate headers, with t as the parent span.
+func (t TraceContext) Inject(h http.Header) {
+	h.Set(HeaderTraceParent, t.TraceParent())
+	if t.State != "" {
+		h.Set(HeaderTraceState, t.State)
+	} else {
+		h.Del(HeaderTraceState)
+	}
+}
+
+// Baggage are the W3C Baggage entries propagated along a trace, like tenant or user ids.
+type Baggage map[string]string
+
+// ParseBaggage parses a baggage header. Malformed entries and entry properties are ignored.
+func ParseBaggage(header string) Baggage {
+	b := make(Baggage)
+	for _, member := range strings.Split(header, ",") {
+		if i := strings.IndexByte(member, ';'); i >= 0 {
+			member = member[:i]
+		}
+
+		kv := strings.SplitN(member, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(kv[0])
+		value, err := url.PathUnescape(strings.TrimSpace(kv[1]))
+		if key == "" || err != nil {
+			continue
+		}
+		b[key] = value
+	}
+
+	return b
+}
+
+// String returns the baggage header value, sorted by key.
+func (b Baggage) String() string {
+	keys := make([]string, 0, len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	members := make([]string, len(keys))
+	for i, k := range keys {
+		members[i] = k + "=" + url.PathEscape(b[k])
+	}
+
+	return strings.Join(members, ",")
+}
+
+// Inject sets the baggage header, or removes it if the baggage is empty.
+func (b Baggage) Inject(h http.Header) {
+	if len(b) == 0 {
+		h.Del(HeaderBaggage)
+		return
+	}
+	h.Set(HeaderBaggage, b.String())
+}
+
+// traceKey and baggageKey are the Context storage keys of the request trace and baggage.
+type traceKey struct{}
+type baggageKey struct{}
+
+// Trace returns the trace context of the request: a child of the caller span sent in the traceparent header,
+// or a new trace if the header is missing or malformed.
+// The result is kept for the request, so its SpanID identifies the request span.
+func (c *Context) Trace() TraceContext {
+	if t, ok := c.get(traceKey{}).(TraceContext); ok {
+		return t
+	}
+
+	t, err := ParseTraceParent(c.Request.Header.Get(HeaderTraceParent), c.Request.Header.Get(HeaderTraceState))
+	if err == nil {
+		t = t.Child()
+	} else {
+		t = NewTrace()
+	}
+	c.set(traceKey{}, t)
+
+	return t
+}
+
+// Baggage returns the baggage sent by the caller.
+// Entries set on it are propagated to the outgoing requests along with the trace.
+func (c *Context) Baggage() Baggage {
+	if b, ok := c.get(baggageKey{}).(Baggage); ok {
+		return b
+	}
+
+	b := ParseBaggage(c.Request.Header.Get(HeaderBaggage))
+	c.set(baggageKey{}, b)
+
+	return b
+}
+
+// InjectTrace sets the trace context and baggage headers of an outgoing request,
+// with the request span as the parent. The proxy handler and the webhook sender do it on their requests.
+//
+//	req, _ := http.NewRequestWithContext(c.Request.Context(), "GET", billingURL, nil)
+//	c.InjectTrace(req.Header)
+func (c *Context) InjectTrace(h http.Header) {
+	c.Trace().Inject(h)
+	c.Baggage().Inject(h)
+}
@@ -0,0 +1,147 @@
+package yarf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tc, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "congo=t61rcWkgMzE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tc.SpanID != "00f067aa0ba902b7" || !tc.Sampled() || tc.State != "congo=t61rcWkgMzE" {
+		t.Errorf("Unexpected trace context %+v", tc)
+	}
+
+	// Future versions may add fields
+	if _, err := ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra", ""); err != nil {
+		t.Errorf("Expected future version to be accepted: %s", err)
+	}
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz",
+	}
+	for _, h := range invalid {
+		if _, err := ParseTraceParent(h, ""); err != ErrInvalidTraceParent {
+			t.Errorf("Expected %q to be invalid, got %v", h, err)
+		}
+	}
+}
+
+func TestTraceChild(t *testing.T) {
+	root := NewTrace()
+	if !isHexID(root.TraceID, 32) || !isHexID(root.SpanID, 16) || !root.Sampled() || root.ParentID != "" {
+		t.Errorf("Unexpected new trace %+v", root)
+	}
+
+	child := root.Child()
+	if child.TraceID != root.TraceID || child.ParentID != root.SpanID || child.SpanID == root.SpanID {
+		t.Errorf("Unexpected child %+v of %+v", child, root)
+	}
+
+	parsed, err := ParseTraceParent(child.TraceParent(), "")
+	if err != nil || parsed.TraceID != child.TraceID || parsed.SpanID != child.SpanID || parsed.Flags != child.Flags {
+		t.Errorf("Round trip of %+v: %+v %v", child, parsed, err)
+	}
+}
+
+func TestBaggage(t *testing.T) {
+	b := ParseBaggage("userId=alice, serverNode=DF%2028;prop=1,isProduction=false,invalid,=empty")
+	if len(b) != 3 || b["userId"] != "alice" || b["serverNode"] != "DF 28" || b["isProduction"] != "false" {
+		t.Errorf("Unexpected baggage %v", b)
+	}
+
+	if s := b.String(); s != "isProduction=false,serverNode=DF%2028,userId=alice" {
+		t.Errorf("Unexpected baggage header %q", s)
+	}
+
+	h := http.Header{HeaderBaggage: {"old=1"}}
+	Baggage{}.Inject(h)
+	if h.Get(HeaderBaggage) != "" {
+		t.Error("Empty baggage should remove the header")
+	}
+}
+
+type TraceResource struct {
+	Resource
+}
+
+func (r *TraceResource) Get(c *Context) error {
+	c.Baggage()["tenant"] = "acme"
+
+	h := make(http.Header)
+	c.InjectTrace(h)
+	for k := range h {
+		c.Response.Header().Set(k, h.Get(k))
+	}
+	c.Response.Header().Set("X-Span", c.Trace().SpanID)
+
+	return nil
+}
+
+func TestContextTrace(t *testing.T) {
+	y := New()
+	y.Add("/trace", new(TraceResource))
+
+	req := httptest.NewRequest("GET", "/trace", nil)
+	req.Header.Set(HeaderTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set(HeaderTraceState, "congo=t61rcWkgMzE")
+	req.Header.Set(HeaderBaggage, "userId=alice")
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	span := res.Header().Get("X-Span")
+	if tp := res.Header().Get(HeaderTraceParent); tp != "00-4bf92f3577b34da6a3ce929d0e0e4736-"+span+"-01" || span == "00f067aa0ba902b7" {
+		t.Errorf("Expected the request span as parent, got %q", tp)
+	}
+	if res.Header().Get(HeaderTraceState) != "congo=t61rcWkgMzE" || res.Header().Get(HeaderBaggage) != "tenant=acme,userId=alice" {
+		t.Errorf("Unexpected propagated headers %v", res.Header())
+	}
+
+	// New trace without a valid traceparent
+	req = httptest.NewRequest("GET", "/trace", nil)
+	req.Header.Set(HeaderTraceParent, "invalid")
+	res = httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	tc, err := ParseTraceParent(res.Header().Get(HeaderTraceParent), "")
+	if err != nil || tc.TraceID == "4bf92f3577b34da6a3ce929d0e0e4736" || res.Header().Get(HeaderTraceState) != "" {
+		t.Errorf("Expected a new trace, got %v", res.Header())
+	}
+}
+
+func TestProxyTrace(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Traceparent", r.Header.Get(HeaderTraceParent))
+		w.Header().Set("X-Baggage", r.Header.Get(HeaderBaggage))
+	}))
+	defer upstream.Close()
+
+	target, _ := url.Parse(upstream.URL)
+	y := New()
+	y.Add("/api/*", ProxyHandler(target, ProxyOptions{}))
+
+	req := httptest.NewRequest("GET", "/api/items", nil)
+	req.Header.Set(HeaderTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set(HeaderBaggage, "userId=alice")
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	tc, err := ParseTraceParent(res.Header().Get("X-Traceparent"), "")
+	if err != nil || tc.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tc.SpanID == "00f067aa0ba902b7" {
+		t.Errorf("Expected the trace to continue upstream, got %q", res.Header().Get("X-Traceparent"))
+	}
+	if res.Header().Get("X-Baggage") != "userId=alice" {
+		t.Errorf("Expected the baggage upstream, got %q", res.Header().Get("X-Baggage"))
+	}
+}
@@ -0,0 +1,366 @@
+package yarf
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TransformedResponse is the buffered response going through the transformers.
+type TransformedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Transformer modifies a buffered response before it's written to the client.
+type Transformer interface {
+	Transform(c *Context, res *TransformedResponse) error
+}
+
+// TransformerFunc adapts a function into a Transformer.
+type TransformerFunc func(c *Context, res *TransformedResponse) error
+
+// Transform calls f(c, res).
+func (f TransformerFunc) Transform(c *Context, res *TransformedResponse) error {
+	return f(c, res)
+}
+
+// transformKey is the Context storage key for the response buffered by a TransformPipeline.
+type transformKey struct {
+	p *TransformPipeline
+}
+
+// transformState keeps the original response while the pipeline buffers it.
+type transformState struct {
+	original http.ResponseWriter
+	buffer   *bufferedResponse
+}
+
+// TransformPipeline is a middleware that buffers the responses of the routes it covers
+// and runs them through its transformers, in order, before writing them to the client.
+// Insert it into a group to transform the responses of the group only:
+//
+//	g.Insert(yarf.NewTransformPipeline(
+//		yarf.SelectFields("fields"),
+//		yarf.ConvertKeys(yarf.SnakeCase),
+//	))
+//
+// Responses are fully buffered, so streaming handlers shouldn't be covered by a pipeline.
+// Responses of failed requests are written unchanged.
+// If a transformer fails, the response is discarded and the client gets the transformer error.
+type TransformPipeline struct {
+	Middleware
+
+	Transformers []Transformer
+}
+
+// NewTransformPipeline creates a TransformPipeline running the transformers provided.
+func NewTransformPipeline(transformers ...Transformer) *TransformPipeline {
+	return &TransformPipeline{
+		Transformers: transformers,
+	}
+}
+
+// PreDispatch starts buffering the response.
+func (p *TransformPipeline) PreDispatch(c *Context) error {
+	s := &transformState{
+		original: c.Response,
+		buffer:   newBufferedResponse(),
+	}
+	c.Response = s.buffer
+	c.set(transformKey{p}, s)
+
+	return nil
+}
+
+// End runs the transformers and writes the response.
+func (p *TransformPipeline) End(c *Context) error {
+	s, ok := c.get(transformKey{p}).(*transformState)
+	if !ok {
+		return nil
+	}
+	c.set(transformKey{p}, nil)
+	c.Response = s.original
+
+	if c.Err() != nil {
+		s.buffer.flush(c.Response)
+		return nil
+	}
+
+	res := &TransformedResponse{
+		Status: s.buffer.code,
+		Header: s.buffer.header,
+		Body:   s.buffer.body.Bytes(),
+	}
+	if res.Status == 0 {
+		res.Status = http.StatusOK
+	}
+
+	for _, t := range p.Transformers {
+		if err := t.Transform(c, res); err != nil {
+			c.err = err
+			writeTransformError(c, err)
+			return err
+		}
+	}
+
+	h := c.Response.Header()
+	for k, v := range res.Header {
+		h[k] = v
+	}
+	h.Del("Content-Length")
+
+	c.Response.WriteHeader(res.Status)
+	c.Response.Write(res.Body)
+
+	return nil
+}
+
+// flush writes the buffered response unchanged, without sending the headers if nothing was written.
+// Header values are copied, so the same buffer can be flushed to several responses.
+func (b *bufferedResponse) flush(rw http.ResponseWriter) {
+	h := rw.Header()
+	for k, v := range b.header {
+		h[k] = append([]string(nil), v...)
+	}
+
+	if b.code != 0 {
+		rw.WriteHeader(b.code)
+		rw.Write(b.body.Bytes())
+	}
+}
+
+// writeTransformError writes the error returned by a transformer.
+func writeTransformError(c *Context, err error) {
+	yerr, ok := err.(YError)
+	if !ok {
+		yerr = ErrorUnexpected()
+	}
+
+	c.Response.WriteHeader(yerr.Code())
+	c.Render(yerr.Body())
+}
+
+// JSONTransformer creates a Transformer that decodes JSON responses, changes them with fn and encodes them back.
+// Responses that aren't JSON are left unchanged.
+// Numbers are decoded as json.Number, objects as map[string]interface{} and arrays as []interface{}.
+func JSONTransformer(fn func(c *Context, v interface{}) (interface{}, error)) Transformer {
+	return TransformerFunc(func(c *Context, res *TransformedResponse) error {
+		if !isJSONResponse(res) {
+			return nil
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(res.Body))
+		dec.UseNumber()
+
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil
+		}
+
+		v, err := fn(c, v)
+		if err != nil {
+			return err
+		}
+
+		body, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		res.Body = body
+
+		return nil
+	})
+}
+
+// isJSONResponse checks the content type of the response, or the body if there is no content type.
+func isJSONResponse(res *TransformedResponse) bool {
+	if ct := res.Header.Get("Content-Type"); ct != "" {
+		return strings.Contains(ct, "json")
+	}
+
+	body := bytes.TrimSpace(res.Body)
+
+	return len(body) > 0 && (body[0] == '{' || body[0] == '[')
+}
+
+// SelectFields creates a JSON Transformer keeping only the fields selected by the query param,
+// with the ParseFields syntax, as in ?fields=id,name,address(city).
+// Responses are unchanged if the param is missing, and invalid selectors get a 400 error.
+func SelectFields(param string) Transformer {
+	return JSONTransformer(func(c *Context, v interface{}) (interface{}, error) {
+		mask, err := ParseFields(c.QueryValue(param))
+		if err != nil {
+			e := ErrorBadRequest()
+			e.ErrorBody = err.Error()
+			return nil, e
+		}
+
+		return mask.Prune(v), nil
+	})
+}
+
+// ConvertKeys creates a JSON Transformer renaming all object keys with fn, like SnakeCase or CamelCase.
+func ConvertKeys(fn func(string) string) Transformer {
+	return JSONTransformer(func(c *Context, v interface{}) (interface{}, error) {
+		return convertKeys(v, fn), nil
+	})
+}
+
+// convertKeys renames the keys of the objects recursively.
+func convertKeys(v interface{}, fn func(string) string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fn(k)] = convertKeys(e, fn)
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = convertKeys(v[i], fn)
+		}
+	}
+
+	return v
+}
+
+// SnakeCase converts a camelCase or PascalCase key to snake_case.
+func SnakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Word boundary, keeping acronyms like "ID" together
+			if i > 0 && runes[i-1] != '_' && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// CamelCase converts a snake_case or kebab-case key to camelCase.
+func CamelCase(s string) string {
+	var b strings.Builder
+	upper := false
+
+	for i, r := range s {
+		switch {
+		case r == '_' || r == '-':
+			upper = b.Len() > 0
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		case i == 0:
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// RedactMask replaces the values of redacted fields.
+const RedactMask = "[REDACTED]"
+
+// RedactKeys creates a JSON Transformer masking the values of the object keys provided, at any depth.
+func RedactKeys(keys ...string) Transformer {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+
+	return JSONTransformer(func(c *Context, v interface{}) (interface{}, error) {
+		return redactKeys(v, set), nil
+	})
+}
+
+// redactKeys masks the values of the keys in set recursively.
+func redactKeys(v interface{}, set map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if set[k] {
+				v[k] = RedactMask
+			} else {
+				v[k] = redactKeys(e, set)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactKeys(v[i], set)
+		}
+	}
+
+	return v
+}
+
+// RedactTagged creates a JSON Transformer masking the fields tagged as `redact:"true"`
+// in the struct types of the samples provided, by their JSON name:
+//
+//	type User struct {
+//		Name string `json:"name"`
+//		SSN  string `json:"ssn" redact:"true"`
+//	}
+//
+//	g.Insert(yarf.NewTransformPipeline(yarf.RedactTagged(User{})))
+func RedactTagged(samples ...interface{}) Transformer {
+	var keys []string
+	for _, s := range samples {
+		keys = append(keys, RedactedFields(reflect.TypeOf(s))...)
+	}
+
+	return RedactKeys(keys...)
+}
+
+// RedactedFields returns the JSON names of the fields tagged as `redact:"true"` in a struct type,
+// including nested and embedded structs.
+func RedactedFields(t reflect.Type) []string {
+	return redactedFields(t, make(map[reflect.Type]bool))
+}
+
+// redactedFields walks the struct type, skipping the types already seen.
+func redactedFields(t reflect.Type, seen map[reflect.Type]bool) []string {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+
+		if redact, _ := strconv.ParseBool(f.Tag.Get("redact")); redact {
+			keys = append(keys, name)
+			continue
+		}
+
+		keys = append(keys, redactedFields(f.Type, seen)...)
+	}
+
+	return keys
+}
@@ -0,0 +1,165 @@
+package yarf
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// UserJSONResource renders a JSON user.
+type UserJSONResource struct {
+	Resource
+}
+
+func (r *UserJSONResource) Get(c *Context) error {
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.RenderJSON(map[string]interface{}{
+		"userId":    1,
+		"firstName": "John",
+		"password":  "secret",
+		"address":   map[string]interface{}{"zipCode": "1234", "password": "nested"},
+	})
+	return nil
+}
+
+// FailingJSONResource writes a response and fails.
+type FailingJSONResource struct {
+	Resource
+}
+
+func (r *FailingJSONResource) Get(c *Context) error {
+	return ErrorNotFound()
+}
+
+// jsonEqual compares two JSON documents ignoring the keys order.
+func jsonEqual(a, b string) bool {
+	var va, vb interface{}
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(va, vb)
+}
+
+func TestTransformPipeline(t *testing.T) {
+	g := RouteGroup("/v1")
+	g.Insert(NewTransformPipeline(SelectFields("fields"), ConvertKeys(SnakeCase)))
+	g.Add("/user", new(UserJSONResource))
+	g.Add("/missing", new(FailingJSONResource))
+
+	y := New()
+	y.AddGroup(g)
+	y.Add("/user", new(UserJSONResource))
+
+	tests := []struct {
+		path     string
+		status   int
+		expected string
+	}{
+		{"/v1/user?fields=userId,address", 200, `{"address":{"zip_code":"1234","password":"nested"},"user_id":1}`},
+		{"/v1/user?fields=firstName", 200, `{"first_name":"John"}`},
+		{"/user?fields=firstName", 200, `{"address":{"password":"nested","zipCode":"1234"},"firstName":"John","password":"secret","userId":1}`},
+		{"/v1/missing?fields=firstName", 404, ""},
+	}
+
+	for _, tt := range tests {
+		res := testRequest(y, "GET", tt.path, nil)
+		if res.Code != tt.status {
+			t.Errorf("Expected status %d for %s, got %d", tt.status, tt.path, res.Code)
+		}
+		if !jsonEqual(res.Body.String(), tt.expected) && res.Body.String() != tt.expected {
+			t.Errorf("Expected %s for %s, got %s", tt.expected, tt.path, res.Body.String())
+		}
+	}
+}
+
+func TestTransformPipelineGlobal(t *testing.T) {
+	y := New()
+	y.Use(NewTransformPipeline(RedactKeys("password")))
+	y.Add("/user", new(UserJSONResource))
+
+	res := testRequest(y, "GET", "/user", nil)
+	expected := `{"address":{"password":"[REDACTED]","zipCode":"1234"},"firstName":"John","password":"[REDACTED]","userId":1}`
+	if !jsonEqual(res.Body.String(), expected) {
+		t.Errorf("Expected %s, got %s", expected, res.Body.String())
+	}
+
+	if res := testRequest(y, "GET", "/missing", nil); res.Code != 404 {
+		t.Errorf("Expected status 404 on errors, got %d", res.Code)
+	}
+}
+
+func TestTransformPipelineNonJSON(t *testing.T) {
+	y := New()
+	y.Use(NewTransformPipeline(ConvertKeys(SnakeCase)))
+	y.Add("/", new(OKResource))
+
+	if res := testRequest(y, "GET", "/", nil); res.Code != 200 || res.Body.String() != "OK" {
+		t.Errorf("Non JSON responses should be unchanged, got %d %s", res.Code, res.Body.String())
+	}
+}
+
+func TestTransformPipelineError(t *testing.T) {
+	y := New()
+	y.Use(NewTransformPipeline(TransformerFunc(func(c *Context, res *TransformedResponse) error {
+		return errors.New("transform failed")
+	})))
+	y.Add("/", new(OKResource))
+
+	if res := testRequest(y, "GET", "/", nil); res.Code != 500 || res.Body.String() == "OK" {
+		t.Errorf("Expected status 500 without response, got %d %s", res.Code, res.Body.String())
+	}
+}
+
+func TestKeyCase(t *testing.T) {
+	snake := map[string]string{
+		"userId":     "user_id",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"already_ok": "already_ok",
+		"name":       "name",
+	}
+	for in, out := range snake {
+		if s := SnakeCase(in); s != out {
+			t.Errorf("SnakeCase(%q) = %q, expected %q", in, s, out)
+		}
+	}
+
+	camel := map[string]string{
+		"user_id":    "userId",
+		"first-name": "firstName",
+		"_private":   "private",
+		"Name":       "name",
+		"alreadyOk":  "alreadyOk",
+	}
+	for in, out := range camel {
+		if s := CamelCase(in); s != out {
+			t.Errorf("CamelCase(%q) = %q, expected %q", in, s, out)
+		}
+	}
+}
+
+func TestRedactedFields(t *testing.T) {
+	type Card struct {
+		Number string `json:"number" redact:"true"`
+		Brand  string `json:"brand"`
+	}
+	type Customer struct {
+		Name     string `json:"name"`
+		Token    string `redact:"true"`
+		Cards    []Card `json:"cards"`
+		Ignored  string `json:"-" redact:"true"`
+		Backup   *Card  `json:"backup,omitempty"`
+		Password string `json:",omitempty" redact:"1"`
+	}
+
+	keys := RedactedFields(reflect.TypeOf(&Customer{}))
+	sort.Strings(keys)
+
+	expected := []string{"Password", "Token", "number"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+}
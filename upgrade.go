@@ -0,0 +1,295 @@
+package yarf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Environment variables used to hand off listeners to an upgraded process.
+const (
+	envListeners = "YARF_LISTENERS"
+	envReady     = "YARF_UPGRADE_READY"
+)
+
+// ErrUpgradeUnsupported is returned by Upgrade on platforms that can't hand off listeners to a child process.
+var ErrUpgradeUnsupported = errors.New("yarf: upgrades aren't supported on this platform")
+
+// First file descriptor inherited by the child process, after stdin, stdout and stderr.
+const firstInheritedFD = 3
+
+// boundListener is a listener opened by the Yarf instance, with the address it was requested for.
+type boundListener struct {
+	network string
+	address string
+	l       net.Listener
+}
+
+// key identifies the listener between processes.
+func (b boundListener) key() string {
+	return b.network + ":" + b.address
+}
+
+// Listeners inherited from the parent process on upgrades.
+var (
+	inherited     map[string]net.Listener
+	inheritedOnce sync.Once
+	inheritedLock sync.Mutex
+	readyOnce     sync.Once
+)
+
+// loadInherited creates the listeners from the file descriptors received from the parent process.
+func loadInherited() {
+	inherited = make(map[string]net.Listener)
+
+	keys := os.Getenv(envListeners)
+	if keys == "" {
+		return
+	}
+
+	for i, k := range strings.Split(keys, ",") {
+		f := os.NewFile(uintptr(firstInheritedFD+i), k)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			continue
+		}
+		inherited[k] = l
+	}
+}
+
+// takeInherited returns the inherited listener for the key, if any.
+func takeInherited(key string) net.Listener {
+	inheritedOnce.Do(loadInherited)
+
+	inheritedLock.Lock()
+	defer inheritedLock.Unlock()
+
+	l, ok := inherited[key]
+	if ok {
+		delete(inherited, key)
+	}
+
+	// Notify the parent once all inherited listeners are in use.
+	if len(inherited) == 0 {
+		go UpgradeReady()
+	}
+
+	return l
+}
+
+// IsUpgrade returns true if the process was started by Upgrade() to replace a parent process.
+// Use it to restore the state handed off by the OnUpgrade hooks.
+func IsUpgrade() bool {
+	return os.Getenv(envReady) != ""
+}
+
+// UpgradeReady notifies the parent process that this process is ready to handle requests,
+// so it can stop and drain its connections.
+// It's called automatically once all the inherited listeners are in use,
+// but applications can call it earlier or if they stop using some listeners after an upgrade.
+func UpgradeReady() {
+	readyOnce.Do(func() {
+		fd, err := strconv.Atoi(os.Getenv(envReady))
+		if err != nil {
+			return
+		}
+
+		f := os.NewFile(uintptr(fd), "ready")
+		f.Write([]byte{1})
+		f.Close()
+	})
+}
+
+// listen opens a listener on the address, or takes the one inherited from the parent process after an upgrade.
+// Listeners are kept to be handed off to the child process on upgrades.
+// The OnStart hooks run before the first listener is opened.
+func (y *Yarf) listen(network, address string, mode os.FileMode) (net.Listener, error) {
+	if err := y.start(); err != nil {
+		return nil, err
+	}
+
+	b := boundListener{network: network, address: address}
+
+	b.l = takeInherited(b.key())
+	if b.l == nil {
+		var err error
+		if network == "unix" {
+			b.l, err = listenUnix(address, mode)
+		} else {
+			b.l, err = net.Listen(network, address)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	y.lock.Lock()
+	y.listeners = append(y.listeners, b)
+	y.lock.Unlock()
+
+	return b.l, nil
+}
+
+// OnUpgrade registers a function to be executed before the child process is started by Upgrade().
+// Use it to persist state the new process needs, and IsUpgrade() on the new process to restore it.
+// If a hook fails, the upgrade is cancelled.
+func (y *Yarf) OnUpgrade(fn func() error) {
+	y.lock.Lock()
+	defer y.lock.Unlock()
+
+	y.onUpgrade = append(y.onUpgrade, fn)
+}
+
+// filer is implemented by listeners that can be duplicated into a file.
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Upgrade replaces the running process with a new one from the same executable without dropping connections.
+// The new process inherits the listeners opened by Start, StartTLS, StartUnix, StartAutoTLS and StartAll,
+// which pick them up instead of opening new ones.
+// Once the new process is ready, the current one gracefully shuts down, draining its connections until ctx is done.
+// If the new process fails to start or isn't ready before ctx is done, the current one keeps running.
+// Upgrades are only supported on unix systems, and return ErrUpgradeUnsupported elsewhere.
+func (y *Yarf) Upgrade(ctx context.Context) error {
+	y.lock.Lock()
+	hooks := y.onUpgrade
+	listeners := y.listeners
+	y.lock.Unlock()
+
+	for _, fn := range hooks {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+
+	var keys []string
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for _, b := range listeners {
+		fl, ok := b.l.(filer)
+		if !ok {
+			return fmt.Errorf("yarf: listener %s can't be handed off", b.key())
+		}
+		f, err := fl.File()
+		if err != nil {
+			return err
+		}
+		keys = append(keys, b.key())
+		files = append(files, f)
+	}
+
+	// Readiness pipe
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	cmd, err := y.startUpgrade(keys, files, w)
+	w.Close()
+	if err != nil {
+		return err
+	}
+
+	// Wait for the child to be ready
+	ready := make(chan error, 1)
+	go func() {
+		b := make([]byte, 1)
+		if _, err := r.Read(b); err != nil {
+			ready <- errors.New("yarf: upgraded process exited before being ready")
+			return
+		}
+		ready <- nil
+	}()
+
+	select {
+	case err = <-ready:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		y.log().Error("upgrade failed", "error", err, "pid", cmd.Process.Pid)
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+
+	y.log().Info("upgraded process ready", "pid", cmd.Process.Pid)
+
+	// Keep unix socket files for the child
+	for _, b := range listeners {
+		if ul, ok := b.l.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+
+	return y.Shutdown(ctx)
+}
+
+// UpgradeOnSignal runs Upgrade when the process receives the signal provided, SIGHUP if nil.
+// The new process is given the timeout to be ready, and then the current one to drain its connections.
+// The returned channel receives the result of each Upgrade attempt.
+func (y *Yarf) UpgradeOnSignal(timeout time.Duration, sig os.Signal) <-chan error {
+	if sig == nil {
+		sig = syscall.SIGHUP
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sig)
+
+	results := make(chan error, 1)
+	go func() {
+		for range ch {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			err := y.Upgrade(ctx)
+			cancel()
+
+			select {
+			case results <- err:
+			default:
+			}
+
+			if err == nil {
+				signal.Stop(ch)
+				return
+			}
+		}
+	}()
+
+	return results
+}
+
+// filterEnv removes the variables provided from an environment list.
+func filterEnv(env []string, names ...string) []string {
+	var res []string
+
+	for _, e := range env {
+		keep := true
+		for _, n := range names {
+			if strings.HasPrefix(e, n+"=") {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
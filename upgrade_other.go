@@ -0,0 +1,13 @@
+//go:build !unix
+
+package yarf
+
+import (
+	"os"
+	"os/exec"
+)
+
+// startUpgrade fails, as listeners can only be handed off to child processes on unix systems.
+func (y *Yarf) startUpgrade(keys []string, files []*os.File, ready *os.File) (*exec.Cmd, error) {
+	return nil, ErrUpgradeUnsupported
+}
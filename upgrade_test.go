@@ -0,0 +1,105 @@
+package yarf
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+type ProcessResource struct {
+	Resource
+
+	name string
+}
+
+func (r *ProcessResource) Get(c *Context) error {
+	c.Render(r.name)
+	return nil
+}
+
+// TestUpgradeHelper runs as the upgraded process on TestUpgrade.
+func TestUpgradeHelper(t *testing.T) {
+	if !IsUpgrade() {
+		t.Skip("Only runs as upgraded process")
+	}
+
+	y := New()
+	y.Add("/", &ProcessResource{name: "child"})
+
+	go func() {
+		time.Sleep(2 * time.Second)
+		y.Shutdown(context.Background())
+	}()
+
+	y.Start(os.Getenv("YARF_TEST_ADDRESS"))
+}
+
+func TestUpgrade(t *testing.T) {
+	if IsUpgrade() {
+		t.Skip("Already upgraded")
+	}
+
+	address := freeAddress(t)
+
+	y := New()
+	y.Add("/", &ProcessResource{name: "parent"})
+
+	var hook bool
+	y.OnUpgrade(func() error {
+		hook = true
+		return nil
+	})
+
+	result := make(chan error)
+	go func() {
+		result <- y.Start(address)
+	}()
+	waitServer(t, address)
+
+	// Run only the helper test on the child process.
+	args := os.Args
+	os.Args = []string{args[0], "-test.run=TestUpgradeHelper"}
+	defer func() {
+		os.Args = args
+	}()
+	os.Setenv("YARF_TEST_ADDRESS", address)
+	defer os.Unsetenv("YARF_TEST_ADDRESS")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := y.Upgrade(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-result; err != nil {
+		t.Errorf("Start should return nil after upgrade, got: %s", err)
+	}
+	if !hook {
+		t.Error("OnUpgrade hooks should run")
+	}
+
+	tr := new(http.Transport)
+	defer tr.CloseIdleConnections()
+
+	res, err := (&http.Client{Transport: tr}).Get("http://" + address + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	if string(body) != "child" {
+		t.Errorf("Requests after upgrade should be served by the child process, got '%s'", body)
+	}
+}
+
+func TestFilterEnv(t *testing.T) {
+	env := filterEnv([]string{"A=1", "YARF_LISTENERS=tcp::80", "B=2"}, envListeners, envReady)
+
+	if len(env) != 2 || env[0] != "A=1" || env[1] != "B=2" {
+		t.Errorf("Unexpected filtered environment: %v", env)
+	}
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+package yarf
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// startUpgrade starts the child process from the same executable, handing off the listener files
+// and the write end of the readiness pipe.
+func (y *Yarf) startUpgrade(keys []string, files []*os.File, ready *os.File) (*exec.Cmd, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = append(files, ready)
+	cmd.Env = append(
+		filterEnv(os.Environ(), envListeners, envReady),
+		envListeners+"="+strings.Join(keys, ","),
+		envReady+"="+strconv.Itoa(firstInheritedFD+len(files)),
+	)
+
+	y.log().Info("upgrading", "executable", exe, "listeners", len(files))
+
+	err = cmd.Start()
+
+	// Passing the files sets the shared sockets in blocking mode, restore them for the running servers.
+	for _, f := range files {
+		syscall.SetNonblock(int(f.Fd()), true)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
@@ -0,0 +1,475 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Headers set on outgoing deliveries, following the Standard Webhooks specification.
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+)
+
+// ErrSenderClosed is returned when sending events after the Sender was closed.
+var ErrSenderClosed = errors.New("webhook: sender closed")
+
+// Subscriber receives events POSTed to its URL.
+type Subscriber struct {
+	// ID identifies the subscriber.
+	ID string
+
+	// URL receiving the events.
+	URL string
+
+	// Secret used to sign the deliveries.
+	Secret []byte
+
+	// Events the subscriber is interested in. Empty means all events.
+	Events []string
+}
+
+// wants returns true if the subscriber is interested in the event type.
+func (s *Subscriber) wants(eventType string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DeliveryStatus is the state of a delivery.
+type DeliveryStatus string
+
+// Delivery status values
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryRetrying  DeliveryStatus = "retrying"
+	DeliveryDead      DeliveryStatus = "dead"
+)
+
+// Delivery is an event sent to a subscriber.
+type Delivery struct {
+	ID          string          `json:"id"`
+	Subscriber  string          `json:"subscriber"`
+	URL         string          `json:"url"`
+	Event       string          `json:"event"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      DeliveryStatus  `json:"status"`
+	Attempts    int             `json:"attempts"`
+	LastStatus  int             `json:"last_status,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	NextAttempt time.Time       `json:"next_attempt,omitempty"`
+	Created     time.Time       `json:"created"`
+	Updated     time.Time       `json:"updated"`
+
+	secret []byte
+	trace  http.Header
+}
+
+// envelope is the JSON body of the deliveries.
+type envelope struct {
+	Type      string          `json:"type"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Sender delivers events to the subscribers with HMAC signatures,
+// retrying failed deliveries with exponential backoff.
+// Deliveries exceeding MaxAttempts are moved to the dead letters, where they can be inspected and retried.
+// Requests are signed following the Standard Webhooks specification, verified by the Standard verifier.
+type Sender struct {
+	// Client used for the requests. Defaults to a client with a 30 seconds timeout.
+	Client *http.Client
+
+	// MaxAttempts before a delivery is dead.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry, doubled on each further attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// History is the number of finished deliveries kept for introspection. Dead letters aren't counted.
+	History int
+
+	// OnDead is called when a delivery is moved to the dead letters.
+	OnDead func(Delivery)
+
+	subscribers map[string]*Subscriber
+	deliveries  map[string]*Delivery
+	finished    []string
+	timers      map[string]*time.Timer
+	queue       chan *Delivery
+	done        chan struct{}
+	closed      bool
+	wg          sync.WaitGroup
+	lock        sync.Mutex
+}
+
+// NewSender creates a Sender with the given number of concurrent workers and default settings:
+// 8 attempts, starting with a 1 second backoff up to 1 hour, and a history of 1000 deliveries.
+func NewSender(workers int) *Sender {
+	if workers < 1 {
+		workers = 1
+	}
+
+	s := &Sender{
+		Client:      &http.Client{Timeout: 30 * time.Second},
+		MaxAttempts: 8,
+		Backoff:     time.Second,
+		MaxBackoff:  time.Hour,
+		History:     1000,
+		subscribers: make(map[string]*Subscriber),
+		deliveries:  make(map[string]*Delivery),
+		timers:      make(map[string]*time.Timer),
+		queue:       make(chan *Delivery, 1024),
+		done:        make(chan struct{}),
+	}
+
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+
+	return s
+}
+
+// Subscribe adds or replaces a subscriber.
+func (s *Sender) Subscribe(sub Subscriber) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.subscribers[sub.ID] = &sub
+}
+
+// Unsubscribe removes a subscriber. Pending deliveries are still attempted.
+func (s *Sender) Unsubscribe(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.subscribers, id)
+}
+
+// newID returns a random delivery id.
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+
+	return "msg_" + hex.EncodeToString(b)
+}
+
+// Send queues the event for delivery to the interested subscribers and returns the delivery ids.
+// The payload is encoded as JSON into the data field of the body.
+func (s *Sender) Send(eventType string, payload interface{}) ([]string, error) {
+	return s.send(eventType, payload, nil)
+}
+
+// SendFrom queues the event as Send does, propagating the trace context and baggage of the request
+// that produced it, so the deliveries join its trace.
+func (s *Sender) SendFrom(c *yarf.Context, eventType string, payload interface{}) ([]string, error) {
+	trace := make(http.Header)
+	c.InjectTrace(trace)
+
+	return s.send(eventType, payload, trace)
+}
+
+// send queues the event for delivery with the trace headers.
+func (s *Sender) send(eventType string, payload interface{}, trace http.Header) ([]string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return nil, ErrSenderClosed
+	}
+
+	now := time.Now()
+	var ids []string
+
+	for _, sub := range s.subscribers {
+		if !sub.wants(eventType) {
+			continue
+		}
+
+		d := &Delivery{
+			ID:         newID(),
+			Subscriber: sub.ID,
+			URL:        sub.URL,
+			Event:      eventType,
+			Payload:    data,
+			Status:     DeliveryPending,
+			Created:    now,
+			Updated:    now,
+			secret:     sub.Secret,
+			trace:      trace,
+		}
+		s.deliveries[d.ID] = d
+		ids = append(ids, d.ID)
+
+		s.schedule(d, 0)
+	}
+
+	return ids, nil
+}
+
+// schedule queues the delivery after the delay. It must be called with the lock held.
+func (s *Sender) schedule(d *Delivery, delay time.Duration) {
+	d.NextAttempt = time.Now().Add(delay)
+
+	s.timers[d.ID] = time.AfterFunc(delay, func() {
+		s.lock.Lock()
+		delete(s.timers, d.ID)
+		s.lock.Unlock()
+
+		select {
+		case s.queue <- d:
+		case <-s.done:
+		}
+	})
+}
+
+// work delivers the queued events until the sender is closed.
+func (s *Sender) work() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case d := <-s.queue:
+			s.attempt(d)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// attempt makes a delivery attempt and updates its status.
+func (s *Sender) attempt(d *Delivery) {
+	s.lock.Lock()
+	req, err := s.request(d)
+	s.lock.Unlock()
+
+	code := 0
+	if err == nil {
+		var res *http.Response
+		res, err = s.Client.Do(req)
+		if err == nil {
+			io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+			res.Body.Close()
+
+			code = res.StatusCode
+			if code < 200 || code > 299 {
+				err = fmt.Errorf("unexpected status %d", code)
+			}
+		}
+	}
+
+	s.lock.Lock()
+
+	d.Attempts++
+	d.LastStatus = code
+	d.Updated = time.Now()
+	d.NextAttempt = time.Time{}
+
+	if err == nil {
+		d.Status = DeliverySucceeded
+		d.LastError = ""
+		s.finish(d)
+		s.lock.Unlock()
+		return
+	}
+
+	d.LastError = err.Error()
+
+	if d.Attempts < s.MaxAttempts {
+		d.Status = DeliveryRetrying
+		if !s.closed {
+			s.schedule(d, s.backoff(d.Attempts))
+		}
+		s.lock.Unlock()
+		return
+	}
+
+	d.Status = DeliveryDead
+	dead := *d
+	s.lock.Unlock()
+
+	if s.OnDead != nil {
+		s.OnDead(dead)
+	}
+}
+
+// backoff returns the delay before the next attempt.
+func (s *Sender) backoff(attempts int) time.Duration {
+	delay := s.Backoff << uint(attempts-1)
+	if delay > s.MaxBackoff || delay <= 0 {
+		delay = s.MaxBackoff
+	}
+
+	return delay
+}
+
+// finish records a succeeded delivery in the history, dropping the oldest ones.
+// It must be called with the lock held.
+func (s *Sender) finish(d *Delivery) {
+	s.finished = append(s.finished, d.ID)
+	for len(s.finished) > s.History {
+		delete(s.deliveries, s.finished[0])
+		s.finished = s.finished[1:]
+	}
+}
+
+// request builds the signed request of a delivery. It must be called with the lock held.
+func (s *Sender) request(d *Delivery) (*http.Request, error) {
+	body, err := json.Marshal(envelope{Type: d.Event, Timestamp: d.Created.UTC(), Data: d.Payload})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", d.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	for k, v := range d.trace {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, d.ID)
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, "v1,"+signStandard(d.secret, d.ID, ts, body))
+
+	return req, nil
+}
+
+// signStandard returns the base64 encoded HMAC-SHA256 signature of a Standard Webhooks message.
+func signStandard(secret []byte, id, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(id + "." + ts + "."))
+	mac.Write(body)
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Delivery returns a copy of a delivery by id.
+func (s *Sender) Delivery(id string) (Delivery, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	d, ok := s.deliveries[id]
+	if !ok {
+		return Delivery{}, false
+	}
+
+	return *d, true
+}
+
+// Deliveries returns copies of the known deliveries with the status, or all of them if status is empty.
+func (s *Sender) Deliveries(status DeliveryStatus) []Delivery {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var list []Delivery
+	for _, d := range s.deliveries {
+		if status == "" || d.Status == status {
+			list = append(list, *d)
+		}
+	}
+
+	return list
+}
+
+// DeadLetters returns the deliveries that exceeded the attempts.
+func (s *Sender) DeadLetters() []Delivery {
+	return s.Deliveries(DeliveryDead)
+}
+
+// Retry queues a dead delivery again, resetting its attempts.
+func (s *Sender) Retry(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return ErrSenderClosed
+	}
+
+	d, ok := s.deliveries[id]
+	if !ok || d.Status != DeliveryDead {
+		return fmt.Errorf("webhook: delivery %s isn't dead", id)
+	}
+
+	d.Status = DeliveryPending
+	d.Attempts = 0
+	d.Updated = time.Now()
+	s.schedule(d, 0)
+
+	return nil
+}
+
+// Discard removes a dead delivery.
+func (s *Sender) Discard(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if d, ok := s.deliveries[id]; ok && d.Status == DeliveryDead {
+		delete(s.deliveries, id)
+	}
+}
+
+// Close stops the scheduled retries and waits for the running attempts to finish, or for ctx to be done.
+// Deliveries not attempted yet are kept with their status for introspection.
+func (s *Sender) Close(ctx context.Context) error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	for id, t := range s.timers {
+		t.Stop()
+		delete(s.timers, id)
+	}
+	s.lock.Unlock()
+
+	close(s.done)
+
+	wait := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(wait)
+	}()
+
+	select {
+	case <-wait:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,246 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yarf-framework/yarf"
+)
+
+func newTestSender(t *testing.T) *Sender {
+	s := NewSender(2)
+	s.Backoff = time.Millisecond
+	s.MaxBackoff = 5 * time.Millisecond
+	t.Cleanup(func() { s.Close(context.Background()) })
+
+	return s
+}
+
+func waitStatus(t *testing.T, s *Sender, id string, status DeliveryStatus) Delivery {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if d, ok := s.Delivery(id); ok && d.Status == status {
+			return d
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	d, _ := s.Delivery(id)
+	t.Fatalf("Delivery %s didn't reach status %s: %+v", id, status, d)
+
+	return d
+}
+
+func TestSenderSignsForReceiver(t *testing.T) {
+	// A Yarf receiver verifies the deliveries of the sender
+	r := NewReceiver(NewStandard(secret))
+
+	var lock sync.Mutex
+	var got *Event
+	r.On("user.created", func(c *yarf.Context, e *Event) error {
+		lock.Lock()
+		got = e
+		lock.Unlock()
+		return nil
+	})
+
+	y := yarf.New()
+	y.Add("/hook", r)
+	srv := httptest.NewServer(y)
+	defer srv.Close()
+
+	s := newTestSender(t)
+	s.Subscribe(Subscriber{ID: "app", URL: srv.URL + "/hook", Secret: []byte(secret)})
+
+	ids, err := s.Send("user.created", map[string]string{"name": "Jane"})
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("Expected one delivery, got %v: %v", ids, err)
+	}
+
+	d := waitStatus(t, s, ids[0], DeliverySucceeded)
+	if d.Attempts != 1 || d.LastStatus != http.StatusOK {
+		t.Errorf("Unexpected delivery %+v", d)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if got == nil || got.ID != ids[0] {
+		t.Fatalf("Receiver didn't get the delivery: %+v", got)
+	}
+	var body struct {
+		Type string
+		Data struct{ Name string }
+	}
+	got.Decode(&body)
+	if body.Type != "user.created" || body.Data.Name != "Jane" {
+		t.Errorf("Unexpected body %s", got.Body)
+	}
+}
+
+func TestSenderRetriesAndDeadLetters(t *testing.T) {
+	var calls int32
+	var fail int32 = 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	s := newTestSender(t)
+	s.MaxAttempts = 3
+
+	dead := make(chan Delivery, 1)
+	s.OnDead = func(d Delivery) {
+		dead <- d
+	}
+	s.Subscribe(Subscriber{ID: "app", URL: srv.URL, Secret: []byte(secret)})
+
+	ids, _ := s.Send("order.paid", 1)
+
+	d := waitStatus(t, s, ids[0], DeliveryDead)
+	if d.Attempts != 3 || d.LastStatus != http.StatusInternalServerError || d.LastError == "" {
+		t.Errorf("Unexpected dead delivery %+v", d)
+	}
+	if atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+
+	select {
+	case d := <-dead:
+		if d.ID != ids[0] {
+			t.Errorf("Unexpected dead letter %s", d.ID)
+		}
+	case <-time.After(time.Second):
+		t.Error("OnDead wasn't called")
+	}
+
+	if letters := s.DeadLetters(); len(letters) != 1 {
+		t.Errorf("Expected one dead letter, got %d", len(letters))
+	}
+
+	atomic.StoreInt32(&fail, 0)
+	if err := s.Retry(ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	waitStatus(t, s, ids[0], DeliverySucceeded)
+
+	if err := s.Retry(ids[0]); err == nil {
+		t.Error("Only dead deliveries can be retried")
+	}
+}
+
+func TestSenderSubscriptions(t *testing.T) {
+	s := newTestSender(t)
+	s.Subscribe(Subscriber{ID: "all", URL: "http://127.0.0.1:1"})
+	s.Subscribe(Subscriber{ID: "orders", URL: "http://127.0.0.1:1", Events: []string{"order.paid"}})
+
+	if ids, _ := s.Send("order.paid", nil); len(ids) != 2 {
+		t.Errorf("Expected 2 deliveries, got %d", len(ids))
+	}
+	if ids, _ := s.Send("user.created", nil); len(ids) != 1 {
+		t.Errorf("Expected 1 delivery, got %d", len(ids))
+	}
+
+	s.Unsubscribe("all")
+	if ids, _ := s.Send("user.created", nil); len(ids) != 0 {
+		t.Errorf("Expected no deliveries, got %d", len(ids))
+	}
+}
+
+func TestSenderBackoff(t *testing.T) {
+	s := &Sender{Backoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}
+	for i, e := range expected {
+		if b := s.backoff(i + 1); b != e {
+			t.Errorf("Expected backoff %s for attempt %d, got %s", e, i+1, b)
+		}
+	}
+	if b := s.backoff(100); b != 10*time.Second {
+		t.Errorf("Backoff should be capped on overflow, got %s", b)
+	}
+}
+
+func TestSenderHistory(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	s := newTestSender(t)
+	s.History = 2
+	s.Subscribe(Subscriber{ID: "app", URL: srv.URL})
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		sent, _ := s.Send("tick", i)
+		waitStatus(t, s, sent[0], DeliverySucceeded)
+		ids = append(ids, sent[0])
+	}
+
+	if _, ok := s.Delivery(ids[0]); ok {
+		t.Error("Oldest delivery should be dropped from the history")
+	}
+	if len(s.Deliveries("")) != 2 {
+		t.Errorf("Expected 2 deliveries in history, got %d", len(s.Deliveries("")))
+	}
+}
+
+func TestSenderClose(t *testing.T) {
+	s := NewSender(1)
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Send("tick", nil); err != ErrSenderClosed {
+		t.Errorf("Expected ErrSenderClosed, got %v", err)
+	}
+}
+
+type orderResource struct {
+	yarf.Resource
+
+	sender *Sender
+	span   yarf.TraceContext
+}
+
+func (r *orderResource) Get(c *yarf.Context) error {
+	r.span = c.Trace()
+	_, err := r.sender.SendFrom(c, "order.paid", nil)
+
+	return err
+}
+
+func TestSenderPropagatesTrace(t *testing.T) {
+	traces := make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traces <- r.Header.Get(yarf.HeaderTraceParent)
+	}))
+	defer upstream.Close()
+
+	s := newTestSender(t)
+	s.Subscribe(Subscriber{ID: "app", URL: upstream.URL, Secret: []byte(secret)})
+
+	r := &orderResource{sender: s}
+	y := yarf.New()
+	y.Add("/orders", r)
+
+	req := httptest.NewRequest("GET", "/orders", nil)
+	req.Header.Set(yarf.HeaderTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	y.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case tp := <-traces:
+		if tp != r.span.TraceParent() || r.span.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("Expected the request span %+v as parent, got %q", r.span, tp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Delivery not received")
+	}
+}
@@ -0,0 +1,319 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Verification errors
+var (
+	ErrMissingSignature = errors.New("webhook: missing signature")
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	ErrExpiredTimestamp = errors.New("webhook: timestamp outside the tolerance window")
+)
+
+// DefaultTolerance is the maximum age of signed timestamps accepted by the verifiers.
+const DefaultTolerance = 5 * time.Minute
+
+// Verifier checks the authenticity of a webhook request.
+type Verifier interface {
+	// Verify returns an error if the request isn't signed by the provider.
+	Verify(r *http.Request, body []byte) error
+}
+
+// EventTyper is implemented by verifiers that know how their provider identifies events.
+type EventTyper interface {
+	// EventType returns the event type and the delivery ID of the request, if any.
+	EventType(r *http.Request, body []byte) (eventType, id string)
+}
+
+// VerifierFunc adapts a function into a Verifier.
+type VerifierFunc func(r *http.Request, body []byte) error
+
+// Verify calls f(r, body).
+func (f VerifierFunc) Verify(r *http.Request, body []byte) error {
+	return f(r, body)
+}
+
+// sign returns the hex encoded HMAC of the parts.
+func sign(h func() hash.Hash, secret []byte, parts ...string) string {
+	mac := hmac.New(h, secret)
+	for _, p := range parts {
+		mac.Write([]byte(p))
+	}
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// equal compares signatures in constant time.
+func equal(a, b string) bool {
+	return hmac.Equal([]byte(a), []byte(b))
+}
+
+// checkTimestamp validates an unix timestamp against the tolerance window.
+func checkTimestamp(ts string, now func() time.Time, tolerance time.Duration) error {
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if now == nil {
+		now = time.Now
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+
+	diff := now().Sub(time.Unix(sec, 0))
+	if diff > tolerance || diff < -tolerance {
+		return ErrExpiredTimestamp
+	}
+
+	return nil
+}
+
+// HMAC verifies a hex encoded HMAC-SHA256 signature of the body sent in a header,
+// for providers without a dedicated verifier.
+type HMAC struct {
+	// Secret shared with the provider.
+	Secret []byte
+
+	// Header holding the signature.
+	Header string
+
+	// Prefix of the signature in the header, like "sha256=".
+	Prefix string
+}
+
+// Verify checks the signature of the body.
+func (v *HMAC) Verify(r *http.Request, body []byte) error {
+	sig := r.Header.Get(v.Header)
+	if sig == "" {
+		return ErrMissingSignature
+	}
+	if !strings.HasPrefix(sig, v.Prefix) || !equal(strings.TrimPrefix(sig, v.Prefix), sign(sha256.New, v.Secret, string(body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// GitHub verifies GitHub webhooks signed with the X-Hub-Signature-256 header.
+// The legacy SHA-1 X-Hub-Signature header is only accepted if AllowSHA1 is set.
+// GitHub doesn't sign timestamps, so deliveries are identified by the X-GitHub-Delivery header.
+type GitHub struct {
+	Secret    []byte
+	AllowSHA1 bool
+}
+
+// NewGitHub creates a GitHub verifier.
+func NewGitHub(secret string) *GitHub {
+	return &GitHub{Secret: []byte(secret)}
+}
+
+// Verify checks the signature of the body.
+func (v *GitHub) Verify(r *http.Request, body []byte) error {
+	if sig := r.Header.Get("X-Hub-Signature-256"); sig != "" {
+		if !equal(sig, "sha256="+sign(sha256.New, v.Secret, string(body))) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+
+	if sig := r.Header.Get("X-Hub-Signature"); sig != "" && v.AllowSHA1 {
+		if !equal(sig, "sha1="+sign(sha1.New, v.Secret, string(body))) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+
+	return ErrMissingSignature
+}
+
+// EventType returns the X-GitHub-Event and X-GitHub-Delivery headers.
+func (v *GitHub) EventType(r *http.Request, body []byte) (string, string) {
+	return r.Header.Get("X-GitHub-Event"), r.Header.Get("X-GitHub-Delivery")
+}
+
+// Stripe verifies Stripe webhooks signed with the Stripe-Signature header,
+// rejecting signatures with timestamps outside the tolerance window.
+type Stripe struct {
+	Secret []byte
+
+	// Tolerance for the signed timestamp. Defaults to DefaultTolerance.
+	Tolerance time.Duration
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewStripe creates a Stripe verifier with the endpoint secret.
+func NewStripe(secret string) *Stripe {
+	return &Stripe{Secret: []byte(secret)}
+}
+
+// Verify checks the signature of the timestamp and body.
+// Any of the v1 signatures in the header is accepted, to support secret rotation.
+func (v *Stripe) Verify(r *http.Request, body []byte) error {
+	header := r.Header.Get("Stripe-Signature")
+	if header == "" {
+		return ErrMissingSignature
+	}
+
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = val
+		case "v1":
+			sigs = append(sigs, val)
+		}
+	}
+	if ts == "" || len(sigs) == 0 {
+		return ErrMissingSignature
+	}
+
+	expected := sign(sha256.New, v.Secret, ts, ".", string(body))
+	valid := false
+	for _, sig := range sigs {
+		if equal(sig, expected) {
+			valid = true
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	return checkTimestamp(ts, v.Now, v.Tolerance)
+}
+
+// EventType returns the type and id of the Stripe event object.
+func (v *Stripe) EventType(r *http.Request, body []byte) (string, string) {
+	var e struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	}
+	json.Unmarshal(body, &e)
+
+	return e.Type, e.ID
+}
+
+// Slack verifies Slack requests signed with the X-Slack-Signature and X-Slack-Request-Timestamp headers,
+// rejecting timestamps outside the tolerance window.
+type Slack struct {
+	Secret []byte
+
+	// Tolerance for the signed timestamp. Defaults to DefaultTolerance.
+	Tolerance time.Duration
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewSlack creates a Slack verifier with the signing secret.
+func NewSlack(secret string) *Slack {
+	return &Slack{Secret: []byte(secret)}
+}
+
+// Verify checks the signature of the timestamp and body.
+func (v *Slack) Verify(r *http.Request, body []byte) error {
+	sig := r.Header.Get("X-Slack-Signature")
+	ts := r.Header.Get("X-Slack-Request-Timestamp")
+	if sig == "" || ts == "" {
+		return ErrMissingSignature
+	}
+
+	if !equal(sig, "v0="+sign(sha256.New, v.Secret, "v0:", ts, ":", string(body))) {
+		return ErrInvalidSignature
+	}
+
+	return checkTimestamp(ts, v.Now, v.Tolerance)
+}
+
+// EventType returns the type of Events API callbacks, using the inner event type for event_callback payloads.
+func (v *Slack) EventType(r *http.Request, body []byte) (string, string) {
+	var e struct {
+		Type    string `json:"type"`
+		EventID string `json:"event_id"`
+		Event   struct {
+			Type string `json:"type"`
+		} `json:"event"`
+	}
+	json.Unmarshal(body, &e)
+
+	if e.Type == "event_callback" && e.Event.Type != "" {
+		return e.Event.Type, e.EventID
+	}
+
+	return e.Type, e.EventID
+}
+
+// Standard verifies webhooks signed following the Standard Webhooks specification,
+// like the ones sent by Sender, rejecting timestamps outside the tolerance window.
+type Standard struct {
+	Secret []byte
+
+	// Tolerance for the signed timestamp. Defaults to DefaultTolerance.
+	Tolerance time.Duration
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewStandard creates a Standard verifier. Secrets with the "whsec_" prefix are base64 decoded.
+func NewStandard(secret string) *Standard {
+	key := []byte(secret)
+	if strings.HasPrefix(secret, "whsec_") {
+		if b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_")); err == nil {
+			key = b
+		}
+	}
+
+	return &Standard{Secret: key}
+}
+
+// Verify checks the signature of the id, timestamp and body.
+// Any of the v1 signatures in the header is accepted, to support secret rotation.
+func (v *Standard) Verify(r *http.Request, body []byte) error {
+	id := r.Header.Get(HeaderID)
+	ts := r.Header.Get(HeaderTimestamp)
+	header := r.Header.Get(HeaderSignature)
+	if id == "" || ts == "" || header == "" {
+		return ErrMissingSignature
+	}
+
+	expected := signStandard(v.Secret, id, ts, body)
+	valid := false
+	for _, sig := range strings.Fields(header) {
+		if version, s, _ := strings.Cut(sig, ","); version == "v1" && equal(s, expected) {
+			valid = true
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	return checkTimestamp(ts, v.Now, v.Tolerance)
+}
+
+// EventType returns the type field of the body and the Webhook-Id header.
+func (v *Standard) EventType(r *http.Request, body []byte) (string, string) {
+	var e struct {
+		Type string `json:"type"`
+	}
+	json.Unmarshal(body, &e)
+
+	return e.Type, r.Header.Get(HeaderID)
+}
@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const secret = "s3cr3t"
+
+func hexHMAC(h func() hash.Hash, data string) string {
+	mac := hmac.New(h, []byte(secret))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newRequest(header map[string]string) *http.Request {
+	r, _ := http.NewRequest("POST", "http://localhost/hook", nil)
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	return r
+}
+
+func TestGitHub(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	v := NewGitHub(secret)
+
+	r := newRequest(map[string]string{
+		"X-Hub-Signature-256": "sha256=" + hexHMAC(sha256.New, string(body)),
+		"X-GitHub-Event":      "push",
+		"X-GitHub-Delivery":   "abc",
+	})
+	if err := v.Verify(r, body); err != nil {
+		t.Errorf("Valid signature rejected: %s", err)
+	}
+	if typ, id := v.EventType(r, body); typ != "push" || id != "abc" {
+		t.Errorf("Unexpected event type %s and id %s", typ, id)
+	}
+	if err := v.Verify(r, []byte(`{"ref":"tampered"}`)); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature for tampered body, got %v", err)
+	}
+	if err := v.Verify(newRequest(nil), body); err != ErrMissingSignature {
+		t.Errorf("Expected ErrMissingSignature, got %v", err)
+	}
+
+	legacy := newRequest(map[string]string{"X-Hub-Signature": "sha1=" + hexHMAC(sha1.New, string(body))})
+	if err := v.Verify(legacy, body); err != ErrMissingSignature {
+		t.Errorf("SHA-1 signatures should be ignored by default, got %v", err)
+	}
+	v.AllowSHA1 = true
+	if err := v.Verify(legacy, body); err != nil {
+		t.Errorf("Valid SHA-1 signature rejected: %s", err)
+	}
+}
+
+func TestStripe(t *testing.T) {
+	body := []byte(`{"id":"evt_1","type":"invoice.paid"}`)
+	now := time.Unix(1700000000, 0)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	sig := hexHMAC(sha256.New, ts+"."+string(body))
+
+	v := NewStripe(secret)
+	v.Now = func() time.Time { return now.Add(time.Minute) }
+
+	r := newRequest(map[string]string{"Stripe-Signature": "t=" + ts + ",v1=deadbeef,v1=" + sig})
+	if err := v.Verify(r, body); err != nil {
+		t.Errorf("Valid signature rejected: %s", err)
+	}
+	if typ, id := v.EventType(r, body); typ != "invoice.paid" || id != "evt_1" {
+		t.Errorf("Unexpected event type %s and id %s", typ, id)
+	}
+
+	if err := v.Verify(newRequest(map[string]string{"Stripe-Signature": "t=" + ts + ",v1=deadbeef"}), body); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature, got %v", err)
+	}
+	if err := v.Verify(newRequest(map[string]string{"Stripe-Signature": "v1=" + sig}), body); err != ErrMissingSignature {
+		t.Errorf("Expected ErrMissingSignature without timestamp, got %v", err)
+	}
+
+	// Replayed later
+	v.Now = func() time.Time { return now.Add(time.Hour) }
+	if err := v.Verify(r, body); err != ErrExpiredTimestamp {
+		t.Errorf("Expected ErrExpiredTimestamp, got %v", err)
+	}
+	v.Tolerance = 2 * time.Hour
+	if err := v.Verify(r, body); err != nil {
+		t.Errorf("Signature within custom tolerance rejected: %s", err)
+	}
+}
+
+func TestSlack(t *testing.T) {
+	body := []byte(`{"type":"event_callback","event_id":"Ev1","event":{"type":"app_mention"}}`)
+	now := time.Unix(1700000000, 0)
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	v := NewSlack(secret)
+	v.Now = func() time.Time { return now }
+
+	r := newRequest(map[string]string{
+		"X-Slack-Signature":         "v0=" + hexHMAC(sha256.New, "v0:"+ts+":"+string(body)),
+		"X-Slack-Request-Timestamp": ts,
+	})
+	if err := v.Verify(r, body); err != nil {
+		t.Errorf("Valid signature rejected: %s", err)
+	}
+	if typ, id := v.EventType(r, body); typ != "app_mention" || id != "Ev1" {
+		t.Errorf("Unexpected event type %s and id %s", typ, id)
+	}
+	if typ, _ := v.EventType(r, []byte(`{"type":"url_verification"}`)); typ != "url_verification" {
+		t.Errorf("Expected url_verification type, got %s", typ)
+	}
+
+	r.Header.Set("X-Slack-Request-Timestamp", strconv.FormatInt(now.Unix()+1, 10))
+	if err := v.Verify(r, body); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature for changed timestamp, got %v", err)
+	}
+
+	v.Now = func() time.Time { return now.Add(-10 * time.Minute) }
+	r.Header.Set("X-Slack-Request-Timestamp", ts)
+	if err := v.Verify(r, body); err != ErrExpiredTimestamp {
+		t.Errorf("Expected ErrExpiredTimestamp for future timestamp, got %v", err)
+	}
+}
+
+func TestHMAC(t *testing.T) {
+	body := []byte("payload")
+	v := &HMAC{Secret: []byte(secret), Header: "X-Signature", Prefix: "sha256="}
+
+	if err := v.Verify(newRequest(map[string]string{"X-Signature": "sha256=" + hexHMAC(sha256.New, "payload")}), body); err != nil {
+		t.Errorf("Valid signature rejected: %s", err)
+	}
+	if err := v.Verify(newRequest(map[string]string{"X-Signature": hexHMAC(sha256.New, "payload")}), body); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature without prefix, got %v", err)
+	}
+	if err := v.Verify(newRequest(nil), body); err != ErrMissingSignature {
+		t.Errorf("Expected ErrMissingSignature, got %v", err)
+	}
+}
+
+func TestStandard(t *testing.T) {
+	body := []byte(`{"type":"user.created"}`)
+	now := time.Unix(1700000000, 0)
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	v := NewStandard("whsec_" + base64.StdEncoding.EncodeToString([]byte(secret)))
+	v.Now = func() time.Time { return now }
+
+	r := newRequest(map[string]string{
+		HeaderID:        "msg_1",
+		HeaderTimestamp: ts,
+		HeaderSignature: "v1,invalid v1," + signStandard([]byte(secret), "msg_1", ts, body),
+	})
+	if err := v.Verify(r, body); err != nil {
+		t.Errorf("Valid signature rejected: %s", err)
+	}
+	if typ, id := v.EventType(r, body); typ != "user.created" || id != "msg_1" {
+		t.Errorf("Unexpected event type %s and id %s", typ, id)
+	}
+
+	r.Header.Set(HeaderID, "msg_2")
+	if err := v.Verify(r, body); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature for changed id, got %v", err)
+	}
+	if err := v.Verify(newRequest(nil), body); err != ErrMissingSignature {
+		t.Errorf("Expected ErrMissingSignature, got %v", err)
+	}
+}
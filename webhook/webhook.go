@@ -0,0 +1,136 @@
+// Package webhook receives webhooks from third party providers, and delivers events to subscribers.
+// Requests are authenticated by a Verifier for the provider, and dispatched to handlers by event type.
+// The Sender delivers signed events, retrying failed deliveries.
+//
+//	r := webhook.NewReceiver(webhook.NewGitHub(secret))
+//	r.On("push", func(c *yarf.Context, e *webhook.Event) error {
+//		var push PushEvent
+//		return e.Decode(&push)
+//	})
+//	y.Add("/hooks/github", r)
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Event is a verified webhook delivery.
+type Event struct {
+	// Type of the event, as identified by the provider.
+	Type string
+
+	// ID of the delivery, if the provider sends one.
+	ID string
+
+	// Body is the raw request body.
+	Body []byte
+
+	// Header of the request.
+	Header http.Header
+}
+
+// Decode unmarshals the JSON body into v.
+func (e *Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Body, v)
+}
+
+// HandlerFunc handles an event. A nil error without writing a response answers with 200 OK.
+type HandlerFunc func(c *yarf.Context, e *Event) error
+
+// DefaultMaxBodySize is the default maximum webhook body size.
+const DefaultMaxBodySize = 10 << 20
+
+// Receiver is a ResourceHandler verifying webhook requests on POST and dispatching them to the event handlers.
+// Requests failing verification get a 401 error. Events without handlers are acknowledged with 204 No Content,
+// so providers don't retry them.
+type Receiver struct {
+	yarf.Resource
+
+	// Verifier authenticates the requests.
+	Verifier Verifier
+
+	// EventType identifies the event for verifiers that don't implement EventTyper.
+	EventType func(r *http.Request, body []byte) (eventType, id string)
+
+	// MaxBodySize limits the request body. Larger requests get a 413 error. Zero means no limit.
+	MaxBodySize int64
+
+	handlers map[string]HandlerFunc
+	fallback HandlerFunc
+	lock     sync.RWMutex
+}
+
+// NewReceiver creates a Receiver using the verifier.
+func NewReceiver(v Verifier) *Receiver {
+	return &Receiver{
+		Verifier:    v,
+		MaxBodySize: DefaultMaxBodySize,
+		handlers:    make(map[string]HandlerFunc),
+	}
+}
+
+// On registers the handler for an event type.
+func (r *Receiver) On(eventType string, fn HandlerFunc) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.handlers[eventType] = fn
+}
+
+// OnAny registers the handler for events without a specific handler.
+func (r *Receiver) OnAny(fn HandlerFunc) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.fallback = fn
+}
+
+// Post verifies and dispatches the webhook.
+func (r *Receiver) Post(c *yarf.Context) error {
+	var src io.Reader = c.Request.Body
+	if r.MaxBodySize > 0 {
+		src = io.LimitReader(src, r.MaxBodySize+1)
+	}
+	body, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	if r.MaxBodySize > 0 && int64(len(body)) > r.MaxBodySize {
+		return &yarf.CustomError{HTTPCode: http.StatusRequestEntityTooLarge, ErrorMsg: "Webhook body too large"}
+	}
+
+	if r.Verifier == nil {
+		return yarf.ErrorUnauthorized()
+	}
+	if err := r.Verifier.Verify(c.Request, body); err != nil {
+		e := yarf.ErrorUnauthorized()
+		e.ErrorMsg = err.Error()
+		return e
+	}
+
+	e := &Event{Body: body, Header: c.Request.Header}
+	if t, ok := r.Verifier.(EventTyper); ok {
+		e.Type, e.ID = t.EventType(c.Request, body)
+	} else if r.EventType != nil {
+		e.Type, e.ID = r.EventType(c.Request, body)
+	}
+
+	r.lock.RLock()
+	fn, ok := r.handlers[e.Type]
+	if !ok {
+		fn = r.fallback
+	}
+	r.lock.RUnlock()
+
+	if fn == nil {
+		c.Response.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	return fn(c, e)
+}
@@ -0,0 +1,126 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+)
+
+func post(y *yarf.Yarf, body string, header map[string]string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "http://localhost/hook", strings.NewReader(body))
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	return res
+}
+
+func signed(event, body string) map[string]string {
+	return map[string]string{
+		"X-Hub-Signature-256": "sha256=" + hexHMAC(sha256.New, body),
+		"X-GitHub-Event":      event,
+		"X-GitHub-Delivery":   "delivery-1",
+	}
+}
+
+func TestReceiverDispatch(t *testing.T) {
+	r := NewReceiver(NewGitHub(secret))
+
+	var got *Event
+	r.On("push", func(c *yarf.Context, e *Event) error {
+		got = e
+		c.Render("pushed")
+		return nil
+	})
+
+	y := yarf.New()
+	y.Add("/hook", r)
+
+	body := `{"ref":"main"}`
+	res := post(y, body, signed("push", body))
+	if res.Code != http.StatusOK || res.Body.String() != "pushed" {
+		t.Fatalf("Expected handler response, got %d %s", res.Code, res.Body.String())
+	}
+	if got == nil || got.Type != "push" || got.ID != "delivery-1" || string(got.Body) != body {
+		t.Fatalf("Unexpected event %+v", got)
+	}
+
+	var decoded struct{ Ref string }
+	if err := got.Decode(&decoded); err != nil || decoded.Ref != "main" {
+		t.Errorf("Unexpected decoded body %+v: %v", decoded, err)
+	}
+
+	// No handler
+	res = post(y, body, signed("issues", body))
+	if res.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 for unhandled event, got %d", res.Code)
+	}
+
+	// Fallback
+	r.OnAny(func(c *yarf.Context, e *Event) error {
+		c.Render("any " + e.Type)
+		return nil
+	})
+	res = post(y, body, signed("issues", body))
+	if res.Body.String() != "any issues" {
+		t.Errorf("Expected fallback handler, got %s", res.Body.String())
+	}
+}
+
+func TestReceiverRejects(t *testing.T) {
+	r := NewReceiver(NewGitHub(secret))
+	called := false
+	r.OnAny(func(c *yarf.Context, e *Event) error {
+		called = true
+		return nil
+	})
+
+	y := yarf.New()
+	y.Add("/hook", r)
+
+	body := `{"ref":"main"}`
+	header := signed("push", body)
+	header["X-Hub-Signature-256"] = "sha256=0000"
+
+	res := post(y, body, header)
+	if res.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for invalid signature, got %d", res.Code)
+	}
+
+	r.MaxBodySize = 4
+	res = post(y, body, signed("push", body))
+	if res.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 for large body, got %d", res.Code)
+	}
+
+	if called {
+		t.Error("Handler shouldn't be called for rejected requests")
+	}
+}
+
+func TestReceiverEventType(t *testing.T) {
+	r := NewReceiver(VerifierFunc(func(r *http.Request, body []byte) error {
+		return nil
+	}))
+	r.EventType = func(req *http.Request, body []byte) (string, string) {
+		return req.Header.Get("X-Event"), ""
+	}
+	r.On("created", func(c *yarf.Context, e *Event) error {
+		c.Render("created")
+		return nil
+	})
+
+	y := yarf.New()
+	y.Add("/hook", r)
+
+	res := post(y, "{}", map[string]string{"X-Event": "created"})
+	if res.Body.String() != "created" {
+		t.Errorf("Expected event type from EventType func, got %s", res.Body.String())
+	}
+}
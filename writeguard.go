@@ -0,0 +1,234 @@
+package yarf
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// ErrResponseSent is returned by the writes made to a response once the request has ended,
+// like the ones of goroutines started by the handlers.
+var ErrResponseSent = errors.New("yarf: response already sent")
+
+// writeGuard wraps the http.ResponseWriter of the requests, below the middleware writers.
+// It ignores the status codes sent after the first one, and every write made once the request has ended,
+// logging them with their route instead of letting net/http complain or corrupt the next response.
+type writeGuard struct {
+	http.ResponseWriter
+
+	y        *Yarf
+	c        *Context
+	status   int
+	closed   bool
+	hijacked bool
+
+	// Request data kept at close, as the Context may be reused
+	method, path, route string
+
+	lock sync.Mutex
+}
+
+// informational returns true for the 1xx status codes sent before the final response, like 103 Early Hints.
+func informational(code int) bool {
+	return code >= 100 && code < 200 && code != http.StatusSwitchingProtocols
+}
+
+// guardResponse wraps the Context response with a writeGuard.
+// The guard exposes the http.Hijacker and http.Pusher interfaces of the response when it implements them,
+// so handlers checking for them keep working.
+func guardResponse(y *Yarf, c *Context) *writeGuard {
+	g := &writeGuard{
+		ResponseWriter: c.Response,
+		y:              y,
+		c:              c,
+	}
+
+	_, hijacker := c.Response.(http.Hijacker)
+	_, pusher := c.Response.(http.Pusher)
+	switch {
+	case hijacker && pusher:
+		c.Response = hijackPushGuard{g}
+	case hijacker:
+		c.Response = hijackGuard{g}
+	case pusher:
+		c.Response = pushGuard{g}
+	default:
+		c.Response = g
+	}
+
+	return g
+}
+
+// hijackGuard is a writeGuard over a response implementing http.Hijacker, like the HTTP/1 ones.
+type hijackGuard struct {
+	*writeGuard
+}
+
+// Hijack takes over the connection.
+func (w hijackGuard) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+// pushGuard is a writeGuard over a response implementing http.Pusher, like the HTTP/2 ones.
+type pushGuard struct {
+	*writeGuard
+}
+
+// Push initiates an HTTP/2 server push.
+func (w pushGuard) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// hijackPushGuard is a writeGuard over a response implementing both http.Hijacker and http.Pusher.
+type hijackPushGuard struct {
+	*writeGuard
+}
+
+// Hijack takes over the connection.
+func (w hijackPushGuard) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+// Push initiates an HTTP/2 server push.
+func (w hijackPushGuard) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// guard returns the writeGuard, for the lookups through the middleware writers.
+func (w *writeGuard) guard() *writeGuard {
+	return w
+}
+
+// hijack takes over the connection of the underlying response, once nothing was written.
+// Writes made after it are ignored.
+func (w *writeGuard) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.status != 0 || w.closed {
+		return nil, nil, ErrHijackWritten
+	}
+
+	conn, rw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	w.hijacked = true
+
+	return conn, rw, nil
+}
+
+// lateWrite logs a write made after the request ended. The guard must be locked.
+func (w *writeGuard) lateWrite(call string) {
+	w.y.log().Error("write after response", "call", call, "method", w.method, "path", w.path, "route", w.route)
+}
+
+// WriteHeader sends the first final status code and ignores the next ones.
+// Informational 1xx codes are sent as they are.
+func (w *writeGuard) WriteHeader(code int) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.hijacked {
+		return
+	}
+	if w.closed {
+		w.lateWrite("WriteHeader")
+		return
+	}
+	if w.status != 0 {
+		w.y.log().Error("superfluous WriteHeader", "status", code, "sent", w.status,
+			"method", w.c.Request.Method, "path", w.c.Request.URL.Path, "route", w.c.RoutePattern())
+		return
+	}
+	if informational(code) {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	w.status = code
+	defaultContentType(w.c, code)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes data to the response, sending the implicit 200 status if needed.
+func (w *writeGuard) Write(data []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.hijacked {
+		return 0, http.ErrHijacked
+	}
+	if w.closed {
+		w.lateWrite("Write")
+		return 0, ErrResponseSent
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+		defaultContentType(w.c, http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+// ReadFrom copies the reader into the response, using the io.ReaderFrom of the underlying response if any,
+// so file responses can still use sendfile.
+func (w *writeGuard) ReadFrom(r io.Reader) (int64, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.hijacked {
+		return 0, http.ErrHijacked
+	}
+	if w.closed {
+		w.lateWrite("ReadFrom")
+		return 0, ErrResponseSent
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+		defaultContentType(w.c, http.StatusOK)
+	}
+
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+
+	return io.Copy(struct{ io.Writer }{w.ResponseWriter}, r)
+}
+
+// Flush flushes the response if supported.
+func (w *writeGuard) Flush() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed || w.hijacked {
+		return
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+		defaultContentType(w.c, http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the original http.ResponseWriter, as used by http.ResponseController.
+func (w *writeGuard) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close ends the response: further writes are ignored.
+func (w *writeGuard) close() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.closed = true
+	w.method = w.c.Request.Method
+	w.path = w.c.Request.URL.Path
+	w.route = w.c.RoutePattern()
+	w.c = nil
+}
@@ -0,0 +1,175 @@
+package yarf
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type WriteThenFailResource struct {
+	Resource
+}
+
+func (r *WriteThenFailResource) Get(c *Context) error {
+	c.Status(http.StatusCreated)
+	c.Render("partial")
+
+	return errors.New("database is down")
+}
+
+type LateWriteResource struct {
+	Resource
+	response http.ResponseWriter
+}
+
+func (r *LateWriteResource) Get(c *Context) error {
+	r.response = c.Response
+	c.Render("done")
+
+	return nil
+}
+
+func TestWriteGuardSuperfluousWriteHeader(t *testing.T) {
+	l := new(MockLogger)
+
+	y := New()
+	y.Log = l
+	y.Add("/items", new(WriteThenFailResource))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://localhost:8080/items", nil)
+	y.ServeHTTP(res, req)
+
+	if res.Code != http.StatusCreated {
+		t.Errorf("Expected the first status to be kept, got %d", res.Code)
+	}
+
+	found := false
+	for _, e := range l.entries {
+		if e.msg == "superfluous WriteHeader" {
+			found = true
+			if e.fields[1] != 500 || e.fields[3] != http.StatusCreated || e.fields[9] != "/items" {
+				t.Errorf("Unexpected log fields %v", e.fields)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected superfluous WriteHeader to be logged, got %+v", l.entries)
+	}
+}
+
+func TestWriteGuardLateWrites(t *testing.T) {
+	l := new(MockLogger)
+	r := new(LateWriteResource)
+
+	y := New()
+	y.Log = l
+	y.UsePool = true
+	y.Add("/items/:id", r)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://localhost:8080/items/1", nil)
+	y.ServeHTTP(res, req)
+
+	// A goroutine of the handler writing once the request has ended
+	if _, err := r.response.Write([]byte("late")); err != ErrResponseSent {
+		t.Errorf("Expected ErrResponseSent, got %v", err)
+	}
+	r.response.WriteHeader(http.StatusTeapot)
+	r.response.(http.Flusher).Flush()
+
+	if res.Body.String() != "done" || res.Code != http.StatusOK {
+		t.Errorf("Late writes should be ignored, got %d %q", res.Code, res.Body.String())
+	}
+
+	last := l.entries[len(l.entries)-1]
+	if last.msg != "write after response" || last.fields[1] != "WriteHeader" || last.fields[7] != "/items/:id" {
+		t.Errorf("Expected late writes to be logged with the route, got %+v", l.entries)
+	}
+}
+
+func TestWriteGuardInformational(t *testing.T) {
+	c := NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+	g := guardResponse(New(), c)
+
+	c.Response.WriteHeader(http.StatusEarlyHints)
+	c.Response.WriteHeader(http.StatusAccepted)
+	g.close()
+
+	if g.status != http.StatusAccepted {
+		t.Errorf("Informational codes should be sent before the final one, got %d", g.status)
+	}
+	if g.c != nil {
+		t.Error("Closed guards shouldn't keep the Context")
+	}
+}
+
+type HijackerResource struct {
+	Resource
+}
+
+func (r *HijackerResource) Get(c *Context) error {
+	h, ok := c.Response.(http.Hijacker)
+	if !ok {
+		c.Render("not a hijacker")
+		return nil
+	}
+
+	conn, rw, err := h.Hijack()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+	rw.Flush()
+
+	// Writes after the hijack are ignored
+	c.Render("ignored")
+
+	return nil
+}
+
+func TestWriteGuardInterfaces(t *testing.T) {
+	y := New()
+	y.Add("/hijack", new(HijackerResource))
+
+	s := httptest.NewServer(y)
+	defer s.Close()
+
+	res, err := http.Get(s.URL + "/hijack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "hijacked" {
+		t.Errorf("Expected the HTTP/1 response to be hijackable, got '%s'", body)
+	}
+
+	c := NewContext(httptest.NewRequest("GET", "/", nil), &pushRecorder{ResponseRecorder: httptest.NewRecorder()})
+	guardResponse(y, c)
+	if _, ok := c.Response.(http.Hijacker); ok {
+		t.Error("The guard shouldn't implement http.Hijacker over responses that don't")
+	}
+	p, ok := c.Response.(http.Pusher)
+	if !ok || p.Push("/app.js", &http.PushOptions{}) != nil {
+		t.Error("The guard should expose http.Pusher")
+	}
+	if findGuard(c.Response) == nil {
+		t.Error("Expected the guard found behind the interfaces")
+	}
+
+	rec := httptest.NewRecorder()
+	c = NewContext(httptest.NewRequest("GET", "/", nil), rec)
+	g := guardResponse(y, c)
+	if n, err := io.Copy(c.Response, strings.NewReader("copied")); n != 6 || err != nil || rec.Body.String() != "copied" || g.status != 200 {
+		t.Errorf("Unexpected copy %d %v '%s' %d", n, err, rec.Body.String(), g.status)
+	}
+	g.close()
+	if _, err := c.Response.(io.ReaderFrom).ReadFrom(strings.NewReader("late")); err != ErrResponseSent {
+		t.Errorf("Expected late copies rejected, got %v", err)
+	}
+}
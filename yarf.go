@@ -1,9 +1,17 @@
 package yarf
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Version string
@@ -15,27 +23,147 @@ type Yarf struct {
 	// UseCache indicates if the route cache should be used.
 	UseCache bool
 
+	// UsePool indicates if Context objects should be reused between requests.
+	// When enabled, a Context and its Params are only valid until the request ends,
+	// so they shouldn't be used from goroutines that outlive the request.
+	UsePool bool
+
 	// Debug enables/disables the debug mode.
-	// On debug mode, extra error information is sent to the client.
+	// On debug mode, extra error information is sent to the client:
+	// error details, matched route, request dump and stack traces of panics,
+	// as HTML for browsers and JSON for other clients.
+	// Panics are recovered and rendered as errors, so PanicHandler doesn't receive them.
+	// Outside debug mode, the messages of errors not implementing YError aren't sent to the client.
+	// Don't enable it in production.
 	Debug bool
 
 	// PanicHandler can store a func() that will be defered by each request to be able to recover().
 	// If you need to log, send information or do anything about a panic, this is your place.
+	// Middleware panics, recovered by the chain, are raised again into it before MiddlewarePanics applies.
 	PanicHandler func()
 
 	GroupRouter
 
+	// Global middleware
+	global *GroupRoute
+
+	// Context objects pool
+	pool sync.Pool
+
 	// Cached routes storage
 	cache *Cache
 
-	// Logger object will be used if present
+	// Cache stores the responses of the routes declared Cacheable.
+	// New() sets NewResponseCache(), nil disables the response caching.
+	Cache *ResponseCache
+
+	// Logger object will be used if present to write the access log.
 	Logger *log.Logger
 
+	// Redactor masks the sensitive headers, params and fields in the access log, debug error pages,
+	// audit events and request dumps. New() sets NewRedactor(), nil disables the redaction.
+	Redactor *Redactor
+
+	// Metrics receives the measurements of the requests and the built-in middleware.
+	// If nil, nothing is measured.
+	Metrics Metrics
+
+	// Client configures the outbound clients returned by Context.HTTPClient(). If nil, the defaults are used.
+	Client *ClientOptions
+
+	// Log receives the framework messages: server startup and shutdown, panics and dispatch errors.
+	// If nil, slog.Default() is used.
+	Log Logger
+
 	// Follow defines a standard http.Handler implementation to follow if no route matches.
 	Follow http.Handler
 
+	// URLs is the URL normalization pipeline, run on the request paths before the global middleware.
+	// nil leaves the paths as they are. See StrictURLs() and LenientURLs().
+	URLs *URLNormalizer
+
+	// DumpBodySize is the number of request body bytes kept for Context.Dump(), for the debug error pages
+	// and the error reports. 0 disables the body capture.
+	DumpBodySize int64
+
+	// ContentType is the Content-Type of the responses sent without one, like "application/json; charset=utf-8".
+	// Groups and routes can declare their own. If empty, net/http detects it from the body.
+	ContentType string
+
+	// MiddlewarePanics decides if the requests fail or continue when a middleware panics.
+	// Defaults to FailOnMiddlewarePanic.
+	MiddlewarePanics MiddlewarePanicPolicy
+
 	// NotFound defines a function interface to execute when a NotFound (404) error is thrown.
 	NotFound func(c *Context)
+
+	// RouteNotFound renders the requests that don't match any route. If nil, NotFound is used.
+	RouteNotFound func(c *Context)
+
+	// ResourceNotFound renders the 404 errors returned by the handlers and middleware,
+	// for resources that don't exist under an existing route. If nil, NotFound is used.
+	ResourceNotFound func(c *Context)
+
+	// H2C enables HTTP/2 over cleartext connections on the servers started by Yarf,
+	// for internal traffic behind load balancers. HTTP/1 keeps being served.
+	H2C bool
+
+	// TLSConfig is the TLS configuration of the servers started by StartTLS() and of the TLS listeners without one,
+	// like a MutualTLSConfig() requiring client certificates. The certificate and key files are added to it.
+	TLSConfig *tls.Config
+
+	// HTTP2 tunes the HTTP/2 settings (max concurrent streams, frame sizes, etc.) of the servers started by Yarf.
+	// If nil, net/http defaults are used.
+	HTTP2 *http.HTTP2Config
+
+	// Trusted proxies networks
+	trusted proxyTrust
+
+	// Public URL of the application
+	baseURL *url.URL
+
+	// Global path prefix removed before matching
+	prefix string
+
+	// Route patterns by name, built on the first URL() call
+	names     map[string]string
+	namesLock sync.RWMutex
+
+	// Authorization policy of the route permissions
+	policy Policy
+
+	// Running servers, listeners and lifecycle hooks
+	servers   []*http.Server
+	listeners []boundListener
+	onStart   []func() error
+	onStop    []func(context.Context) error
+	onUpgrade []func() error
+	health    *Health
+	jobs      JobQueue
+	bus       *Bus
+	sched     *Scheduler
+	flags     *Flags
+	dashboard *Dashboard
+	memory    *MemoryCache
+	hijacked  map[*HijackedConn]struct{}
+	startOnce sync.Once
+	startErr  error
+	stopping  bool
+	lock      sync.Mutex
+
+	// Connection state tracking
+	conns       connState
+	onConnState []func(net.Conn, http.ConnState)
+
+	// Route objectives tracking
+	slos  map[string]*sloWindow
+	onSLO []func(*SLOEvent)
+
+	// Crash reporting
+	reporters []ErrorReporter
+
+	// Runtime stats
+	counters runtimeCounters
 }
 
 // New creates a new yarf and returns a pointer to it.
@@ -45,8 +173,17 @@ func New() *Yarf {
 
 	// Init cache
 	y.UseCache = true
+	y.UsePool = true
 	y.cache = NewCache()
+	y.Redactor = NewRedactor()
+	y.Cache = NewResponseCache()
 	y.GroupRouter = RouteGroup("")
+	y.global = RouteGroup("")
+	y.counters.started = time.Now()
+	y.pool.New = func() interface{} {
+		atomic.AddUint64(&y.counters.poolNews, 1)
+		return NewContext(nil, nil)
+	}
 
 	// Return object
 	return y
@@ -58,53 +195,274 @@ func New() *Yarf {
 // If no route matches, tries to forward the request to the Yarf.Follow (http.Handler type) property if set.
 // Otherwise it returns a 404 response.
 func (y *Yarf) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	y.serve(res, req, nil)
+}
+
+// serve handles the request running the listener middleware provided around the global middleware.
+func (y *Yarf) serve(res http.ResponseWriter, req *http.Request, local []MiddlewareHandler) {
+	atomic.AddUint64(&y.counters.requests, 1)
+	atomic.AddInt64(&y.conns.inFlight, 1)
+	defer atomic.AddInt64(&y.conns.inFlight, -1)
+	start := time.Now()
+
 	if y.PanicHandler != nil {
 		defer y.PanicHandler()
 	}
 
 	// Set initial context data.
 	// The Context pointer will be affected by the middleware and resources.
-	c := NewContext(req, res)
+	var c *Context
+	if y.UsePool {
+		atomic.AddUint64(&y.counters.poolGets, 1)
+		c = y.pool.Get().(*Context)
+		c.reset(req, res)
+		defer y.pool.Put(c)
+	} else {
+		c = NewContext(req, res)
+	}
+	c.app = y
+	c.client = req.Context()
+	defer guardResponse(y, c).close()
+	if len(y.reporters) > 0 && !y.Debug {
+		defer y.reportPanic(c)
+	}
+	if y.trusted != nil {
+		c.set(trustKey{}, y.trusted)
+	}
+	if y.bus != nil {
+		c.set(busKey{}, y.bus)
+	}
+	if y.flags != nil {
+		c.set(flagsKey{}, y.flags)
+	}
+	if y.DumpBodySize > 0 {
+		captureBody(c, y.DumpBodySize)
+	}
+	if y.Metrics != nil {
+		defer y.requestMetrics(c)()
+	}
+	if y.dashboard != nil {
+		defer y.dashboard.track(c)()
+	}
+	if y.Debug {
+		defer y.recoverDebug(c, local)
+	}
+
+	// URL normalization
+	err := y.normalizeURL(c)
+
+	// Global pre-dispatch middleware
+	if err == nil {
+		err = y.preDispatch(c, local)
+	}
+
+	// Route and dispatch
+	if err == nil {
+		err = y.handle(c)
+	}
+
+	// Errors added by the handlers
+	err = c.joinErrors(err)
+
+	// Global post-dispatch middleware
+	if err == nil {
+		err = y.postDispatch(c, local)
+	}
+
+	// Client disconnects, timeouts and cancellations
+	err = contextError(clientError(c, err))
+
+	y.finish(c, err)
+	y.reportError(c, err)
+
+	// Global end middleware
+	if err != nil {
+		c.err = err
+	}
+	y.endDispatch(c, local)
+
+	// Route objectives
+	y.trackSLO(c, time.Since(start))
+
+	// Follow-up jobs
+	y.enqueueAfter(c)
+}
+
+// handle matches the request against the routes and dispatches it.
+func (y *Yarf) handle(c *Context) error {
+	if path, ok := y.routePath(c.Request.URL.Path); ok && y.matchRoute(path, c) {
+		return y.Dispatch(c)
+	}
+
+	// Follow only when route doesn't match.
+	// Returned 404 errors won't follow.
+	if y.Follow != nil {
+		y.Follow.ServeHTTP(c.Response, c.Request)
+		return nil
+	}
 
+	// Return 404
+	return ErrorRouteNotFound()
+}
+
+// matchRoute sets the route matching path on the Context, from the route cache when enabled,
+// caching the new matches. Returns false if no route matches.
+func (y *Yarf) matchRoute(path string, c *Context) bool {
 	// Cached routes
 	if y.UseCache {
-		if cache, ok := y.cache.Get(req.URL.Path); ok {
-			// Set context params
-			c.Params = cache.params
-			c.groupDispatch = cache.route
-
-			// Dispatch and stop
-			err := y.Dispatch(c)
-			y.finish(c, err)
-			return
+		if cache, ok := y.cache.Get(path); ok {
+			// Set context params, copied as the context may be reused.
+			for k, v := range cache.params {
+				c.Params[k] = v
+			}
+			c.groupDispatch = append(c.groupDispatch[:0], cache.route...)
+			c.route = leafRoute(c.groupDispatch)
+			c.matched = c.groupDispatch
+
+			return true
 		}
 	}
 
 	// Route match
-	if y.Match(req.URL.Path, c) {
-		if y.UseCache {
-			y.cache.Set(req.URL.Path, RouteCache{c.groupDispatch, c.Params})
+	if !y.Match(path, c) {
+		return false
+	}
+	if y.UseCache {
+		y.cache.Set(path, newRouteCache(c))
+	}
+	c.route = leafRoute(c.groupDispatch)
+	c.matched = c.groupDispatch
+
+	return true
+}
+
+// notFoundHandler returns the renderer of a 404 error, or nil to render the error as it is.
+func (y *Yarf) notFoundHandler(err YError) func(*Context) {
+	h := y.ResourceNotFound
+	if _, ok := err.(*RouteNotFoundError); ok {
+		h = y.RouteNotFound
+	}
+	if h == nil {
+		h = y.NotFound
+	}
+
+	return h
+}
+
+// Use adds a MiddlewareHandler to the global middleware list.
+// Global middleware runs for every request, before route matching begins,
+// so it also runs for requests that don't match any route.
+// As in groups, global middleware is sorted by phase.
+func (y *Yarf) Use(m MiddlewareHandler) {
+	y.global.Insert(m)
+}
+
+// preDispatch runs the listener and global PreDispatch middleware.
+func (y *Yarf) preDispatch(c *Context, local []MiddlewareHandler) error {
+	for _, list := range [][]MiddlewareHandler{local, y.global.middleware} {
+		for _, m := range list {
+			if err := callMiddleware(c, m, stagePre); err != nil {
+				return err
+			}
 		}
-		err := y.Dispatch(c)
-		y.finish(c, err)
-		return
 	}
 
-	// Follow only when route doesn't match.
-	// Returned 404 errors won't follow.
-	if y.Follow != nil {
-		// Log follow
-		y.finish(c, nil)
+	return nil
+}
 
-		// Follow
-		y.Follow.ServeHTTP(c.Response, c.Request)
+// postDispatch runs the global and listener PostDispatch middleware.
+func (y *Yarf) postDispatch(c *Context, local []MiddlewareHandler) error {
+	for _, list := range [][]MiddlewareHandler{y.global.middleware, local} {
+		for _, m := range list {
+			if err := callMiddleware(c, m, stagePost); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// endDispatch runs the global and listener End middleware after the response has been sent.
+func (y *Yarf) endDispatch(c *Context, local []MiddlewareHandler) {
+	for _, list := range [][]MiddlewareHandler{y.global.middleware, local} {
+		for _, m := range list {
+			callMiddleware(c, m, stageEnd)
+		}
+	}
+}
+
+// root returns the root group, or nil if GroupRouter was replaced by another implementation.
+func (y *Yarf) root() *GroupRoute {
+	g, _ := y.GroupRouter.(*GroupRoute)
+
+	return g
+}
+
+// AddRoute inserts a new resource with it's associated route into the router, and returns the route metadata.
+// With a custom GroupRouter, the route is added through its Add method and the metadata is nil,
+// so the values declared on it are discarded.
+func (y *Yarf) AddRoute(url string, h ResourceHandler) *RouteMeta {
+	if g := y.root(); g != nil {
+		return g.AddRoute(url, h)
+	}
+	y.GroupRouter.Add(url, h)
+
+	return nil
+}
 
-		// End here
+// InsertPhase adds a MiddlewareHandler to the router on the phase provided.
+// With a custom GroupRouter, the middleware is inserted through it and the phase is ignored.
+func (y *Yarf) InsertPhase(p Phase, m MiddlewareHandler) {
+	if g := y.root(); g != nil {
+		g.InsertPhase(p, m)
 		return
 	}
+	y.GroupRouter.Insert(m)
+}
 
-	// Return 404
-	y.finish(c, ErrorNotFound())
+// Header declares a default response header for all the routes of the router.
+// It has no effect with a custom GroupRouter.
+func (y *Yarf) Header(key, value string) {
+	if g := y.root(); g != nil {
+		g.Header(key, value)
+	}
+}
+
+// chainRoot wraps the router into a group holding the global middleware, used for chain introspection.
+func (y *Yarf) chainRoot() *GroupRoute {
+	g := &GroupRoute{
+		middleware: y.global.middleware,
+		phases:     y.global.phases,
+	}
+	if r := y.root(); r != nil {
+		g.routes = []Router{r}
+	} else {
+		g.routes = []Router{y.GroupRouter}
+	}
+
+	return g
+}
+
+// Chain returns the effective middleware chain, including global middleware, for every route pattern.
+func (y *Yarf) Chain() map[string][]ChainItem {
+	return y.chainRoot().Chain()
+}
+
+// Routes returns all the routes of the Yarf instance in matching order, with their chain including global middleware.
+func (y *Yarf) Routes() []RouteInfo {
+	return y.chainRoot().Routes()
+}
+
+// PrintChain writes the effective middleware chain, including global middleware, of each route.
+func (y *Yarf) PrintChain(w io.Writer) {
+	y.chainRoot().PrintChain(w)
+}
+
+// ParamConflicts returns a *PatternError for each route param hiding the one of an enclosing group.
+// See GroupRoute.Namespace().
+func (y *Yarf) ParamConflicts() []error {
+	return y.chainRoot().ParamConflicts()
 }
 
 // Finish handles the end of the execution.
@@ -114,18 +472,14 @@ func (y *Yarf) finish(c *Context, err error) {
 	// If a logger is present, lets log everything.
 	if y.Logger != nil {
 		// Construct request host string
-		req := "http"
-		if c.Request.TLS != nil {
-			req += "s"
-		}
-		req += "://" + c.Request.Host + c.Request.URL.String()
+		req := c.Scheme() + "://" + c.Host() + y.Redactor.URL(c.Request.URL)
 
 		// Check for errors
 		errorMsg := "OK"
 		if err != nil {
 			yerr, ok := err.(YError)
 			if ok {
-				if yerr.Code() == 404 && y.NotFound != nil {
+				if yerr.Code() == 404 && y.notFoundHandler(yerr) != nil {
 					errorMsg = "FOLLOW NotFound"
 				} else {
 					errorMsg = fmt.Sprintf("ERROR: %d - %s | %s", yerr.Code(), yerr.Body(), yerr.Msg())
@@ -150,6 +504,18 @@ func (y *Yarf) finish(c *Context, err error) {
 		return
 	}
 
+	// Log server side errors
+	if yerr, ok := err.(YError); !ok || yerr.Code() >= 500 {
+		y.log().Error("dispatch error", "error", err, "method", c.Request.Method, "path", c.Request.URL.Path)
+	} else {
+		y.log().Debug("dispatch error", "error", err, "method", c.Request.Method, "path", c.Request.URL.Path, "status", yerr.Code())
+	}
+
+	// Nobody is left to read the response
+	if _, ok := err.(*ClientClosedRequestError); ok {
+		return
+	}
+
 	// Check error type
 	yerr, ok := err.(YError)
 	if !ok {
@@ -158,29 +524,39 @@ func (y *Yarf) finish(c *Context, err error) {
 			HTTPCode:  500,
 			ErrorCode: 0,
 			ErrorMsg:  err.Error(),
-			ErrorBody: err.Error(),
 		}
 	}
 
+	// Redirects
+	if r, ok := yerr.(*RedirectError); ok {
+		c.redirect(r.URL, r.Code())
+		return
+	}
+
 	// Custom 404
-	if yerr.Code() == 404 && y.NotFound != nil {
-		y.NotFound(c)
+	if yerr.Code() == 404 {
+		kind := "resource"
+		if _, ok := yerr.(*RouteNotFoundError); ok {
+			kind = "route"
+		}
+		c.metrics().Counter("yarf_not_found_total", "404 responses, by kind: unmatched route or missing resource.", "kind").Add(1, kind)
+
+		if h := y.notFoundHandler(yerr); h != nil {
+			h(c)
+			return
+		}
+	}
+
+	// Debug error page
+	if y.Debug {
+		renderDebugError(c, err, yerr)
 		return
 	}
 
 	// Write error data to response.
+	if _, ok := yerr.(*MultiError); ok {
+		c.Response.Header().Set("Content-Type", "application/json")
+	}
 	c.Response.WriteHeader(yerr.Code())
 	c.Render(yerr.Body())
 }
-
-// Start initiates a new http yarf server and start listening.
-// It's a shortcut for http.ListenAndServe(address, y)
-func (y *Yarf) Start(address string) {
-	http.ListenAndServe(address, y)
-}
-
-// StartTLS initiates a new http yarf server and starts listening to HTTPS requests.
-// It is a shortcut for http.ListenAndServeTLS(address, cert, key, yarf)
-func (y *Yarf) StartTLS(address, cert, key string) {
-	http.ListenAndServeTLS(address, cert, key, y)
-}
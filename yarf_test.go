@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 type MockResource struct {
@@ -97,3 +98,245 @@ func TestNotFoundResponse(t *testing.T) {
 		t.Error("Non matching route should return 404 response")
 	}
 }
+
+type MissingResource struct {
+	Resource
+}
+
+func (r *MissingResource) Get(c *Context) error {
+	e := ErrorNotFound()
+	e.ErrorBody = "user not found"
+	return e
+}
+
+func TestNotFoundKinds(t *testing.T) {
+	m := newTestMetrics()
+	y := New()
+	y.Metrics = m
+	y.Add("/users/:id", new(MissingResource))
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+url, nil)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+		return res
+	}
+
+	// Without renderers, the resource error body is kept
+	if res := get("/users/1"); res.Code != 404 || res.Body.String() != "user not found" {
+		t.Errorf("Unexpected resource 404 %d %q", res.Code, res.Body.String())
+	}
+
+	y.NotFound = func(c *Context) {
+		c.Status(404)
+		c.Render("not found")
+	}
+	y.RouteNotFound = func(c *Context) {
+		c.Status(404)
+		c.Render("no route")
+	}
+	if res := get("/nope"); res.Body.String() != "no route" {
+		t.Errorf("Expected the RouteNotFound renderer, got %q", res.Body.String())
+	}
+	if res := get("/users/1"); res.Body.String() != "not found" {
+		t.Errorf("Expected the NotFound fallback, got %q", res.Body.String())
+	}
+
+	y.ResourceNotFound = func(c *Context) {
+		c.Status(404)
+		c.Render("no resource")
+	}
+	if res := get("/users/1"); res.Body.String() != "no resource" {
+		t.Errorf("Expected the ResourceNotFound renderer, got %q", res.Body.String())
+	}
+
+	if m.get("yarf_not_found_total{route}") != 1 || m.get("yarf_not_found_total{resource}") != 3 {
+		t.Errorf("Unexpected not found metrics %v", m.values)
+	}
+}
+
+type CountMiddleware struct {
+	Middleware
+
+	pre, post, end int
+}
+
+func (m *CountMiddleware) PreDispatch(c *Context) error {
+	m.pre++
+	return nil
+}
+
+func (m *CountMiddleware) PostDispatch(c *Context) error {
+	m.post++
+	return nil
+}
+
+func (m *CountMiddleware) End(c *Context) error {
+	m.end++
+	return nil
+}
+
+type OKResource struct {
+	Resource
+}
+
+func (r *OKResource) Get(c *Context) error {
+	c.Render("OK")
+	return nil
+}
+
+// testRequest serves a request to y with the headers provided, skipping the empty ones, and returns the response.
+func testRequest(y *Yarf, method, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		if v != "" {
+			req.Header.Set(k, v)
+		}
+	}
+
+	return serveRequest(y, req)
+}
+
+// serveRequest serves req to y and returns the response.
+func serveRequest(y *Yarf, req *http.Request) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	return res
+}
+
+func TestYarfUse(t *testing.T) {
+	m := new(CountMiddleware)
+
+	y := New()
+	y.Use(m)
+	y.Add("/test", new(OKResource))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/test", nil)
+	y.ServeHTTP(httptest.NewRecorder(), req)
+
+	if m.pre != 1 || m.post != 1 || m.end != 1 {
+		t.Errorf("Global middleware should run once on matching routes. Got pre: %d, post: %d, end: %d", m.pre, m.post, m.end)
+	}
+
+	req, _ = http.NewRequest("GET", "http://localhost:8080/route/not/match", nil)
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, req)
+
+	if res.Code != 404 {
+		t.Error("Non matching route should return 404 response")
+	}
+	if m.pre != 2 || m.end != 2 {
+		t.Error("Global middleware should run on non matching routes")
+	}
+	if m.post != 1 {
+		t.Error("Global PostDispatch shouldn't run after errors")
+	}
+}
+
+func TestYarfUseChain(t *testing.T) {
+	y := New()
+	y.Use(new(CountMiddleware))
+	y.Insert(new(MockMiddleware))
+	y.Add("/test", new(OKResource))
+
+	chain := y.Chain()["/test"]
+	if len(chain) != 2 {
+		t.Fatalf("Route should have 2 middleware in chain, found %d", len(chain))
+	}
+	if _, ok := chain[0].Middleware.(*CountMiddleware); !ok {
+		t.Error("Global middleware should run first in the chain")
+	}
+}
+
+type ParamsResource struct {
+	Resource
+}
+
+func (r *ParamsResource) Get(c *Context) error {
+	c.RenderJSON(c.Params)
+
+	// Changes shouldn't leak to other requests
+	c.Params.Set("leak", "true")
+
+	return nil
+}
+
+func TestYarfPoolParams(t *testing.T) {
+	y := New()
+	y.Add("/a/:one", new(ParamsResource))
+	y.Add("/b/:two", new(ParamsResource))
+
+	for _, path := range []string{"/a/1", "/b/2", "/a/1", "/b/2"} {
+		req, _ := http.NewRequest("GET", "http://localhost:8080"+path, nil)
+		res := httptest.NewRecorder()
+		y.ServeHTTP(res, req)
+
+		expected := `{"one":"1"}`
+		if path == "/b/2" {
+			expected = `{"two":"2"}`
+		}
+		if res.Body.String() != expected {
+			t.Errorf("Request to %s should render %s, got %s", path, expected, res.Body.String())
+		}
+	}
+}
+
+func BenchmarkServeHTTP(b *testing.B) {
+	y := New()
+	y.Add("/hello/:name", new(OKResource))
+
+	req, _ := http.NewRequest("GET", "http://localhost:8080/hello/joe", nil)
+	res := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		y.ServeHTTP(res, req)
+	}
+}
+
+// countingRouter is a GroupRouter implementation outside the package types, counting the routes added.
+type countingRouter struct {
+	group  *GroupRoute
+	routes int
+}
+
+func (r *countingRouter) Match(url string, c *Context) bool { return r.group.Match(url, c) }
+func (r *countingRouter) Dispatch(c *Context) error         { return r.group.Dispatch(c) }
+func (r *countingRouter) AddGroup(g *GroupRoute)            { r.group.AddGroup(g) }
+func (r *countingRouter) Insert(m MiddlewareHandler)        { r.group.Insert(m) }
+
+func (r *countingRouter) Add(url string, h ResourceHandler) {
+	r.routes++
+	r.group.Add(url, h)
+}
+
+// The built-in routers keep implementing GroupRouter.
+var (
+	_ GroupRouter = RouteGroup("")
+	_ GroupRouter = New()
+)
+
+func TestYarfCustomGroupRouter(t *testing.T) {
+	router := &countingRouter{group: RouteGroup("")}
+
+	y := New()
+	y.GroupRouter = router
+	if meta := y.AddRoute("/", new(OKResource)); meta != nil {
+		t.Errorf("Expected no metadata with a custom router, got %v", meta)
+	}
+	y.AddRoute("/meta", new(OKResource)).Name("meta").Header("X-Test", "1").Scopes("read").Permissions("read").
+		Cacheable(time.Minute).CacheVary("Accept").RequireHeader("X-Version", "").RequireFlag("beta").
+		ContentType("application/json").Push("/app.js").CORS(CORSOverride{}).SLO(SLO{}).ContentDigest(DigestPolicy{})
+	y.InsertPhase(PhaseSecurity, new(Middleware))
+	y.Header("X-Test", "1")
+
+	res := httptest.NewRecorder()
+	y.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	if res.Body.String() != "OK" || router.routes != 2 || len(router.group.middleware) != 1 {
+		t.Errorf("Expected the route added through the custom router, got %q %d", res.Body.String(), router.routes)
+	}
+	if res.Header().Get("X-Test") != "" {
+		t.Error("Headers shouldn't be declared on a custom router")
+	}
+}
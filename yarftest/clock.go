@@ -0,0 +1,48 @@
+package yarftest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a fake yarf.Clock, moved forward by the tests instead of sleeping.
+// It's safe for concurrent use.
+//
+//	clock := yarftest.NewClock(time.Now())
+//	cache := yarf.NewMemoryCache(100, time.Minute)
+//	cache.Clock = clock
+//	cache.Set("key", "value")
+//	clock.Advance(2 * time.Minute) // The value expired
+type Clock struct {
+	now  time.Time
+	lock sync.Mutex
+}
+
+// NewClock creates a Clock stopped at t.
+func NewClock(t time.Time) *Clock {
+	return &Clock{now: t}
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to t.
+func (c *Clock) Set(t time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = t
+}
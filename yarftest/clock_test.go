@@ -0,0 +1,46 @@
+package yarftest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yarf-framework/yarf"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+
+	var _ yarf.Clock = clock
+
+	if !clock.Now().Equal(start) {
+		t.Errorf("Expected %s, got %s", start, clock.Now())
+	}
+
+	clock.Advance(time.Hour)
+	if !clock.Now().Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected the clock advanced by an hour, got %s", clock.Now())
+	}
+
+	clock.Set(start)
+	if !clock.Now().Equal(start) {
+		t.Errorf("Expected the clock set back, got %s", clock.Now())
+	}
+}
+
+func TestClockExpiresCache(t *testing.T) {
+	clock := NewClock(time.Now())
+	cache := yarf.NewMemoryCache(10, time.Minute)
+	cache.Clock = clock
+
+	cache.Set("key", "value")
+	clock.Advance(30 * time.Second)
+	if _, ok := cache.Get("key"); !ok {
+		t.Error("Expected the value within its TTL")
+	}
+
+	clock.Advance(time.Minute)
+	if _, ok := cache.Get("key"); ok {
+		t.Error("Expected the value expired")
+	}
+}
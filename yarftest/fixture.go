@@ -0,0 +1,350 @@
+package yarftest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Redacted replaces the sanitized header values on fixtures.
+const Redacted = "REDACTED"
+
+// DefaultRedactHeaders are the headers sanitized by default on recorded fixtures.
+var DefaultRedactHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization", "X-Api-Key"}
+
+// Fixture is a recorded request and response pair.
+type Fixture struct {
+	Request  FixtureRequest  `json:"request"`
+	Response FixtureResponse `json:"response"`
+}
+
+// FixtureRequest is the recorded request.
+type FixtureRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// FixtureResponse is the recorded response.
+type FixtureResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// FixtureRecorder is a middleware saving the requests and responses it serves as fixture files,
+// to be replayed by the Replayer in tests. Use it as global middleware so error responses are recorded too:
+//
+//	if *record {
+//		y.Use(yarftest.NewFixtureRecorder("testdata/fixtures"))
+//	}
+type FixtureRecorder struct {
+	yarf.Middleware
+
+	// Dir where the fixture files are written.
+	Dir string
+
+	// RedactHeaders are replaced by the Redacted value on the fixtures.
+	RedactHeaders []string
+
+	// Sanitize optionally changes the fixture before it's saved, to remove personal data or secrets from bodies.
+	Sanitize func(*Fixture)
+
+	// MaxBody limits the recorded size of request and response bodies.
+	MaxBody int64
+
+	seq uint64
+}
+
+// NewFixtureRecorder creates a FixtureRecorder writing into dir, redacting the DefaultRedactHeaders
+// and recording up to 1MB of each body.
+func NewFixtureRecorder(dir string) *FixtureRecorder {
+	return &FixtureRecorder{
+		Dir:           dir,
+		RedactHeaders: DefaultRedactHeaders,
+		MaxBody:       1 << 20,
+	}
+}
+
+// teeResponse copies the response into a fixture while it's written.
+type teeResponse struct {
+	http.ResponseWriter
+
+	f   *Fixture
+	max int64
+	buf bytes.Buffer
+}
+
+// WriteHeader records the final status code and sends every code.
+func (w *teeResponse) WriteHeader(code int) {
+	if w.f.Response.Status == 0 && (code >= 200 || code == http.StatusSwitchingProtocols) {
+		w.f.Response.Status = code
+		w.f.Response.Header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records and sends the data.
+func (w *teeResponse) Write(data []byte) (int, error) {
+	if w.f.Response.Status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if room := w.max - int64(w.buf.Len()); room > 0 {
+		if int64(len(data)) < room {
+			room = int64(len(data))
+		}
+		w.buf.Write(data[:room])
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+// Unwrap returns the original http.ResponseWriter.
+func (w *teeResponse) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// PreDispatch starts recording the request.
+func (r *FixtureRecorder) PreDispatch(c *yarf.Context) error {
+	f := &Fixture{
+		Request: FixtureRequest{
+			Method: c.Request.Method,
+			URL:    c.Request.URL.RequestURI(),
+			Header: c.Request.Header.Clone(),
+		},
+	}
+
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, r.MaxBody))
+		if err != nil {
+			return err
+		}
+		f.Request.Body = string(body)
+		c.Request.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+	}
+
+	tee := &teeResponse{ResponseWriter: c.Response, f: f, max: r.MaxBody}
+	c.Response = tee
+
+	return nil
+}
+
+// End saves the fixture.
+func (r *FixtureRecorder) End(c *yarf.Context) error {
+	tee, ok := c.Response.(*teeResponse)
+	if !ok {
+		return nil
+	}
+	c.Response = tee.ResponseWriter
+
+	f := tee.f
+	f.Response.Body = tee.buf.String()
+	if f.Response.Status == 0 {
+		f.Response.Status = http.StatusOK
+		f.Response.Header = tee.Header().Clone()
+	}
+
+	redact(f.Request.Header, r.RedactHeaders)
+	redact(f.Response.Header, r.RedactHeaders)
+	if r.Sanitize != nil {
+		r.Sanitize(f)
+	}
+
+	n := atomic.AddUint64(&r.seq, 1)
+	name := fmt.Sprintf("%04d-%s-%s.json", n, strings.ToLower(f.Request.Method), slug(c.Request.URL.Path))
+
+	return writeFixture(filepath.Join(r.Dir, name), f)
+}
+
+// redact replaces the values of the headers provided.
+func redact(h http.Header, headers []string) {
+	for _, k := range headers {
+		if _, ok := h[http.CanonicalHeaderKey(k)]; ok {
+			h.Set(k, Redacted)
+		}
+	}
+}
+
+var slugChars = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// slug converts a path into a file name part.
+func slug(path string) string {
+	s := strings.Trim(slugChars.ReplaceAllString(path, "-"), "-")
+	if s == "" {
+		s = "root"
+	}
+	if len(s) > 60 {
+		s = s[:60]
+	}
+
+	return s
+}
+
+// writeFixture saves a fixture as indented JSON.
+func writeFixture(path string, f *Fixture) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadFixture reads a fixture file.
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := new(Fixture)
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Replayer feeds recorded fixtures through a handler and compares the responses with the recorded ones,
+// as golden-file regression tests.
+type Replayer struct {
+	// Dir with the fixture files.
+	Dir string
+
+	// IgnoreHeaders aren't compared. Date and redacted headers are never compared.
+	IgnoreHeaders []string
+
+	// Prepare optionally changes each request before it's sent, to set real credentials for redacted headers.
+	Prepare func(*http.Request)
+
+	// Update rewrites the fixtures with the current responses instead of comparing them.
+	Update bool
+}
+
+// Replay runs the fixtures in dir against the handler, as subtests.
+func Replay(t *testing.T, h http.Handler, dir string) {
+	t.Helper()
+
+	(&Replayer{Dir: dir}).Run(t, h)
+}
+
+// Run replays every fixture in the directory as a subtest named after the file.
+func (r *Replayer) Run(t *testing.T, h http.Handler) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(r.Dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("yarftest: no fixtures found in %s", r.Dir)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".json"), func(t *testing.T) {
+			f, err := LoadFixture(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res := r.replay(t, h, f)
+
+			if r.Update {
+				f.Response = res
+				if err := writeFixture(file, f); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			r.compare(t, f.Response, res)
+		})
+	}
+}
+
+// replay sends the fixture request through the handler.
+func (r *Replayer) replay(t *testing.T, h http.Handler, f *Fixture) FixtureResponse {
+	req := NewRequest(t, f.Request.Method, f.Request.URL)
+	for k, v := range f.Request.Header {
+		for _, value := range v {
+			if value != Redacted {
+				req.req.Header.Add(k, value)
+			}
+		}
+	}
+	if f.Request.Body != "" {
+		req.Body(f.Request.Body)
+	}
+	if r.Prepare != nil {
+		r.Prepare(req.req)
+	}
+
+	rec := req.Serve(h).Recorder
+
+	return FixtureResponse{
+		Status: rec.Code,
+		Header: rec.Header().Clone(),
+		Body:   rec.Body.String(),
+	}
+}
+
+// compare checks the replayed response against the recorded one.
+func (r *Replayer) compare(t testing.TB, want, got FixtureResponse) {
+	t.Helper()
+
+	if want.Status != got.Status {
+		t.Errorf("Expected status %d, got %d", want.Status, got.Status)
+	}
+
+	ignore := map[string]bool{"Date": true}
+	for _, k := range r.IgnoreHeaders {
+		ignore[http.CanonicalHeaderKey(k)] = true
+	}
+	for k, v := range want.Header {
+		if ignore[k] || (len(v) == 1 && v[0] == Redacted) {
+			continue
+		}
+		if g := got.Header.Values(k); strings.Join(g, ", ") != strings.Join(v, ", ") {
+			t.Errorf("Expected header %s '%s', got '%s'", k, strings.Join(v, ", "), strings.Join(g, ", "))
+		}
+	}
+
+	if !sameBody(want.Body, got.Body) {
+		t.Errorf("Expected body '%s', got '%s'", want.Body, got.Body)
+	}
+}
+
+// sameBody compares bodies, as JSON values if both are valid JSON.
+func sameBody(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	var ja, jb interface{}
+	if json.Unmarshal([]byte(a), &ja) != nil || json.Unmarshal([]byte(b), &jb) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(ja, jb)
+}
@@ -0,0 +1,90 @@
+package yarftest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+)
+
+type Echo struct {
+	yarf.Resource
+}
+
+func (r *Echo) Post(c *yarf.Context) error {
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.Render(`{"user": "` + c.FormValue("user") + `", "token": "t0k3n"}`)
+	return nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	rec := NewFixtureRecorder(dir)
+	rec.Sanitize = func(f *Fixture) {
+		f.Response.Body = strings.ReplaceAll(f.Response.Body, "t0k3n", Redacted)
+	}
+
+	y := yarf.New()
+	y.Use(rec)
+	y.Add("/echo", new(Echo))
+
+	NewRequest(t, "POST", "/echo").Form(map[string]string{"user": "joe"}).Bearer("secret").Serve(y).ExpectStatus(200)
+	NewRequest(t, "GET", "/missing").Serve(y).ExpectStatus(404)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 fixtures, got %v", files)
+	}
+
+	f, err := LoadFixture(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Request.Body != "user=joe" || f.Request.Header.Get("Authorization") != Redacted {
+		t.Errorf("Unexpected recorded request: %+v", f.Request)
+	}
+	if f.Response.Status != 200 || strings.Contains(f.Response.Body, "t0k3n") {
+		t.Errorf("Unexpected recorded response: %+v", f.Response)
+	}
+
+	// Replay against an app without the recorder, updating the sanitized token
+	app := yarf.New()
+	app.Add("/echo", new(Echo))
+
+	r := &Replayer{Dir: dir}
+	r.Update = true
+	r.Run(t, app)
+
+	Replay(t, app, dir)
+
+	data, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(data), "t0k3n") {
+		t.Error("Update should rewrite the fixtures with the current responses")
+	}
+
+	// Regressions are detected
+	f.Response.Status = 201
+	got := r.replay(t, app, f)
+
+	ft := &failTB{TB: t}
+	r.compare(ft, f.Response, got)
+	if !ft.failed {
+		t.Error("Replay should fail when the response changes")
+	}
+
+	if !sameBody(`{"a": 1, "b": [1, 2]}`, `{"b":[1,2],"a":1}`) || sameBody("a", "b") {
+		t.Error("Bodies should be compared as JSON values")
+	}
+}
+
+type failTB struct {
+	testing.TB
+	failed bool
+}
+
+func (t *failTB) Errorf(format string, args ...interface{}) {
+	t.failed = true
+}
@@ -0,0 +1,137 @@
+package yarftest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Response holds the result of a test request, with fluent assertions.
+// Failed assertions mark the test as failed and the chain continues.
+type Response struct {
+	t testing.TB
+
+	// Recorder with the response written.
+	Recorder *httptest.ResponseRecorder
+
+	// Err returned by the resource, for requests dispatched with Request.Call.
+	Err error
+}
+
+// newResponse creates a Response.
+func newResponse(t testing.TB, rec *httptest.ResponseRecorder, err error) *Response {
+	return &Response{t: t, Recorder: rec, Err: err}
+}
+
+// Status returns the response status code.
+// For resources called directly that return a yarf.YError, it's the error code.
+func (r *Response) Status() int {
+	if yerr, ok := r.Err.(yarf.YError); ok {
+		return yerr.Code()
+	}
+	if r.Err != nil {
+		return http.StatusInternalServerError
+	}
+
+	return r.Recorder.Code
+}
+
+// Body returns the response body.
+func (r *Response) Body() string {
+	return r.Recorder.Body.String()
+}
+
+// DecodeJSON decodes the JSON response body into v.
+func (r *Response) DecodeJSON(v interface{}) *Response {
+	r.t.Helper()
+
+	if err := json.Unmarshal(r.Recorder.Body.Bytes(), v); err != nil {
+		r.t.Fatalf("yarftest: decoding JSON body '%s': %s", r.Body(), err)
+	}
+
+	return r
+}
+
+// ExpectStatus checks the response status code.
+func (r *Response) ExpectStatus(code int) *Response {
+	r.t.Helper()
+
+	if s := r.Status(); s != code {
+		r.t.Errorf("Expected status %d, got %d (error: %v, body: %.200s)", code, s, r.Err, r.Body())
+	}
+
+	return r
+}
+
+// ExpectNoError checks that the resource didn't return an error.
+func (r *Response) ExpectNoError() *Response {
+	r.t.Helper()
+
+	if r.Err != nil {
+		r.t.Errorf("Expected no error, got: %s", r.Err)
+	}
+
+	return r
+}
+
+// ExpectHeader checks a response header value.
+func (r *Response) ExpectHeader(key, value string) *Response {
+	r.t.Helper()
+
+	if v := r.Recorder.Header().Get(key); v != value {
+		r.t.Errorf("Expected header %s '%s', got '%s'", key, value, v)
+	}
+
+	return r
+}
+
+// ExpectBody checks the response body.
+func (r *Response) ExpectBody(body string) *Response {
+	r.t.Helper()
+
+	if b := r.Body(); b != body {
+		r.t.Errorf("Expected body '%s', got '%s'", body, b)
+	}
+
+	return r
+}
+
+// ExpectBodyContains checks that the response body contains s.
+func (r *Response) ExpectBodyContains(s string) *Response {
+	r.t.Helper()
+
+	if !strings.Contains(r.Body(), s) {
+		r.t.Errorf("Expected body to contain '%s', got '%s'", s, r.Body())
+	}
+
+	return r
+}
+
+// ExpectJSON checks that the JSON response body is equal to the JSON encoding of expected,
+// regardless of the key order and formatting.
+func (r *Response) ExpectJSON(expected interface{}) *Response {
+	r.t.Helper()
+
+	data, err := json.Marshal(expected)
+	if err != nil {
+		r.t.Fatalf("yarftest: encoding expected JSON: %s", err)
+	}
+
+	var want, got interface{}
+	json.Unmarshal(data, &want)
+	if err := json.Unmarshal(r.Recorder.Body.Bytes(), &got); err != nil {
+		r.t.Errorf("Expected JSON body %s, got '%s'", data, r.Body())
+		return r
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		r.t.Errorf("Expected JSON body %s, got %s", data, r.Body())
+	}
+
+	return r
+}
@@ -0,0 +1,159 @@
+// Package yarftest provides utilities to test Yarf resources, middleware and whole applications.
+//
+//	func TestGetUser(t *testing.T) {
+//		res := yarftest.NewRequest(t, "GET", "/users/42").Pattern("/users/:id").Bearer("token").Call(new(User))
+//		res.ExpectStatus(200).ExpectJSON(map[string]interface{}{"id": 42, "name": "Joe"})
+//	}
+package yarftest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+)
+
+// Request builds a test request.
+type Request struct {
+	t       testing.TB
+	req     *http.Request
+	pattern string
+	params  yarf.Params
+}
+
+// NewRequest creates a test Request for the method and path provided.
+// The path can include a query string.
+func NewRequest(t testing.TB, method, path string) *Request {
+	return &Request{
+		t:      t,
+		req:    httptest.NewRequest(method, path, nil),
+		params: yarf.Params{},
+	}
+}
+
+// Header sets a request header.
+func (r *Request) Header(key, value string) *Request {
+	r.req.Header.Set(key, value)
+	return r
+}
+
+// Body sets the request body.
+func (r *Request) Body(body string) *Request {
+	r.req.Body = io.NopCloser(strings.NewReader(body))
+	r.req.ContentLength = int64(len(body))
+	return r
+}
+
+// JSON sets the request body to the JSON encoding of v, with the JSON content type.
+func (r *Request) JSON(v interface{}) *Request {
+	r.t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		r.t.Fatalf("yarftest: encoding JSON body: %s", err)
+	}
+
+	r.req.Body = io.NopCloser(bytes.NewReader(data))
+	r.req.ContentLength = int64(len(data))
+	r.req.Header.Set("Content-Type", "application/json")
+
+	return r
+}
+
+// Form sets the request body to the encoded form values, with the form content type.
+func (r *Request) Form(values map[string]string) *Request {
+	form := url.Values{}
+	for k, v := range values {
+		form.Set(k, v)
+	}
+
+	r.Body(form.Encode())
+	r.req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return r
+}
+
+// Bearer sets a bearer token Authorization header.
+func (r *Request) Bearer(token string) *Request {
+	return r.Header("Authorization", "Bearer "+token)
+}
+
+// BasicAuth sets a basic authentication Authorization header.
+func (r *Request) BasicAuth(user, password string) *Request {
+	r.req.SetBasicAuth(user, password)
+	return r
+}
+
+// Cookie adds a cookie to the request.
+func (r *Request) Cookie(c *http.Cookie) *Request {
+	r.req.AddCookie(c)
+	return r
+}
+
+// RemoteAddr sets the client address.
+func (r *Request) RemoteAddr(addr string) *Request {
+	r.req.RemoteAddr = addr
+	return r
+}
+
+// Param sets a route param for handlers called directly.
+func (r *Request) Param(key, value string) *Request {
+	r.params.Set(key, value)
+	return r
+}
+
+// Pattern sets the route pattern matched against the request path to fill the params
+// for handlers called directly, like "/users/:id".
+func (r *Request) Pattern(pattern string) *Request {
+	r.pattern = pattern
+	return r
+}
+
+// HTTPRequest returns the built *http.Request.
+func (r *Request) HTTPRequest() *http.Request {
+	return r.req
+}
+
+// Context creates a Context for the request, with a *httptest.ResponseRecorder as response,
+// to unit test middleware or functions using it.
+func (r *Request) Context() *yarf.Context {
+	r.t.Helper()
+
+	c := yarf.NewContext(r.req, httptest.NewRecorder())
+
+	if r.pattern != "" && !yarf.Route(r.pattern, new(yarf.Resource)).Match(r.req.URL.Path, c) {
+		r.t.Fatalf("yarftest: path %s doesn't match pattern %s", r.req.URL.Path, r.pattern)
+	}
+	for k, v := range r.params {
+		c.Params.Set(k, v)
+	}
+
+	return c
+}
+
+// Call dispatches the request to the method of the resource provided, without middleware.
+// The error returned by the resource is kept in the Response, and isn't rendered.
+func (r *Request) Call(h yarf.ResourceHandler) *Response {
+	r.t.Helper()
+
+	c := r.Context()
+	err := yarf.Route("/", h).Dispatch(c)
+
+	return newResponse(r.t, c.Response.(*httptest.ResponseRecorder), err)
+}
+
+// Serve dispatches the request through a handler, usually the whole *yarf.Yarf app, with its middleware.
+func (r *Request) Serve(h http.Handler) *Response {
+	r.t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r.req)
+
+	return newResponse(r.t, rec, nil)
+}
@@ -0,0 +1,93 @@
+package yarftest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/yarf-framework/yarf"
+)
+
+type User struct {
+	yarf.Resource
+}
+
+func (r *User) Get(c *yarf.Context) error {
+	if c.Param("id") != "42" {
+		return yarf.ErrorNotFound()
+	}
+
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.Render(`{"name": "Joe", "id": 42}`)
+	return nil
+}
+
+func (r *User) Put(c *yarf.Context) error {
+	user, pass, ok := c.Request.BasicAuth()
+	if !ok || user != "admin" || pass != "secret" {
+		return &yarf.CustomError{HTTPCode: 401, ErrorMsg: "Unauthorized"}
+	}
+
+	c.Response.WriteHeader(204)
+	return nil
+}
+
+type Auth struct {
+	yarf.Middleware
+}
+
+func (m *Auth) PreDispatch(c *yarf.Context) error {
+	if c.Request.Header.Get("Authorization") != "Bearer token" {
+		return &yarf.CustomError{HTTPCode: 401, ErrorMsg: "Unauthorized", ErrorBody: "Unauthorized"}
+	}
+
+	return nil
+}
+
+func TestCall(t *testing.T) {
+	NewRequest(t, "GET", "/users/42").Pattern("/users/:id").Call(new(User)).
+		ExpectNoError().
+		ExpectStatus(200).
+		ExpectHeader("Content-Type", "application/json").
+		ExpectJSON(map[string]interface{}{"id": 42, "name": "Joe"})
+
+	NewRequest(t, "GET", "/users/1").Param("id", "1").Call(new(User)).ExpectStatus(404)
+
+	NewRequest(t, "PUT", "/users/42").BasicAuth("admin", "secret").Call(new(User)).ExpectStatus(204)
+	NewRequest(t, "PUT", "/users/42").Call(new(User)).ExpectStatus(401)
+	NewRequest(t, "DELETE", "/users/42").Call(new(User)).ExpectStatus(405)
+}
+
+func TestServe(t *testing.T) {
+	y := yarf.New()
+	y.Insert(new(Auth))
+	y.Add("/users/:id", new(User))
+
+	var user struct {
+		Name string `json:"name"`
+	}
+	NewRequest(t, "GET", "/users/42").Bearer("token").Serve(y).ExpectStatus(200).DecodeJSON(&user)
+	if user.Name != "Joe" {
+		t.Errorf("Expected user Joe, got %s", user.Name)
+	}
+
+	NewRequest(t, "GET", "/users/42").Serve(y).ExpectStatus(401).ExpectBody("Unauthorized")
+}
+
+func TestContext(t *testing.T) {
+	c := NewRequest(t, "POST", "/login?next=/home").
+		Form(map[string]string{"user": "joe"}).
+		Cookie(&http.Cookie{Name: "session", Value: "abc"}).
+		RemoteAddr("10.0.0.1:1234").
+		Context()
+
+	if c.FormValue("user") != "joe" || c.QueryValue("next") != "/home" || c.GetClientIP() != "10.0.0.1" {
+		t.Errorf("Unexpected context values")
+	}
+	if cookie, err := c.Request.Cookie("session"); err != nil || cookie.Value != "abc" {
+		t.Error("Expected session cookie")
+	}
+
+	if err := new(Auth).PreDispatch(c); err == nil {
+		t.Error("Auth middleware should fail without token")
+	}
+}